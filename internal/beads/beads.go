@@ -77,6 +77,11 @@ type ListOptions struct {
 	Parent     string // filter by parent ID
 	Assignee   string // filter by assignee (e.g., "gastown/Toast")
 	NoAssignee bool   // filter for issues with no assignee
+
+	Labels        []string // Additional labels that must all match
+	IssueType     string   // bd issue type filter (e.g., "message"); unlike Type, not a gt: label
+	NoLimit       bool     // Return every match instead of bd's default page
+	SortByCreated bool     // Oldest first
 }
 
 // CreateOptions specifies options for creating an issue.
@@ -100,6 +105,7 @@ type UpdateOptions struct {
 	AddLabels    []string // Labels to add
 	RemoveLabels []string // Labels to remove
 	SetLabels    []string // Labels to set (replaces all existing)
+	Notes        *string  // Notes (replaces existing notes)
 }
 
 // SyncStatus represents the sync status of the beads repository.
@@ -120,6 +126,9 @@ type Beads struct {
 	// Populated on first call to getTownRoot() to avoid filesystem walk on every operation.
 	townRoot     string
 	searchedRoot bool
+
+	// st is the storage backend, chosen on first use (see store).
+	st Store
 }
 
 // New creates a new Beads wrapper for the given directory.
@@ -140,6 +149,12 @@ func NewWithBeadsDir(workDir, beadsDir string) *Beads {
 	return &Beads{workDir: workDir, beadsDir: beadsDir}
 }
 
+// NewWithStore creates a Beads wrapper that uses the given storage backend
+// instead of the one configured for the town.
+func NewWithStore(workDir string, s Store) *Beads {
+	return &Beads{workDir: workDir, st: s}
+}
+
 // getActor returns the BD_ACTOR value for this context.
 // Returns empty string when in isolated mode (tests) to prevent
 // inherited actors from routing to production databases.
@@ -289,42 +304,7 @@ func filterBeadsEnv(environ []string) []string {
 
 // List returns issues matching the given options.
 func (b *Beads) List(opts ListOptions) ([]*Issue, error) {
-	args := []string{"list", "--json"}
-
-	if opts.Status != "" {
-		args = append(args, "--status="+opts.Status)
-	}
-	// Prefer Label over Type (Type is deprecated)
-	if opts.Label != "" {
-		args = append(args, "--label="+opts.Label)
-	} else if opts.Type != "" {
-		// Deprecated: convert type to label for backward compatibility
-		args = append(args, "--label=gt:"+opts.Type)
-	}
-	if opts.Priority >= 0 {
-		args = append(args, fmt.Sprintf("--priority=%d", opts.Priority))
-	}
-	if opts.Parent != "" {
-		args = append(args, "--parent="+opts.Parent)
-	}
-	if opts.Assignee != "" {
-		args = append(args, "--assignee="+opts.Assignee)
-	}
-	if opts.NoAssignee {
-		args = append(args, "--no-assignee")
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd list output: %w", err)
-	}
-
-	return issues, nil
+	return b.store().List(opts)
 }
 
 // ListByAssignee returns all issues assigned to a specific assignee.
@@ -382,76 +362,40 @@ func (b *Beads) GetAssignedIssue(assignee string) (*Issue, error) {
 
 // Ready returns issues that are ready to work (not blocked).
 func (b *Beads) Ready() ([]*Issue, error) {
-	out, err := b.run("ready", "--json")
-	if err != nil {
-		return nil, err
-	}
-
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd ready output: %w", err)
-	}
-
-	return issues, nil
+	return b.store().Ready(ReadyOptions{})
 }
 
 // ReadyWithType returns ready issues filtered by label.
-// Uses bd ready --label flag for server-side filtering.
 // The issueType is converted to a gt:<type> label (e.g., "molecule" -> "gt:molecule").
 func (b *Beads) ReadyWithType(issueType string) ([]*Issue, error) {
-	out, err := b.run("ready", "--json", "--label", "gt:"+issueType, "-n", "100")
-	if err != nil {
-		return nil, err
-	}
-
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd ready output: %w", err)
-	}
-
-	return issues, nil
+	return b.store().Ready(ReadyOptions{Label: "gt:" + issueType, Limit: 100})
 }
 
 // Show returns detailed information about an issue.
 func (b *Beads) Show(id string) (*Issue, error) {
-	out, err := b.run("show", id, "--json")
+	issues, err := b.store().Show(id)
 	if err != nil {
 		return nil, err
 	}
-
-	// bd show --json returns an array with one element
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd show output: %w", err)
-	}
-
 	if len(issues) == 0 {
 		return nil, ErrNotFound
 	}
-
 	return issues[0], nil
 }
 
-// ShowMultiple fetches multiple issues by ID in a single bd call.
+// ShowMultiple fetches multiple issues by ID in a single call.
 // Returns a map of ID to Issue. Missing IDs are not included in the map.
 func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 	if len(ids) == 0 {
 		return make(map[string]*Issue), nil
 	}
 
-	// bd show supports multiple IDs
-	args := append([]string{"show", "--json"}, ids...)
-	out, err := b.run(args...)
+	issues, err := b.store().Show(ids...)
 	if err != nil {
-		// If bd fails, return empty map (some IDs might not exist)
+		// If the lookup fails, return empty map (some IDs might not exist)
 		return make(map[string]*Issue), nil
 	}
 
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd show output: %w", err)
-	}
-
 	result := make(map[string]*Issue, len(issues))
 	for _, issue := range issues {
 		result[issue.ID] = issue
@@ -462,189 +406,70 @@ func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 
 // Blocked returns issues that are blocked by dependencies.
 func (b *Beads) Blocked() ([]*Issue, error) {
-	out, err := b.run("blocked", "--json")
-	if err != nil {
-		return nil, err
-	}
-
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd blocked output: %w", err)
-	}
-
-	return issues, nil
+	return b.store().Blocked()
 }
 
 // Create creates a new issue and returns it.
 // If opts.Actor is empty, it defaults to the BD_ACTOR environment variable.
 // This ensures created_by is populated for issue provenance tracking.
 func (b *Beads) Create(opts CreateOptions) (*Issue, error) {
-	args := []string{"create", "--json"}
-
-	if opts.Title != "" {
-		args = append(args, "--title="+opts.Title)
-	}
-	// Type is deprecated: convert to gt:<type> label
-	if opts.Type != "" {
-		args = append(args, "--labels=gt:"+opts.Type)
-	}
-	if opts.Priority >= 0 {
-		args = append(args, fmt.Sprintf("--priority=%d", opts.Priority))
-	}
-	if opts.Description != "" {
-		args = append(args, "--description="+opts.Description)
-	}
-	if opts.Parent != "" {
-		args = append(args, "--parent="+opts.Parent)
-	}
-	if opts.Ephemeral {
-		args = append(args, "--ephemeral")
-	}
-	// Default Actor from BD_ACTOR env var if not specified
-	// Uses getActor() to respect isolated mode (tests)
-	actor := opts.Actor
-	if actor == "" {
-		actor = b.getActor()
-	}
-	if actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+	return b.store().Create(b.newIssue("", opts))
 }
 
 // CreateWithID creates an issue with a specific ID.
 // This is useful for agent beads, role beads, and other beads that need
 // deterministic IDs rather than auto-generated ones.
 func (b *Beads) CreateWithID(id string, opts CreateOptions) (*Issue, error) {
-	args := []string{"create", "--json", "--id=" + id}
-	if NeedsForceForID(id) {
-		args = append(args, "--force")
-	}
-
-	if opts.Title != "" {
-		args = append(args, "--title="+opts.Title)
+	issue := b.newIssue(id, opts)
+	issue.Force = NeedsForceForID(id)
+	// Ephemeral is only honoured for generated IDs
+	issue.Ephemeral = false
+	return b.store().Create(issue)
+}
+
+// newIssue converts CreateOptions to a NewIssue.
+func (b *Beads) newIssue(id string, opts CreateOptions) NewIssue {
+	issue := NewIssue{
+		ID:          id,
+		Title:       opts.Title,
+		Description: opts.Description,
+		Priority:    opts.Priority,
+		Parent:      opts.Parent,
+		Actor:       opts.Actor,
+		Ephemeral:   opts.Ephemeral,
 	}
 	// Type is deprecated: convert to gt:<type> label
 	if opts.Type != "" {
-		args = append(args, "--labels=gt:"+opts.Type)
-	}
-	if opts.Priority >= 0 {
-		args = append(args, fmt.Sprintf("--priority=%d", opts.Priority))
-	}
-	if opts.Description != "" {
-		args = append(args, "--description="+opts.Description)
-	}
-	if opts.Parent != "" {
-		args = append(args, "--parent="+opts.Parent)
+		issue.Labels = []string{"gt:" + opts.Type}
 	}
 	// Default Actor from BD_ACTOR env var if not specified
 	// Uses getActor() to respect isolated mode (tests)
-	actor := opts.Actor
-	if actor == "" {
-		actor = b.getActor()
-	}
-	if actor != "" {
-		args = append(args, "--actor="+actor)
+	if issue.Actor == "" {
+		issue.Actor = b.getActor()
 	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+	return issue
 }
 
 // Update updates an existing issue.
 func (b *Beads) Update(id string, opts UpdateOptions) error {
-	args := []string{"update", id}
-
-	if opts.Title != nil {
-		args = append(args, "--title="+*opts.Title)
-	}
-	if opts.Status != nil {
-		args = append(args, "--status="+*opts.Status)
-	}
-	if opts.Priority != nil {
-		args = append(args, fmt.Sprintf("--priority=%d", *opts.Priority))
-	}
-	if opts.Description != nil {
-		args = append(args, "--description="+*opts.Description)
-	}
-	if opts.Assignee != nil {
-		args = append(args, "--assignee="+*opts.Assignee)
-	}
-	// Label operations: set-labels replaces all, otherwise use add/remove
-	if len(opts.SetLabels) > 0 {
-		for _, label := range opts.SetLabels {
-			args = append(args, "--set-labels="+label)
-		}
-	} else {
-		for _, label := range opts.AddLabels {
-			args = append(args, "--add-label="+label)
-		}
-		for _, label := range opts.RemoveLabels {
-			args = append(args, "--remove-label="+label)
-		}
-	}
-
-	_, err := b.run(args...)
-	return err
+	return b.store().Update(id, opts)
 }
 
 // Close closes one or more issues.
-// If a runtime session ID is set in the environment, it is passed to bd close
+// If a runtime session ID is set in the environment, it is recorded
 // for work attribution tracking (see decision 009-session-events-architecture.md).
 func (b *Beads) Close(ids ...string) error {
-	if len(ids) == 0 {
-		return nil
-	}
-
-	args := append([]string{"close"}, ids...)
-
-	// Pass session ID for work attribution if available
-	if sessionID := runtime.SessionIDFromEnv(); sessionID != "" {
-		args = append(args, "--session="+sessionID)
-	}
-
-	_, err := b.run(args...)
-	return err
+	return b.CloseWithReason("", ids...)
 }
 
 // CloseWithReason closes one or more issues with a reason.
-// If a runtime session ID is set in the environment, it is passed to bd close
+// If a runtime session ID is set in the environment, it is recorded
 // for work attribution tracking (see decision 009-session-events-architecture.md).
 func (b *Beads) CloseWithReason(reason string, ids ...string) error {
 	if len(ids) == 0 {
 		return nil
 	}
-
-	args := append([]string{"close"}, ids...)
-	args = append(args, "--reason="+reason)
-
-	// Pass session ID for work attribution if available
-	if sessionID := runtime.SessionIDFromEnv(); sessionID != "" {
-		args = append(args, "--session="+sessionID)
-	}
-
-	_, err := b.run(args...)
-	return err
+	return b.store().Close(CloseOptions{Reason: reason, Session: runtime.SessionIDFromEnv()}, ids...)
 }
 
 // ForceCloseWithReason closes one or more issues with --force, bypassing
@@ -654,17 +479,12 @@ func (b *Beads) ForceCloseWithReason(reason string, ids ...string) error {
 	if len(ids) == 0 {
 		return nil
 	}
+	return b.store().Close(CloseOptions{Reason: reason, Force: true, Session: runtime.SessionIDFromEnv()}, ids...)
+}
 
-	args := append([]string{"close"}, ids...)
-	args = append(args, "--reason="+reason, "--force")
-
-	// Pass session ID for work attribution if available
-	if sessionID := runtime.SessionIDFromEnv(); sessionID != "" {
-		args = append(args, "--session="+sessionID)
-	}
-
-	_, err := b.run(args...)
-	return err
+// Reopen reopens a closed issue.
+func (b *Beads) Reopen(id, reason string) error {
+	return b.store().Reopen(id, reason)
 }
 
 // Release moves an in_progress issue back to open status.
@@ -677,27 +497,26 @@ func (b *Beads) Release(id string) error {
 // ReleaseWithReason moves an in_progress issue back to open status with a reason.
 // The reason is added as a note to the issue for tracking purposes.
 func (b *Beads) ReleaseWithReason(id, reason string) error {
-	args := []string{"update", id, "--status=open", "--assignee="}
+	status, assignee := "open", ""
+	opts := UpdateOptions{Status: &status, Assignee: &assignee}
 
 	// Add reason as a note if provided
 	if reason != "" {
-		args = append(args, "--notes=Released: "+reason)
+		notes := "Released: " + reason
+		opts.Notes = &notes
 	}
 
-	_, err := b.run(args...)
-	return err
+	return b.store().Update(id, opts)
 }
 
 // AddDependency adds a dependency: issue depends on dependsOn.
 func (b *Beads) AddDependency(issue, dependsOn string) error {
-	_, err := b.run("dep", "add", issue, dependsOn)
-	return err
+	return b.store().AddDependency(issue, dependsOn, "blocks")
 }
 
 // RemoveDependency removes a dependency.
 func (b *Beads) RemoveDependency(issue, dependsOn string) error {
-	_, err := b.run("dep", "remove", issue, dependsOn)
-	return err
+	return b.store().RemoveDependency(issue, dependsOn)
 }
 
 // Sync syncs beads with remote.
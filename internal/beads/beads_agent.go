@@ -2,7 +2,6 @@
 package beads
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os/exec"
@@ -31,6 +30,33 @@ func runSlotClear(workDir, beadID, slotName string) error {
 	return nil
 }
 
+// usesBD reports whether this wrapper writes with the bd CLI.
+func (b *Beads) usesBD() bool {
+	switch b.store().(type) {
+	case *bdStore, jsonlReadStore:
+		return true
+	}
+	return false
+}
+
+// setSlotAt sets a slot on a bead that lives in beadsDir (see
+// ResolveRoutingTarget). bd must run from that directory; the native store
+// routes by ID itself.
+func (b *Beads) setSlotAt(beadsDir, id, slot, value string) error {
+	if b.usesBD() {
+		return runSlotSet(beadsDir, id, slot, value)
+	}
+	return b.store().SetSlot(id, slot, value)
+}
+
+// clearSlotAt clears a slot on a bead that lives in beadsDir.
+func (b *Beads) clearSlotAt(beadsDir, id, slot string) error {
+	if b.usesBD() {
+		return runSlotClear(beadsDir, id, slot)
+	}
+	return b.store().ClearSlot(id, slot)
+}
+
 // AgentFields holds structured fields for agent beads.
 // These are stored as "key: value" lines in the description.
 type AgentFields struct {
@@ -158,40 +184,32 @@ func (b *Beads) CreateAgentBead(id, title string, fields *AgentFields) (*Issue,
 	targetDir := ResolveRoutingTarget(b.getTownRoot(), id, b.getResolvedBeadsDir())
 
 	// Ensure target database has custom types configured
-	// This is cached (sentinel file + in-memory) so repeated calls are fast
-	if err := EnsureCustomTypes(targetDir); err != nil {
-		return nil, fmt.Errorf("prepare target for agent bead %s: %w", id, err)
+	// This is cached (sentinel file + in-memory) so repeated calls are fast.
+	// The native store does not validate types, so only bd needs this.
+	if b.usesBD() {
+		if err := EnsureCustomTypes(targetDir); err != nil {
+			return nil, fmt.Errorf("prepare target for agent bead %s: %w", id, err)
+		}
 	}
 
 	description := FormatAgentDescription(title, fields)
 
-	args := []string{"create", "--json",
-		"--id=" + id,
-		"--title=" + title,
-		"--description=" + description,
-		"--type=agent",
-		"--labels=gt:agent",
-	}
-	if NeedsForceForID(id) {
-		args = append(args, "--force")
-	}
-
 	// Default actor from BD_ACTOR env var for provenance tracking
 	// Uses getActor() to respect isolated mode (tests)
-	if actor := b.getActor(); actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
+	issue, err := b.store().Create(NewIssue{
+		ID:          id,
+		Title:       title,
+		Description: description,
+		IssueType:   "agent",
+		Labels:      []string{"gt:agent"},
+		Priority:    -1,
+		Actor:       b.getActor(),
+		Force:       NeedsForceForID(id),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
 	// Note: role slot no longer set - role definitions are config-based
 
 	// Set the hook slot if specified (this is the authoritative storage)
@@ -199,13 +217,13 @@ func (b *Beads) CreateAgentBead(id, title string, fields *AgentFields) (*Issue,
 	// agent's hook slot is empty. See mi-619.
 	// Must run from targetDir since that's where the agent bead was created
 	if fields != nil && fields.HookBead != "" {
-		if err := runSlotSet(targetDir, id, "hook", fields.HookBead); err != nil {
+		if err := b.setSlotAt(targetDir, id, "hook", fields.HookBead); err != nil {
 			// Non-fatal: warn but continue - description text has the backup
 			fmt.Printf("Warning: could not set hook slot: %v\n", err)
 		}
 	}
 
-	return &issue, nil
+	return issue, nil
 }
 
 // CreateOrReopenAgentBead creates an agent bead or reopens an existing one.
@@ -238,7 +256,7 @@ func (b *Beads) CreateOrReopenAgentBead(id, title string, fields *AgentFields) (
 
 	// The bead already exists (should be closed from previous polecat lifecycle)
 	// Reopen it and update its fields
-	if _, reopenErr := b.run("reopen", id, "--reason=re-spawning agent"); reopenErr != nil {
+	if reopenErr := b.store().Reopen(id, "re-spawning agent"); reopenErr != nil {
 		// If reopen fails, the bead might already be open - continue with update
 		if !strings.Contains(reopenErr.Error(), "already open") {
 			return nil, fmt.Errorf("reopening existing agent bead: %w (original error: %v)", reopenErr, err)
@@ -259,12 +277,12 @@ func (b *Beads) CreateOrReopenAgentBead(id, title string, fields *AgentFields) (
 
 	// Clear any existing hook slot (handles stale state from previous lifecycle)
 	// Must run from targetDir since that's where the agent bead lives
-	_ = runSlotClear(targetDir, id, "hook")
+	_ = b.clearSlotAt(targetDir, id, "hook")
 
 	// Set the hook slot if specified
 	// Must run from targetDir since that's where the agent bead lives
 	if fields != nil && fields.HookBead != "" {
-		if err := runSlotSet(targetDir, id, "hook", fields.HookBead); err != nil {
+		if err := b.setSlotAt(targetDir, id, "hook", fields.HookBead); err != nil {
 			// Non-fatal: warn but continue - description text has the backup
 			fmt.Printf("Warning: could not set hook slot: %v\n", err)
 		}
@@ -287,8 +305,7 @@ func (b *Beads) CreateOrReopenAgentBead(id, title string, fields *AgentFields) (
 func (b *Beads) UpdateAgentState(id string, state string, hookBead *string) error {
 	// Update agent state using bd agent state command
 	// This updates the agent_state column directly in SQLite
-	_, err := b.run("agent", "state", id, state)
-	if err != nil {
+	if err := b.store().SetAgentState(id, state); err != nil {
 		return fmt.Errorf("updating agent state: %w", err)
 	}
 
@@ -297,24 +314,13 @@ func (b *Beads) UpdateAgentState(id string, state string, hookBead *string) erro
 		if *hookBead != "" {
 			// Set the hook using bd slot set
 			// This updates the hook_bead column directly in SQLite
-			_, err = b.run("slot", "set", id, "hook", *hookBead)
-			if err != nil {
-				// If slot is already occupied, clear it first then retry
-				// This handles re-slinging scenarios where we're updating the hook
-				errStr := err.Error()
-				if strings.Contains(errStr, "already occupied") {
-					_, _ = b.run("slot", "clear", id, "hook")
-					_, err = b.run("slot", "set", id, "hook", *hookBead)
-				}
-				if err != nil {
-					return fmt.Errorf("setting hook: %w", err)
-				}
+			if err := b.SetHookBead(id, *hookBead); err != nil {
+				return err
 			}
 		} else {
 			// Clear the hook
-			_, err = b.run("slot", "clear", id, "hook")
-			if err != nil {
-				return fmt.Errorf("clearing hook: %w", err)
+			if err := b.ClearHookBead(id); err != nil {
+				return err
 			}
 		}
 	}
@@ -329,13 +335,14 @@ func (b *Beads) UpdateAgentState(id string, state string, hookBead *string) erro
 func (b *Beads) SetHookBead(agentBeadID, hookBeadID string) error {
 	// Set the hook using bd slot set
 	// This updates the hook_bead column directly in SQLite
-	_, err := b.run("slot", "set", agentBeadID, "hook", hookBeadID)
+	err := b.store().SetSlot(agentBeadID, "hook", hookBeadID)
 	if err != nil {
 		// If slot is already occupied, clear it first then retry
+		// This handles re-slinging scenarios where we're updating the hook
 		errStr := err.Error()
 		if strings.Contains(errStr, "already occupied") {
-			_, _ = b.run("slot", "clear", agentBeadID, "hook")
-			_, err = b.run("slot", "set", agentBeadID, "hook", hookBeadID)
+			_ = b.store().ClearSlot(agentBeadID, "hook")
+			err = b.store().SetSlot(agentBeadID, "hook", hookBeadID)
 		}
 		if err != nil {
 			return fmt.Errorf("setting hook: %w", err)
@@ -347,8 +354,7 @@ func (b *Beads) SetHookBead(agentBeadID, hookBeadID string) error {
 // ClearHookBead clears the hook_bead slot on an agent bead.
 // Used when work is complete or unslung.
 func (b *Beads) ClearHookBead(agentBeadID string) error {
-	_, err := b.run("slot", "clear", agentBeadID, "hook")
-	if err != nil {
+	if err := b.store().ClearSlot(agentBeadID, "hook"); err != nil {
 		return fmt.Errorf("clearing hook: %w", err)
 	}
 	return nil
@@ -446,8 +452,7 @@ func (b *Beads) GetAgentNotificationLevel(id string) (string, error) {
 // WORKAROUND: Use CloseAndClearAgentBead instead, which allows CreateOrReopenAgentBead
 // to reopen the bead on re-spawn.
 func (b *Beads) DeleteAgentBead(id string) error {
-	_, err := b.run("delete", id, "--hard", "--force")
-	return err
+	return b.store().Delete(id)
 }
 
 // CloseAndClearAgentBead closes an agent bead (soft delete).
@@ -467,12 +472,7 @@ func (b *Beads) CloseAndClearAgentBead(id, reason string) error {
 	issue, err := b.Show(id)
 	if err != nil {
 		// If we can't read the issue, still attempt to close
-		args := []string{"close", id}
-		if reason != "" {
-			args = append(args, "--reason="+reason)
-		}
-		_, closeErr := b.run(args...)
-		return closeErr
+		return b.store().Close(CloseOptions{Reason: reason}, id)
 	}
 
 	// Parse existing fields and clear mutable ones
@@ -493,12 +493,7 @@ func (b *Beads) CloseAndClearAgentBead(id, reason string) error {
 		// Non-fatal
 	}
 
-	args := []string{"close", id}
-	if reason != "" {
-		args = append(args, "--reason="+reason)
-	}
-	_, err = b.run(args...)
-	return err
+	return b.store().Close(CloseOptions{Reason: reason}, id)
 }
 
 // GetAgentBead retrieves an agent bead by ID.
@@ -523,16 +518,11 @@ func (b *Beads) GetAgentBead(id string) (*Issue, *AgentFields, error) {
 // ListAgentBeads returns all agent beads in a single query.
 // Returns a map of agent bead ID to Issue.
 func (b *Beads) ListAgentBeads() (map[string]*Issue, error) {
-	out, err := b.run("list", "--label=gt:agent", "--json")
+	issues, err := b.List(ListOptions{Label: "gt:agent", Priority: -1})
 	if err != nil {
 		return nil, err
 	}
 
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd list output: %w", err)
-	}
-
 	result := make(map[string]*Issue, len(issues))
 	for _, issue := range issues {
 		result[issue.ID] = issue
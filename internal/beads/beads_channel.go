@@ -3,7 +3,6 @@
 package beads
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -151,32 +150,18 @@ func (b *Beads) CreateChannelBead(name string, subscribers []string, createdBy s
 
 	description := FormatChannelDescription(title, fields)
 
-	args := []string{"create", "--json",
-		"--id=" + id,
-		"--title=" + title,
-		"--description=" + description,
-		"--type=task", // Channels use task type with gt:channel label
-		"--labels=gt:channel",
-		"--force", // Override prefix check (town beads may have mixed prefixes)
-	}
-
-	// Default actor from BD_ACTOR env var for provenance tracking
-	// Uses getActor() to respect isolated mode (tests)
-	if actor := b.getActor(); actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+	return b.store().Create(NewIssue{
+		ID:          id,
+		Title:       title,
+		Description: description,
+		IssueType:   "task", // Channels use task type with gt:channel label
+		Labels:      []string{"gt:channel"},
+		Priority:    -1,
+		Force:       true, // Override prefix check (town beads may have mixed prefixes)
+		// Default actor from BD_ACTOR env var for provenance tracking
+		// Uses getActor() to respect isolated mode (tests)
+		Actor: b.getActor(),
+	})
 }
 
 // GetChannelBead retrieves a channel bead by name.
@@ -322,22 +307,16 @@ func (b *Beads) UpdateChannelStatus(name, status string) error {
 // DeleteChannelBead permanently deletes a channel bead.
 func (b *Beads) DeleteChannelBead(name string) error {
 	id := ChannelBeadID(name)
-	_, err := b.run("delete", id, "--hard", "--force")
-	return err
+	return b.store().Delete(id)
 }
 
 // ListChannelBeads returns all channel beads.
 func (b *Beads) ListChannelBeads() (map[string]*ChannelFields, error) {
-	out, err := b.run("list", "--label=gt:channel", "--json")
+	issues, err := b.List(ListOptions{Label: "gt:channel", Priority: -1})
 	if err != nil {
 		return nil, err
 	}
 
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd list output: %w", err)
-	}
-
 	result := make(map[string]*ChannelFields, len(issues))
 	for _, issue := range issues {
 		fields := ParseChannelFields(issue.Description)
@@ -399,25 +378,11 @@ func (b *Beads) EnforceChannelRetention(name string) error {
 	}
 
 	// Query messages in this channel (oldest first)
-	out, err := b.run("list",
-		"--type=message",
-		"--label=channel:"+name,
-		"--json",
-		"--limit=0",
-		"--sort=created",
-	)
+	messages, err := b.listChannelMessages(name)
 	if err != nil {
 		return fmt.Errorf("listing channel messages: %w", err)
 	}
 
-	var messages []struct {
-		ID        string `json:"id"`
-		CreatedAt string `json:"created_at"`
-	}
-	if err := json.Unmarshal(out, &messages); err != nil {
-		return fmt.Errorf("parsing channel messages: %w", err)
-	}
-
 	// Track which messages to delete (use map to avoid duplicates)
 	toDeleteIDs := make(map[string]bool)
 
@@ -446,12 +411,23 @@ func (b *Beads) EnforceChannelRetention(name string) error {
 	// Delete marked messages (best-effort)
 	for id := range toDeleteIDs {
 		// Use close instead of delete for audit trail
-		_, _ = b.run("close", id, "--reason=channel retention pruning")
+		_ = b.store().Close(CloseOptions{Reason: "channel retention pruning"}, id)
 	}
 
 	return nil
 }
 
+// listChannelMessages returns the messages posted to a channel, oldest first.
+func (b *Beads) listChannelMessages(name string) ([]*Issue, error) {
+	return b.List(ListOptions{
+		IssueType:     "message",
+		Label:         "channel:" + name,
+		Priority:      -1,
+		NoLimit:       true,
+		SortByCreated: true,
+	})
+}
+
 // PruneAllChannels enforces retention on all channels.
 // Called by Deacon patrol as a backup cleanup mechanism.
 // Enforces both count-based (RetentionCount) and time-based (RetentionHours) limits.
@@ -470,25 +446,11 @@ func (b *Beads) PruneAllChannels() (int, error) {
 		}
 
 		// Get messages with timestamps
-		out, err := b.run("list",
-			"--type=message",
-			"--label=channel:"+name,
-			"--json",
-			"--limit=0",
-			"--sort=created",
-		)
+		messages, err := b.listChannelMessages(name)
 		if err != nil {
 			continue // Skip on error
 		}
 
-		var messages []struct {
-			ID        string `json:"id"`
-			CreatedAt string `json:"created_at"`
-		}
-		if err := json.Unmarshal(out, &messages); err != nil {
-			continue
-		}
-
 		// Track which messages to delete (use map to avoid duplicates)
 		toDeleteIDs := make(map[string]bool)
 
@@ -519,7 +481,7 @@ func (b *Beads) PruneAllChannels() (int, error) {
 
 		// Delete marked messages
 		for id := range toDeleteIDs {
-			if _, err := b.run("close", id, "--reason=patrol retention pruning"); err == nil {
+			if err := b.store().Close(CloseOptions{Reason: "patrol retention pruning"}, id); err == nil {
 				pruned++
 			}
 		}
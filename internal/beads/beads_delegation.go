@@ -69,8 +69,7 @@ func (b *Beads) AddDelegation(d *Delegation) error {
 	}
 
 	// Set the delegated_from slot on the child issue
-	_, err = b.run("slot", "set", d.Child, "delegated_from", string(delegationJSON))
-	if err != nil {
+	if err := b.store().SetSlot(d.Child, "delegated_from", string(delegationJSON)); err != nil {
 		return fmt.Errorf("setting delegation slot: %w", err)
 	}
 
@@ -86,8 +85,7 @@ func (b *Beads) AddDelegation(d *Delegation) error {
 // RemoveDelegation removes a delegation relationship.
 func (b *Beads) RemoveDelegation(parent, child string) error {
 	// Clear the delegated_from slot on the child
-	_, err := b.run("slot", "clear", child, "delegated_from")
-	if err != nil {
+	if err := b.store().ClearSlot(child, "delegated_from"); err != nil {
 		return fmt.Errorf("clearing delegation slot: %w", err)
 	}
 
@@ -109,7 +107,7 @@ func (b *Beads) GetDelegation(child string) (*Delegation, error) {
 	}
 
 	// Get delegation from the slot
-	out, err := b.run("slot", "get", child, "delegated_from")
+	slotValue, err := b.store().GetSlot(child, "delegated_from")
 	if err != nil {
 		// No delegation slot means no delegation
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "no slot") {
@@ -118,8 +116,7 @@ func (b *Beads) GetDelegation(child string) (*Delegation, error) {
 		return nil, fmt.Errorf("getting delegation slot: %w", err)
 	}
 
-	slotValue := strings.TrimSpace(string(out))
-	if slotValue == "" || slotValue == "null" {
+	if slotValue == "" {
 		return nil, nil
 	}
 
@@ -2,9 +2,7 @@
 package beads
 
 import (
-	"encoding/json"
 	"fmt"
-	"strings"
 )
 
 // CreateDogAgentBead creates an agent bead for a dog.
@@ -20,32 +18,17 @@ func (b *Beads) CreateDogAgentBead(name, location string) (*Issue, error) {
 		"location:" + location,
 	}
 
-	args := []string{
-		"create", "--json",
-		"--id=" + beadID,
-		"--type=agent",
-		"--role-type=dog",
-		"--title=" + title,
-		"--labels=" + strings.Join(labels, ","),
-	}
-
-	// Default actor from BD_ACTOR env var for provenance tracking
-	// Uses getActor() to respect isolated mode (tests)
-	if actor := b.getActor(); actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+	return b.store().Create(NewIssue{
+		ID:        beadID,
+		Title:     title,
+		IssueType: "agent",
+		RoleType:  "dog",
+		Labels:    labels,
+		Priority:  -1,
+		// Default actor from BD_ACTOR env var for provenance tracking
+		// Uses getActor() to respect isolated mode (tests)
+		Actor: b.getActor(),
+	})
 }
 
 // FindDogAgentBead finds the agent bead for a dog by name.
@@ -2,7 +2,6 @@
 package beads
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -27,6 +26,7 @@ type EscalationFields struct {
 	ReescalationCount  int    // Number of times this has been re-escalated
 	LastReescalatedAt  string // When last re-escalated (empty if never)
 	LastReescalatedBy  string // Who last re-escalated (empty if never)
+	Deliveries         []string // External notification delivery records (email, sms, slack)
 }
 
 // EscalationState constants for bead status tracking.
@@ -103,6 +103,11 @@ func FormatEscalationDescription(title string, fields *EscalationFields) string
 		lines = append(lines, "last_reescalated_by: null")
 	}
 
+	// Delivery records (repeated key, one line each)
+	for _, d := range fields.Deliveries {
+		lines = append(lines, fmt.Sprintf("delivery: %s", d))
+	}
+
 	return strings.Join(lines, "\n")
 }
 
@@ -158,6 +163,10 @@ func ParseEscalationFields(description string) *EscalationFields {
 			fields.LastReescalatedAt = value
 		case "last_reescalated_by":
 			fields.LastReescalatedBy = value
+		case "delivery":
+			if value != "" {
+				fields.Deliveries = append(fields.Deliveries, value)
+			}
 		}
 	}
 
@@ -169,35 +178,22 @@ func ParseEscalationFields(description string) *EscalationFields {
 func (b *Beads) CreateEscalationBead(title string, fields *EscalationFields) (*Issue, error) {
 	description := FormatEscalationDescription(title, fields)
 
-	args := []string{"create", "--json",
-		"--title=" + title,
-		"--description=" + description,
-		"--type=task",
-		"--labels=gt:escalation",
-	}
-
+	labels := []string{"gt:escalation"}
 	// Add severity as a label for easy filtering
 	if fields != nil && fields.Severity != "" {
-		args = append(args, fmt.Sprintf("--labels=severity:%s", fields.Severity))
-	}
-
-	// Default actor from BD_ACTOR env var for provenance tracking
-	// Uses getActor() to respect isolated mode (tests)
-	if actor := b.getActor(); actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+		labels = append(labels, "severity:"+fields.Severity)
+	}
+
+	return b.store().Create(NewIssue{
+		Title:       title,
+		Description: description,
+		IssueType:   "task",
+		Labels:      labels,
+		Priority:    -1,
+		// Default actor from BD_ACTOR env var for provenance tracking
+		// Uses getActor() to respect isolated mode (tests)
+		Actor: b.getActor(),
+	})
 }
 
 // AckEscalation acknowledges an escalation bead.
@@ -259,8 +255,28 @@ func (b *Beads) CloseEscalation(id, closedBy, reason string) error {
 	}
 
 	// Close the issue
-	_, err = b.run("close", id, "--reason="+reason)
-	return err
+	return b.store().Close(CloseOptions{Reason: reason}, id)
+}
+
+// RecordEscalationDeliveries appends external notification delivery records
+// (email, SMS, Slack) to an escalation bead's description.
+func (b *Beads) RecordEscalationDeliveries(id string, records []string) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	issue, fields, err := b.GetEscalationBead(id)
+	if err != nil {
+		return err
+	}
+	if issue == nil {
+		return fmt.Errorf("escalation not found: %s", id)
+	}
+
+	fields.Deliveries = append(fields.Deliveries, records...)
+	description := FormatEscalationDescription(issue.Title, fields)
+
+	return b.Update(id, UpdateOptions{Description: &description})
 }
 
 // GetEscalationBead retrieves an escalation bead by ID.
@@ -284,37 +300,22 @@ func (b *Beads) GetEscalationBead(id string) (*Issue, *EscalationFields, error)
 
 // ListEscalations returns all open escalation beads.
 func (b *Beads) ListEscalations() ([]*Issue, error) {
-	out, err := b.run("list", "--label=gt:escalation", "--status=open", "--json")
+	issues, err := b.List(ListOptions{Label: "gt:escalation", Status: "open", Priority: -1})
 	if err != nil {
 		return nil, err
 	}
 
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd list output: %w", err)
-	}
-
 	return issues, nil
 }
 
 // ListEscalationsBySeverity returns open escalation beads filtered by severity.
 func (b *Beads) ListEscalationsBySeverity(severity string) ([]*Issue, error) {
-	out, err := b.run("list",
-		"--label=gt:escalation",
-		"--label=severity:"+severity,
-		"--status=open",
-		"--json",
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	var issues []*Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd list output: %w", err)
-	}
-
-	return issues, nil
+	return b.List(ListOptions{
+		Label:    "gt:escalation",
+		Labels:   []string{"severity:" + severity},
+		Status:   "open",
+		Priority: -1,
+	})
 }
 
 // ListStaleEscalations returns escalations older than the given threshold.
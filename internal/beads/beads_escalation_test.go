@@ -0,0 +1,47 @@
+package beads
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscalationFieldsRoundTrip_Deliveries(t *testing.T) {
+	fields := &EscalationFields{
+		Severity:    "critical",
+		Reason:      "CI blocked",
+		EscalatedBy: "gastown/witness",
+		EscalatedAt: "2026-01-02T15:04:05Z",
+		Deliveries: []string{
+			"email:human sent attempts=1 at=2026-01-02T15:04:06Z",
+			"sms:human failed attempts=3 at=2026-01-02T15:04:20Z error=gateway returned 503",
+		},
+	}
+
+	desc := FormatEscalationDescription("Build failing", fields)
+	if got := strings.Count(desc, "\ndelivery: "); got != 2 {
+		t.Fatalf("description has %d delivery lines, want 2:\n%s", got, desc)
+	}
+
+	parsed := ParseEscalationFields(desc)
+	if parsed.Severity != "critical" {
+		t.Errorf("Severity = %q, want critical", parsed.Severity)
+	}
+	if len(parsed.Deliveries) != 2 {
+		t.Fatalf("Deliveries = %v, want 2 entries", parsed.Deliveries)
+	}
+	for i, want := range fields.Deliveries {
+		if parsed.Deliveries[i] != want {
+			t.Errorf("Deliveries[%d] = %q, want %q", i, parsed.Deliveries[i], want)
+		}
+	}
+}
+
+func TestEscalationFields_NoDeliveries(t *testing.T) {
+	desc := FormatEscalationDescription("Quiet", &EscalationFields{Severity: "low"})
+	if strings.Contains(desc, "delivery:") {
+		t.Errorf("description should not contain delivery lines:\n%s", desc)
+	}
+	if got := ParseEscalationFields(desc).Deliveries; len(got) != 0 {
+		t.Errorf("Deliveries = %v, want none", got)
+	}
+}
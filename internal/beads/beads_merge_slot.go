@@ -2,7 +2,6 @@
 package beads
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -20,26 +19,17 @@ type MergeSlotStatus struct {
 // The slot is used for serialized conflict resolution in the merge queue.
 // Returns the slot ID if successful.
 func (b *Beads) MergeSlotCreate() (string, error) {
-	out, err := b.run("merge-slot", "create", "--json")
+	id, err := b.store().MergeSlotCreate()
 	if err != nil {
 		return "", fmt.Errorf("creating merge slot: %w", err)
 	}
-
-	var result struct {
-		ID     string `json:"id"`
-		Status string `json:"status"`
-	}
-	if err := json.Unmarshal(out, &result); err != nil {
-		return "", fmt.Errorf("parsing merge-slot create output: %w", err)
-	}
-
-	return result.ID, nil
+	return id, nil
 }
 
 // MergeSlotCheck checks the availability of the merge slot.
 // Returns the current status including holder and waiters if held.
 func (b *Beads) MergeSlotCheck() (*MergeSlotStatus, error) {
-	out, err := b.run("merge-slot", "check", "--json")
+	status, err := b.store().MergeSlotCheck()
 	if err != nil {
 		// Check if slot doesn't exist
 		if strings.Contains(err.Error(), "not found") {
@@ -47,13 +37,7 @@ func (b *Beads) MergeSlotCheck() (*MergeSlotStatus, error) {
 		}
 		return nil, fmt.Errorf("checking merge slot: %w", err)
 	}
-
-	var status MergeSlotStatus
-	if err := json.Unmarshal(out, &status); err != nil {
-		return nil, fmt.Errorf("parsing merge-slot check output: %w", err)
-	}
-
-	return &status, nil
+	return status, nil
 }
 
 // MergeSlotAcquire attempts to acquire the merge slot for exclusive access.
@@ -61,57 +45,19 @@ func (b *Beads) MergeSlotCheck() (*MergeSlotStatus, error) {
 // If addWaiter is true and the slot is held, the requester is added to the waiters queue.
 // Returns the acquisition result.
 func (b *Beads) MergeSlotAcquire(holder string, addWaiter bool) (*MergeSlotStatus, error) {
-	args := []string{"merge-slot", "acquire", "--json"}
-	if holder != "" {
-		args = append(args, "--holder="+holder)
-	}
-	if addWaiter {
-		args = append(args, "--wait")
-	}
-
-	out, err := b.run(args...)
+	status, err := b.store().MergeSlotAcquire(holder, addWaiter)
 	if err != nil {
-		// Parse the output even on error - it may contain useful info
-		var status MergeSlotStatus
-		if jsonErr := json.Unmarshal(out, &status); jsonErr == nil {
-			return &status, nil
-		}
 		return nil, fmt.Errorf("acquiring merge slot: %w", err)
 	}
-
-	var status MergeSlotStatus
-	if err := json.Unmarshal(out, &status); err != nil {
-		return nil, fmt.Errorf("parsing merge-slot acquire output: %w", err)
-	}
-
-	return &status, nil
+	return status, nil
 }
 
 // MergeSlotRelease releases the merge slot after conflict resolution completes.
 // If holder is provided, it verifies the slot is held by that holder before releasing.
 func (b *Beads) MergeSlotRelease(holder string) error {
-	args := []string{"merge-slot", "release", "--json"}
-	if holder != "" {
-		args = append(args, "--holder="+holder)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
+	if err := b.store().MergeSlotRelease(holder); err != nil {
 		return fmt.Errorf("releasing merge slot: %w", err)
 	}
-
-	var result struct {
-		Released bool   `json:"released"`
-		Error    string `json:"error,omitempty"`
-	}
-	if err := json.Unmarshal(out, &result); err != nil {
-		return fmt.Errorf("parsing merge-slot release output: %w", err)
-	}
-
-	if !result.Released && result.Error != "" {
-		return fmt.Errorf("slot release failed: %s", result.Error)
-	}
-
 	return nil
 }
 
@@ -2,7 +2,6 @@
 package beads
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -78,33 +77,17 @@ func ParseRigFields(description string) *RigFields {
 func (b *Beads) CreateRigBead(id, title string, fields *RigFields) (*Issue, error) {
 	description := FormatRigDescription(title, fields)
 
-	args := []string{"create", "--json",
-		"--id=" + id,
-		"--title=" + title,
-		"--description=" + description,
-		"--labels=gt:rig",
-	}
-	if NeedsForceForID(id) {
-		args = append(args, "--force")
-	}
-
-	// Default actor from BD_ACTOR env var for provenance tracking
-	// Uses getActor() to respect isolated mode (tests)
-	if actor := b.getActor(); actor != "" {
-		args = append(args, "--actor="+actor)
-	}
-
-	out, err := b.run(args...)
-	if err != nil {
-		return nil, err
-	}
-
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("parsing bd create output: %w", err)
-	}
-
-	return &issue, nil
+	return b.store().Create(NewIssue{
+		ID:          id,
+		Title:       title,
+		Description: description,
+		Labels:      []string{"gt:rig"},
+		Priority:    -1,
+		Force:       NeedsForceForID(id),
+		// Default actor from BD_ACTOR env var for provenance tracking
+		// Uses getActor() to respect isolated mode (tests)
+		Actor: b.getActor(),
+	})
 }
 
 // RigBeadIDWithPrefix generates a rig identity bead ID using the specified prefix.
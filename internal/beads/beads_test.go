@@ -439,6 +439,57 @@ worker: Toast`,
 			want: `merge_commit: deadbeef
 close_reason: rejected`,
 		},
+		{
+			name: "conflict resolution",
+			fields: &MRFields{
+				MergeCommit:        "deadbeef",
+				CloseReason:        "merged",
+				ConflictResolution: "auto_rebase",
+			},
+			want: `merge_commit: deadbeef
+close_reason: merged
+conflict_resolution: auto_rebase`,
+		},
+		{
+			name: "pull request",
+			fields: &MRFields{
+				MergeCommit: "deadbeef",
+				PRURL:       "https://github.com/acme/widgets/pull/7",
+			},
+			want: `merge_commit: deadbeef
+pr_url: https://github.com/acme/widgets/pull/7`,
+		},
+		{
+			name: "check results",
+			fields: &MRFields{
+				Branch: "polecat/nux",
+				Checks: "build=passed unit=failed lint=skipped",
+			},
+			want: `branch: polecat/nux
+checks: build=passed unit=failed lint=skipped`,
+		},
+		{
+			name: "backports",
+			fields: &MRFields{
+				Branch:         "polecat/nux",
+				Backports:      "release/1.2,release/1.3",
+				BackportStatus: "release/1.2=merged release/1.3=conflict",
+			},
+			want: `branch: polecat/nux
+backports: release/1.2,release/1.3
+backport_status: release/1.2=merged release/1.3=conflict`,
+		},
+		{
+			name: "merge strategy",
+			fields: &MRFields{
+				Branch:         "polecat/nux",
+				MergeStrategy:  "merge",
+				CommitTemplate: `{title} ({issue})\n\n{coauthors}`,
+			},
+			want: `branch: polecat/nux
+merge_strategy: merge
+commit_template: {title} ({issue})\n\n{coauthors}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -787,6 +838,15 @@ attached_at: 2025-12-21T15:30:00Z`,
 			},
 			want: "attached_molecule: mol-abc",
 		},
+		{
+			name: "trace fields",
+			fields: &AttachmentFields{
+				TraceParent:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				TraceStartedAt: "2025-12-21T15:30:00Z",
+			},
+			want: `traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+trace_started_at: 2025-12-21T15:30:00Z`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -903,6 +963,43 @@ func TestAttachmentFieldsRoundTrip(t *testing.T) {
 	}
 }
 
+// TestStepFieldsRoundTrip tests that step fields round-trip and that setting
+// them keeps the step's instructions.
+func TestStepFieldsRoundTrip(t *testing.T) {
+	original := &StepFields{
+		StepID:        "verify",
+		When:          `steps.check.output == "dirty"`,
+		MaxAttempts:   3,
+		Timeout:       "15m",
+		Group:         "cycle",
+		RepeatUntil:   `steps.poll.output == "ready"`,
+		MaxIterations: 10,
+		Attempts:      1,
+		Iteration:     2,
+		StartedAt:     "2025-12-21T15:30:00Z",
+		Output:        "3 files changed",
+		Skipped:       true,
+	}
+
+	issue := &Issue{Description: "Run the tests.\n\nOutput: goes to the log\nstep_output: stale"}
+	issue.Description = SetStepFields(issue, original)
+
+	parsed := ParseStepFields(issue)
+	if parsed == nil {
+		t.Fatal("round-trip parse returned nil")
+	}
+	if *parsed != *original {
+		t.Errorf("round-trip mismatch:\ngot  %+v\nwant %+v", parsed, original)
+	}
+	if !strings.HasSuffix(issue.Description, "\n\nRun the tests.\n\nOutput: goes to the log") {
+		t.Errorf("instructions not preserved:\n%s", issue.Description)
+	}
+
+	if ParseStepFields(&Issue{Description: "Output: prose, not a field"}) != nil {
+		t.Error("prose parsed as step fields")
+	}
+}
+
 // TestNoMergeField tests the no_merge field in AttachmentFields.
 // The no_merge flag tells gt done to skip the merge queue and keep work on a feature branch.
 func TestNoMergeField(t *testing.T) {
@@ -22,6 +22,8 @@ type AttachmentFields struct {
 	AttachedArgs     string // Natural language args passed via gt sling --args (no-tmux mode)
 	DispatchedBy     string // Agent ID that dispatched this work (for completion notification)
 	NoMerge          bool   // If true, gt done skips merge queue (for upstream PRs/human review)
+	TraceParent      string // W3C traceparent of the bead's lifecycle trace (set by gt sling)
+	TraceStartedAt   string // RFC 3339 start of the lifecycle trace; empty if the trace was joined
 }
 
 // ParseAttachmentFields extracts attachment fields from an issue's description.
@@ -69,6 +71,12 @@ func ParseAttachmentFields(issue *Issue) *AttachmentFields {
 		case "no_merge", "no-merge", "nomerge":
 			fields.NoMerge = strings.ToLower(value) == "true"
 			hasFields = true
+		case "traceparent", "trace_parent", "trace-parent":
+			fields.TraceParent = value
+			hasFields = true
+		case "trace_started_at", "trace-started-at", "tracestartedat":
+			fields.TraceStartedAt = value
+			hasFields = true
 		}
 	}
 
@@ -102,6 +110,12 @@ func FormatAttachmentFields(fields *AttachmentFields) string {
 	if fields.NoMerge {
 		lines = append(lines, "no_merge: true")
 	}
+	if fields.TraceParent != "" {
+		lines = append(lines, "traceparent: "+fields.TraceParent)
+	}
+	if fields.TraceStartedAt != "" {
+		lines = append(lines, "trace_started_at: "+fields.TraceStartedAt)
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -127,6 +141,12 @@ func SetAttachmentFields(issue *Issue, fields *AttachmentFields) string {
 		"no_merge":          true,
 		"no-merge":          true,
 		"nomerge":           true,
+		"traceparent":       true,
+		"trace_parent":      true,
+		"trace-parent":      true,
+		"trace_started_at":  true,
+		"trace-started-at":  true,
+		"tracestartedat":    true,
 	}
 
 	// Collect non-attachment lines from existing description
@@ -194,9 +214,40 @@ type MRFields struct {
 	LastConflictSHA string // SHA of main when conflict occurred
 	ConflictTaskID  string // Link to conflict-resolution task (if any)
 
+	// ConflictResolution records how the last conflict was handled:
+	// auto_rebase (refinery rebased and merged) or assign_back (task created).
+	ConflictResolution string
+
 	// Convoy tracking (for priority scoring - convoy starvation prevention)
 	ConvoyID        string // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt string // Convoy creation time (ISO 8601) for starvation prevention
+
+	// Lifecycle tracing, copied from the source issue by gt mq submit
+	TraceParent    string // W3C traceparent of the source issue's trace
+	TraceStartedAt string // RFC 3339 start of that trace (empty if not its root)
+
+	// PRURL is the forge pull request used to merge this MR (merge_mode "pr")
+	PRURL string
+
+	// Checks summarizes the last pre-merge check run as name=status pairs,
+	// e.g. "build=passed unit=failed lint=skipped"
+	Checks string
+
+	// Backports lists release branches the MR should also land on after
+	// its target, comma-separated (e.g. "release/1.2,release/1.3")
+	Backports string
+
+	// BackportStatus tracks each backport as target=status pairs,
+	// e.g. "release/1.2=merged release/1.3=conflict"
+	BackportStatus string
+
+	// MergeStrategy overrides the rig's merge_strategy for this MR:
+	// "squash", "rebase" or "merge"
+	MergeStrategy string
+
+	// CommitTemplate overrides the rig's commit_template for this MR, with
+	// newlines written as \n
+	CommitTemplate string
 }
 
 // ParseMRFields extracts structured merge-request fields from an issue's description.
@@ -265,12 +316,39 @@ func ParseMRFields(issue *Issue) *MRFields {
 		case "conflict_task_id", "conflict-task-id", "conflicttaskid":
 			fields.ConflictTaskID = value
 			hasFields = true
+		case "conflict_resolution", "conflict-resolution", "conflictresolution":
+			fields.ConflictResolution = value
+			hasFields = true
 		case "convoy_id", "convoy-id", "convoyid", "convoy":
 			fields.ConvoyID = value
 			hasFields = true
 		case "convoy_created_at", "convoy-created-at", "convoycreatedat":
 			fields.ConvoyCreatedAt = value
 			hasFields = true
+		case "traceparent", "trace_parent", "trace-parent":
+			fields.TraceParent = value
+			hasFields = true
+		case "trace_started_at", "trace-started-at", "tracestartedat":
+			fields.TraceStartedAt = value
+			hasFields = true
+		case "pr_url", "pr-url", "prurl":
+			fields.PRURL = value
+			hasFields = true
+		case "checks":
+			fields.Checks = value
+			hasFields = true
+		case "backports":
+			fields.Backports = value
+			hasFields = true
+		case "backport_status", "backport-status", "backportstatus":
+			fields.BackportStatus = value
+			hasFields = true
+		case "merge_strategy", "merge-strategy", "mergestrategy":
+			fields.MergeStrategy = value
+			hasFields = true
+		case "commit_template", "commit-template", "committemplate":
+			fields.CommitTemplate = value
+			hasFields = true
 		}
 	}
 
@@ -329,12 +407,39 @@ func FormatMRFields(fields *MRFields) string {
 	if fields.ConflictTaskID != "" {
 		lines = append(lines, "conflict_task_id: "+fields.ConflictTaskID)
 	}
+	if fields.ConflictResolution != "" {
+		lines = append(lines, "conflict_resolution: "+fields.ConflictResolution)
+	}
 	if fields.ConvoyID != "" {
 		lines = append(lines, "convoy_id: "+fields.ConvoyID)
 	}
 	if fields.ConvoyCreatedAt != "" {
 		lines = append(lines, "convoy_created_at: "+fields.ConvoyCreatedAt)
 	}
+	if fields.TraceParent != "" {
+		lines = append(lines, "traceparent: "+fields.TraceParent)
+	}
+	if fields.TraceStartedAt != "" {
+		lines = append(lines, "trace_started_at: "+fields.TraceStartedAt)
+	}
+	if fields.PRURL != "" {
+		lines = append(lines, "pr_url: "+fields.PRURL)
+	}
+	if fields.Checks != "" {
+		lines = append(lines, "checks: "+fields.Checks)
+	}
+	if fields.Backports != "" {
+		lines = append(lines, "backports: "+fields.Backports)
+	}
+	if fields.BackportStatus != "" {
+		lines = append(lines, "backport_status: "+fields.BackportStatus)
+	}
+	if fields.MergeStrategy != "" {
+		lines = append(lines, "merge_strategy: "+fields.MergeStrategy)
+	}
+	if fields.CommitTemplate != "" {
+		lines = append(lines, "commit_template: "+fields.CommitTemplate)
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -349,38 +454,61 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 
 	// Known MR field keys (lowercase)
 	mrKeys := map[string]bool{
-		"branch":             true,
-		"target":             true,
-		"source_issue":       true,
-		"source-issue":       true,
-		"sourceissue":        true,
-		"worker":             true,
-		"rig":                true,
-		"merge_commit":       true,
-		"merge-commit":       true,
-		"mergecommit":        true,
-		"close_reason":       true,
-		"close-reason":       true,
-		"closereason":        true,
-		"agent_bead":         true,
-		"agent-bead":         true,
-		"agentbead":          true,
-		"retry_count":        true,
-		"retry-count":        true,
-		"retrycount":         true,
-		"last_conflict_sha":  true,
-		"last-conflict-sha":  true,
-		"lastconflictsha":    true,
-		"conflict_task_id":   true,
-		"conflict-task-id":   true,
-		"conflicttaskid":     true,
-		"convoy_id":          true,
-		"convoy-id":          true,
-		"convoyid":           true,
-		"convoy":             true,
-		"convoy_created_at":  true,
-		"convoy-created-at":  true,
-		"convoycreatedat":    true,
+		"branch":              true,
+		"target":              true,
+		"source_issue":        true,
+		"source-issue":        true,
+		"sourceissue":         true,
+		"worker":              true,
+		"rig":                 true,
+		"merge_commit":        true,
+		"merge-commit":        true,
+		"mergecommit":         true,
+		"close_reason":        true,
+		"close-reason":        true,
+		"closereason":         true,
+		"agent_bead":          true,
+		"agent-bead":          true,
+		"agentbead":           true,
+		"retry_count":         true,
+		"retry-count":         true,
+		"retrycount":          true,
+		"last_conflict_sha":   true,
+		"last-conflict-sha":   true,
+		"lastconflictsha":     true,
+		"conflict_task_id":    true,
+		"conflict-task-id":    true,
+		"conflicttaskid":      true,
+		"conflict_resolution": true,
+		"conflict-resolution": true,
+		"conflictresolution":  true,
+		"convoy_id":           true,
+		"convoy-id":           true,
+		"convoyid":            true,
+		"convoy":              true,
+		"convoy_created_at":   true,
+		"convoy-created-at":   true,
+		"convoycreatedat":     true,
+		"traceparent":         true,
+		"trace_parent":        true,
+		"trace-parent":        true,
+		"trace_started_at":    true,
+		"trace-started-at":    true,
+		"tracestartedat":      true,
+		"pr_url":              true,
+		"pr-url":              true,
+		"prurl":               true,
+		"checks":              true,
+		"backports":           true,
+		"backport_status":     true,
+		"backport-status":     true,
+		"backportstatus":      true,
+		"merge_strategy":      true,
+		"merge-strategy":      true,
+		"mergestrategy":       true,
+		"commit_template":     true,
+		"commit-template":     true,
+		"committemplate":      true,
 	}
 
 	// Collect non-MR lines from existing description
@@ -512,6 +640,174 @@ func FormatSynthesisFields(fields *SynthesisFields) string {
 	return strings.Join(lines, "\n")
 }
 
+// StepFields holds the execution policy and state of a molecule step bead.
+// The policy comes from the formula step (when, retry, timeout, group) and is
+// stamped on the step when the molecule is instantiated; the state is
+// updated by gt mol step done. Keys carry a step_ prefix so they cannot be
+// confused with prose in the step description.
+type StepFields struct {
+	StepID        string // Formula step ID, referenced by conditions as steps.<id>
+	When          string // Condition; the step is skipped when it is false
+	MaxAttempts   int    // Retry policy: total attempts allowed (0 means 1)
+	Timeout       string // Go duration an attempt may take
+	Group         string // Repeating group the step belongs to
+	RepeatUntil   string // Group condition that ends the repetition
+	MaxIterations int    // Group iteration limit
+
+	Attempts  int    // Failed attempts so far
+	Iteration int    // Completed group iterations
+	StartedAt string // RFC 3339 start of the current attempt
+	Output    string // Output recorded with gt mol step done --output
+	Skipped   bool   // Closed because When was false
+}
+
+// stepFieldKeys are the StepFields keys, normalized to underscores.
+var stepFieldKeys = map[string]bool{
+	"step_id":             true,
+	"step_when":           true,
+	"step_max_attempts":   true,
+	"step_timeout":        true,
+	"step_group":          true,
+	"step_repeat_until":   true,
+	"step_max_iterations": true,
+	"step_attempts":       true,
+	"step_iteration":      true,
+	"step_started_at":     true,
+	"step_output":         true,
+	"step_skipped":        true,
+}
+
+// ParseStepFields extracts step fields from a step bead's description.
+// Returns nil if no step fields are found.
+func ParseStepFields(issue *Issue) *StepFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &StepFields{}
+	hasFields := false
+
+	for _, line := range strings.Split(issue.Description, "\n") {
+		line = strings.TrimSpace(line)
+		colonIdx := strings.Index(line, ":")
+		if colonIdx == -1 {
+			continue
+		}
+
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(line[:colonIdx])), "-", "_")
+		value := strings.TrimSpace(line[colonIdx+1:])
+		if value == "" || !stepFieldKeys[key] {
+			continue
+		}
+		hasFields = true
+
+		switch key {
+		case "step_id":
+			fields.StepID = value
+		case "step_when":
+			fields.When = value
+		case "step_max_attempts":
+			fields.MaxAttempts, _ = parseIntField(value)
+		case "step_timeout":
+			fields.Timeout = value
+		case "step_group":
+			fields.Group = value
+		case "step_repeat_until":
+			fields.RepeatUntil = value
+		case "step_max_iterations":
+			fields.MaxIterations, _ = parseIntField(value)
+		case "step_attempts":
+			fields.Attempts, _ = parseIntField(value)
+		case "step_iteration":
+			fields.Iteration, _ = parseIntField(value)
+		case "step_started_at":
+			fields.StartedAt = value
+		case "step_output":
+			fields.Output = value
+		case "step_skipped":
+			fields.Skipped = strings.ToLower(value) == "true"
+		}
+	}
+
+	if !hasFields {
+		return nil
+	}
+	return fields
+}
+
+// FormatStepFields formats StepFields as "key: value" lines.
+// Only non-empty fields are included.
+func FormatStepFields(fields *StepFields) string {
+	if fields == nil {
+		return ""
+	}
+
+	var lines []string
+	add := func(key, value string) {
+		if value != "" {
+			lines = append(lines, key+": "+value)
+		}
+	}
+	addInt := func(key string, value int) {
+		if value != 0 {
+			lines = append(lines, fmt.Sprintf("%s: %d", key, value))
+		}
+	}
+
+	add("step_id", fields.StepID)
+	add("step_when", fields.When)
+	addInt("step_max_attempts", fields.MaxAttempts)
+	add("step_timeout", fields.Timeout)
+	add("step_group", fields.Group)
+	add("step_repeat_until", fields.RepeatUntil)
+	addInt("step_max_iterations", fields.MaxIterations)
+	addInt("step_attempts", fields.Attempts)
+	addInt("step_iteration", fields.Iteration)
+	add("step_started_at", fields.StartedAt)
+	// Outputs are single-line values.
+	add("step_output", strings.Join(strings.Fields(fields.Output), " "))
+	if fields.Skipped {
+		lines = append(lines, "step_skipped: true")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// SetStepFields updates a step bead's description with the given step fields.
+// Existing step field lines are replaced; the step instructions are preserved
+// after them.
+func SetStepFields(issue *Issue, fields *StepFields) string {
+	var otherLines []string
+	if issue != nil && issue.Description != "" {
+		for _, line := range strings.Split(issue.Description, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if colonIdx := strings.Index(trimmed, ":"); colonIdx != -1 {
+				key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(trimmed[:colonIdx])), "-", "_")
+				if stepFieldKeys[key] {
+					continue
+				}
+			}
+			otherLines = append(otherLines, line)
+		}
+	}
+
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[len(otherLines)-1]) == "" {
+		otherLines = otherLines[:len(otherLines)-1]
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[0]) == "" {
+		otherLines = otherLines[1:]
+	}
+
+	formatted := FormatStepFields(fields)
+	if formatted == "" {
+		return strings.Join(otherLines, "\n")
+	}
+	if len(otherLines) == 0 {
+		return formatted
+	}
+	return formatted + "\n\n" + strings.Join(otherLines, "\n")
+}
+
 // RoleConfig holds structured lifecycle configuration for role beads.
 // These fields are stored as "key: value" lines in the role bead description.
 // This enables agents to self-register their lifecycle configuration,
@@ -0,0 +1,256 @@
+package beads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Store is the storage backend behind a Beads wrapper.
+//
+// bdStore runs the bd CLI (the default). The native backend avoids a fork
+// per query: jsonlReadStore reads the beads JSONL of a no-db beads directory
+// directly and leaves writes to bd, and nativeStore reads and writes the
+// tables of a Dolt server mode directory through the Dolt SQL server. A town
+// picks one with "beads_backend" in town settings or the GT_BEADS_BACKEND
+// environment variable. Operations not covered here
+// (sync, stats, init, raw Run) always go through bd.
+type Store interface {
+	// List returns issues matching opts.
+	List(opts ListOptions) ([]*Issue, error)
+	// Show returns the issues with the given IDs, with dependency details.
+	// IDs that do not exist are omitted; a single missing ID is ErrNotFound.
+	Show(ids ...string) ([]*Issue, error)
+	// Ready returns open issues with no unresolved blockers.
+	Ready(opts ReadyOptions) ([]*Issue, error)
+	// Blocked returns open issues waiting on unresolved blockers.
+	Blocked() ([]*Issue, error)
+
+	// Create creates an issue and returns it.
+	Create(issue NewIssue) (*Issue, error)
+	// Update changes fields of an existing issue.
+	Update(id string, opts UpdateOptions) error
+	// Close closes issues.
+	Close(opts CloseOptions, ids ...string) error
+	// Reopen reopens a closed issue.
+	Reopen(id, reason string) error
+	// Delete permanently removes an issue.
+	Delete(id string) error
+
+	// AddDependency records that issue depends on dependsOn.
+	// depType is a bd dependency type such as "blocks" or "tracks".
+	AddDependency(issue, dependsOn, depType string) error
+	// RemoveDependency removes a dependency between two issues.
+	RemoveDependency(issue, dependsOn string) error
+
+	// SetAgentState sets the agent_state of an agent bead.
+	SetAgentState(id, state string) error
+	// SetSlot sets a named slot (e.g. "hook") on an issue.
+	SetSlot(id, slot, value string) error
+	// ClearSlot clears a named slot.
+	ClearSlot(id, slot string) error
+	// GetSlot returns a slot's value, or "" when unset.
+	GetSlot(id, slot string) (string, error)
+	// AddGateWaiter registers a waiter on a gate bead.
+	AddGateWaiter(gateID, waiter string) error
+
+	// MergeSlotCreate creates the rig's merge slot and returns its ID.
+	MergeSlotCreate() (string, error)
+	// MergeSlotCheck reports the merge slot's holder and waiters.
+	MergeSlotCheck() (*MergeSlotStatus, error)
+	// MergeSlotAcquire takes the merge slot for holder, optionally queueing.
+	MergeSlotAcquire(holder string, wait bool) (*MergeSlotStatus, error)
+	// MergeSlotRelease releases the merge slot.
+	MergeSlotRelease(holder string) error
+}
+
+// ErrNativeNeedsNoDB is returned by every call of a native store selected
+// for a beads directory whose database it cannot reach: SQLite, or Dolt
+// that bd opens embedded rather than through a SQL server.
+var ErrNativeNeedsNoDB = errors.New("native beads backend needs a no-db or Dolt server beads directory")
+
+// ErrBDManaged is returned by a native write to a no-db beads directory.
+// bd in no-db mode holds issues.jsonl in memory and rewrites it when it
+// exits, without a lock gt could share, so only bd writes that file.
+var ErrBDManaged = errors.New("issues.jsonl is written by bd")
+
+// NewIssue describes an issue for Store.Create.
+type NewIssue struct {
+	ID          string // Explicit ID; empty to generate one
+	Title       string
+	Description string
+	IssueType   string   // bd issue type (e.g., "agent", "task"); empty for the default
+	RoleType    string   // Agent role type (e.g., "dog")
+	Labels      []string // Labels to attach
+	Priority    int      // 0-4, -1 for the default
+	Parent      string   // Parent issue ID
+	Actor       string   // Who is creating this issue (populates created_by)
+	Ephemeral   bool     // Create as ephemeral (wisp)
+	Force       bool     // Accept an ID whose prefix differs from the database's
+}
+
+// ReadyOptions filters Store.Ready.
+type ReadyOptions struct {
+	Label string // Label filter (e.g., "gt:molecule")
+	Limit int    // Maximum results; 0 for the backend default
+}
+
+// CloseOptions specifies how Store.Close closes issues.
+type CloseOptions struct {
+	Reason  string // Close reason
+	Force   bool   // Close even if dependency checks would refuse
+	Session string // Runtime session ID for work attribution
+}
+
+// store returns the backend for this wrapper, choosing it on first use.
+func (b *Beads) store() Store {
+	if b.st == nil {
+		b.st = b.openStore()
+	}
+	return b.st
+}
+
+// openStore picks the backend configured for the town. Isolated wrappers
+// (tests) and anything outside a town use bd. The native backend reads no-db
+// beads directories in-process and writes them with bd, and reads and writes
+// Dolt directories bd reaches through a SQL server. With a SQLite or
+// embedded Dolt database, bd exports issues.jsonl itself and reads of it may
+// be stale, so a native selection there fails every call instead of guessing.
+func (b *Beads) openStore() Store {
+	if b.isolated || beadsBackend(b.getTownRoot()) != config.BeadsBackendNative {
+		return &bdStore{b: b}
+	}
+	beadsDir := b.getResolvedBeadsDir()
+	native := newNativeStore(beadsDir, b.getTownRoot(), b.getActor())
+	db := databaseBackend(beadsDir)
+	switch {
+	case db == "" && !jsonlWritesForTesting:
+		return jsonlReadStore{bdStore: &bdStore{b: b}, native: native}
+	case db == "", doltServerFor(beadsDir, b.getTownRoot()) != nil:
+		return native
+	}
+	return errStore{fmt.Errorf("%w: %s has a %s database; use beads_backend %q, run bd in no-db mode or serve Dolt through a SQL server",
+		ErrNativeNeedsNoDB, beadsDir, db, config.BeadsBackendBD)}
+}
+
+// jsonlWritesForTesting lets native stores write issues.jsonl themselves.
+var jsonlWritesForTesting bool
+
+// WriteJSONLForTesting makes the native backend write no-db beads
+// directories' issues.jsonl directly instead of through bd, so tests can
+// create and update beads without bd installed. Nothing else may write the
+// file meanwhile. It returns a func that restores the default.
+// This is intended for use in tests only.
+func WriteJSONLForTesting() (restore func()) {
+	jsonlWritesForTesting = true
+	return func() { jsonlWritesForTesting = false }
+}
+
+// jsonlReadStore serves a no-db beads directory: queries read issues.jsonl
+// in-process and every write goes through bd, which owns the file.
+type jsonlReadStore struct {
+	*bdStore
+	native *nativeStore
+}
+
+func (s jsonlReadStore) List(opts ListOptions) ([]*Issue, error)   { return s.native.List(opts) }
+func (s jsonlReadStore) Show(ids ...string) ([]*Issue, error)      { return s.native.Show(ids...) }
+func (s jsonlReadStore) Ready(opts ReadyOptions) ([]*Issue, error) { return s.native.Ready(opts) }
+func (s jsonlReadStore) Blocked() ([]*Issue, error)                { return s.native.Blocked() }
+func (s jsonlReadStore) GetSlot(id, slot string) (string, error)   { return s.native.GetSlot(id, slot) }
+func (s jsonlReadStore) MergeSlotCheck() (*MergeSlotStatus, error) { return s.native.MergeSlotCheck() }
+
+// beadsBackend returns the configured backend name for a town.
+func beadsBackend(townRoot string) string {
+	if env := os.Getenv("GT_BEADS_BACKEND"); env != "" {
+		return env
+	}
+	if townRoot == "" {
+		return config.BeadsBackendBD
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.BeadsBackend == "" {
+		return config.BeadsBackendBD
+	}
+	return settings.BeadsBackend
+}
+
+// databaseBackend returns the database behind a beads directory ("dolt",
+// "sqlite"), or "" when bd runs it in no-db mode on issues.jsonl alone.
+func databaseBackend(beadsDir string) string {
+	if noDBMode(beadsDir) {
+		return ""
+	}
+	if info, err := os.Stat(filepath.Join(beadsDir, "dolt")); err == nil && info.IsDir() {
+		return "dolt"
+	}
+	if backend := storageBackend(beadsDir); backend != "" {
+		return backend
+	}
+	if dbs, _ := filepath.Glob(filepath.Join(beadsDir, "*.db")); len(dbs) > 0 {
+		return "sqlite"
+	}
+	return ""
+}
+
+// noDBMode reports whether a beads directory's config.yaml sets no-db.
+func noDBMode(beadsDir string) bool {
+	data, err := os.ReadFile(filepath.Join(beadsDir, "config.yaml")) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "no-db:"); ok {
+			return strings.Trim(strings.TrimSpace(v), `"'`) == "true"
+		}
+	}
+	return false
+}
+
+// storageBackend returns the database backend recorded in a beads
+// directory's metadata.json ("dolt", "sqlite"), or "" if unrecorded.
+func storageBackend(beadsDir string) string {
+	data, err := os.ReadFile(filepath.Join(beadsDir, "metadata.json")) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return ""
+	}
+	var metadata struct {
+		Backend string `json:"backend"`
+	}
+	if json.Unmarshal(data, &metadata) != nil {
+		return ""
+	}
+	return metadata.Backend
+}
+
+// errStore is a Store whose every call fails with err, for a backend that
+// was selected but cannot serve the beads directory.
+type errStore struct{ err error }
+
+func (s errStore) List(ListOptions) ([]*Issue, error)         { return nil, s.err }
+func (s errStore) Show(...string) ([]*Issue, error)           { return nil, s.err }
+func (s errStore) Ready(ReadyOptions) ([]*Issue, error)       { return nil, s.err }
+func (s errStore) Blocked() ([]*Issue, error)                 { return nil, s.err }
+func (s errStore) Create(NewIssue) (*Issue, error)            { return nil, s.err }
+func (s errStore) Update(string, UpdateOptions) error         { return s.err }
+func (s errStore) Close(CloseOptions, ...string) error        { return s.err }
+func (s errStore) Reopen(string, string) error                { return s.err }
+func (s errStore) Delete(string) error                        { return s.err }
+func (s errStore) AddDependency(string, string, string) error { return s.err }
+func (s errStore) RemoveDependency(string, string) error      { return s.err }
+func (s errStore) SetAgentState(string, string) error         { return s.err }
+func (s errStore) SetSlot(string, string, string) error       { return s.err }
+func (s errStore) ClearSlot(string, string) error             { return s.err }
+func (s errStore) GetSlot(string, string) (string, error)     { return "", s.err }
+func (s errStore) AddGateWaiter(string, string) error         { return s.err }
+func (s errStore) MergeSlotCreate() (string, error)           { return "", s.err }
+func (s errStore) MergeSlotCheck() (*MergeSlotStatus, error)  { return nil, s.err }
+func (s errStore) MergeSlotAcquire(string, bool) (*MergeSlotStatus, error) {
+	return nil, s.err
+}
+func (s errStore) MergeSlotRelease(string) error { return s.err }
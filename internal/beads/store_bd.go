@@ -0,0 +1,367 @@
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// bdStore implements Store by running the bd CLI.
+type bdStore struct {
+	b *Beads
+}
+
+func (s *bdStore) List(opts ListOptions) ([]*Issue, error) {
+	args := []string{"list", "--json"}
+
+	if opts.Status != "" {
+		args = append(args, "--status="+opts.Status)
+	}
+	// Prefer Label over Type (Type is deprecated)
+	if opts.Label != "" {
+		args = append(args, "--label="+opts.Label)
+	} else if opts.Type != "" {
+		// Deprecated: convert type to label for backward compatibility
+		args = append(args, "--label=gt:"+opts.Type)
+	}
+	for _, label := range opts.Labels {
+		args = append(args, "--label="+label)
+	}
+	if opts.IssueType != "" {
+		args = append(args, "--type="+opts.IssueType)
+	}
+	if opts.Priority >= 0 {
+		args = append(args, fmt.Sprintf("--priority=%d", opts.Priority))
+	}
+	if opts.Parent != "" {
+		args = append(args, "--parent="+opts.Parent)
+	}
+	if opts.Assignee != "" {
+		args = append(args, "--assignee="+opts.Assignee)
+	}
+	if opts.NoAssignee {
+		args = append(args, "--no-assignee")
+	}
+	if opts.NoLimit {
+		args = append(args, "--limit=0")
+	}
+	if opts.SortByCreated {
+		args = append(args, "--sort=created")
+	}
+
+	out, err := s.b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd list output: %w", err)
+	}
+
+	return issues, nil
+}
+
+func (s *bdStore) Show(ids ...string) ([]*Issue, error) {
+	args := append([]string{"show", "--json"}, ids...)
+	if len(ids) == 1 {
+		args = []string{"show", ids[0], "--json"}
+	}
+	out, err := s.b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// bd show --json returns an array, even for one ID
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd show output: %w", err)
+	}
+	if len(ids) == 1 && len(issues) == 0 {
+		return nil, ErrNotFound
+	}
+	return issues, nil
+}
+
+func (s *bdStore) Ready(opts ReadyOptions) ([]*Issue, error) {
+	args := []string{"ready", "--json"}
+	if opts.Label != "" {
+		args = append(args, "--label", opts.Label)
+	}
+	if opts.Limit > 0 {
+		args = append(args, "-n", fmt.Sprintf("%d", opts.Limit))
+	}
+	out, err := s.b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd ready output: %w", err)
+	}
+
+	return issues, nil
+}
+
+func (s *bdStore) Blocked() ([]*Issue, error) {
+	out, err := s.b.run("blocked", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd blocked output: %w", err)
+	}
+
+	return issues, nil
+}
+
+func (s *bdStore) Create(issue NewIssue) (*Issue, error) {
+	args := []string{"create", "--json"}
+
+	if issue.ID != "" {
+		args = append(args, "--id="+issue.ID)
+	}
+	if issue.Force {
+		args = append(args, "--force")
+	}
+	if issue.Title != "" {
+		args = append(args, "--title="+issue.Title)
+	}
+	if issue.Description != "" {
+		args = append(args, "--description="+issue.Description)
+	}
+	if issue.IssueType != "" {
+		args = append(args, "--type="+issue.IssueType)
+	}
+	if issue.RoleType != "" {
+		args = append(args, "--role-type="+issue.RoleType)
+	}
+	for _, label := range issue.Labels {
+		args = append(args, "--labels="+label)
+	}
+	if issue.Priority >= 0 {
+		args = append(args, fmt.Sprintf("--priority=%d", issue.Priority))
+	}
+	if issue.Parent != "" {
+		args = append(args, "--parent="+issue.Parent)
+	}
+	if issue.Ephemeral {
+		args = append(args, "--ephemeral")
+	}
+	if issue.Actor != "" {
+		args = append(args, "--actor="+issue.Actor)
+	}
+
+	out, err := s.b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Issue
+	if err := json.Unmarshal(out, &created); err != nil {
+		return nil, fmt.Errorf("parsing bd create output: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (s *bdStore) Update(id string, opts UpdateOptions) error {
+	args := []string{"update", id}
+
+	if opts.Title != nil {
+		args = append(args, "--title="+*opts.Title)
+	}
+	if opts.Status != nil {
+		args = append(args, "--status="+*opts.Status)
+	}
+	if opts.Priority != nil {
+		args = append(args, fmt.Sprintf("--priority=%d", *opts.Priority))
+	}
+	if opts.Description != nil {
+		args = append(args, "--description="+*opts.Description)
+	}
+	if opts.Assignee != nil {
+		args = append(args, "--assignee="+*opts.Assignee)
+	}
+	if opts.Notes != nil {
+		args = append(args, "--notes="+*opts.Notes)
+	}
+	// Label operations: set-labels replaces all, otherwise use add/remove
+	if len(opts.SetLabels) > 0 {
+		for _, label := range opts.SetLabels {
+			args = append(args, "--set-labels="+label)
+		}
+	} else {
+		for _, label := range opts.AddLabels {
+			args = append(args, "--add-label="+label)
+		}
+		for _, label := range opts.RemoveLabels {
+			args = append(args, "--remove-label="+label)
+		}
+	}
+
+	_, err := s.b.run(args...)
+	return err
+}
+
+func (s *bdStore) Close(opts CloseOptions, ids ...string) error {
+	args := append([]string{"close"}, ids...)
+	if opts.Reason != "" {
+		args = append(args, "--reason="+opts.Reason)
+	}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	if opts.Session != "" {
+		args = append(args, "--session="+opts.Session)
+	}
+	_, err := s.b.run(args...)
+	return err
+}
+
+func (s *bdStore) Reopen(id, reason string) error {
+	args := []string{"reopen", id}
+	if reason != "" {
+		args = append(args, "--reason="+reason)
+	}
+	_, err := s.b.run(args...)
+	return err
+}
+
+func (s *bdStore) Delete(id string) error {
+	_, err := s.b.run("delete", id, "--hard", "--force")
+	return err
+}
+
+func (s *bdStore) AddDependency(issue, dependsOn, depType string) error {
+	args := []string{"dep", "add", issue, dependsOn}
+	if depType != "" && depType != "blocks" {
+		args = append(args, "--type="+depType)
+	}
+	_, err := s.b.run(args...)
+	return err
+}
+
+func (s *bdStore) RemoveDependency(issue, dependsOn string) error {
+	_, err := s.b.run("dep", "remove", issue, dependsOn)
+	return err
+}
+
+func (s *bdStore) SetAgentState(id, state string) error {
+	_, err := s.b.run("agent", "state", id, state)
+	return err
+}
+
+func (s *bdStore) SetSlot(id, slot, value string) error {
+	_, err := s.b.run("slot", "set", id, slot, value)
+	return err
+}
+
+func (s *bdStore) ClearSlot(id, slot string) error {
+	_, err := s.b.run("slot", "clear", id, slot)
+	return err
+}
+
+func (s *bdStore) GetSlot(id, slot string) (string, error) {
+	out, err := s.b.run("slot", "get", id, slot)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "null" {
+		return "", nil
+	}
+	return value, nil
+}
+
+func (s *bdStore) AddGateWaiter(gateID, waiter string) error {
+	_, err := s.b.run("gate", "add-waiter", gateID, waiter)
+	return err
+}
+
+func (s *bdStore) MergeSlotCreate() (string, error) {
+	out, err := s.b.run("merge-slot", "create", "--json")
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return "", fmt.Errorf("parsing merge-slot create output: %w", err)
+	}
+
+	return result.ID, nil
+}
+
+func (s *bdStore) MergeSlotCheck() (*MergeSlotStatus, error) {
+	out, err := s.b.run("merge-slot", "check", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var status MergeSlotStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, fmt.Errorf("parsing merge-slot check output: %w", err)
+	}
+
+	return &status, nil
+}
+
+func (s *bdStore) MergeSlotAcquire(holder string, wait bool) (*MergeSlotStatus, error) {
+	args := []string{"merge-slot", "acquire", "--json"}
+	if holder != "" {
+		args = append(args, "--holder="+holder)
+	}
+	if wait {
+		args = append(args, "--wait")
+	}
+
+	out, err := s.b.run(args...)
+	if err != nil {
+		// Parse the output even on error - it may contain useful info
+		var status MergeSlotStatus
+		if jsonErr := json.Unmarshal(out, &status); jsonErr == nil {
+			return &status, nil
+		}
+		return nil, err
+	}
+
+	var status MergeSlotStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, fmt.Errorf("parsing merge-slot acquire output: %w", err)
+	}
+
+	return &status, nil
+}
+
+func (s *bdStore) MergeSlotRelease(holder string) error {
+	args := []string{"merge-slot", "release", "--json"}
+	if holder != "" {
+		args = append(args, "--holder="+holder)
+	}
+
+	out, err := s.b.run(args...)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Released bool   `json:"released"`
+		Error    string `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return fmt.Errorf("parsing merge-slot release output: %w", err)
+	}
+
+	if !result.Released && result.Error != "" {
+		return fmt.Errorf("slot release failed: %s", result.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,446 @@
+package beads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// doltServer is the Dolt SQL server behind a beads directory that bd runs in
+// Dolt server mode, normally the one the gt daemon manages (patrols.dolt_server
+// in mayor/daemon.json). The native store reads and writes such a directory
+// through the server instead of issues.jsonl, so it sees bd's writes at once
+// and bd sees its own.
+//
+// Statements go through the dolt CLI, as the daemon's own health checks do.
+// A read loads the issues, labels and dependencies tables into the same
+// records issues.jsonl holds; a write applies fn to a copy and sends only the
+// rows and columns it changed, in one transaction. Changes are left in the
+// working set for bd's next Dolt commit.
+type doltServer struct {
+	Host     string
+	Port     int
+	User     string
+	Database string
+}
+
+// Defaults of the daemon's Dolt server and of bd's database name.
+const (
+	defaultDoltHost     = "127.0.0.1"
+	defaultDoltPort     = 3306
+	defaultDoltUser     = "root"
+	defaultDoltDatabase = "beads"
+)
+
+// doltServerFor returns the Dolt SQL server holding beadsDir's issues, or nil
+// unless bd runs the directory in Dolt server mode. Connection settings come
+// from bd's metadata.json, then the daemon's dolt_server config, then the
+// daemon's defaults.
+func doltServerFor(beadsDir, townRoot string) *doltServer {
+	data, err := os.ReadFile(filepath.Join(beadsDir, "metadata.json")) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return nil
+	}
+	var metadata struct {
+		Backend  string `json:"backend"`
+		DoltMode string `json:"dolt_mode"`
+		Host     string `json:"dolt_server_host"`
+		Port     int    `json:"dolt_server_port"`
+		User     string `json:"dolt_server_user"`
+		Database string `json:"dolt_database"`
+	}
+	if json.Unmarshal(data, &metadata) != nil || metadata.Backend != "dolt" || metadata.DoltMode != "server" {
+		return nil
+	}
+
+	srv := &doltServer{Host: defaultDoltHost, Port: defaultDoltPort, User: defaultDoltUser, Database: defaultDoltDatabase}
+	if townRoot != "" {
+		var daemon struct {
+			Patrols struct {
+				DoltServer struct {
+					Host string `json:"host"`
+					Port int    `json:"port"`
+				} `json:"dolt_server"`
+			} `json:"patrols"`
+		}
+		if data, err := os.ReadFile(filepath.Join(townRoot, "mayor", "daemon.json")); err == nil && json.Unmarshal(data, &daemon) == nil { //nolint:gosec // G304: path is constructed internally
+			if daemon.Patrols.DoltServer.Host != "" {
+				srv.Host = daemon.Patrols.DoltServer.Host
+			}
+			if daemon.Patrols.DoltServer.Port != 0 {
+				srv.Port = daemon.Patrols.DoltServer.Port
+			}
+		}
+	}
+	if metadata.Host != "" {
+		srv.Host = metadata.Host
+	}
+	if metadata.Port != 0 {
+		srv.Port = metadata.Port
+	}
+	if metadata.User != "" {
+		srv.User = metadata.User
+	}
+	if metadata.Database != "" {
+		srv.Database = metadata.Database
+	}
+	return srv
+}
+
+func (d *doltServer) String() string {
+	return fmt.Sprintf("dolt://%s@%s:%d/%s", d.User, d.Host, d.Port, d.Database)
+}
+
+// sql runs statements on the server and returns the output.
+func (d *doltServer) sql(query string, resultFormat string) ([]byte, error) {
+	args := []string{
+		"--host", d.Host,
+		"--port", strconv.Itoa(d.Port),
+		"--user", d.User,
+		"--password", "",
+		"--no-tls",
+		"--use-db", d.Database,
+		"sql", "-q", query,
+	}
+	if resultFormat != "" {
+		args = append(args, "--result-format", resultFormat)
+	}
+	cmd := exec.Command("dolt", args...) //nolint:gosec // G204: arguments are constructed internally
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return nil, fmt.Errorf("%s: %w (%s)", d, err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// query runs a SELECT and returns its rows keyed by column.
+func (d *doltServer) query(query string) ([]record, error) {
+	out, err := d.sql(query, "json")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var result struct {
+		Rows []record `json:"rows"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("%s: parsing result of %q: %w", d, query, err)
+	}
+	return result.Rows, nil
+}
+
+// doltBoolColumns are issues columns bd stores as TINYINT and exports as
+// JSON booleans.
+var doltBoolColumns = map[string]bool{
+	"ephemeral":   true,
+	"pinned":      true,
+	"is_template": true,
+}
+
+// doltJSONColumns are issues columns bd stores as JSON text and exports as
+// JSON values.
+var doltJSONColumns = map[string]bool{
+	"waiters": true,
+}
+
+// read loads the database's issues as the records bd exports to JSONL.
+func (d *doltServer) read() (*jsonlFile, error) {
+	rows, err := d.query("SELECT * FROM issues")
+	if err != nil {
+		return nil, err
+	}
+	labels, err := d.query("SELECT issue_id, label FROM labels")
+	if err != nil {
+		return nil, err
+	}
+	deps, err := d.query("SELECT issue_id, depends_on_id, type, created_at, created_by FROM dependencies")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &jsonlFile{index: make(map[string]int)}
+	for _, row := range rows {
+		f.add(issueRecord(row))
+	}
+	labelsOf := make(map[string][]string)
+	for _, row := range labels {
+		id := row.str("issue_id")
+		labelsOf[id] = append(labelsOf[id], row.str("label"))
+	}
+	depsOf := make(map[string][]jsonlDep)
+	for _, row := range deps {
+		dep := jsonlDep{
+			IssueID:     row.str("issue_id"),
+			DependsOnID: row.str("depends_on_id"),
+			Type:        row.str("type"),
+			CreatedAt:   row.str("created_at"),
+			CreatedBy:   row.str("created_by"),
+		}
+		depsOf[dep.IssueID] = append(depsOf[dep.IssueID], dep)
+	}
+	for _, r := range f.records {
+		id := r.str("id")
+		if l := labelsOf[id]; len(l) > 0 {
+			sort.Strings(l)
+			r.set("labels", l)
+		}
+		r.setDeps(depsOf[id])
+	}
+
+	if rows, err := d.query("SELECT `value` FROM config WHERE `key` = 'issue_prefix'"); err == nil && len(rows) == 1 {
+		f.prefix = strings.TrimSuffix(rows[0].str("value"), "-")
+	}
+	return f, nil
+}
+
+// issueRecord converts an issues row to the record bd exports for it,
+// which omits empty columns.
+func issueRecord(row record) record {
+	r := make(record, len(row))
+	for col, v := range row {
+		switch s := string(v); {
+		case s == "null" || s == `""`:
+			continue
+		case doltBoolColumns[col]:
+			if s == "0" || s == "false" {
+				continue
+			}
+			r.set(col, true)
+		case doltJSONColumns[col]:
+			var text string
+			if json.Unmarshal(v, &text) == nil && json.Valid([]byte(text)) {
+				r[col] = json.RawMessage(text)
+			} else {
+				r[col] = v
+			}
+		default:
+			r[col] = v
+		}
+	}
+	return r
+}
+
+// write loads the issues, applies fn and saves the rows it changed.
+func (d *doltServer) write(fn func(f *jsonlFile) error) error {
+	before, err := d.read()
+	if err != nil {
+		return err
+	}
+	after := before.clone()
+	if err := fn(after); err != nil {
+		return err
+	}
+
+	columns, err := d.query("SHOW COLUMNS FROM issues")
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[c.str("Field")] = true
+	}
+
+	stmts, err := doltStatements(before, after, known)
+	if err != nil {
+		return fmt.Errorf("%s: %w", d, err)
+	}
+	if len(stmts) == 0 {
+		return nil
+	}
+	query := "START TRANSACTION;\n" + strings.Join(stmts, ";\n") + ";\nCOMMIT;"
+	_, err = d.sql(query, "")
+	return err
+}
+
+// clone returns a copy of f whose records can be changed without touching f.
+func (f *jsonlFile) clone() *jsonlFile {
+	c := &jsonlFile{prefix: f.prefix, index: make(map[string]int, len(f.records))}
+	for _, r := range f.records {
+		copied := make(record, len(r))
+		for k, v := range r {
+			copied[k] = v
+		}
+		c.add(copied)
+	}
+	return c
+}
+
+// doltStatements returns the SQL that turns the before records into the
+// after records. known holds the issues table's columns; setting any other
+// field is an error rather than a silently dropped change.
+func doltStatements(before, after *jsonlFile, known map[string]bool) ([]string, error) {
+	var stmts []string
+	for _, old := range before.records {
+		id := old.str("id")
+		if after.get(id) == nil {
+			stmts = append(stmts,
+				"DELETE FROM dependencies WHERE issue_id = "+sqlString(id),
+				"DELETE FROM labels WHERE issue_id = "+sqlString(id),
+				"DELETE FROM issues WHERE id = "+sqlString(id))
+		}
+	}
+
+	for _, r := range after.records {
+		id := r.str("id")
+		old := before.get(id)
+		var cols []string
+		for col := range r {
+			if col == "labels" || col == "dependencies" {
+				continue
+			}
+			if !known[col] {
+				return nil, fmt.Errorf("issues table has no column %q for %s", col, id)
+			}
+			if old == nil || !bytes.Equal(old[col], r[col]) {
+				cols = append(cols, col)
+			}
+		}
+		sort.Strings(cols)
+
+		if old == nil {
+			values := make([]string, len(cols))
+			for i, col := range cols {
+				values[i] = sqlValue(col, r[col])
+			}
+			stmts = append(stmts, fmt.Sprintf("INSERT INTO issues (%s) VALUES (%s)",
+				sqlColumns(cols), strings.Join(values, ", ")))
+			old = record{}
+		} else {
+			var removed []string
+			for col := range old {
+				if _, ok := r[col]; !ok && col != "labels" && col != "dependencies" {
+					removed = append(removed, col)
+				}
+			}
+			sort.Strings(removed)
+			var sets []string
+			for _, col := range cols {
+				sets = append(sets, fmt.Sprintf("`%s` = %s", col, sqlValue(col, r[col])))
+			}
+			for _, col := range removed {
+				sets = append(sets, fmt.Sprintf("`%s` = DEFAULT", col))
+			}
+			if len(sets) > 0 {
+				stmts = append(stmts, fmt.Sprintf("UPDATE issues SET %s WHERE id = %s",
+					strings.Join(sets, ", "), sqlString(id)))
+			}
+		}
+
+		oldLabels, newLabels := stringSet(old.strings("labels")), stringSet(r.strings("labels"))
+		for _, l := range sortedKeys(oldLabels) {
+			if !newLabels[l] {
+				stmts = append(stmts, fmt.Sprintf("DELETE FROM labels WHERE issue_id = %s AND label = %s", sqlString(id), sqlString(l)))
+			}
+		}
+		for _, l := range sortedKeys(newLabels) {
+			if !oldLabels[l] {
+				stmts = append(stmts, fmt.Sprintf("INSERT INTO labels (issue_id, label) VALUES (%s, %s)", sqlString(id), sqlString(l)))
+			}
+		}
+
+		depKey := func(d jsonlDep) string { return d.DependsOnID + "\x00" + d.Type }
+		oldDeps := make(map[string]bool)
+		for _, d := range old.deps() {
+			oldDeps[depKey(d)] = true
+		}
+		newDeps := make(map[string]bool)
+		for _, d := range r.deps() {
+			newDeps[depKey(d)] = true
+		}
+		for _, d := range old.deps() {
+			if !newDeps[depKey(d)] {
+				stmts = append(stmts, fmt.Sprintf("DELETE FROM dependencies WHERE issue_id = %s AND depends_on_id = %s AND type = %s",
+					sqlString(id), sqlString(d.DependsOnID), sqlString(d.Type)))
+			}
+		}
+		for _, d := range r.deps() {
+			if !oldDeps[depKey(d)] {
+				stmts = append(stmts, fmt.Sprintf("INSERT INTO dependencies (issue_id, depends_on_id, type, created_at, created_by) VALUES (%s, %s, %s, %s, %s)",
+					sqlString(id), sqlString(d.DependsOnID), sqlString(d.Type), sqlTime(d.CreatedAt), sqlString(d.CreatedBy)))
+			}
+		}
+	}
+	return stmts, nil
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sqlColumns(cols []string) string {
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = "`" + col + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlValue renders a record field as a SQL literal for column col.
+func sqlValue(col string, v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		if strings.HasSuffix(col, "_at") {
+			return sqlTime(s)
+		}
+		return sqlString(s)
+	}
+	switch string(v) {
+	case "null":
+		return "NULL"
+	case "true":
+		return "TRUE"
+	case "false":
+		return "FALSE"
+	}
+	if _, err := strconv.ParseFloat(string(v), 64); err == nil {
+		return string(v)
+	}
+	// Arrays and objects are stored as JSON text.
+	return sqlString(string(v))
+}
+
+// sqlTime renders a timestamp for a DATETIME column. RFC 3339 times, as this
+// package writes them, are converted; anything else is passed through.
+func sqlTime(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		s = t.UTC().Format("2006-01-02 15:04:05.999999")
+	}
+	return sqlString(s)
+}
+
+var sqlEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`)
+
+func sqlString(s string) string {
+	return "'" + sqlEscaper.Replace(s) + "'"
+}
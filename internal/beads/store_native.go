@@ -0,0 +1,1044 @@
+package beads
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nativeStore implements Store directly on a beads directory's issues: in
+// issues.jsonl, the same file bd reads in no-db mode, or in the tables of a
+// directory bd runs in Dolt server mode, through the Dolt SQL server (see
+// doltServer). Fields this package does not know about are preserved.
+//
+// issues.jsonl is only ever read. bd owns that file and takes no lock on it,
+// so writes to a no-db directory go through bd (see jsonlReadStore) and a
+// native write there fails with ErrBDManaged.
+//
+// IDs are routed like bd does: an issue whose prefix belongs to another rig
+// (per routes.jsonl) is read from and written to that rig's beads directory.
+type nativeStore struct {
+	beadsDir string
+	townRoot string
+	actor    string
+	now      func() time.Time
+	// table returns where a beads directory's issues live; defaults to
+	// defaultTable.
+	table func(dir string) issueTable
+}
+
+// newNativeStore returns a native store for beadsDir.
+func newNativeStore(beadsDir, townRoot, actor string) *nativeStore {
+	s := &nativeStore{beadsDir: beadsDir, townRoot: townRoot, actor: actor, now: time.Now}
+	s.table = s.defaultTable
+	return s
+}
+
+// issueTable holds the issues of one beads directory.
+type issueTable interface {
+	// read loads every issue.
+	read() (*jsonlFile, error)
+	// write loads every issue, applies fn and saves what it changed.
+	write(fn func(f *jsonlFile) error) error
+}
+
+// defaultTable returns the Dolt SQL server for a Dolt server mode directory
+// and issues.jsonl for anything else.
+func (s *nativeStore) defaultTable(dir string) issueTable {
+	if srv := doltServerFor(dir, s.townRoot); srv != nil {
+		return srv
+	}
+	if jsonlWritesForTesting {
+		return writableJSONLTable(filepath.Join(dir, "issues.jsonl"))
+	}
+	return jsonlTable(filepath.Join(dir, "issues.jsonl"))
+}
+
+// Dependency types with special meaning.
+const (
+	depBlocks      = "blocks"
+	depParentChild = "parent-child"
+)
+
+// Issue statuses the store interprets.
+const (
+	statusOpen      = "open"
+	statusClosed    = "closed"
+	statusTombstone = "tombstone"
+)
+
+// record is one JSONL line, kept as raw fields so rewriting the file never
+// drops data written by bd.
+type record map[string]json.RawMessage
+
+// jsonlDep is a dependency as bd stores it in JSONL.
+type jsonlDep struct {
+	IssueID     string `json:"issue_id"`
+	DependsOnID string `json:"depends_on_id"`
+	Type        string `json:"type"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty"`
+}
+
+func (r record) str(key string) string {
+	var s string
+	if raw, ok := r[key]; ok {
+		_ = json.Unmarshal(raw, &s)
+	}
+	return s
+}
+
+func (r record) strings(key string) []string {
+	var out []string
+	if raw, ok := r[key]; ok {
+		_ = json.Unmarshal(raw, &out)
+	}
+	return out
+}
+
+func (r record) priority() int {
+	var p int
+	if raw, ok := r["priority"]; ok {
+		_ = json.Unmarshal(raw, &p)
+	}
+	return p
+}
+
+func (r record) set(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	r[key] = data
+}
+
+// setOrDelete sets a string field, removing it when empty.
+func (r record) setOrDelete(key, value string) {
+	if value == "" {
+		delete(r, key)
+		return
+	}
+	r.set(key, value)
+}
+
+func (r record) deps() []jsonlDep {
+	var deps []jsonlDep
+	if raw, ok := r["dependencies"]; ok {
+		_ = json.Unmarshal(raw, &deps)
+	}
+	return deps
+}
+
+func (r record) setDeps(deps []jsonlDep) {
+	if len(deps) == 0 {
+		delete(r, "dependencies")
+		return
+	}
+	r.set("dependencies", deps)
+}
+
+func (r record) hasLabel(label string) bool {
+	for _, l := range r.strings("labels") {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (r record) live() bool {
+	return r.str("status") != statusTombstone
+}
+
+// jsonlFile is a loaded set of issues, as issues.jsonl holds them.
+type jsonlFile struct {
+	records []record
+	index   map[string]int
+
+	// prefix is the database's issue prefix when it records one.
+	prefix string
+
+	// dependents maps an ID to the deps that point at it, built on demand.
+	dependents map[string][]jsonlDep
+}
+
+func loadJSONL(path string) (*jsonlFile, error) {
+	f := &jsonlFile{index: make(map[string]int)}
+	file, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		r := make(record)
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		f.add(r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *jsonlFile) get(id string) record {
+	if i, ok := f.index[id]; ok {
+		return f.records[i]
+	}
+	return nil
+}
+
+func (f *jsonlFile) add(r record) {
+	f.index[r.str("id")] = len(f.records)
+	f.records = append(f.records, r)
+	f.dependents = nil
+}
+
+func (f *jsonlFile) remove(id string) {
+	i, ok := f.index[id]
+	if !ok {
+		return
+	}
+	f.records = append(f.records[:i], f.records[i+1:]...)
+	f.index = make(map[string]int, len(f.records))
+	for j, r := range f.records {
+		f.index[r.str("id")] = j
+	}
+	f.dependents = nil
+}
+
+func (f *jsonlFile) dependentsOf(id string) []jsonlDep {
+	if f.dependents == nil {
+		f.dependents = make(map[string][]jsonlDep)
+		for _, r := range f.records {
+			if !r.live() {
+				continue
+			}
+			for _, d := range r.deps() {
+				target := externalID(d.DependsOnID)
+				f.dependents[target] = append(f.dependents[target], d)
+			}
+		}
+	}
+	return f.dependents[id]
+}
+
+// externalID strips bd's cross-rig reference form "external:<prefix>:<id>".
+func externalID(id string) string {
+	if parts := strings.SplitN(id, ":", 3); len(parts) == 3 && parts[0] == "external" {
+		return parts[2]
+	}
+	return id
+}
+
+// dirFor returns the beads directory an ID routes to.
+func (s *nativeStore) dirFor(id string) string {
+	return ResolveRoutingTarget(s.townRoot, externalID(id), s.beadsDir)
+}
+
+// jsonlTable is a no-db directory's issues.jsonl, read-only.
+type jsonlTable string
+
+func (t jsonlTable) read() (*jsonlFile, error) {
+	return loadJSONL(string(t))
+}
+
+func (t jsonlTable) write(func(f *jsonlFile) error) error {
+	return fmt.Errorf("%w: %s", ErrBDManaged, filepath.Dir(string(t)))
+}
+
+// writableJSONLTable is issues.jsonl written in place, for tests that run
+// without bd (see WriteJSONLForTesting).
+type writableJSONLTable string
+
+func (t writableJSONLTable) read() (*jsonlFile, error) {
+	return loadJSONL(string(t))
+}
+
+func (t writableJSONLTable) write(fn func(f *jsonlFile) error) error {
+	f, err := loadJSONL(string(t))
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		return err
+	}
+	return f.save(string(t))
+}
+
+// save writes the issues to path atomically, sorted by ID as bd exports
+// them.
+func (f *jsonlFile) save(path string) error {
+	sorted := append([]record(nil), f.records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].str("id") < sorted[j].str("id") })
+
+	var buf bytes.Buffer
+	for _, r := range sorted {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".issues-*.jsonl.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// read loads dir's issues.
+func (s *nativeStore) read(dir string) (*jsonlFile, error) {
+	return s.table(dir).read()
+}
+
+// write loads dir's issues, applies fn and saves the result.
+func (s *nativeStore) write(dir string, fn func(f *jsonlFile) error) error {
+	return s.table(dir).write(fn)
+}
+
+// modify applies fn to the live issue id in the directory it routes to.
+func (s *nativeStore) modify(id string, fn func(r record) error) error {
+	return s.write(s.dirFor(id), func(f *jsonlFile) error {
+		r := f.get(id)
+		if r == nil || !r.live() {
+			return ErrNotFound
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+		r.set("updated_at", s.timestamp())
+		f.dependents = nil
+		return nil
+	})
+}
+
+func (s *nativeStore) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339Nano)
+}
+
+// resolver loads issues across routed directories, caching each file for
+// the duration of one call.
+type resolver struct {
+	s     *nativeStore
+	files map[string]*jsonlFile
+}
+
+func (s *nativeStore) resolver(local *jsonlFile) *resolver {
+	rv := &resolver{s: s, files: make(map[string]*jsonlFile)}
+	if local != nil {
+		rv.files[s.beadsDir] = local
+	}
+	return rv
+}
+
+func (rv *resolver) file(dir string) *jsonlFile {
+	if f, ok := rv.files[dir]; ok {
+		return f
+	}
+	f, err := rv.s.read(dir)
+	if err != nil {
+		f = &jsonlFile{index: map[string]int{}}
+	}
+	rv.files[dir] = f
+	return f
+}
+
+func (rv *resolver) get(id string) (record, *jsonlFile) {
+	id = externalID(id)
+	f := rv.file(rv.s.dirFor(id))
+	r := f.get(id)
+	if r == nil || !r.live() {
+		return nil, f
+	}
+	return r, f
+}
+
+// issue converts a record to an Issue with dependency details filled in.
+func (rv *resolver) issue(r record, f *jsonlFile) *Issue {
+	plain := make(record, len(r))
+	for k, v := range r {
+		if k != "dependencies" {
+			plain[k] = v
+		}
+	}
+	data, _ := json.Marshal(plain)
+	var issue Issue
+	_ = json.Unmarshal(data, &issue)
+
+	for _, d := range r.deps() {
+		targetID := externalID(d.DependsOnID)
+		dep := IssueDep{ID: targetID, DependencyType: d.Type}
+		target, _ := rv.get(targetID)
+		if target != nil {
+			dep.Title = target.str("title")
+			dep.Status = target.str("status")
+			dep.Priority = target.priority()
+			dep.Type = target.str("issue_type")
+		}
+		issue.Dependencies = append(issue.Dependencies, dep)
+		switch d.Type {
+		case depParentChild:
+			issue.Parent = targetID
+		case depBlocks, "":
+			issue.DependsOn = append(issue.DependsOn, targetID)
+			if target != nil && target.str("status") != statusClosed {
+				issue.BlockedBy = append(issue.BlockedBy, targetID)
+			}
+		}
+	}
+
+	for _, d := range f.dependentsOf(issue.ID) {
+		dep := IssueDep{ID: d.IssueID, DependencyType: d.Type}
+		if src := f.get(d.IssueID); src != nil {
+			dep.Title = src.str("title")
+			dep.Status = src.str("status")
+			dep.Priority = src.priority()
+			dep.Type = src.str("issue_type")
+		}
+		issue.Dependents = append(issue.Dependents, dep)
+		switch d.Type {
+		case depParentChild:
+			issue.Children = append(issue.Children, d.IssueID)
+		case depBlocks, "":
+			issue.Blocks = append(issue.Blocks, d.IssueID)
+		}
+	}
+
+	issue.DependencyCount = len(issue.Dependencies)
+	issue.DependentCount = len(issue.Dependents)
+	issue.BlockedByCount = len(issue.BlockedBy)
+	return &issue
+}
+
+// blocked reports whether r has an unresolved blocking dependency.
+func (rv *resolver) blocked(r record) bool {
+	for _, d := range r.deps() {
+		if d.Type != depBlocks && d.Type != "" {
+			continue
+		}
+		if target, _ := rv.get(d.DependsOnID); target != nil && target.str("status") != statusClosed {
+			return true
+		}
+	}
+	return false
+}
+
+func (rv *resolver) parentOf(r record) string {
+	for _, d := range r.deps() {
+		if d.Type == depParentChild {
+			return externalID(d.DependsOnID)
+		}
+	}
+	return ""
+}
+
+// sortIssues orders by priority, then creation time (oldest first).
+func sortIssues(issues []*Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Priority != issues[j].Priority {
+			return issues[i].Priority < issues[j].Priority
+		}
+		return issues[i].CreatedAt < issues[j].CreatedAt
+	})
+}
+
+func (s *nativeStore) List(opts ListOptions) ([]*Issue, error) {
+	f, err := s.read(s.beadsDir)
+	if err != nil {
+		return nil, err
+	}
+	rv := s.resolver(f)
+
+	labels := append([]string(nil), opts.Labels...)
+	if opts.Label != "" {
+		labels = append(labels, opts.Label)
+	} else if opts.Type != "" {
+		labels = append(labels, "gt:"+opts.Type)
+	}
+
+	var issues []*Issue
+	for _, r := range f.records {
+		status := r.str("status")
+		switch {
+		case !r.live():
+			continue
+		case opts.Status == "":
+			if status == statusClosed {
+				continue
+			}
+		case opts.Status != "all" && status != opts.Status:
+			continue
+		}
+		if opts.IssueType != "" && r.str("issue_type") != opts.IssueType {
+			continue
+		}
+		if opts.Priority >= 0 && r.priority() != opts.Priority {
+			continue
+		}
+		if opts.Assignee != "" && r.str("assignee") != opts.Assignee {
+			continue
+		}
+		if opts.NoAssignee && r.str("assignee") != "" {
+			continue
+		}
+		if opts.Parent != "" && rv.parentOf(r) != opts.Parent {
+			continue
+		}
+		matched := true
+		for _, l := range labels {
+			if !r.hasLabel(l) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		issues = append(issues, rv.issue(r, f))
+	}
+
+	if opts.SortByCreated {
+		sort.SliceStable(issues, func(i, j int) bool { return issues[i].CreatedAt < issues[j].CreatedAt })
+	} else {
+		sortIssues(issues)
+	}
+	return issues, nil
+}
+
+func (s *nativeStore) Show(ids ...string) ([]*Issue, error) {
+	rv := s.resolver(nil)
+	var issues []*Issue
+	for _, id := range ids {
+		r, f := rv.get(id)
+		if r == nil {
+			continue
+		}
+		issues = append(issues, rv.issue(r, f))
+	}
+	if len(ids) == 1 && len(issues) == 0 {
+		return nil, ErrNotFound
+	}
+	return issues, nil
+}
+
+func (s *nativeStore) Ready(opts ReadyOptions) ([]*Issue, error) {
+	f, err := s.read(s.beadsDir)
+	if err != nil {
+		return nil, err
+	}
+	rv := s.resolver(f)
+
+	var issues []*Issue
+	for _, r := range f.records {
+		if r.str("status") != statusOpen || rv.blocked(r) {
+			continue
+		}
+		if opts.Label != "" && !r.hasLabel(opts.Label) {
+			continue
+		}
+		issues = append(issues, rv.issue(r, f))
+	}
+	sortIssues(issues)
+	if opts.Limit > 0 && len(issues) > opts.Limit {
+		issues = issues[:opts.Limit]
+	}
+	return issues, nil
+}
+
+func (s *nativeStore) Blocked() ([]*Issue, error) {
+	f, err := s.read(s.beadsDir)
+	if err != nil {
+		return nil, err
+	}
+	rv := s.resolver(f)
+
+	var issues []*Issue
+	for _, r := range f.records {
+		status := r.str("status")
+		if !r.live() || status == statusClosed || !rv.blocked(r) {
+			continue
+		}
+		issues = append(issues, rv.issue(r, f))
+	}
+	sortIssues(issues)
+	return issues, nil
+}
+
+func (s *nativeStore) Create(issue NewIssue) (*Issue, error) {
+	dir := s.beadsDir
+	if issue.ID != "" {
+		dir = s.dirFor(issue.ID)
+	}
+
+	var r record
+	err := s.write(dir, func(f *jsonlFile) error {
+		id := issue.ID
+		switch {
+		case id != "":
+			if f.get(id) != nil {
+				return fmt.Errorf("UNIQUE constraint failed: issues.id (%s)", id)
+			}
+		case issue.Parent != "":
+			id = nextChildID(f, issue.Parent)
+		default:
+			var err error
+			if id, err = generateID(f, dir); err != nil {
+				return err
+			}
+		}
+
+		now := s.timestamp()
+		r = make(record)
+		r.set("id", id)
+		r.set("title", issue.Title)
+		r.setOrDelete("description", issue.Description)
+		r.set("status", statusOpen)
+		priority := issue.Priority
+		if priority < 0 {
+			priority = 2
+		}
+		r.set("priority", priority)
+		issueType := issue.IssueType
+		if issueType == "" {
+			issueType = "task"
+		}
+		r.set("issue_type", issueType)
+		r.setOrDelete("role_type", issue.RoleType)
+		if len(issue.Labels) > 0 {
+			r.set("labels", uniqueStrings(issue.Labels))
+		}
+		r.set("created_at", now)
+		r.set("updated_at", now)
+		actor := issue.Actor
+		if actor == "" {
+			actor = s.actor
+		}
+		r.setOrDelete("created_by", actor)
+		if issue.Ephemeral {
+			r.set("ephemeral", true)
+		}
+		if issue.Parent != "" {
+			r.setDeps([]jsonlDep{{IssueID: id, DependsOnID: issue.Parent, Type: depParentChild, CreatedAt: now, CreatedBy: actor}})
+		}
+		f.add(r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.resolver(nil).issue(r, &jsonlFile{index: map[string]int{}}), nil
+}
+
+// nextChildID returns the next hierarchical ID under parent (parent.N).
+func nextChildID(f *jsonlFile, parent string) string {
+	n := 0
+	for _, r := range f.records {
+		rest, ok := strings.CutPrefix(r.str("id"), parent+".")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.Atoi(rest); err == nil && v > n {
+			n = v
+		}
+	}
+	return fmt.Sprintf("%s.%d", parent, n+1)
+}
+
+// generateID returns an unused <prefix>-<random> ID for a new issue.
+func generateID(f *jsonlFile, dir string) (string, error) {
+	prefix := issuePrefix(f, dir)
+	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	for length := 4; length <= 8; length++ {
+		for attempt := 0; attempt < 10; attempt++ {
+			suffix := make([]byte, length)
+			for i := range suffix {
+				n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+				if err != nil {
+					return "", err
+				}
+				suffix[i] = alphabet[n.Int64()]
+			}
+			if id := prefix + "-" + string(suffix); f.get(id) == nil {
+				return id, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("could not generate a unique issue ID")
+}
+
+// issuePrefix returns the database's issue prefix: the one it records,
+// else issue-prefix from config.yaml, else the prefix of existing issues,
+// else the directory name.
+func issuePrefix(f *jsonlFile, dir string) string {
+	if f.prefix != "" {
+		return f.prefix
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "config.yaml")); err == nil { //nolint:gosec // G304: path is constructed internally
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if v, ok := strings.CutPrefix(line, "issue-prefix:"); ok {
+				if v = strings.Trim(strings.TrimSpace(v), `"'`); v != "" {
+					return strings.TrimSuffix(v, "-")
+				}
+			}
+		}
+	}
+	counts := make(map[string]int)
+	best := ""
+	for _, r := range f.records {
+		id := r.str("id")
+		if i := strings.Index(id, "-"); i > 0 {
+			p := id[:i]
+			counts[p]++
+			if counts[p] > counts[best] {
+				best = p
+			}
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return filepath.Base(filepath.Dir(dir))
+}
+
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (s *nativeStore) Update(id string, opts UpdateOptions) error {
+	return s.modify(id, func(r record) error {
+		if opts.Title != nil {
+			r.set("title", *opts.Title)
+		}
+		if opts.Status != nil {
+			r.set("status", *opts.Status)
+			if *opts.Status == statusClosed {
+				r.set("closed_at", s.timestamp())
+			} else {
+				delete(r, "closed_at")
+			}
+		}
+		if opts.Priority != nil {
+			r.set("priority", *opts.Priority)
+		}
+		if opts.Description != nil {
+			r.setOrDelete("description", *opts.Description)
+		}
+		if opts.Assignee != nil {
+			r.setOrDelete("assignee", *opts.Assignee)
+		}
+		if opts.Notes != nil {
+			r.setOrDelete("notes", *opts.Notes)
+		}
+
+		labels := r.strings("labels")
+		if len(opts.SetLabels) > 0 {
+			labels = opts.SetLabels
+		} else {
+			labels = append(labels, opts.AddLabels...)
+			if len(opts.RemoveLabels) > 0 {
+				remove := make(map[string]bool, len(opts.RemoveLabels))
+				for _, l := range opts.RemoveLabels {
+					remove[l] = true
+				}
+				kept := labels[:0:0]
+				for _, l := range labels {
+					if !remove[l] {
+						kept = append(kept, l)
+					}
+				}
+				labels = kept
+			}
+		}
+		if labels = uniqueStrings(labels); len(labels) > 0 {
+			r.set("labels", labels)
+		} else {
+			delete(r, "labels")
+		}
+		return nil
+	})
+}
+
+func (s *nativeStore) Close(opts CloseOptions, ids ...string) error {
+	for _, id := range ids {
+		err := s.modify(id, func(r record) error {
+			r.set("status", statusClosed)
+			r.set("closed_at", s.timestamp())
+			r.setOrDelete("close_reason", opts.Reason)
+			r.setOrDelete("closed_by_session", opts.Session)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("closing %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *nativeStore) Reopen(id, reason string) error {
+	return s.modify(id, func(r record) error {
+		if r.str("status") != statusClosed {
+			return fmt.Errorf("%s is already open", id)
+		}
+		r.set("status", statusOpen)
+		delete(r, "closed_at")
+		delete(r, "close_reason")
+		delete(r, "closed_by_session")
+		return nil
+	})
+}
+
+func (s *nativeStore) Delete(id string) error {
+	return s.write(s.dirFor(id), func(f *jsonlFile) error {
+		if f.get(id) == nil {
+			return ErrNotFound
+		}
+		f.remove(id)
+		// Drop dependencies on the deleted issue so nothing stays blocked on it.
+		for _, r := range f.records {
+			deps := r.deps()
+			kept := deps[:0]
+			for _, d := range deps {
+				if externalID(d.DependsOnID) != id {
+					kept = append(kept, d)
+				}
+			}
+			if len(kept) != len(deps) {
+				r.setDeps(kept)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *nativeStore) AddDependency(issue, dependsOn, depType string) error {
+	if depType == "" {
+		depType = depBlocks
+	}
+	if target, _ := s.resolver(nil).get(dependsOn); target == nil {
+		return ErrNotFound
+	}
+	return s.modify(issue, func(r record) error {
+		deps := r.deps()
+		for _, d := range deps {
+			if externalID(d.DependsOnID) == externalID(dependsOn) && d.Type == depType {
+				return nil
+			}
+		}
+		r.setDeps(append(deps, jsonlDep{
+			IssueID:     issue,
+			DependsOnID: dependsOn,
+			Type:        depType,
+			CreatedAt:   s.timestamp(),
+			CreatedBy:   s.actor,
+		}))
+		return nil
+	})
+}
+
+func (s *nativeStore) RemoveDependency(issue, dependsOn string) error {
+	return s.modify(issue, func(r record) error {
+		deps := r.deps()
+		kept := deps[:0]
+		for _, d := range deps {
+			if externalID(d.DependsOnID) != externalID(dependsOn) {
+				kept = append(kept, d)
+			}
+		}
+		r.setDeps(kept)
+		return nil
+	})
+}
+
+func (s *nativeStore) SetAgentState(id, state string) error {
+	return s.modify(id, func(r record) error {
+		r.set("agent_state", state)
+		return nil
+	})
+}
+
+// slotField maps a bd slot name to the issue field that stores it.
+func slotField(slot string) string {
+	switch slot {
+	case "hook":
+		return "hook_bead"
+	case "role":
+		return "role_bead"
+	}
+	return slot
+}
+
+func (s *nativeStore) SetSlot(id, slot, value string) error {
+	return s.modify(id, func(r record) error {
+		field := slotField(slot)
+		if current := r.str(field); current != "" && current != value {
+			return fmt.Errorf("slot %s on %s already occupied by %s", slot, id, current)
+		}
+		r.set(field, value)
+		return nil
+	})
+}
+
+func (s *nativeStore) ClearSlot(id, slot string) error {
+	return s.modify(id, func(r record) error {
+		delete(r, slotField(slot))
+		return nil
+	})
+}
+
+func (s *nativeStore) GetSlot(id, slot string) (string, error) {
+	r, _ := s.resolver(nil).get(id)
+	if r == nil {
+		return "", ErrNotFound
+	}
+	return r.str(slotField(slot)), nil
+}
+
+func (s *nativeStore) AddGateWaiter(gateID, waiter string) error {
+	return s.modify(gateID, func(r record) error {
+		r.set("waiters", uniqueStrings(append(r.strings("waiters"), waiter)))
+		return nil
+	})
+}
+
+// mergeSlotID returns the ID of the merge slot bead for this database.
+func (s *nativeStore) mergeSlotID() (string, error) {
+	f, err := s.read(s.beadsDir)
+	if err != nil {
+		return "", err
+	}
+	return issuePrefix(f, s.beadsDir) + "-merge-slot", nil
+}
+
+func mergeSlotStatus(r record) *MergeSlotStatus {
+	holder := r.str("holder")
+	return &MergeSlotStatus{
+		ID:        r.str("id"),
+		Available: holder == "",
+		Holder:    holder,
+		Waiters:   r.strings("waiters"),
+	}
+}
+
+func (s *nativeStore) MergeSlotCreate() (string, error) {
+	id, err := s.mergeSlotID()
+	if err != nil {
+		return "", err
+	}
+	err = s.write(s.beadsDir, func(f *jsonlFile) error {
+		if r := f.get(id); r != nil && r.live() {
+			return nil
+		}
+		f.remove(id)
+		now := s.timestamp()
+		r := make(record)
+		r.set("id", id)
+		r.set("title", "Merge slot")
+		r.set("status", statusOpen)
+		r.set("priority", 2)
+		r.set("issue_type", "task")
+		r.set("labels", []string{"gt:slot"})
+		r.set("created_at", now)
+		r.set("updated_at", now)
+		f.add(r)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *nativeStore) MergeSlotCheck() (*MergeSlotStatus, error) {
+	id, err := s.mergeSlotID()
+	if err != nil {
+		return nil, err
+	}
+	r, _ := s.resolver(nil).get(id)
+	if r == nil {
+		return nil, ErrNotFound
+	}
+	return mergeSlotStatus(r), nil
+}
+
+func (s *nativeStore) MergeSlotAcquire(holder string, wait bool) (*MergeSlotStatus, error) {
+	id, err := s.mergeSlotID()
+	if err != nil {
+		return nil, err
+	}
+	if holder == "" {
+		holder = s.actor
+	}
+	var status *MergeSlotStatus
+	err = s.modify(id, func(r record) error {
+		current := r.str("holder")
+		if current == "" || current == holder {
+			r.set("holder", holder)
+			r.set("status", "in_progress")
+		} else if wait {
+			r.set("waiters", uniqueStrings(append(r.strings("waiters"), holder)))
+		}
+		status = mergeSlotStatus(r)
+		return nil
+	})
+	return status, err
+}
+
+func (s *nativeStore) MergeSlotRelease(holder string) error {
+	id, err := s.mergeSlotID()
+	if err != nil {
+		return err
+	}
+	return s.modify(id, func(r record) error {
+		current := r.str("holder")
+		if holder != "" && current != "" && current != holder {
+			return fmt.Errorf("slot release failed: held by %s, not %s", current, holder)
+		}
+		delete(r, "holder")
+		r.set("status", statusOpen)
+		return nil
+	})
+}
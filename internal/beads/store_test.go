@@ -0,0 +1,625 @@
+package beads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storeFactory returns a Store on a fresh, empty database with prefix "ts".
+type storeFactory func(t *testing.T) Store
+
+// memTable keeps a directory's issues in memory, so the native store's
+// logic can be tested without a Dolt server.
+type memTable struct {
+	mu sync.Mutex
+	f  *jsonlFile
+}
+
+func (m *memTable) read() (*jsonlFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.f.clone(), nil
+}
+
+func (m *memTable) write(fn func(f *jsonlFile) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f := m.f.clone()
+	if err := fn(f); err != nil {
+		return err
+	}
+	m.f = f
+	return nil
+}
+
+// newTestBeadsDir returns an empty beads directory with prefix "ts".
+func newTestBeadsDir(t *testing.T) string {
+	t.Helper()
+	beadsDir := filepath.Join(t.TempDir(), ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(beadsDir, "config.yaml"), []byte("issue-prefix: ts\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return beadsDir
+}
+
+func newTestNativeStore(t *testing.T) Store {
+	t.Helper()
+	s := newNativeStore(newTestBeadsDir(t), "", "tester")
+	tables := make(map[string]*memTable)
+	s.table = func(dir string) issueTable {
+		if tables[dir] == nil {
+			tables[dir] = &memTable{f: &jsonlFile{index: map[string]int{}}}
+		}
+		return tables[dir]
+	}
+	return s
+}
+
+func newTestBDStore(t *testing.T) Store {
+	t.Helper()
+	if _, err := exec.LookPath("bd"); err != nil {
+		t.Skip("bd not installed")
+	}
+	b := NewIsolated(t.TempDir())
+	if err := b.Init("ts"); err != nil {
+		t.Skipf("bd init: %v", err)
+	}
+	return &bdStore{b: b}
+}
+
+// testDoltSchema is the part of bd's Dolt schema the native store touches.
+const testDoltSchema = `
+CREATE TABLE issues (
+	id VARCHAR(255) PRIMARY KEY,
+	title VARCHAR(500) NOT NULL,
+	description TEXT,
+	notes TEXT,
+	status VARCHAR(32) NOT NULL DEFAULT 'open',
+	priority INT NOT NULL DEFAULT 2,
+	issue_type VARCHAR(32) NOT NULL DEFAULT 'task',
+	assignee VARCHAR(255),
+	created_at DATETIME(6),
+	created_by VARCHAR(255),
+	updated_at DATETIME(6),
+	closed_at DATETIME(6),
+	close_reason TEXT,
+	closed_by_session VARCHAR(255),
+	ephemeral TINYINT(1) DEFAULT 0,
+	role_type VARCHAR(32),
+	agent_state VARCHAR(32),
+	hook_bead VARCHAR(255),
+	role_bead VARCHAR(255),
+	holder VARCHAR(255),
+	waiters TEXT
+);
+CREATE TABLE labels (
+	issue_id VARCHAR(255) NOT NULL,
+	label VARCHAR(255) NOT NULL,
+	PRIMARY KEY (issue_id, label)
+);
+CREATE TABLE dependencies (
+	issue_id VARCHAR(255) NOT NULL,
+	depends_on_id VARCHAR(255) NOT NULL,
+	type VARCHAR(32) NOT NULL DEFAULT 'blocks',
+	created_at DATETIME(6),
+	created_by VARCHAR(255),
+	PRIMARY KEY (issue_id, depends_on_id)
+);
+CREATE TABLE config (` + "`key`" + ` VARCHAR(255) PRIMARY KEY, value TEXT);
+INSERT INTO config VALUES ('issue_prefix', 'ts');
+`
+
+// newTestDoltStore serves a fresh database with bd's schema from a Dolt SQL
+// server and returns a native store on a beads directory bd would run in
+// Dolt server mode against it.
+func newTestDoltStore(t *testing.T) Store {
+	t.Helper()
+	if _, err := exec.LookPath("dolt"); err != nil {
+		t.Skip("dolt not installed")
+	}
+	dataDir := t.TempDir()
+	dbDir := filepath.Join(dataDir, "beads")
+	if err := os.MkdirAll(dbDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--name", "tester", "--email", "tester@example.com"},
+		{"sql", "-q", testDoltSchema},
+	} {
+		cmd := exec.Command("dolt", args...)
+		cmd.Dir = dbDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("dolt %s: %v\n%s", args[0], err, out)
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+	server := exec.Command("dolt", "sql-server", "--host", "127.0.0.1", "--port", strconv.Itoa(port), "--data-dir", dataDir)
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = server.Process.Kill()
+		_ = server.Wait()
+	})
+
+	beadsDir := filepath.Join(t.TempDir(), ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := fmt.Sprintf(`{"backend":"dolt","dolt_mode":"server","dolt_server_host":"127.0.0.1","dolt_server_port":%d,"dolt_database":"beads"}`, port)
+	if err := os.WriteFile(filepath.Join(beadsDir, "metadata.json"), []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := doltServerFor(beadsDir, "")
+	for deadline := time.Now().Add(30 * time.Second); ; time.Sleep(200 * time.Millisecond) {
+		_, err := srv.query("SELECT 1")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dolt sql-server did not come up: %v", err)
+		}
+	}
+	return newNativeStore(beadsDir, "", "tester")
+}
+
+func TestNativeStore(t *testing.T) { testStoreConformance(t, newTestNativeStore) }
+
+func TestDoltStore(t *testing.T) { testStoreConformance(t, newTestDoltStore) }
+
+func TestBDStore(t *testing.T) { testStoreConformance(t, newTestBDStore) }
+
+// testStoreConformance checks the behavior gt relies on from every backend.
+func testStoreConformance(t *testing.T, newStore storeFactory) {
+	t.Run("CreateShowList", func(t *testing.T) {
+		s := newStore(t)
+		created, err := s.Create(NewIssue{
+			Title:       "First",
+			Description: "body",
+			IssueType:   "task",
+			Labels:      []string{"gt:task", "area:x"},
+			Priority:    1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(created.ID, "ts-") || created.Title != "First" || created.Priority != 1 {
+			t.Fatalf("created = %+v", created)
+		}
+
+		if _, err := s.Create(NewIssue{ID: "ts-fixed", Title: "Second", Priority: -1}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Create(NewIssue{ID: "ts-fixed", Title: "Dup", Priority: -1}); err == nil ||
+			!strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			t.Errorf("duplicate create err = %v", err)
+		}
+
+		shown, err := s.Show(created.ID)
+		if err != nil || len(shown) != 1 || shown[0].Description != "body" || !HasLabel(shown[0], "area:x") {
+			t.Fatalf("Show = %+v, %v", shown, err)
+		}
+		if _, err := s.Show("ts-missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Show(missing) err = %v, want ErrNotFound", err)
+		}
+
+		all, err := s.List(ListOptions{Priority: -1})
+		if err != nil || len(all) != 2 {
+			t.Fatalf("List = %d issues, %v", len(all), err)
+		}
+		byLabel, err := s.List(ListOptions{Label: "gt:task", Labels: []string{"area:x"}, Priority: -1})
+		if err != nil || len(byLabel) != 1 || byLabel[0].ID != created.ID {
+			t.Errorf("List(labels) = %+v, %v", byLabel, err)
+		}
+		byPriority, err := s.List(ListOptions{Priority: 1})
+		if err != nil || len(byPriority) != 1 {
+			t.Errorf("List(priority) = %+v, %v", byPriority, err)
+		}
+	})
+
+	t.Run("UpdateCloseReopen", func(t *testing.T) {
+		s := newStore(t)
+		issue, err := s.Create(NewIssue{Title: "Work", Labels: []string{"a"}, Priority: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		title, assignee := "Renamed", "gastown/polecats/Toast"
+		if err := s.Update(issue.ID, UpdateOptions{
+			Title:     &title,
+			Assignee:  &assignee,
+			AddLabels: []string{"b"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Show(issue.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].Title != title || got[0].Assignee != assignee || !HasLabel(got[0], "a") || !HasLabel(got[0], "b") {
+			t.Errorf("after update = %+v", got[0])
+		}
+		mine, err := s.List(ListOptions{Assignee: assignee, Priority: -1})
+		if err != nil || len(mine) != 1 {
+			t.Errorf("List(assignee) = %+v, %v", mine, err)
+		}
+
+		if err := s.Close(CloseOptions{Reason: "done"}, issue.ID); err != nil {
+			t.Fatal(err)
+		}
+		open, _ := s.List(ListOptions{Priority: -1})
+		if len(open) != 0 {
+			t.Errorf("closed issue listed by default: %+v", open)
+		}
+		all, _ := s.List(ListOptions{Status: "all", Priority: -1})
+		if len(all) != 1 || all[0].Status != "closed" {
+			t.Errorf("List(all) = %+v", all)
+		}
+
+		if err := s.Reopen(issue.ID, "again"); err != nil {
+			t.Fatal(err)
+		}
+		got, _ = s.Show(issue.ID)
+		if got[0].Status != "open" {
+			t.Errorf("status after reopen = %s", got[0].Status)
+		}
+
+		if err := s.Delete(issue.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Show(issue.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Show after delete err = %v", err)
+		}
+	})
+
+	t.Run("DependenciesReadyBlocked", func(t *testing.T) {
+		s := newStore(t)
+		blocker, _ := s.Create(NewIssue{Title: "Blocker", Priority: -1})
+		blocked, _ := s.Create(NewIssue{Title: "Blocked", Priority: -1})
+		if blocker == nil || blocked == nil {
+			t.Fatal("create failed")
+		}
+		if err := s.AddDependency(blocked.ID, blocker.ID, "blocks"); err != nil {
+			t.Fatal(err)
+		}
+
+		ready, err := s.Ready(ReadyOptions{})
+		if err != nil || len(ready) != 1 || ready[0].ID != blocker.ID {
+			t.Errorf("Ready = %+v, %v", ready, err)
+		}
+		waiting, err := s.Blocked()
+		if err != nil || len(waiting) != 1 || waiting[0].ID != blocked.ID {
+			t.Errorf("Blocked = %+v, %v", waiting, err)
+		}
+		shown, _ := s.Show(blocked.ID)
+		if len(shown[0].Dependencies) != 1 || shown[0].Dependencies[0].ID != blocker.ID {
+			t.Errorf("Dependencies = %+v", shown[0].Dependencies)
+		}
+
+		if err := s.Close(CloseOptions{}, blocker.ID); err != nil {
+			t.Fatal(err)
+		}
+		ready, _ = s.Ready(ReadyOptions{})
+		if len(ready) != 1 || ready[0].ID != blocked.ID {
+			t.Errorf("Ready after close = %+v", ready)
+		}
+
+		if err := s.RemoveDependency(blocked.ID, blocker.ID); err != nil {
+			t.Fatal(err)
+		}
+		shown, _ = s.Show(blocked.ID)
+		if len(shown[0].Dependencies) != 0 {
+			t.Errorf("Dependencies after remove = %+v", shown[0].Dependencies)
+		}
+	})
+
+	t.Run("AgentSlots", func(t *testing.T) {
+		s := newStore(t)
+		agent, err := s.Create(NewIssue{
+			ID:        "ts-gastown-polecat-toast",
+			Title:     "Toast",
+			IssueType: "agent",
+			Labels:    []string{"gt:agent"},
+			Priority:  -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		work, _ := s.Create(NewIssue{Title: "Hooked work", Priority: -1})
+
+		if err := s.SetAgentState(agent.ID, "working"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SetSlot(agent.ID, "hook", work.ID); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := s.GetSlot(agent.ID, "hook"); err != nil || v != work.ID {
+			t.Errorf("GetSlot(hook) = %q, %v", v, err)
+		}
+		got, _ := s.Show(agent.ID)
+		if got[0].AgentState != "working" || got[0].HookBead != work.ID {
+			t.Errorf("agent = state %q hook %q", got[0].AgentState, got[0].HookBead)
+		}
+
+		if err := s.ClearSlot(agent.ID, "hook"); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := s.GetSlot(agent.ID, "hook"); err != nil || v != "" {
+			t.Errorf("GetSlot after clear = %q, %v", v, err)
+		}
+	})
+
+	t.Run("MergeSlot", func(t *testing.T) {
+		s := newStore(t)
+		id, err := s.MergeSlotCreate()
+		if err != nil || id == "" {
+			t.Fatalf("MergeSlotCreate = %q, %v", id, err)
+		}
+		status, err := s.MergeSlotAcquire("refinery", false)
+		if err != nil || status.Holder != "refinery" {
+			t.Fatalf("acquire = %+v, %v", status, err)
+		}
+		status, _ = s.MergeSlotAcquire("polecat", true)
+		if status == nil || status.Available || len(status.Waiters) != 1 {
+			t.Errorf("second acquire = %+v", status)
+		}
+		if err := s.MergeSlotRelease("refinery"); err != nil {
+			t.Fatal(err)
+		}
+		status, err = s.MergeSlotCheck()
+		if err != nil || !status.Available {
+			t.Errorf("check after release = %+v, %v", status, err)
+		}
+	})
+}
+
+func TestNativeStoreReadsJSONLWithoutWriting(t *testing.T) {
+	beadsDir := newTestBeadsDir(t)
+	s := newNativeStore(beadsDir, "", "tester")
+	lines := `{"id":"ts-old","title":"Old","status":"open","priority":2,"issue_type":"task","compaction_level":3,"labels":["gt:task"]}` + "\n" +
+		`{"id":"ts-dep","title":"Dep","status":"open","priority":1,"issue_type":"task","dependencies":[{"issue_id":"ts-dep","depends_on_id":"ts-old","type":"blocks"}]}` + "\n"
+	path := filepath.Join(beadsDir, "issues.jsonl")
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := s.List(ListOptions{Label: "gt:task", Priority: -1})
+	if err != nil || len(issues) != 1 || issues[0].ID != "ts-old" {
+		t.Fatalf("List = %+v, %v", issues, err)
+	}
+	blocked, err := s.Blocked()
+	if err != nil || len(blocked) != 1 || blocked[0].ID != "ts-dep" {
+		t.Errorf("Blocked = %+v, %v", blocked, err)
+	}
+
+	// bd owns issues.jsonl: a native write fails and leaves the file alone.
+	title := "New"
+	if err := s.Update("ts-old", UpdateOptions{Title: &title}); !errors.Is(err, ErrBDManaged) {
+		t.Errorf("Update = %v, want ErrBDManaged", err)
+	}
+	if _, err := s.Create(NewIssue{Title: "Lost", Priority: -1}); !errors.Is(err, ErrBDManaged) {
+		t.Errorf("Create = %v, want ErrBDManaged", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != lines {
+		t.Errorf("issues.jsonl changed: %s, %v", data, err)
+	}
+}
+
+func TestDoltStoreConcurrentWriters(t *testing.T) {
+	s := newTestDoltStore(t).(*nativeStore)
+	first, err := s.Create(NewIssue{Title: "Shared", Priority: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each writer has its own store, as separate gt processes would.
+	const writers = 8
+	errs := make(chan error, 2*writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := newNativeStore(s.beadsDir, "", "tester")
+			_, err := w.Create(NewIssue{Title: fmt.Sprintf("Issue %d", i), Priority: -1})
+			errs <- err
+			errs <- w.Update(first.ID, UpdateOptions{AddLabels: []string{fmt.Sprintf("w:%d", i)}})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := s.List(ListOptions{Priority: -1})
+	if err != nil || len(issues) != writers+1 {
+		t.Fatalf("List = %d issues, %v; want %d", len(issues), err, writers+1)
+	}
+	shared, err := s.Show(first.ID)
+	if err != nil || len(shared[0].Labels) != writers {
+		t.Errorf("labels of the shared issue = %v, %v; want %d", shared[0].Labels, err, writers)
+	}
+}
+
+func TestDoltServerFor(t *testing.T) {
+	town := t.TempDir()
+	beadsDir := filepath.Join(town, "gastown", ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeMetadata := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(beadsDir, "metadata.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeMetadata(`{"backend":"dolt","dolt_mode":"embedded"}`)
+	if srv := doltServerFor(beadsDir, town); srv != nil {
+		t.Errorf("embedded Dolt = %v, want no server", srv)
+	}
+
+	writeMetadata(`{"backend":"dolt","dolt_mode":"server"}`)
+	if srv := doltServerFor(beadsDir, town); srv == nil || srv.String() != "dolt://root@127.0.0.1:3306/beads" {
+		t.Errorf("server mode with defaults = %v", srv)
+	}
+
+	// The daemon's dolt_server config names the server; bd's own settings win.
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	daemon := `{"type":"daemon-patrol-config","version":1,"patrols":{"dolt_server":{"enabled":true,"host":"10.0.0.5","port":3310}}}`
+	if err := os.WriteFile(filepath.Join(town, "mayor", "daemon.json"), []byte(daemon), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if srv := doltServerFor(beadsDir, town); srv == nil || srv.String() != "dolt://root@10.0.0.5:3310/beads" {
+		t.Errorf("server from daemon config = %v", srv)
+	}
+	writeMetadata(`{"backend":"dolt","dolt_mode":"server","dolt_server_port":3311,"dolt_database":"gastown"}`)
+	if srv := doltServerFor(beadsDir, town); srv == nil || srv.String() != "dolt://root@10.0.0.5:3311/gastown" {
+		t.Errorf("server from metadata = %v", srv)
+	}
+}
+
+func TestDoltStatements(t *testing.T) {
+	before := &jsonlFile{index: map[string]int{}}
+	for _, line := range []string{
+		`{"id":"ts-a","title":"A","status":"open","priority":2,"assignee":"mayor","labels":["x","y"]}`,
+		`{"id":"ts-b","title":"B","status":"open","priority":2,"dependencies":[{"issue_id":"ts-b","depends_on_id":"ts-a","type":"blocks"}]}`,
+		`{"id":"ts-gone","title":"Gone","status":"open","priority":2}`,
+	} {
+		r := make(record)
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		before.add(r)
+	}
+	after := before.clone()
+	a := after.get("ts-a")
+	a.set("title", "It's A")
+	a.set("closed_at", "2026-01-02T03:04:05.5Z")
+	delete(a, "assignee")
+	a.set("labels", []string{"y", "z"})
+	after.get("ts-b").setDeps(nil)
+	after.remove("ts-gone")
+	c := make(record)
+	c.set("id", "ts-c")
+	c.set("title", "C")
+	c.set("ephemeral", true)
+	after.add(c)
+
+	known := map[string]bool{"id": true, "title": true, "status": true, "priority": true, "assignee": true, "closed_at": true, "ephemeral": true}
+	stmts, err := doltStatements(before, after, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"DELETE FROM dependencies WHERE issue_id = 'ts-gone'",
+		"DELETE FROM labels WHERE issue_id = 'ts-gone'",
+		"DELETE FROM issues WHERE id = 'ts-gone'",
+		"UPDATE issues SET `closed_at` = '2026-01-02 03:04:05.5', `title` = 'It\\'s A', `assignee` = DEFAULT WHERE id = 'ts-a'",
+		"DELETE FROM labels WHERE issue_id = 'ts-a' AND label = 'x'",
+		"INSERT INTO labels (issue_id, label) VALUES ('ts-a', 'z')",
+		"DELETE FROM dependencies WHERE issue_id = 'ts-b' AND depends_on_id = 'ts-a' AND type = 'blocks'",
+		"INSERT INTO issues (`ephemeral`, `id`, `title`) VALUES (TRUE, 'ts-c', 'C')",
+	}
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements:\n%s\nwant:\n%s", strings.Join(stmts, "\n"), strings.Join(want, "\n"))
+	}
+
+	c.set("holder", "refinery")
+	if _, err := doltStatements(before, after, known); err == nil || !strings.Contains(err.Error(), `"holder"`) {
+		t.Errorf("unknown column err = %v", err)
+	}
+}
+
+func TestOpenStoreSelection(t *testing.T) {
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(town, "mayor", "town.json"), []byte(`{"name":"test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rig := filepath.Join(town, "gastown")
+	if err := os.MkdirAll(filepath.Join(rig, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GT_BEADS_BACKEND", "")
+	if _, ok := New(rig).store().(*bdStore); !ok {
+		t.Error("default backend is not bd")
+	}
+
+	// Over a no-db directory the native backend reads issues.jsonl itself
+	// and writes through bd.
+	t.Setenv("GT_BEADS_BACKEND", "native")
+	if st, ok := New(rig).store().(jsonlReadStore); !ok || st.bdStore == nil || st.native == nil {
+		t.Error("GT_BEADS_BACKEND=native did not select the JSONL read store")
+	}
+	if _, ok := NewIsolated(rig).store().(*bdStore); !ok {
+		t.Error("isolated wrapper should always use bd")
+	}
+	restore := WriteJSONLForTesting()
+	if _, ok := New(rig).store().(*nativeStore); !ok {
+		t.Error("WriteJSONLForTesting did not select the native store")
+	}
+	restore()
+
+	// A native selection over a database fails rather than reading a stale
+	// JSONL export, unless bd runs the directory in no-db mode or reaches
+	// its Dolt database through a SQL server (see TestDoltStore).
+	for _, db := range []struct{ file, content string }{
+		{"metadata.json", `{"backend":"dolt"}`},
+		{"beads.db", ""},
+	} {
+		t.Run(db.file, func(t *testing.T) {
+			path := filepath.Join(rig, ".beads", db.file)
+			if err := os.WriteFile(path, []byte(db.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(path)
+			if _, err := New(rig).List(ListOptions{Priority: -1}); !errors.Is(err, ErrNativeNeedsNoDB) {
+				t.Errorf("List over a database = %v, want ErrNativeNeedsNoDB", err)
+			}
+
+			config := filepath.Join(rig, ".beads", "config.yaml")
+			if err := os.WriteFile(config, []byte("no-db: true\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(config)
+			if _, ok := New(rig).store().(jsonlReadStore); !ok {
+				t.Error("no-db directory did not select the JSONL read store")
+			}
+		})
+	}
+
+	metadata := filepath.Join(rig, ".beads", "metadata.json")
+	if err := os.WriteFile(metadata, []byte(`{"backend":"dolt","dolt_mode":"server"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := New(rig).store().(*nativeStore); !ok {
+		t.Error("Dolt server mode directory did not select the native store")
+	}
+}
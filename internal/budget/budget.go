@@ -0,0 +1,153 @@
+// Package budget checks recorded session spend against the per-rig,
+// per-role and per-convoy caps in town and rig settings.
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Scope kinds a cap can apply to.
+const (
+	KindRig    = "rig"
+	KindRole   = "role"
+	KindConvoy = "convoy"
+)
+
+// Budget periods.
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// Spend is the cost of one session, attributed to where it ran.
+type Spend struct {
+	Rig      string
+	Role     string
+	WorkItem string // bead the session worked on, if known
+	CostUSD  float64
+	At       time.Time // when the cost was incurred (session end, or now for live sessions)
+}
+
+// Cap is one USD limit on one scope for one period.
+type Cap struct {
+	Kind     string  `json:"kind"`
+	Name     string  `json:"name"`
+	Period   string  `json:"period"`
+	LimitUSD float64 `json:"limit_usd"`
+}
+
+// Key identifies the cap across checks, e.g. "rig:gastown:daily".
+func (c Cap) Key() string {
+	return c.Kind + ":" + c.Name + ":" + c.Period
+}
+
+// String describes the cap for messages, e.g. "rig gastown daily cap ($50.00)".
+func (c Cap) String() string {
+	return fmt.Sprintf("%s %s %s cap ($%.2f)", c.Kind, c.Name, c.Period, c.LimitUSD)
+}
+
+// Status is a cap with the spend counted against it.
+type Status struct {
+	Cap
+	SpentUSD float64 `json:"spent_usd"`
+}
+
+// RemainingUSD returns the headroom left under the cap (negative when over).
+func (s Status) RemainingUSD() float64 {
+	return s.LimitUSD - s.SpentUSD
+}
+
+// Fraction returns spend as a fraction of the cap.
+func (s Status) Fraction() float64 {
+	if s.LimitUSD <= 0 {
+		return 0
+	}
+	return s.SpentUSD / s.LimitUSD
+}
+
+// Exceeded reports whether the hard cap has been reached.
+func (s Status) Exceeded() bool {
+	return s.SpentUSD >= s.LimitUSD
+}
+
+// Caps lists the caps defined by town budgets and per-rig settings, sorted
+// by key. rigLimits (from each rig's settings) override town.Rigs entries.
+func Caps(town *config.BudgetConfig, rigLimits map[string]*config.BudgetLimit) []Cap {
+	var caps []Cap
+	add := func(kind string, limits map[string]*config.BudgetLimit) {
+		for name, l := range limits {
+			if l == nil {
+				continue
+			}
+			if l.DailyUSD > 0 {
+				caps = append(caps, Cap{Kind: kind, Name: name, Period: PeriodDaily, LimitUSD: l.DailyUSD})
+			}
+			if l.WeeklyUSD > 0 {
+				caps = append(caps, Cap{Kind: kind, Name: name, Period: PeriodWeekly, LimitUSD: l.WeeklyUSD})
+			}
+		}
+	}
+
+	rigs := make(map[string]*config.BudgetLimit)
+	if town != nil {
+		for name, l := range town.Rigs {
+			rigs[name] = l
+		}
+		add(KindRole, town.Roles)
+		add(KindConvoy, town.Convoys)
+	}
+	for name, l := range rigLimits {
+		rigs[name] = l
+	}
+	add(KindRig, rigs)
+
+	sort.Slice(caps, func(i, j int) bool { return caps[i].Key() < caps[j].Key() })
+	return caps
+}
+
+// PeriodStart returns the start of the period containing now: local
+// midnight for daily caps, local midnight six days ago for weekly caps.
+func PeriodStart(period string, now time.Time) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if period == PeriodWeekly {
+		return midnight.AddDate(0, 0, -6)
+	}
+	return midnight
+}
+
+// Covers reports whether a cap applies to work in rig by role on workItem.
+// convoyItems maps convoy IDs to the set of issues they track.
+func (c Cap) Covers(rig, role, workItem string, convoyItems map[string]map[string]bool) bool {
+	switch c.Kind {
+	case KindRig:
+		return rig != "" && rig == c.Name
+	case KindRole:
+		return role != "" && role == c.Name
+	case KindConvoy:
+		return workItem != "" && convoyItems[c.Name][workItem]
+	}
+	return false
+}
+
+// Evaluate sums spend against each cap for the period containing now.
+func Evaluate(caps []Cap, spend []Spend, convoyItems map[string]map[string]bool, now time.Time) []Status {
+	out := make([]Status, 0, len(caps))
+	for _, c := range caps {
+		start := PeriodStart(c.Period, now)
+		st := Status{Cap: c}
+		for _, s := range spend {
+			if s.At.Before(start) || s.At.After(now) {
+				continue
+			}
+			if c.Covers(s.Rig, s.Role, s.WorkItem, convoyItems) {
+				st.SpentUSD += s.CostUSD
+			}
+		}
+		out = append(out, st)
+	}
+	return out
+}
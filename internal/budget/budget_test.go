@@ -0,0 +1,153 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestCaps(t *testing.T) {
+	town := &config.BudgetConfig{
+		Rigs:    map[string]*config.BudgetLimit{"gastown": {DailyUSD: 50}, "beads": {WeeklyUSD: 100}},
+		Roles:   map[string]*config.BudgetLimit{"polecat": {DailyUSD: 20, WeeklyUSD: 80}},
+		Convoys: map[string]*config.BudgetLimit{"hq-cv-1": {WeeklyUSD: 10}, "hq-cv-2": nil},
+	}
+	// Rig settings replace the town entry for that rig.
+	rigs := map[string]*config.BudgetLimit{"gastown": {WeeklyUSD: 300}}
+
+	var keys []string
+	for _, c := range Caps(town, rigs) {
+		keys = append(keys, c.Key())
+	}
+	want := []string{
+		"convoy:hq-cv-1:weekly",
+		"rig:beads:weekly",
+		"rig:gastown:weekly",
+		"role:polecat:daily",
+		"role:polecat:weekly",
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("caps = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("caps[%d] = %s, want %s", i, keys[i], want[i])
+		}
+	}
+
+	if got := Caps(nil, nil); len(got) != 0 {
+		t.Errorf("Caps(nil, nil) = %v", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	caps := []Cap{
+		{Kind: KindRig, Name: "gastown", Period: PeriodDaily, LimitUSD: 10},
+		{Kind: KindRig, Name: "gastown", Period: PeriodWeekly, LimitUSD: 30},
+		{Kind: KindRole, Name: "polecat", Period: PeriodDaily, LimitUSD: 5},
+		{Kind: KindConvoy, Name: "hq-cv-1", Period: PeriodWeekly, LimitUSD: 8},
+	}
+	spend := []Spend{
+		{Rig: "gastown", Role: "polecat", WorkItem: "gt-a", CostUSD: 4, At: now.Add(-time.Hour)},
+		{Rig: "gastown", Role: "witness", CostUSD: 2, At: now.Add(-14 * time.Hour)}, // today, early
+		{Rig: "gastown", Role: "polecat", WorkItem: "gt-b", CostUSD: 7, At: now.AddDate(0, 0, -3)},
+		{Rig: "gastown", Role: "polecat", CostUSD: 100, At: now.AddDate(0, 0, -7)}, // outside the week
+		{Rig: "beads", Role: "polecat", WorkItem: "bd-x", CostUSD: 3, At: now},
+	}
+	convoys := map[string]map[string]bool{"hq-cv-1": {"gt-a": true, "bd-x": true}}
+
+	got := Evaluate(caps, spend, convoys, now)
+	want := []float64{6, 13, 7, 7}
+	for i, st := range got {
+		if st.SpentUSD != want[i] {
+			t.Errorf("%s spent = %.2f, want %.2f", st.Key(), st.SpentUSD, want[i])
+		}
+	}
+	if !got[2].Exceeded() || got[0].Exceeded() {
+		t.Errorf("exceeded: polecat=%v rig=%v", got[2].Exceeded(), got[0].Exceeded())
+	}
+	if r := got[0].RemainingUSD(); r != 4 {
+		t.Errorf("remaining = %.2f, want 4", r)
+	}
+}
+
+func TestStateUpdate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	rig := Cap{Kind: KindRig, Name: "gastown", Period: PeriodDaily, LimitUSD: 10}
+	convoy := Cap{Kind: KindConvoy, Name: "hq-cv-1", Period: PeriodWeekly, LimitUSD: 5}
+	items := map[string]map[string]bool{"hq-cv-1": {"gt-a": true}}
+	warnAt := []float64{0.5, 0.8}
+
+	s := &State{}
+
+	// Crossing 50% warns once.
+	ch := s.Update([]Status{{Cap: rig, SpentUSD: 6}, {Cap: convoy, SpentUSD: 1}}, warnAt, items, now)
+	if len(ch.Warnings) != 1 || ch.Warnings[0].Threshold != 0.5 {
+		t.Fatalf("warnings = %+v", ch.Warnings)
+	}
+	ch = s.Update([]Status{{Cap: rig, SpentUSD: 7}, {Cap: convoy, SpentUSD: 1}}, warnAt, items, now)
+	if len(ch.Warnings) != 0 {
+		t.Errorf("repeated warning: %+v", ch.Warnings)
+	}
+
+	// 80% warns again; the convoy hits its cap.
+	ch = s.Update([]Status{{Cap: rig, SpentUSD: 8.5}, {Cap: convoy, SpentUSD: 5}}, warnAt, items, now)
+	if len(ch.Warnings) != 1 || ch.Warnings[0].Threshold != 0.8 {
+		t.Errorf("warnings = %+v", ch.Warnings)
+	}
+	if len(ch.Exceeded) != 1 || ch.Exceeded[0].Kind != KindConvoy {
+		t.Fatalf("exceeded = %+v", ch.Exceeded)
+	}
+
+	if b := s.Blocking("gastown", "polecat", "gt-a", now); b == nil || b.Name != "hq-cv-1" {
+		t.Errorf("Blocking(gt-a) = %v, want convoy cap", b)
+	}
+	if b := s.Blocking("gastown", "polecat", "gt-other", now); b != nil {
+		t.Errorf("Blocking(gt-other) = %v, want nil", b)
+	}
+
+	// Still exceeded: not reported again.
+	ch = s.Update([]Status{{Cap: rig, SpentUSD: 8.5}, {Cap: convoy, SpentUSD: 6}}, warnAt, items, now)
+	if len(ch.Exceeded) != 0 {
+		t.Errorf("repeated exceeded: %+v", ch.Exceeded)
+	}
+
+	// Cap raised: cleared.
+	convoy.LimitUSD = 20
+	ch = s.Update([]Status{{Cap: rig, SpentUSD: 8.5}, {Cap: convoy, SpentUSD: 6}}, warnAt, items, now)
+	if len(ch.Cleared) != 1 || s.Blocking("gastown", "polecat", "gt-a", now) != nil {
+		t.Errorf("cleared = %+v, exceeded = %+v", ch.Cleared, s.Exceeded)
+	}
+}
+
+func TestStateBlockingIgnoresPreviousPeriod(t *testing.T) {
+	yesterday := time.Date(2026, 3, 9, 18, 0, 0, 0, time.Local)
+	s := &State{}
+	s.Update([]Status{{Cap: Cap{Kind: KindRole, Name: "polecat", Period: PeriodDaily, LimitUSD: 1}, SpentUSD: 2}}, nil, nil, yesterday)
+
+	if s.Blocking("gastown", "polecat", "", yesterday) == nil {
+		t.Fatal("expected block on the day the cap was hit")
+	}
+	if b := s.Blocking("gastown", "polecat", "", yesterday.Add(8*time.Hour)); b != nil {
+		t.Errorf("daily cap still blocking the next day: %v", b)
+	}
+}
+
+func TestLoadSaveState(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadState(dir)
+	if err != nil || len(s.Exceeded) != 0 {
+		t.Fatalf("LoadState(empty) = %+v, %v", s, err)
+	}
+	s.Paused = []string{"gastown/polecats/Toast"}
+	s.CheckedAt = time.Now()
+	if err := SaveState(dir, s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadState(dir)
+	if err != nil || len(got.Paused) != 1 || got.Paused[0] != "gastown/polecats/Toast" {
+		t.Errorf("LoadState = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,182 @@
+package budget
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// State is the result of the last enforcement check, persisted so that
+// gt sling can refuse spawns without recomputing spend, and so warnings
+// and pauses are not repeated every heartbeat.
+type State struct {
+	// CheckedAt is when spend was last evaluated.
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Exceeded lists caps whose hard limit has been reached.
+	Exceeded []Status `json:"exceeded,omitempty"`
+
+	// ConvoyItems records the tracked issues of exceeded convoy caps.
+	ConvoyItems map[string][]string `json:"convoy_items,omitempty"`
+
+	// Warned maps cap keys to the highest warning threshold already
+	// escalated. Entries are dropped once spend falls back below them.
+	Warned map[string]float64 `json:"warned,omitempty"`
+
+	// Paused lists polecat addresses (rig/polecats/name) paused by budget
+	// enforcement, so they can be resumed when headroom returns.
+	Paused []string `json:"paused,omitempty"`
+}
+
+// StatePath returns the path of the budget state file.
+func StatePath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "budget.json")
+}
+
+// LoadState reads the budget state. A missing file yields an empty state.
+func LoadState(townRoot string) (*State, error) {
+	data, err := os.ReadFile(StatePath(townRoot)) //nolint:gosec // G304: path is constructed from trusted townRoot
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveState writes the budget state.
+func SaveState(townRoot string, s *State) error {
+	path := StatePath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, s)
+}
+
+// convoySets converts ConvoyItems to lookup sets.
+func (s *State) convoySets() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(s.ConvoyItems))
+	for id, items := range s.ConvoyItems {
+		set := make(map[string]bool, len(items))
+		for _, item := range items {
+			set[item] = true
+		}
+		sets[id] = set
+	}
+	return sets
+}
+
+// Blocking returns the first exceeded cap covering work in rig by role on
+// workItem, or nil. Caps from a previous period are ignored so a stale
+// state file never blocks forever.
+func (s *State) Blocking(rig, role, workItem string, now time.Time) *Status {
+	sets := s.convoySets()
+	for i := range s.Exceeded {
+		st := &s.Exceeded[i]
+		if s.CheckedAt.Before(PeriodStart(st.Period, now)) {
+			continue
+		}
+		if st.Covers(rig, role, workItem, sets) {
+			return st
+		}
+	}
+	return nil
+}
+
+// Warning is a threshold newly crossed by a cap.
+type Warning struct {
+	Status
+	Threshold float64
+}
+
+// Changes are the transitions found by Update.
+type Changes struct {
+	Warnings []Warning // thresholds crossed since the last check
+	Exceeded []Status  // caps that reached their hard limit
+	Cleared  []Status  // caps back under their hard limit
+}
+
+// Update records statuses in the state and returns what changed since the
+// previous check. warnAt lists warning thresholds as fractions of a cap.
+func (s *State) Update(statuses []Status, warnAt []float64, convoyItems map[string]map[string]bool, now time.Time) Changes {
+	var ch Changes
+	if s.Warned == nil {
+		s.Warned = make(map[string]float64)
+	}
+
+	wasExceeded := make(map[string]bool, len(s.Exceeded))
+	for _, st := range s.Exceeded {
+		// A cap from an earlier period has reset, even if no check ran since.
+		if !s.CheckedAt.Before(PeriodStart(st.Period, now)) {
+			wasExceeded[st.Key()] = true
+		}
+	}
+
+	thresholds := append([]float64(nil), warnAt...)
+	sort.Float64s(thresholds)
+
+	var exceeded []Status
+	convoys := make(map[string][]string)
+	for _, st := range statuses {
+		key := st.Key()
+		if st.Exceeded() {
+			exceeded = append(exceeded, st)
+			if !wasExceeded[key] {
+				ch.Exceeded = append(ch.Exceeded, st)
+			}
+			if st.Kind == KindConvoy {
+				for item := range convoyItems[st.Name] {
+					convoys[st.Name] = append(convoys[st.Name], item)
+				}
+				sort.Strings(convoys[st.Name])
+			}
+		} else if wasExceeded[key] {
+			ch.Cleared = append(ch.Cleared, st)
+		}
+
+		// Highest threshold reached below the hard cap.
+		reached := 0.0
+		for _, t := range thresholds {
+			if t < 1 && st.Fraction() >= t {
+				reached = t
+			}
+		}
+		switch {
+		case reached == 0:
+			delete(s.Warned, key)
+		case st.Exceeded():
+			s.Warned[key] = reached // the hard cap escalation supersedes the warning
+		case reached > s.Warned[key]:
+			s.Warned[key] = reached
+			ch.Warnings = append(ch.Warnings, Warning{Status: st, Threshold: reached})
+		}
+	}
+
+	// Forget warnings for caps that no longer exist.
+	live := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		live[st.Key()] = true
+	}
+	for key := range s.Warned {
+		if !live[key] {
+			delete(s.Warned, key)
+		}
+	}
+
+	s.Exceeded = exceeded
+	s.ConvoyItems = nil
+	if len(convoys) > 0 {
+		s.ConvoyItems = convoys
+	}
+	s.CheckedAt = now
+	return ch
+}
@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -63,7 +64,8 @@ Examples:
 
 Subcommands:
   gt costs record       # Record session cost to local log file (Stop hook)
-  gt costs digest       # Aggregate log entries into daily digest bead (Deacon patrol)`,
+  gt costs digest       # Aggregate log entries into daily digest bead (Deacon patrol)
+  gt costs budget       # Show headroom under daily/weekly budgets`,
 	RunE: runCosts,
 }
 
@@ -762,9 +764,14 @@ func parseTranscriptUsage(transcriptPath string) (*TokenUsage, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return scanTranscriptUsage(file)
+}
 
+// scanTranscriptUsage sums token usage from the assistant messages of a
+// transcript read from r.
+func scanTranscriptUsage(r io.Reader) (*TokenUsage, error) {
 	usage := &TokenUsage{}
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	// Increase buffer for potentially large JSON lines
 	buf := make([]byte, 0, 256*1024)
 	scanner.Buffer(buf, 1024*1024)
@@ -0,0 +1,509 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/budget"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+var (
+	budgetJSON    bool
+	budgetEnforce bool
+)
+
+var costsBudgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show remaining headroom under cost budgets",
+	Long: `Show spend against the daily and weekly cost budgets.
+
+Budgets are USD caps per rig, per role and per convoy. Town-wide caps live
+in settings/config.json under "budgets"; a rig can set its own cap under
+"budget" in <rig>/settings/config.json, which overrides the town entry.
+
+  "budgets": {
+    "rigs":    {"gastown": {"daily_usd": 50, "weekly_usd": 250}},
+    "roles":   {"polecat": {"daily_usd": 100}},
+    "convoys": {"hq-cv-abc": {"weekly_usd": 40}},
+    "warn_at": [0.5, 0.8],
+    "pause_polecats": true
+  }
+
+Daily caps cover spend since local midnight; weekly caps cover the last
+seven days including today. Spend comes from the session cost log, daily
+digests and running sessions that have not been recorded yet; a running
+session's spend counts against the convoy tracking the bead on its hook.
+
+With --enforce (run by the daemon each heartbeat), crossing a warn_at
+threshold raises a medium escalation and reaching a cap raises a high one.
+While a cap is exceeded, gt sling refuses to spawn polecats it covers and,
+if pause_polecats is set, covered working polecats are paused until the
+cap clears.
+
+Examples:
+  gt costs budget            # Show headroom for every cap
+  gt costs budget --json     # Output as JSON
+  gt costs budget --enforce  # Escalate, block and pause (daemon)`,
+	RunE: runCostsBudget,
+}
+
+func init() {
+	costsCmd.AddCommand(costsBudgetCmd)
+	costsBudgetCmd.Flags().BoolVar(&budgetJSON, "json", false, "Output as JSON")
+	costsBudgetCmd.Flags().BoolVar(&budgetEnforce, "enforce", false, "Record state, escalate and pause polecats (used by the daemon)")
+}
+
+// BudgetStatusOutput is one cap in gt costs budget --json.
+type BudgetStatusOutput struct {
+	budget.Status
+	RemainingUSD float64 `json:"remaining_usd"`
+	Exceeded     bool    `json:"exceeded"`
+}
+
+func runCostsBudget(cmd *cobra.Command, args []string) error {
+	rigs, townRoot, err := getAllRigs()
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return fmt.Errorf("loading town settings: %w", err)
+	}
+
+	rigLimits := make(map[string]*config.BudgetLimit)
+	for _, r := range rigs {
+		if limit := rigBudgetLimit(r); limit != nil {
+			rigLimits[r.Name] = limit
+		}
+	}
+
+	caps := budget.Caps(settings.Budgets, rigLimits)
+	if len(caps) == 0 {
+		if budgetEnforce {
+			// Budgets were removed: clear any recorded blocks and pauses.
+			if err := enforceBudgets(townRoot, rigs, settings.Budgets, nil, nil, time.Now()); err != nil {
+				return err
+			}
+		}
+		if budgetJSON {
+			fmt.Println("[]")
+			return nil
+		}
+		fmt.Println(style.Dim.Render("No budgets configured (see gt costs budget --help)"))
+		return nil
+	}
+
+	now := time.Now()
+	convoyItems := budgetConvoyItems(townRoot, caps)
+	statuses := budget.Evaluate(caps, collectBudgetSpend(rigs, now), convoyItems, now)
+
+	if budgetEnforce {
+		if err := enforceBudgets(townRoot, rigs, settings.Budgets, statuses, convoyItems, now); err != nil {
+			return err
+		}
+	}
+
+	if budgetJSON {
+		out := make([]BudgetStatusOutput, 0, len(statuses))
+		for _, st := range statuses {
+			out = append(out, BudgetStatusOutput{Status: st, RemainingUSD: st.RemainingUSD(), Exceeded: st.Exceeded()})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Printf("\n%s Cost Budgets\n\n", style.Bold.Render("💰"))
+	fmt.Printf("%-8s %-20s %-7s %10s %10s %10s %6s\n", "Kind", "Name", "Period", "Spent", "Limit", "Remaining", "Used")
+	fmt.Println(strings.Repeat("─", 77))
+	for _, st := range statuses {
+		used := fmt.Sprintf("%5.0f%%", st.Fraction()*100)
+		switch {
+		case st.Exceeded():
+			used = style.Error.Render(used)
+		case st.Fraction() >= 0.8:
+			used = style.Warning.Render(used)
+		}
+		fmt.Printf("%-8s %-20s %-7s %10s %10s %10s %s\n",
+			st.Kind, st.Name, st.Period,
+			fmt.Sprintf("$%.2f", st.SpentUSD),
+			fmt.Sprintf("$%.2f", st.LimitUSD),
+			fmt.Sprintf("$%.2f", st.RemainingUSD()),
+			used)
+	}
+	fmt.Println()
+	return nil
+}
+
+// rigBudgetLimit returns the budget in a rig's settings, read over the
+// rig's connection so remote rigs count too.
+func rigBudgetLimit(r *rig.Rig) *config.BudgetLimit {
+	data, err := r.Connection().ReadFile(config.RigSettingsPath(r.Path))
+	if err != nil {
+		return nil
+	}
+	rs, err := config.ParseRigSettings(data)
+	if err != nil {
+		return nil
+	}
+	return rs.Budget
+}
+
+// collectBudgetSpend gathers session spend for the last seven days: the
+// cost log (not yet digested), digest beads, and running sessions that have
+// no log entry yet, on this machine and on the machines of remote rigs.
+func collectBudgetSpend(rigs []*rig.Rig, now time.Time) []budget.Spend {
+	var entries []CostEntry
+	for d := 0; d < 7; d++ {
+		day, err := querySessionCostEntries(now.AddDate(0, 0, -d))
+		if err != nil && costsVerbose {
+			fmt.Fprintf(os.Stderr, "[costs] reading costs log: %v\n", err)
+		}
+		entries = append(entries, day...)
+	}
+	digests, err := queryDigestBeads(7)
+	if err != nil && costsVerbose {
+		fmt.Fprintf(os.Stderr, "[costs] reading digests: %v\n", err)
+	}
+	entries = append(entries, digests...)
+
+	recorded := make(map[string]bool)
+	spend := make([]budget.Spend, 0, len(entries))
+	for _, e := range entries {
+		spend = append(spend, budget.Spend{Rig: e.Rig, Role: e.Role, WorkItem: e.WorkItem, CostUSD: e.CostUSD, At: e.EndedAt})
+		if e.EndedAt.Format("2006-01-02") == now.Format("2006-01-02") {
+			recorded[e.SessionID] = true
+		}
+	}
+
+	remote := make(map[string]*rig.Rig)
+	for _, r := range rigs {
+		if r.IsRemote() {
+			remote[r.Name] = r
+		}
+	}
+	if sessions, err := tmux.NewTmux().ListSessions(); err == nil {
+		for _, session := range sessions {
+			if !strings.HasPrefix(session, constants.SessionPrefix) || recorded[session] {
+				continue
+			}
+			role, rigName, _ := parseSessionName(session)
+			if remote[rigName] != nil {
+				continue
+			}
+			workDir, err := getTmuxSessionWorkDir(session)
+			if err != nil {
+				continue
+			}
+			cost, err := extractCostFromWorkDir(workDir)
+			if err != nil || cost == 0 {
+				continue
+			}
+			spend = append(spend, budget.Spend{Rig: rigName, Role: role, WorkItem: sessionWorkItem(session, workDir), CostUSD: cost, At: now})
+		}
+	}
+
+	for _, r := range remote {
+		t := rigTmux(r)
+		sessions, err := t.ListSessions()
+		if err != nil {
+			if costsVerbose {
+				fmt.Fprintf(os.Stderr, "[costs] listing sessions of %s: %v\n", r.Name, err)
+			}
+			continue
+		}
+		for _, session := range sessions {
+			role, rigName, _ := parseSessionName(session)
+			if !strings.HasPrefix(session, constants.SessionPrefix) || rigName != r.Name || recorded[session] {
+				continue
+			}
+			workDir, err := t.GetPaneWorkDir(session)
+			if err != nil {
+				continue
+			}
+			cost, err := remoteSessionCost(r, workDir)
+			if err != nil || cost == 0 {
+				continue
+			}
+			spend = append(spend, budget.Spend{Rig: rigName, Role: role, WorkItem: remoteHookedBead(r, workDir, sessionToAgentID(session)), CostUSD: cost, At: now})
+		}
+	}
+	return spend
+}
+
+// remoteSessionCost is extractCostFromWorkDir for a session on a remote
+// rig's machine, whose transcripts live under that machine's home.
+func remoteSessionCost(r *rig.Rig, workDir string) (float64, error) {
+	script := `f=$(ls -t "$HOME/.claude/projects/$1"/*.jsonl 2>/dev/null | head -n 1); [ -n "$f" ] && cat -- "$f"`
+	out, err := r.Connection().Exec("sh", "-c", script, "sh", strings.ReplaceAll(workDir, "/", "-"))
+	if err != nil {
+		return 0, fmt.Errorf("reading transcript: %w", err)
+	}
+	usage, err := scanTranscriptUsage(bytes.NewReader(out))
+	if err != nil {
+		return 0, fmt.Errorf("parsing transcript: %w", err)
+	}
+	return calculateCost(usage), nil
+}
+
+// remoteBD runs bd in dir on a remote rig's machine and returns its stdout.
+// Stderr is dropped so warnings don't corrupt JSON output.
+func remoteBD(r *rig.Rig, dir string, args ...string) ([]byte, error) {
+	script := `cd "$1" && shift && exec bd "$@" 2>/dev/null`
+	return r.Connection().Exec("sh", append([]string{"-c", script, "sh", dir}, args...)...)
+}
+
+// remoteHookedBead is sessionWorkItem for an agent on a remote rig: the
+// bead hooked to (or claimed by) agentID, looked up with bd in dir.
+func remoteHookedBead(r *rig.Rig, dir, agentID string) string {
+	for _, status := range []string{beads.StatusHooked, "in_progress"} {
+		out, err := remoteBD(r, dir, "list", "--status="+status, "--assignee="+agentID, "--json")
+		if err != nil {
+			continue
+		}
+		var issues []beads.Issue
+		if json.Unmarshal(out, &issues) == nil && len(issues) > 0 {
+			return issues[0].ID
+		}
+	}
+	return ""
+}
+
+// sessionWorkItem returns the bead on a running session's hook, so its
+// unrecorded spend counts against the convoy tracking that bead. Work that
+// was claimed (in_progress) but is still assigned to the agent counts too.
+func sessionWorkItem(session, workDir string) string {
+	b := beads.New(workDir)
+	agentID := sessionToAgentID(session)
+	for _, status := range []string{beads.StatusHooked, "in_progress"} {
+		issues, err := b.List(beads.ListOptions{Status: status, Assignee: agentID, Priority: -1})
+		if err == nil && len(issues) > 0 {
+			return issues[0].ID
+		}
+	}
+	return ""
+}
+
+// budgetConvoyItems looks up the issues tracked by each budgeted convoy.
+func budgetConvoyItems(townRoot string, caps []budget.Cap) map[string]map[string]bool {
+	items := make(map[string]map[string]bool)
+	for _, c := range caps {
+		if c.Kind != budget.KindConvoy || items[c.Name] != nil {
+			continue
+		}
+		set := make(map[string]bool)
+		depCmd := exec.Command("bd", "dep", "list", c.Name, "-t", "tracks", "--json") //nolint:gosec // G204: convoy ID comes from town settings
+		depCmd.Dir = townRoot
+		out, err := depCmd.Output()
+		if err == nil {
+			var deps []struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(out, &deps) == nil {
+				for _, d := range deps {
+					// Cross-rig tracks are stored as external:<prefix>:<id>.
+					id := d.ID
+					if parts := strings.SplitN(id, ":", 3); len(parts) == 3 && parts[0] == "external" {
+						id = parts[2]
+					}
+					set[id] = true
+				}
+			}
+		}
+		items[c.Name] = set
+	}
+	return items
+}
+
+// enforceBudgets records budget state for gt sling, escalates newly crossed
+// thresholds and caps, and pauses or resumes covered polecats.
+func enforceBudgets(townRoot string, rigs []*rig.Rig, cfg *config.BudgetConfig, statuses []budget.Status, convoyItems map[string]map[string]bool, now time.Time) error {
+	state, err := budget.LoadState(townRoot)
+	if err != nil {
+		return fmt.Errorf("loading budget state: %w", err)
+	}
+
+	warnAt := config.DefaultBudgetWarnAt
+	if cfg != nil && len(cfg.WarnAt) > 0 {
+		warnAt = cfg.WarnAt
+	}
+	changes := state.Update(statuses, warnAt, convoyItems, now)
+
+	for _, w := range changes.Warnings {
+		escalateBudget("medium",
+			fmt.Sprintf("Budget warning: %s %s at %.0f%% of %s cap", w.Kind, w.Name, w.Fraction()*100, w.Period),
+			fmt.Sprintf("Spent $%.2f of $%.2f (%s); warning threshold %.0f%%.", w.SpentUSD, w.LimitUSD, w.Cap, w.Threshold*100))
+	}
+	for _, st := range changes.Exceeded {
+		escalateBudget("high",
+			fmt.Sprintf("Budget exceeded: %s %s %s cap reached", st.Kind, st.Name, st.Period),
+			fmt.Sprintf("Spent $%.2f of $%.2f (%s). New polecat spawns covered by this cap are refused until it resets.", st.SpentUSD, st.LimitUSD, st.Cap))
+	}
+
+	if cfg != nil && cfg.PausePolecats {
+		state.Paused = applyBudgetPauses(townRoot, rigs, state, now)
+	} else {
+		state.Paused = resumeBudgetPolecats(townRoot, rigs, state.Paused, nil)
+	}
+
+	if len(statuses) == 0 && len(state.Paused) == 0 {
+		// No budgets left to track; drop the state so the daemon stops checking.
+		if err := os.Remove(budget.StatePath(townRoot)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing budget state: %w", err)
+		}
+		return nil
+	}
+	if err := budget.SaveState(townRoot, state); err != nil {
+		return fmt.Errorf("saving budget state: %w", err)
+	}
+	return nil
+}
+
+// escalateBudget raises an escalation through gt escalate.
+func escalateBudget(severity, description, reason string) {
+	cmd := exec.Command("gt", "escalate", description, "--severity", severity, "--reason", reason, "--source", "budget") //nolint:gosec // G204: args are constructed internally
+	if out, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "[costs] escalation failed: %v: %s\n", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// budgetPolecat is a polecat that budget enforcement may pause.
+type budgetPolecat struct {
+	name    string
+	issue   string
+	working bool
+}
+
+// listBudgetPolecats lists r's polecats. Remote rigs are listed from their
+// running sessions, with the hooked bead looked up over the connection.
+func listBudgetPolecats(r *rig.Rig) ([]budgetPolecat, error) {
+	if !r.IsRemote() {
+		polecats, err := polecat.NewManager(r, git.NewGit(r.Path), tmux.NewTmux()).List()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]budgetPolecat, 0, len(polecats))
+		for _, p := range polecats {
+			out = append(out, budgetPolecat{name: p.Name, issue: p.Issue, working: p.State == polecat.StateWorking})
+		}
+		return out, nil
+	}
+	sessions, err := polecat.NewSessionManager(rigTmux(r), r).List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]budgetPolecat, 0, len(sessions))
+	for _, s := range sessions {
+		issue := remoteHookedBead(r, r.Path, fmt.Sprintf("%s/polecats/%s", r.Name, s.Polecat))
+		out = append(out, budgetPolecat{name: s.Polecat, issue: issue, working: issue != ""})
+	}
+	return out, nil
+}
+
+// setBudgetAgentState sets the agent state of r's polecat name, over the
+// rig's connection for remote rigs.
+func setBudgetAgentState(townRoot string, r *rig.Rig, name, state string) error {
+	if !r.IsRemote() {
+		return polecat.NewManager(r, git.NewGit(r.Path), tmux.NewTmux()).SetAgentState(name, state)
+	}
+	agentID := beads.PolecatBeadIDWithPrefix(beads.GetPrefixForRig(townRoot, r.Name), r.Name, name)
+	_, err := remoteBD(r, r.Path, "agent", "state", agentID, state)
+	return err
+}
+
+// nudgeBudgetPolecat sends msg to the session of r's polecat name.
+func nudgeBudgetPolecat(r *rig.Rig, name, msg string) error {
+	t := rigTmux(r)
+	return t.NudgeSession(polecat.NewSessionManager(t, r).SessionName(name), msg)
+}
+
+// applyBudgetPauses pauses working polecats covered by an exceeded cap and
+// resumes the ones paused earlier that no longer are. It returns the
+// addresses that remain paused.
+func applyBudgetPauses(townRoot string, rigs []*rig.Rig, state *budget.State, now time.Time) []string {
+	keep := make(map[string]bool)
+	wasPaused := make(map[string]bool, len(state.Paused))
+	for _, addr := range state.Paused {
+		wasPaused[addr] = true
+	}
+
+	for _, r := range rigs {
+		polecats, err := listBudgetPolecats(r)
+		if err != nil {
+			continue
+		}
+		for _, p := range polecats {
+			addr := fmt.Sprintf("%s/polecats/%s", r.Name, p.name)
+			st := state.Blocking(r.Name, constants.RolePolecat, p.issue, now)
+			if st == nil {
+				continue
+			}
+			if wasPaused[addr] {
+				keep[addr] = true
+				continue
+			}
+			if !p.working {
+				continue
+			}
+			if err := setBudgetAgentState(townRoot, r, p.name, "paused"); err != nil {
+				fmt.Fprintf(os.Stderr, "[costs] could not pause %s: %v\n", addr, err)
+				continue
+			}
+			keep[addr] = true
+			msg := fmt.Sprintf("BUDGET PAUSE: the %s is exhausted. Stop working now: commit your progress and wait. Do not start new tasks until you are told the budget has cleared.", st.Cap)
+			if err := nudgeBudgetPolecat(r, p.name, msg); err != nil && costsVerbose {
+				fmt.Fprintf(os.Stderr, "[costs] could not nudge %s: %v\n", addr, err)
+			}
+			fmt.Printf("%s Paused %s (%s)\n", style.Warning.Render("⏸"), addr, st.Cap)
+		}
+	}
+
+	return resumeBudgetPolecats(townRoot, rigs, state.Paused, keep)
+}
+
+// resumeBudgetPolecats resumes paused polecats not in keep and returns the
+// sorted addresses in keep.
+func resumeBudgetPolecats(townRoot string, rigs []*rig.Rig, paused []string, keep map[string]bool) []string {
+	byName := make(map[string]*rig.Rig, len(rigs))
+	for _, r := range rigs {
+		byName[r.Name] = r
+	}
+
+	for _, addr := range paused {
+		if keep[addr] {
+			continue
+		}
+		parts := strings.Split(addr, "/")
+		if len(parts) != 3 || byName[parts[0]] == nil {
+			continue
+		}
+		r := byName[parts[0]]
+		if err := setBudgetAgentState(townRoot, r, parts[2], "working"); err != nil {
+			fmt.Fprintf(os.Stderr, "[costs] could not resume %s: %v\n", addr, err)
+		}
+		_ = nudgeBudgetPolecat(r, parts[2], "BUDGET CLEARED: spend is back under its cap. Resume your hooked work.")
+		fmt.Printf("%s Resumed %s\n", style.Success.Render("▶"), addr)
+	}
+
+	out := make([]string, 0, len(keep))
+	for addr := range keep {
+		out = append(out, addr)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
 func TestDeriveSessionName(t *testing.T) {
@@ -112,3 +115,38 @@ func TestDeriveSessionName(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionWorkItem(t *testing.T) {
+	townRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"mayor/town.json":    "{}",
+		".beads/config.yaml": "issue-prefix: gt\n",
+	} {
+		full := filepath.Join(townRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GT_BEADS_BACKEND", "native")
+	t.Cleanup(beads.WriteJSONLForTesting())
+
+	b := beads.New(townRoot)
+	issue, err := b.Create(beads.CreateOptions{Title: "Work", Type: "task", Priority: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sessionWorkItem("gt-gastown-toast", townRoot); got != "" {
+		t.Errorf("sessionWorkItem with nothing hooked = %q", got)
+	}
+
+	status, assignee := beads.StatusHooked, "gastown/polecats/toast"
+	if err := b.Update(issue.ID, beads.UpdateOptions{Status: &status, Assignee: &assignee}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sessionWorkItem("gt-gastown-toast", townRoot); got != issue.ID {
+		t.Errorf("sessionWorkItem = %q, want %s", got, issue.ID)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/metrics"
+	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/web"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -27,7 +30,29 @@ The dashboard shows real-time convoy status with:
 - Convoy list with status indicators
 - Progress tracking for each convoy
 - Last activity indicator (green/yellow/red)
-- Auto-refresh every 30 seconds via htmx
+- Auto-refresh every 30 seconds via htmx, and immediately on new events
+
+The API includes a server-sent events stream of the town's activity log:
+
+  GET /api/events/stream[?rig=R&actor=A&type=T&last_event_id=N]
+
+Filters take comma-separated values (actor accepts a trailing * as a
+prefix match). Each event's id is its offset in .events.jsonl; reconnecting
+with Last-Event-ID (or last_event_id) resumes after it.
+
+Scripts should use the typed, versioned JSON API under /api/v1 (rigs,
+polecats, merge queue, convoys, issues, mail). Its OpenAPI document is
+served at /api/v1/openapi.json.
+
+Prometheus metrics (polecats, merge queue depth, ready beads, escalations,
+merges, merge failures, session deaths and restarts, time to merge) are
+served at /metrics. The daemon can serve the same metrics without the
+dashboard; see 'metrics' in mayor/daemon.json.
+
+Access control: once tokens exist (see 'gt dashboard token'), requests need
+a bearer token. Read-only tokens may only GET; operator tokens may also run
+commands, send mail and create issues. Mutating requests are audit-logged
+with the token's name.
 
 Example:
   gt dashboard              # Start on default port 8080
@@ -47,7 +72,8 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	var handler http.Handler
 	var err error
 
-	if _, wsErr := workspace.FindFromCwdOrError(); wsErr != nil {
+	townRoot, wsErr := workspace.FindFromCwdOrError()
+	if wsErr != nil {
 		// No workspace - run in setup mode
 		handler, err = web.NewSetupMux()
 		if err != nil {
@@ -60,10 +86,25 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("creating convoy fetcher: %w", fetchErr)
 		}
 
-		handler, err = web.NewDashboardMux(fetcher)
+		dashboard, err := web.NewDashboardMux(fetcher)
 		if err != nil {
 			return fmt.Errorf("creating dashboard handler: %w", err)
 		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.NewCollector(townRoot))
+		mux.Handle("/", dashboard)
+		handler = mux
+
+		settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+		if err != nil {
+			return fmt.Errorf("loading town settings: %w", err)
+		}
+		if auth := web.NewAuthenticator(settings.Dashboard); auth != nil {
+			handler = auth.Wrap(handler)
+		} else {
+			fmt.Printf("%s no dashboard tokens configured; anyone who can reach port %d can run commands (see 'gt dashboard token')\n",
+				style.Warning.Render("⚠"), dashboardPort)
+		}
 	}
 
 	// Build the URL
@@ -97,7 +138,7 @@ func runDashboard(cmd *cobra.Command, args []string) error {
     \$$     \$$$$$$         \$$$$$$  \$$   \$$  \$$$$$$     \$$     \$$$$$$  \$$      \$$ \$$   \$$
 
 `)
-	fmt.Printf("  launching dashboard at %s  •  api: %s/api/  •  ctrl+c to stop\n", url, url)
+	fmt.Printf("  launching dashboard at %s  •  api: %s/api/  •  metrics: %s/metrics  •  ctrl+c to stop\n", url, url, url)
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", dashboardPort),
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/web"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var dashboardTokenRole string
+
+var dashboardTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage dashboard access tokens",
+	Long: `Manage bearer tokens for gt dashboard.
+
+Once any token exists, every dashboard and API request must present one:
+
+  Authorization: Bearer <token>     (scripts)
+  http://host:8080/?token=<token>   (browser page loads; moved into a cookie)
+
+Roles:
+  read-only   GET requests only (dashboard, /api/v1, event stream)
+  operator    may also run commands, send mail and create issues
+
+Mutating requests are recorded in the town's audit log (.events.jsonl)
+with the token's name. Tokens are stored hashed in settings/config.json.`,
+	RunE: requireSubcommand,
+}
+
+var dashboardTokenAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a token and print its secret",
+	Long: `Create a dashboard token for <name> and print the secret.
+
+The secret is shown only once. Restart gt dashboard to pick up new tokens.
+
+Examples:
+  gt dashboard token add alice --role operator
+  gt dashboard token add grafana`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDashboardTokenAdd,
+}
+
+var dashboardTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dashboard tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runDashboardTokenList,
+}
+
+var dashboardTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a dashboard token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDashboardTokenRevoke,
+}
+
+func init() {
+	dashboardTokenAddCmd.Flags().StringVar(&dashboardTokenRole, "role", config.DashboardRoleReadOnly,
+		"Token role: read-only or operator")
+
+	dashboardTokenCmd.AddCommand(dashboardTokenAddCmd)
+	dashboardTokenCmd.AddCommand(dashboardTokenListCmd)
+	dashboardTokenCmd.AddCommand(dashboardTokenRevokeCmd)
+	dashboardCmd.AddCommand(dashboardTokenCmd)
+}
+
+// loadDashboardSettings returns the town settings path and contents.
+func loadDashboardSettings() (string, *config.TownSettings, error) {
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil {
+		return "", nil, fmt.Errorf("finding town root: %w", err)
+	}
+	if townRoot == "" {
+		return "", nil, fmt.Errorf("not in a Gas Town workspace")
+	}
+	path := config.TownSettingsPath(townRoot)
+	settings, err := config.LoadOrCreateTownSettings(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("loading town settings: %w", err)
+	}
+	return path, settings, nil
+}
+
+func runDashboardTokenAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, settings, err := loadDashboardSettings()
+	if err != nil {
+		return err
+	}
+	if settings.Dashboard == nil {
+		settings.Dashboard = &config.DashboardConfig{}
+	}
+	for _, t := range settings.Dashboard.Tokens {
+		if t.Name == name {
+			return fmt.Errorf("token '%s' already exists (revoke it first)", name)
+		}
+	}
+
+	secret, entry, err := web.GenerateDashboardToken(name, dashboardTokenRole)
+	if err != nil {
+		return err
+	}
+	settings.Dashboard.Tokens = append(settings.Dashboard.Tokens, entry)
+	if err := config.SaveTownSettings(path, settings); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+
+	fmt.Printf("%s Created %s token '%s'\n\n", style.Success.Render("✓"), entry.Role, style.Bold.Render(name))
+	fmt.Printf("  %s\n\n", secret)
+	fmt.Printf("%s\n", style.Dim.Render("This secret will not be shown again. Restart gt dashboard to apply."))
+	return nil
+}
+
+func runDashboardTokenList(cmd *cobra.Command, args []string) error {
+	_, settings, err := loadDashboardSettings()
+	if err != nil {
+		return err
+	}
+	if settings.Dashboard == nil || len(settings.Dashboard.Tokens) == 0 {
+		fmt.Println("No dashboard tokens; the dashboard is unauthenticated.")
+		return nil
+	}
+	for _, t := range settings.Dashboard.Tokens {
+		fmt.Printf("%-20s %-10s %s\n", t.Name, t.Role, style.Dim.Render(t.CreatedAt))
+	}
+	return nil
+}
+
+func runDashboardTokenRevoke(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, settings, err := loadDashboardSettings()
+	if err != nil {
+		return err
+	}
+	if settings.Dashboard == nil {
+		return fmt.Errorf("token '%s' not found", name)
+	}
+	kept := settings.Dashboard.Tokens[:0]
+	for _, t := range settings.Dashboard.Tokens {
+		if t.Name != name {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(settings.Dashboard.Tokens) {
+		return fmt.Errorf("token '%s' not found", name)
+	}
+	settings.Dashboard.Tokens = kept
+	if err := config.SaveTownSettings(path, settings); err != nil {
+		return fmt.Errorf("saving town settings: %w", err)
+	}
+
+	fmt.Printf("%s Revoked token '%s'\n", style.Success.Render("✓"), name)
+	if len(kept) == 0 {
+		fmt.Printf("%s\n", style.Dim.Render("No tokens remain; the dashboard will be unauthenticated."))
+	}
+	return nil
+}
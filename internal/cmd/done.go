@@ -18,6 +18,7 @@ import (
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/townlog"
+	"github.com/steveyegge/gastown/internal/tracing"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -82,7 +83,7 @@ func init() {
 	rootCmd.AddCommand(doneCmd)
 }
 
-func runDone(cmd *cobra.Command, args []string) error {
+func runDone(cmd *cobra.Command, args []string) (err error) {
 	// Guard: Only polecats should call gt done
 	// Crew, deacons, witnesses etc. don't use gt done - they persist across tasks.
 	// Polecats are ephemeral workers that self-destruct after completing work.
@@ -235,6 +236,20 @@ func runDone(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Join the bead's lifecycle trace. The session's TRACEPARENT covers a
+	// bead whose trace could not be stored by gt sling.
+	tracer := tracing.New(townRoot)
+	var traceParent, traceStartedAt string
+	if tracer != nil {
+		traceParent, traceStartedAt = getTraceFromBead(cwd, issueID)
+		if traceParent == "" {
+			traceParent = os.Getenv(tracing.EnvTraceParent)
+		}
+	}
+	doneSpan := tracer.Start(traceParent, "gt done")
+	doneSpan.SetAttr("gt.bead", issueID).SetAttr("gt.exit", exitType).SetAttr("gt.rig", rigName).SetAttr("gt.polecat", polecatName)
+	defer func() { doneSpan.EndErr(err) }()
+
 	// Get configured default branch for this rig
 	defaultBranch := "main" // fallback
 	if rigCfg, err := rig.LoadRigConfig(filepath.Join(townRoot, rigName)); err == nil && rigCfg.DefaultBranch != "" {
@@ -427,7 +442,16 @@ func runDone(cmd *cobra.Command, args []string) error {
 			description += "\nlast_conflict_sha: null"
 			description += "\nconflict_task_id: null"
 
+			// Carry the trace so the refinery's spans join it
+			if traceParent != "" {
+				description += "\ntraceparent: " + traceParent
+			}
+			if traceStartedAt != "" {
+				description += "\ntrace_started_at: " + traceStartedAt
+			}
+
 			// Create MR bead (ephemeral wisp - will be cleaned up after merge)
+			submitSpan := doneSpan.Start("mq.submit")
 			mrIssue, err := bd.Create(beads.CreateOptions{
 				Title:       title,
 				Type:        "merge-request",
@@ -435,6 +459,10 @@ func runDone(cmd *cobra.Command, args []string) error {
 				Description: description,
 				Ephemeral:   true,
 			})
+			if err == nil {
+				submitSpan.SetAttr("gt.mr", mrIssue.ID)
+			}
+			submitSpan.EndErr(err)
 			if err != nil {
 				return fmt.Errorf("creating merge request bead: %w", err)
 			}
@@ -509,6 +537,9 @@ notifyWitness:
 		bodyLines = append(bodyLines, fmt.Sprintf("Gate: %s", doneGate))
 	}
 	bodyLines = append(bodyLines, fmt.Sprintf("Branch: %s", branch))
+	if tp := doneSpan.TraceParent(); tp != "" {
+		bodyLines = append(bodyLines, fmt.Sprintf("Traceparent: %s", tp))
+	}
 
 	doneNotification := &mail.Message{
 		To:      witnessAddr,
@@ -548,6 +579,9 @@ notifyWitness:
 	// Update agent bead state (ZFC: self-report completion)
 	updateAgentStateOnDone(cwd, townRoot, exitType, issueID)
 
+	// End the span now: self-cleaning may kill this process's session
+	doneSpan.End()
+
 	// Self-cleaning: Nuke our own sandbox and session (if we're a polecat)
 	// This is the self-cleaning model - polecats clean up after themselves
 	// "done means gone" - both worktree and session are terminated
@@ -785,6 +819,27 @@ func getDispatcherFromBead(cwd, issueID string) string {
 	return fields.DispatchedBy
 }
 
+// getTraceFromBead retrieves the lifecycle trace recorded on the bead by
+// gt sling. Returns empty strings if the bead is not traced.
+func getTraceFromBead(cwd, issueID string) (traceParent, startedAt string) {
+	if issueID == "" {
+		return "", ""
+	}
+
+	bd := beads.New(beads.ResolveBeadsDir(cwd))
+	issue, err := bd.Show(issueID)
+	if err != nil {
+		return "", ""
+	}
+
+	fields := beads.ParseAttachmentFields(issue)
+	if fields == nil {
+		return "", ""
+	}
+
+	return fields.TraceParent, fields.TraceStartedAt
+}
+
 // parseCleanupStatus converts a string flag value to a CleanupStatus.
 // ZFC: Agent observes git state and passes the appropriate status.
 func parseCleanupStatus(s string) polecat.CleanupStatus {
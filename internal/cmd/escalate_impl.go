@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/notify"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -115,7 +118,12 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Process external notification actions (email:, sms:, slack)
-	executeExternalActions(actions, escalationConfig, issue.ID, severity, description)
+	deliveries := executeExternalActions(actions, escalationConfig, &notify.Message{
+		Subject:  fmt.Sprintf("[%s] %s", strings.ToUpper(severity), description),
+		Body:     formatEscalationMailBody(issue.ID, severity, escalateReason, agentID, escalateRelatedBead),
+		Severity: severity,
+	})
+	recordDeliveries(bd, issue.ID, deliveries)
 
 	// Log to activity feed
 	payload := events.EscalationPayload(issue.ID, agentID, strings.Join(targets, ","), description)
@@ -134,6 +142,9 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 			"actions":  actions,
 			"targets":  targets,
 		}
+		if len(deliveries) > 0 {
+			result["deliveries"] = deliveries
+		}
 		if escalateSource != "" {
 			result["source"] = escalateSource
 		}
@@ -379,6 +390,14 @@ func runEscalateStale(cmd *cobra.Command, args []string) error {
 				}
 			}
 
+			// Page humans on the new route (email:, sms:, slack)
+			deliveries := executeExternalActions(actions, escalationConfig, &notify.Message{
+				Subject:  fmt.Sprintf("[%s→%s] Re-escalated: %s", strings.ToUpper(result.OldSeverity), strings.ToUpper(result.NewSeverity), result.Title),
+				Body:     formatReescalationMailBody(result, reescalatedBy),
+				Severity: result.NewSeverity,
+			})
+			recordDeliveries(bd, result.ID, deliveries)
+
 			// Log to activity feed
 			_ = events.LogFeed(events.TypeEscalationSent, reescalatedBy, map[string]interface{}{
 				"escalation_id":    result.ID,
@@ -490,6 +509,7 @@ func runEscalateShow(cmd *cobra.Command, args []string) error {
 			"closedBy":    fields.ClosedBy,
 			"closedReason": fields.ClosedReason,
 			"relatedBead": fields.RelatedBead,
+			"deliveries":  fields.Deliveries,
 		}
 		out, _ := json.MarshalIndent(data, "", "  ")
 		fmt.Println(string(out))
@@ -516,6 +536,12 @@ func runEscalateShow(cmd *cobra.Command, args []string) error {
 	if fields.RelatedBead != "" {
 		fmt.Printf("  Related: %s\n", fields.RelatedBead)
 	}
+	if len(fields.Deliveries) > 0 {
+		fmt.Printf("  Deliveries:\n")
+		for _, d := range fields.Deliveries {
+			fmt.Printf("    %s\n", d)
+		}
+	}
 
 	return nil
 }
@@ -538,41 +564,78 @@ func extractMailTargetsFromActions(actions []string) []string {
 	return targets
 }
 
-// executeExternalActions processes external notification actions (email:, sms:, slack).
-// For now, this logs warnings if contacts aren't configured - actual sending is future work.
-func executeExternalActions(actions []string, cfg *config.EscalationConfig, _, _, _ string) {
+// executeExternalActions delivers external notification actions (email:, sms:, slack)
+// concurrently, with the timeout and retry policy from the escalation config.
+// Returns one delivery record per external action, in route order.
+func executeExternalActions(actions []string, cfg *config.EscalationConfig, msg *notify.Message) []notify.Delivery {
+	var external []string
 	for _, action := range actions {
 		switch {
-		case strings.HasPrefix(action, "email:"):
-			if cfg.Contacts.HumanEmail == "" {
-				style.PrintWarning("email action '%s' skipped: contacts.human_email not configured in settings/escalation.json", action)
-			} else {
-				// TODO: Implement actual email sending
-				fmt.Printf("  📧 Would send email to %s (not yet implemented)\n", cfg.Contacts.HumanEmail)
-			}
-
-		case strings.HasPrefix(action, "sms:"):
-			if cfg.Contacts.HumanSMS == "" {
-				style.PrintWarning("sms action '%s' skipped: contacts.human_sms not configured in settings/escalation.json", action)
-			} else {
-				// TODO: Implement actual SMS sending
-				fmt.Printf("  📱 Would send SMS to %s (not yet implemented)\n", cfg.Contacts.HumanSMS)
-			}
-
-		case action == "slack":
-			if cfg.Contacts.SlackWebhook == "" {
-				style.PrintWarning("slack action skipped: contacts.slack_webhook not configured in settings/escalation.json")
-			} else {
-				// TODO: Implement actual Slack webhook posting
-				fmt.Printf("  💬 Would post to Slack (not yet implemented)\n")
-			}
-
+		case notify.IsExternalAction(action):
+			external = append(external, action)
 		case action == "log":
 			// Log action always succeeds - writes to escalation log file
 			// TODO: Implement actual log file writing
 			fmt.Printf("  📝 Logged to escalation log\n")
 		}
 	}
+	if len(external) == 0 {
+		return nil
+	}
+
+	policy := notify.PolicyFor(cfg)
+	deliveries := make([]notify.Delivery, len(external))
+	var wg sync.WaitGroup
+	for i, action := range external {
+		n, err := notify.ForAction(action, cfg)
+		if err != nil {
+			deliveries[i] = notify.Skipped(action, err.Error())
+			continue
+		}
+		wg.Add(1)
+		go func(i int, n notify.Notifier) {
+			defer wg.Done()
+			deliveries[i] = notify.Deliver(context.Background(), n, msg, policy)
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, d := range deliveries {
+		switch d.Status {
+		case notify.StatusSent:
+			fmt.Printf("  %s Notified via %s\n", notificationEmoji(d.Channel), d.Channel)
+		case notify.StatusSkipped:
+			style.PrintWarning("%s action skipped: %s in settings/escalation.json", d.Channel, d.Error)
+		default:
+			style.PrintWarning("%s delivery failed after %d attempt(s): %s", d.Channel, d.Attempts, d.Error)
+		}
+	}
+	return deliveries
+}
+
+// recordDeliveries stores delivery records on the escalation bead.
+func recordDeliveries(bd *beads.Beads, beadID string, deliveries []notify.Delivery) {
+	if len(deliveries) == 0 {
+		return
+	}
+	records := make([]string, len(deliveries))
+	for i, d := range deliveries {
+		records[i] = d.String()
+	}
+	if err := bd.RecordEscalationDeliveries(beadID, records); err != nil {
+		style.PrintWarning("failed to record deliveries on %s: %v", beadID, err)
+	}
+}
+
+func notificationEmoji(channel string) string {
+	switch {
+	case strings.HasPrefix(channel, "email:"):
+		return "📧"
+	case strings.HasPrefix(channel, "sms:"):
+		return "📱"
+	default:
+		return "💬"
+	}
 }
 
 func formatEscalationMailBody(beadID, severity, reason, from, related string) string {
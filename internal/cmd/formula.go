@@ -16,6 +16,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/graph"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 	"golang.org/x/text/cases"
@@ -24,12 +26,13 @@ import (
 
 // Formula command flags
 var (
-	formulaListJSON   bool
-	formulaShowJSON   bool
-	formulaRunPR      int
-	formulaRunRig     string
-	formulaRunDryRun  bool
-	formulaCreateType string
+	formulaListJSON    bool
+	formulaShowJSON    bool
+	formulaGraphFormat string
+	formulaRunPR       int
+	formulaRunRig      string
+	formulaRunDryRun   bool
+	formulaCreateType  string
 )
 
 var formulaCmd = &cobra.Command{
@@ -47,6 +50,8 @@ for ephemeral patrol cycles.
 Commands:
   list    List available formulas from all search paths
   show    Display formula details (steps, variables, composition)
+  resolve Print a formula with extends/include/overrides flattened
+  graph   Export the step graph (dot, mermaid, json)
   run     Execute a formula (pour and dispatch)
   create  Create a new formula template
 
@@ -96,6 +101,61 @@ Examples:
 	RunE: runFormulaShow,
 }
 
+var formulaResolveCmd = &cobra.Command{
+	Use:   "resolve <name>",
+	Short: "Print the flattened formula",
+	Long: `Print a formula as TOML with its composition resolved.
+
+A formula can build on others:
+
+  extends = ["shiny"]          # start from these formulas, merged in order
+
+  [[include]]                  # take steps from another formula
+  formula = "mol-patrol-tail"
+  steps = ["report"]           # optional subset
+  needs = ["survey"]           # entry steps of the include wait for these
+
+  [[steps]]                    # same ID as an inherited step: replaces it
+  id = "lint"                  # new ID: added, optionally spliced in with
+  after = "implement"          # after/before = "<step>"
+
+  [[overrides]]                # patch an inherited step (or remove = true)
+  id = "review"
+  description = "..."
+
+bd does not understand extends, include, overrides or after/before, so gt
+sling cooks a composed formula from this output rather than the original.
+To use one with bd directly, flatten it first:
+gt formula resolve <name> > <name>-flat.formula.toml
+
+Examples:
+  gt formula resolve shiny-secure`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaResolve,
+}
+
+var formulaGraphCmd = &cobra.Command{
+	Use:   "graph <name>",
+	Short: "Export the formula's step graph",
+	Long: `Export the dependency graph of a formula: workflow steps, convoy legs
+and synthesis, expansion templates or aspects.
+
+Formats:
+  dot      Graphviz (render with: dot -Tsvg)
+  mermaid  Mermaid flowchart (renders in Markdown)
+  json     Nodes and edges
+
+Use 'gt mol dag <molecule> --format ...' for the same graph of a running
+molecule, coloured by step status.
+
+Examples:
+  gt formula graph shiny
+  gt formula graph shiny --format mermaid
+  gt formula graph mol-witness-patrol | dot -Tsvg > patrol.svg`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaGraph,
+}
+
 var formulaRunCmd = &cobra.Command{
 	Use:   "run [name]",
 	Short: "Execute a formula",
@@ -154,6 +214,9 @@ func init() {
 	// Show flags
 	formulaShowCmd.Flags().BoolVar(&formulaShowJSON, "json", false, "Output as JSON")
 
+	// Graph flags
+	formulaGraphCmd.Flags().StringVar(&formulaGraphFormat, "format", "dot", "Output format: dot, mermaid, or json")
+
 	// Run flags
 	formulaRunCmd.Flags().IntVar(&formulaRunPR, "pr", 0, "GitHub PR number to run formula on")
 	formulaRunCmd.Flags().StringVar(&formulaRunRig, "rig", "", "Target rig (default: current or gastown)")
@@ -165,6 +228,8 @@ func init() {
 	// Add subcommands
 	formulaCmd.AddCommand(formulaListCmd)
 	formulaCmd.AddCommand(formulaShowCmd)
+	formulaCmd.AddCommand(formulaResolveCmd)
+	formulaCmd.AddCommand(formulaGraphCmd)
 	formulaCmd.AddCommand(formulaRunCmd)
 	formulaCmd.AddCommand(formulaCreateCmd)
 
@@ -195,7 +260,57 @@ func runFormulaShow(cmd *cobra.Command, args []string) error {
 	bdCmd := exec.Command("bd", bdArgs...)
 	bdCmd.Stdout = os.Stdout
 	bdCmd.Stderr = os.Stderr
-	return bdCmd.Run()
+	err := bdCmd.Run()
+
+	// Add the typed var usage table, which bd does not know about
+	if !formulaShowJSON {
+		printFormulaVarUsage(formulaName)
+	}
+	return err
+}
+
+// printFormulaVarUsage prints the vars table and an example invocation for
+// a TOML formula found in the search paths.
+func printFormulaVarUsage(formulaName string) {
+	path, err := findFormulaFile(formulaName)
+	if err != nil || !strings.HasSuffix(path, ".toml") {
+		return
+	}
+	f, err := formula.ParseFile(path)
+	if err != nil || len(f.Vars) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", style.Bold.Render("Variables:"))
+	fmt.Print(formulaVarsTable(f))
+	fmt.Printf("\n%s %s\n", style.Bold.Render("Usage:"), formulaUsage(f))
+}
+
+// runFormulaResolve prints a formula with its composition flattened.
+func runFormulaResolve(cmd *cobra.Command, args []string) error {
+	f, err := formula.Load(args[0], formulaSearchPaths()...)
+	if err != nil {
+		return fmt.Errorf("resolving formula %s: %w", args[0], err)
+	}
+	data, err := f.TOML()
+	if err != nil {
+		return fmt.Errorf("encoding formula %s: %w", args[0], err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// runFormulaGraph exports a formula's step graph.
+func runFormulaGraph(cmd *cobra.Command, args []string) error {
+	f, err := formula.Load(args[0], formulaSearchPaths()...)
+	if err != nil {
+		return fmt.Errorf("loading formula %s: %w", args[0], err)
+	}
+	out, err := graph.FromFormula(f).Render(formulaGraphFormat)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
 }
 
 // runFormulaRun executes a formula by spawning a convoy of polecats.
@@ -658,8 +773,9 @@ type formulaSynthesis struct {
 	DependsOn   []string
 }
 
-// findFormulaFile searches for a formula file by name
-func findFormulaFile(name string) (string, error) {
+// formulaSearchPaths returns the directories formulas are looked up in, in
+// order.
+func formulaSearchPaths() []string {
 	// Search paths in order
 	searchPaths := []string{}
 
@@ -678,6 +794,13 @@ func findFormulaFile(name string) (string, error) {
 		searchPaths = append(searchPaths, filepath.Join(home, ".beads", "formulas"))
 	}
 
+	return searchPaths
+}
+
+// findFormulaFile searches for a formula file by name
+func findFormulaFile(name string) (string, error) {
+	searchPaths := formulaSearchPaths()
+
 	// Try each path with common extensions
 	extensions := []string{".formula.toml", ".formula.json"}
 	for _, basePath := range searchPaths {
@@ -1058,6 +1181,7 @@ Perform the main task work.
 # [vars]
 # [vars.issue]
 # description = "Issue ID to work on"
+# type = "bead-id"          # string, int, bool, bead-id, rig, agent, enum
 # required = true
 #
 # [vars.target]
@@ -1137,6 +1261,7 @@ Finalize and clean up.
 [vars]
 [vars.issue]
 description = "Issue ID to work on"
+type = "bead-id"
 required = true
 `, name, title, name)
 }
@@ -1189,6 +1314,7 @@ Perform the patrol inspection.
 # [vars]
 # [vars.verbose]
 # description = "Enable verbose output"
+# type = "bool"
 # default = "false"
 `, name, title, name)
 }
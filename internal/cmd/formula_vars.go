@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/suggest"
+)
+
+// checkFormulaVars validates --var values against the typed var
+// definitions of formulaName before anything is instantiated, so a typo
+// fails the sling instead of producing a broken molecule.
+//
+// implicitVars are vars gt passes on its own (feature=, issue=); they are
+// only checked when the formula declares them. Formulas gt cannot find are
+// left to bd; one that does not parse or resolve fails the sling.
+func checkFormulaVars(formulaName string, varArgs, implicitVars []string, townRoot string, dirs ...string) error {
+	f, err := findMoleculeFormula(formulaName, append(dirs, townRoot)...)
+	if err != nil || f == nil {
+		return err
+	}
+
+	values, err := formula.ParseVarArgs(varArgs)
+	if err != nil {
+		return err
+	}
+	implicit, err := formula.ParseVarArgs(implicitVars)
+	if err != nil {
+		return err
+	}
+	for name, value := range implicit {
+		if _, declared := f.Vars[name]; !declared {
+			continue
+		}
+		if _, set := values[name]; !set {
+			values[name] = value
+		}
+	}
+
+	_, err = f.ResolveVars(values, formulaVarLookup(townRoot))
+	return err
+}
+
+// formulaVarLookup checks rig and agent vars against the town's rig
+// registry. Bead IDs are only checked for shape, to keep validation free of
+// bd calls.
+func formulaVarLookup(townRoot string) *formula.VarLookup {
+	if townRoot == "" {
+		return nil
+	}
+	rigsConfig, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json"))
+	if err != nil {
+		return nil
+	}
+	var rigNames []string
+	for name := range rigsConfig.Rigs {
+		rigNames = append(rigNames, name)
+	}
+	sort.Strings(rigNames)
+
+	checkRig := func(name string) error {
+		if _, ok := rigsConfig.Rigs[name]; ok {
+			return nil
+		}
+		if similar := suggest.FindSimilar(name, rigNames, 1); len(similar) > 0 {
+			return fmt.Errorf("unknown rig %q (did you mean %s?)", name, similar[0])
+		}
+		return fmt.Errorf("unknown rig %q", name)
+	}
+	return &formula.VarLookup{
+		Rig: checkRig,
+		Agent: func(address string) error {
+			identity, err := session.ParseAddress(address)
+			if err != nil {
+				return err
+			}
+			if identity.Rig == "" {
+				return nil
+			}
+			return checkRig(identity.Rig)
+		},
+	}
+}
+
+// formulaVarsTable renders the usage table for a formula's vars.
+func formulaVarsTable(f *formula.Formula) string {
+	table := style.NewTable(
+		style.Column{Name: "VAR", Width: 16},
+		style.Column{Name: "TYPE", Width: 8},
+		style.Column{Name: "REQUIRED", Width: 8},
+		style.Column{Name: "DEFAULT", Width: 12},
+		style.Column{Name: "ALLOWED", Width: 20},
+		style.Column{Name: "DESCRIPTION", Width: 40},
+	)
+
+	names := make([]string, 0, len(f.Vars))
+	for name := range f.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := f.Vars[name]
+		required := "no"
+		if v.IsRequired() {
+			required = "yes"
+		}
+		table.AddRow(name, string(v.TypeName()), required, v.Default, v.Constraint(), v.Description)
+	}
+	return table.Render()
+}
+
+// formulaUsage returns an example gt sling invocation passing the formula's
+// required vars.
+func formulaUsage(f *formula.Formula) string {
+	usage := []string{"gt sling", f.Name, "<target>"}
+	names := make([]string, 0, len(f.Vars))
+	for name, v := range f.Vars {
+		if v.IsRequired() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		usage = append(usage, fmt.Sprintf("--var %s=<%s>", name, f.Vars[name].TypeName()))
+	}
+	return strings.Join(usage, " ")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFormulaVars(t *testing.T) {
+	townRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"mayor/rigs.json": `{"version": 1, "rigs": {"gastown": {}}}`,
+		".beads/formulas/review.formula.toml": `
+formula = "review"
+
+[vars.issue]
+type = "bead-id"
+required = true
+
+[vars.rig]
+type = "rig"
+required = true
+
+[vars.depth]
+enum = ["quick", "deep"]
+default = "quick"
+
+[[steps]]
+id = "review"
+title = "Review {{issue}} in {{rig}}"
+`,
+		".beads/formulas/review-strict.formula.toml": `
+formula = "review-strict"
+extends = ["review"]
+`,
+		".beads/formulas/broken.formula.toml": `formula = "broken`,
+	} {
+		full := filepath.Join(townRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	implicit := []string{"feature=Fix the thing", "issue=gt-abc12"}
+
+	if err := checkFormulaVars("review", []string{"rig=gastown", "depth=deep"}, implicit, townRoot, ""); err != nil {
+		t.Errorf("valid vars rejected: %v", err)
+	}
+	if err := checkFormulaVars("unknown-formula", []string{"anything=1"}, nil, townRoot); err != nil {
+		t.Errorf("formula gt cannot find should be left to bd: %v", err)
+	}
+
+	err := checkFormulaVars("review", []string{"rig=gastwon", "dpeth=deep"}, implicit, townRoot)
+	if err == nil {
+		t.Fatal("bad vars accepted")
+	}
+	for _, want := range []string{`unknown rig "gastwon" (did you mean gastown?)`, "unknown var dpeth (did you mean depth?)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+
+	if err := checkFormulaVars("review", []string{"rig=gastown"}, nil, townRoot); err == nil || !strings.Contains(err.Error(), "missing required var issue") {
+		t.Errorf("missing issue: err = %v", err)
+	}
+
+	// Vars inherited through extends are checked too.
+	if err := checkFormulaVars("review-strict", []string{"rig=gastwon"}, implicit, townRoot); err == nil || !strings.Contains(err.Error(), `unknown rig "gastwon"`) {
+		t.Errorf("composed formula: err = %v", err)
+	}
+	if err := checkFormulaVars("broken", nil, nil, townRoot); err == nil || !strings.Contains(err.Error(), "loading formula broken") {
+		t.Errorf("unparseable formula: err = %v", err)
+	}
+
+	f := loadMoleculeFormula("review", townRoot)
+	table := formulaVarsTable(f)
+	for _, want := range []string{"bead-id", "quick|deep", "yes"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("vars table missing %q:\n%s", want, table)
+		}
+	}
+	if got, want := formulaUsage(f), "gt sling review <target> --var issue=<bead-id> --var rig=<rig>"; got != want {
+		t.Errorf("usage = %q, want %q", got, want)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/graph"
 	"github.com/steveyegge/gastown/internal/style"
 )
 
@@ -46,11 +47,17 @@ Shows the dependency structure with execution tiers and status:
   ○ ready       - Step ready to execute (all deps met)
   ◌ blocked     - Step waiting on dependencies
 
+Use --format to export the graph with the same status colouring:
+  dot      Graphviz (render with: dot -Tsvg)
+  mermaid  Mermaid flowchart (renders in Markdown)
+  json     Nodes and edges, as also served to the dashboard
+
 Examples:
   gt mol dag gs-wisp-abc     # Show DAG for molecule
   gt mol dag gs-wisp-abc --json  # JSON output
   gt mol dag gs-wisp-abc --tree  # Tree view (default)
-  gt mol dag gs-wisp-abc --tiers # Group by execution tier`,
+  gt mol dag gs-wisp-abc --tiers # Group by execution tier
+  gt mol dag gs-wisp-abc --format dot | dot -Tsvg > dag.svg`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMoleculeDag,
 }
@@ -58,12 +65,14 @@ Examples:
 var (
 	dagShowTiers bool
 	dagTreeView  bool
+	dagFormat    string
 )
 
 func init() {
 	moleculeDagCmd.Flags().BoolVar(&dagShowTiers, "tiers", false, "Group output by execution tier")
 	moleculeDagCmd.Flags().BoolVar(&dagTreeView, "tree", true, "Show tree view (default)")
 	moleculeDagCmd.Flags().BoolVar(&moleculeJSON, "json", false, "Output as JSON")
+	moleculeDagCmd.Flags().StringVar(&dagFormat, "format", "text", "Output format: text, dot, mermaid, or json")
 }
 
 func runMoleculeDag(cmd *cobra.Command, args []string) error {
@@ -76,6 +85,20 @@ func runMoleculeDag(cmd *cobra.Command, args []string) error {
 
 	b := beads.New(workDir)
 
+	// Graph export
+	if dagFormat != "" && dagFormat != "text" {
+		g, err := graph.Molecule(b, rootID)
+		if err != nil {
+			return err
+		}
+		out, err := g.Render(dagFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+
 	// Get the root issue
 	root, err := b.Show(rootID)
 	if err != nil {
@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Step execution policies (formula step when/retry/timeout and step groups)
+// are not understood by bd, so gt stamps them on the step beads as
+// beads.StepFields right after a molecule is instantiated, and
+// gt mol step done enforces them from there. bd cooks such formulas from a
+// copy with each step's ID recorded in its description (see
+// flattenFormulaForBD), which bd carries onto the step bead.
+
+// findMoleculeFormula finds a formula by name in the .beads/formulas
+// directories under dirs, then in the formula search paths, and resolves
+// its extends and includes. Returns nil and no error if it is not found.
+// Formulas embedded in gt but not installed on disk don't count: bd, which
+// instantiates the molecule, cannot see them.
+func findMoleculeFormula(name string, dirs ...string) (*formula.Formula, error) {
+	paths := moleculeFormulaPaths(dirs...)
+	if installedFormula(name, paths) == nil {
+		return nil, nil
+	}
+	f, err := formula.Load(name, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("loading formula %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// moleculeFormulaPaths returns the .beads/formulas directories under dirs
+// followed by the formula search paths.
+func moleculeFormulaPaths(dirs ...string) []string {
+	var paths []string
+	for _, dir := range dirs {
+		if dir != "" {
+			paths = append(paths, filepath.Join(dir, ".beads", "formulas"))
+		}
+	}
+	return append(paths, formulaSearchPaths()...)
+}
+
+// installedFormula returns the source of <name>.formula.toml from the first
+// of paths that has it, or nil.
+func installedFormula(name string, paths []string) []byte {
+	for _, dir := range paths {
+		data, err := os.ReadFile(filepath.Join(dir, name+".formula.toml")) //nolint:gosec // G304: formula search path
+		if err == nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// loadMoleculeFormula is findMoleculeFormula for callers that carry on
+// without the formula: it returns nil if it is not found or does not parse.
+func loadMoleculeFormula(name string, dirs ...string) *formula.Formula {
+	f, err := findMoleculeFormula(name, dirs...)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// stampFormulaPolicies stamps the execution policies of formulaName on the
+// steps of a freshly instantiated molecule. Formulas without policies cost
+// no bd calls. Failures are reported as warnings: the molecule still works,
+// just without its policies.
+func stampFormulaPolicies(moleculeID, formulaName, workDir, townRoot string, varArgs []string) {
+	f := loadMoleculeFormula(formulaName, workDir, townRoot)
+	if f == nil || !f.HasExecutionPolicy() {
+		return
+	}
+
+	vars := make(map[string]string)
+	for name, v := range f.Vars {
+		if v.Default != "" {
+			vars[name] = v.Default
+		}
+	}
+	for _, arg := range varArgs {
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			vars[name] = value
+		}
+	}
+
+	b := beads.New(workDir)
+	if err := stampStepPolicies(b, moleculeID, f, vars); err != nil {
+		style.PrintWarning("could not record step policies on %s: %v", moleculeID, err)
+		return
+	}
+	if err := startFirstSteps(b, moleculeID, time.Now()); err != nil {
+		style.PrintWarning("could not start step timeouts on %s: %v", moleculeID, err)
+	}
+}
+
+// startFirstSteps starts the timeout clock on the steps of a freshly
+// slung molecule that are ready from the outset. Later steps get theirs
+// when gt mol step done pins them, but nothing pins the first ones: the
+// agent picks them up straight from its hook.
+func startFirstSteps(b *beads.Beads, moleculeID string, now time.Time) error {
+	ready, _, err := findAllReadySteps(b, moleculeID)
+	if err != nil {
+		return err
+	}
+	for _, step := range ready {
+		fields := beads.ParseStepFields(step)
+		if fields == nil || fields.Timeout == "" || fields.StartedAt != "" {
+			continue
+		}
+		fields.StartedAt = now.UTC().Format(time.RFC3339)
+		description := beads.SetStepFields(step, fields)
+		if err := b.Update(step.ID, beads.UpdateOptions{Description: &description}); err != nil {
+			return fmt.Errorf("updating step %s: %w", step.ID, err)
+		}
+	}
+	return nil
+}
+
+// recordFormulaStepIDs records each step's ID in its description as a
+// step_id field, for bd to copy onto the step bead it creates.
+func recordFormulaStepIDs(f *formula.Formula) {
+	for i := range f.Steps {
+		step := &f.Steps[i]
+		step.Description = beads.SetStepFields(&beads.Issue{Description: step.Description}, &beads.StepFields{StepID: step.ID})
+	}
+}
+
+// stampStepPolicies writes the policy of each formula step that has one (or
+// that a condition refers to) onto the matching step bead. Step beads are
+// matched to formula steps by the step ID recorded on them (see
+// recordFormulaStepIDs).
+func stampStepPolicies(b *beads.Beads, moleculeID string, f *formula.Formula, vars map[string]string) error {
+	children, err := b.List(beads.ListOptions{
+		Parent:   moleculeID,
+		Status:   "all",
+		Priority: -1,
+	})
+	if err != nil {
+		return fmt.Errorf("listing molecule steps: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	addRefs := func(expr string) {
+		if cond, err := formula.ParseCondition(expr); err == nil {
+			for _, id := range cond.StepRefs() {
+				referenced[id] = true
+			}
+		}
+	}
+	for _, step := range f.Steps {
+		if step.When != "" {
+			addRefs(step.When)
+		}
+	}
+	for _, group := range f.Groups {
+		addRefs(group.RepeatUntil)
+	}
+
+	byStepID := make(map[string]*beads.Issue)
+	for _, child := range children {
+		if fields := beads.ParseStepFields(child); fields != nil && fields.StepID != "" {
+			byStepID[fields.StepID] = child
+		}
+	}
+
+	for _, step := range f.Steps {
+		group := f.GetGroup(step.ID)
+		if step.When == "" && step.Retry == nil && step.Timeout == "" && group == nil && !referenced[step.ID] {
+			continue
+		}
+
+		bead := byStepID[step.ID]
+		if bead == nil {
+			return fmt.Errorf("no step bead records step %s (was %s cooked by gt?)", step.ID, f.Name)
+		}
+
+		fields := beads.ParseStepFields(bead)
+		if step.When != "" {
+			fields.When = bindCondition(step.When, vars)
+		}
+		if step.Retry != nil {
+			fields.MaxAttempts = step.Retry.MaxAttempts
+		}
+		fields.Timeout = step.Timeout
+		if group != nil {
+			fields.Group = group.ID
+			fields.RepeatUntil = bindCondition(group.RepeatUntil, vars)
+			fields.MaxIterations = group.Iterations()
+		}
+
+		description := beads.SetStepFields(bead, fields)
+		if err := b.Update(bead.ID, beads.UpdateOptions{Description: &description}); err != nil {
+			return fmt.Errorf("updating step %s: %w", bead.ID, err)
+		}
+	}
+	return nil
+}
+
+// bindCondition substitutes vars into a condition, leaving step references
+// to be resolved at run time.
+func bindCondition(expr string, vars map[string]string) string {
+	cond, err := formula.ParseCondition(expr)
+	if err != nil {
+		return expr
+	}
+	return cond.Bind(vars)
+}
+
+// stepConditionEnv builds the environment conditions are evaluated in from
+// a molecule's step beads.
+func stepConditionEnv(steps []*beads.Issue) formula.ConditionEnv {
+	env := formula.ConditionEnv{Steps: make(map[string]formula.StepState)}
+	for _, step := range steps {
+		fields := beads.ParseStepFields(step)
+		if fields == nil || fields.StepID == "" {
+			continue
+		}
+		state := formula.StepState{Status: step.Status, Output: fields.Output}
+		if fields.Skipped {
+			state.Status = "skipped"
+		}
+		env.Steps[fields.StepID] = state
+	}
+	return env
+}
+
+// evalStepCondition evaluates a stamped condition. A condition that no
+// longer parses counts as true, so the step runs rather than vanishing.
+func evalStepCondition(expr string, env formula.ConditionEnv) bool {
+	cond, err := formula.ParseCondition(expr)
+	if err != nil {
+		style.PrintWarning("ignoring bad step condition %q: %v", expr, err)
+		return true
+	}
+	return cond.Eval(env)
+}
+
+// findRunnableSteps is findAllReadySteps that also skips ready steps whose
+// when condition is false. Skipped steps are closed, which can make further
+// steps ready, so it repeats until no more steps are skipped.
+func findRunnableSteps(b *beads.Beads, moleculeID string) (ready []*beads.Issue, allComplete bool, skipped []string, err error) {
+	for {
+		ready, allComplete, err = findAllReadySteps(b, moleculeID)
+		if err != nil || allComplete {
+			return ready, allComplete, skipped, err
+		}
+
+		var conditional []*beads.Issue
+		for _, step := range ready {
+			if fields := beads.ParseStepFields(step); fields != nil && fields.When != "" {
+				conditional = append(conditional, step)
+			}
+		}
+		if len(conditional) == 0 {
+			return ready, false, skipped, nil
+		}
+
+		children, listErr := b.List(beads.ListOptions{
+			Parent:   moleculeID,
+			Status:   "all",
+			Priority: -1,
+		})
+		if listErr != nil {
+			return nil, false, skipped, fmt.Errorf("listing molecule steps: %w", listErr)
+		}
+		env := stepConditionEnv(children)
+
+		skippedNow := 0
+		for _, step := range conditional {
+			fields := beads.ParseStepFields(step)
+			if evalStepCondition(fields.When, env) {
+				continue
+			}
+			fields.Skipped = true
+			description := beads.SetStepFields(step, fields)
+			if err := b.Update(step.ID, beads.UpdateOptions{Description: &description}); err != nil {
+				return nil, false, skipped, fmt.Errorf("marking step %s skipped: %w", step.ID, err)
+			}
+			if err := b.CloseWithReason("skipped: condition is false: "+fields.When, step.ID); err != nil {
+				return nil, false, skipped, fmt.Errorf("closing skipped step %s: %w", step.ID, err)
+			}
+			skipped = append(skipped, step.ID)
+			skippedNow++
+
+			// Skipping the last step of a group iteration ends the iteration
+			if _, err := repeatStepGroup(b, moleculeID, fields); err != nil {
+				return nil, false, skipped, fmt.Errorf("repeating step group: %w", err)
+			}
+		}
+		if skippedNow == 0 {
+			return ready, false, skipped, nil
+		}
+	}
+}
+
+// stepTimedOut reports whether the current attempt at a step has run
+// longer than its timeout.
+func stepTimedOut(fields *beads.StepFields, now time.Time) bool {
+	if fields == nil || fields.Timeout == "" || fields.StartedAt == "" {
+		return false
+	}
+	timeout, err := time.ParseDuration(fields.Timeout)
+	if err != nil {
+		return false
+	}
+	started, err := time.Parse(time.RFC3339, fields.StartedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(started) > timeout
+}
+
+// maxStepAttempts returns how many attempts a step is allowed.
+func maxStepAttempts(fields *beads.StepFields) int {
+	if fields == nil || fields.MaxAttempts < 1 {
+		return 1
+	}
+	return fields.MaxAttempts
+}
+
+// failStepAttempt records a failed attempt at a step. If the retry policy
+// allows another attempt the step is reopened and retry is true; otherwise
+// the step is marked blocked until someone fixes the cause and closes it.
+func failStepAttempt(b *beads.Beads, step *beads.Issue, fields *beads.StepFields) (attempt int, retry bool, err error) {
+	if fields == nil {
+		fields = &beads.StepFields{}
+	}
+	fields.Attempts++
+	fields.StartedAt = ""
+	attempt = fields.Attempts
+	retry = attempt < maxStepAttempts(fields)
+
+	description := beads.SetStepFields(step, fields)
+	status := "blocked"
+	if retry {
+		status = "open"
+	}
+	if err := b.Update(step.ID, beads.UpdateOptions{Status: &status, Description: &description}); err != nil {
+		return attempt, false, fmt.Errorf("recording failed attempt: %w", err)
+	}
+	step.Description = description
+	step.Status = status
+	return attempt, retry, nil
+}
+
+// escalateStepFailure raises an escalation through gt escalate for a step
+// that has used up its attempts.
+func escalateStepFailure(moleculeID string, step *beads.Issue, reason string) error {
+	description := fmt.Sprintf("Molecule step %s (%s) failed", step.ID, step.Title)
+	cmd := exec.Command("gt", "escalate", description, "--severity", config.SeverityMedium, //nolint:gosec // G204: args are constructed internally
+		"--reason", reason, "--source", "molecule:"+moleculeID, "--related", step.ID)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// repeatStepGroup starts another iteration of a step's group if the step
+// was the last open one of the iteration and the group's repeat_until
+// condition is still false. Returns the group's new iteration number, or 0
+// if the group was not repeated.
+func repeatStepGroup(b *beads.Beads, moleculeID string, fields *beads.StepFields) (int, error) {
+	if fields == nil || fields.Group == "" {
+		return 0, nil
+	}
+
+	children, err := b.List(beads.ListOptions{
+		Parent:   moleculeID,
+		Status:   "all",
+		Priority: -1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("listing molecule steps: %w", err)
+	}
+
+	var members []*beads.Issue
+	iteration := 0
+	for _, child := range children {
+		childFields := beads.ParseStepFields(child)
+		if childFields == nil || childFields.Group != fields.Group {
+			continue
+		}
+		if child.Status != "closed" {
+			return 0, nil // Iteration still in progress
+		}
+		members = append(members, child)
+		if childFields.Iteration > iteration {
+			iteration = childFields.Iteration
+		}
+	}
+
+	if fields.RepeatUntil == "" || evalStepCondition(fields.RepeatUntil, stepConditionEnv(children)) {
+		return 0, nil
+	}
+	iteration++
+	limit := fields.MaxIterations
+	if limit < 1 {
+		limit = formula.DefaultMaxIterations
+	}
+	if iteration >= limit {
+		style.PrintWarning("group %s stopped after %d iterations without reaching: %s", fields.Group, iteration, fields.RepeatUntil)
+		return 0, nil
+	}
+
+	// Reopen the group, clearing the previous iteration's state.
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	status := "open"
+	for _, member := range members {
+		memberFields := beads.ParseStepFields(member)
+		memberFields.Iteration = iteration
+		memberFields.Attempts = 0
+		memberFields.StartedAt = ""
+		memberFields.Output = ""
+		memberFields.Skipped = false
+		description := beads.SetStepFields(member, memberFields)
+		if err := b.Update(member.ID, beads.UpdateOptions{Status: &status, Description: &description}); err != nil {
+			return 0, fmt.Errorf("reopening step %s: %w", member.ID, err)
+		}
+	}
+	return iteration, nil
+}
+
+// stepStartArgs returns the extra bd update arguments that start an attempt
+// at a step: a step with a timeout records when the attempt started.
+func stepStartArgs(step *beads.Issue) []string {
+	fields := beads.ParseStepFields(step)
+	if fields == nil || fields.Timeout == "" {
+		return nil
+	}
+	fields.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	return []string{"--description=" + beads.SetStepFields(step, fields)}
+}
+
+// annotateStepPolicies adds skipped, failed, timed-out and retrying steps
+// to a molecule's progress.
+func annotateStepPolicies(progress *MoleculeProgressInfo, steps []*beads.Issue, now time.Time) {
+	for _, step := range steps {
+		fields := beads.ParseStepFields(step)
+		if fields == nil {
+			continue
+		}
+		switch {
+		case step.Status == "closed" && fields.Skipped:
+			progress.SkippedSteps = append(progress.SkippedSteps, step.ID)
+		case step.Status == "closed":
+		case step.Status == "blocked" && fields.Attempts >= maxStepAttempts(fields):
+			progress.FailedSteps = append(progress.FailedSteps, step.ID)
+		case stepTimedOut(fields, now):
+			progress.TimedOutSteps = append(progress.TimedOutSteps, step.ID)
+		case fields.Attempts > 0:
+			progress.RetryingSteps = append(progress.RetryingSteps,
+				step.ID+" (attempt "+strconv.Itoa(fields.Attempts+1)+"/"+strconv.Itoa(maxStepAttempts(fields))+")")
+		}
+	}
+}
+
+// printStepPolicyStatus prints the lines annotateStepPolicies feeds.
+func printStepPolicyStatus(progress *MoleculeProgressInfo) {
+	if len(progress.SkippedSteps) > 0 {
+		fmt.Printf("  Skipped:     %d (%s)\n", len(progress.SkippedSteps), strings.Join(progress.SkippedSteps, ", "))
+	}
+	if len(progress.RetryingSteps) > 0 {
+		fmt.Printf("  Retrying:    %s\n", strings.Join(progress.RetryingSteps, ", "))
+	}
+	if len(progress.TimedOutSteps) > 0 {
+		fmt.Printf("  %s %s\n", style.Bold.Render("Timed out:  "), strings.Join(progress.TimedOutSteps, ", "))
+	}
+	if len(progress.FailedSteps) > 0 {
+		fmt.Printf("  %s %s\n", style.Bold.Render("Failed:     "), strings.Join(progress.FailedSteps, ", "))
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// newPolicyTestMolecule instantiates a formula the way bd does from the
+// copy gt cooks - a root with one child per step carrying its description,
+// chained by "blocks" dependencies - in a town using the native beads
+// store, and returns the step bead IDs by step ID.
+func newPolicyTestMolecule(t *testing.T, f *formula.Formula) (*beads.Beads, string, map[string]string) {
+	t.Helper()
+	townRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"mayor/town.json":    "{}",
+		".beads/config.yaml": "issue-prefix: gt\n",
+	} {
+		full := filepath.Join(townRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GT_BEADS_BACKEND", "native")
+	t.Cleanup(beads.WriteJSONLForTesting())
+
+	b := beads.New(townRoot)
+	root, err := b.CreateWithID("gt-mol", beads.CreateOptions{Title: f.Name, Priority: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordFormulaStepIDs(f)
+	ids := make(map[string]string)
+	for _, step := range f.Steps {
+		child, err := b.Create(beads.CreateOptions{Title: step.Title, Description: step.Description, Parent: root.ID, Priority: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[step.ID] = child.ID
+		for _, need := range step.Needs {
+			if err := b.AddDependency(child.ID, ids[need]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := stampStepPolicies(b, root.ID, f, map[string]string{"mode": "full"}); err != nil {
+		t.Fatal(err)
+	}
+	return b, root.ID, ids
+}
+
+func readyIDs(steps []*beads.Issue) []string {
+	var ids []string
+	for _, s := range steps {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func closeStepWithOutput(t *testing.T, b *beads.Beads, id, output string) *beads.StepFields {
+	t.Helper()
+	step, err := b.Show(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := beads.ParseStepFields(step)
+	if fields == nil {
+		fields = &beads.StepFields{}
+	}
+	fields.Output = output
+	description := beads.SetStepFields(step, fields)
+	if err := b.Update(id, beads.UpdateOptions{Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(id); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestStepPoliciesSkipAndRetry(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "policy-test"
+
+[vars.mode]
+description = "Run mode"
+
+[[steps]]
+id = "check"
+title = "Check"
+description = "Look around."
+
+[[steps]]
+id = "fix"
+title = "Fix"
+needs = ["check"]
+when = 'steps.check.output == "dirty" && {{mode}} == "full"'
+
+[[steps]]
+id = "verify"
+title = "Verify"
+needs = ["fix"]
+timeout = "1m"
+retry = { max_attempts = 2 }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, molID, ids := newPolicyTestMolecule(t, f)
+
+	fix, err := b.Show(ids["fix"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixFields := beads.ParseStepFields(fix)
+	if fixFields == nil || fixFields.StepID != "fix" || fixFields.When != `steps.check.output == "dirty" && "full" == "full"` {
+		t.Fatalf("fix fields = %+v", fixFields)
+	}
+
+	ready, _, skipped, err := findRunnableSteps(b, molID)
+	if err != nil || !reflect.DeepEqual(readyIDs(ready), []string{ids["check"]}) || skipped != nil {
+		t.Fatalf("first ready = %v, skipped %v, err %v", readyIDs(ready), skipped, err)
+	}
+
+	closeStepWithOutput(t, b, ids["check"], "clean")
+	ready, _, skipped, err = findRunnableSteps(b, molID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(skipped, []string{ids["fix"]}) || !reflect.DeepEqual(readyIDs(ready), []string{ids["verify"]}) {
+		t.Fatalf("after check: ready %v, skipped %v", readyIDs(ready), skipped)
+	}
+
+	verify := ready[0]
+	fields := beads.ParseStepFields(verify)
+	fields.StartedAt = time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)
+	if !stepTimedOut(fields, time.Now()) {
+		t.Error("attempt started 2m ago with a 1m timeout is not timed out")
+	}
+
+	attempt, retry, err := failStepAttempt(b, verify, fields)
+	if err != nil || attempt != 1 || !retry {
+		t.Fatalf("first failure = attempt %d, retry %v, err %v", attempt, retry, err)
+	}
+	verify, _ = b.Show(ids["verify"])
+	if verify.Status != "open" {
+		t.Errorf("retried step status = %s, want open", verify.Status)
+	}
+
+	progress := &MoleculeProgressInfo{}
+	children, _ := b.List(beads.ListOptions{Parent: molID, Status: "all", Priority: -1})
+	annotateStepPolicies(progress, children, time.Now())
+	if !reflect.DeepEqual(progress.SkippedSteps, []string{ids["fix"]}) ||
+		!reflect.DeepEqual(progress.RetryingSteps, []string{ids["verify"] + " (attempt 2/2)"}) {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	attempt, retry, err = failStepAttempt(b, verify, beads.ParseStepFields(verify))
+	if err != nil || attempt != 2 || retry {
+		t.Fatalf("second failure = attempt %d, retry %v, err %v", attempt, retry, err)
+	}
+	verify, _ = b.Show(ids["verify"])
+	if verify.Status != "blocked" {
+		t.Errorf("step out of attempts has status %s, want blocked", verify.Status)
+	}
+
+	progress = &MoleculeProgressInfo{}
+	children, _ = b.List(beads.ListOptions{Parent: molID, Status: "all", Priority: -1})
+	annotateStepPolicies(progress, children, time.Now())
+	if !reflect.DeepEqual(progress.FailedSteps, []string{ids["verify"]}) || len(progress.RetryingSteps) != 0 {
+		t.Errorf("progress after last attempt = %+v", progress)
+	}
+}
+
+func TestStampStepPoliciesMatchesStepIDs(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "same-titles"
+
+[[steps]]
+id = "first"
+title = "Check"
+
+[[steps]]
+id = "second"
+title = "Check"
+needs = ["first"]
+timeout = "5m"
+retry = { max_attempts = 3 }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, molID, ids := newPolicyTestMolecule(t, f)
+
+	// Edit a title after pour; restamping must still find the step.
+	title := "Check again"
+	if err := b.Update(ids["second"], beads.UpdateOptions{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stampStepPolicies(b, molID, f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := b.Show(ids["first"])
+	if fields := beads.ParseStepFields(first); fields.StepID != "first" || fields.Timeout != "" || fields.MaxAttempts != 0 {
+		t.Errorf("first step fields = %+v, want no policy", fields)
+	}
+	second, _ := b.Show(ids["second"])
+	if fields := beads.ParseStepFields(second); fields.StepID != "second" || fields.Timeout != "5m" || fields.MaxAttempts != 3 {
+		t.Errorf("second step fields = %+v, want its policy", fields)
+	}
+}
+
+func TestFlattenFormulaForBDRecordsStepIDs(t *testing.T) {
+	townRoot := t.TempDir()
+	formulas := filepath.Join(townRoot, ".beads", "formulas")
+	if err := os.MkdirAll(formulas, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"plain":  "formula = \"plain\"\n\n[[steps]]\nid = \"build\"\ntitle = \"Build\"\n",
+		"policy": "formula = \"policy\"\n\n[[steps]]\nid = \"build\"\ntitle = \"Build\"\ndescription = \"Run make.\"\ntimeout = \"5m\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(formulas, name+".formula.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if dir, err := flattenFormulaForBD("plain", townRoot); err != nil || dir != "" {
+		t.Errorf("plain formula flattened to %q, %v; want it left to bd", dir, err)
+	}
+
+	dir, err := flattenFormulaForBD("policy", townRoot)
+	if err != nil || dir == "" {
+		t.Fatalf("flattenFormulaForBD = %q, %v", dir, err)
+	}
+	defer os.RemoveAll(dir)
+	f, err := formula.ParseFile(filepath.Join(dir, ".beads", "formulas", "policy.formula.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := &beads.Issue{Description: f.Steps[0].Description}
+	if fields := beads.ParseStepFields(step); fields == nil || fields.StepID != "build" || !strings.Contains(step.Description, "Run make.") {
+		t.Errorf("flattened step description = %q", step.Description)
+	}
+}
+
+func TestStepGroupRepeatsUntilConditionHolds(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "group-test"
+
+[[steps]]
+id = "poll"
+title = "Poll"
+
+[[steps]]
+id = "report"
+title = "Report"
+needs = ["poll"]
+
+[[groups]]
+id = "wait"
+steps = ["poll"]
+repeat_until = 'steps.poll.output == "ready"'
+max_iterations = 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, molID, ids := newPolicyTestMolecule(t, f)
+
+	for want := 1; want <= 2; want++ {
+		fields := closeStepWithOutput(t, b, ids["poll"], "pending")
+		iteration, err := repeatStepGroup(b, molID, fields)
+		if err != nil || iteration != want {
+			t.Fatalf("iteration = %d, err %v; want %d", iteration, err, want)
+		}
+		poll, _ := b.Show(ids["poll"])
+		if pollFields := beads.ParseStepFields(poll); poll.Status != "open" || pollFields.Output != "" || pollFields.Iteration != want {
+			t.Fatalf("reopened poll = %s %+v", poll.Status, pollFields)
+		}
+	}
+
+	// The third iteration is the last one allowed.
+	fields := closeStepWithOutput(t, b, ids["poll"], "pending")
+	if iteration, err := repeatStepGroup(b, molID, fields); err != nil || iteration != 0 {
+		t.Fatalf("repeated past max_iterations: %d, %v", iteration, err)
+	}
+	ready, _, _, err := findRunnableSteps(b, molID)
+	if err != nil || !reflect.DeepEqual(readyIDs(ready), []string{ids["report"]}) {
+		t.Fatalf("ready after group = %v, %v", readyIDs(ready), err)
+	}
+}
+
+func TestStartFirstStepsStartsTimeoutClock(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "timeout-test"
+
+[[steps]]
+id = "build"
+title = "Build"
+timeout = "10m"
+
+[[steps]]
+id = "ship"
+title = "Ship"
+needs = ["build"]
+timeout = "5m"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, molID, ids := newPolicyTestMolecule(t, f)
+
+	now := time.Now().Add(-time.Hour)
+	if err := startFirstSteps(b, molID, now); err != nil {
+		t.Fatal(err)
+	}
+	build, _ := b.Show(ids["build"])
+	if fields := beads.ParseStepFields(build); !stepTimedOut(fields, time.Now()) {
+		t.Errorf("first step started an hour ago is not timed out: %+v", fields)
+	}
+	ship, _ := b.Show(ids["ship"])
+	if fields := beads.ParseStepFields(ship); fields.StartedAt != "" {
+		t.Errorf("blocked step StartedAt = %q, want unset", fields.StartedAt)
+	}
+}
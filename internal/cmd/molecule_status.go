@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
@@ -109,6 +110,12 @@ type MoleculeProgressInfo struct {
 	BlockedSteps []string `json:"blocked_steps"`
 	Percent      int      `json:"percent_complete"`
 	Complete     bool     `json:"complete"`
+
+	// Step execution policies (see molecule_policy.go)
+	SkippedSteps  []string `json:"skipped_steps,omitempty"`   // Closed because their condition was false
+	TimedOutSteps []string `json:"timed_out_steps,omitempty"` // Current attempt exceeded its timeout
+	RetryingSteps []string `json:"retrying_steps,omitempty"`  // Retried after failed attempts, with attempt count
+	FailedSteps   []string `json:"failed_steps,omitempty"`    // Blocked after their last attempt failed
 }
 
 // MoleculeStatusInfo contains status information for an agent's work.
@@ -247,6 +254,8 @@ func runMoleculeProgress(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	annotateStepPolicies(&progress, children, time.Now())
+
 	// Calculate completion percentage
 	if progress.TotalSteps > 0 {
 		progress.Percent = (progress.DoneSteps * 100) / progress.TotalSteps
@@ -283,6 +292,7 @@ func runMoleculeProgress(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 	fmt.Printf("  Blocked:     %d\n", len(progress.BlockedSteps))
+	printStepPolicyStatus(&progress)
 
 	if progress.Complete {
 		fmt.Printf("\n  %s\n", style.Bold.Render("✓ Molecule complete!"))
@@ -620,6 +630,8 @@ func getMoleculeProgressInfo(b *beads.Beads, moleculeRootID string) (*MoleculePr
 		}
 	}
 
+	annotateStepPolicies(progress, children, time.Now())
+
 	// Calculate completion percentage
 	if progress.TotalSteps > 0 {
 		progress.Percent = (progress.DoneSteps * 100) / progress.TotalSteps
@@ -738,6 +750,7 @@ func outputMoleculeStatus(status MoleculeStatusInfo) error {
 		}
 		fmt.Println()
 		fmt.Printf("  Blocked:     %d\n", len(status.Progress.BlockedSteps))
+		printStepPolicyStatus(status.Progress)
 
 		if status.Progress.Complete {
 			fmt.Printf("\n%s\n", style.Bold.Render("✓ Molecule complete!"))
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
@@ -34,21 +35,41 @@ This command handles the step-to-step transition for polecats:
    - Sends POLECAT_DONE to witness
    - Exits the session
 
+Steps from formulas with execution policies are handled as the formula says:
+
+- Steps whose 'when' condition is false are skipped (closed as skipped).
+- --failed, or finishing after the step's timeout, counts as a failed attempt.
+  The step is reopened and retried while its retry policy allows. Once the
+  attempts are used up the step is marked blocked, the failure is escalated
+  and the command fails; run it again without --failed to close the step
+  once the cause is fixed.
+- When the last step of a repeating group closes and the group's
+  repeat_until condition is false, the group's steps are reopened.
+
+Use --output to record a result that later conditions can test as
+steps.<id>.output.
+
 IMPORTANT: This is the canonical way to complete molecule steps. Do NOT manually
 close steps with 'bd close' - it skips the auto-continuation logic.
 
 Example:
-  gt mol step done gt-abc.1    # Complete step 1 of molecule gt-abc`,
+  gt mol step done gt-abc.1                  # Complete step 1 of molecule gt-abc
+  gt mol step done gt-abc.2 --output=clean   # Complete step 2, recording its output
+  gt mol step done gt-abc.3 --failed         # Step 3 failed; retry it if allowed`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMoleculeStepDone,
 }
 
 var (
 	moleculeStepDryRun bool
+	moleculeStepOutput string
+	moleculeStepFailed bool
 )
 
 func init() {
 	moleculeStepDoneCmd.Flags().BoolVarP(&moleculeStepDryRun, "dry-run", "n", false, "Show what would be done without executing")
+	moleculeStepDoneCmd.Flags().StringVar(&moleculeStepOutput, "output", "", "Record the step's output for later conditions (steps.<id>.output)")
+	moleculeStepDoneCmd.Flags().BoolVar(&moleculeStepFailed, "failed", false, "Record a failed attempt instead of completing the step")
 	moleculeStepDoneCmd.Flags().BoolVar(&moleculeJSON, "json", false, "Output as JSON")
 }
 
@@ -60,8 +81,11 @@ type StepDoneResult struct {
 	NextStepID    string   `json:"next_step_id,omitempty"`
 	NextStepTitle string   `json:"next_step_title,omitempty"`
 	ParallelSteps []string `json:"parallel_steps,omitempty"` // Multiple ready steps for fan-out
+	SkippedSteps  []string `json:"skipped_steps,omitempty"`  // Steps skipped because their condition was false
+	Attempt       int      `json:"attempt,omitempty"`        // Failed attempts so far (retry/failed)
+	Iteration     int      `json:"iteration,omitempty"`      // Iteration a repeating group started
 	Complete      bool     `json:"complete"`
-	Action        string   `json:"action"` // "continue", "parallel", "done", "no_more_ready"
+	Action        string   `json:"action"` // "continue", "parallel", "done", "no_more_ready", "retry", "failed"
 }
 
 func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
@@ -106,23 +130,65 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		MoleculeID: moleculeID,
 	}
 
+	// A failed or timed-out attempt is retried instead of closing the step
+	fields := beads.ParseStepFields(step)
+	failure := ""
+	if moleculeStepFailed {
+		failure = "failed"
+	} else if stepTimedOut(fields, time.Now()) {
+		failure = "timed out after " + fields.Timeout
+	}
+	if failure != "" {
+		return handleStepFailure(cwd, townRoot, workDir, b, step, fields, failure, result)
+	}
+
 	// Step 3: Close the step
 	if moleculeStepDryRun {
 		fmt.Printf("[dry-run] Would close step: %s\n", stepID)
 		result.StepClosed = true
 	} else {
+		if moleculeStepOutput != "" {
+			if fields == nil {
+				fields = &beads.StepFields{}
+			}
+			fields.Output = moleculeStepOutput
+			description := beads.SetStepFields(step, fields)
+			if err := b.Update(stepID, beads.UpdateOptions{Description: &description}); err != nil {
+				return fmt.Errorf("recording step output: %w", err)
+			}
+		}
 		if err := b.Close(stepID); err != nil {
 			return fmt.Errorf("closing step: %w", err)
 		}
 		result.StepClosed = true
 		fmt.Printf("%s Closed step %s: %s\n", style.Bold.Render("✓"), stepID, step.Title)
+
+		iteration, err := repeatStepGroup(b, moleculeID, fields)
+		if err != nil {
+			return fmt.Errorf("repeating step group: %w", err)
+		}
+		if iteration > 0 {
+			result.Iteration = iteration
+			fmt.Printf("%s Repeating group %s (iteration %d): %s is not yet true\n",
+				style.Bold.Render("↻"), fields.Group, iteration+1, fields.RepeatUntil)
+		}
 	}
 
-	// Step 4: Find all ready steps (supports fan-out pattern)
-	readySteps, allComplete, err := findAllReadySteps(b, moleculeID)
+	// Step 4: Find all ready steps (supports fan-out pattern), skipping
+	// steps whose condition is false
+	var readySteps []*beads.Issue
+	var allComplete bool
+	if moleculeStepDryRun {
+		readySteps, allComplete, err = findAllReadySteps(b, moleculeID)
+	} else {
+		readySteps, allComplete, result.SkippedSteps, err = findRunnableSteps(b, moleculeID)
+	}
 	if err != nil {
 		return fmt.Errorf("finding next steps: %w", err)
 	}
+	for _, id := range result.SkippedSteps {
+		fmt.Printf("%s Skipped step %s: condition is false\n", style.Dim.Render("○"), id)
+	}
 
 	if allComplete {
 		result.Complete = true
@@ -171,6 +237,60 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// handleStepFailure records a failed attempt at a step and retries it if
+// its retry policy allows. Otherwise the step is marked blocked, the failure
+// is escalated and an error is returned; once the cause is fixed,
+// gt mol step done closes the step.
+func handleStepFailure(cwd, townRoot, workDir string, b *beads.Beads, step *beads.Issue, fields *beads.StepFields, failure string, result StepDoneResult) error {
+	maxAttempts := maxStepAttempts(fields)
+	if moleculeStepDryRun {
+		attempts := 1
+		if fields != nil {
+			attempts += fields.Attempts
+		}
+		fmt.Printf("[dry-run] Would record failed attempt %d/%d at step %s (%s)\n", attempts, maxAttempts, step.ID, failure)
+		return nil
+	}
+
+	attempt, retry, err := failStepAttempt(b, step, fields)
+	if err != nil {
+		return err
+	}
+	result.Attempt = attempt
+	if retry {
+		result.Action = "retry"
+		result.NextStepID = step.ID
+		result.NextStepTitle = step.Title
+	} else {
+		result.Action = "failed"
+	}
+
+	if moleculeJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s Step %s %s (attempt %d/%d)\n", style.Bold.Render("✗"), step.ID, failure, attempt, maxAttempts)
+	}
+
+	if !retry {
+		reason := fmt.Sprintf("step %s %s; no attempts left (%d/%d)", step.ID, failure, attempt, maxAttempts)
+		if err := escalateStepFailure(result.MoleculeID, step, reason); err != nil {
+			style.PrintWarning("could not escalate failed step %s: %v", step.ID, err)
+		} else if !moleculeJSON {
+			fmt.Printf("%s Escalated failed step %s\n", style.Bold.Render("⚠"), step.ID)
+		}
+		return fmt.Errorf("%s - step marked blocked; fix the cause, then run gt mol step done %s", reason, step.ID)
+	}
+	if moleculeJSON {
+		return nil
+	}
+	fmt.Printf("%s Retrying step %s\n", style.Bold.Render("↻"), step.ID)
+	return handleStepContinue(cwd, townRoot, workDir, step, false)
+}
+
 // extractMoleculeIDFromStep extracts the molecule ID from a step ID.
 // Step IDs have format: mol-id.N where N is the step number.
 // Examples:
@@ -403,7 +523,8 @@ func handleStepContinue(cwd, townRoot, _ string, nextStep *beads.Issue, dryRun b
 	}
 
 	// Pin the next step bead
-	pinCmd := exec.Command("bd", "update", nextStep.ID, "--status=pinned", "--assignee="+agentID)
+	pinArgs := append([]string{"update", nextStep.ID, "--status=pinned", "--assignee=" + agentID}, stepStartArgs(nextStep)...)
+	pinCmd := exec.Command("bd", pinArgs...)
 	pinCmd.Dir = gitRoot
 	pinCmd.Stderr = os.Stderr
 	if err := pinCmd.Run(); err != nil {
@@ -482,7 +603,8 @@ func handleParallelSteps(cwd, townRoot, workDir string, steps []*beads.Issue, dr
 	}
 
 	for _, step := range steps {
-		markCmd := exec.Command("bd", "update", step.ID, "--status=in_progress")
+		markArgs := append([]string{"update", step.ID, "--status=in_progress"}, stepStartArgs(step)...)
+		markCmd := exec.Command("bd", markArgs...)
 		markCmd.Dir = gitRoot
 		markCmd.Stderr = os.Stderr
 		if err := markCmd.Run(); err != nil {
@@ -20,6 +20,10 @@ var (
 	mqSubmitBranch    string
 	mqSubmitIssue     string
 	mqSubmitEpic      string
+	mqSubmitTarget    string
+	mqSubmitBackports []string
+	mqSubmitStrategy  string
+	mqSubmitTemplate  string
 	mqSubmitPriority  int
 	mqSubmitNoCleanup bool
 
@@ -40,6 +44,9 @@ var (
 	// Status command flags
 	mqStatusJSON bool
 
+	// Flaky command flags
+	mqFlakyJSON bool
+
 	// Integration land flags
 	mqIntegrationLandForce     bool
 	mqIntegrationLandSkipTests bool
@@ -82,12 +89,24 @@ Auto-detection:
   - Priority: inherited from source issue
 
 Target branch auto-detection:
-  1. If --epic is specified: target integration/<epic>
-  2. If source issue has a parent epic with integration/<epic> branch: target it
-  3. Otherwise: target main
+  1. If --target is specified: target that branch
+  2. If --epic is specified: target integration/<epic>
+  3. If source issue has a parent epic with integration/<epic> branch: target it
+  4. Otherwise: target main
 
 This ensures batch work on epics automatically flows to integration branches.
 
+Backports:
+  --backport release/1.2 (repeatable) asks the Refinery to cherry-pick the
+  merge onto that branch too, after it lands. A "backport:release/1.2" label
+  on the source issue does the same.
+
+Merge strategy:
+  --strategy squash|rebase|merge and --commit-template override the rig's
+  merge_queue.merge_strategy and commit_template for this MR. Templates
+  take {message}, {title}, {issue}, {mr}, {branch}, {target}, {polecat},
+  {rig} and {coauthors}; write newlines as \n.
+
 Polecat auto-cleanup:
   When run from a polecat work branch (polecat/<worker>/<issue>), this command
   automatically triggers polecat shutdown after submitting the MR. The polecat
@@ -100,6 +119,8 @@ Examples:
   gt mq submit                           # Auto-detect everything + auto-cleanup
   gt mq submit --issue gp-abc            # Explicit issue
   gt mq submit --epic gt-xyz             # Target integration branch explicitly
+  gt mq submit --backport release/1.2    # Also land on release/1.2
+  gt mq submit --strategy rebase         # Keep the branch's commits
   gt mq submit --priority 0              # Override priority (P0)
   gt mq submit --no-cleanup              # Submit without auto-cleanup`,
 	RunE: runMqSubmit,
@@ -172,6 +193,30 @@ Example:
 	RunE: runMqStatus,
 }
 
+var mqFlakyCmd = &cobra.Command{
+	Use:   "flaky <rig>",
+	Short: "Show flaky tests and their flake rates",
+	Long: `Show tests the refinery has seen flake, and the quarantine list.
+
+The refinery parses test results from its pre-merge checks (go test -json
+output, or JUnit XML reports named by a check's "junit" glob). A test that
+fails and then passes on retry counts as a flake; the first flake of each
+test files a bug bead.
+
+Tests listed in merge_queue.quarantine still run, but their failures no
+longer block merges.
+
+Output format:
+  RATE  FLAKES  RUNS  BEAD      TEST
+  25%   2       8     gt-abc12  example.com/pkg.TestRace  (quarantined)
+
+Examples:
+  gt mq flaky greenplace
+  gt mq flaky greenplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMQFlaky,
+}
+
 var mqIntegrationCmd = &cobra.Command{
 	Use:   "integration",
 	Short: "Manage integration branches for epics",
@@ -274,6 +319,10 @@ func init() {
 	mqSubmitCmd.Flags().StringVar(&mqSubmitBranch, "branch", "", "Source branch (default: current branch)")
 	mqSubmitCmd.Flags().StringVar(&mqSubmitIssue, "issue", "", "Source issue ID (default: parse from branch name)")
 	mqSubmitCmd.Flags().StringVar(&mqSubmitEpic, "epic", "", "Target epic's integration branch instead of main")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitTarget, "target", "", "Target branch (overrides --epic and auto-detection)")
+	mqSubmitCmd.Flags().StringSliceVar(&mqSubmitBackports, "backport", nil, "Also backport to this branch after merging (repeatable)")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitStrategy, "strategy", "", "Merge strategy: squash, rebase or merge (default: rig's merge_strategy)")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitTemplate, "commit-template", "", "Commit message template (default: rig's commit_template)")
 	mqSubmitCmd.Flags().IntVarP(&mqSubmitPriority, "priority", "p", -1, "Override priority (0-4, default: inherit from issue)")
 	mqSubmitCmd.Flags().BoolVar(&mqSubmitNoCleanup, "no-cleanup", false, "Don't auto-cleanup after submit (for polecats)")
 
@@ -295,12 +344,16 @@ func init() {
 	// Status flags
 	mqStatusCmd.Flags().BoolVar(&mqStatusJSON, "json", false, "Output as JSON")
 
+	// Flaky flags
+	mqFlakyCmd.Flags().BoolVar(&mqFlakyJSON, "json", false, "Output as JSON")
+
 	// Add subcommands
 	mqCmd.AddCommand(mqSubmitCmd)
 	mqCmd.AddCommand(mqRetryCmd)
 	mqCmd.AddCommand(mqListCmd)
 	mqCmd.AddCommand(mqRejectCmd)
 	mqCmd.AddCommand(mqStatusCmd)
+	mqCmd.AddCommand(mqFlakyCmd)
 
 	// Integration branch subcommands
 	mqIntegrationCreateCmd.Flags().StringVar(&mqIntegrationCreateBranch, "branch", "", "Override branch name template (supports {epic}, {prefix}, {user})")
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+func runMQFlaky(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	// The quarantine list lives in the refinery's merge_queue config
+	e := refinery.NewEngineer(r)
+	if err := e.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	history, err := refinery.LoadTestHistory(r.Path)
+	if err != nil {
+		return fmt.Errorf("loading test history: %w", err)
+	}
+	report := history.Flaky(e.Config().Quarantine)
+
+	if mqFlakyJSON {
+		return outputJSON(report)
+	}
+
+	fmt.Printf("%s Flaky tests for '%s':\n\n", style.Bold.Render("🎲"), rigName)
+	if len(report) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none)"))
+		return nil
+	}
+
+	table := style.NewTable(
+		style.Column{Name: "RATE", Width: 5, Align: style.AlignRight},
+		style.Column{Name: "FLAKES", Width: 6, Align: style.AlignRight},
+		style.Column{Name: "RUNS", Width: 5, Align: style.AlignRight},
+		style.Column{Name: "BEAD", Width: 12},
+		style.Column{Name: "TEST", Width: 72},
+	)
+	for _, t := range report {
+		bead := style.Dim.Render("-")
+		if t.Bead != "" {
+			bead = t.Bead
+		}
+		name := t.Name
+		if t.Quarantined {
+			name += " " + style.Warning.Render("(quarantined)")
+		}
+		table.AddRow(
+			fmt.Sprintf("%.0f%%", t.FlakeRate*100),
+			fmt.Sprintf("%d", t.Flakes),
+			fmt.Sprintf("%d", t.Runs),
+			bead,
+			name,
+		)
+	}
+	fmt.Print(table.Render())
+
+	return nil
+}
@@ -137,6 +137,14 @@ func runMQList(cmd *cobra.Command, args []string) error {
 	// Human-readable output
 	fmt.Printf("%s Merge queue for '%s':\n\n", style.Bold.Render("📋"), rigName)
 
+	if state, err := refinery.LoadVerifyState(r.Path); err == nil && state.Pause != nil {
+		fmt.Printf("  %s %s\n", style.Warning.Render("⏸ Paused:"), state.Pause.Reason)
+		if state.Pause.RevertMR != "" {
+			fmt.Printf("  %s\n", style.Dim.Render("Only "+state.Pause.RevertMR+" (the revert) is processed until the target is green"))
+		}
+		fmt.Println()
+	}
+
 	if len(filtered) == 0 {
 		fmt.Printf("  %s\n", style.Dim.Render("(empty)"))
 		return nil
@@ -11,10 +11,12 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tracing"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -75,6 +77,11 @@ func parseBranchName(branch string) branchInfo {
 }
 
 func runMqSubmit(cmd *cobra.Command, args []string) error {
+	if !config.ValidMergeStrategy(mqSubmitStrategy) {
+		return fmt.Errorf("invalid --strategy %q: want %s, %s or %s", mqSubmitStrategy,
+			config.MergeStrategySquash, config.MergeStrategyRebase, config.MergeStrategyMerge)
+	}
+
 	// Find workspace
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
@@ -132,7 +139,9 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 
 	// Determine target branch
 	target := defaultBranch
-	if mqSubmitEpic != "" {
+	if mqSubmitTarget != "" {
+		target = mqSubmitTarget
+	} else if mqSubmitEpic != "" {
 		// Explicit --epic flag takes precedence
 		target = "integration/" + mqSubmitEpic
 	} else {
@@ -168,6 +177,35 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	if worker != "" {
 		description += fmt.Sprintf("\nworker: %s", worker)
 	}
+	if len(mqSubmitBackports) > 0 {
+		description += "\nbackports: " + strings.Join(mqSubmitBackports, ",")
+	}
+	if mqSubmitStrategy != "" {
+		description += "\nmerge_strategy: " + mqSubmitStrategy
+	}
+	if mqSubmitTemplate != "" {
+		description += "\ncommit_template: " + strings.ReplaceAll(mqSubmitTemplate, "\n", `\n`)
+	}
+
+	// Join the source issue's lifecycle trace and carry it on the MR so the
+	// refinery's spans join it too
+	tracer := tracing.New(townRoot)
+	var traceParent string
+	if tracer != nil {
+		var traceStartedAt string
+		traceParent, traceStartedAt = getTraceFromBead(cwd, issueID)
+		if traceParent == "" {
+			traceParent = os.Getenv(tracing.EnvTraceParent)
+		}
+		if traceParent != "" {
+			description += "\ntraceparent: " + traceParent
+		}
+		if traceStartedAt != "" {
+			description += "\ntrace_started_at: " + traceStartedAt
+		}
+	}
+	submitSpan := tracer.Start(traceParent, "mq.submit")
+	submitSpan.SetAttr("gt.bead", issueID).SetAttr("gt.rig", rigName).SetAttr("gt.branch", branch)
 
 	// Check if MR bead already exists for this branch (idempotency)
 	var mrIssue *beads.Issue
@@ -188,6 +226,7 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 			Ephemeral:   true,
 		})
 		if err != nil {
+			submitSpan.EndErr(err)
 			return fmt.Errorf("creating merge request bead: %w", err)
 		}
 
@@ -195,6 +234,9 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 		nudgeRefinery(rigName, fmt.Sprintf("MR submitted: %s branch=%s", mrIssue.ID, branch))
 	}
 
+	submitSpan.SetAttr("gt.mr", mrIssue.ID)
+	submitSpan.End()
+
 	// Success output
 	fmt.Printf("%s Submitted to merge queue\n", style.Bold.Render("✓"))
 	fmt.Printf("  MR ID: %s\n", style.Bold.Render(mrIssue.ID))
@@ -204,6 +246,12 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	if worker != "" {
 		fmt.Printf("  Worker: %s\n", worker)
 	}
+	if len(mqSubmitBackports) > 0 {
+		fmt.Printf("  Backports: %s\n", strings.Join(mqSubmitBackports, ", "))
+	}
+	if mqSubmitStrategy != "" {
+		fmt.Printf("  Strategy: %s\n", mqSubmitStrategy)
+	}
 	fmt.Printf("  Priority: P%d\n", priority)
 
 	// Auto-cleanup for polecats: if this is a polecat branch and cleanup not disabled,
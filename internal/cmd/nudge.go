@@ -181,6 +181,11 @@ func runNudge(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		// Sessions for remote rigs live on the rig's machine.
+		if _, r, err := getRig(rigName); err == nil {
+			t = rigTmux(r)
+		}
+
 		var sessionName string
 
 		// Check if this is a crew address (polecatName starts with "crew/")
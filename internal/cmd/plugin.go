@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -18,6 +19,7 @@ import (
 // Plugin command flags
 var (
 	pluginListJSON    bool
+	pluginListDue     bool
 	pluginShowJSON    bool
 	pluginRunForce    bool
 	pluginRunDryRun   bool
@@ -62,9 +64,14 @@ Plugins are discovered from:
 
 When a plugin exists at both levels, the rig-level version takes precedence.
 
+With --due, only plugins whose gate is open right now are listed. Cooldown
+and cron gates are checked against the last recorded run; condition gates
+run their check command. Deacon patrols use this to pick plugins to dispatch.
+
 Examples:
   gt plugin list              # Human-readable output
-  gt plugin list --json       # JSON output for scripting`,
+  gt plugin list --json       # JSON output for scripting
+  gt plugin list --due        # Plugins ready to run now`,
 	RunE: runPluginList,
 }
 
@@ -116,6 +123,7 @@ Examples:
 func init() {
 	// List subcommand flags
 	pluginListCmd.Flags().BoolVar(&pluginListJSON, "json", false, "Output as JSON")
+	pluginListCmd.Flags().BoolVar(&pluginListDue, "due", false, "Only list plugins whose gate is open now")
 
 	// Show subcommand flags
 	pluginShowCmd.Flags().BoolVar(&pluginShowJSON, "json", false, "Output as JSON")
@@ -178,6 +186,10 @@ func runPluginList(cmd *cobra.Command, args []string) error {
 		return plugins[i].Name < plugins[j].Name
 	})
 
+	if pluginListDue {
+		return runPluginListDue(plugins, townRoot)
+	}
+
 	if pluginListJSON {
 		return outputPluginListJSON(plugins)
 	}
@@ -185,6 +197,50 @@ func runPluginList(cmd *cobra.Command, args []string) error {
 	return outputPluginListText(plugins, townRoot)
 }
 
+// duePlugin pairs a plugin summary with its gate evaluation for --due output.
+type duePlugin struct {
+	plugin.PluginSummary
+	plugin.GateStatus
+}
+
+// runPluginListDue evaluates every plugin's gate and lists the due ones.
+// Gates that cannot be evaluated are reported as warnings, never as due.
+func runPluginListDue(plugins []*plugin.Plugin, townRoot string) error {
+	evaluator := plugin.NewGateEvaluator(plugin.NewRecorder(townRoot))
+	due := make([]duePlugin, 0)
+	for _, p := range plugins {
+		status := evaluator.Evaluate(context.Background(), p)
+		if status.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", p.Name, status.Err)
+			continue
+		}
+		if status.Due {
+			due = append(due, duePlugin{PluginSummary: p.Summary(), GateStatus: status})
+		}
+	}
+
+	if pluginListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(due)
+	}
+
+	if len(due) == 0 {
+		fmt.Printf("%s No plugins due\n", style.Dim.Render("○"))
+		return nil
+	}
+
+	fmt.Printf("%s %d plugin(s) due\n\n", style.Success.Render("●"), len(due))
+	for _, d := range due {
+		name := d.Name
+		if d.RigName != "" {
+			name = fmt.Sprintf("%s (%s)", d.Name, d.RigName)
+		}
+		fmt.Printf("    %s %s\n", style.Bold.Render(name), style.Dim.Render(fmt.Sprintf("[%s] %s", d.GateType, d.Reason)))
+	}
+	return nil
+}
+
 func outputPluginListJSON(plugins []*plugin.Plugin) error {
 	summaries := make([]plugin.PluginSummary, len(plugins))
 	for i, p := range plugins {
@@ -317,9 +373,15 @@ func outputPluginShowText(p *plugin.Plugin) error {
 		if p.Gate.Schedule != "" {
 			fmt.Printf("  Schedule: %s\n", p.Gate.Schedule)
 		}
+		if p.Gate.Timezone != "" {
+			fmt.Printf("  Timezone: %s\n", p.Gate.Timezone)
+		}
 		if p.Gate.Check != "" {
 			fmt.Printf("  Check: %s\n", p.Gate.Check)
 		}
+		if p.Gate.Timeout != "" {
+			fmt.Printf("  Check timeout: %s\n", p.Gate.Timeout)
+		}
 		if p.Gate.On != "" {
 			fmt.Printf("  On: %s\n", p.Gate.On)
 		}
@@ -383,22 +445,18 @@ func runPluginRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Check gate status for cooldown gates
+	// Check whether the gate is open. Manual and event-gated plugins are
+	// always runnable here: an explicit run is how they are triggered.
 	gateOpen := true
 	gateReason := ""
-	if p.Gate != nil && p.Gate.Type == plugin.GateCooldown && !pluginRunForce {
-		recorder := plugin.NewRecorder(townRoot)
-		duration := p.Gate.Duration
-		if duration == "" {
-			duration = "1h" // default
-		}
-		count, err := recorder.CountRunsSince(p.Name, duration)
-		if err != nil {
+	if p.Gate != nil && p.Gate.Type != plugin.GateManual && p.Gate.Type != plugin.GateEvent && p.Gate.Type != "" && !pluginRunForce {
+		status := plugin.NewGateEvaluator(plugin.NewRecorder(townRoot)).Evaluate(context.Background(), p)
+		if status.Err != nil {
 			// Log warning but continue
-			fmt.Fprintf(os.Stderr, "Warning: checking gate status: %v\n", err)
-		} else if count > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: checking gate status: %v\n", status.Err)
+		} else if !status.Due {
 			gateOpen = false
-			gateReason = fmt.Sprintf("ran %d time(s) within %s cooldown", count, duration)
+			gateReason = status.Reason
 		}
 	}
 
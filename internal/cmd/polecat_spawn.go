@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/budget"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/events"
@@ -17,6 +18,7 @@ import (
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/tracing"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -27,6 +29,7 @@ type SpawnedPolecatInfo struct {
 	ClonePath   string // Path to polecat's git worktree
 	SessionName string // Tmux session name (e.g., "gt-gastown-p-Toast")
 	Pane        string // Tmux pane ID (empty until StartSession is called)
+	TraceParent string // Lifecycle trace of the slung bead (empty when tracing is off)
 
 	// Internal fields for deferred session start
 	account string
@@ -76,6 +79,15 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 		return nil, fmt.Errorf("rig '%s' not found", rigName)
 	}
 
+	// Refuse to spawn while a cost budget covering this work is exhausted.
+	// The daemon records exceeded caps via 'gt costs budget --enforce'.
+	if state, err := budget.LoadState(townRoot); err == nil {
+		if st := state.Blocking(rigName, constants.RolePolecat, opts.HookBead, time.Now()); st != nil {
+			return nil, fmt.Errorf("%s exhausted: spent $%.2f\nRaise the cap in settings or wait for it to reset (see 'gt costs budget')",
+				st.Cap, st.SpentUSD)
+		}
+	}
+
 	// Get polecat manager (with tmux for session-aware allocation)
 	polecatGit := git.NewGit(r.Path)
 	t := tmux.NewTmux()
@@ -173,7 +185,7 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 // This is called after the molecule/bead is attached, so the polecat
 // sees its work when gt prime runs on session start.
 // Returns the pane ID after session start.
-func (s *SpawnedPolecatInfo) StartSession() (string, error) {
+func (s *SpawnedPolecatInfo) StartSession() (_ string, err error) {
 	if s.SessionStarted() {
 		return s.Pane, nil
 	}
@@ -183,6 +195,10 @@ func (s *SpawnedPolecatInfo) StartSession() (string, error) {
 		return "", fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
 
+	span := tracing.New(townRoot).Start(s.TraceParent, "polecat.session_start")
+	span.SetAttr("gt.rig", s.RigName).SetAttr("gt.polecat", s.PolecatName)
+	defer func() { span.EndErr(err) }()
+
 	// Load rig config
 	rigsConfigPath := filepath.Join(townRoot, "mayor", "rigs.json")
 	rigsConfig, err := config.LoadRigsConfig(rigsConfigPath)
@@ -211,6 +227,7 @@ func (s *SpawnedPolecatInfo) StartSession() (string, error) {
 	fmt.Printf("Starting session for %s/%s...\n", s.RigName, s.PolecatName)
 	startOpts := polecat.SessionStartOptions{
 		RuntimeConfigDir: claudeConfigDir,
+		TraceParent:      s.TraceParent,
 	}
 	if s.agent != "" {
 		cmd, err := config.BuildPolecatStartupCommandWithAgentOverride(s.RigName, s.PolecatName, r.Path, "", s.agent)
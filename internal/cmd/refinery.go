@@ -217,6 +217,27 @@ Examples:
 
 var refineryBlockedJSON bool
 
+var refineryProcessCmd = &cobra.Command{
+	Use:   "process [rig]",
+	Short: "Merge the next batch of ready MRs",
+	Long: `Merge the next batch of ready MRs.
+
+Claims the highest-priority ready MRs (by ScoreMR) and merges them.
+
+With merge_queue.max_concurrent = 1 (the default) one MR is merged at a time.
+With a higher value, up to max_concurrent MRs for the same target are run as a
+speculative merge train: each MR is merged with the configured strategy in its
+own worktree on top of the MRs ahead of it and all are tested at once. MRs
+land in priority order. If one fails, only the MRs stacked behind it are
+invalidated and returned to the queue; the MRs ahead of it still merge.
+
+Examples:
+  gt refinery process
+  gt refinery process gastown`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryProcess,
+}
+
 func init() {
 	// Start flags
 	refineryStartCmd.Flags().BoolVar(&refineryForeground, "foreground", false, "Run in foreground (default: background)")
@@ -255,6 +276,7 @@ func init() {
 	refineryCmd.AddCommand(refineryUnclaimedCmd)
 	refineryCmd.AddCommand(refineryReadyCmd)
 	refineryCmd.AddCommand(refineryBlockedCmd)
+	refineryCmd.AddCommand(refineryProcessCmd)
 
 	rootCmd.AddCommand(refineryCmd)
 }
@@ -758,3 +780,27 @@ func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runRefineryProcess(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+
+	merged, err := eng.ProcessQueue(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("processing queue: %w", err)
+	}
+	fmt.Printf("%s Merged %d MR(s)\n", style.Bold.Render("✓"), merged)
+	return nil
+}
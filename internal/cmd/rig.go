@@ -934,7 +934,6 @@ func runRigStart(cmd *cobra.Command, args []string) error {
 
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	t := tmux.NewTmux()
 
 	var successRigs []string
 	var failedRigs []string
@@ -946,6 +945,7 @@ func runRigStart(cmd *cobra.Command, args []string) error {
 			failedRigs = append(failedRigs, rigName)
 			continue
 		}
+		t := rigTmux(r)
 
 		// Check if rig is parked or docked
 		cfg := wisp.NewConfig(townRoot, rigName)
@@ -956,7 +956,11 @@ func runRigStart(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		fmt.Printf("Starting rig %s...\n", style.Bold.Render(rigName))
+		if r.IsRemote() {
+			fmt.Printf("Starting rig %s on %s...\n", style.Bold.Render(rigName), r.Machine)
+		} else {
+			fmt.Printf("Starting rig %s...\n", style.Bold.Render(rigName))
+		}
 
 		var started []string
 		var skipped []string
@@ -4,9 +4,11 @@ import (
 	"fmt"
 
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -34,3 +36,9 @@ func getRig(rigName string) (string, *rig.Rig, error) {
 
 	return townRoot, r, nil
 }
+
+// rigTmux returns the tmux wrapper for the machine hosting r, so session
+// operations reach remote rigs through their connection.
+func rigTmux(r *rig.Rig) *tmux.Tmux {
+	return connection.TmuxFor(r.Connection())
+}
@@ -62,6 +62,7 @@ var beadsExemptCommands = map[string]bool{
 	"tap":        true,
 	"dnd":        true,
 	"krc":        true, // KRC doesn't require beads
+	"scripted-agent": true,
 }
 
 // Commands exempt from the town root branch warning.
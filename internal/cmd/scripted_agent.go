@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/scripted"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var scriptedAgentScript string
+
+var scriptedAgentCmd = &cobra.Command{
+	Use:   "scripted-agent [prompt]",
+	Short: "Run the scripted agent runtime (internal use)",
+	Long: `Run a deterministic stand-in for an LLM agent in the current pane.
+
+This is the command behind the "scripted" agent preset. It follows a YAML
+script of shell commands, mail waits and prompts, printing a ready prompt
+("> ") whenever it waits for input, so town workflows can run end to end
+without model access.
+
+The script is the first of:
+  --script <path>
+  $GT_AGENT_SCRIPT
+  <town>/<rig>/settings/scripts/<role>.yaml
+  <town>/settings/scripts/<role>.yaml
+
+where <role> is mayor, deacon, boot, witness, refinery, polecat or crew,
+taken from GT_ROLE. Commands run with the session's environment plus
+GT_PROMPT (the startup prompt), GT_INPUT (the last matched prompt input)
+and GT_MAIL_ID/GT_MAIL_FROM/GT_MAIL_SUBJECT/GT_MAIL_BODY (the last mail
+matched by wait_mail).
+
+Example script:
+  steps:
+    - run: gt hook
+    - run: |
+        echo done > result.txt
+        git add result.txt && git commit -m "scripted work"
+    - run: gt done
+  exit_when_done: true
+
+Use it by setting a role's agent to "scripted":
+  gt config default-agent scripted`,
+	Hidden: true, // Launched by session startup, not by hand
+	Args:   cobra.MaximumNArgs(1),
+	RunE:   runScriptedAgent,
+}
+
+func init() {
+	scriptedAgentCmd.Flags().StringVar(&scriptedAgentScript, "script", "", "Script file (default: resolved from GT_AGENT_SCRIPT or GT_ROLE)")
+	rootCmd.AddCommand(scriptedAgentCmd)
+}
+
+func runScriptedAgent(cmd *cobra.Command, args []string) error {
+	path, err := resolveAgentScript()
+	if err != nil {
+		return err
+	}
+	script, err := scripted.Load(path)
+	if err != nil {
+		return fmt.Errorf("loading script: %w", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gtPath, err := os.Executable()
+	if err != nil {
+		gtPath = "gt"
+	}
+
+	r := &scripted.Runner{
+		Script: script,
+		GT:     gtPath,
+		Dir:    cwd,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+	}
+	if len(args) > 0 {
+		r.Prompt = args[0]
+	}
+
+	fmt.Printf("scripted agent: %s (%d steps)\n", path, len(script.Steps))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = r.Run(ctx)
+	var exit *scripted.ExitError
+	if errors.As(err, &exit) {
+		return NewSilentExit(exit.Code)
+	}
+	return err
+}
+
+// resolveAgentScript finds the script for this session.
+func resolveAgentScript() (string, error) {
+	if scriptedAgentScript != "" {
+		return scriptedAgentScript, nil
+	}
+	if env := os.Getenv("GT_AGENT_SCRIPT"); env != "" {
+		return env, nil
+	}
+
+	townRoot := os.Getenv("GT_ROOT")
+	if townRoot == "" {
+		townRoot, _ = workspace.FindFromCwd()
+	}
+	envRole := os.Getenv(EnvGTRole)
+	if townRoot == "" || envRole == "" {
+		return "", fmt.Errorf("no script: use --script or set GT_AGENT_SCRIPT")
+	}
+
+	role, rig, _ := parseRoleString(envRole)
+	if envRole == "deacon/boot" {
+		role, rig = RoleBoot, ""
+	}
+	name := string(role) + ".yaml"
+
+	var candidates []string
+	if rig != "" {
+		candidates = append(candidates, filepath.Join(townRoot, rig, "settings", "scripts", name))
+	}
+	candidates = append(candidates, filepath.Join(townRoot, "settings", "scripts", name))
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no script for %s: create %s or set GT_AGENT_SCRIPT", envRole, candidates[len(candidates)-1])
+}
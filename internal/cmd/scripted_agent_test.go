@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveAgentScript(t *testing.T) {
+	town := t.TempDir()
+	townScript := filepath.Join(town, "settings", "scripts", "polecat.yaml")
+	rigScript := filepath.Join(town, "gastown", "settings", "scripts", "polecat.yaml")
+	bootScript := filepath.Join(town, "settings", "scripts", "boot.yaml")
+	for _, p := range []string{townScript, rigScript, bootScript} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("steps:\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldFlag := scriptedAgentScript
+	t.Cleanup(func() { scriptedAgentScript = oldFlag })
+	scriptedAgentScript = ""
+	t.Setenv("GT_AGENT_SCRIPT", "")
+	t.Setenv("GT_ROOT", town)
+
+	tests := []struct {
+		role string
+		want string
+	}{
+		{"gastown/polecats/Toast", rigScript},
+		{"beads/polecats/Nux", townScript},
+		{"deacon/boot", bootScript},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvGTRole, tt.role)
+		got, err := resolveAgentScript()
+		if err != nil || got != tt.want {
+			t.Errorf("resolveAgentScript(%s) = %q, %v; want %q", tt.role, got, err, tt.want)
+		}
+	}
+
+	t.Setenv(EnvGTRole, "gastown/witness")
+	if _, err := resolveAgentScript(); err == nil || !strings.Contains(err.Error(), "witness.yaml") {
+		t.Errorf("missing script error = %v", err)
+	}
+
+	t.Setenv("GT_AGENT_SCRIPT", "/tmp/explicit.yaml")
+	if got, _ := resolveAgentScript(); got != "/tmp/explicit.yaml" {
+		t.Errorf("GT_AGENT_SCRIPT ignored: %q", got)
+	}
+	scriptedAgentScript = "/tmp/flag.yaml"
+	if got, _ := resolveAgentScript(); got != "/tmp/flag.yaml" {
+		t.Errorf("--script ignored: %q", got)
+	}
+}
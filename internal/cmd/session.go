@@ -204,6 +204,19 @@ func init() {
 // parseAddress parses "rig/polecat" format.
 // If no "/" is present, attempts to infer rig from current directory.
 func parseAddress(addr string) (rigName, polecatName string, err error) {
+	// Federation form: machine:rig/polecat. The machine must match the
+	// machine the rig is registered on.
+	if machine, rest, ok := strings.Cut(addr, ":"); ok && !strings.Contains(machine, "/") {
+		rigName, polecatName, err = parseAddress(rest)
+		if err != nil {
+			return "", "", err
+		}
+		if err := checkRigMachine(rigName, machine); err != nil {
+			return "", "", err
+		}
+		return rigName, polecatName, nil
+	}
+
 	parts := strings.SplitN(addr, "/", 2)
 	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
 		return parts[0], parts[1], nil
@@ -223,6 +236,25 @@ func parseAddress(addr string) (rigName, polecatName string, err error) {
 	return "", "", fmt.Errorf("invalid address format: expected 'rig/polecat', got '%s'", addr)
 }
 
+// checkRigMachine verifies that rigName is hosted on the named machine.
+func checkRigMachine(rigName, machine string) error {
+	if machine == "" {
+		return fmt.Errorf("empty machine name before ':'")
+	}
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return err
+	}
+	actual := r.Machine
+	if actual == "" {
+		actual = "local"
+	}
+	if actual != machine {
+		return fmt.Errorf("rig '%s' is on machine '%s', not '%s'", rigName, actual, machine)
+	}
+	return nil
+}
+
 // getSessionManager creates a session manager for the given rig.
 func getSessionManager(rigName string) (*polecat.SessionManager, *rig.Rig, error) {
 	_, r, err := getRig(rigName)
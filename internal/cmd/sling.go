@@ -126,6 +126,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 	if polecatName := os.Getenv("GT_POLECAT"); polecatName != "" {
 		return fmt.Errorf("polecats cannot sling (use gt done for handoff)")
 	}
+	slingStart := time.Now()
 
 	// Get town root early - needed for BEADS_DIR when running bd commands
 	// This ensures hq-* beads are accessible even when running from polecat worktree
@@ -572,6 +573,14 @@ func runSling(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Start the bead's lifecycle trace (or join the one it carries). The
+	// polecat session, gt done, the merge queue and the refinery add their
+	// spans to it.
+	traceParent := startBeadTrace(townRoot, beadID, targetAgent, slingStart)
+	if newPolecatInfo != nil {
+		newPolecatInfo.TraceParent = traceParent
+	}
+
 	// Record the attached molecule in the BASE bead's description.
 	// This field points to the wisp (compound root) and enables:
 	// - gt hook/gt prime: follow attached_molecule to show molecule steps
@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -278,6 +279,54 @@ exit /b 0
 	}
 }
 
+// TestCookFormulaComposed verifies that bd cooks a composed formula from
+// its flattened form, found ahead of the original, on the original beads.
+func TestCookFormulaComposed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bd stub is a shell script")
+	}
+	townRoot := t.TempDir()
+	formulas := filepath.Join(townRoot, ".beads", "formulas")
+	if err := os.MkdirAll(formulas, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"base":  "formula = \"base\"\n\n[[steps]]\nid = \"build\"\ntitle = \"Build\"\n",
+		"child": "formula = \"child\"\nextends = [\"base\"]\n\n[[steps]]\nid = \"ship\"\ntitle = \"Ship\"\nafter = \"build\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(formulas, name+".formula.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	binDir := filepath.Join(townRoot, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("mkdir binDir: %v", err)
+	}
+	logPath := filepath.Join(townRoot, "bd.log")
+	bdScript := `#!/bin/sh
+echo "CMD:$* BEADS_DIR=$BEADS_DIR" >> "${BD_LOG}"
+cat .beads/formulas/child.formula.toml >> "${BD_LOG}"
+exit 0
+`
+	_ = writeBDStub(t, binDir, bdScript, "")
+	t.Setenv("BD_LOG", logPath)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := CookFormula("child", townRoot); err != nil {
+		t.Fatalf("CookFormula failed: %v", err)
+	}
+
+	logBytes, _ := os.ReadFile(logPath)
+	log := string(logBytes)
+	if !strings.Contains(log, "CMD:cook child BEADS_DIR="+filepath.Join(townRoot, ".beads")) {
+		t.Errorf("cook did not run on the town beads:\n%s", log)
+	}
+	if strings.Contains(log, "extends") || strings.Contains(log, "after") || !strings.Contains(log, `id = "build"`) {
+		t.Errorf("bd did not see the flattened formula:\n%s", log)
+	}
+}
+
 // TestSlingHookRawBeadFlag verifies --hook-raw-bead flag exists.
 func TestSlingHookRawBeadFlag(t *testing.T) {
 	// Verify the flag variable exists and works
@@ -173,6 +173,11 @@ func runSlingFormula(args []string) error {
 		isSelfSling = true
 	}
 
+	// Reject bad --var values before cooking or creating the wisp
+	if err := checkFormulaVars(formulaName, slingVars, nil, townRoot, formulaWorkDir); err != nil {
+		return err
+	}
+
 	fmt.Printf("%s Slinging formula %s to %s...\n", style.Bold.Render("🎯"), formulaName, targetAgent)
 
 	if slingDryRun {
@@ -191,11 +196,18 @@ func runSlingFormula(args []string) error {
 		formulaWorkDir = townRoot
 	}
 
+	// bd reads composed formulas only in their flattened form
+	flatDir, err := flattenFormulaForBD(formulaName, formulaWorkDir, townRoot)
+	if err != nil {
+		return err
+	}
+	if flatDir != "" {
+		defer func() { _ = os.RemoveAll(flatDir) }()
+	}
+
 	// Step 1: Cook the formula (ensures proto exists)
 	fmt.Printf("  Cooking formula...\n")
-	cookArgs := []string{"--no-daemon", "cook", formulaName}
-	cookCmd := exec.Command("bd", cookArgs...)
-	cookCmd.Dir = formulaWorkDir
+	cookCmd := formulaBDCommand(flatDir, formulaWorkDir, "--no-daemon", "cook", formulaName)
 	cookCmd.Stderr = os.Stderr
 	if err := cookCmd.Run(); err != nil {
 		return fmt.Errorf("cooking formula: %w", err)
@@ -209,8 +221,7 @@ func runSlingFormula(args []string) error {
 	}
 	wispArgs = append(wispArgs, "--json")
 
-	wispCmd := exec.Command("bd", wispArgs...)
-	wispCmd.Dir = formulaWorkDir
+	wispCmd := formulaBDCommand(flatDir, formulaWorkDir, wispArgs...)
 	wispCmd.Stderr = os.Stderr // Show wisp errors to user
 	wispOut, err := wispCmd.Output()
 	if err != nil {
@@ -224,6 +235,7 @@ func runSlingFormula(args []string) error {
 	}
 
 	fmt.Printf("%s Wisp created: %s\n", style.Bold.Render("✓"), wispRootID)
+	stampFormulaPolicies(wispRootID, formulaName, formulaWorkDir, townRoot, slingVars)
 	attachedMoleculeID := wispRootID
 
 	// Step 3: Hook the wisp bead using bd update.
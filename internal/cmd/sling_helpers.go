@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/tracing"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -317,6 +321,78 @@ func storeNoMergeInBead(beadID string, noMerge bool) error {
 	return nil
 }
 
+// storeTraceInBead records a lifecycle trace in a bead's description and
+// returns the bead's traceparent. If the bead already carries a valid
+// trace, that trace is kept and returned instead.
+func storeTraceInBead(beadID, traceParent string, startedAt time.Time) (string, error) {
+	// Get the bead to preserve existing description content
+	showCmd := exec.Command("bd", "show", beadID, "--json")
+	out, err := showCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("fetching bead: %w", err)
+	}
+
+	// Parse the bead
+	var issues []beads.Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return "", fmt.Errorf("parsing bead: %w", err)
+	}
+	if len(issues) == 0 {
+		return "", fmt.Errorf("bead not found")
+	}
+	issue := &issues[0]
+
+	// Get or create attachment fields
+	fields := beads.ParseAttachmentFields(issue)
+	if fields == nil {
+		fields = &beads.AttachmentFields{}
+	}
+	if _, err := tracing.ParseTraceParent(fields.TraceParent); err == nil {
+		return fields.TraceParent, nil
+	}
+
+	// Set the trace fields
+	fields.TraceParent = traceParent
+	fields.TraceStartedAt = startedAt.UTC().Format(time.RFC3339Nano)
+
+	// Update the description
+	newDesc := beads.SetAttachmentFields(issue, fields)
+
+	// Update the bead
+	updateCmd := exec.Command("bd", "update", beadID, "--description="+newDesc)
+	updateCmd.Stderr = os.Stderr
+	if err := updateCmd.Run(); err != nil {
+		return "", fmt.Errorf("updating bead description: %w", err)
+	}
+
+	return traceParent, nil
+}
+
+// startBeadTrace records the gt sling span of a bead's lifecycle trace and
+// returns the traceparent later steps should join, or "" when tracing is
+// off. A bead slung for the first time gets a new trace whose root span is
+// stored on the bead and ended by the refinery when the work merges. A bead
+// that already carries a trace (re-slung, or a conflict-resolution task for
+// a merge request) joins it.
+func startBeadTrace(townRoot, beadID, targetAgent string, slingStart time.Time) string {
+	tracer := tracing.New(townRoot)
+	if tracer == nil {
+		return ""
+	}
+
+	root := tracer.StartAt("", "bead "+beadID, slingStart)
+	traceParent, err := storeTraceInBead(beadID, root.TraceParent(), slingStart)
+	if err != nil {
+		fmt.Printf("%s Could not store trace in bead: %v\n", style.Dim.Render("Warning:"), err)
+		return ""
+	}
+
+	span := tracer.StartAt(traceParent, "gt sling", slingStart)
+	span.SetAttr("gt.bead", beadID).SetAttr("gt.target", targetAgent)
+	span.End()
+	return traceParent
+}
+
 // injectStartPrompt sends a prompt to the target pane to start working.
 // Uses the reliable nudge pattern: literal mode + 500ms debounce + separate Enter.
 func injectStartPrompt(pane, beadID, subject, args string) error {
@@ -592,10 +668,25 @@ func InstantiateFormulaOnBead(formulaName, beadID, title, hookWorkDir, townRoot
 	// Route bd mutations (wisp/bond) to the correct beads context for the target bead.
 	formulaWorkDir := beads.ResolveHookDir(townRoot, beadID, hookWorkDir)
 
+	// Reject bad --var values before anything is created
+	featureVar := fmt.Sprintf("feature=%s", title)
+	issueVar := fmt.Sprintf("issue=%s", beadID)
+	if err := checkFormulaVars(formulaName, extraVars, []string{featureVar, issueVar}, townRoot, formulaWorkDir); err != nil {
+		return nil, err
+	}
+
+	// bd reads composed formulas only in their flattened form
+	flatDir, err := flattenFormulaForBD(formulaName, formulaWorkDir, townRoot)
+	if err != nil {
+		return nil, err
+	}
+	if flatDir != "" {
+		defer func() { _ = os.RemoveAll(flatDir) }()
+	}
+
 	// Step 1: Cook the formula (ensures proto exists)
 	if !skipCook {
-		cookCmd := exec.Command("bd", "cook", formulaName)
-		cookCmd.Dir = formulaWorkDir
+		cookCmd := formulaBDCommand(flatDir, formulaWorkDir, "cook", formulaName)
 		cookCmd.Stderr = os.Stderr
 		if err := cookCmd.Run(); err != nil {
 			return nil, fmt.Errorf("cooking formula %s: %w", formulaName, err)
@@ -603,16 +694,13 @@ func InstantiateFormulaOnBead(formulaName, beadID, title, hookWorkDir, townRoot
 	}
 
 	// Step 2: Create wisp with feature and issue variables from bead
-	featureVar := fmt.Sprintf("feature=%s", title)
-	issueVar := fmt.Sprintf("issue=%s", beadID)
 	wispArgs := []string{"mol", "wisp", formulaName, "--var", featureVar, "--var", issueVar}
 	for _, variable := range extraVars {
 		wispArgs = append(wispArgs, "--var", variable)
 	}
 	wispArgs = append(wispArgs, "--json")
-	wispCmd := exec.Command("bd", wispArgs...)
-	wispCmd.Dir = formulaWorkDir
-	wispCmd.Env = append(os.Environ(), "GT_ROOT="+townRoot)
+	wispCmd := formulaBDCommand(flatDir, formulaWorkDir, wispArgs...)
+	wispCmd.Env = append(wispCmd.Environ(), "GT_ROOT="+townRoot)
 	wispCmd.Stderr = os.Stderr
 	wispOut, err := wispCmd.Output()
 	if err != nil {
@@ -625,6 +713,8 @@ func InstantiateFormulaOnBead(formulaName, beadID, title, hookWorkDir, townRoot
 		return nil, fmt.Errorf("parsing wisp output: %w", err)
 	}
 
+	stampFormulaPolicies(wispRootID, formulaName, formulaWorkDir, townRoot, append([]string{featureVar, issueVar}, extraVars...))
+
 	// Step 3: Bond wisp to original bead (creates compound)
 	bondArgs := []string{"mol", "bond", wispRootID, beadID, "--json"}
 	bondCmd := exec.Command("bd", bondArgs...)
@@ -652,8 +742,74 @@ func InstantiateFormulaOnBead(formulaName, beadID, title, hookWorkDir, townRoot
 // CookFormula cooks a formula to ensure its proto exists.
 // This is useful for batch mode where we cook once before processing multiple beads.
 func CookFormula(formulaName, workDir string) error {
-	cookCmd := exec.Command("bd", "cook", formulaName)
-	cookCmd.Dir = workDir
+	flatDir, err := flattenFormulaForBD(formulaName, workDir)
+	if err != nil {
+		return err
+	}
+	if flatDir != "" {
+		defer func() { _ = os.RemoveAll(flatDir) }()
+	}
+	cookCmd := formulaBDCommand(flatDir, workDir, "cook", formulaName)
 	cookCmd.Stderr = os.Stderr
 	return cookCmd.Run()
 }
+
+// flattenFormulaForBD prepares a formula for bd, which does not understand
+// extends, include, overrides or after/before. A composed formula, or one
+// with step execution policies, is resolved, has its step IDs recorded for
+// stampStepPolicies (see recordFormulaStepIDs) and is written to
+// <tmp>/.beads/formulas/<name>.formula.toml, and tmp is returned for
+// formulaBDCommand; bd finds it there ahead of the original. Formulas bd
+// can read as they are, or that gt cannot find, return "". The caller
+// removes the directory.
+func flattenFormulaForBD(formulaName string, dirs ...string) (string, error) {
+	paths := moleculeFormulaPaths(dirs...)
+	data := installedFormula(formulaName, paths)
+	if data == nil {
+		return "", nil
+	}
+	composed := formula.IsComposedSource(data)
+	f, err := formula.Load(formulaName, paths...)
+	if err != nil {
+		if !composed {
+			return "", nil // Let bd report what it makes of it
+		}
+		return "", fmt.Errorf("resolving formula %s: %w", formulaName, err)
+	}
+	if !composed && !f.HasExecutionPolicy() {
+		return "", nil
+	}
+	recordFormulaStepIDs(f)
+	flat, err := f.TOML()
+	if err != nil {
+		return "", fmt.Errorf("encoding formula %s: %w", formulaName, err)
+	}
+
+	dir, err := os.MkdirTemp("", "gt-formula-")
+	if err != nil {
+		return "", fmt.Errorf("creating formula dir: %w", err)
+	}
+	formulasDir := filepath.Join(dir, ".beads", "formulas")
+	if err := os.MkdirAll(formulasDir, 0755); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("creating formula dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(formulasDir, formulaName+".formula.toml"), flat, 0644); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("writing flattened formula: %w", err)
+	}
+	return dir, nil
+}
+
+// formulaBDCommand returns a bd command on the beads at workDir. With a
+// flatDir from flattenFormulaForBD, bd runs there, with BEADS_DIR pointing
+// back at workDir's database, so formula lookups see the flattened copy.
+func formulaBDCommand(flatDir, workDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("bd", args...)
+	cmd.Dir = workDir
+	if flatDir != "" {
+		cmd.Dir = flatDir
+		cmd.Env = append(os.Environ(), "BEADS_DIR="+beads.ResolveBeadsDir(workDir))
+	}
+	return cmd
+}
@@ -47,7 +47,9 @@ Exit codes:
   2 - Operation BLOCKED (in agent context)
 
 The guard only blocks when running as a Gas Town agent (crew, polecat,
-witness, etc.). Humans running outside Gas Town can still use PRs.`,
+witness, etc.). Humans running outside Gas Town can still use PRs.
+Rigs with protected branches use the refinery's merge_mode "pr", which
+opens and merges PRs through the forge API on the workers' behalf.`,
 	RunE: runTapGuardPRWorkflow,
 }
 
@@ -29,6 +29,9 @@ const (
 	AgentAmp AgentPreset = "amp"
 	// AgentOpenCode is OpenCode multi-model CLI.
 	AgentOpenCode AgentPreset = "opencode"
+	// AgentScripted is the built-in scripted runtime (gt scripted-agent),
+	// which follows a script instead of a model. Used for tests and demos.
+	AgentScripted AgentPreset = "scripted"
 )
 
 // AgentPresetInfo contains the configuration details for an agent preset.
@@ -203,6 +206,17 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 			OutputFlag: "--format json",
 		},
 	},
+	AgentScripted: {
+		Name:                AgentScripted,
+		Command:             "gt",
+		Args:                []string{"scripted-agent"},
+		ProcessNames:        []string{"gt"},
+		SessionIDEnv:        "",
+		ResumeFlag:          "", // Scripts restart from the top
+		ResumeStyle:         "",
+		SupportsHooks:       false, // Startup fallback nudges arrive as input
+		SupportsForkSession: false,
+	},
 }
 
 // Registry state with proper synchronization.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -218,6 +219,12 @@ func validateRigSettings(c *RigSettings) error {
 // ErrInvalidOnConflict indicates an invalid on_conflict strategy.
 var ErrInvalidOnConflict = errors.New("invalid on_conflict strategy")
 
+// ErrInvalidMergeMode indicates an invalid merge_mode or forge setting.
+var ErrInvalidMergeMode = errors.New("invalid merge_mode")
+
+// ErrInvalidMergeStrategy indicates an invalid merge_strategy.
+var ErrInvalidMergeStrategy = errors.New("invalid merge_strategy")
+
 // validateMergeQueueConfig validates a MergeQueueConfig.
 func validateMergeQueueConfig(c *MergeQueueConfig) error {
 	// Validate on_conflict strategy
@@ -240,7 +247,97 @@ func validateMergeQueueConfig(c *MergeQueueConfig) error {
 	if c.MaxConcurrent < 0 {
 		return fmt.Errorf("%w: max_concurrent must be non-negative", ErrMissingField)
 	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("%w: batch_size must be non-negative", ErrMissingField)
+	}
+	for _, pattern := range c.BackportBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid backport_branches pattern %q: %w", pattern, err)
+		}
+	}
+
+	// Validate merge_mode and its forge settings
+	if c.MergeMode != "" && c.MergeMode != MergeModeDirect && c.MergeMode != MergeModePR {
+		return fmt.Errorf("%w: got '%s', want '%s' or '%s'",
+			ErrInvalidMergeMode, c.MergeMode, MergeModeDirect, MergeModePR)
+	}
+	if !ValidMergeStrategy(c.MergeStrategy) {
+		return fmt.Errorf("%w: got '%s', want '%s', '%s' or '%s'",
+			ErrInvalidMergeStrategy, c.MergeStrategy, MergeStrategySquash, MergeStrategyRebase, MergeStrategyMerge)
+	}
+	if c.Forge != nil {
+		if c.Forge.Type != "" && c.Forge.Type != ForgeGitHub && c.Forge.Type != ForgeGitea {
+			return fmt.Errorf("%w: forge type '%s', want '%s' or '%s'",
+				ErrInvalidMergeMode, c.Forge.Type, ForgeGitHub, ForgeGitea)
+		}
+		if c.Forge.CheckTimeout != "" {
+			if _, err := time.ParseDuration(c.Forge.CheckTimeout); err != nil {
+				return fmt.Errorf("invalid forge check_timeout: %w", err)
+			}
+		}
+	}
 
+	return ValidateCheckStages(c.Checks)
+}
+
+// ErrInvalidCheckStage indicates an invalid merge_queue.checks entry.
+var ErrInvalidCheckStage = errors.New("invalid check stage")
+
+// ValidateCheckStages validates merge_queue.checks: unique names, a command
+// per stage, valid timeouts, and needs that name other stages without
+// forming a cycle.
+func ValidateCheckStages(stages []CheckStage) error {
+	byName := make(map[string]CheckStage, len(stages))
+	for _, s := range stages {
+		if s.Name == "" {
+			return fmt.Errorf("%w: stage without a name", ErrInvalidCheckStage)
+		}
+		if _, dup := byName[s.Name]; dup {
+			return fmt.Errorf("%w: duplicate stage %q", ErrInvalidCheckStage, s.Name)
+		}
+		if strings.TrimSpace(s.Command) == "" {
+			return fmt.Errorf("%w: stage %q has no command", ErrInvalidCheckStage, s.Name)
+		}
+		if s.Timeout != "" {
+			if _, err := time.ParseDuration(s.Timeout); err != nil {
+				return fmt.Errorf("%w: stage %q timeout: %v", ErrInvalidCheckStage, s.Name, err)
+			}
+		}
+		if s.Retries < 0 {
+			return fmt.Errorf("%w: stage %q retries must be non-negative", ErrInvalidCheckStage, s.Name)
+		}
+		if _, err := filepath.Match(s.JUnit, ""); err != nil {
+			return fmt.Errorf("%w: stage %q junit: %v", ErrInvalidCheckStage, s.Name, err)
+		}
+		byName[s.Name] = s
+	}
+
+	state := make(map[string]int) // 1 = visiting, 2 = done
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("%w: needs cycle through %q", ErrInvalidCheckStage, name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, need := range byName[name].Needs {
+			if _, ok := byName[need]; !ok {
+				return fmt.Errorf("%w: stage %q needs unknown stage %q", ErrInvalidCheckStage, name, need)
+			}
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	for _, s := range stages {
+		if err := visit(s.Name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -273,7 +370,12 @@ func LoadRigSettings(path string) (*RigSettings, error) {
 		}
 		return nil, fmt.Errorf("reading settings: %w", err)
 	}
+	return ParseRigSettings(data)
+}
 
+// ParseRigSettings parses and validates rig settings read elsewhere, e.g.
+// from a remote rig over its connection.
+func ParseRigSettings(data []byte) (*RigSettings, error) {
 	var settings RigSettings
 	if err := json.Unmarshal(data, &settings); err != nil {
 		return nil, fmt.Errorf("parsing settings: %w", err)
@@ -1747,6 +1849,27 @@ func validateEscalationConfig(c *EscalationConfig) error {
 		return fmt.Errorf("%w: max_reescalations must be non-negative", ErrMissingField)
 	}
 
+	// Validate delivery settings if specified
+	if c.Delivery.Timeout != "" {
+		if _, err := time.ParseDuration(c.Delivery.Timeout); err != nil {
+			return fmt.Errorf("invalid delivery.timeout: %w", err)
+		}
+	}
+	if c.Delivery.RetryDelay != "" {
+		if _, err := time.ParseDuration(c.Delivery.RetryDelay); err != nil {
+			return fmt.Errorf("invalid delivery.retry_delay: %w", err)
+		}
+	}
+	if c.Delivery.Attempts < 0 {
+		return fmt.Errorf("%w: delivery.attempts must be non-negative", ErrMissingField)
+	}
+	if c.Delivery.SMTP != nil && (c.Delivery.SMTP.Host == "" || c.Delivery.SMTP.From == "") {
+		return fmt.Errorf("%w: delivery.smtp requires host and from", ErrMissingField)
+	}
+	if c.Delivery.SMS != nil && c.Delivery.SMS.URL == "" {
+		return fmt.Errorf("%w: delivery.sms requires url", ErrMissingField)
+	}
+
 	return nil
 }
 
@@ -1773,6 +1896,35 @@ func (c *EscalationConfig) GetRouteForSeverity(severity string) []string {
 	return []string{"bead", "mail:mayor"}
 }
 
+// GetDeliveryTimeout returns the per-attempt timeout for external notifications.
+// Returns 10 seconds if not configured or invalid.
+func (c *EscalationConfig) GetDeliveryTimeout() time.Duration {
+	d, err := time.ParseDuration(c.Delivery.Timeout)
+	if err != nil || d <= 0 {
+		return 10 * time.Second
+	}
+	return d
+}
+
+// GetDeliveryAttempts returns how many times an external notification is tried.
+// Returns 3 if not configured.
+func (c *EscalationConfig) GetDeliveryAttempts() int {
+	if c.Delivery.Attempts <= 0 {
+		return 3
+	}
+	return c.Delivery.Attempts
+}
+
+// GetDeliveryRetryDelay returns the initial backoff between notification attempts.
+// Returns 2 seconds if not configured or invalid.
+func (c *EscalationConfig) GetDeliveryRetryDelay() time.Duration {
+	d, err := time.ParseDuration(c.Delivery.RetryDelay)
+	if err != nil || d < 0 {
+		return 2 * time.Second
+	}
+	return d
+}
+
 // GetMaxReescalations returns the maximum number of re-escalations allowed.
 // Returns 2 if not configured.
 func (c *EscalationConfig) GetMaxReescalations() int {
@@ -344,6 +344,140 @@ func TestRigSettingsValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "pr merge mode",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					MergeMode: MergeModePR,
+					Forge:     &ForgeConfig{Type: ForgeGitea, CheckTimeout: "45m"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid merge_mode",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					MergeMode: "push",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid forge type",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					MergeMode: MergeModePR,
+					Forge:     &ForgeConfig{Type: "gitlab"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative batch_size",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					BatchSize: -1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "merge_strategy",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					MergeStrategy:  MergeStrategyRebase,
+					CommitTemplate: "{message}\n\n{coauthors}",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid merge_strategy",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					MergeStrategy: "octopus",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid backport_branches pattern",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					BackportBranches: []string{"release/["},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "check stages",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					Checks: []CheckStage{
+						{Name: "build", Command: "go build ./...", Timeout: "5m"},
+						{Name: "unit", Command: "go test ./...", Needs: []string{"build"}, Retries: 1},
+						{Name: "docs", Command: "make docs", Paths: []string{"docs/**"}, Optional: true},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "duplicate check stage",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					Checks: []CheckStage{
+						{Name: "unit", Command: "go test ./..."},
+						{Name: "unit", Command: "go vet ./..."},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "check stage timeout",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					Checks: []CheckStage{{Name: "unit", Command: "go test ./...", Timeout: "soon"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "check stage needs cycle",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					Checks: []CheckStage{
+						{Name: "a", Command: "true", Needs: []string{"b"}},
+						{Name: "b", Command: "true", Needs: []string{"a"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -3126,6 +3260,51 @@ func TestEscalationConfigValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_reescalations must be non-negative",
 		},
+		{
+			name: "valid delivery settings",
+			config: &EscalationConfig{
+				Type:    "escalation",
+				Version: 1,
+				Delivery: EscalationDelivery{
+					Timeout:    "5s",
+					Attempts:   4,
+					RetryDelay: "1s",
+					SMTP:       &SMTPConfig{Host: "smtp.example.com", From: "gt@example.com"},
+					SMS:        &SMSWebhookConfig{URL: "https://sms.example.com/send"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid delivery timeout",
+			config: &EscalationConfig{
+				Type:     "escalation",
+				Version:  1,
+				Delivery: EscalationDelivery{Timeout: "soon"},
+			},
+			wantErr: true,
+			errMsg:  "invalid delivery.timeout",
+		},
+		{
+			name: "smtp without host",
+			config: &EscalationConfig{
+				Type:     "escalation",
+				Version:  1,
+				Delivery: EscalationDelivery{SMTP: &SMTPConfig{From: "gt@example.com"}},
+			},
+			wantErr: true,
+			errMsg:  "delivery.smtp requires host and from",
+		},
+		{
+			name: "sms without url",
+			config: &EscalationConfig{
+				Type:     "escalation",
+				Version:  1,
+				Delivery: EscalationDelivery{SMS: &SMSWebhookConfig{From: "gastown"}},
+			},
+			wantErr: true,
+			errMsg:  "delivery.sms requires url",
+		},
 	}
 
 	for _, tt := range tests {
@@ -3192,6 +3371,32 @@ func TestEscalationConfigGetStaleThreshold(t *testing.T) {
 	}
 }
 
+func TestEscalationConfigDeliveryDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := &EscalationConfig{}
+	if got := cfg.GetDeliveryTimeout(); got != 10*time.Second {
+		t.Errorf("GetDeliveryTimeout() = %v, want 10s", got)
+	}
+	if got := cfg.GetDeliveryAttempts(); got != 3 {
+		t.Errorf("GetDeliveryAttempts() = %d, want 3", got)
+	}
+	if got := cfg.GetDeliveryRetryDelay(); got != 2*time.Second {
+		t.Errorf("GetDeliveryRetryDelay() = %v, want 2s", got)
+	}
+
+	cfg.Delivery = EscalationDelivery{Timeout: "30s", Attempts: 5, RetryDelay: "0s"}
+	if got := cfg.GetDeliveryTimeout(); got != 30*time.Second {
+		t.Errorf("GetDeliveryTimeout() = %v, want 30s", got)
+	}
+	if got := cfg.GetDeliveryAttempts(); got != 5 {
+		t.Errorf("GetDeliveryAttempts() = %d, want 5", got)
+	}
+	if got := cfg.GetDeliveryRetryDelay(); got != 0 {
+		t.Errorf("GetDeliveryRetryDelay() = %v, want 0", got)
+	}
+}
+
 func TestEscalationConfigGetRouteForSeverity(t *testing.T) {
 	t.Parallel()
 
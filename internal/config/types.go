@@ -72,8 +72,54 @@ type TownSettings struct {
 	// Agent addresses like "gastown/crew/jack" become "gastown.crew.jack@{domain}".
 	// Default: "gastown.local"
 	AgentEmailDomain string `json:"agent_email_domain,omitempty"`
+
+	// Dashboard configures access control for `gt dashboard`.
+	// When no tokens are configured the dashboard is unauthenticated.
+	Dashboard *DashboardConfig `json:"dashboard,omitempty"`
+
+	// Budgets caps spend per rig, role and convoy. The daemon checks spend
+	// against them each heartbeat (see `gt costs budget`).
+	Budgets *BudgetConfig `json:"budgets,omitempty"`
+
+	// BeadsBackend selects how gt reaches the beads database.
+	// Values: "bd" (default, runs the bd CLI) or "native" (reads the beads
+	// JSONL in-process and writes with bd, or reads and writes the Dolt SQL
+	// server for Dolt server mode; no-db and Dolt server directories only).
+	// GT_BEADS_BACKEND overrides this.
+	BeadsBackend string `json:"beads_backend,omitempty"`
+
+	// Tracing exports a trace per bead covering its lifecycle from
+	// gt sling to merge. Disabled when nil.
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+}
+
+// TracingConfig configures OpenTelemetry trace export.
+type TracingConfig struct {
+	// Enabled turns tracing on.
+	Enabled bool `json:"enabled"`
+
+	// Endpoint is the OTLP/HTTP collector base URL; spans are posted to
+	// <endpoint>/v1/traces. Default: "http://localhost:4318".
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT
+	// override it.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Exporter is "otlp" (default) to send spans to Endpoint, or "file" to
+	// only write them to File.
+	Exporter string `json:"exporter,omitempty"`
+
+	// File receives spans as OTLP JSON lines when the collector cannot be
+	// reached, or always with the file exporter. Relative paths are
+	// resolved against the town root. Default: ".traces.jsonl".
+	File string `json:"file,omitempty"`
 }
 
+// Beads backends for TownSettings.BeadsBackend.
+const (
+	BeadsBackendBD     = "bd"
+	BeadsBackendNative = "native"
+)
+
 // NewTownSettings creates a new TownSettings with defaults.
 func NewTownSettings() *TownSettings {
 	return &TownSettings{
@@ -109,6 +155,36 @@ type ZombieConfig struct {
 	Protected []string `json:"protected,omitempty"`
 }
 
+// Dashboard token roles.
+const (
+	// DashboardRoleReadOnly may only make GET requests.
+	DashboardRoleReadOnly = "read-only"
+	// DashboardRoleOperator may also run commands, send mail and create issues.
+	DashboardRoleOperator = "operator"
+)
+
+// DashboardConfig holds the bearer tokens accepted by `gt dashboard`.
+type DashboardConfig struct {
+	// Tokens are the access tokens. Manage them with `gt dashboard token`.
+	Tokens []DashboardToken `json:"tokens,omitempty"`
+}
+
+// DashboardToken is one dashboard access token. Only the SHA-256 of the
+// secret is stored; the secret itself is shown once when the token is created.
+type DashboardToken struct {
+	// Name identifies the token holder in the audit log (e.g., "alice", "ci").
+	Name string `json:"name"`
+
+	// Role is DashboardRoleReadOnly or DashboardRoleOperator.
+	Role string `json:"role"`
+
+	// SHA256 is the hex-encoded SHA-256 of the token secret.
+	SHA256 string `json:"sha256"`
+
+	// CreatedAt is when the token was created (RFC 3339).
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
 // DefaultZombieIdleThreshold is the default idle time before a polecat is considered zombie.
 const DefaultZombieIdleThreshold = "2h"
 
@@ -211,6 +287,15 @@ type RigEntry struct {
 	LocalRepo   string       `json:"local_repo,omitempty"`
 	AddedAt     time.Time    `json:"added_at"`
 	BeadsConfig *BeadsConfig `json:"beads,omitempty"`
+
+	// Machine names the machine (in mayor/machines.json) that hosts the rig.
+	// Empty or "local" means the rig lives on this machine.
+	Machine string `json:"machine,omitempty"`
+}
+
+// IsRemote returns true if the rig is hosted on another machine.
+func (e RigEntry) IsRemote() bool {
+	return e.Machine != "" && e.Machine != "local"
 }
 
 // BeadsConfig represents beads configuration for a rig.
@@ -280,8 +365,43 @@ type RigSettings struct {
 	// Overrides TownSettings.RoleAgents for this specific rig.
 	// Example: {"witness": "claude-haiku", "polecat": "claude-sonnet"}
 	RoleAgents map[string]string `json:"role_agents,omitempty"`
+
+	// Budget caps this rig's spend. Takes precedence over the rig's entry
+	// in TownSettings.Budgets.Rigs.
+	Budget *BudgetLimit `json:"budget,omitempty"`
+}
+
+// BudgetConfig defines spend caps for the town.
+type BudgetConfig struct {
+	// Rigs maps rig names to caps on all sessions in that rig.
+	Rigs map[string]*BudgetLimit `json:"rigs,omitempty"`
+
+	// Roles maps role names ("polecat", "witness", "mayor", ...) to caps on
+	// all sessions with that role, town-wide.
+	Roles map[string]*BudgetLimit `json:"roles,omitempty"`
+
+	// Convoys maps convoy IDs to caps on sessions working its tracked issues.
+	Convoys map[string]*BudgetLimit `json:"convoys,omitempty"`
+
+	// WarnAt lists fractions of a cap (e.g., 0.8) at which the daemon
+	// escalates a warning. Default: [0.8].
+	WarnAt []float64 `json:"warn_at,omitempty"`
+
+	// PausePolecats pauses working polecats covered by a cap once it is hit.
+	// New polecat spawns are refused regardless.
+	PausePolecats bool `json:"pause_polecats,omitempty"`
 }
 
+// BudgetLimit is a daily and/or weekly USD cap. Zero means no cap.
+// Days are calendar days in local time; weeks are the last seven days.
+type BudgetLimit struct {
+	DailyUSD  float64 `json:"daily_usd,omitempty"`
+	WeeklyUSD float64 `json:"weekly_usd,omitempty"`
+}
+
+// DefaultBudgetWarnAt is the warning threshold used when WarnAt is empty.
+var DefaultBudgetWarnAt = []float64{0.8}
+
 // CrewConfig represents crew workspace settings for a rig.
 type CrewConfig struct {
 	// Startup is a natural language instruction for which crew to start on boot.
@@ -301,7 +421,7 @@ type CrewConfig struct {
 // without modifying startup code.
 type RuntimeConfig struct {
 	// Provider selects runtime-specific defaults and integration behavior.
-	// Known values: "claude", "codex", "generic". Default: "claude".
+	// Known values: "claude", "codex", "opencode", "scripted", "generic". Default: "claude".
 	Provider string `json:"provider,omitempty"`
 
 	// Command is the CLI command to invoke (e.g., "claude", "aider").
@@ -522,6 +642,8 @@ func defaultRuntimeCommand(provider string) string {
 		return "codex"
 	case "opencode":
 		return "opencode"
+	case "scripted":
+		return "gt"
 	case "generic":
 		return ""
 	default:
@@ -558,6 +680,8 @@ func defaultRuntimeArgs(provider string) []string {
 	switch provider {
 	case "claude":
 		return []string{"--dangerously-skip-permissions"}
+	case "scripted":
+		return []string{"scripted-agent"}
 	default:
 		return nil
 	}
@@ -641,6 +765,10 @@ func defaultReadyPromptPrefix(provider string) string {
 		// Claude Code uses ❯ (U+276F) as the prompt character
 		return "❯ "
 	}
+	if provider == "scripted" {
+		// Must match scripted.DefaultReadyPrompt
+		return "> "
+	}
 	return ""
 }
 
@@ -760,6 +888,91 @@ type MergeQueueConfig struct {
 
 	// MaxConcurrent is the maximum number of concurrent merges.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// BatchSize, when greater than one, merges up to this many MRs as one
+	// batch that is tested once, bisecting on failure. Takes precedence
+	// over MaxConcurrent.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// MergeMode is how MRs land: "direct" (merge locally and push,
+	// the default) or "pr" (open a pull request through the forge API, wait
+	// for its checks and merge it there, for protected branches).
+	MergeMode string `json:"merge_mode,omitempty"`
+
+	// MergeStrategy is how an MR's commits land on its target: "squash"
+	// (one commit, the default), "rebase" (the commits rebased onto the
+	// target and fast-forwarded) or "merge" (a merge commit, --no-ff).
+	// An MR's merge_strategy field overrides it.
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+
+	// CommitTemplate, if set, formats the squash or merge commit message.
+	// Supports variables: {message}, {title}, {issue}, {mr}, {branch},
+	// {target}, {polecat}, {rig}, {coauthors}
+	// - {message}: The branch's own commit message
+	// - {title}: The source issue's title
+	// - {issue}: The source issue ID (e.g., "gt-abc")
+	// - {polecat}: The worker that submitted the MR (e.g., "nux")
+	// - {coauthors}: Co-authored-by trailers for the branch's commit authors
+	// Unused by "rebase", which keeps each commit's message. An MR's
+	// commit_template field overrides it.
+	CommitTemplate string `json:"commit_template,omitempty"`
+
+	// Forge configures the forge API used when MergeMode is "pr".
+	Forge *ForgeConfig `json:"forge,omitempty"`
+
+	// Checks are the named pre-merge check stages. If empty, TestCommand
+	// runs as a single "test" stage.
+	Checks []CheckStage `json:"checks,omitempty"`
+
+	// Quarantine lists tests whose failures don't gate merges, by name
+	// ("TestFoo") or package-qualified ("example.com/pkg.TestFoo").
+	Quarantine []string `json:"quarantine,omitempty"`
+
+	// PostMergeVerify runs verification on the target branch after MRs
+	// land. If it fails, the refinery bisects the recent merges, queues a
+	// revert of the culprit and pauses the queue until the target is green.
+	PostMergeVerify bool `json:"post_merge_verify,omitempty"`
+
+	// VerifyCommand, if set, is run for post-merge verification instead
+	// of the checks.
+	VerifyCommand string `json:"verify_command,omitempty"`
+
+	// BackportBranches are glob patterns for the branches MRs may request
+	// backports to, via a "backport:<branch>" label on the source issue or
+	// the MR's backports field. Default: ["release/*"].
+	BackportBranches []string `json:"backport_branches,omitempty"`
+}
+
+// CheckStage is a named pre-merge check run by the refinery. Stages run in
+// parallel unless ordered with Needs.
+type CheckStage struct {
+	// Name identifies the stage in results (e.g., "build", "unit").
+	Name string `json:"name"`
+
+	// Command is run with sh -c in the merge worktree.
+	Command string `json:"command"`
+
+	// Timeout bounds each attempt (e.g., "10m"). Default: no limit.
+	Timeout string `json:"timeout,omitempty"`
+
+	// Retries is how many times a failed attempt is retried.
+	Retries int `json:"retries,omitempty"`
+
+	// Optional stages are reported but never block a merge.
+	Optional bool `json:"optional,omitempty"`
+
+	// Paths limits the stage to MRs that change a matching file. Patterns
+	// are globs ("*.go", "docs/*.md"); "dir/**" matches everything under
+	// dir. Default: always run.
+	Paths []string `json:"paths,omitempty"`
+
+	// Needs lists stages that must pass (or be skipped) before this one
+	// starts.
+	Needs []string `json:"needs,omitempty"`
+
+	// JUnit is a glob, relative to the worktree, of JUnit XML reports the
+	// command writes. Output of "go test -json" is detected automatically.
+	JUnit string `json:"junit,omitempty"`
 }
 
 // OnConflict strategy constants.
@@ -768,6 +981,63 @@ const (
 	OnConflictAutoRebase = "auto_rebase"
 )
 
+// MergeMode constants.
+const (
+	MergeModeDirect = "direct"
+	MergeModePR     = "pr"
+)
+
+// MergeStrategy constants.
+const (
+	MergeStrategySquash = "squash"
+	MergeStrategyRebase = "rebase"
+	MergeStrategyMerge  = "merge"
+)
+
+// ValidMergeStrategy reports whether s is a merge strategy (empty means
+// the default).
+func ValidMergeStrategy(s string) bool {
+	switch s {
+	case "", MergeStrategySquash, MergeStrategyRebase, MergeStrategyMerge:
+		return true
+	}
+	return false
+}
+
+// Forge types supported by merge_mode "pr".
+const (
+	ForgeGitHub = "github"
+	ForgeGitea  = "gitea"
+)
+
+// ForgeConfig configures the forge (GitHub or Gitea) API the refinery uses
+// in merge_mode "pr". Every field is optional when the rig's origin is on
+// github.com.
+type ForgeConfig struct {
+	// Type is "github" or "gitea". Inferred from the origin URL if empty:
+	// github.com is GitHub, any other host must set it.
+	Type string `json:"type,omitempty"`
+
+	// APIURL is the API base URL. Default: https://api.github.com for
+	// GitHub, https://<origin host>/api/v1 for Gitea.
+	APIURL string `json:"api_url,omitempty"`
+
+	// Repo is the repository as "owner/name". Inferred from the origin URL.
+	Repo string `json:"repo,omitempty"`
+
+	// TokenEnv names the environment variable holding the API token.
+	// Default: GITHUB_TOKEN or GITEA_TOKEN.
+	TokenEnv string `json:"token_env,omitempty"`
+
+	// RequiredChecks names the checks that must pass before merging. If
+	// empty, every check reported on the PR head must pass.
+	RequiredChecks []string `json:"required_checks,omitempty"`
+
+	// CheckTimeout is how long to wait for checks (e.g., "30m").
+	// Default: "30m".
+	CheckTimeout string `json:"check_timeout,omitempty"`
+}
+
 // DefaultMergeQueueConfig returns a MergeQueueConfig with sensible defaults.
 func DefaultMergeQueueConfig() *MergeQueueConfig {
 	return &MergeQueueConfig{
@@ -913,6 +1183,10 @@ type EscalationConfig struct {
 	// Contacts contains contact information for external notification actions.
 	Contacts EscalationContacts `json:"contacts"`
 
+	// Delivery configures how external notification actions are sent
+	// (SMTP server, SMS gateway, timeouts and retries).
+	Delivery EscalationDelivery `json:"delivery,omitempty"`
+
 	// StaleThreshold is how long before an unacknowledged escalation
 	// is considered stale and gets re-escalated.
 	// Format: Go duration string (e.g., "4h", "30m", "24h")
@@ -931,6 +1205,47 @@ type EscalationContacts struct {
 	SlackWebhook string `json:"slack_webhook,omitempty"` // webhook URL for slack action
 }
 
+// EscalationDelivery configures delivery of external notification actions.
+// Secrets are never stored in the file; they are read from the environment
+// variables named by the *_env fields.
+type EscalationDelivery struct {
+	// Timeout bounds each delivery attempt. Go duration string. Default: "10s"
+	Timeout string `json:"timeout,omitempty"`
+
+	// Attempts is the total number of tries per action before giving up.
+	// Default: 3
+	Attempts int `json:"attempts,omitempty"`
+
+	// RetryDelay is the wait before the first retry; it doubles after each
+	// failed attempt. Go duration string. Default: "2s"
+	RetryDelay string `json:"retry_delay,omitempty"`
+
+	// SMTP configures the mail server used by email: actions.
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+
+	// SMS configures the HTTP gateway used by sms: actions.
+	SMS *SMSWebhookConfig `json:"sms,omitempty"`
+}
+
+// SMTPConfig describes an SMTP server for email delivery.
+// STARTTLS is used whenever the server offers it.
+type SMTPConfig struct {
+	Host        string `json:"host"`                   // server hostname
+	Port        int    `json:"port,omitempty"`         // default: 587
+	From        string `json:"from"`                   // envelope and header sender
+	Username    string `json:"username,omitempty"`     // AUTH PLAIN user (optional)
+	PasswordEnv string `json:"password_env,omitempty"` // env var holding the password
+}
+
+// SMSWebhookConfig describes a generic SMS gateway reached over HTTP.
+// The gateway receives a JSON POST of {"to", "from", "body"}.
+type SMSWebhookConfig struct {
+	URL      string            `json:"url"`                 // gateway endpoint
+	From     string            `json:"from,omitempty"`      // sender number or ID
+	TokenEnv string            `json:"token_env,omitempty"` // env var holding a bearer token
+	Headers  map[string]string `json:"headers,omitempty"`   // extra request headers
+}
+
 // CurrentEscalationVersion is the current schema version for EscalationConfig.
 const CurrentEscalationVersion = 1
 
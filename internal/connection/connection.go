@@ -166,6 +166,16 @@ func (e *NotFoundError) Error() string {
 	return "not found: " + e.Path
 }
 
+// Is lets errors.Is(err, fs.ErrNotExist) match NotFoundError.
+func (e *NotFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
 func (e *PermissionError) Error() string {
 	return "permission denied: " + e.Op + " " + e.Path
 }
+
+// Is lets errors.Is(err, fs.ErrPermission) match PermissionError.
+func (e *PermissionError) Is(target error) bool {
+	return target == fs.ErrPermission
+}
@@ -31,6 +31,11 @@ func (c *LocalConnection) IsLocal() bool {
 	return true
 }
 
+// Tmux returns the tmux wrapper for the local tmux server.
+func (c *LocalConnection) Tmux() *tmux.Tmux {
+	return c.tmux
+}
+
 // ReadFile reads the named file.
 func (c *LocalConnection) ReadFile(path string) ([]byte, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from Connection interface, validated by caller
@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
+
+	"github.com/steveyegge/gastown/internal/tmux"
 )
 
 // Machine represents a managed machine in the federation.
 type Machine struct {
 	Name     string `json:"name"`
-	Type     string `json:"type"`      // "local", "ssh"
-	Host     string `json:"host"`      // for ssh: user@host
-	KeyPath  string `json:"key_path"`  // SSH private key path
-	TownPath string `json:"town_path"` // Path to town root on remote
+	Type     string `json:"type"`           // "local", "ssh"
+	Host     string `json:"host"`           // for ssh: user@host
+	Port     int    `json:"port,omitempty"` // SSH port (0: ssh default)
+	User     string `json:"user,omitempty"` // SSH login user, if not in Host
+	KeyPath  string `json:"key_path"`       // SSH private key path
+	TownPath string `json:"town_path"`      // Path to town root on remote
 }
 
 // registryData is the JSON file structure.
@@ -176,15 +181,44 @@ func (r *MachineRegistry) Connection(name string) (Connection, error) {
 	case "local":
 		return NewLocalConnection(), nil
 	case "ssh":
-		// SSH connection not yet implemented
-		return nil, fmt.Errorf("ssh connections not yet implemented")
+		return NewSSHConnection(m.Name, SSHConfig{
+			Host:    m.Host,
+			Port:    m.Port,
+			User:    m.User,
+			KeyPath: m.KeyPath,
+		}), nil
 	default:
 		return nil, fmt.Errorf("unknown machine type: %s", m.Type)
 	}
 }
 
+// RigPath returns the path of a rig on the named machine.
+// Remote rigs live under the machine's TownPath; local rigs under localTownRoot.
+func (r *MachineRegistry) RigPath(machine, localTownRoot, rigName string) (string, error) {
+	m, err := r.Get(machine)
+	if err != nil {
+		return "", err
+	}
+	if m.Type == "local" {
+		return filepath.Join(localTownRoot, rigName), nil
+	}
+	if m.TownPath == "" {
+		return "", fmt.Errorf("machine %s has no town_path", machine)
+	}
+	return path.Join(m.TownPath, rigName), nil
+}
+
 // LocalConnection returns the local connection.
 // This is a convenience method for the common case.
 func (r *MachineRegistry) LocalConnection() *LocalConnection {
 	return NewLocalConnection()
 }
+
+// TmuxFor returns the tmux wrapper that manages sessions through conn.
+// Connections that don't expose one fall back to the local tmux server.
+func TmuxFor(conn Connection) *tmux.Tmux {
+	if tc, ok := conn.(interface{ Tmux() *tmux.Tmux }); ok {
+		return tc.Tmux()
+	}
+	return tmux.NewTmux()
+}
@@ -0,0 +1,574 @@
+package connection
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Exit codes used by the remote helper scripts to report well-known failures.
+// They follow sysexits(3) so they are unlikely to collide with real commands.
+const (
+	exitNotFound   = 66 // EX_NOINPUT
+	exitPermission = 77 // EX_NOPERM
+
+	// sshExitConnection is the status ssh itself exits with when the
+	// connection (rather than the remote command) fails.
+	sshExitConnection = 255
+)
+
+// Default settings for SSH connections.
+const (
+	DefaultSSHConnectTimeout = 10 * time.Second
+	DefaultSSHControlPersist = 10 * time.Minute
+)
+
+// SSHConfig configures an SSHConnection.
+type SSHConfig struct {
+	// Host is the ssh destination, e.g. "user@host" or an ssh_config alias.
+	Host string
+
+	// Port overrides the ssh port. Zero uses the ssh default.
+	Port int
+
+	// User overrides the login user. Empty uses the one in Host, if any,
+	// or the ssh_config default.
+	User string
+
+	// KeyPath is the private key to authenticate with. Empty uses the agent
+	// and ssh_config defaults.
+	KeyPath string
+
+	// ControlDir holds the ControlMaster sockets used to multiplex commands
+	// over one persistent connection. Defaults to ~/.gt/ssh.
+	ControlDir string
+
+	// ConnectTimeout bounds how long establishing the connection may take.
+	ConnectTimeout time.Duration
+
+	// ControlPersist is how long the master connection stays open after the
+	// last command finishes.
+	ControlPersist time.Duration
+
+	// Binary is the ssh client to run. Defaults to "ssh".
+	Binary string
+
+	// Options are extra "-o" options passed to every invocation.
+	Options []string
+}
+
+// SSHConnection implements Connection for a remote machine reached over SSH.
+//
+// All commands share a single multiplexed session (OpenSSH ControlMaster), so
+// only the first operation pays the handshake cost. Commands are executed by
+// the remote user's /bin/sh; every argument is shell-quoted before transport.
+type SSHConnection struct {
+	name   string
+	cfg    SSHConfig
+	tmux   *tmux.Tmux
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSSHConnection creates an SSH connection to the given host.
+// The connection is established lazily on first use.
+func NewSSHConnection(name string, cfg SSHConfig) *SSHConnection {
+	if cfg.Binary == "" {
+		cfg.Binary = "ssh"
+	}
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = DefaultSSHConnectTimeout
+	}
+	if cfg.ControlPersist <= 0 {
+		cfg.ControlPersist = DefaultSSHControlPersist
+	}
+	if cfg.ControlDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			cfg.ControlDir = filepath.Join(home, ".gt", "ssh")
+		} else {
+			cfg.ControlDir = filepath.Join(os.TempDir(), "gt-ssh")
+		}
+	}
+	c := &SSHConnection{name: name, cfg: cfg}
+	c.tmux = tmux.NewTmuxWithCommand(c.command)
+	return c
+}
+
+// Name returns the machine name for this connection.
+func (c *SSHConnection) Name() string {
+	return c.name
+}
+
+// IsLocal returns false for SSH connections.
+func (c *SSHConnection) IsLocal() bool {
+	return false
+}
+
+// Host returns the ssh destination for this connection.
+func (c *SSHConnection) Host() string {
+	return c.cfg.Host
+}
+
+// Tmux returns a tmux wrapper that drives the tmux server on the remote machine.
+func (c *SSHConnection) Tmux() *tmux.Tmux {
+	return c.tmux
+}
+
+// controlPath returns the ControlMaster socket path for this host.
+// The path is hashed because unix socket paths are limited to ~104 bytes.
+func (c *SSHConnection) controlPath() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s@%s:%d", c.cfg.User, c.cfg.Host, c.cfg.Port)))
+	return filepath.Join(c.cfg.ControlDir, hex.EncodeToString(sum[:])[:16]+".sock")
+}
+
+// sshArgs returns the ssh client arguments preceding the remote command.
+func (c *SSHConnection) sshArgs() []string {
+	args := []string{
+		"-T",
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + c.controlPath(),
+		"-o", fmt.Sprintf("ControlPersist=%d", int(c.cfg.ControlPersist.Seconds())),
+		"-o", fmt.Sprintf("ConnectTimeout=%d", int(c.cfg.ConnectTimeout.Seconds())),
+		"-o", "ServerAliveInterval=15",
+	}
+	if c.cfg.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(c.cfg.Port))
+	}
+	if c.cfg.User != "" {
+		args = append(args, "-l", c.cfg.User)
+	}
+	if c.cfg.KeyPath != "" {
+		args = append(args, "-i", c.cfg.KeyPath, "-o", "IdentitiesOnly=yes")
+	}
+	for _, opt := range c.cfg.Options {
+		args = append(args, "-o", opt)
+	}
+	return args
+}
+
+// command builds an *exec.Cmd that runs name with args on the remote machine.
+func (c *SSHConnection) command(name string, args ...string) *exec.Cmd {
+	return c.remoteCommand(shellJoin(append([]string{name}, args...)))
+}
+
+// remoteCommand builds an *exec.Cmd that runs a shell command line remotely.
+func (c *SSHConnection) remoteCommand(script string) *exec.Cmd {
+	_ = os.MkdirAll(c.cfg.ControlDir, 0700)
+	args := append(c.sshArgs(), "--", c.cfg.Host, script)
+	return exec.Command(c.cfg.Binary, args...) //nolint:gosec // G204: binary and host come from the machine registry
+}
+
+// run executes a remote shell command line, feeding stdin if non-nil.
+// It returns stdout and stderr separately; ssh transport failures are
+// reported as *ConnectionError.
+func (c *SSHConnection) run(op, script string, stdin []byte) ([]byte, []byte, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, nil, &ConnectionError{Op: op, Machine: c.name, Err: errors.New("connection closed")}
+	}
+
+	cmd := c.remoteCommand(script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() == sshExitConnection {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			return stdout.Bytes(), stderr.Bytes(), &ConnectionError{Op: op, Machine: c.name, Err: errors.New(msg)}
+		}
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// fileOp runs a remote file helper script and maps its exit status onto the
+// connection error types.
+func (c *SSHConnection) fileOp(op, path, script string, stdin []byte) ([]byte, error) {
+	out, stderr, err := c.run(op, script, stdin)
+	if err == nil {
+		return out, nil
+	}
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return nil, err
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case exitNotFound:
+			return nil, &NotFoundError{Path: path}
+		case exitPermission:
+			return nil, &PermissionError{Path: path, Op: op}
+		}
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return nil, fmt.Errorf("%s %s on %s: %s", op, path, c.name, msg)
+	}
+	return nil, fmt.Errorf("%s %s on %s: %w", op, path, c.name, err)
+}
+
+// Connect establishes the master connection and verifies the remote shell.
+// Calling it is optional; the first operation connects implicitly.
+func (c *SSHConnection) Connect() error {
+	_, _, err := c.run("connect", "true", nil)
+	return err
+}
+
+// Close tears down the multiplexed master connection.
+// The connection cannot be used afterwards.
+func (c *SSHConnection) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	args := append(c.sshArgs(), "-O", "exit", "--", c.cfg.Host)
+	// "-O exit" fails when no master is running, which is fine.
+	_ = exec.Command(c.cfg.Binary, args...).Run() //nolint:gosec // G204: see remoteCommand
+	return nil
+}
+
+// ReadFile reads the named file on the remote machine.
+func (c *SSHConnection) ReadFile(path string) ([]byte, error) {
+	p := shellQuote(path)
+	script := fmt.Sprintf(`[ -e %[1]s ] || exit %[2]d; [ -r %[1]s ] || exit %[3]d; cat -- %[1]s`,
+		p, exitNotFound, exitPermission)
+	return c.fileOp("read", path, script, nil)
+}
+
+// WriteFile writes data to the named file on the remote machine.
+// The data is written to a temporary file and renamed into place so readers
+// never observe a partial write.
+func (c *SSHConnection) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	p := shellQuote(path)
+	tmp := shellQuote(path + ".gt-tmp")
+	script := fmt.Sprintf(`d=$(dirname -- %[1]s); [ -d "$d" ] || exit %[3]d; [ -w "$d" ] || exit %[4]d; `+
+		`cat > %[2]s && chmod %[5]o %[2]s && mv -f -- %[2]s %[1]s || { rm -f -- %[2]s; exit 1; }`,
+		p, tmp, exitNotFound, exitPermission, perm.Perm())
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := c.fileOp("write", path, script, data)
+	return err
+}
+
+// MkdirAll creates a directory and all parent directories on the remote machine.
+func (c *SSHConnection) MkdirAll(path string, perm fs.FileMode) error {
+	p := shellQuote(path)
+	script := fmt.Sprintf(`[ -d %[1]s ] && exit 0; mkdir -p -m %[2]o -- %[1]s 2>/dev/null && exit 0; `+
+		`[ -e %[1]s ] && exit 1; exit %[3]d`, p, perm.Perm(), exitPermission)
+	_, err := c.fileOp("mkdir", path, script, nil)
+	return err
+}
+
+// Remove removes the named file or empty directory on the remote machine.
+// Removing a path that does not exist is not an error.
+func (c *SSHConnection) Remove(path string) error {
+	p := shellQuote(path)
+	script := fmt.Sprintf(`[ -e %[1]s ] || [ -L %[1]s ] || exit 0; d=$(dirname -- %[1]s); [ -w "$d" ] || exit %[2]d; `+
+		`if [ -d %[1]s ] && [ ! -L %[1]s ]; then rmdir -- %[1]s; else rm -f -- %[1]s; fi`, p, exitPermission)
+	_, err := c.fileOp("remove", path, script, nil)
+	return err
+}
+
+// RemoveAll removes the named file or directory and any children on the remote machine.
+func (c *SSHConnection) RemoveAll(path string) error {
+	p := shellQuote(path)
+	script := fmt.Sprintf(`[ -e %[1]s ] || [ -L %[1]s ] || exit 0; d=$(dirname -- %[1]s); [ -w "$d" ] || exit %[2]d; `+
+		`rm -rf -- %[1]s`, p, exitPermission)
+	_, err := c.fileOp("remove", path, script, nil)
+	return err
+}
+
+// statScript prints "<size> <octal perm> <mtime unix> <d|f>" for $1.
+// GNU and BSD stat take different flags, so both are tried.
+const statScript = `[ -e "$1" ] || exit %d
+s=$(stat -c '%%s %%a %%Y' -- "$1" 2>/dev/null || stat -f '%%z %%Lp %%m' -- "$1" 2>/dev/null) || exit %d
+if [ -d "$1" ]; then echo "$s d"; else echo "$s f"; fi`
+
+// Stat returns file info for the named file on the remote machine.
+func (c *SSHConnection) Stat(path string) (FileInfo, error) {
+	script := "sh -c " + shellQuote(fmt.Sprintf(statScript, exitNotFound, exitPermission)) + " sh " + shellQuote(path)
+	out, err := c.fileOp("stat", path, script, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatOutput(path, string(out))
+}
+
+// parseStatOutput parses the output of statScript.
+func parseStatOutput(path, out string) (BasicFileInfo, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 4 {
+		return BasicFileInfo{}, fmt.Errorf("stat %s: unexpected output %q", path, strings.TrimSpace(out))
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return BasicFileInfo{}, fmt.Errorf("stat %s: parsing size: %w", path, err)
+	}
+	perm, err := strconv.ParseUint(fields[1], 8, 32)
+	if err != nil {
+		return BasicFileInfo{}, fmt.Errorf("stat %s: parsing mode: %w", path, err)
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return BasicFileInfo{}, fmt.Errorf("stat %s: parsing mtime: %w", path, err)
+	}
+	mode := fs.FileMode(perm) & fs.ModePerm
+	isDir := fields[3] == "d"
+	if isDir {
+		mode |= fs.ModeDir
+	}
+	return BasicFileInfo{
+		FileName:    filepath.Base(path),
+		FileSize:    size,
+		FileMode:    mode,
+		FileModTime: time.Unix(mtime, 0),
+		FileIsDir:   isDir,
+	}, nil
+}
+
+// Glob returns the names of all files matching the pattern on the remote machine.
+// Pattern syntax is that of the remote shell, which matches filepath.Glob for
+// the common *, ? and [...] forms.
+func (c *SSHConnection) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf(`for f in %s; do [ -e "$f" ] || [ -L "$f" ] && printf '%%s\n' "$f"; done; exit 0`,
+		globQuote(pattern))
+	out, err := c.fileOp("glob", pattern, script, nil)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			matches = append(matches, line)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Exists returns true if the path exists on the remote machine.
+func (c *SSHConnection) Exists(path string) (bool, error) {
+	p := shellQuote(path)
+	_, err := c.fileOp("stat", path, fmt.Sprintf(`[ -e %s ] || exit %d`, p, exitNotFound), nil)
+	if err != nil {
+		var nf *NotFoundError
+		if errors.As(err, &nf) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// exec runs a remote command line and returns its combined output,
+// matching the semantics of exec.Cmd.CombinedOutput.
+func (c *SSHConnection) exec(script string) ([]byte, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, &ConnectionError{Op: "exec", Machine: c.name, Err: errors.New("connection closed")}
+	}
+
+	cmd := c.remoteCommand(script)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() == sshExitConnection {
+			return out, &ConnectionError{Op: "exec", Machine: c.name, Err: err}
+		}
+	}
+	return out, err
+}
+
+// Exec runs a command on the remote machine and returns its combined output.
+func (c *SSHConnection) Exec(cmd string, args ...string) ([]byte, error) {
+	return c.exec(shellJoin(append([]string{cmd}, args...)))
+}
+
+// ExecDir runs a command in the specified directory on the remote machine.
+func (c *SSHConnection) ExecDir(dir, cmd string, args ...string) ([]byte, error) {
+	return c.exec("cd " + shellQuote(dir) + " && exec " + shellJoin(append([]string{cmd}, args...)))
+}
+
+// ExecEnv runs a command with additional environment variables on the remote machine.
+func (c *SSHConnection) ExecEnv(env map[string]string, cmd string, args ...string) ([]byte, error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	words := []string{"env"}
+	for _, k := range keys {
+		words = append(words, k+"="+env[k])
+	}
+	words = append(words, cmd)
+	words = append(words, args...)
+	return c.exec("exec " + shellJoin(words))
+}
+
+// TmuxNewSession creates a new tmux session on the remote machine.
+func (c *SSHConnection) TmuxNewSession(name, dir string) error {
+	return c.wrapTmuxError("tmux", c.tmux.NewSession(name, dir))
+}
+
+// TmuxKillSession terminates a tmux session on the remote machine.
+// Uses KillSessionWithProcesses to ensure all descendant processes are killed.
+func (c *SSHConnection) TmuxKillSession(name string) error {
+	return c.wrapTmuxError("tmux", c.tmux.KillSessionWithProcesses(name))
+}
+
+// TmuxSendKeys sends keys to a tmux session on the remote machine.
+func (c *SSHConnection) TmuxSendKeys(session, keys string) error {
+	return c.wrapTmuxError("tmux", c.tmux.SendKeys(session, keys))
+}
+
+// TmuxCapturePane captures the last N lines from a tmux pane on the remote machine.
+func (c *SSHConnection) TmuxCapturePane(session string, lines int) (string, error) {
+	out, err := c.tmux.CapturePane(session, lines)
+	return out, c.wrapTmuxError("tmux", err)
+}
+
+// TmuxHasSession returns true if the session exists on the remote machine.
+func (c *SSHConnection) TmuxHasSession(name string) (bool, error) {
+	ok, err := c.tmux.HasSession(name)
+	return ok, c.wrapTmuxError("tmux", err)
+}
+
+// TmuxListSessions returns all tmux session names on the remote machine.
+func (c *SSHConnection) TmuxListSessions() ([]string, error) {
+	sessions, err := c.tmux.ListSessions()
+	return sessions, c.wrapTmuxError("tmux", err)
+}
+
+// wrapTmuxError converts ssh transport failures surfaced through tmux into
+// *ConnectionError. tmux's own sentinel errors pass through unchanged.
+func (c *SSHConnection) wrapTmuxError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, tmux.ErrNoServer) || errors.Is(err, tmux.ErrSessionExists) ||
+		errors.Is(err, tmux.ErrSessionNotFound) {
+		return err
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sshExitConnection {
+		return &ConnectionError{Op: op, Machine: c.name, Err: err}
+	}
+	if isSSHTransportMessage(err.Error()) {
+		return &ConnectionError{Op: op, Machine: c.name, Err: err}
+	}
+	return err
+}
+
+// isSSHTransportMessage reports whether msg looks like an ssh client failure
+// rather than output from the remote command.
+func isSSHTransportMessage(msg string) bool {
+	for _, marker := range []string{
+		"ssh: ",
+		"Connection refused",
+		"Connection timed out",
+		"Could not resolve hostname",
+		"Permission denied (publickey",
+		"Host key verification failed",
+		"Connection closed by",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes s for safe use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("@%+=:,./_-", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellJoin quotes and joins words into a shell command line.
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = shellQuote(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// globQuote escapes a glob pattern for the shell while leaving the glob
+// metacharacters *, ? and [...] active.
+func globQuote(pattern string) string {
+	var b strings.Builder
+	inBracket := false
+	for _, r := range pattern {
+		switch {
+		case r == '[':
+			inBracket = true
+			b.WriteRune(r)
+		case r == ']':
+			inBracket = false
+			b.WriteRune(r)
+		case r == '*' || r == '?':
+			b.WriteRune(r)
+		case inBracket && (r == '!' || r == '^'):
+			b.WriteRune(r)
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("/._-", r):
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString("'\n'")
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Verify SSHConnection implements Connection.
+var _ Connection = (*SSHConnection)(nil)
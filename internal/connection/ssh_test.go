@@ -0,0 +1,409 @@
+package connection
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSHScript stands in for the ssh client: it skips the client options,
+// then runs the remote command line with the local /bin/sh, just as sshd
+// would hand it to the remote user's shell. The host "unreachable" simulates
+// a transport failure (exit 255).
+const fakeSSHScript = `#!/bin/sh
+control=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --) shift; break ;;
+    -O) control="$2"; shift 2 ;;
+    -o|-i|-p) shift 2 ;;
+    *) shift ;;
+  esac
+done
+host="$1"; shift
+if [ "$host" = "unreachable" ]; then
+  echo "ssh: connect to host unreachable port 22: Connection refused" >&2
+  exit 255
+fi
+[ -n "$control" ] && exit 0
+exec /bin/sh -c "$*"
+`
+
+func newTestSSHConnection(t *testing.T, host string) *SSHConnection {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh stand-in requires /bin/sh")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "ssh")
+	if err := os.WriteFile(bin, []byte(fakeSSHScript), 0755); err != nil {
+		t.Fatalf("writing fake ssh: %v", err)
+	}
+	c := NewSSHConnection("testbox", SSHConfig{
+		Host:       host,
+		Binary:     bin,
+		ControlDir: filepath.Join(dir, "control"),
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestSSHConnection_Identity(t *testing.T) {
+	c := NewSSHConnection("vm", SSHConfig{Host: "me@vm"})
+	if c.Name() != "vm" {
+		t.Errorf("Name() = %q, want %q", c.Name(), "vm")
+	}
+	if c.IsLocal() {
+		t.Error("IsLocal() = true, want false")
+	}
+	if !c.Tmux().IsRemote() {
+		t.Error("Tmux().IsRemote() = false, want true")
+	}
+}
+
+func TestSSHConnection_Args(t *testing.T) {
+	c := NewSSHConnection("vm", SSHConfig{
+		Host:       "me@vm",
+		Port:       2222,
+		KeyPath:    "/keys/id",
+		ControlDir: "/tmp/ctl",
+		Options:    []string{"StrictHostKeyChecking=no"},
+	})
+	args := strings.Join(c.sshArgs(), " ")
+	for _, want := range []string{
+		"ControlMaster=auto",
+		"ControlPath=/tmp/ctl/",
+		"-p 2222",
+		"-i /keys/id",
+		"-o StrictHostKeyChecking=no",
+		"BatchMode=yes",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("ssh args %q missing %q", args, want)
+		}
+	}
+}
+
+func TestSSHConnection_FileOps(t *testing.T) {
+	c := newTestSSHConnection(t, "box")
+	root := t.TempDir()
+	dir := filepath.Join(root, "nested dir", "it's")
+
+	if err := c.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	path := filepath.Join(dir, "file $1.txt")
+	data := []byte("hello\nworld\x00binary")
+	if err := c.WriteFile(path, data, 0640); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := c.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("ReadFile = %q, want %q", got, data)
+	}
+
+	fi, err := c.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Name() != "file $1.txt" || fi.Size() != int64(len(data)) || fi.IsDir() {
+		t.Errorf("Stat = %+v, unexpected", fi)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("Stat mode = %o, want 640", fi.Mode().Perm())
+	}
+	if time.Since(fi.ModTime()) > time.Hour {
+		t.Errorf("Stat modtime = %v, too old", fi.ModTime())
+	}
+
+	dfi, err := c.Stat(dir)
+	if err != nil {
+		t.Fatalf("Stat dir: %v", err)
+	}
+	if !dfi.IsDir() || !dfi.Mode().IsDir() {
+		t.Errorf("Stat dir IsDir = false, want true")
+	}
+
+	ok, err := c.Exists(path)
+	if err != nil || !ok {
+		t.Errorf("Exists = %v, %v; want true, nil", ok, err)
+	}
+
+	if err := c.WriteFile(filepath.Join(dir, "other.txt"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile empty: %v", err)
+	}
+	matches, err := c.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	want := []string{filepath.Join(dir, "file $1.txt"), filepath.Join(dir, "other.txt")}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob = %v, want %v", matches, want)
+	}
+	none, err := c.Glob(filepath.Join(dir, "*.md"))
+	if err != nil || len(none) != 0 {
+		t.Errorf("Glob no match = %v, %v; want empty", none, err)
+	}
+
+	if err := c.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := c.Remove(path); err != nil {
+		t.Errorf("Remove missing file = %v, want nil", err)
+	}
+	ok, err = c.Exists(path)
+	if err != nil || ok {
+		t.Errorf("Exists after Remove = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := c.RemoveAll(filepath.Join(root, "nested dir")); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "nested dir")); !os.IsNotExist(err) {
+		t.Errorf("directory still present after RemoveAll: %v", err)
+	}
+}
+
+func TestSSHConnection_NotFound(t *testing.T) {
+	c := newTestSSHConnection(t, "box")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	var nf *NotFoundError
+	if _, err := c.ReadFile(missing); !errors.As(err, &nf) {
+		t.Errorf("ReadFile missing = %v, want NotFoundError", err)
+	}
+	if _, err := c.Stat(missing); !errors.As(err, &nf) {
+		t.Errorf("Stat missing = %v, want NotFoundError", err)
+	}
+	if nf != nil && nf.Path != missing {
+		t.Errorf("NotFoundError.Path = %q, want %q", nf.Path, missing)
+	}
+}
+
+func TestSSHConnection_PermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks are bypassed for root")
+	}
+	c := newTestSSHConnection(t, "box")
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked")
+	if err := os.WriteFile(locked, []byte("x"), 0000); err != nil {
+		t.Fatal(err)
+	}
+
+	var pe *PermissionError
+	if _, err := c.ReadFile(locked); !errors.As(err, &pe) {
+		t.Errorf("ReadFile unreadable = %v, want PermissionError", err)
+	}
+}
+
+func TestSSHConnection_Exec(t *testing.T) {
+	c := newTestSSHConnection(t, "box")
+
+	out, err := c.Exec("printf", "%s|", "a b", "it's", "$HOME")
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if string(out) != "a b|it's|$HOME|" {
+		t.Errorf("Exec output = %q, want arguments passed verbatim", out)
+	}
+
+	dir := t.TempDir()
+	out, err = c.ExecDir(dir, "pwd")
+	if err != nil {
+		t.Fatalf("ExecDir: %v", err)
+	}
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(out))); got != mustEvalSymlinks(t, dir) {
+		t.Errorf("ExecDir pwd = %q, want %q", got, dir)
+	}
+
+	out, err = c.ExecEnv(map[string]string{"GT_TEST_VAR": "x y"}, "sh", "-c", `printf %s "$GT_TEST_VAR"`)
+	if err != nil {
+		t.Fatalf("ExecEnv: %v", err)
+	}
+	if string(out) != "x y" {
+		t.Errorf("ExecEnv output = %q, want %q", out, "x y")
+	}
+
+	_, err = c.Exec("sh", "-c", "exit 3")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Exec failing command = %v, want exit status 3", err)
+	}
+}
+
+func TestSSHConnection_ConnectionError(t *testing.T) {
+	c := newTestSSHConnection(t, "unreachable")
+
+	var ce *ConnectionError
+	if err := c.Connect(); !errors.As(err, &ce) {
+		t.Fatalf("Connect = %v, want ConnectionError", err)
+	}
+	if ce.Machine != "testbox" || !strings.Contains(ce.Error(), "Connection refused") {
+		t.Errorf("ConnectionError = %v, want machine testbox and ssh message", ce)
+	}
+	if _, err := c.ReadFile("/etc/hostname"); !errors.As(err, &ce) {
+		t.Errorf("ReadFile = %v, want ConnectionError", err)
+	}
+	if _, err := c.Exec("true"); !errors.As(err, &ce) {
+		t.Errorf("Exec = %v, want ConnectionError", err)
+	}
+	if _, err := c.TmuxListSessions(); !errors.As(err, &ce) {
+		t.Errorf("TmuxListSessions = %v, want ConnectionError", err)
+	}
+}
+
+func TestSSHConnection_Closed(t *testing.T) {
+	c := newTestSSHConnection(t, "box")
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	var ce *ConnectionError
+	if _, err := c.Exec("true"); !errors.As(err, &ce) {
+		t.Errorf("Exec after Close = %v, want ConnectionError", err)
+	}
+}
+
+func TestSSHConnection_Tmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	c := newTestSSHConnection(t, "box")
+	name := "gt-ssh-test-" + strings.ReplaceAll(t.Name(), "/", "-")
+	_ = c.TmuxKillSession(name)
+
+	if err := c.TmuxNewSession(name, t.TempDir()); err != nil {
+		t.Skipf("cannot start tmux session: %v", err)
+	}
+	defer func() { _ = c.Tmux().KillSession(name) }()
+
+	ok, err := c.TmuxHasSession(name)
+	if err != nil || !ok {
+		t.Fatalf("TmuxHasSession = %v, %v; want true", ok, err)
+	}
+	sessions, err := c.TmuxListSessions()
+	if err != nil {
+		t.Fatalf("TmuxListSessions: %v", err)
+	}
+	found := false
+	for _, s := range sessions {
+		found = found || s == name
+	}
+	if !found {
+		t.Errorf("TmuxListSessions = %v, missing %q", sessions, name)
+	}
+
+	if err := c.TmuxSendKeys(name, "echo gt-ssh-marker"); err != nil {
+		t.Fatalf("TmuxSendKeys: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		out, err := c.TmuxCapturePane(name, 50)
+		if err == nil && strings.Count(out, "gt-ssh-marker") >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("TmuxCapturePane never showed command output: %q (%v)", out, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := c.TmuxKillSession(name); err != nil {
+		t.Fatalf("TmuxKillSession: %v", err)
+	}
+	if ok, _ := c.TmuxHasSession(name); ok {
+		t.Error("session still exists after TmuxKillSession")
+	}
+}
+
+func TestRegistryConnection_SSH(t *testing.T) {
+	r, err := NewMachineRegistry(filepath.Join(t.TempDir(), "machines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(&Machine{Name: "vm", Type: "ssh", Host: "vm", Port: 2222, User: "me", KeyPath: "/k"}); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := r.Connection("vm")
+	if err != nil {
+		t.Fatalf("Connection: %v", err)
+	}
+	sc, ok := conn.(*SSHConnection)
+	if !ok {
+		t.Fatalf("Connection type = %T, want *SSHConnection", conn)
+	}
+	if sc.Host() != "vm" || sc.Name() != "vm" || sc.IsLocal() {
+		t.Errorf("SSHConnection = %+v, unexpected", sc)
+	}
+	if args := strings.Join(sc.sshArgs(), " "); !strings.Contains(args, "-p 2222") || !strings.Contains(args, "-l me") || !strings.Contains(args, "-i /k") {
+		t.Errorf("sshArgs = %s, want the machine's port, user and key", args)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":           "''",
+		"plain":      "plain",
+		"/a/b.c":     "/a/b.c",
+		"a b":        "'a b'",
+		"it's":       `'it'\''s'`,
+		"$HOME":      "'$HOME'",
+		"=gt-deacon": "=gt-deacon",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, p string) string {
+	t.Helper()
+	r, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestTmuxFor(t *testing.T) {
+	if TmuxFor(NewLocalConnection()).IsRemote() {
+		t.Error("TmuxFor(local).IsRemote() = true, want false")
+	}
+	if !TmuxFor(NewSSHConnection("vm", SSHConfig{Host: "vm"})).IsRemote() {
+		t.Error("TmuxFor(ssh).IsRemote() = false, want true")
+	}
+}
+
+func TestRegistryRigPath(t *testing.T) {
+	r, err := NewMachineRegistry(filepath.Join(t.TempDir(), "machines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(&Machine{Name: "vm", Type: "ssh", Host: "vm", TownPath: "/srv/gt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(&Machine{Name: "bare", Type: "ssh", Host: "bare"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := r.RigPath("vm", "/home/me/gt", "gastown"); err != nil || got != "/srv/gt/gastown" {
+		t.Errorf("RigPath(vm) = %q, %v; want /srv/gt/gastown", got, err)
+	}
+	if got, err := r.RigPath("local", "/home/me/gt", "gastown"); err != nil || got != filepath.Join("/home/me/gt", "gastown") {
+		t.Errorf("RigPath(local) = %q, %v; want local town path", got, err)
+	}
+	if _, err := r.RigPath("bare", "/home/me/gt", "gastown"); err == nil {
+		t.Error("RigPath without town_path succeeded, want error")
+	}
+}
@@ -0,0 +1,79 @@
+package convoy
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// Convoy is a convoy bead and the issues it tracks.
+type Convoy struct {
+	ID      string
+	Title   string
+	Status  string
+	Tracked []TrackedIssue
+}
+
+// TrackedIssue is an issue a convoy tracks through a "tracks" dependency.
+type TrackedIssue struct {
+	ID     string
+	Title  string
+	Status string
+}
+
+// Completed returns how many of the convoy's tracked issues are closed.
+func (c *Convoy) Completed() int {
+	n := 0
+	for _, t := range c.Tracked {
+		if t.Status == "closed" {
+			n++
+		}
+	}
+	return n
+}
+
+// List returns the convoys in the town beads with the given status ("open",
+// "closed" or "all"; empty means bd's default) and the issues each tracks.
+func List(townRoot, status string) ([]*Convoy, error) {
+	b := beads.New(townRoot)
+	issues, err := b.List(beads.ListOptions{Status: status, IssueType: "convoy", Priority: -1})
+	if err != nil {
+		return nil, fmt.Errorf("listing convoys: %w", err)
+	}
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	// List output omits dependencies; show all convoys in one call for
+	// their tracked issues.
+	ids := make([]string, len(issues))
+	for i, issue := range issues {
+		ids[i] = issue.ID
+	}
+	details, err := b.ShowMultiple(ids)
+	if err != nil {
+		return nil, fmt.Errorf("showing convoys: %w", err)
+	}
+
+	convoys := make([]*Convoy, 0, len(issues))
+	for _, issue := range issues {
+		detail, ok := details[issue.ID]
+		if !ok {
+			detail = issue
+		}
+		convoys = append(convoys, fromIssue(issue, detail.Dependencies))
+	}
+	return convoys, nil
+}
+
+// fromIssue builds a Convoy from its bead and the bead's dependencies.
+func fromIssue(issue *beads.Issue, deps []beads.IssueDep) *Convoy {
+	c := &Convoy{ID: issue.ID, Title: issue.Title, Status: issue.Status, Tracked: []TrackedIssue{}}
+	for _, dep := range deps {
+		if dep.DependencyType != "tracks" {
+			continue
+		}
+		c.Tracked = append(c.Tracked, TrackedIssue{ID: dep.ID, Title: dep.Title, Status: dep.Status})
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package convoy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	townRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"mayor/town.json":    "{}",
+		".beads/config.yaml": "issue-prefix: hq\n",
+		".beads/issues.jsonl": `{"id":"hq-cv-1","title":"Ship it","status":"open","issue_type":"convoy","priority":2,"dependencies":[{"issue_id":"hq-cv-1","depends_on_id":"hq-a","type":"tracks"},{"issue_id":"hq-cv-1","depends_on_id":"hq-b","type":"tracks"},{"issue_id":"hq-cv-1","depends_on_id":"hq-c","type":"blocks"}]}
+{"id":"hq-a","title":"Part A","status":"closed","issue_type":"task","priority":2}
+{"id":"hq-b","title":"Part B","status":"open","issue_type":"task","priority":2}
+{"id":"hq-c","title":"Not tracked","status":"open","issue_type":"task","priority":2}
+`,
+	} {
+		full := filepath.Join(townRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GT_BEADS_BACKEND", "native")
+
+	convoys, err := List(townRoot, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(convoys) != 1 {
+		t.Fatalf("List = %d convoys, want 1", len(convoys))
+	}
+	c := convoys[0]
+	if c.ID != "hq-cv-1" || c.Title != "Ship it" || len(c.Tracked) != 2 || c.Completed() != 1 {
+		t.Errorf("convoy = %+v, completed %d", c, c.Completed())
+	}
+	if c.Tracked[0].Title != "Part A" || c.Tracked[1].Status != "open" {
+		t.Errorf("tracked = %+v", c.Tracked)
+	}
+}
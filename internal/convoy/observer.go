@@ -1,4 +1,5 @@
-// Package convoy provides shared convoy operations for redundant observers.
+// Package convoy provides shared convoy operations: listing convoys with
+// the issues they track, and completion checks for redundant observers.
 package convoy
 
 import (
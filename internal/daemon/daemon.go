@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -18,7 +19,9 @@ import (
 	"github.com/gofrs/flock"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/boot"
+	"github.com/steveyegge/gastown/internal/budget"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/deacon"
 	"github.com/steveyegge/gastown/internal/events"
@@ -48,6 +51,7 @@ type Daemon struct {
 	convoyWatcher *ConvoyWatcher
 	doltServer    *DoltServerManager
 	krcPruner     *KRCPruner
+	metricsServer *http.Server
 
 	// Mass death detection: track recent session deaths
 	deathsMu     sync.Mutex
@@ -213,6 +217,15 @@ func (d *Daemon) Run() error {
 		}
 	}
 
+	// Serve Prometheus metrics if enabled in mayor/daemon.json
+	if addr := MetricsListenAddr(d.patrolConfig); addr != "" {
+		if err := d.startMetricsServer(addr); err != nil {
+			d.logger.Printf("Warning: failed to start metrics server: %v", err)
+		} else {
+			d.logger.Printf("Metrics server listening on %s", addr)
+		}
+	}
+
 	// Note: PATCH-010 uses per-session hooks in deacon/manager.go (SetAutoRespawnHook).
 	// Global pane-died hooks don't fire reliably in tmux 3.2a, so we rely on the
 	// per-session approach which has been tested to work for continuous recovery.
@@ -349,6 +362,9 @@ func (d *Daemon) heartbeat(state *State) {
 	// If they have local .beads with databases, bd uses the wrong database.
 	d.cleanupTownServiceBeads()
 
+	// 14. Enforce cost budgets (warn, block sling spawns, pause polecats)
+	d.enforceBudgets()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
@@ -575,6 +591,8 @@ func (d *Daemon) restartStuckDeacon(sessionName string) {
 	}
 	// Spawn new Deacon immediately
 	d.ensureDeaconRunning()
+	_ = events.LogFeed(events.TypeSessionRestart, "daemon",
+		events.SessionRestartPayload(sessionName, "deacon", "stuck"))
 }
 
 // ensureWitnessesRunning ensures witnesses are running for configured rigs.
@@ -599,9 +617,10 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 	// Manager.Start() handles: zombie detection, session creation, env vars, theming,
 	// startup readiness waits, and crucially - startup/propulsion nudges (GUPP).
 	// It returns ErrAlreadyRunning if Claude is already running in tmux.
-	r := &rig.Rig{
-		Name: rigName,
-		Path: filepath.Join(d.config.TownRoot, rigName),
+	r, err := rig.Locate(d.config.TownRoot, rigName)
+	if err != nil {
+		d.logger.Printf("Error locating rig %s: %v", rigName, err)
+		return
 	}
 	mgr := witness.NewManager(r)
 
@@ -616,6 +635,8 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 	}
 
 	d.logger.Printf("Witness session for %s started successfully", rigName)
+	_ = events.LogFeed(events.TypeSessionRestart, "daemon",
+		events.SessionRestartPayload(session.WitnessSessionName(rigName), "witness", "not running"))
 }
 
 // ensureRefineriesRunning ensures refineries are running for configured rigs.
@@ -640,9 +661,10 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 	// Manager.Start() handles: zombie detection, session creation, env vars, theming,
 	// WaitForClaudeReady, and crucially - startup/propulsion nudges (GUPP).
 	// It returns ErrAlreadyRunning if Claude is already running in tmux.
-	r := &rig.Rig{
-		Name: rigName,
-		Path: filepath.Join(d.config.TownRoot, rigName),
+	r, err := rig.Locate(d.config.TownRoot, rigName)
+	if err != nil {
+		d.logger.Printf("Error locating rig %s: %v", rigName, err)
+		return
 	}
 	mgr := refinery.NewManager(r)
 
@@ -657,6 +679,8 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 	}
 
 	d.logger.Printf("Refinery session for %s started successfully", rigName)
+	_ = events.LogFeed(events.TypeSessionRestart, "daemon",
+		events.SessionRestartPayload(session.RefinerySessionName(rigName), "refinery", "not running"))
 }
 
 // killDeaconSessions kills leftover deacon and boot tmux sessions.
@@ -679,10 +703,11 @@ func (d *Daemon) killDeaconSessions() {
 func (d *Daemon) killWitnessSessions() {
 	for _, rigName := range d.getKnownRigs() {
 		name := session.WitnessSessionName(rigName)
-		exists, _ := d.tmux.HasSession(name)
+		t := d.tmuxForRig(rigName)
+		exists, _ := t.HasSession(name)
 		if exists {
 			d.logger.Printf("Killing leftover %s session (patrol disabled)", name)
-			if err := d.tmux.KillSessionWithProcesses(name); err != nil {
+			if err := t.KillSessionWithProcesses(name); err != nil {
 				d.logger.Printf("Error killing %s session: %v", name, err)
 			}
 		}
@@ -694,16 +719,35 @@ func (d *Daemon) killWitnessSessions() {
 func (d *Daemon) killRefinerySessions() {
 	for _, rigName := range d.getKnownRigs() {
 		name := session.RefinerySessionName(rigName)
-		exists, _ := d.tmux.HasSession(name)
+		t := d.tmuxForRig(rigName)
+		exists, _ := t.HasSession(name)
 		if exists {
 			d.logger.Printf("Killing leftover %s session (patrol disabled)", name)
-			if err := d.tmux.KillSessionWithProcesses(name); err != nil {
+			if err := t.KillSessionWithProcesses(name); err != nil {
 				d.logger.Printf("Error killing %s session: %v", name, err)
 			}
 		}
 	}
 }
 
+// tmuxForRig returns the tmux wrapper for the machine hosting a rig.
+// Local rigs (and rigs that can't be resolved) use the daemon's own tmux.
+func (d *Daemon) tmuxForRig(rigName string) *tmux.Tmux {
+	r, err := rig.Locate(d.config.TownRoot, rigName)
+	if err != nil {
+		return d.tmux
+	}
+	return d.rigTmux(r)
+}
+
+// rigTmux returns the tmux wrapper for an already-located rig.
+func (d *Daemon) rigTmux(r *rig.Rig) *tmux.Tmux {
+	if !r.IsRemote() {
+		return d.tmux
+	}
+	return connection.TmuxFor(r.Connection())
+}
+
 // getKnownRigs returns list of registered rig names.
 func (d *Daemon) getKnownRigs() []string {
 	rigsPath := filepath.Join(d.config.TownRoot, "mayor", "rigs.json")
@@ -1035,6 +1079,12 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("KRC pruner stopped")
 	}
 
+	// Stop metrics server
+	if d.metricsServer != nil {
+		_ = d.metricsServer.Close()
+		d.logger.Println("Metrics server stopped")
+	}
+
 	// Stop Dolt server if we're managing it
 	if d.doltServer != nil && d.doltServer.IsEnabled() && !d.doltServer.IsExternal() {
 		if err := d.doltServer.Stop(); err != nil {
@@ -1283,18 +1333,44 @@ func (d *Daemon) checkPolecatSessionHealth() {
 
 // checkRigPolecatHealth checks polecat session health for a specific rig.
 func (d *Daemon) checkRigPolecatHealth(rigName string) {
+	r, err := rig.Locate(d.config.TownRoot, rigName)
+	if err != nil {
+		d.logger.Printf("Error locating rig %s: %v", rigName, err)
+		return
+	}
+
 	// Get polecat directories for this rig
-	polecatsDir := filepath.Join(d.config.TownRoot, rigName, "polecats")
-	polecats, err := listPolecatWorktrees(polecatsDir)
+	var polecats []string
+	if r.IsRemote() {
+		polecats, err = listRemotePolecatWorktrees(r)
+	} else {
+		polecats, err = listPolecatWorktrees(filepath.Join(r.Path, "polecats"))
+	}
 	if err != nil {
 		return // No polecats directory - rig might not have polecats
 	}
 
 	for _, polecatName := range polecats {
-		d.checkPolecatHealth(rigName, polecatName)
+		d.checkPolecatHealth(r, polecatName)
 	}
 }
 
+// listRemotePolecatWorktrees is listPolecatWorktrees over a remote rig's connection.
+func listRemotePolecatWorktrees(r *rig.Rig) ([]string, error) {
+	conn := r.Connection()
+	matches, err := conn.Glob(filepath.Join(r.Path, "polecats", "*"))
+	if err != nil {
+		return nil, err
+	}
+	polecats := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if info, err := conn.Stat(match); err == nil && info.IsDir() {
+			polecats = append(polecats, filepath.Base(match))
+		}
+	}
+	return polecats, nil
+}
+
 func listPolecatWorktrees(polecatsDir string) ([]string, error) {
 	entries, err := os.ReadDir(polecatsDir)
 	if err != nil {
@@ -1318,12 +1394,14 @@ func listPolecatWorktrees(polecatsDir string) ([]string, error) {
 
 // checkPolecatHealth checks a single polecat's session health.
 // If the polecat has work-on-hook but the tmux session is dead, it's restarted.
-func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
+func (d *Daemon) checkPolecatHealth(r *rig.Rig, polecatName string) {
+	rigName := r.Name
+
 	// Build the expected tmux session name
 	sessionName := fmt.Sprintf("gt-%s-%s", rigName, polecatName)
 
 	// Check if tmux session exists
-	sessionAlive, err := d.tmux.HasSession(sessionName)
+	sessionAlive, err := d.rigTmux(r).HasSession(sessionName)
 	if err != nil {
 		d.logger.Printf("Error checking session %s: %v", sessionName, err)
 		return
@@ -1358,14 +1436,18 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 
 	// Track this death for mass death detection
 	d.recordSessionDeath(sessionName)
+	_ = events.LogFeed(events.TypeSessionDeath, sessionName,
+		events.SessionDeathPayload(sessionName, rigName+"/polecats/"+polecatName, "crashed with work on hook", "daemon"))
 
 	// Auto-restart the polecat
-	if err := d.restartPolecatSession(rigName, polecatName, sessionName); err != nil {
+	if err := d.restartPolecatSession(r, polecatName, sessionName); err != nil {
 		d.logger.Printf("Error restarting polecat %s/%s: %v", rigName, polecatName, err)
 		// Notify witness as fallback
 		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
 	} else {
 		d.logger.Printf("Successfully restarted crashed polecat %s/%s", rigName, polecatName)
+		_ = events.LogFeed(events.TypeSessionRestart, "daemon",
+			events.SessionRestartPayload(sessionName, "polecat", "crashed"))
 	}
 }
 
@@ -1420,35 +1502,43 @@ func (d *Daemon) emitMassDeathEvent() {
 }
 
 // restartPolecatSession restarts a crashed polecat session.
-func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string) error {
+func (d *Daemon) restartPolecatSession(r *rig.Rig, polecatName, sessionName string) error {
+	rigName := r.Name
+
 	// Check rig operational state before auto-restarting
 	if operational, reason := d.isRigOperational(rigName); !operational {
 		return fmt.Errorf("cannot restart polecat: %s", reason)
 	}
 
 	// Calculate rig path for agent config resolution
-	rigPath := filepath.Join(d.config.TownRoot, rigName)
+	rigPath := r.Path
+	conn := r.Connection()
+	t := d.rigTmux(r)
 
 	// Determine working directory (handle both new and old structures)
 	// New structure: polecats/<name>/<rigname>/
 	// Old structure: polecats/<name>/
 	workDir := filepath.Join(rigPath, "polecats", polecatName, rigName)
-	if _, err := os.Stat(workDir); os.IsNotExist(err) {
+	if exists, _ := conn.Exists(workDir); !exists {
 		// Fall back to old structure
 		workDir = filepath.Join(rigPath, "polecats", polecatName)
 	}
 
 	// Verify the worktree exists
-	if _, err := os.Stat(workDir); os.IsNotExist(err) {
+	if exists, _ := conn.Exists(workDir); !exists {
 		return fmt.Errorf("polecat worktree does not exist: %s", workDir)
 	}
 
 	// Pre-sync workspace (ensure beads are current)
-	d.syncWorkspace(workDir)
+	if r.IsRemote() {
+		d.syncRemoteWorkspace(r, workDir)
+	} else {
+		d.syncWorkspace(workDir)
+	}
 
 	// Create new tmux session
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
-	if err := d.tmux.EnsureSessionFresh(sessionName, workDir); err != nil {
+	if err := t.EnsureSessionFresh(sessionName, workDir); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
 
@@ -1463,30 +1553,30 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 
 	// Set all env vars in tmux session (for debugging) and they'll also be exported to Claude
 	for k, v := range envVars {
-		_ = d.tmux.SetEnvironment(sessionName, k, v)
+		_ = t.SetEnvironment(sessionName, k, v)
 	}
 
 	// Apply theme
 	theme := tmux.AssignTheme(rigName)
-	_ = d.tmux.ConfigureGasTownSession(sessionName, theme, rigName, polecatName, "polecat")
+	_ = t.ConfigureGasTownSession(sessionName, theme, rigName, polecatName, "polecat")
 
 	// Set pane-died hook for future crash detection
 	agentID := fmt.Sprintf("%s/%s", rigName, polecatName)
-	_ = d.tmux.SetPaneDiedHook(sessionName, agentID)
+	_ = t.SetPaneDiedHook(sessionName, agentID)
 
 	// Launch Claude with environment exported inline
 	// Pass rigPath so rig agent settings are honored (not town-level defaults)
 	startCmd := config.BuildStartupCommand(envVars, rigPath, "")
-	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
+	if err := t.SendKeys(sessionName, startCmd); err != nil {
 		return fmt.Errorf("sending startup command: %w", err)
 	}
 
 	// Wait for Claude to start, then accept bypass permissions warning if it appears.
 	// This ensures automated restarts aren't blocked by the warning dialog.
-	if err := d.tmux.WaitForCommand(sessionName, constants.SupportedShells, constants.ClaudeStartTimeout); err != nil {
+	if err := t.WaitForCommand(sessionName, constants.SupportedShells, constants.ClaudeStartTimeout); err != nil {
 		// Non-fatal - Claude might still start
 	}
-	_ = d.tmux.AcceptBypassPermissionsWarning(sessionName)
+	_ = t.AcceptBypassPermissionsWarning(sessionName)
 
 	return nil
 }
@@ -1511,6 +1601,48 @@ Manual intervention may be required.`,
 	}
 }
 
+// enforceBudgets checks spend against the configured cost budgets.
+// The check itself lives in 'gt costs budget --enforce', which records the
+// exceeded caps that gt sling consults and escalates threshold crossings.
+func (d *Daemon) enforceBudgets() {
+	if !d.budgetsConfigured() {
+		return
+	}
+
+	cmd := exec.Command(d.gtPath, "costs", "budget", "--enforce") //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	cmd.Env = os.Environ()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		d.logger.Printf("Warning: budget enforcement failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// budgetsConfigured reports whether any budget is set in town or rig
+// settings, or a previous check left state behind that may need clearing.
+func (d *Daemon) budgetsConfigured() bool {
+	if _, err := os.Stat(budget.StatePath(d.config.TownRoot)); err == nil {
+		return true
+	}
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot)); err == nil && settings.Budgets != nil {
+		return true
+	}
+	for _, rigName := range d.getKnownRigs() {
+		r, err := rig.Locate(d.config.TownRoot, rigName)
+		if err != nil {
+			continue
+		}
+		// Read through the rig's connection so remote rigs' budgets count.
+		data, err := r.Connection().ReadFile(config.RigSettingsPath(r.Path))
+		if err != nil {
+			continue
+		}
+		if rs, err := config.ParseRigSettings(data); err == nil && rs.Budget != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanupOrphanedProcesses kills orphaned claude subagent processes.
 // These are Task tool subagents that didn't clean up after completion.
 // Detection uses TTY column: processes with TTY "?" have no controlling terminal.
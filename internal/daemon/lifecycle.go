@@ -608,6 +608,28 @@ func (d *Daemon) syncWorkspace(workDir string) {
 	// Note: With Dolt backend, beads changes are persisted immediately - no sync needed
 }
 
+// syncRemoteWorkspace is syncWorkspace for a workspace on a remote rig's machine.
+// The git commands run over the rig's connection.
+func (d *Daemon) syncRemoteWorkspace(r *rig.Rig, workDir string) {
+	conn := r.Connection()
+
+	defaultBranch := "main" // fallback
+	if data, err := conn.ReadFile(filepath.Join(r.Path, "config.json")); err == nil {
+		var rigCfg rig.RigConfig
+		if json.Unmarshal(data, &rigCfg) == nil && rigCfg.DefaultBranch != "" {
+			defaultBranch = rigCfg.DefaultBranch
+		}
+	}
+
+	if out, err := conn.ExecDir(workDir, "git", "fetch", "origin"); err != nil {
+		d.logger.Printf("Error: git fetch failed in %s on %s: %s", workDir, r.Machine, strings.TrimSpace(string(out)))
+		return // Fail fast - don't start agent with stale code
+	}
+	if out, err := conn.ExecDir(workDir, "git", "pull", "--rebase", "origin", defaultBranch); err != nil {
+		d.logger.Printf("Warning: git pull failed in %s on %s: %s (agent may have conflicts)", workDir, r.Machine, strings.TrimSpace(string(out)))
+	}
+}
+
 // closeMessage removes a lifecycle mail message after processing.
 // We use delete instead of read because gt mail read intentionally
 // doesn't mark messages as read (to preserve handoff messages).
@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/metrics"
+)
+
+// startMetricsServer serves the town's Prometheus metrics at /metrics on
+// addr until shutdown. Listening happens synchronously so a bad or busy
+// address is reported at startup.
+func (d *Daemon) startMetricsServer(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.NewCollector(d.config.TownRoot))
+	d.metricsServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+
+	go func() {
+		if err := d.metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+	return nil
+}
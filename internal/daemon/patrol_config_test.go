@@ -51,3 +51,24 @@ func TestIsPatrolEnabled_NilConfig(t *testing.T) {
 		t.Error("expected default to be enabled")
 	}
 }
+
+func TestMetricsListenAddr(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *DaemonPatrolConfig
+		want   string
+	}{
+		{"no config", nil, ""},
+		{"no metrics section", &DaemonPatrolConfig{}, ""},
+		{"disabled", &DaemonPatrolConfig{Metrics: &MetricsConfig{Listen: ":9000"}}, ""},
+		{"default address", &DaemonPatrolConfig{Metrics: &MetricsConfig{Enabled: true}}, DefaultMetricsListen},
+		{"custom address", &DaemonPatrolConfig{Metrics: &MetricsConfig{Enabled: true, Listen: ":9000"}}, ":9000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetricsListenAddr(tt.config); got != tt.want {
+				t.Errorf("MetricsListenAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -119,12 +119,25 @@ type PatrolsConfig struct {
 	DoltServer *DoltServerConfig `json:"dolt_server,omitempty"`
 }
 
+// MetricsConfig configures the daemon's Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	// Enabled starts the endpoint. It is off by default.
+	Enabled bool `json:"enabled"`
+
+	// Listen is the address to serve on (default DefaultMetricsListen).
+	Listen string `json:"listen,omitempty"`
+}
+
+// DefaultMetricsListen is the default /metrics address: localhost only.
+const DefaultMetricsListen = "127.0.0.1:9464"
+
 // DaemonPatrolConfig is the structure of mayor/daemon.json.
 type DaemonPatrolConfig struct {
 	Type      string         `json:"type"`
 	Version   int            `json:"version"`
 	Heartbeat *PatrolConfig  `json:"heartbeat,omitempty"`
 	Patrols   *PatrolsConfig `json:"patrols,omitempty"`
+	Metrics   *MetricsConfig `json:"metrics,omitempty"`
 }
 
 // PatrolConfigFile returns the path to the patrol config file.
@@ -191,6 +204,18 @@ func GetPatrolRigs(config *DaemonPatrolConfig, patrol string) []string {
 	return nil // All rigs
 }
 
+// MetricsListenAddr returns the address to serve /metrics on, or "" if the
+// endpoint is disabled.
+func MetricsListenAddr(config *DaemonPatrolConfig) string {
+	if config == nil || config.Metrics == nil || !config.Metrics.Enabled {
+		return ""
+	}
+	if config.Metrics.Listen == "" {
+		return DefaultMetricsListen
+	}
+	return config.Metrics.Listen
+}
+
 // LifecycleAction represents a lifecycle request action.
 type LifecycleAction string
 
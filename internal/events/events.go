@@ -51,8 +51,9 @@ const (
 	TypeSessionEnd   = "session_end"
 
 	// Session death events (for crash investigation)
-	TypeSessionDeath = "session_death" // Feed-visible session termination
-	TypeMassDeath    = "mass_death"    // Multiple sessions died in short window
+	TypeSessionDeath   = "session_death"   // Feed-visible session termination
+	TypeMassDeath      = "mass_death"      // Multiple sessions died in short window
+	TypeSessionRestart = "session_restart" // Daemon restarted a dead or stuck session
 
 	// Witness patrol events
 	TypePatrolStarted   = "patrol_started"
@@ -68,6 +69,9 @@ const (
 	TypeMerged       = "merged"
 	TypeMergeFailed  = "merge_failed"
 	TypeMergeSkipped = "merge_skipped"
+
+	// Dashboard events (mutating requests made through gt dashboard)
+	TypeDashboardRequest = "dashboard_request"
 )
 
 // EventsFile is the name of the raw events log.
@@ -294,6 +298,18 @@ func SessionDeathPayload(session, agent, reason, caller string) map[string]inter
 	}
 }
 
+// SessionRestartPayload creates a payload for session restart events.
+// session: tmux session name that was restarted
+// role: Gas Town role of the session (e.g., "polecat", "witness", "deacon")
+// reason: why it was restarted (e.g., "crashed", "stuck", "not running")
+func SessionRestartPayload(session, role, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"session": session,
+		"role":    role,
+		"reason":  reason,
+	}
+}
+
 // MassDeathPayload creates a payload for mass death events.
 // count: number of sessions that died
 // window: time window in which deaths occurred (e.g., "5s")
@@ -376,6 +376,14 @@ func (c *Curator) generateSummary(event *events.Event) string {
 		}
 		return "Session terminated"
 
+	case events.TypeSessionRestart:
+		session, _ := event.Payload["session"].(string)
+		reason, _ := event.Payload["reason"].(string)
+		if session != "" && reason != "" {
+			return fmt.Sprintf("Session %s restarted: %s", session, reason)
+		}
+		return "Session restarted"
+
 	case events.TypeMassDeath:
 		count, _ := event.Payload["count"].(float64) // JSON numbers are float64
 		possibleCause, _ := event.Payload["possible_cause"].(string)
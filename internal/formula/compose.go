@@ -0,0 +1,430 @@
+package formula
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Include takes steps from another formula.
+//
+//	[[include]]
+//	formula = "mol-patrol-tail"
+//	steps = ["report", "cleanup"]  # optional subset, default all
+//	needs = ["survey"]             # entry steps of the include wait for these
+type Include struct {
+	Formula string   `toml:"formula,omitempty"`
+	Steps   []string `toml:"steps,omitempty"`
+	Needs   []string `toml:"needs,omitempty"`
+}
+
+// StepOverride patches an inherited or included step by ID. Only the fields
+// that are set are changed.
+type StepOverride struct {
+	ID          string       `toml:"id,omitempty"`
+	Title       *string      `toml:"title,omitempty"`
+	Description *string      `toml:"description,omitempty"`
+	Needs       []string     `toml:"needs,omitempty"` // replaces the step's needs
+	Parallel    *bool        `toml:"parallel,omitempty"`
+	When        *string      `toml:"when,omitempty"`
+	Retry       *RetryPolicy `toml:"retry,omitempty"`
+	Timeout     *string      `toml:"timeout,omitempty"`
+	Remove      bool         `toml:"remove,omitempty"` // drop the step; its dependents inherit its needs
+}
+
+// Loader returns the TOML source of a formula by name, for extends and
+// include.
+type Loader func(name string) ([]byte, error)
+
+// DirLoader loads <name>.formula.toml from the first of dirs that has it,
+// falling back to the embedded formulas.
+func DirLoader(dirs ...string) Loader {
+	return func(name string) ([]byte, error) {
+		file := name + ".formula.toml"
+		for _, dir := range dirs {
+			data, err := os.ReadFile(filepath.Join(dir, file)) //nolint:gosec // G304: formula search path
+			if err == nil {
+				return data, nil
+			}
+		}
+		data, err := formulasFS.ReadFile("formulas/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("formula %q not found", name)
+		}
+		return data, nil
+	}
+}
+
+// Load finds formula name in dirs (or the embedded formulas) and parses it.
+func Load(name string, dirs ...string) (*Formula, error) {
+	load := DirLoader(dirs...)
+	data, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWithLoader(data, load)
+}
+
+// IsComposed reports whether the formula needs resolving: it extends or
+// includes other formulas, patches steps, or places steps with after/before.
+func (f *Formula) IsComposed() bool {
+	if len(f.Extends) > 0 || len(f.Include) > 0 || len(f.Overrides) > 0 {
+		return true
+	}
+	for _, step := range f.Steps {
+		if step.After != "" || step.Before != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsComposedSource reports whether formula source (TOML) is composed, for
+// callers that hand formulas to bd, which only reads flattened ones.
+// Source that does not decode is reported as not composed.
+func IsComposedSource(data []byte) bool {
+	var f Formula
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return false
+	}
+	return f.IsComposed()
+}
+
+// TOML encodes the formula. For a parsed formula this is the flattened
+// result of its composition.
+func (f *Formula) TOML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resolve flattens extends, include and overrides into a new formula.
+// Bases are merged in order, then includes, then the formula's own content
+// (steps with a known ID replace the inherited step), then overrides.
+// stack holds the formulas being resolved, to report cycles.
+func resolve(f *Formula, load Loader, stack []string) (*Formula, error) {
+	for _, name := range stack {
+		if name == f.Name {
+			return nil, fmt.Errorf("formula composition cycle: %s -> %s", strings.Join(stack, " -> "), f.Name)
+		}
+	}
+	stack = append(stack, f.Name)
+
+	out := &Formula{}
+	for _, name := range f.Extends {
+		base, err := loadComposed(name, load, stack)
+		if err != nil {
+			return nil, fmt.Errorf("extends %s: %w", name, err)
+		}
+		if err := out.overlay(base); err != nil {
+			return nil, fmt.Errorf("extends %s: %w", name, err)
+		}
+	}
+
+	for _, inc := range f.Include {
+		src, err := loadComposed(inc.Formula, load, stack)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", inc.Formula, err)
+		}
+		if err := out.include(src, inc); err != nil {
+			return nil, fmt.Errorf("include %s: %w", inc.Formula, err)
+		}
+	}
+
+	if err := out.overlay(f); err != nil {
+		return nil, err
+	}
+	for _, o := range f.Overrides {
+		if err := out.applyOverride(o); err != nil {
+			return nil, err
+		}
+	}
+
+	out.Name = f.Name
+	out.Extends, out.Include, out.Overrides = nil, nil, nil
+	out.inferType()
+	return out, nil
+}
+
+// loadComposed loads and resolves a formula without validating it: bases
+// and include sources may be fragments that are only valid once composed.
+func loadComposed(name string, load Loader, stack []string) (*Formula, error) {
+	if load == nil {
+		return nil, fmt.Errorf("no loader for formula %q", name)
+	}
+	data, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	var f Formula
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+	if f.Name == "" {
+		f.Name = name
+	}
+	return resolve(&f, load, stack)
+}
+
+// overlay merges src into f. Scalars and tables set in src win; list
+// entries replace entries with the same ID and are appended otherwise.
+func (f *Formula) overlay(src *Formula) error {
+	if src.Description != "" {
+		f.Description = src.Description
+	}
+	if src.Type != "" {
+		f.Type = src.Type
+	}
+	if src.Version != 0 {
+		f.Version = src.Version
+	}
+	if src.Output != nil {
+		f.Output = src.Output
+	}
+	if src.Synthesis != nil {
+		f.Synthesis = src.Synthesis
+	}
+	if src.Compose != nil {
+		f.Compose = src.Compose
+	}
+	f.Inputs = mergeMap(f.Inputs, src.Inputs)
+	f.Prompts = mergeMap(f.Prompts, src.Prompts)
+	f.Vars = mergeMap(f.Vars, src.Vars)
+
+	seen := make(map[string]bool)
+	for _, step := range src.Steps {
+		if seen[step.ID] {
+			return fmt.Errorf("duplicate step id: %s", step.ID)
+		}
+		seen[step.ID] = true
+		var err error
+		if f.Steps, err = placeStep(f.Steps, step); err != nil {
+			return err
+		}
+	}
+	f.Groups = mergeByID(f.Groups, src.Groups, func(g StepGroup) string { return g.ID })
+	f.Legs = mergeByID(f.Legs, src.Legs, func(l Leg) string { return l.ID })
+	f.Template = mergeByID(f.Template, src.Template, func(t Template) string { return t.ID })
+	f.Aspects = mergeByID(f.Aspects, src.Aspects, func(a Aspect) string { return a.ID })
+	return nil
+}
+
+// include adds the steps selected by inc, and the vars and groups that come
+// with them. Needs on steps left out are replaced by inc.Needs.
+func (f *Formula) include(src *Formula, inc Include) error {
+	selected := make(map[string]bool)
+	for _, step := range src.Steps {
+		selected[step.ID] = len(inc.Steps) == 0
+	}
+	for _, id := range inc.Steps {
+		if _, ok := selected[id]; !ok {
+			return fmt.Errorf("unknown step: %s", id)
+		}
+		selected[id] = true
+	}
+
+	for _, step := range src.Steps {
+		if !selected[step.ID] {
+			continue
+		}
+		if stepIndex(f.Steps, step.ID) >= 0 {
+			return fmt.Errorf("step %q is already defined", step.ID)
+		}
+		var needs []string
+		entry := len(step.Needs) == 0
+		for _, need := range step.Needs {
+			if selected[need] {
+				needs = append(needs, need)
+			} else {
+				entry = true
+			}
+		}
+		if entry {
+			needs = appendUnique(needs, inc.Needs...)
+		}
+		step.Needs = needs
+		f.Steps = append(f.Steps, step)
+	}
+
+	for name, v := range src.Vars {
+		if _, ok := f.Vars[name]; !ok {
+			f.Vars = mergeMap(f.Vars, map[string]Var{name: v})
+		}
+	}
+	for _, g := range src.Groups {
+		whole := true
+		for _, id := range g.Steps {
+			whole = whole && selected[id]
+		}
+		if whole {
+			f.Groups = mergeByID(f.Groups, []StepGroup{g}, func(g StepGroup) string { return g.ID })
+		}
+	}
+	return nil
+}
+
+// placeStep adds step to steps: in place of a step with the same ID, or
+// spliced in where its After/Before say, or at the end.
+func placeStep(steps []Step, step Step) ([]Step, error) {
+	existing := stepIndex(steps, step.ID)
+	if step.After == "" && step.Before == "" {
+		if existing >= 0 {
+			steps[existing] = step
+			return steps, nil
+		}
+		return append(steps, step), nil
+	}
+	if existing >= 0 {
+		steps = append(steps[:existing:existing], steps[existing+1:]...)
+	}
+
+	pos := len(steps)
+	if step.After != "" {
+		i := stepIndex(steps, step.After)
+		if i < 0 {
+			return nil, fmt.Errorf("step %q: after unknown step: %s", step.ID, step.After)
+		}
+		for j := range steps {
+			steps[j].Needs = replaceNeed(steps[j].Needs, step.After, step.ID)
+		}
+		step.Needs = appendUnique(step.Needs, step.After)
+		pos = i + 1
+	}
+	if step.Before != "" {
+		i := stepIndex(steps, step.Before)
+		if i < 0 {
+			return nil, fmt.Errorf("step %q: before unknown step: %s", step.ID, step.Before)
+		}
+		steps[i].Needs = appendUnique(steps[i].Needs, step.ID)
+		if step.After == "" || i < pos {
+			pos = i
+		}
+	}
+	step.After, step.Before = "", ""
+
+	steps = append(steps, Step{})
+	copy(steps[pos+1:], steps[pos:])
+	steps[pos] = step
+	return steps, nil
+}
+
+// applyOverride patches or removes a step.
+func (f *Formula) applyOverride(o StepOverride) error {
+	i := stepIndex(f.Steps, o.ID)
+	if i < 0 {
+		return fmt.Errorf("override: unknown step: %s", o.ID)
+	}
+
+	if o.Remove {
+		removed := f.Steps[i]
+		f.Steps = append(f.Steps[:i:i], f.Steps[i+1:]...)
+		for j := range f.Steps {
+			if contains(f.Steps[j].Needs, removed.ID) {
+				f.Steps[j].Needs = appendUnique(replaceNeed(f.Steps[j].Needs, removed.ID, ""), removed.Needs...)
+			}
+		}
+		for j := range f.Groups {
+			f.Groups[j].Steps = replaceNeed(f.Groups[j].Steps, removed.ID, "")
+		}
+		return nil
+	}
+
+	step := &f.Steps[i]
+	if o.Title != nil {
+		step.Title = *o.Title
+	}
+	if o.Description != nil {
+		step.Description = *o.Description
+	}
+	if o.Needs != nil {
+		step.Needs = o.Needs
+	}
+	if o.Parallel != nil {
+		step.Parallel = *o.Parallel
+	}
+	if o.When != nil {
+		step.When = *o.When
+	}
+	if o.Retry != nil {
+		step.Retry = o.Retry
+	}
+	if o.Timeout != nil {
+		step.Timeout = *o.Timeout
+	}
+	return nil
+}
+
+func stepIndex(steps []Step, id string) int {
+	for i, step := range steps {
+		if step.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceNeed returns needs with old replaced by new (or dropped when new is
+// empty). It never modifies needs in place, as slices are shared with bases.
+func replaceNeed(needs []string, old, new string) []string {
+	if !contains(needs, old) {
+		return needs
+	}
+	var out []string
+	for _, need := range needs {
+		switch {
+		case need != old:
+			out = appendUnique(out, need)
+		case new != "":
+			out = appendUnique(out, new)
+		}
+	}
+	return out
+}
+
+func appendUnique(items []string, add ...string) []string {
+	out := append([]string(nil), items...)
+	for _, item := range add {
+		if !contains(out, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func mergeMap[V any](dst, src map[string]V) map[string]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]V, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func mergeByID[T any](dst, src []T, id func(T) string) []T {
+	for _, item := range src {
+		replaced := false
+		for i := range dst {
+			if id(dst[i]) == id(item) {
+				dst[i] = item
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			dst = append(dst, item)
+		}
+	}
+	return dst
+}
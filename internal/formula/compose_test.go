@@ -0,0 +1,267 @@
+package formula
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// mapLoader serves formulas from memory.
+func mapLoader(formulas map[string]string) Loader {
+	return func(name string) ([]byte, error) {
+		src, ok := formulas[name]
+		if !ok {
+			return nil, fmt.Errorf("formula %q not found", name)
+		}
+		return []byte(src), nil
+	}
+}
+
+const composeBase = `
+formula = "base"
+description = "Base workflow"
+
+[vars.feature]
+required = true
+
+[[steps]]
+id = "design"
+title = "Design {{feature}}"
+
+[[steps]]
+id = "implement"
+title = "Implement"
+needs = ["design"]
+
+[[steps]]
+id = "review"
+title = "Review"
+needs = ["implement"]
+
+[[steps]]
+id = "submit"
+title = "Submit"
+needs = ["review"]
+`
+
+const composeTail = `
+formula = "tail"
+
+[vars.report_to]
+default = "mayor"
+
+[[steps]]
+id = "collect"
+title = "Collect"
+
+[[steps]]
+id = "report"
+title = "Report to {{report_to}}"
+needs = ["collect"]
+
+[[steps]]
+id = "archive"
+title = "Archive"
+needs = ["report"]
+`
+
+func stepNeeds(f *Formula) map[string][]string {
+	needs := make(map[string][]string)
+	for _, step := range f.Steps {
+		needs[step.ID] = step.Needs
+	}
+	return needs
+}
+
+func TestResolveExtendsOverridesAndInserts(t *testing.T) {
+	load := mapLoader(map[string]string{"base": composeBase})
+	f, err := ParseWithLoader([]byte(`
+formula = "variant"
+extends = ["base"]
+
+[[steps]]
+id = "lint"
+title = "Lint"
+after = "implement"
+
+[[steps]]
+id = "threat-model"
+title = "Threat model"
+before = "implement"
+needs = ["design"]
+
+[[steps]]
+id = "review"
+title = "Security review"
+needs = ["lint"]
+
+[[overrides]]
+id = "design"
+description = "Write an ADR first."
+
+[[overrides]]
+id = "submit"
+remove = true
+`), load)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Name != "variant" || f.Description != "Base workflow" || f.Type != TypeWorkflow || f.IsComposed() {
+		t.Errorf("resolved header = %q %q %q composed=%v", f.Name, f.Description, f.Type, f.IsComposed())
+	}
+	if _, ok := f.Vars["feature"]; !ok {
+		t.Error("inherited var missing")
+	}
+	want := map[string][]string{
+		"design":       nil,
+		"threat-model": {"design"},
+		"implement":    {"design", "threat-model"},
+		"lint":         {"implement"},
+		"review":       {"lint"},
+	}
+	if got := stepNeeds(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("needs = %v\nwant    %v", got, want)
+	}
+	if f.GetStep("review").Title != "Security review" || f.GetStep("design").Description != "Write an ADR first." {
+		t.Errorf("step content not overridden: %+v %+v", f.GetStep("review"), f.GetStep("design"))
+	}
+
+	order, err := f.TopologicalSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"design", "threat-model", "implement", "lint", "review"}) {
+		t.Errorf("order = %v", order)
+	}
+}
+
+func TestResolveInclude(t *testing.T) {
+	load := mapLoader(map[string]string{"base": composeBase, "tail": composeTail})
+	f, err := ParseWithLoader([]byte(`
+formula = "with-tail"
+extends = ["base"]
+
+[[include]]
+formula = "tail"
+steps = ["report", "archive"]
+needs = ["submit"]
+`), load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	needs := stepNeeds(f)
+	if !reflect.DeepEqual(needs["report"], []string{"submit"}) || !reflect.DeepEqual(needs["archive"], []string{"report"}) {
+		t.Errorf("included needs = %v", needs)
+	}
+	if _, ok := needs["collect"]; ok {
+		t.Error("step outside the include subset was included")
+	}
+	if f.Vars["report_to"].Default != "mayor" {
+		t.Error("included var missing")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"base": composeBase,
+		"a":    "formula = \"a\"\nextends = [\"b\"]\n",
+		"b":    "formula = \"b\"\nextends = [\"a\"]\n",
+	})
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"missing base", `extends = ["nope"]`, `formula "nope" not found`},
+		{"cycle", `extends = ["a"]`, "composition cycle"},
+		{"override unknown step", "extends = [\"base\"]\n[[overrides]]\nid = \"nope\"\ntitle = \"x\"", "unknown step: nope"},
+		{"after unknown step", "extends = [\"base\"]\n[[steps]]\nid = \"x\"\ntitle = \"X\"\nafter = \"nope\"", "after unknown step"},
+		{"include clash", "extends = [\"base\"]\n[[include]]\nformula = \"base\"", "already defined"},
+		{"cycle after resolve", "extends = [\"base\"]\n[[overrides]]\nid = \"design\"\nneeds = [\"submit\"]", "cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWithLoader([]byte("formula = \"x\"\n"+tt.src+"\n"), load)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveEmbeddedAndRoundTrip(t *testing.T) {
+	f, err := ParseFile("formulas/shiny-secure.formula.toml")
+	if err != nil {
+		t.Fatalf("shiny-secure: %v", err)
+	}
+	if len(f.Steps) != 5 || f.GetStep("implement") == nil || f.Compose["aspects"] == nil {
+		t.Errorf("shiny-secure resolved to %d steps, compose %v", len(f.Steps), f.Compose)
+	}
+
+	data, err := f.TOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "extends") {
+		t.Errorf("flattened TOML still extends:\n%s", data)
+	}
+	again, err := Parse(data)
+	if err != nil {
+		t.Fatalf("flattened TOML does not parse: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(again.Steps, f.Steps) || !reflect.DeepEqual(again.Vars, f.Vars) {
+		t.Errorf("round trip changed the formula:\n%s", data)
+	}
+}
+
+// TestEmbeddedFormulasComposeForBD verifies that embedded formulas only
+// compose in ways bd reads the same, since agents instantiate them with bd
+// directly: extends that adds steps, never replaces or patches them.
+func TestEmbeddedFormulasComposeForBD(t *testing.T) {
+	entries, err := formulasFS.ReadDir("formulas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := DirLoader()
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".formula.toml")
+		data, err := load(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw Formula
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(raw.Include) > 0 || len(raw.Overrides) > 0 {
+			t.Errorf("%s uses include or overrides, which bd does not read", name)
+		}
+		inherited := make(map[string]bool)
+		for _, base := range raw.Extends {
+			f, err := Load(base)
+			if err != nil {
+				t.Fatalf("%s: extends %s: %v", name, base, err)
+			}
+			for _, step := range f.Steps {
+				inherited[step.ID] = true
+			}
+		}
+		for _, step := range raw.Steps {
+			if step.After != "" || step.Before != "" || inherited[step.ID] {
+				t.Errorf("%s: step %s is placed or replaced in a way bd does not read", name, step.ID)
+			}
+		}
+	}
+
+	for _, name := range []string{"shiny-secure", "shiny-enterprise"} {
+		data, _ := load(name)
+		var raw Formula
+		if _, err := toml.Decode(string(data), &raw); err != nil || !reflect.DeepEqual(raw.Extends, []string{"shiny"}) {
+			t.Errorf("%s extends %v, %v; want shiny", name, raw.Extends, err)
+		}
+	}
+}
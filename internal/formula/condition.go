@@ -0,0 +1,437 @@
+package formula
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Condition is a parsed step condition, as used by a step's when field and a
+// group's repeat_until field.
+//
+// The grammar is deliberately small:
+//
+//	expr    := and ("||" and)*
+//	and     := unary ("&&" unary)*
+//	unary   := "!" unary | "(" expr ")" | operand [op operand]
+//	op      := "==" | "!=" | "contains"
+//	operand := "string" | number | true | false | {{var}} | steps.<id>.output | steps.<id>.status
+//
+// A lone operand is true unless it is empty, "false" or "0".
+type Condition struct {
+	root condNode
+}
+
+// StepState is what a condition can see of an earlier step.
+type StepState struct {
+	Status string // open, in_progress, closed, skipped, ...
+	Output string // recorded with gt mol step done --output
+}
+
+// ConditionEnv holds the values a condition is evaluated against. Unknown
+// variables and steps evaluate to the empty string.
+type ConditionEnv struct {
+	Vars  map[string]string
+	Steps map[string]StepState
+}
+
+// ParseCondition parses a condition expression.
+func ParseCondition(src string) (*Condition, error) {
+	p := &condParser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("condition %q: unexpected %q", src, p.tokens[p.pos].text)
+	}
+	return &Condition{root: root}, nil
+}
+
+// Eval evaluates the condition.
+func (c *Condition) Eval(env ConditionEnv) bool {
+	return c.root.eval(env)
+}
+
+// Vars returns the variables the condition refers to, sorted.
+func (c *Condition) Vars() []string {
+	var names []string
+	c.walk(func(o operand) {
+		if o.kind == operandVar {
+			names = append(names, o.name)
+		}
+	})
+	return sortedUnique(names)
+}
+
+// StepRefs returns the step IDs the condition refers to, sorted.
+func (c *Condition) StepRefs() []string {
+	var ids []string
+	c.walk(func(o operand) {
+		if o.kind == operandStep {
+			ids = append(ids, o.name)
+		}
+	})
+	return sortedUnique(ids)
+}
+
+// Bind replaces references to the given variables with their values and
+// returns the resulting expression. Variables not in vars are kept.
+func (c *Condition) Bind(vars map[string]string) string {
+	return c.root.format(vars)
+}
+
+// String returns the expression in canonical form.
+func (c *Condition) String() string {
+	return c.root.format(nil)
+}
+
+func (c *Condition) walk(fn func(operand)) {
+	var visit func(n condNode)
+	visit = func(n condNode) {
+		switch n := n.(type) {
+		case *logicNode:
+			visit(n.left)
+			visit(n.right)
+		case *notNode:
+			visit(n.inner)
+		case *compareNode:
+			fn(n.left)
+			if n.op != "" {
+				fn(n.right)
+			}
+		}
+	}
+	visit(c.root)
+}
+
+func sortedUnique(items []string) []string {
+	sort.Strings(items)
+	var out []string
+	for i, item := range items {
+		if i == 0 || item != items[i-1] {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+type condNode interface {
+	eval(env ConditionEnv) bool
+	format(vars map[string]string) string
+}
+
+// logicNode is a && or || of two conditions.
+type logicNode struct {
+	op          string
+	left, right condNode
+}
+
+func (n *logicNode) eval(env ConditionEnv) bool {
+	if n.op == "&&" {
+		return n.left.eval(env) && n.right.eval(env)
+	}
+	return n.left.eval(env) || n.right.eval(env)
+}
+
+func (n *logicNode) format(vars map[string]string) string {
+	side := func(child condNode) string {
+		if l, ok := child.(*logicNode); ok && l.op != n.op {
+			return "(" + l.format(vars) + ")"
+		}
+		return child.format(vars)
+	}
+	return side(n.left) + " " + n.op + " " + side(n.right)
+}
+
+type notNode struct {
+	inner condNode
+}
+
+func (n *notNode) eval(env ConditionEnv) bool {
+	return !n.inner.eval(env)
+}
+
+func (n *notNode) format(vars map[string]string) string {
+	if c, ok := n.inner.(*compareNode); ok && c.op == "" {
+		return "!" + c.format(vars)
+	}
+	if _, ok := n.inner.(*notNode); ok {
+		return "!" + n.inner.format(vars)
+	}
+	return "!(" + n.inner.format(vars) + ")"
+}
+
+// compareNode compares two operands, or tests one for truth when op is "".
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n *compareNode) eval(env ConditionEnv) bool {
+	left := n.left.resolve(env)
+	switch n.op {
+	case "==":
+		return left == n.right.resolve(env)
+	case "!=":
+		return left != n.right.resolve(env)
+	case "contains":
+		return strings.Contains(left, n.right.resolve(env))
+	}
+	return left != "" && left != "false" && left != "0"
+}
+
+func (n *compareNode) format(vars map[string]string) string {
+	if n.op == "" {
+		return n.left.format(vars)
+	}
+	return n.left.format(vars) + " " + n.op + " " + n.right.format(vars)
+}
+
+type operandKind int
+
+const (
+	operandLiteral operandKind = iota
+	operandVar
+	operandStep
+)
+
+type operand struct {
+	kind  operandKind
+	value string // literal value
+	name  string // variable name or step ID
+	field string // step field: output or status
+}
+
+func (o operand) resolve(env ConditionEnv) string {
+	switch o.kind {
+	case operandVar:
+		return env.Vars[o.name]
+	case operandStep:
+		state := env.Steps[o.name]
+		if o.field == "status" {
+			return state.Status
+		}
+		return state.Output
+	}
+	return o.value
+}
+
+func (o operand) format(vars map[string]string) string {
+	switch o.kind {
+	case operandVar:
+		if v, ok := vars[o.name]; ok {
+			return strconv.Quote(v)
+		}
+		return "{{" + o.name + "}}"
+	case operandStep:
+		return "steps." + o.name + "." + o.field
+	}
+	if o.value == "true" || o.value == "false" {
+		return o.value
+	}
+	if _, err := strconv.ParseFloat(o.value, 64); err == nil {
+		return o.value
+	}
+	return strconv.Quote(o.value)
+}
+
+type condToken struct {
+	text    string
+	literal bool // quoted string; text is the unquoted value
+}
+
+type condParser struct {
+	src    string
+	tokens []condToken
+	pos    int
+}
+
+func (p *condParser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			p.tokens = append(p.tokens, condToken{text: string(c)})
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||") ||
+			strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!="):
+			p.tokens = append(p.tokens, condToken{text: s[i : i+2]})
+			i += 2
+		case c == '!':
+			p.tokens = append(p.tokens, condToken{text: "!"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return fmt.Errorf("condition %q: unterminated string", p.src)
+			}
+			value, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return fmt.Errorf("condition %q: bad string %s", p.src, s[i:end+1])
+			}
+			p.tokens = append(p.tokens, condToken{text: value, literal: true})
+			i = end + 1
+		case strings.HasPrefix(s[i:], "{{"):
+			end := strings.Index(s[i:], "}}")
+			if end == -1 {
+				return fmt.Errorf("condition %q: unterminated {{", p.src)
+			}
+			p.tokens = append(p.tokens, condToken{text: s[i : i+end+2]})
+			i += end + 2
+		case isWordChar(c):
+			end := i
+			for end < len(s) && isWordChar(s[end]) {
+				end++
+			}
+			p.tokens = append(p.tokens, condToken{text: s[i:end]})
+			i = end
+		default:
+			return fmt.Errorf("condition %q: unexpected character %q", p.src, c)
+		}
+	}
+	return nil
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '.'
+}
+
+func (p *condParser) peek() (condToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return condToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// peekOp reports whether the next token is the operator op (not a string
+// literal with the same text).
+func (p *condParser) peekOp(op string) bool {
+	tok, ok := p.peek()
+	return ok && !tok.literal && tok.text == op
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseUnary() (condNode, error) {
+	if p.peekOp("!") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	}
+	if p.peekOp("(") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOp(")") {
+			return nil, fmt.Errorf("condition %q: missing )", p.src)
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "contains"} {
+		if p.peekOp(op) {
+			p.pos++
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &compareNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return &compareNode{left: left}, nil
+}
+
+func (p *condParser) parseOperand() (operand, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return operand{}, fmt.Errorf("condition %q: unexpected end", p.src)
+	}
+	p.pos++
+
+	if tok.literal {
+		return operand{kind: operandLiteral, value: tok.text}, nil
+	}
+	text := tok.text
+	switch {
+	case strings.HasPrefix(text, "{{"):
+		name := strings.TrimSpace(text[2 : len(text)-2])
+		if name == "" {
+			return operand{}, fmt.Errorf("condition %q: empty variable reference", p.src)
+		}
+		return operand{kind: operandVar, name: name}, nil
+	case strings.HasPrefix(text, "steps."):
+		rest := strings.TrimPrefix(text, "steps.")
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			return operand{}, fmt.Errorf("condition %q: %s must be steps.<id>.output or steps.<id>.status", p.src, text)
+		}
+		field := rest[dot+1:]
+		if field != "output" && field != "status" {
+			return operand{}, fmt.Errorf("condition %q: unknown step field %q (want output or status)", p.src, field)
+		}
+		return operand{kind: operandStep, name: rest[:dot], field: field}, nil
+	case text == "true" || text == "false":
+		return operand{kind: operandLiteral, value: text}, nil
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return operand{kind: operandLiteral, value: text}, nil
+	}
+	return operand{}, fmt.Errorf("condition %q: unexpected %q (quote string values)", p.src, text)
+}
@@ -0,0 +1,150 @@
+package formula
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConditionEval(t *testing.T) {
+	env := ConditionEnv{
+		Vars: map[string]string{"mode": "fast", "dry": "false"},
+		Steps: map[string]StepState{
+			"check": {Status: "closed", Output: "2 dirty polecats"},
+			"lint":  {Status: "skipped"},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`{{mode}} == "fast"`, true},
+		{`{{mode}} != "fast"`, false},
+		{`{{dry}}`, false},
+		{`!{{dry}}`, true},
+		{`{{unset}}`, false},
+		{`steps.check.output contains "dirty"`, true},
+		{`steps.check.status == "closed" && steps.lint.status == "skipped"`, true},
+		{`steps.missing.output == ""`, true},
+		{`{{mode}} == "slow" || steps.check.output`, true},
+		{`!({{mode}} == "fast" && {{dry}})`, true},
+		{`{{mode}} == "slow" || {{mode}} == "fast" && {{dry}}`, false},
+		{`"&&" == "&&"`, true},
+		{`1`, true},
+		{`0`, false},
+	}
+	for _, tt := range tests {
+		cond, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := cond.Eval(env); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestConditionParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`{{mode}} ==`,
+		`({{mode}} == "x"`,
+		`"unterminated`,
+		`{{mode`,
+		`steps.check`,
+		`steps.check.title == "x"`,
+		`fast == {{mode}}`,
+		`{{mode}} == "x" "y"`,
+		`{{mode}} > 1`,
+	} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestConditionRefsAndBind(t *testing.T) {
+	cond, err := ParseCondition(`!(steps.b.output == {{mode}}) && (steps.a.status == "closed" || {{dry}})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cond.Vars(); !reflect.DeepEqual(got, []string{"dry", "mode"}) {
+		t.Errorf("Vars = %v", got)
+	}
+	if got := cond.StepRefs(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("StepRefs = %v", got)
+	}
+
+	bound := cond.Bind(map[string]string{"mode": `say "hi"`})
+	want := `!(steps.b.output == "say \"hi\"") && (steps.a.status == "closed" || {{dry}})`
+	if bound != want {
+		t.Errorf("Bind = %s\nwant   %s", bound, want)
+	}
+	rebound, err := ParseCondition(bound)
+	if err != nil {
+		t.Fatalf("bound condition does not parse: %v", err)
+	}
+	if rebound.String() != bound {
+		t.Errorf("round trip = %s", rebound.String())
+	}
+}
+
+func TestValidate_ExecutionPolicy(t *testing.T) {
+	base := `
+formula = "test"
+
+[vars.mode]
+description = "Mode"
+
+[[steps]]
+id = "a"
+title = "A"
+
+[[steps]]
+id = "b"
+title = "B"
+needs = ["a"]
+`
+	valid := base + `
+when = 'steps.a.output == "x" || {{mode}} == "full"'
+timeout = "10m"
+retry = { max_attempts = 3 }
+
+[[groups]]
+id = "loop"
+steps = ["a", "b"]
+repeat_until = 'steps.b.output == "done"'
+`
+	f, err := Parse([]byte(valid))
+	if err != nil {
+		t.Fatalf("valid formula rejected: %v", err)
+	}
+	if !f.HasExecutionPolicy() || f.GetGroup("b").Iterations() != DefaultMaxIterations || f.GetGroup("c") != nil {
+		t.Errorf("unexpected policy accessors: %+v", f.Groups)
+	}
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{"bad condition", `when = '{{mode}} =='`, "step \"b\" when"},
+		{"undeclared var", `when = '{{other}} == "x"'`, "undeclared var"},
+		{"later step", `when = 'steps.b.output == "x"'`, "not one of its (transitive) needs"},
+		{"zero attempts", `retry = { max_attempts = 0 }`, "max_attempts"},
+		{"bad timeout", `timeout = "soon"`, "invalid timeout"},
+		{"unknown group step", "[[groups]]\nid = \"g\"\nsteps = [\"z\"]\nrepeat_until = 'true'", "unknown step: z"},
+		{"group without condition", "[[groups]]\nid = \"g\"\nsteps = [\"a\"]", "requires repeat_until"},
+		{"step in two groups", "[[groups]]\nid = \"g\"\nsteps = [\"a\"]\nrepeat_until = 'true'\n[[groups]]\nid = \"h\"\nsteps = [\"a\"]\nrepeat_until = 'true'", "in both group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(base + tt.extra + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
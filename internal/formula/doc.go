@@ -85,6 +85,12 @@
 //   - Unique IDs within steps/legs/templates/aspects
 //   - Valid dependency references (needs/depends_on)
 //   - Cycle detection in dependency graphs
+//   - Step conditions, retry policies, timeouts and step groups
+//   - Variable definitions: type, enum values, pattern and default
+//
+// Values supplied for a formula's vars are checked with ResolveVars, which
+// rejects undeclared names, missing required vars and values that do not fit
+// the var's type (string, int, bool, bead-id, rig, agent or enum) or pattern.
 //
 // # Cycle Detection
 //
@@ -114,6 +120,53 @@
 //	ready := f.ReadySteps(completed)
 //	// Returns: ["build"] (test is done, build can run)
 //
+// # Composition
+//
+// A formula can build on others. Bases named in extends are merged in
+// order, [[include]] tables take (a subset of) another formula's steps, own
+// steps replace inherited steps with the same ID or are spliced in with
+// after/before, and [[overrides]] patch or remove inherited steps:
+//
+//	formula = "shiny-strict"
+//	extends = ["shiny"]
+//
+//	[[steps]]
+//	id = "lint"
+//	title = "Lint"
+//	after = "implement"
+//
+//	[[overrides]]
+//	id = "review"
+//	description = "Two reviewers."
+//
+// Parse resolves composition before validating, so Validate, checkCycles
+// and TopologicalSort all see the flattened formula. ParseFile looks up
+// bases next to the file, then among the embedded formulas; ParseWithLoader
+// takes any Loader. Formula.TOML encodes the flattened result.
+//
+// # Execution Policies
+//
+// Workflow steps may declare a condition, a retry policy and a timeout,
+// and steps can be grouped to repeat until a condition holds:
+//
+//	[[steps]]
+//	id = "fix"
+//	needs = ["check"]
+//	when = 'steps.check.output == "dirty" && {{mode}} != "dry-run"'
+//	retry = { max_attempts = 3 }
+//	timeout = "15m"
+//
+//	[[groups]]
+//	id = "poll"
+//	steps = ["check", "fix"]
+//	repeat_until = 'steps.check.output == "clean"'
+//	max_iterations = 5
+//
+// Validate checks that conditions parse (see ParseCondition), only use
+// declared vars, and only refer to steps the conditional step needs
+// (directly or transitively). The policies are enforced when a molecule
+// made from the formula is executed, by gt mol step done.
+//
 // # Embedded Formulas
 //
 // The package includes embedded formula files that can be provisioned
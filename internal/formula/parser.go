@@ -3,26 +3,44 @@ package formula
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
-// ParseFile reads and parses a formula.toml file.
+// ParseFile reads and parses a formula.toml file. Formulas it extends or
+// includes are looked up next to it, then among the embedded formulas.
 func ParseFile(path string) (*Formula, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from trusted formula directory
 	if err != nil {
 		return nil, fmt.Errorf("reading formula file: %w", err)
 	}
-	return Parse(data)
+	return ParseWithLoader(data, DirLoader(filepath.Dir(path)))
 }
 
-// Parse parses formula.toml content from bytes.
+// Parse parses formula.toml content from bytes. Formulas it extends or
+// includes are looked up among the embedded formulas.
 func Parse(data []byte) (*Formula, error) {
+	return ParseWithLoader(data, DirLoader())
+}
+
+// ParseWithLoader parses formula.toml content, resolving extends, include
+// and overrides with load. Validation runs on the resolved formula.
+func ParseWithLoader(data []byte, load Loader) (*Formula, error) {
 	var f Formula
 	if _, err := toml.Decode(string(data), &f); err != nil {
 		return nil, fmt.Errorf("parsing TOML: %w", err)
 	}
 
+	if f.IsComposed() {
+		resolved, err := resolve(&f, load, nil)
+		if err != nil {
+			return nil, err
+		}
+		f = *resolved
+	}
+
 	// Infer type from content if not explicitly set
 	f.inferType()
 
@@ -62,6 +80,10 @@ func (f *Formula) Validate() error {
 		return fmt.Errorf("invalid formula type %q (must be convoy, workflow, expansion, or aspect)", f.Type)
 	}
 
+	if err := f.validateVars(); err != nil {
+		return err
+	}
+
 	// Type-specific validation
 	switch f.Type {
 	case TypeConvoy:
@@ -137,9 +159,110 @@ func (f *Formula) validateWorkflow() error {
 		return err
 	}
 
+	return f.validateExecution()
+}
+
+// validateExecution checks step conditions, retry policies, timeouts and
+// step groups. It runs after checkCycles, so needs can be walked safely.
+func (f *Formula) validateExecution() error {
+	for _, step := range f.Steps {
+		if step.When != "" {
+			cond, err := ParseCondition(step.When)
+			if err != nil {
+				return fmt.Errorf("step %q when: %w", step.ID, err)
+			}
+			if err := f.checkConditionVars(cond); err != nil {
+				return fmt.Errorf("step %q when: %w", step.ID, err)
+			}
+			earlier := f.ancestors(step.ID)
+			for _, ref := range cond.StepRefs() {
+				if !earlier[ref] {
+					return fmt.Errorf("step %q when refers to step %q, which is not one of its (transitive) needs", step.ID, ref)
+				}
+			}
+		}
+		if step.Retry != nil && step.Retry.MaxAttempts < 1 {
+			return fmt.Errorf("step %q retry.max_attempts must be at least 1", step.ID)
+		}
+		if step.Timeout != "" {
+			d, err := time.ParseDuration(step.Timeout)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("step %q has invalid timeout %q (want a positive duration like \"15m\")", step.ID, step.Timeout)
+			}
+		}
+	}
+
+	grouped := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, group := range f.Groups {
+		if group.ID == "" {
+			return fmt.Errorf("group missing required id field")
+		}
+		if seen[group.ID] {
+			return fmt.Errorf("duplicate group id: %s", group.ID)
+		}
+		seen[group.ID] = true
+		if len(group.Steps) == 0 {
+			return fmt.Errorf("group %q has no steps", group.ID)
+		}
+		for _, id := range group.Steps {
+			if f.GetStep(id) == nil {
+				return fmt.Errorf("group %q contains unknown step: %s", group.ID, id)
+			}
+			if other, ok := grouped[id]; ok {
+				return fmt.Errorf("step %q is in both group %q and group %q", id, other, group.ID)
+			}
+			grouped[id] = group.ID
+		}
+		if group.RepeatUntil == "" {
+			return fmt.Errorf("group %q requires repeat_until", group.ID)
+		}
+		cond, err := ParseCondition(group.RepeatUntil)
+		if err != nil {
+			return fmt.Errorf("group %q repeat_until: %w", group.ID, err)
+		}
+		if err := f.checkConditionVars(cond); err != nil {
+			return fmt.Errorf("group %q repeat_until: %w", group.ID, err)
+		}
+		for _, ref := range cond.StepRefs() {
+			if f.GetStep(ref) == nil {
+				return fmt.Errorf("group %q repeat_until refers to unknown step: %s", group.ID, ref)
+			}
+		}
+		if group.MaxIterations < 0 {
+			return fmt.Errorf("group %q max_iterations must not be negative", group.ID)
+		}
+	}
+
 	return nil
 }
 
+// checkConditionVars checks that a condition only uses declared vars.
+func (f *Formula) checkConditionVars(cond *Condition) error {
+	for _, name := range cond.Vars() {
+		if _, ok := f.Vars[name]; !ok {
+			return fmt.Errorf("undeclared var %q", name)
+		}
+	}
+	return nil
+}
+
+// ancestors returns the steps that id transitively needs.
+func (f *Formula) ancestors(id string) map[string]bool {
+	result := make(map[string]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		for _, need := range f.GetDependencies(id) {
+			if !result[need] {
+				result[need] = true
+				visit(need)
+			}
+		}
+	}
+	visit(id)
+	return result
+}
+
 func (f *Formula) validateExpansion() error {
 	if len(f.Template) == 0 {
 		return fmt.Errorf("expansion formula requires at least one template")
@@ -430,6 +553,41 @@ func (f *Formula) ParallelReadySteps(completed map[string]bool) (parallel []stri
 	return nil, ""
 }
 
+// GetGroup returns the step group containing the step, or nil if the step
+// is not in a group.
+func (f *Formula) GetGroup(stepID string) *StepGroup {
+	for i := range f.Groups {
+		for _, id := range f.Groups[i].Steps {
+			if id == stepID {
+				return &f.Groups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// HasExecutionPolicy reports whether any step has a condition, retry policy
+// or timeout, or the formula has step groups.
+func (f *Formula) HasExecutionPolicy() bool {
+	if len(f.Groups) > 0 {
+		return true
+	}
+	for _, step := range f.Steps {
+		if step.When != "" || step.Retry != nil || step.Timeout != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterations returns the group's iteration limit.
+func (g *StepGroup) Iterations() int {
+	if g.MaxIterations > 0 {
+		return g.MaxIterations
+	}
+	return DefaultMaxIterations
+}
+
 // GetLeg returns a leg by ID, or nil if not found.
 func (f *Formula) GetLeg(id string) *Leg {
 	for i := range f.Legs {
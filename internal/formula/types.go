@@ -25,90 +25,128 @@ const (
 // Formula represents a parsed formula.toml file.
 type Formula struct {
 	// Common fields
-	Name        string      `toml:"formula"`
-	Description string      `toml:"description"`
-	Type        FormulaType `toml:"type"`
-	Version     int         `toml:"version"`
+	Name        string      `toml:"formula,omitempty"`
+	Description string      `toml:"description,omitempty"`
+	Type        FormulaType `toml:"type,omitempty"`
+	Version     int         `toml:"version,omitzero"`
+
+	// Composition, flattened away by Parse (see compose.go)
+	Extends   []string       `toml:"extends,omitempty"`   // base formulas, merged in order
+	Include   []Include      `toml:"include,omitempty"`   // step sets taken from other formulas
+	Overrides []StepOverride `toml:"overrides,omitempty"` // patches to inherited steps
+	Compose   map[string]any `toml:"compose,omitempty"`   // bd compose rules, passed through as is
 
 	// Convoy-specific
-	Inputs    map[string]Input `toml:"inputs"`
-	Prompts   map[string]string `toml:"prompts"`
-	Output    *Output           `toml:"output"`
-	Legs      []Leg             `toml:"legs"`
-	Synthesis *Synthesis        `toml:"synthesis"`
+	Inputs    map[string]Input  `toml:"inputs,omitempty"`
+	Prompts   map[string]string `toml:"prompts,omitempty"`
+	Output    *Output           `toml:"output,omitempty"`
+	Legs      []Leg             `toml:"legs,omitempty"`
+	Synthesis *Synthesis        `toml:"synthesis,omitempty"`
 
 	// Workflow-specific
-	Steps []Step           `toml:"steps"`
-	Vars  map[string]Var   `toml:"vars"`
+	Steps  []Step         `toml:"steps,omitempty"`
+	Groups []StepGroup    `toml:"groups,omitempty"`
+	Vars   map[string]Var `toml:"vars,omitempty"`
 
 	// Expansion-specific
-	Template []Template `toml:"template"`
+	Template []Template `toml:"template,omitempty"`
 
 	// Aspect-specific (similar to convoy but for analysis)
-	Aspects []Aspect `toml:"aspects"`
+	Aspects []Aspect `toml:"aspects,omitempty"`
 }
 
 // Aspect represents a parallel analysis aspect in an aspect formula.
 type Aspect struct {
-	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	ID          string `toml:"id,omitempty"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Input represents an input parameter for a formula.
 type Input struct {
-	Description    string   `toml:"description"`
-	Type           string   `toml:"type"`
-	Required       bool     `toml:"required"`
-	RequiredUnless []string `toml:"required_unless"`
-	Default        string   `toml:"default"`
+	Description    string   `toml:"description,omitempty"`
+	Type           string   `toml:"type,omitempty"`
+	Required       bool     `toml:"required,omitempty"`
+	RequiredUnless []string `toml:"required_unless,omitempty"`
+	Default        string   `toml:"default,omitempty"`
 }
 
 // Output configures where formula outputs are written.
 type Output struct {
-	Directory  string `toml:"directory"`
-	LegPattern string `toml:"leg_pattern"`
-	Synthesis  string `toml:"synthesis"`
+	Directory  string `toml:"directory,omitempty"`
+	LegPattern string `toml:"leg_pattern,omitempty"`
+	Synthesis  string `toml:"synthesis,omitempty"`
 }
 
 // Leg represents a parallel execution unit in a convoy formula.
 type Leg struct {
-	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	ID          string `toml:"id,omitempty"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Synthesis represents the synthesis step that combines leg outputs.
 type Synthesis struct {
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	DependsOn   []string `toml:"depends_on"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	DependsOn   []string `toml:"depends_on,omitempty"`
 }
 
 // Step represents a sequential step in a workflow formula.
 type Step struct {
-	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
-	Parallel    bool     `toml:"parallel"` // If true, this step can run concurrently with other parallel steps that share the same needs
+	ID          string   `toml:"id,omitempty"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
+	Parallel    bool     `toml:"parallel,omitempty"` // If true, this step can run concurrently with other parallel steps that share the same needs
+
+	// Execution policy, honored by gt mol step done.
+	When    string       `toml:"when,omitempty"`    // Condition; the step is skipped when it is false
+	Retry   *RetryPolicy `toml:"retry,omitempty"`   // Retry policy for failed or timed-out attempts
+	Timeout string       `toml:"timeout,omitempty"` // Go duration, e.g. "15m"; an attempt finishing later fails
+
+	// Placement of a step added by a composed formula.
+	After  string `toml:"after,omitempty"`  // Splice in after this step: it needs it, and its dependents need this step instead
+	Before string `toml:"before,omitempty"` // This step must finish before the given step starts
+}
+
+// RetryPolicy controls how often a failed step is attempted.
+type RetryPolicy struct {
+	MaxAttempts int `toml:"max_attempts,omitzero"` // Total attempts, including the first
+}
+
+// DefaultMaxIterations bounds a repeating step group that sets no
+// max_iterations of its own.
+const DefaultMaxIterations = 10
+
+// StepGroup is a set of workflow steps that repeats until a condition holds.
+// When the last step of an iteration closes and RepeatUntil is false, the
+// group's steps are reopened for another iteration.
+type StepGroup struct {
+	ID            string   `toml:"id,omitempty"`
+	Steps         []string `toml:"steps,omitempty"`
+	RepeatUntil   string   `toml:"repeat_until,omitempty"`
+	MaxIterations int      `toml:"max_iterations,omitzero"` // 0 means DefaultMaxIterations
 }
 
 // Template represents a template step in an expansion formula.
 type Template struct {
-	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
+	ID          string   `toml:"id,omitempty"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
 }
 
 // Var represents a variable definition for formulas.
 type Var struct {
-	Description string `toml:"description"`
-	Required    bool   `toml:"required"`
-	Default     string `toml:"default"`
+	Description string   `toml:"description,omitempty"`
+	Required    bool     `toml:"required,omitempty"`
+	Default     string   `toml:"default,omitempty"`
+	Type        VarType  `toml:"type,omitempty"`    // see VarType; default string
+	Enum        []string `toml:"enum,omitempty"`    // allowed values for enum vars
+	Pattern     string   `toml:"pattern,omitempty"` // regexp the whole value must match
 }
 
 // IsValid returns true if the formula type is recognized.
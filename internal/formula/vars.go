@@ -0,0 +1,291 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/suggest"
+)
+
+// VarType is the type of a formula variable. The empty type is a string.
+type VarType string
+
+const (
+	VarString VarType = "string"
+	VarInt    VarType = "int"
+	VarBool   VarType = "bool"
+	VarBeadID VarType = "bead-id" // e.g. gt-abc12
+	VarRig    VarType = "rig"     // a rig name
+	VarAgent  VarType = "agent"   // an agent address, e.g. gastown/crew/max
+	VarEnum   VarType = "enum"    // one of Var.Enum
+)
+
+// IsValid returns true if the variable type is recognized.
+func (t VarType) IsValid() bool {
+	switch t {
+	case "", VarString, VarInt, VarBool, VarBeadID, VarRig, VarAgent, VarEnum:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	beadIDPattern  = regexp.MustCompile(`^[a-z][a-z0-9]*-[a-z0-9][a-z0-9.-]*$`)
+	rigNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+)
+
+// TypeName returns the variable's type, defaulting to string (or enum when
+// allowed values are given without a type).
+func (v Var) TypeName() VarType {
+	switch {
+	case v.Type != "":
+		return v.Type
+	case len(v.Enum) > 0:
+		return VarEnum
+	default:
+		return VarString
+	}
+}
+
+// IsRequired reports whether a value must be supplied: the var is required
+// and has no default.
+func (v Var) IsRequired() bool {
+	return v.Required && v.Default == ""
+}
+
+// Constraint describes the allowed values beyond the type, for usage output.
+func (v Var) Constraint() string {
+	var parts []string
+	if len(v.Enum) > 0 {
+		parts = append(parts, strings.Join(v.Enum, "|"))
+	}
+	if v.Pattern != "" {
+		parts = append(parts, "/"+v.Pattern+"/")
+	}
+	return strings.Join(parts, " ")
+}
+
+// CheckValue checks a value against the variable's type, allowed values and
+// pattern. Existence of rigs, agents and beads is not checked here; see
+// VarLookup.
+func (v Var) CheckValue(value string) error {
+	switch v.TypeName() {
+	case VarInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an int", value)
+		}
+	case VarBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a bool (use true or false)", value)
+		}
+	case VarBeadID:
+		if !beadIDPattern.MatchString(value) {
+			return fmt.Errorf("%q is not a bead ID (e.g. gt-abc12)", value)
+		}
+	case VarRig:
+		if !rigNamePattern.MatchString(value) {
+			return fmt.Errorf("%q is not a rig name", value)
+		}
+	case VarAgent:
+		if !isAgentAddress(value) {
+			return fmt.Errorf("%q is not an agent address (e.g. mayor, <rig>/witness, <rig>/crew/<name>)", value)
+		}
+	case VarEnum:
+		if !contains(v.Enum, value) {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(v.Enum, ", "))
+		}
+	}
+	if v.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + v.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", v.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match /%s/", value, v.Pattern)
+		}
+	}
+	return nil
+}
+
+// isAgentAddress reports whether s has the shape of an agent address:
+// mayor, deacon, <rig>/<role-or-polecat> or <rig>/<crew|polecats>/<name>.
+func isAgentAddress(s string) bool {
+	s = strings.TrimSuffix(s, "/")
+	if s == "mayor" || s == "deacon" {
+		return true
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	for _, part := range parts {
+		if !rigNamePattern.MatchString(part) {
+			return false
+		}
+	}
+	if len(parts) == 3 {
+		return parts[1] == "crew" || parts[1] == "polecats"
+	}
+	return parts[1] != "crew" && parts[1] != "polecats"
+}
+
+func contains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateVars checks the variable definitions themselves.
+func (f *Formula) validateVars() error {
+	for _, name := range f.varNames() {
+		v := f.Vars[name]
+		if !v.Type.IsValid() {
+			return fmt.Errorf("var %q: invalid type %q (must be string, int, bool, bead-id, rig, agent, or enum)", name, v.Type)
+		}
+		if v.TypeName() == VarEnum && len(v.Enum) == 0 {
+			return fmt.Errorf("var %q: enum requires allowed values", name)
+		}
+		if v.TypeName() != VarEnum && len(v.Enum) > 0 {
+			return fmt.Errorf("var %q: allowed values are only valid for enum vars", name)
+		}
+		if v.Pattern != "" {
+			if _, err := regexp.Compile(v.Pattern); err != nil {
+				return fmt.Errorf("var %q: invalid pattern: %w", name, err)
+			}
+		}
+		if v.Default != "" {
+			if err := v.CheckValue(v.Default); err != nil {
+				return fmt.Errorf("var %q: default: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// varNames returns the declared variable names, sorted.
+func (f *Formula) varNames() []string {
+	names := make([]string, 0, len(f.Vars))
+	for name := range f.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VarLookup checks that rig, agent and bead-id values refer to things that
+// exist. Nil funcs skip the check.
+type VarLookup struct {
+	Rig   func(name string) error
+	Agent func(address string) error
+	Bead  func(id string) error
+}
+
+// VarError lists every problem found with a set of variable values.
+type VarError struct {
+	Formula  string
+	Problems []string
+}
+
+func (e *VarError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("formula %s: %s", e.Formula, e.Problems[0])
+	}
+	return fmt.Sprintf("formula %s: invalid vars:\n  %s", e.Formula, strings.Join(e.Problems, "\n  "))
+}
+
+// ParseVarArgs parses key=value arguments, as given to --var.
+func ParseVarArgs(args []string) (map[string]string, error) {
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid var %q (want key=value)", arg)
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values, nil
+}
+
+// ResolveVars checks values against the formula's variable definitions and
+// returns them with defaults filled in. Unknown names, missing required
+// vars and values of the wrong type are all reported in one *VarError.
+func (f *Formula) ResolveVars(values map[string]string, lookup *VarLookup) (map[string]string, error) {
+	var problems []string
+
+	var given []string
+	for name := range values {
+		given = append(given, name)
+	}
+	sort.Strings(given)
+	for _, name := range given {
+		if _, ok := f.Vars[name]; !ok {
+			problems = append(problems, unknownVarProblem(name, f.varNames()))
+		}
+	}
+
+	resolved := make(map[string]string, len(f.Vars))
+	for _, name := range f.varNames() {
+		v := f.Vars[name]
+		value, ok := values[name]
+		if !ok || value == "" {
+			if v.IsRequired() {
+				problems = append(problems, "missing required var "+name)
+				continue
+			}
+			resolved[name] = v.Default
+			continue
+		}
+		if err := v.CheckValue(value); err != nil {
+			problems = append(problems, fmt.Sprintf("var %s: %v", name, err))
+			continue
+		}
+		if err := lookup.check(v.TypeName(), value); err != nil {
+			problems = append(problems, fmt.Sprintf("var %s: %v", name, err))
+			continue
+		}
+		resolved[name] = value
+	}
+
+	if len(problems) > 0 {
+		return nil, &VarError{Formula: f.Name, Problems: problems}
+	}
+	return resolved, nil
+}
+
+func (l *VarLookup) check(t VarType, value string) error {
+	if l == nil {
+		return nil
+	}
+	var fn func(string) error
+	switch t {
+	case VarRig:
+		fn = l.Rig
+	case VarAgent:
+		fn = l.Agent
+	case VarBeadID:
+		fn = l.Bead
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(value)
+}
+
+// unknownVarProblem describes an undeclared var, suggesting the declared
+// var it most likely misspells.
+func unknownVarProblem(name string, declared []string) string {
+	if len(declared) == 0 {
+		return fmt.Sprintf("unknown var %s (formula declares no vars)", name)
+	}
+	if similar := suggest.FindSimilar(name, declared, 1); len(similar) > 0 {
+		return fmt.Sprintf("unknown var %s (did you mean %s?)", name, similar[0])
+	}
+	return fmt.Sprintf("unknown var %s (declared: %s)", name, strings.Join(declared, ", "))
+}
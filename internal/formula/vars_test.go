@@ -0,0 +1,142 @@
+package formula
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const typedVarsFormula = `
+formula = "typed"
+
+[vars.issue]
+description = "Issue to work on"
+type = "bead-id"
+required = true
+
+[vars.count]
+type = "int"
+default = "3"
+
+[vars.dry_run]
+type = "bool"
+default = "false"
+
+[vars.mode]
+enum = ["fast", "full"]
+default = "fast"
+
+[vars.rig]
+type = "rig"
+
+[vars.reviewer]
+type = "agent"
+
+[vars.version]
+pattern = 'v[0-9]+\.[0-9]+\.[0-9]+'
+
+[[steps]]
+id = "work"
+title = "Work on {{issue}}"
+`
+
+func TestResolveVars(t *testing.T) {
+	f, err := Parse([]byte(typedVarsFormula))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := f.ResolveVars(map[string]string{"issue": "gt-abc12", "mode": "full", "reviewer": "gastown/crew/max"}, nil)
+	if err != nil {
+		t.Fatalf("ResolveVars: %v", err)
+	}
+	want := map[string]string{
+		"issue": "gt-abc12", "count": "3", "dry_run": "false", "mode": "full",
+		"rig": "", "reviewer": "gastown/crew/max", "version": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolved = %v, want %v", got, want)
+	}
+
+	_, err = f.ResolveVars(map[string]string{
+		"isue":     "gt-abc12",
+		"count":    "three",
+		"dry_run":  "maybe",
+		"mode":     "slow",
+		"reviewer": "gastown/crew",
+		"version":  "1.2",
+	}, nil)
+	var varErr *VarError
+	if !errors.As(err, &varErr) {
+		t.Fatalf("err = %v, want *VarError", err)
+	}
+	for _, want := range []string{
+		"unknown var isue (did you mean issue?)",
+		"missing required var issue",
+		`var count: "three" is not an int`,
+		`var dry_run: "maybe" is not a bool`,
+		`var mode: "slow" is not one of fast, full`,
+		`var reviewer: "gastown/crew" is not an agent address`,
+		`var version: "1.2" does not match`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestResolveVarsLookup(t *testing.T) {
+	f, err := Parse([]byte(typedVarsFormula))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := &VarLookup{Rig: func(name string) error {
+		if name != "gastown" {
+			return errors.New("unknown rig")
+		}
+		return nil
+	}}
+	if _, err := f.ResolveVars(map[string]string{"issue": "gt-1", "rig": "gastown"}, lookup); err != nil {
+		t.Errorf("known rig rejected: %v", err)
+	}
+	if _, err := f.ResolveVars(map[string]string{"issue": "gt-1", "rig": "gastwon"}, lookup); err == nil {
+		t.Error("unknown rig accepted")
+	}
+}
+
+func TestValidate_VarDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     string
+		wantErr string
+	}{
+		{"unknown type", `type = "float"`, "invalid type"},
+		{"enum without values", `type = "enum"`, "enum requires allowed values"},
+		{"values without enum", "type = \"int\"\nenum = [\"1\"]", "only valid for enum"},
+		{"bad pattern", `pattern = "("`, "invalid pattern"},
+		{"bad default", "type = \"int\"\ndefault = \"x\"", "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "formula = \"v\"\n\n[vars.x]\n" + tt.def + "\n\n[[steps]]\nid = \"a\"\ntitle = \"A\"\n"
+			_, err := Parse([]byte(src))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseVarArgs(t *testing.T) {
+	got, err := ParseVarArgs([]string{"a=1", "b=x=y", "c="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "1", "b": "x=y", "c": ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseVarArgs = %v", got)
+	}
+	if _, err := ParseVarArgs([]string{"novalue"}); err == nil {
+		t.Error("arg without = accepted")
+	}
+}
@@ -369,6 +369,21 @@ func (g *Git) Push(remote, branch string, force bool) error {
 	return err
 }
 
+// PushCommit pushes a specific commit to a branch on the remote.
+// The push is not forced, so it only succeeds as a fast-forward.
+func (g *Git) PushCommit(remote, commit, branch string) error {
+	_, err := g.run("push", remote, commit+":refs/heads/"+branch)
+	return err
+}
+
+// PushForceWithLease pushes ref to branch on the remote, replacing the
+// branch's history if needed, but only while the remote branch is still
+// where the local remote-tracking ref says it is.
+func (g *Git) PushForceWithLease(remote, ref, branch string) error {
+	_, err := g.run("push", "--force-with-lease=refs/heads/"+branch, remote, ref+":refs/heads/"+branch)
+	return err
+}
+
 // Add stages files for commit.
 func (g *Git) Add(paths ...string) error {
 	args := append([]string{"add"}, paths...)
@@ -529,6 +544,13 @@ func (g *Git) MergeNoFF(branch, message string) error {
 	return err
 }
 
+// MergeFFOnly fast-forwards the current branch to ref, failing if that
+// is not possible.
+func (g *Git) MergeFFOnly(ref string) error {
+	_, err := g.run("merge", "--ff-only", ref)
+	return err
+}
+
 // MergeSquash performs a squash merge of the given branch and commits with the provided message.
 // This stages all changes from the branch without creating a merge commit, then commits them
 // as a single commit with the given message. This eliminates redundant merge commits while
@@ -550,6 +572,25 @@ func (g *Git) GetBranchCommitMessage(branch string) (string, error) {
 	return g.run("log", "-1", "--format=%B", branch)
 }
 
+// BranchAuthors returns the distinct authors ("Name <email>") of the
+// commits on branch that are not on base, oldest first.
+func (g *Git) BranchAuthors(base, branch string) ([]string, error) {
+	out, err := g.run("log", "--reverse", "--format=%an <%ae>", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var authors []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		authors = append(authors, line)
+	}
+	return authors, nil
+}
+
 // DeleteRemoteBranch deletes a branch on the remote.
 func (g *Git) DeleteRemoteBranch(remote, branch string) error {
 	_, err := g.run("push", remote, "--delete", branch)
@@ -568,6 +609,51 @@ func (g *Git) AbortMerge() error {
 	return err
 }
 
+// Revert commits the inverse of commit on top of HEAD, with git's default
+// "Revert ..." message. commit may be a range (A..B), reverted newest
+// first; a merge commit is reverted against its first parent.
+func (g *Git) Revert(commit string) error {
+	args := []string{"revert", "--no-edit"}
+	if g.IsMergeCommit(commit) {
+		args = append(args, "-m", "1")
+	}
+	_, err := g.run(append(args, commit)...)
+	return err
+}
+
+// AbortRevert aborts a revert in progress.
+func (g *Git) AbortRevert() error {
+	_, err := g.run("revert", "--abort")
+	return err
+}
+
+// CherryPick applies commit on top of HEAD, recording its origin in the
+// message (git cherry-pick -x). commit may be a range (A..B); a merge
+// commit is applied as its changes against its first parent.
+func (g *Git) CherryPick(commit string) error {
+	args := []string{"cherry-pick", "-x"}
+	if g.IsMergeCommit(commit) {
+		args = append(args, "-m", "1")
+	}
+	_, err := g.run(append(args, commit)...)
+	return err
+}
+
+// IsMergeCommit reports whether commit (not a range) has a second parent.
+func (g *Git) IsMergeCommit(commit string) bool {
+	if strings.Contains(commit, "..") {
+		return false
+	}
+	_, err := g.run("rev-parse", "--verify", "--quiet", commit+"^2")
+	return err == nil
+}
+
+// AbortCherryPick aborts a cherry-pick in progress.
+func (g *Git) AbortCherryPick() error {
+	_, err := g.run("cherry-pick", "--abort")
+	return err
+}
+
 // CheckConflicts performs a test merge to check if source can be merged into target
 // without conflicts. Returns a list of conflicting files, or empty slice if clean.
 // The merge is always aborted after checking - no actual changes are made.
@@ -649,6 +735,20 @@ func (g *Git) GetConflictingFiles() ([]string, error) {
 	return result, nil
 }
 
+// ChangedFiles returns the files changed on head since it diverged from
+// base (git diff --name-only base...head). An empty diff returns an
+// empty, non-nil slice.
+func (g *Git) ChangedFiles(base, head string) ([]string, error) {
+	out, err := g.run("diff", "--name-only", base+"..."+head)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return []string{}, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
 // AbortRebase aborts a rebase in progress.
 func (g *Git) AbortRebase() error {
 	_, err := g.run("rebase", "--abort")
@@ -729,6 +829,12 @@ func (g *Git) ResetBranch(name, ref string) error {
 	return err
 }
 
+// ResetHard resets the index and working tree to ref, discarding changes.
+func (g *Git) ResetHard(ref string) error {
+	_, err := g.run("reset", "--hard", ref)
+	return err
+}
+
 // Rev returns the commit hash for the given ref.
 func (g *Git) Rev(ref string) (string, error) {
 	return g.run("rev-parse", ref)
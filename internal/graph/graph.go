@@ -0,0 +1,256 @@
+// Package graph builds dependency graphs of formulas and molecules and
+// renders them as Graphviz DOT, Mermaid, JSON or a standalone SVG.
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// Node kinds.
+const (
+	KindStep      = "step"
+	KindLeg       = "leg"
+	KindSynthesis = "synthesis"
+	KindAspect    = "aspect"
+	KindTemplate  = "template"
+)
+
+// Node statuses. Formula graphs have no status.
+const (
+	StatusDone       = "done"
+	StatusInProgress = "in_progress"
+	StatusReady      = "ready"
+	StatusBlocked    = "blocked"
+	StatusSkipped    = "skipped"
+)
+
+// Node is a step, leg, synthesis, aspect or template.
+type Node struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Kind     string `json:"kind"`
+	Status   string `json:"status,omitempty"`
+	Color    string `json:"color,omitempty"` // fill colour for Status
+	Parallel bool   `json:"parallel,omitempty"`
+}
+
+// Edge says From must finish before To starts.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is a formula or molecule dependency graph.
+type Graph struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// statusColors are the fills used for node statuses in every format.
+var statusColors = map[string]string{
+	StatusDone:       "#2da44e",
+	StatusInProgress: "#d4a72c",
+	StatusReady:      "#54aeff",
+	StatusBlocked:    "#d0d7de",
+	StatusSkipped:    "#f6f8fa",
+}
+
+func (g *Graph) addNode(n Node) {
+	if n.Label == "" {
+		n.Label = n.ID
+	}
+	n.Color = statusColors[n.Status]
+	g.Nodes = append(g.Nodes, n)
+}
+
+func (g *Graph) addEdges(to string, from []string) {
+	for _, f := range from {
+		g.Edges = append(g.Edges, Edge{From: f, To: to})
+	}
+}
+
+// FromFormula builds the graph of a formula: workflow steps, convoy legs
+// and synthesis, expansion templates or aspects.
+func FromFormula(f *formula.Formula) *Graph {
+	g := &Graph{Name: f.Name, Title: f.Description}
+	switch f.Type {
+	case formula.TypeWorkflow:
+		for _, step := range f.Steps {
+			g.addNode(Node{ID: step.ID, Label: step.Title, Kind: KindStep, Parallel: step.Parallel})
+			g.addEdges(step.ID, step.Needs)
+		}
+	case formula.TypeConvoy:
+		var legs []string
+		for _, leg := range f.Legs {
+			g.addNode(Node{ID: leg.ID, Label: leg.Title, Kind: KindLeg, Parallel: true})
+			legs = append(legs, leg.ID)
+		}
+		if f.Synthesis != nil {
+			g.addNode(Node{ID: KindSynthesis, Label: f.Synthesis.Title, Kind: KindSynthesis})
+			deps := f.Synthesis.DependsOn
+			if len(deps) == 0 {
+				deps = legs
+			}
+			g.addEdges(KindSynthesis, deps)
+		}
+	case formula.TypeExpansion:
+		for _, tmpl := range f.Template {
+			g.addNode(Node{ID: tmpl.ID, Label: tmpl.Title, Kind: KindTemplate})
+			g.addEdges(tmpl.ID, tmpl.Needs)
+		}
+	case formula.TypeAspect:
+		for _, aspect := range f.Aspects {
+			g.addNode(Node{ID: aspect.ID, Label: aspect.Title, Kind: KindAspect, Parallel: true})
+		}
+	}
+	return g
+}
+
+// FromMolecule builds the graph of a molecule from its root and step beads
+// (with dependencies loaded), colouring steps by status: done, in progress,
+// skipped, ready (all blockers done) or blocked.
+func FromMolecule(root *beads.Issue, steps []*beads.Issue) *Graph {
+	g := &Graph{Name: root.ID, Title: root.Title}
+
+	inMolecule := make(map[string]bool)
+	closed := make(map[string]bool)
+	for _, step := range steps {
+		inMolecule[step.ID] = true
+		closed[step.ID] = step.Status == "closed"
+	}
+
+	sorted := append([]*beads.Issue(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, step := range sorted {
+		var needs []string
+		for _, dep := range step.Dependencies {
+			if dep.DependencyType == "blocks" && inMolecule[dep.ID] {
+				needs = append(needs, dep.ID)
+			}
+		}
+
+		status := StatusReady
+		switch step.Status {
+		case "closed":
+			status = StatusDone
+			if fields := beads.ParseStepFields(step); fields != nil && fields.Skipped {
+				status = StatusSkipped
+			}
+		case "in_progress", "hooked", "pinned":
+			status = StatusInProgress
+		default:
+			for _, need := range needs {
+				if !closed[need] {
+					status = StatusBlocked
+					break
+				}
+			}
+		}
+
+		g.addNode(Node{
+			ID:     step.ID,
+			Label:  fmt.Sprintf("%s: %s", step.ID, step.Title),
+			Kind:   KindStep,
+			Status: status,
+		})
+		g.addEdges(step.ID, needs)
+	}
+	return g
+}
+
+// Molecule loads a molecule's steps and builds its graph.
+func Molecule(b *beads.Beads, rootID string) (*Graph, error) {
+	root, err := b.Show(rootID)
+	if err != nil {
+		return nil, fmt.Errorf("getting molecule %s: %w", rootID, err)
+	}
+	children, err := b.List(beads.ListOptions{Parent: rootID, Status: "all", Priority: -1})
+	if err != nil {
+		return nil, fmt.Errorf("listing steps of %s: %w", rootID, err)
+	}
+	if len(children) == 0 {
+		return nil, fmt.Errorf("no steps found for %s (not a molecule root?)", rootID)
+	}
+
+	// List output does not carry dependencies; fetch full details.
+	ids := make([]string, 0, len(children))
+	for _, child := range children {
+		ids = append(ids, child.ID)
+	}
+	details, err := b.ShowMultiple(ids)
+	if err != nil {
+		return nil, fmt.Errorf("fetching steps of %s: %w", rootID, err)
+	}
+	steps := make([]*beads.Issue, 0, len(children))
+	for _, child := range children {
+		if full := details[child.ID]; full != nil {
+			steps = append(steps, full)
+		} else {
+			steps = append(steps, child)
+		}
+	}
+	return FromMolecule(root, steps), nil
+}
+
+// Tiers groups node IDs by depth: tier 0 has no dependencies, and every
+// node sits one tier after its deepest dependency. Nodes on a cycle are
+// left out.
+func (g *Graph) Tiers() [][]string {
+	needs := make(map[string][]string)
+	for _, e := range g.Edges {
+		needs[e.To] = append(needs[e.To], e.From)
+	}
+	known := make(map[string]bool)
+	for _, n := range g.Nodes {
+		known[n.ID] = true
+	}
+
+	tier := make(map[string]int)
+	visiting := make(map[string]bool)
+	var depth func(id string) int
+	depth = func(id string) int {
+		if t, ok := tier[id]; ok {
+			return t
+		}
+		if visiting[id] {
+			return -1
+		}
+		visiting[id] = true
+		t := 0
+		for _, need := range needs[id] {
+			if !known[need] {
+				continue
+			}
+			d := depth(need)
+			if d < 0 {
+				t = -1
+				break
+			}
+			if d+1 > t {
+				t = d + 1
+			}
+		}
+		visiting[id] = false
+		tier[id] = t
+		return t
+	}
+
+	var tiers [][]string
+	for _, n := range g.Nodes {
+		t := depth(n.ID)
+		if t < 0 {
+			continue
+		}
+		for len(tiers) <= t {
+			tiers = append(tiers, nil)
+		}
+		tiers[t] = append(tiers[t], n.ID)
+	}
+	return tiers
+}
@@ -0,0 +1,149 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestFromFormulaWorkflow(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "wf"
+[[steps]]
+id = "a"
+title = "A"
+[[steps]]
+id = "b"
+title = "B"
+needs = ["a"]
+parallel = true
+[[steps]]
+id = "c"
+title = "C"
+needs = ["a"]
+[[steps]]
+id = "d"
+title = "D"
+needs = ["b", "c"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := FromFormula(f)
+	if len(g.Nodes) != 4 || len(g.Edges) != 4 {
+		t.Fatalf("got %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+	if !g.Nodes[1].Parallel {
+		t.Error("parallel flag lost")
+	}
+	if got, want := g.Tiers(), [][]string{{"a"}, {"b", "c"}, {"d"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tiers() = %v, want %v", got, want)
+	}
+}
+
+func TestFromFormulaConvoy(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "review"
+type = "convoy"
+[[legs]]
+id = "style"
+title = "Style"
+[[legs]]
+id = "security"
+title = "Security"
+[synthesis]
+title = "Combine"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := FromFormula(f)
+	want := []Edge{{From: "style", To: "synthesis"}, {From: "security", To: "synthesis"}}
+	if !reflect.DeepEqual(g.Edges, want) {
+		t.Errorf("Edges = %v, want %v", g.Edges, want)
+	}
+	if g.Nodes[2].Kind != KindSynthesis {
+		t.Errorf("synthesis kind = %q", g.Nodes[2].Kind)
+	}
+	if dot := g.DOT(); !strings.Contains(dot, `"synthesis" [label="Combine", shape=hexagon]`) {
+		t.Errorf("DOT() missing synthesis node:\n%s", dot)
+	}
+	if mm := g.Mermaid(); !strings.Contains(mm, `n2{{"Combine"}}`) || !strings.Contains(mm, "n0 --> n2") {
+		t.Errorf("Mermaid() =\n%s", mm)
+	}
+}
+
+func TestFromMoleculeStatuses(t *testing.T) {
+	blocks := func(ids ...string) []beads.IssueDep {
+		var deps []beads.IssueDep
+		for _, id := range ids {
+			deps = append(deps, beads.IssueDep{ID: id, DependencyType: "blocks"})
+		}
+		return deps
+	}
+	root := &beads.Issue{ID: "gt-mol", Title: "Molecule"}
+	steps := []*beads.Issue{
+		{ID: "gt-mol.1", Title: "Design", Status: "closed"},
+		{ID: "gt-mol.2", Title: "Implement", Status: "in_progress", Dependencies: blocks("gt-mol.1")},
+		{ID: "gt-mol.3", Title: "Docs", Status: "open", Dependencies: blocks("gt-mol.1")},
+		{ID: "gt-mol.4", Title: "Review", Status: "open", Dependencies: blocks("gt-mol.2", "gt-other")},
+		{ID: "gt-mol.5", Title: "Lint", Status: "closed", Description: "step_skipped: true"},
+	}
+	g := FromMolecule(root, steps)
+
+	got := make(map[string]string)
+	for _, n := range g.Nodes {
+		got[n.ID] = n.Status
+		if n.Color != statusColors[n.Status] {
+			t.Errorf("%s color = %q", n.ID, n.Color)
+		}
+	}
+	want := map[string]string{
+		"gt-mol.1": StatusDone,
+		"gt-mol.2": StatusInProgress,
+		"gt-mol.3": StatusReady,
+		"gt-mol.4": StatusBlocked,
+		"gt-mol.5": StatusSkipped,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	for _, e := range g.Edges {
+		if e.From == "gt-other" {
+			t.Error("edge to a bead outside the molecule")
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := &Graph{
+		Name:  "gt-mol",
+		Nodes: []Node{{ID: "a", Label: `say "hi" & <go>`, Kind: KindStep, Status: StatusDone, Color: statusColors[StatusDone]}},
+	}
+
+	out, err := g.Render("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Graph
+	if err := json.Unmarshal([]byte(out), &back); err != nil || !reflect.DeepEqual(back.Nodes, g.Nodes) {
+		t.Errorf("json round trip = %+v, %v", back, err)
+	}
+
+	if out, _ := g.Render("dot"); !strings.Contains(out, `label="say \"hi\" & <go>"`) {
+		t.Errorf("dot label not escaped:\n%s", out)
+	}
+	if out, _ := g.Render("mermaid"); !strings.Contains(out, `["say #quot;hi#quot; & <go>"]`) || !strings.Contains(out, "classDef done fill:#2da44e") {
+		t.Errorf("mermaid =\n%s", out)
+	}
+	if svg := g.SVG(); !strings.Contains(svg, "say &#34;hi&#34; &amp; &lt;go&gt;") || strings.Contains(svg, "<go>") {
+		t.Errorf("svg label not escaped:\n%s", svg)
+	}
+	if _, err := g.Render("png"); err == nil {
+		t.Error("Render(png) should fail")
+	}
+}
@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Formats accepted by Render.
+var Formats = []string{"dot", "mermaid", "json"}
+
+// Render renders the graph in one of Formats.
+func (g *Graph) Render(format string) (string, error) {
+	switch format {
+	case "dot":
+		return g.DOT(), nil
+	case "mermaid":
+		return g.Mermaid(), nil
+	case "json":
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	return "", fmt.Errorf("unknown format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// dotShapes gives node kinds other than boxes their shape.
+var dotShapes = map[string]string{
+	KindSynthesis: "hexagon",
+	KindAspect:    "ellipse",
+}
+
+// DOT renders the graph for Graphviz.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(g.Name))
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\", fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(n.Label)}
+		if shape, ok := dotShapes[n.Kind]; ok {
+			attrs = append(attrs, "shape="+shape)
+		}
+		if n.Color != "" {
+			attrs = append(attrs, "fillcolor="+dotQuote(n.Color))
+		}
+		if n.Parallel {
+			attrs = append(attrs, `style="rounded,filled,dashed"`)
+		}
+		if n.Status != "" {
+			attrs = append(attrs, "tooltip="+dotQuote(n.Status))
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *Graph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	// Mermaid IDs are restricted, so nodes are numbered.
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	for _, n := range g.Nodes {
+		label := mermaidLabel(n.Label)
+		switch n.Kind {
+		case KindSynthesis:
+			fmt.Fprintf(&sb, "  %s{{%s}}\n", ids[n.ID], label)
+		case KindAspect:
+			fmt.Fprintf(&sb, "  %s([%s])\n", ids[n.ID], label)
+		default:
+			fmt.Fprintf(&sb, "  %s[%s]\n", ids[n.ID], label)
+		}
+	}
+	for _, e := range g.Edges {
+		from, okFrom := ids[e.From]
+		to, okTo := ids[e.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s --> %s\n", from, to)
+	}
+
+	var used []string
+	for _, status := range []string{StatusDone, StatusInProgress, StatusReady, StatusBlocked, StatusSkipped} {
+		var members []string
+		for _, n := range g.Nodes {
+			if n.Status == status {
+				members = append(members, ids[n.ID])
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		used = append(used, status)
+		fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(members, ","), status)
+	}
+	for _, status := range used {
+		fmt.Fprintf(&sb, "  classDef %s fill:%s\n", status, statusColors[status])
+	}
+	return sb.String()
+}
+
+func mermaidLabel(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return `"` + s + `"`
+}
+
+// SVG layout, in pixels.
+const (
+	svgNodeWidth  = 200
+	svgNodeHeight = 40
+	svgColGap     = 60
+	svgRowGap     = 20
+	svgMargin     = 10
+	svgLabelChars = 28
+)
+
+// SVG renders a standalone SVG of the graph, laid out left to right by
+// tier, for embedding in HTML without a graph library.
+func (g *Graph) SVG() string {
+	tiers := g.Tiers()
+	type point struct{ x, y int }
+	pos := make(map[string]point)
+	rows := 0
+	for col, ids := range tiers {
+		for row, id := range ids {
+			pos[id] = point{
+				x: svgMargin + col*(svgNodeWidth+svgColGap),
+				y: svgMargin + row*(svgNodeHeight+svgRowGap),
+			}
+		}
+		if len(ids) > rows {
+			rows = len(ids)
+		}
+	}
+	width := 2*svgMargin + len(tiers)*(svgNodeWidth+svgColGap) - svgColGap
+	height := 2*svgMargin + rows*(svgNodeHeight+svgRowGap) - svgRowGap
+	if width < 0 || height < 0 {
+		width, height = 0, 0
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" class="dag" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	sb.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#57606a"/></marker></defs>` + "\n")
+	for _, e := range g.Edges {
+		from, okFrom := pos[e.From]
+		to, okTo := pos[e.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#57606a" marker-end="url(#arrow)"/>`+"\n",
+			from.x+svgNodeWidth, from.y+svgNodeHeight/2, to.x, to.y+svgNodeHeight/2)
+	}
+	for _, n := range g.Nodes {
+		p, ok := pos[n.ID]
+		if !ok {
+			continue
+		}
+		fill := n.Color
+		if fill == "" {
+			fill = "#ffffff"
+		}
+		dash := ""
+		if n.Parallel {
+			dash = ` stroke-dasharray="4 2"`
+		}
+		label := n.Label
+		if runes := []rune(label); len(runes) > svgLabelChars {
+			label = string(runes[:svgLabelChars-1]) + "…"
+		}
+		fmt.Fprintf(&sb, `<g class="node %s"><title>%s</title><rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="%s" stroke="#57606a"%s/><text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle">%s</text></g>`+"\n",
+			html.EscapeString(n.Status), html.EscapeString(n.Label+statusSuffix(n.Status)),
+			p.x, p.y, svgNodeWidth, svgNodeHeight, fill, dash,
+			p.x+svgNodeWidth/2, p.y+svgNodeHeight/2, html.EscapeString(label))
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+func statusSuffix(status string) string {
+	if status == "" {
+		return ""
+	}
+	return " (" + status + ")"
+}
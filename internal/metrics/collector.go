@@ -0,0 +1,271 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/session"
+)
+
+// DefaultSnapshotTTL is how long gauge values are reused between scrapes.
+// Reading them shells out to bd and tmux, so a tight scrape interval
+// should not multiply that work.
+const DefaultSnapshotTTL = 15 * time.Second
+
+// TimeToMergeBuckets are the time-to-merge histogram bounds in seconds,
+// from one minute to one day.
+var TimeToMergeBuckets = []float64{60, 300, 600, 1800, 3600, 7200, 14400, 28800, 86400}
+
+// EscalationSeverities are always reported, so alerts on a severity work
+// before the first escalation of that severity is opened.
+var EscalationSeverities = []string{config.SeverityCritical, config.SeverityHigh, config.SeverityMedium, config.SeverityLow}
+
+// Snapshot is the town state behind the gauges. Per-rig maps are keyed by
+// rig name; a rig missing from a map (because reading it failed) is left
+// out of that gauge rather than reported as zero.
+type Snapshot struct {
+	// Rigs lists every rig, so idle rigs report zero polecats.
+	Rigs []string
+	// Polecats counts live polecat sessions.
+	Polecats map[string]int
+	// MergeQueue counts merge requests ready to merge.
+	MergeQueue map[string]int
+	// ReadyBeads counts beads with no open blockers.
+	ReadyBeads map[string]int
+	// Escalations counts open escalations by severity.
+	Escalations map[string]int
+}
+
+// Source reads the town state behind the gauges.
+type Source interface {
+	Snapshot() *Snapshot
+}
+
+// Collector serves a town's metrics. It is an http.Handler for /metrics.
+type Collector struct {
+	source      Source
+	eventsPath  string
+	snapshotTTL time.Duration
+
+	mu         sync.Mutex
+	snapshot   *Snapshot
+	snapshotAt time.Time
+	tail       eventTail
+
+	merges        *vec
+	mergeFailures *vec
+	deaths        *vec
+	restarts      *vec
+	massDeaths    *vec
+	timeToMerge   *histogram
+}
+
+// NewCollector creates a collector for the town at townRoot.
+func NewCollector(townRoot string) *Collector {
+	return newCollector(NewTownSource(townRoot), filepath.Join(townRoot, events.EventsFile), DefaultSnapshotTTL)
+}
+
+func newCollector(source Source, eventsPath string, snapshotTTL time.Duration) *Collector {
+	return &Collector{
+		source:        source,
+		eventsPath:    eventsPath,
+		snapshotTTL:   snapshotTTL,
+		merges:        newVec("rig"),
+		mergeFailures: newVec("rig", "failure_type"),
+		deaths:        newVec("role"),
+		restarts:      newVec("role"),
+		massDeaths:    newVec(),
+		timeToMerge:   newHistogram(TimeToMergeBuckets, "rig"),
+	}
+}
+
+// ServeHTTP writes the current metrics in the text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = WriteText(w, c.Gather())
+}
+
+// Gather reads new events and, if the cached snapshot is stale, the town
+// state, and returns every metric family.
+func (c *Collector) Gather() []Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readEvents()
+	if c.snapshot == nil || time.Since(c.snapshotAt) >= c.snapshotTTL {
+		c.snapshot = c.source.Snapshot()
+		c.snapshotAt = time.Now()
+	}
+	snap := c.snapshot
+
+	polecats := newVec("rig")
+	for _, rig := range snap.Rigs {
+		polecats.set(float64(snap.Polecats[rig]), rig)
+	}
+	escalations := newVec("severity")
+	for _, sev := range EscalationSeverities {
+		escalations.set(0, sev)
+	}
+	for sev, n := range snap.Escalations {
+		escalations.set(float64(n), sev)
+	}
+
+	return []Family{
+		{Name: "gastown_polecats", Help: "Live polecat sessions.", Type: TypeGauge, Samples: polecats.samples()},
+		{Name: "gastown_merge_queue_depth", Help: "Merge requests ready to merge.", Type: TypeGauge, Samples: perRig(snap.MergeQueue)},
+		{Name: "gastown_ready_beads", Help: "Beads with no open blockers.", Type: TypeGauge, Samples: perRig(snap.ReadyBeads)},
+		{Name: "gastown_escalations_open", Help: "Open escalations by severity.", Type: TypeGauge, Samples: escalations.samples()},
+		{Name: "gastown_merges_total", Help: "Merge requests merged by the refinery.", Type: TypeCounter, Samples: c.merges.samples()},
+		{Name: "gastown_merge_failures_total", Help: "Failed merge attempts by failure type.", Type: TypeCounter, Samples: c.mergeFailures.samples()},
+		{Name: "gastown_time_to_merge_seconds", Help: "Time from merge request submission to merge.", Type: TypeHistogram, Samples: c.timeToMerge.samples()},
+		{Name: "gastown_session_deaths_total", Help: "Agent sessions that died or were killed.", Type: TypeCounter, Samples: c.deaths.samples()},
+		{Name: "gastown_session_restarts_total", Help: "Agent sessions restarted by the daemon.", Type: TypeCounter, Samples: c.restarts.samples()},
+		{Name: "gastown_mass_deaths_total", Help: "Mass session death alerts.", Type: TypeCounter, Samples: c.massDeaths.samples()},
+	}
+}
+
+func perRig(counts map[string]int) []Sample {
+	v := newVec("rig")
+	for rig, n := range counts {
+		v.set(float64(n), rig)
+	}
+	return v.samples()
+}
+
+// eventTail tracks how far the events log has been counted.
+//
+// KRC prunes old events by rewriting the file, which invalidates the byte
+// offset. When that happens the new file is read from the start, skipping
+// events up to and including the last one already counted.
+type eventTail struct {
+	file     os.FileInfo
+	offset   int64
+	resync   bool
+	lastTime time.Time
+	atLast   int // events counted with timestamp lastTime
+	skipped  int // events with timestamp lastTime skipped while resyncing
+}
+
+// readEvents counts events appended since the last read.
+func (c *Collector) readEvents() {
+	f, err := os.Open(c.eventsPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	t := &c.tail
+	if t.file != nil && (!os.SameFile(t.file, info) || info.Size() < t.offset) {
+		t.offset = 0
+		t.resync = true
+		t.skipped = 0
+	}
+	t.file = info
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return // EOF or partial line: finish it on the next read
+		}
+		t.offset += int64(len(line))
+
+		var e events.Event
+		if json.Unmarshal(bytes.TrimSpace(line), &e) != nil {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		if t.resync {
+			if ts.Before(t.lastTime) {
+				continue
+			}
+			if ts.Equal(t.lastTime) && t.skipped < t.atLast {
+				t.skipped++
+				continue
+			}
+			t.resync = false
+		}
+		if ts.Equal(t.lastTime) {
+			t.atLast++
+		} else if ts.After(t.lastTime) {
+			t.lastTime, t.atLast = ts, 1
+		}
+		c.count(&e)
+	}
+}
+
+// count updates the counters for one event.
+func (c *Collector) count(e *events.Event) {
+	str := func(key string) string {
+		s, _ := e.Payload[key].(string)
+		return s
+	}
+	rig := str("rig")
+	if rig == "" {
+		// Merge events logged before rig was added: actor is <rig>/refinery.
+		rig = strings.TrimSuffix(e.Actor, "/refinery")
+	}
+
+	switch e.Type {
+	case events.TypeMerged:
+		c.merges.add(1, rig)
+		if d, ok := e.Payload["duration_seconds"].(float64); ok && d >= 0 {
+			c.timeToMerge.observe(d, rig)
+		}
+	case events.TypeMergeFailed:
+		failureType := str("failure_type")
+		if failureType == "" {
+			failureType = "unknown"
+		}
+		c.mergeFailures.add(1, rig, failureType)
+	case events.TypeSessionDeath:
+		c.deaths.add(1, sessionRole(str("session")))
+	case events.TypeSessionRestart:
+		role := str("role")
+		if role == "" {
+			role = sessionRole(str("session"))
+		}
+		c.restarts.add(1, role)
+	case events.TypeMassDeath:
+		c.massDeaths.add(1)
+	}
+}
+
+// sessionRole returns the role of a tmux session name, or "unknown".
+func sessionRole(name string) string {
+	if name == session.BootSessionName() {
+		return "boot"
+	}
+	id, err := session.ParseSessionName(name)
+	if err != nil {
+		return "unknown"
+	}
+	return string(id.Role)
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+type fakeSource struct {
+	snap  *Snapshot
+	calls int
+}
+
+func (f *fakeSource) Snapshot() *Snapshot {
+	f.calls++
+	return f.snap
+}
+
+func appendEvents(t *testing.T, path string, evs ...events.Event) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, e := range evs {
+		data, _ := json.Marshal(e)
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func event(ts, typ, actor string, payload map[string]interface{}) events.Event {
+	return events.Event{Timestamp: ts, Type: typ, Actor: actor, Payload: payload}
+}
+
+// scrape returns the /metrics body.
+func scrape(t *testing.T, c *Collector) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	return rec.Body.String()
+}
+
+func assertLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, "\n"+line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestCollectorGauges(t *testing.T) {
+	src := &fakeSource{snap: &Snapshot{
+		Rigs:        []string{"beads", "gastown"},
+		Polecats:    map[string]int{"gastown": 3},
+		MergeQueue:  map[string]int{"gastown": 2, "beads": 0},
+		ReadyBeads:  map[string]int{"gastown": 7},
+		Escalations: map[string]int{"high": 1},
+	}}
+	c := newCollector(src, filepath.Join(t.TempDir(), "missing.jsonl"), time.Hour)
+
+	body := scrape(t, c)
+	assertLines(t, body,
+		`gastown_polecats{rig="beads"} 0`,
+		`gastown_polecats{rig="gastown"} 3`,
+		`gastown_merge_queue_depth{rig="beads"} 0`,
+		`gastown_merge_queue_depth{rig="gastown"} 2`,
+		`gastown_ready_beads{rig="gastown"} 7`,
+		`gastown_escalations_open{severity="critical"} 0`,
+		`gastown_escalations_open{severity="high"} 1`,
+		"# TYPE gastown_merges_total counter",
+	)
+	if strings.Contains(body, `gastown_ready_beads{rig="beads"}`) {
+		t.Error("unreadable rig reported as zero ready beads")
+	}
+
+	scrape(t, c)
+	if src.calls != 1 {
+		t.Errorf("source read %d times within the TTL, want 1", src.calls)
+	}
+}
+
+func TestCollectorCountsEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), events.EventsFile)
+	c := newCollector(&fakeSource{snap: &Snapshot{}}, path, time.Hour)
+
+	appendEvents(t, path,
+		event("2026-01-01T10:00:00Z", events.TypeMerged, "gastown/refinery", map[string]interface{}{"rig": "gastown", "duration_seconds": 120.0}),
+		event("2026-01-01T10:00:00Z", events.TypeMerged, "beads/refinery", nil), // pre-metrics event: rig from actor
+		event("2026-01-01T10:01:00Z", events.TypeMergeFailed, "gastown/refinery", map[string]interface{}{"rig": "gastown", "failure_type": "tests_fail"}),
+		event("2026-01-01T10:02:00Z", events.TypeMergeFailed, "gastown/refinery", map[string]interface{}{"rig": "gastown"}),
+		event("2026-01-01T10:03:00Z", events.TypeSessionDeath, "gt-gastown-Toast", map[string]interface{}{"session": "gt-gastown-Toast"}),
+		event("2026-01-01T10:03:00Z", events.TypeSessionRestart, "daemon", map[string]interface{}{"session": "gt-gastown-Toast", "role": "polecat"}),
+		event("2026-01-01T10:04:00Z", events.TypeSling, "mayor", nil),
+	)
+	body := scrape(t, c)
+	assertLines(t, body,
+		`gastown_merges_total{rig="beads"} 1`,
+		`gastown_merges_total{rig="gastown"} 1`,
+		`gastown_merge_failures_total{rig="gastown",failure_type="tests_fail"} 1`,
+		`gastown_merge_failures_total{rig="gastown",failure_type="unknown"} 1`,
+		`gastown_time_to_merge_seconds_bucket{rig="gastown",le="300"} 1`,
+		`gastown_time_to_merge_seconds_count{rig="gastown"} 1`,
+		`gastown_session_deaths_total{role="polecat"} 1`,
+		`gastown_session_restarts_total{role="polecat"} 1`,
+	)
+
+	// Only new events are counted, and a partial line waits for its newline.
+	appendEvents(t, path, event("2026-01-01T11:00:00Z", events.TypeMerged, "gastown/refinery", map[string]interface{}{"rig": "gastown"}))
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString(`{"ts":"2026-01-01T11:00:01Z","type":"merged","actor":"gastown/refinery"`)
+	f.Close()
+	assertLines(t, scrape(t, c), `gastown_merges_total{rig="gastown"} 2`)
+
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString("}\n")
+	f.Close()
+	assertLines(t, scrape(t, c), `gastown_merges_total{rig="gastown"} 3`)
+}
+
+func TestCollectorSurvivesPrune(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, events.EventsFile)
+	c := newCollector(&fakeSource{snap: &Snapshot{}}, path, time.Hour)
+
+	old := event("2026-01-01T09:00:00Z", events.TypeMerged, "gastown/refinery", nil)
+	kept1 := event("2026-01-01T10:00:00Z", events.TypeMerged, "gastown/refinery", nil)
+	kept2 := event("2026-01-01T10:00:00Z", events.TypeMerged, "gastown/refinery", nil)
+	appendEvents(t, path, old, kept1, kept2)
+	assertLines(t, scrape(t, c), `gastown_merges_total{rig="gastown"} 3`)
+
+	// KRC rewrites the file without the old event; one event arrives after.
+	pruned := filepath.Join(dir, "pruned.jsonl")
+	appendEvents(t, pruned, kept1, kept2, event("2026-01-01T10:00:00Z", events.TypeMerged, "gastown/refinery", nil))
+	if err := os.Rename(pruned, path); err != nil {
+		t.Fatal(err)
+	}
+	assertLines(t, scrape(t, c), `gastown_merges_total{rig="gastown"} 4`)
+}
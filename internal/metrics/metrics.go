@@ -0,0 +1,196 @@
+// Package metrics exposes town health as Prometheus metrics.
+//
+// Gauges (live polecats, merge queue depth, ready beads, open escalations)
+// are read from the town on scrape. Counters and the time-to-merge
+// histogram are derived from .events.jsonl, so they cover every process
+// that logs events (refinery, daemon, gt commands), not just the one
+// serving /metrics.
+//
+// The text exposition format is written by hand; the town has no other
+// use for the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric types in the exposition format.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Label is a metric label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one line of a metric family.
+type Sample struct {
+	// Suffix is appended to the family name: "_bucket", "_sum" or
+	// "_count" for histograms, empty otherwise.
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named metric with its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// ContentType is the Content-Type of WriteText output.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText writes families in the Prometheus text exposition format.
+func WriteText(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		_, _ = bw.WriteString("# HELP " + f.Name + " " + escapeHelp(f.Help) + "\n")
+		_, _ = bw.WriteString("# TYPE " + f.Name + " " + f.Type + "\n")
+		for _, s := range f.Samples {
+			_, _ = bw.WriteString(f.Name + s.Suffix)
+			if len(s.Labels) > 0 {
+				_ = bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i > 0 {
+						_ = bw.WriteByte(',')
+					}
+					_, _ = bw.WriteString(l.Name + `="` + escapeLabel(l.Value) + `"`)
+				}
+				_ = bw.WriteByte('}')
+			}
+			_, _ = bw.WriteString(" " + formatValue(s.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// labelKeySep joins label values into map keys; it cannot appear in UTF-8.
+const labelKeySep = "\xff"
+
+// vec is a set of counter or gauge values keyed by label values.
+type vec struct {
+	labels []string
+	values map[string]float64
+}
+
+func newVec(labels ...string) *vec {
+	return &vec{labels: labels, values: make(map[string]float64)}
+}
+
+func (v *vec) add(delta float64, values ...string) {
+	v.values[strings.Join(values, labelKeySep)] += delta
+}
+
+func (v *vec) set(value float64, values ...string) {
+	v.values[strings.Join(values, labelKeySep)] = value
+}
+
+// samples returns the values sorted by label values.
+func (v *vec) samples() []Sample {
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]Sample, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, Sample{Labels: v.labelsFor(k), Value: v.values[k]})
+	}
+	return out
+}
+
+func (v *vec) labelsFor(key string) []Label {
+	if len(v.labels) == 0 {
+		return nil
+	}
+	values := strings.Split(key, labelKeySep)
+	labels := make([]Label, len(v.labels))
+	for i, name := range v.labels {
+		labels[i] = Label{Name: name, Value: values[i]}
+	}
+	return labels
+}
+
+// histogram is a cumulative histogram keyed by label values.
+type histogram struct {
+	*vec    // observation sums, reused for key and label handling
+	buckets []float64
+	counts  map[string][]uint64 // per key: one count per bucket plus +Inf
+}
+
+func newHistogram(buckets []float64, labels ...string) *histogram {
+	return &histogram{vec: newVec(labels...), buckets: buckets, counts: make(map[string][]uint64)}
+}
+
+func (h *histogram) observe(value float64, values ...string) {
+	key := strings.Join(values, labelKeySep)
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets)+1)
+		h.counts[key] = counts
+	}
+	i := sort.SearchFloat64s(h.buckets, value) // first bucket with bound >= value
+	counts[i]++
+	h.values[key] += value
+}
+
+// samples returns _bucket, _sum and _count samples for each series.
+func (h *histogram) samples() []Sample {
+	keys := make([]string, 0, len(h.counts))
+	for k := range h.counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []Sample
+	for _, k := range keys {
+		labels := h.labelsFor(k)
+		var cumulative uint64
+		for i, c := range h.counts[k] {
+			cumulative += c
+			le := "+Inf"
+			if i < len(h.buckets) {
+				le = formatValue(h.buckets[i])
+			}
+			bucketLabels := append(append([]Label(nil), labels...), Label{Name: "le", Value: le})
+			out = append(out, Sample{Suffix: "_bucket", Labels: bucketLabels, Value: float64(cumulative)})
+		}
+		out = append(out,
+			Sample{Suffix: "_sum", Labels: labels, Value: h.values[k]},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(cumulative)},
+		)
+	}
+	return out
+}
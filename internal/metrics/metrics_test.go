@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	v := newVec("rig", "failure_type")
+	v.add(1, "gastown", "conflict")
+	v.add(2, "beads", "tests_fail")
+	v.add(1, "gastown", "conflict")
+
+	h := newHistogram([]float64{60, 300}, "rig")
+	h.observe(30, "gastown")
+	h.observe(60, "gastown")
+	h.observe(1000, "gastown")
+
+	var buf bytes.Buffer
+	err := WriteText(&buf, []Family{
+		{Name: "gastown_merge_failures_total", Help: "Failed merges.\nBy type.", Type: TypeCounter, Samples: v.samples()},
+		{Name: "gastown_time_to_merge_seconds", Help: "Time to merge.", Type: TypeHistogram, Samples: h.samples()},
+		{Name: "gastown_up", Help: "Up.", Type: TypeGauge, Samples: []Sample{{Labels: []Label{{Name: "note", Value: `a "q" \ b`}}, Value: 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP gastown_merge_failures_total Failed merges.\nBy type.
+# TYPE gastown_merge_failures_total counter
+gastown_merge_failures_total{rig="beads",failure_type="tests_fail"} 2
+gastown_merge_failures_total{rig="gastown",failure_type="conflict"} 2
+# HELP gastown_time_to_merge_seconds Time to merge.
+# TYPE gastown_time_to_merge_seconds histogram
+gastown_time_to_merge_seconds_bucket{rig="gastown",le="60"} 2
+gastown_time_to_merge_seconds_bucket{rig="gastown",le="300"} 2
+gastown_time_to_merge_seconds_bucket{rig="gastown",le="+Inf"} 3
+gastown_time_to_merge_seconds_sum{rig="gastown"} 1090
+gastown_time_to_merge_seconds_count{rig="gastown"} 3
+# HELP gastown_up Up.
+# TYPE gastown_up gauge
+gastown_up{note="a \"q\" \\ b"} 1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// TownSource reads gauge values from a town on disk, tmux and beads.
+type TownSource struct {
+	townRoot string
+}
+
+// NewTownSource creates a Source for the town at townRoot.
+func NewTownSource(townRoot string) *TownSource {
+	return &TownSource{townRoot: townRoot}
+}
+
+// Snapshot implements Source. Rigs that cannot be read are left out of the
+// affected gauges.
+func (s *TownSource) Snapshot() *Snapshot {
+	snap := &Snapshot{
+		Polecats:    make(map[string]int),
+		MergeQueue:  make(map[string]int),
+		ReadyBeads:  make(map[string]int),
+		Escalations: make(map[string]int),
+	}
+
+	var rigs []*rig.Rig
+	if rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(s.townRoot)); err == nil {
+		rigs, _ = rig.NewManager(s.townRoot, rigsConfig, git.NewGit(s.townRoot)).DiscoverRigs()
+	}
+
+	local, _ := tmux.NewTmux().ListSessions()
+	for _, r := range rigs {
+		snap.Rigs = append(snap.Rigs, r.Name)
+
+		sessions := local
+		if r.IsRemote() {
+			sessions, _ = connection.TmuxFor(r.Connection()).ListSessions()
+		}
+		snap.Polecats[r.Name] = countPolecatSessions(sessions, r.Name)
+
+		if mrs, err := refinery.NewEngineer(r).ListReadyMRs(); err == nil {
+			snap.MergeQueue[r.Name] = len(mrs)
+		}
+		if ready, err := beads.New(r.BeadsPath()).Ready(); err == nil {
+			snap.ReadyBeads[r.Name] = len(ready)
+		}
+	}
+
+	if escalations, err := beads.New(s.townRoot).ListEscalations(); err == nil {
+		for _, issue := range escalations {
+			snap.Escalations[escalationSeverity(issue.Labels)]++
+		}
+	}
+	return snap
+}
+
+// countPolecatSessions counts the polecat sessions of rigName.
+func countPolecatSessions(sessions []string, rigName string) int {
+	n := 0
+	for _, name := range sessions {
+		id, err := session.ParseSessionName(name)
+		if err == nil && id.Role == session.RolePolecat && id.Rig == rigName {
+			n++
+		}
+	}
+	return n
+}
+
+// escalationSeverity returns the severity label value, defaulting to medium
+// as gt escalate does.
+func escalationSeverity(labels []string) string {
+	for _, label := range labels {
+		if strings.HasPrefix(label, "severity:") {
+			return strings.TrimPrefix(label, "severity:")
+		}
+	}
+	return config.SeverityMedium
+}
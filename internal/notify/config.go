@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// IsExternalAction reports whether an escalation route action is delivered
+// by this package (as opposed to bead, mail: or log actions).
+func IsExternalAction(action string) bool {
+	return strings.HasPrefix(action, "email:") || strings.HasPrefix(action, "sms:") || action == "slack"
+}
+
+// ForAction builds the notifier for an escalation route action from the
+// escalation config. It returns an error describing what is missing when the
+// action cannot be delivered, so callers can record it as skipped.
+func ForAction(action string, cfg *config.EscalationConfig) (Notifier, error) {
+	switch {
+	case strings.HasPrefix(action, "email:"):
+		if cfg.Contacts.HumanEmail == "" {
+			return nil, fmt.Errorf("contacts.human_email not configured")
+		}
+		smtpCfg := cfg.Delivery.SMTP
+		if smtpCfg == nil {
+			return nil, fmt.Errorf("delivery.smtp not configured")
+		}
+		n := &EmailNotifier{
+			ChannelName: action,
+			Host:        smtpCfg.Host,
+			Port:        smtpCfg.Port,
+			From:        smtpCfg.From,
+			To:          cfg.Contacts.HumanEmail,
+			Username:    smtpCfg.Username,
+		}
+		if smtpCfg.PasswordEnv != "" {
+			n.Password = os.Getenv(smtpCfg.PasswordEnv)
+			if n.Password == "" {
+				return nil, fmt.Errorf("$%s (delivery.smtp.password_env) is empty", smtpCfg.PasswordEnv)
+			}
+		}
+		return n, nil
+
+	case strings.HasPrefix(action, "sms:"):
+		if cfg.Contacts.HumanSMS == "" {
+			return nil, fmt.Errorf("contacts.human_sms not configured")
+		}
+		smsCfg := cfg.Delivery.SMS
+		if smsCfg == nil {
+			return nil, fmt.Errorf("delivery.sms not configured")
+		}
+		n := &SMSNotifier{
+			ChannelName: action,
+			URL:         smsCfg.URL,
+			To:          cfg.Contacts.HumanSMS,
+			From:        smsCfg.From,
+			Headers:     smsCfg.Headers,
+		}
+		if smsCfg.TokenEnv != "" {
+			n.Token = os.Getenv(smsCfg.TokenEnv)
+			if n.Token == "" {
+				return nil, fmt.Errorf("$%s (delivery.sms.token_env) is empty", smsCfg.TokenEnv)
+			}
+		}
+		return n, nil
+
+	case action == "slack":
+		if cfg.Contacts.SlackWebhook == "" {
+			return nil, fmt.Errorf("contacts.slack_webhook not configured")
+		}
+		return &SlackNotifier{WebhookURL: cfg.Contacts.SlackWebhook}, nil
+	}
+	return nil, fmt.Errorf("unknown notification action %q", action)
+}
+
+// PolicyFor returns the delivery policy configured in cfg.
+func PolicyFor(cfg *config.EscalationConfig) Policy {
+	return Policy{
+		Timeout:    cfg.GetDeliveryTimeout(),
+		Attempts:   cfg.GetDeliveryAttempts(),
+		RetryDelay: cfg.GetDeliveryRetryDelay(),
+	}
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSMTPPort is the mail submission port used when none is configured.
+const DefaultSMTPPort = 587
+
+// EmailNotifier sends plain-text email through an SMTP server.
+type EmailNotifier struct {
+	ChannelName string // route action, e.g. "email:human"
+	Host        string
+	Port        int
+	From        string
+	To          string
+	Username    string // AUTH PLAIN is used when set
+	Password    string
+
+	// TLSConfig overrides the STARTTLS configuration (tests).
+	TLSConfig *tls.Config
+}
+
+// Channel implements Notifier.
+func (e *EmailNotifier) Channel() string {
+	if e.ChannelName != "" {
+		return e.ChannelName
+	}
+	return "email"
+}
+
+// Send implements Notifier.
+func (e *EmailNotifier) Send(ctx context.Context, msg *Message) error {
+	if e.Host == "" || e.From == "" || e.To == "" {
+		return Permanent(errors.New("smtp host, from and recipient are required"))
+	}
+	port := e.Port
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// The smtp package has no context support; bound the whole exchange
+	// by the context deadline and abort on cancellation.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := e.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: e.Host, MinVersion: tls.VersionTLS12}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if e.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		auth := smtp.PlainAuth("", e.Username, e.Password, e.Host)
+		if err := c.Auth(auth); err != nil {
+			return smtpError("smtp auth", err)
+		}
+	}
+
+	if err := c.Mail(e.From); err != nil {
+		return smtpError("smtp MAIL FROM", err)
+	}
+	if err := c.Rcpt(e.To); err != nil {
+		return smtpError("smtp RCPT TO", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError("smtp DATA", err)
+	}
+	if _, err := w.Write(e.buildMessage(msg)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("smtp DATA", err)
+	}
+	return c.Quit()
+}
+
+// buildMessage renders msg as an RFC 5322 message.
+func (e *EmailNotifier) buildMessage(msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", e.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if msg.Severity == "critical" || msg.Severity == "high" {
+		b.WriteString("X-Priority: 1\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// headerValue flattens s so it cannot inject additional headers.
+func headerValue(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// smtpError wraps err, marking 5xx replies as permanent failures.
+func smtpError(stage string, err error) error {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return Permanent(fmt.Errorf("%s: %w", stage, err))
+	}
+	return fmt.Errorf("%s: %w", stage, err)
+}
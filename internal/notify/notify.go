@@ -0,0 +1,139 @@
+// Package notify delivers escalation notifications to humans outside Gas Town.
+//
+// Each channel (email, SMS, Slack) implements Notifier. Deliver wraps a single
+// send with a per-attempt timeout and exponential-backoff retries, and returns
+// a Delivery record suitable for storing on the escalation bead.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a notification to deliver.
+type Message struct {
+	Subject  string // short one-line summary
+	Body     string // plain-text detail
+	Severity string // escalation severity (critical, high, medium, low)
+}
+
+// Notifier sends a message over one external channel.
+type Notifier interface {
+	// Channel returns the route action this notifier serves (e.g. "email:human").
+	Channel() string
+
+	// Send delivers msg once. It must honor ctx cancellation.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Delivery status values.
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Delivery records the outcome of delivering one notification.
+type Delivery struct {
+	Channel  string    `json:"channel"`
+	Status   string    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// String renders the delivery as a single line, e.g.
+// "email:human sent attempts=1 at=2026-01-02T15:04:05Z".
+func (d Delivery) String() string {
+	s := fmt.Sprintf("%s %s attempts=%d at=%s", d.Channel, d.Status, d.Attempts, d.At.UTC().Format(time.RFC3339))
+	if d.Error != "" {
+		// Keep the record on one line so it fits the bead's key: value format.
+		s += " error=" + strings.Join(strings.Fields(d.Error), " ")
+	}
+	return s
+}
+
+// Skipped returns a Delivery for a channel that was not attempted.
+func Skipped(channel, reason string) Delivery {
+	return Delivery{Channel: channel, Status: StatusSkipped, Error: reason, At: time.Now()}
+}
+
+// Policy controls timeouts and retries for Deliver.
+type Policy struct {
+	Timeout    time.Duration // per attempt; 0 means no timeout
+	Attempts   int           // total tries; values < 1 are treated as 1
+	RetryDelay time.Duration // wait before the first retry, doubled each time
+}
+
+// permanentError marks a failure that retrying cannot fix
+// (bad credentials, rejected recipient, malformed request).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Deliver stops retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Deliver sends msg through n according to p and reports the outcome.
+func Deliver(ctx context.Context, n Notifier, msg *Message, p Policy) Delivery {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := p.RetryDelay
+
+	d := Delivery{Channel: n.Channel()}
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		d.Attempts = i
+		lastErr = sendOnce(ctx, n, msg, p.Timeout)
+		if lastErr == nil || IsPermanent(lastErr) || i == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			lastErr = ctx.Err()
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		delay *= 2
+	}
+
+	d.At = time.Now()
+	if lastErr != nil {
+		d.Status = StatusFailed
+		d.Error = lastErr.Error()
+	} else {
+		d.Status = StatusSent
+	}
+	return d
+}
+
+// sendOnce runs a single attempt bounded by timeout.
+func sendOnce(ctx context.Context, n Notifier, msg *Message, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return n.Send(ctx, msg)
+}
@@ -0,0 +1,327 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts one message per
+// connection and records it. rcptCode overrides the RCPT TO reply.
+type smtpStandIn struct {
+	ln       net.Listener
+	rcptCode string
+
+	mu       sync.Mutex
+	from     string
+	to       []string
+	messages []string
+}
+
+func startSMTP(t *testing.T) *smtpStandIn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStandIn{ln: ln, rcptCode: "250 OK"}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) hostPort(t *testing.T) (string, int) {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatalf("port: %v", err)
+	}
+	return host, p
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 standin ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 standin")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.TrimSpace(line[len("MAIL FROM:"):])
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mu.Lock()
+			s.to = append(s.to, strings.TrimSpace(line[len("RCPT TO:"):]))
+			s.mu.Unlock()
+			reply(s.rcptCode)
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		Subject:  "[CRITICAL] Build failing",
+		Body:     "Escalation ID: hq-123\nSeverity: critical",
+		Severity: "critical",
+	}
+}
+
+func TestEmailNotifier_Send(t *testing.T) {
+	srv := startSMTP(t)
+	host, port := srv.hostPort(t)
+
+	n := &EmailNotifier{ChannelName: "email:human", Host: host, Port: port, From: "gt@example.com", To: "human@example.com"}
+	d := Deliver(context.Background(), n, testMessage(), Policy{Timeout: 5 * time.Second, Attempts: 1})
+	if d.Status != StatusSent {
+		t.Fatalf("Status = %q (%s), want sent", d.Status, d.Error)
+	}
+	if d.Channel != "email:human" || d.Attempts != 1 {
+		t.Errorf("Delivery = %+v", d)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.from != "<gt@example.com>" {
+		t.Errorf("MAIL FROM = %q", srv.from)
+	}
+	if len(srv.to) != 1 || srv.to[0] != "<human@example.com>" {
+		t.Errorf("RCPT TO = %v", srv.to)
+	}
+	if len(srv.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(srv.messages))
+	}
+	msg := srv.messages[0]
+	for _, want := range []string{"Subject: [CRITICAL] Build failing\r\n", "To: human@example.com\r\n", "X-Priority: 1\r\n", "Escalation ID: hq-123\r\nSeverity: critical"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNotifier_RejectedRecipientIsPermanent(t *testing.T) {
+	srv := startSMTP(t)
+	srv.rcptCode = "550 no such user"
+	host, port := srv.hostPort(t)
+
+	n := &EmailNotifier{Host: host, Port: port, From: "gt@example.com", To: "nobody@example.com"}
+	d := Deliver(context.Background(), n, testMessage(), Policy{Attempts: 3, RetryDelay: time.Millisecond})
+	if d.Status != StatusFailed {
+		t.Fatalf("Status = %q, want failed", d.Status)
+	}
+	if d.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1 (5xx should not be retried)", d.Attempts)
+	}
+}
+
+func TestEmailNotifier_Timeout(t *testing.T) {
+	// A server that accepts but never greets.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	n := &EmailNotifier{Host: host, Port: port, From: "gt@example.com", To: "human@example.com"}
+	start := time.Now()
+	d := Deliver(context.Background(), n, testMessage(), Policy{Timeout: 100 * time.Millisecond, Attempts: 2, RetryDelay: time.Millisecond})
+	if d.Status != StatusFailed || d.Attempts != 2 {
+		t.Errorf("Delivery = %+v, want failed after 2 attempts", d)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Deliver took %s, timeout not honored", elapsed)
+	}
+}
+
+func TestSMSNotifier_RetriesThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	var got map[string]string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	n := &SMSNotifier{ChannelName: "sms:human", URL: srv.URL, To: "+15551234567", From: "gastown", Token: "secret"}
+	d := Deliver(context.Background(), n, testMessage(), Policy{Timeout: time.Second, Attempts: 3, RetryDelay: time.Millisecond})
+	if d.Status != StatusSent || d.Attempts != 2 {
+		t.Fatalf("Delivery = %+v, want sent on attempt 2", d)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got["to"] != "+15551234567" || got["from"] != "gastown" {
+		t.Errorf("payload = %v", got)
+	}
+	if !strings.HasPrefix(got["body"], "[CRITICAL] Build failing\n") {
+		t.Errorf("body = %q", got["body"])
+	}
+}
+
+func TestSlackNotifier_ClientErrorIsPermanent(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	d := Deliver(context.Background(), &SlackNotifier{WebhookURL: srv.URL}, testMessage(), Policy{Attempts: 3, RetryDelay: time.Millisecond})
+	if d.Status != StatusFailed || calls.Load() != 1 {
+		t.Errorf("Delivery = %+v after %d calls, want one failed call", d, calls.Load())
+	}
+	if !strings.Contains(d.Error, "invalid_payload") {
+		t.Errorf("Error = %q, want response body", d.Error)
+	}
+}
+
+func TestSlackNotifier_Send(t *testing.T) {
+	var payload map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if err := (&SlackNotifier{WebhookURL: srv.URL}).Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !strings.HasPrefix(payload["text"], "*[CRITICAL] Build failing*") {
+		t.Errorf("text = %q", payload["text"])
+	}
+}
+
+func TestSMSText_TruncatesOnRuneBoundary(t *testing.T) {
+	// "é" is two bytes, so the byte cut lands mid-rune.
+	msg := &Message{Subject: "x" + strings.Repeat("é", smsMaxLen)}
+	text := smsText(msg)
+	if !utf8.ValidString(text) {
+		t.Fatalf("smsText produced invalid UTF-8: %q", text)
+	}
+	if len(text) > smsMaxLen || !strings.HasSuffix(text, "é...") {
+		t.Errorf("smsText = %d bytes, %q", len(text), text[len(text)-10:])
+	}
+}
+
+func TestDeliveryString(t *testing.T) {
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	d := Delivery{Channel: "sms:human", Status: StatusFailed, Attempts: 3, Error: "gateway\nreturned 503", At: at}
+	want := "sms:human failed attempts=3 at=2026-01-02T15:04:05Z error=gateway returned 503"
+	if got := d.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("boom")
+	if !IsPermanent(Permanent(base)) || !errors.Is(Permanent(base), base) {
+		t.Error("Permanent should mark and wrap the error")
+	}
+	if IsPermanent(base) || Permanent(nil) != nil {
+		t.Error("unexpected permanent classification")
+	}
+}
+
+func TestForAction(t *testing.T) {
+	t.Setenv("GT_TEST_SMTP_PASSWORD", "hunter2")
+	cfg := config.NewEscalationConfig()
+
+	if _, err := ForAction("email:human", cfg); err == nil || !strings.Contains(err.Error(), "human_email") {
+		t.Errorf("email without contact: err = %v", err)
+	}
+	cfg.Contacts.HumanEmail = "human@example.com"
+	if _, err := ForAction("email:human", cfg); err == nil || !strings.Contains(err.Error(), "delivery.smtp") {
+		t.Errorf("email without smtp: err = %v", err)
+	}
+	cfg.Delivery.SMTP = &config.SMTPConfig{Host: "smtp.example.com", From: "gt@example.com", Username: "gt", PasswordEnv: "GT_TEST_SMTP_PASSWORD"}
+	n, err := ForAction("email:human", cfg)
+	if err != nil {
+		t.Fatalf("ForAction(email): %v", err)
+	}
+	if e := n.(*EmailNotifier); e.Password != "hunter2" || e.To != "human@example.com" || e.Channel() != "email:human" {
+		t.Errorf("EmailNotifier = %+v", e)
+	}
+
+	cfg.Contacts.HumanSMS = "+15551234567"
+	cfg.Delivery.SMS = &config.SMSWebhookConfig{URL: "https://sms.example.com", TokenEnv: "GT_TEST_UNSET_TOKEN"}
+	if _, err := ForAction("sms:human", cfg); err == nil || !strings.Contains(err.Error(), "GT_TEST_UNSET_TOKEN") {
+		t.Errorf("sms with empty token env: err = %v", err)
+	}
+
+	cfg.Contacts.SlackWebhook = "https://hooks.slack.com/services/x"
+	if n, err := ForAction("slack", cfg); err != nil || n.Channel() != "slack" {
+		t.Errorf("ForAction(slack) = %v, %v", n, err)
+	}
+
+	if IsExternalAction("mail:mayor") || !IsExternalAction("sms:human") {
+		t.Error("IsExternalAction misclassified actions")
+	}
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// SMSNotifier posts text messages to a generic SMS gateway over HTTP.
+// The request body is JSON: {"to": ..., "from": ..., "body": ...}.
+type SMSNotifier struct {
+	ChannelName string // route action, e.g. "sms:human"
+	URL         string
+	To          string
+	From        string
+	Token       string            // sent as "Authorization: Bearer <token>" when set
+	Headers     map[string]string // extra request headers
+	Client      *http.Client      // defaults to http.DefaultClient
+}
+
+// Channel implements Notifier.
+func (s *SMSNotifier) Channel() string {
+	if s.ChannelName != "" {
+		return s.ChannelName
+	}
+	return "sms"
+}
+
+// Send implements Notifier.
+func (s *SMSNotifier) Send(ctx context.Context, msg *Message) error {
+	if s.URL == "" || s.To == "" {
+		return Permanent(errors.New("sms gateway url and recipient are required"))
+	}
+	payload := map[string]string{
+		"to":   s.To,
+		"body": smsText(msg),
+	}
+	if s.From != "" {
+		payload["from"] = s.From
+	}
+	headers := make(map[string]string, len(s.Headers)+1)
+	for k, v := range s.Headers {
+		headers[k] = v
+	}
+	if s.Token != "" {
+		headers["Authorization"] = "Bearer " + s.Token
+	}
+	return postJSON(ctx, s.Client, s.URL, payload, headers)
+}
+
+// smsMaxLen keeps messages within a few SMS segments.
+const smsMaxLen = 320
+
+// smsText renders msg compactly for SMS.
+func smsText(msg *Message) string {
+	text := msg.Subject
+	if msg.Body != "" {
+		text += "\n" + msg.Body
+	}
+	if len(text) > smsMaxLen {
+		// Cut on a rune boundary so the payload stays valid UTF-8.
+		cut := smsMaxLen - 3
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+	return text
+}
+
+// SlackNotifier posts messages to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client // defaults to http.DefaultClient
+}
+
+// Channel implements Notifier.
+func (s *SlackNotifier) Channel() string {
+	return "slack"
+}
+
+// Send implements Notifier.
+func (s *SlackNotifier) Send(ctx context.Context, msg *Message) error {
+	if s.WebhookURL == "" {
+		return Permanent(errors.New("slack webhook url is required"))
+	}
+	text := "*" + msg.Subject + "*"
+	if msg.Body != "" {
+		text += "\n```\n" + msg.Body + "\n```"
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, map[string]string{"text": text}, nil)
+}
+
+// postJSON POSTs payload to url. 4xx responses other than 408 and 429 are
+// permanent failures; everything else is retryable.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Permanent(fmt.Errorf("encoding payload: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return Permanent(fmt.Errorf("building request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	utc := time.UTC
+	base := time.Date(2026, 3, 10, 8, 30, 0, 0, utc) // Tuesday
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 * * *", base, time.Date(2026, 3, 10, 9, 0, 0, 0, utc)},
+		{"0 9 * * *", time.Date(2026, 3, 10, 9, 0, 0, 0, utc), time.Date(2026, 3, 11, 9, 0, 0, 0, utc)},
+		{"*/15 * * * *", base, time.Date(2026, 3, 10, 8, 45, 0, 0, utc)},
+		{"5/20 * * * *", base, time.Date(2026, 3, 10, 8, 45, 0, 0, utc)},
+		{"0 0 * * mon-fri", time.Date(2026, 3, 13, 12, 0, 0, 0, utc), time.Date(2026, 3, 16, 0, 0, 0, 0, utc)},
+		{"0 12 1 jan,jul *", base, time.Date(2026, 7, 1, 12, 0, 0, 0, utc)},
+		{"0 0 * * 7", base, time.Date(2026, 3, 15, 0, 0, 0, 0, utc)}, // 7 = Sunday
+		{"@hourly", base, time.Date(2026, 3, 10, 9, 0, 0, 0, utc)},
+		{"0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		// Both day fields restricted: either matches (the 13th or a Friday).
+		{"0 0 13 * fri", base, time.Date(2026, 3, 13, 0, 0, 0, 0, utc)},
+		{"0 0 20 * fri", base, time.Date(2026, 3, 13, 0, 0, 0, 0, utc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr, utc)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_TimeZone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	s, err := ParseSchedule("CRON_TZ=America/New_York 0 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+	if s.Location().String() != "America/New_York" {
+		t.Errorf("Location = %s", s.Location())
+	}
+
+	from := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC) // 07:00 EST
+	want := time.Date(2026, 1, 5, 9, 0, 0, 0, ny)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+
+	// Spring forward: 02:30 does not exist on 2026-03-08; next valid slot is the following day.
+	s, _ = ParseSchedule("30 2 * * *", ny)
+	got := s.Next(time.Date(2026, 3, 8, 0, 0, 0, 0, ny))
+	if got.Hour() != 2 || got.Minute() != 30 || got.Day() != 9 {
+		t.Errorf("Next across DST gap = %s, want 2026-03-09 02:30", got)
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0 9 * *", "expected 5 fields"},
+		{"60 * * * *", "out of range"},
+		{"* 24 * * *", "out of range"},
+		{"* * 0 * *", "out of range"},
+		{"* * * 13 *", "out of range"},
+		{"*/0 * * * *", "invalid step"},
+		{"5-1 * * * *", "backwards"},
+		{"* * * * funday", "invalid value"},
+		{"CRON_TZ=Mars/Olympus 0 9 * * *", "unknown time zone"},
+		{"CRON_TZ=UTC", "missing schedule"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSchedule(tt.expr, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSchedule(%q) error = %v, want containing %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestSchedule_NeverFires(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 2 *", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next = %s, want zero time", got)
+	}
+}
@@ -0,0 +1,283 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultCooldown is used for cooldown gates without a duration.
+const DefaultCooldown = time.Hour
+
+// DefaultCheckTimeout bounds condition gate checks without a timeout.
+const DefaultCheckTimeout = 30 * time.Second
+
+// RunHistory provides the last recorded run of a plugin.
+// *Recorder implements it.
+type RunHistory interface {
+	GetLastRun(pluginName string) (*PluginRunBead, error)
+}
+
+// BaselineStore remembers when a plugin's gate was first evaluated, so a
+// cron plugin that never ran waits for its next scheduled time instead of
+// firing as soon as it is installed. *Recorder implements it.
+type BaselineStore interface {
+	// Baseline returns pluginName's baseline, recording now if it has none.
+	Baseline(pluginName string, now time.Time) (time.Time, error)
+}
+
+// GateStatus is the result of evaluating a plugin's gate.
+type GateStatus struct {
+	// Due is true when the plugin should run now.
+	Due bool `json:"due"`
+
+	// Reason explains the decision in a short human-readable phrase.
+	Reason string `json:"reason"`
+
+	// LastRun is the time of the most recent recorded run, if any.
+	LastRun *time.Time `json:"last_run,omitempty"`
+
+	// NextRun is when a cooldown or cron gate next opens, if known.
+	NextRun *time.Time `json:"next_run,omitempty"`
+
+	// Err is set when the gate could not be evaluated (bad schedule,
+	// history query failure). Due is false in that case.
+	Err error `json:"-"`
+}
+
+// GateEvaluator decides whether plugins are due to run.
+type GateEvaluator struct {
+	history RunHistory
+	now     func() time.Time
+
+	// baselines holds first-evaluation times when history is not a
+	// BaselineStore.
+	baselines map[string]time.Time
+}
+
+// NewGateEvaluator creates an evaluator that consults history for last runs.
+// If history is also a BaselineStore, cron baselines persist through it.
+func NewGateEvaluator(history RunHistory) *GateEvaluator {
+	return &GateEvaluator{history: history, now: time.Now, baselines: make(map[string]time.Time)}
+}
+
+// Evaluate checks p's gate. Condition checks run in the plugin directory
+// and are cancelled with ctx.
+func (e *GateEvaluator) Evaluate(ctx context.Context, p *Plugin) GateStatus {
+	gate := p.Gate
+	if gate == nil || gate.Type == "" || gate.Type == GateManual {
+		return GateStatus{Reason: "manual gate"}
+	}
+
+	switch gate.Type {
+	case GateCooldown:
+		return e.evaluateCooldown(p)
+	case GateCron:
+		return e.evaluateCron(p)
+	case GateCondition:
+		return e.evaluateCondition(ctx, p)
+	case GateEvent:
+		return GateStatus{Reason: fmt.Sprintf("waits for %q event", gate.On)}
+	default:
+		err := fmt.Errorf("unknown gate type %q", gate.Type)
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+}
+
+// lastRun returns the time of p's most recent run, or nil if it never ran.
+func (e *GateEvaluator) lastRun(p *Plugin) (*time.Time, error) {
+	run, err := e.history.GetLastRun(p.Name)
+	if err != nil {
+		return nil, fmt.Errorf("querying last run: %w", err)
+	}
+	if run == nil || run.CreatedAt.IsZero() {
+		return nil, nil
+	}
+	t := run.CreatedAt
+	return &t, nil
+}
+
+// baseline returns when p's gate was first evaluated (now, if this is the
+// first time).
+func (e *GateEvaluator) baseline(p *Plugin, now time.Time) (time.Time, error) {
+	if store, ok := e.history.(BaselineStore); ok {
+		return store.Baseline(p.Name, now)
+	}
+	if t, ok := e.baselines[p.Name]; ok {
+		return t, nil
+	}
+	e.baselines[p.Name] = now
+	return now, nil
+}
+
+func (e *GateEvaluator) evaluateCooldown(p *Plugin) GateStatus {
+	cooldown, err := gateDuration(p.Gate.Duration, DefaultCooldown)
+	if err != nil {
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+	return e.cooldownStatus(p, cooldown)
+}
+
+// cooldownStatus is due when p has not run within cooldown.
+func (e *GateEvaluator) cooldownStatus(p *Plugin, cooldown time.Duration) GateStatus {
+	last, err := e.lastRun(p)
+	if err != nil {
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+	if last == nil {
+		return GateStatus{Due: true, Reason: "never run"}
+	}
+	next := last.Add(cooldown)
+	status := GateStatus{LastRun: last, NextRun: &next}
+	if !e.now().Before(next) {
+		status.Due = true
+		status.Reason = fmt.Sprintf("cooldown %s elapsed", cooldown)
+	} else {
+		status.Reason = fmt.Sprintf("in cooldown for %s", next.Sub(e.now()).Round(time.Second))
+	}
+	return status
+}
+
+func (e *GateEvaluator) evaluateCron(p *Plugin) GateStatus {
+	sched, err := p.Gate.ParseSchedule()
+	if err != nil {
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+	last, err := e.lastRun(p)
+	if err != nil {
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+
+	// Due when a scheduled time has passed since the last run or, for a
+	// plugin that never ran, since its gate was first evaluated.
+	now := e.now()
+	since := last
+	if last == nil {
+		base, err := e.baseline(p, now)
+		if err != nil {
+			return GateStatus{Reason: err.Error(), Err: err}
+		}
+		since = &base
+	}
+	slot := sched.Next(*since)
+	if slot.IsZero() {
+		err := fmt.Errorf("cron %q never fires", sched)
+		return GateStatus{Reason: err.Error(), Err: err, LastRun: last}
+	}
+	if !slot.After(now) {
+		return GateStatus{
+			Due:     true,
+			Reason:  fmt.Sprintf("scheduled at %s", slot.Format("2006-01-02 15:04 MST")),
+			LastRun: last,
+			NextRun: timePtr(sched.Next(now)),
+		}
+	}
+	return GateStatus{
+		Reason:  fmt.Sprintf("next at %s", slot.Format("2006-01-02 15:04 MST")),
+		LastRun: last,
+		NextRun: &slot,
+	}
+}
+
+func (e *GateEvaluator) evaluateCondition(ctx context.Context, p *Plugin) GateStatus {
+	if strings.TrimSpace(p.Gate.Check) == "" {
+		err := errors.New("condition gate has no check command")
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+
+	// An optional duration rate-limits condition plugins so a condition
+	// that stays true does not fire on every patrol.
+	if p.Gate.Duration != "" {
+		cooldown, err := gateDuration(p.Gate.Duration, DefaultCooldown)
+		if err != nil {
+			return GateStatus{Reason: err.Error(), Err: err}
+		}
+		if status := e.cooldownStatus(p, cooldown); !status.Due {
+			return status
+		}
+	}
+
+	timeout, err := gateDuration(p.Gate.Timeout, DefaultCheckTimeout)
+	if err != nil {
+		return GateStatus{Reason: err.Error(), Err: err}
+	}
+	ok, err := RunCheck(ctx, p.Gate.Check, p.Path, timeout)
+	switch {
+	case err != nil:
+		return GateStatus{Reason: err.Error(), Err: err}
+	case ok:
+		return GateStatus{Due: true, Reason: "check passed"}
+	default:
+		return GateStatus{Reason: "check not met"}
+	}
+}
+
+// RunCheck runs a condition gate command with sh -c in dir. It reports true
+// when the command exits 0 and false for any other exit status. An error is
+// returned only if the command could not be run or exceeded timeout.
+func RunCheck(ctx context.Context, check, dir string, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", check) //nolint:gosec // G204: check comes from the plugin definition
+	cmd.Dir = dir
+	// Don't hang on output pipes held open by children that outlive sh.
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, fmt.Errorf("check timed out after %s", timeout)
+	}
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return false, nil
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return false, fmt.Errorf("running check: %s: %w", msg, err)
+	}
+	return false, fmt.Errorf("running check: %w", err)
+}
+
+// ParseSchedule parses the gate's cron schedule in its configured time zone.
+func (g *Gate) ParseSchedule() (*Schedule, error) {
+	if g.Schedule == "" {
+		return nil, errors.New("cron gate has no schedule")
+	}
+	loc := time.Local
+	if g.Timezone != "" {
+		l, err := time.LoadLocation(g.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gate timezone: %w", err)
+		}
+		loc = l
+	}
+	return ParseSchedule(g.Schedule, loc)
+}
+
+// gateDuration parses a gate duration string, returning def when empty.
+func gateDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gate duration %q: %w", s, err)
+	}
+	return d, nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
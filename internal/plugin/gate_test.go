@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHistory is a RunHistory that returns a fixed last run.
+type fakeHistory struct {
+	last *time.Time
+	err  error
+}
+
+func (f *fakeHistory) GetLastRun(string) (*PluginRunBead, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.last == nil {
+		return nil, nil
+	}
+	return &PluginRunBead{ID: "wisp-1", CreatedAt: *f.last, Result: ResultSuccess}, nil
+}
+
+func newTestEvaluator(last *time.Time, now time.Time) *GateEvaluator {
+	e := NewGateEvaluator(&fakeHistory{last: last})
+	e.now = func() time.Time { return now }
+	return e
+}
+
+func at(t time.Time) *time.Time { return &t }
+
+func TestGateEvaluator_Cooldown(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	p := &Plugin{Name: "p", Gate: &Gate{Type: GateCooldown, Duration: "2h"}}
+
+	if s := newTestEvaluator(nil, now).Evaluate(context.Background(), p); !s.Due || s.Reason != "never run" {
+		t.Errorf("never run: %+v", s)
+	}
+	s := newTestEvaluator(at(now.Add(-time.Hour)), now).Evaluate(context.Background(), p)
+	if s.Due || s.NextRun == nil || !s.NextRun.Equal(now.Add(time.Hour)) {
+		t.Errorf("within cooldown: %+v", s)
+	}
+	if s := newTestEvaluator(at(now.Add(-3*time.Hour)), now).Evaluate(context.Background(), p); !s.Due {
+		t.Errorf("cooldown elapsed: %+v", s)
+	}
+}
+
+func TestGateEvaluator_Cron(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	p := &Plugin{Name: "p", Gate: &Gate{Type: GateCron, Schedule: "0 9 * * *", Timezone: "UTC"}}
+
+	// Last ran yesterday at 09:00; today's 09:00 slot has passed.
+	s := newTestEvaluator(at(now.Add(-24*time.Hour-30*time.Minute)), now).Evaluate(context.Background(), p)
+	if !s.Due || !strings.Contains(s.Reason, "2026-03-10 09:00") {
+		t.Errorf("missed slot: %+v", s)
+	}
+
+	// Already ran after today's slot.
+	s = newTestEvaluator(at(now.Add(-20*time.Minute)), now).Evaluate(context.Background(), p)
+	if s.Due || s.NextRun == nil || !s.NextRun.Equal(time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("ran this slot: %+v", s)
+	}
+
+	// Never run: waits for the first slot after it was first seen.
+	e := newTestEvaluator(nil, now)
+	if s := e.Evaluate(context.Background(), p); s.Due || s.NextRun == nil || !s.NextRun.Equal(time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("never run: %+v", s)
+	}
+	e.now = func() time.Time { return now.Add(24 * time.Hour) }
+	if s := e.Evaluate(context.Background(), p); !s.Due {
+		t.Errorf("never run, slot passed since first seen: %+v", s)
+	}
+
+	bad := &Plugin{Name: "bad", Gate: &Gate{Type: GateCron, Schedule: "not cron"}}
+	if s := newTestEvaluator(nil, now).Evaluate(context.Background(), bad); s.Due || s.Err == nil {
+		t.Errorf("bad schedule: %+v", s)
+	}
+}
+
+func TestGateEvaluator_Condition(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+
+	pass := &Plugin{Name: "p", Path: dir, Gate: &Gate{Type: GateCondition, Check: "test -d ."}}
+	if s := newTestEvaluator(nil, now).Evaluate(context.Background(), pass); !s.Due {
+		t.Errorf("passing check: %+v", s)
+	}
+
+	fail := &Plugin{Name: "p", Path: dir, Gate: &Gate{Type: GateCondition, Check: "exit 1"}}
+	if s := newTestEvaluator(nil, now).Evaluate(context.Background(), fail); s.Due || s.Err != nil {
+		t.Errorf("failing check: %+v", s)
+	}
+
+	slow := &Plugin{Name: "p", Path: dir, Gate: &Gate{Type: GateCondition, Check: "sleep 5", Timeout: "100ms"}}
+	start := time.Now()
+	s := newTestEvaluator(nil, now).Evaluate(context.Background(), slow)
+	if s.Due || s.Err == nil || !strings.Contains(s.Reason, "timed out") {
+		t.Errorf("slow check: %+v", s)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("check took %s, timeout not honored", elapsed)
+	}
+
+	// Duration rate-limits a condition that stays true.
+	limited := &Plugin{Name: "p", Path: dir, Gate: &Gate{Type: GateCondition, Check: "true", Duration: "1h"}}
+	if s := newTestEvaluator(at(now.Add(-time.Minute)), now).Evaluate(context.Background(), limited); s.Due {
+		t.Errorf("rate-limited condition: %+v", s)
+	}
+}
+
+func TestGateEvaluator_NotAutoRun(t *testing.T) {
+	now := time.Now()
+	for _, p := range []*Plugin{
+		{Name: "none"},
+		{Name: "manual", Gate: &Gate{Type: GateManual}},
+		{Name: "event", Gate: &Gate{Type: GateEvent, On: "startup"}},
+	} {
+		if s := newTestEvaluator(nil, now).Evaluate(context.Background(), p); s.Due || s.Err != nil {
+			t.Errorf("%s: %+v", p.Name, s)
+		}
+	}
+}
+
+func TestGateEvaluator_HistoryError(t *testing.T) {
+	e := NewGateEvaluator(&fakeHistory{err: errors.New("bd unavailable")})
+	p := &Plugin{Name: "p", Gate: &Gate{Type: GateCooldown}}
+	if s := e.Evaluate(context.Background(), p); s.Due || s.Err == nil {
+		t.Errorf("history error: %+v", s)
+	}
+}
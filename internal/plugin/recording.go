@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // RunResult represents the outcome of a plugin execution.
@@ -213,3 +215,35 @@ func (r *Recorder) CountRunsSince(pluginName string, since string) (int, error)
 	}
 	return len(runs), nil
 }
+
+// baselinesPath is where Recorder keeps gate baselines.
+func (r *Recorder) baselinesPath() string {
+	return filepath.Join(r.townRoot, ".runtime", "plugin-baselines.json")
+}
+
+// Baseline implements BaselineStore. Baselines are kept in the town's
+// .runtime/plugin-baselines.json.
+func (r *Recorder) Baseline(pluginName string, now time.Time) (time.Time, error) {
+	path := r.baselinesPath()
+	baselines := make(map[string]time.Time)
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from trusted townRoot
+	if err != nil && !os.IsNotExist(err) {
+		return time.Time{}, fmt.Errorf("reading plugin baselines: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &baselines); err != nil {
+			return time.Time{}, fmt.Errorf("parsing plugin baselines: %w", err)
+		}
+	}
+	if t, ok := baselines[pluginName]; ok {
+		return t, nil
+	}
+	baselines[pluginName] = now
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return time.Time{}, err
+	}
+	if err := util.AtomicWriteJSON(path, baselines); err != nil {
+		return time.Time{}, fmt.Errorf("writing plugin baselines: %w", err)
+	}
+	return now, nil
+}
@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"testing"
+	"time"
 )
 
 func TestPluginRunRecord(t *testing.T) {
@@ -48,3 +49,18 @@ func TestNewRecorder(t *testing.T) {
 // Integration tests for RecordRun, GetLastRun, GetRunsSince require
 // a working beads installation and are skipped in unit tests.
 // These functions shell out to `bd` commands.
+
+func TestRecorderBaseline(t *testing.T) {
+	r := NewRecorder(t.TempDir())
+	first := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	got, err := r.Baseline("p", first)
+	if err != nil || !got.Equal(first) {
+		t.Fatalf("first Baseline = %v, %v; want %v", got, err, first)
+	}
+	// Later evaluations, e.g. from another gt process, keep the first one.
+	got, err = r.Baseline("p", first.Add(time.Hour))
+	if err != nil || !got.Equal(first) {
+		t.Errorf("second Baseline = %v, %v; want %v", got, err, first)
+	}
+}
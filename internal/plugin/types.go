@@ -67,9 +67,16 @@ type Gate struct {
 	// Schedule is for cron gates (e.g., "0 9 * * *").
 	Schedule string `json:"schedule,omitempty" toml:"schedule,omitempty"`
 
+	// Timezone is the IANA zone for cron gates (e.g., "America/New_York").
+	// Defaults to the local time zone.
+	Timezone string `json:"timezone,omitempty" toml:"timezone,omitempty"`
+
 	// Check is for condition gates (command that returns exit 0 to run).
 	Check string `json:"check,omitempty" toml:"check,omitempty"`
 
+	// Timeout bounds the condition check (e.g., "30s"). Default: 30s.
+	Timeout string `json:"timeout,omitempty" toml:"timeout,omitempty"`
+
 	// On is for event gates (e.g., "startup").
 	On string `json:"on,omitempty" toml:"on,omitempty"`
 }
@@ -85,6 +92,7 @@ const (
 	GateCron GateType = "cron"
 
 	// GateCondition runs if a check command returns exit 0.
+	// An optional Duration acts as a cooldown between runs.
 	GateCondition GateType = "condition"
 
 	// GateEvent runs on specific events (startup, etc).
@@ -13,11 +13,13 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/runtime"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/tracing"
 )
 
 // debugSession logs non-fatal errors during session startup when GT_DEBUG_SESSION=1.
@@ -38,13 +40,21 @@ var (
 type SessionManager struct {
 	tmux *tmux.Tmux
 	rig  *rig.Rig
+	conn connection.Connection
 }
 
 // NewSessionManager creates a new polecat session manager for a rig.
+// For rigs hosted on another machine, t is replaced by the tmux wrapper of
+// the rig's connection so sessions are managed where the polecats live.
 func NewSessionManager(t *tmux.Tmux, r *rig.Rig) *SessionManager {
+	conn := r.Connection()
+	if r.IsRemote() {
+		t = connection.TmuxFor(conn)
+	}
 	return &SessionManager{
 		tmux: t,
 		rig:  r,
+		conn: conn,
 	}
 }
 
@@ -65,6 +75,11 @@ type SessionStartOptions struct {
 	// RuntimeConfigDir is resolved config directory for the runtime account.
 	// If set, this is injected as an environment variable.
 	RuntimeConfigDir string
+
+	// TraceParent is the lifecycle trace of the polecat's work. If set, it
+	// is passed to the agent as TRACEPARENT so gt commands run in the
+	// session join the trace.
+	TraceParent string
 }
 
 // SessionInfo contains information about a running polecat session.
@@ -111,16 +126,16 @@ func (m *SessionManager) polecatDir(polecat string) string {
 func (m *SessionManager) clonePath(polecat string) string {
 	// New structure: polecats/<name>/<rigname>/
 	newPath := filepath.Join(m.rig.Path, "polecats", polecat, m.rig.Name)
-	if info, err := os.Stat(newPath); err == nil && info.IsDir() {
+	if info, err := m.conn.Stat(newPath); err == nil && info.IsDir() {
 		return newPath
 	}
 
 	// Old structure: polecats/<name>/ (backward compat)
 	oldPath := filepath.Join(m.rig.Path, "polecats", polecat)
-	if info, err := os.Stat(oldPath); err == nil && info.IsDir() {
+	if info, err := m.conn.Stat(oldPath); err == nil && info.IsDir() {
 		// Check if this is actually a git worktree (has .git file or dir)
 		gitPath := filepath.Join(oldPath, ".git")
-		if _, err := os.Stat(gitPath); err == nil {
+		if _, err := m.conn.Stat(gitPath); err == nil {
 			return oldPath
 		}
 	}
@@ -132,7 +147,7 @@ func (m *SessionManager) clonePath(polecat string) string {
 // hasPolecat checks if the polecat exists in this rig.
 func (m *SessionManager) hasPolecat(polecat string) bool {
 	polecatPath := m.polecatDir(polecat)
-	info, err := os.Stat(polecatPath)
+	info, err := m.conn.Stat(polecatPath)
 	if err != nil {
 		return false
 	}
@@ -178,9 +193,12 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	// This keeps settings out of the git worktree while allowing runtime to find them
 	// when walking up the tree from workDir (polecats/<name>/<rigname>/).
 	// Each polecat gets isolated settings rather than sharing a single settings file.
+	// Remote rigs are provisioned by the town on their own machine.
 	polecatHomeDir := m.polecatDir(polecat)
-	if err := runtime.EnsureSettingsForRole(polecatHomeDir, "polecat", runtimeConfig); err != nil {
-		return fmt.Errorf("ensuring runtime settings: %w", err)
+	if !m.rig.IsRemote() {
+		if err := runtime.EnsureSettingsForRole(polecatHomeDir, "polecat", runtimeConfig); err != nil {
+			return fmt.Errorf("ensuring runtime settings: %w", err)
+		}
 	}
 
 	// Get fallback info to determine beacon content based on agent capabilities.
@@ -208,6 +226,9 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	if runtimeConfig.Session != nil && runtimeConfig.Session.ConfigDirEnv != "" && opts.RuntimeConfigDir != "" {
 		command = config.PrependEnv(command, map[string]string{runtimeConfig.Session.ConfigDirEnv: opts.RuntimeConfigDir})
 	}
+	if opts.TraceParent != "" {
+		command = config.PrependEnv(command, map[string]string{tracing.EnvTraceParent: opts.TraceParent})
+	}
 
 	// Create session with command directly to avoid send-keys race condition.
 	// See: https://github.com/anthropics/gastown/issues/280
@@ -502,9 +523,7 @@ func (m *SessionManager) resolveBeadsDir(issueID, fallbackDir string) string {
 func (m *SessionManager) validateIssue(issueID, workDir string) error {
 	bdWorkDir := m.resolveBeadsDir(issueID, workDir)
 
-	cmd := exec.Command("bd", "show", issueID, "--json") //nolint:gosec
-	cmd.Dir = bdWorkDir
-	output, err := cmd.Output()
+	output, err := m.bdOutput(bdWorkDir, "show", issueID, "--json")
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrIssueInvalid, issueID)
 	}
@@ -524,15 +543,34 @@ func (m *SessionManager) validateIssue(issueID, workDir string) error {
 	return nil
 }
 
+// bdOutput runs bd in dir on the rig's machine and returns its stdout.
+func (m *SessionManager) bdOutput(dir string, args ...string) ([]byte, error) {
+	if m.rig.IsRemote() {
+		// Remote stderr can't be separated over the connection; silence it
+		// so warnings don't corrupt JSON output.
+		script := "cd \"$1\" && shift && exec bd \"$@\" 2>/dev/null"
+		return m.conn.Exec("sh", append([]string{"-c", script, "sh", dir}, args...)...)
+	}
+	cmd := exec.Command("bd", args...) //nolint:gosec
+	cmd.Dir = dir
+	return cmd.Output()
+}
+
 // hookIssue pins an issue to a polecat's hook using bd update.
 func (m *SessionManager) hookIssue(issueID, agentID, workDir string) error {
 	bdWorkDir := m.resolveBeadsDir(issueID, workDir)
 
-	cmd := exec.Command("bd", "update", issueID, "--status=hooked", "--assignee="+agentID) //nolint:gosec
-	cmd.Dir = bdWorkDir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("bd update failed: %w", err)
+	if m.rig.IsRemote() {
+		if out, err := m.conn.ExecDir(bdWorkDir, "bd", "update", issueID, "--status=hooked", "--assignee="+agentID); err != nil {
+			return fmt.Errorf("bd update failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	} else {
+		cmd := exec.Command("bd", "update", issueID, "--status=hooked", "--assignee="+agentID) //nolint:gosec
+		cmd.Dir = bdWorkDir
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("bd update failed: %w", err)
+		}
 	}
 	fmt.Printf("✓ Hooked issue %s to %s\n", issueID, agentID)
 	return nil
@@ -131,6 +131,32 @@ func formatMergeFailedBody(p MergeFailedPayload) string {
 	return sb.String()
 }
 
+// FormatChecks formats per-stage check results as a MERGE_FAILED body
+// section. Each stage is an indented "- name: status (detail)" line,
+// followed by its log tail with lines prefixed "| ". Returns "" if there
+// are no results.
+func FormatChecks(results []CheckResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Checks:\n")
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("  - %s: %s", r.Name, r.Status))
+		if r.Detail != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", r.Detail))
+		}
+		sb.WriteString("\n")
+		if r.LogTail == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(r.LogTail, "\n"), "\n") {
+			sb.WriteString("    | " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
 // NewReworkRequestMessage creates a REWORK_REQUEST protocol message.
 // Sent by Refinery to Witness when a branch needs rebasing due to conflicts.
 func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, conflictFiles []string) *mail.Message {
@@ -234,6 +260,7 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 		TargetBranch: parseField(body, "Target"),
 		FailureType:  parseField(body, "Failure-Type"),
 		Error:        parseField(body, "Error"),
+		Checks:       parseSection(body, "Checks"),
 	}
 
 	// Parse timestamp
@@ -286,3 +313,22 @@ func parseField(body, key string) string {
 
 	return ""
 }
+
+// parseSection extracts an indented section that starts with a "Key:"
+// line and runs until the next unindented line. The two-space section
+// indent is removed from each line.
+func parseSection(body, key string) string {
+	var lines []string
+	in := false
+	for _, line := range strings.Split(body, "\n") {
+		if in {
+			if !strings.HasPrefix(line, " ") {
+				break
+			}
+			lines = append(lines, strings.TrimPrefix(line, "  "))
+			continue
+		}
+		in = strings.TrimSpace(line) == key+":"
+	}
+	return strings.Join(lines, "\n")
+}
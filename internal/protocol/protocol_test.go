@@ -136,6 +136,32 @@ func TestNewMergeFailedMessage(t *testing.T) {
 	}
 }
 
+func TestFormatChecks_RoundTrip(t *testing.T) {
+	msg := NewMergeFailedMessage("gastown", "nux", "polecat/nux/gt-abc", "gt-abc", "main", "tests", "checks failed: unit")
+	msg.Body += FormatChecks([]CheckResult{
+		{Name: "build", Status: "passed"},
+		{Name: "unit", Status: "failed", Detail: "exit status 1", LogTail: "--- FAIL: TestWidget\nFAIL\n"},
+		{Name: "e2e", Status: "blocked", Detail: "needs unit"},
+	})
+	msg.Body += "Traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\n"
+
+	payload := ParseMergeFailedPayload(msg.Body)
+	want := `- build: passed
+- unit: failed (exit status 1)
+  | --- FAIL: TestWidget
+  | FAIL
+- e2e: blocked (needs unit)`
+	if payload.Checks != want {
+		t.Errorf("Checks =\n%s\nwant\n%s", payload.Checks, want)
+	}
+	if payload.Error != "checks failed: unit" {
+		t.Errorf("Error = %q, want %q", payload.Error, "checks failed: unit")
+	}
+	if FormatChecks(nil) != "" {
+		t.Error("FormatChecks(nil) should be empty")
+	}
+}
+
 func TestNewReworkRequestMessage(t *testing.T) {
 	conflicts := []string{"file1.go", "file2.go"}
 	msg := NewReworkRequestMessage("gastown", "nux", "polecat/nux/gt-abc", "gt-abc", "main", conflicts)
@@ -135,6 +135,25 @@ type MergeFailedPayload struct {
 
 	// TargetBranch is the branch we tried to merge into.
 	TargetBranch string `json:"target_branch"`
+
+	// Checks is the per-stage check report (see FormatChecks), without
+	// the "Checks:" header or its indentation. Empty if no checks ran.
+	Checks string `json:"checks,omitempty"`
+}
+
+// CheckResult is one pre-merge check stage as reported in MERGE_FAILED.
+type CheckResult struct {
+	// Name is the stage name from merge_queue.checks.
+	Name string `json:"name"`
+
+	// Status is passed, failed, skipped or blocked.
+	Status string `json:"status"`
+
+	// Detail is a short explanation (error, skip or block reason).
+	Detail string `json:"detail,omitempty"`
+
+	// LogTail holds the last lines of a failed stage's output.
+	LogTail string `json:"log_tail,omitempty"`
 }
 
 // ReworkRequestPayload contains the data for a REWORK_REQUEST message.
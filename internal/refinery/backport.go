@@ -0,0 +1,323 @@
+// This file contains backports: landing a merged MR on release branches
+// after its own target.
+
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// BackportLabelPrefix starts a source issue label requesting a backport,
+// e.g. "backport:release/1.2".
+const BackportLabelPrefix = "backport:"
+
+// Backport statuses, recorded per target in the MR's backport_status field.
+const (
+	BackportPending  = "pending"
+	BackportMerged   = "merged"
+	BackportConflict = "conflict"
+	BackportFailed   = "failed"
+)
+
+// BackportResult is the outcome of backporting an MR to one branch.
+type BackportResult struct {
+	Target string
+	Status string
+	Commit string // cherry-picked commit on Target (merged only)
+	Task   string // conflict-resolution task (conflict only)
+	Error  string
+	Stages []StageResult
+}
+
+// splitBackports parses a backports field ("release/1.2, release/1.3").
+func splitBackports(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// parseBackportStatus returns the status of each backport, given an MR's
+// backports and backport_status fields. Requested backports without a
+// status are pending.
+func parseBackportStatus(requested, status string) map[string]string {
+	statuses := make(map[string]string)
+	for _, t := range splitBackports(requested) {
+		statuses[t] = BackportPending
+	}
+	for _, pair := range strings.Fields(status) {
+		if t, s, ok := strings.Cut(pair, "="); ok {
+			statuses[t] = s
+		}
+	}
+	if len(statuses) == 0 {
+		return nil
+	}
+	return statuses
+}
+
+// backportTargets returns the branches mr should be backported to: those
+// in its backports field and in the source issue's backport: labels that
+// match BackportBranches, in order, without duplicates or mr's own target.
+func (e *Engineer) backportTargets(mr *MRInfo) []string {
+	requested := append([]string(nil), mr.Backports...)
+	if mr.SourceIssue != "" {
+		if issue, err := e.beads.Show(mr.SourceIssue); err == nil {
+			for _, label := range issue.Labels {
+				if t, ok := strings.CutPrefix(label, BackportLabelPrefix); ok {
+					requested = append(requested, t)
+				}
+			}
+		}
+	}
+
+	var targets []string
+	for _, t := range requested {
+		if t == mr.Target || slices.Contains(targets, t) {
+			continue
+		}
+		if !e.isBackportBranch(t) {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: not backporting %s to %s: not a backport branch %v\n", mr.ID, t, e.config.BackportBranches)
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+func (e *Engineer) isBackportBranch(branch string) bool {
+	for _, pattern := range e.config.BackportBranches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessBackports lands mr, already merged to its target as commit (or
+// a range of commits, see ProcessResult.landed), on each of its backport
+// targets: the commit is cherry-picked onto the target, the checks run
+// there, and the result is pushed. Targets are independent; a conflict
+// creates a resolution task for that target only.
+// Returns one result per target (nil if the MR requests no backports).
+func (e *Engineer) ProcessBackports(ctx context.Context, mr *MRInfo, commit string) []BackportResult {
+	targets := e.backportTargets(mr)
+	var results []BackportResult
+	for _, target := range targets {
+		var r BackportResult
+		if e.prMode() {
+			r = BackportResult{Target: target, Status: BackportFailed, Error: `backports are not supported in merge_mode "pr"`}
+		} else {
+			r = e.backport(ctx, mr, commit, target)
+		}
+		if r.Status == BackportMerged {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Backported %s to %s (commit: %s)\n", mr.ID, target, shortSHA(r.Commit))
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Backport of %s to %s %s: %s\n", mr.ID, target, r.Status, r.Error)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// backport cherry-picks commit onto target in a scratch worktree, runs
+// the checks and pushes the result.
+func (e *Engineer) backport(ctx context.Context, mr *MRInfo, commit, target string) BackportResult {
+	r := BackportResult{Target: target, Status: BackportFailed}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Backporting %s to %s...\n", mr.ID, target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		r.Error = fmt.Sprintf("failed to fetch origin/%s: %v", target, err)
+		return r
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		r.Error = fmt.Sprintf("failed to resolve origin/%s: %v", target, err)
+		return r
+	}
+
+	dir := filepath.Join(e.trainDir(), "backport-"+mr.ID+"-"+strings.ReplaceAll(target, "/", "-"))
+	e.removeScratchWorktree(dir) // leftovers from an interrupted run
+	if err := os.MkdirAll(e.trainDir(), 0755); err != nil {
+		r.Error = fmt.Sprintf("creating train directory: %v", err)
+		return r
+	}
+	if err := e.git.WorktreeAddDetached(dir, base); err != nil {
+		r.Error = fmt.Sprintf("creating worktree: %v", err)
+		return r
+	}
+	defer func() {
+		e.removeScratchWorktree(dir)
+		_ = e.git.WorktreePrune()
+	}()
+
+	wt := git.NewGit(dir)
+	if err := wt.CherryPick(commit); err != nil {
+		conflicts, conflictErr := wt.GetConflictingFiles()
+		_ = wt.AbortCherryPick()
+		if conflictErr != nil || len(conflicts) == 0 {
+			r.Error = fmt.Sprintf("cherry-pick failed: %v", err)
+			return r
+		}
+		r.Status = BackportConflict
+		r.Error = fmt.Sprintf("cherry-pick conflicts in: %v", conflicts)
+		task, err := e.createBackportConflictTask(mr, commit, target, base, conflicts)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to create backport conflict task: %v\n", err)
+		} else {
+			r.Task = task
+		}
+		return r
+	}
+	head, err := wt.Rev("HEAD")
+	if err != nil {
+		r.Error = fmt.Sprintf("failed to get cherry-picked commit SHA: %v", err)
+		return r
+	}
+
+	if len(e.checkStages()) > 0 {
+		changed, err := e.git.ChangedFiles(base, head)
+		if err != nil {
+			changed = nil // run every stage
+		}
+		result := e.runChecks(ctx, dir, changed, e.output)
+		r.Stages = result.Stages
+		if !result.Success {
+			r.Error = result.Error
+			return r
+		}
+	}
+
+	if err := e.git.PushCommit("origin", head, target); err != nil {
+		r.Error = fmt.Sprintf("failed to push to origin: %v", err)
+		return r
+	}
+	r.Status, r.Commit = BackportMerged, head
+	return r
+}
+
+// createBackportConflictTask creates a task to backport mr to target by
+// hand, since its merge commit doesn't cherry-pick cleanly.
+func (e *Engineer) createBackportConflictTask(mr *MRInfo, commit, target, base string, conflicts []string) (string, error) {
+	originalTitle := mr.SourceIssue
+	if mr.SourceIssue != "" {
+		if sourceIssue, err := e.beads.Show(mr.SourceIssue); err == nil && sourceIssue != nil {
+			originalTitle = sourceIssue.Title
+		}
+	}
+	branch := "backport/" + target + "/" + mr.ID
+	cherryPick := "git cherry-pick -x " + commit
+	if e.git.IsMergeCommit(commit) {
+		cherryPick = "git cherry-pick -x -m 1 " + commit
+	}
+
+	description := fmt.Sprintf(`Backport %s to %s: its merge commit conflicts with the branch
+
+## Metadata
+- Original MR: %s
+- Landed as: %s
+- Backport target: %s@%s
+- Conflicts in: %s
+- Original issue: %s
+
+## Instructions
+1. Branch from the target: git checkout -b %s origin/%s
+2. Cherry-pick the merge: %s
+3. Resolve conflicts in your editor
+4. Complete the cherry-pick: git add . && git cherry-pick --continue
+5. Submit it: gt mq submit --branch %s --issue %s --target %s
+6. Close this task: bd close <this-task-id>`,
+		mr.ID, target,
+		mr.ID,
+		commit,
+		target, shortSHA(base),
+		strings.Join(conflicts, ", "),
+		mr.SourceIssue,
+		branch, target,
+		cherryPick,
+		branch, mr.SourceIssue, target,
+	)
+	if mr.TraceParent != "" {
+		description += "\n\ntraceparent: " + mr.TraceParent
+	}
+
+	task, err := e.beads.Create(beads.CreateOptions{
+		Title:       fmt.Sprintf("Resolve backport conflicts (%s): %s", target, originalTitle),
+		Type:        "task",
+		Priority:    mr.Priority,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating backport conflict task: %w", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Created backport conflict task: %s (P%d)\n", task.ID, task.Priority)
+	return task.ID, nil
+}
+
+// backportsHeading starts the MR bead description section detailing each
+// backport.
+const backportsHeading = "## Backports"
+
+// recordBackports stores the backport results on the MR bead: a
+// backport_status field with each target's status, and a "## Backports"
+// section with the details.
+func (e *Engineer) recordBackports(mrID string, results []BackportResult) {
+	if mrID == "" || len(results) == 0 {
+		return
+	}
+	mrBead, err := e.beads.Show(mrID)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to fetch MR bead %s: %v\n", mrID, err)
+		return
+	}
+	mrFields := beads.ParseMRFields(mrBead)
+	if mrFields == nil {
+		mrFields = &beads.MRFields{}
+	}
+	mrFields.BackportStatus = backportSummary(results)
+	newDesc := beads.SetMRFields(mrBead, mrFields)
+	newDesc = replaceSection(newDesc, backportsHeading, formatBackportsSection(results))
+	if err := e.beads.Update(mrID, beads.UpdateOptions{Description: &newDesc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record backports on %s: %v\n", mrID, err)
+	}
+}
+
+// backportSummary formats backport results as the MR bead's
+// backport_status field.
+func backportSummary(results []BackportResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, r.Target+"="+r.Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatBackportsSection formats one line per backport, followed by the
+// log tails of its failed check stages.
+func formatBackportsSection(results []BackportResult) string {
+	var sb strings.Builder
+	sb.WriteString(backportsHeading)
+	for _, r := range results {
+		switch r.Status {
+		case BackportMerged:
+			sb.WriteString(fmt.Sprintf("\n- %s: merged as %s", r.Target, shortSHA(r.Commit)))
+		case BackportConflict:
+			sb.WriteString(fmt.Sprintf("\n- %s: conflict (%s)", r.Target, r.Error))
+			if r.Task != "" {
+				sb.WriteString("; resolution task " + r.Task)
+			}
+		default:
+			sb.WriteString(fmt.Sprintf("\n- %s: %s (%s)", r.Target, r.Status, r.Error))
+		}
+		if log := formatFailedStages(r.Stages); log != "" {
+			sb.WriteString("\n" + log)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,157 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// releaseBranch creates branch on origin from main, optionally with an
+// extra commit writing name=content.
+func releaseBranch(t *testing.T, e *Engineer, branch, name, content string) {
+	t.Helper()
+	gitRun(t, e.workDir, "checkout", "-q", "-b", branch, "main")
+	if name != "" {
+		writeFile(t, e.workDir, name, content)
+		gitRun(t, e.workDir, "add", ".")
+		gitRun(t, e.workDir, "commit", "-q", "-m", "release: "+name)
+	}
+	gitRun(t, e.workDir, "push", "-q", "origin", branch)
+	gitRun(t, e.workDir, "checkout", "-q", "main")
+}
+
+// landMR merges mr on main and returns its merge commit.
+func landMR(t *testing.T, e *Engineer, mr *MRInfo) string {
+	t.Helper()
+	cars := e.ProcessTrain(context.Background(), []*MRInfo{mr})
+	if !cars[0].Result.Success {
+		t.Fatalf("%s did not land: %+v", mr.ID, cars[0].Result)
+	}
+	return cars[0].Result.MergeCommit
+}
+
+func TestProcessBackports_PerTargetStatus(t *testing.T) {
+	e, origin := trainRepo(t)
+	useNativeBeads(t, e)
+	releaseBranch(t, e, "release/1.1", "README", "release 1.1\n") // conflicts
+	releaseBranch(t, e, "release/1.2", "", "")                    // clean
+	releaseBranch(t, e, "release/1.3", "broken", "x\n")           // fails its checks
+	before13 := gitRun(t, origin, "rev-parse", "release/1.3")
+
+	mr := mrBranch(t, e, "fix", "README", "fixed\n")
+	mr.Backports = []string{"release/1.1", "release/1.2", "release/1.3"}
+	commit := landMR(t, e, mr)
+
+	results := e.ProcessBackports(context.Background(), mr, commit)
+	if got := backportSummary(results); got != "release/1.1=conflict release/1.2=merged release/1.3=failed" {
+		t.Fatalf("backports = %s", got)
+	}
+
+	// release/1.2 gets the cherry-picked fix.
+	if head := gitRun(t, origin, "rev-parse", "release/1.2"); head != results[1].Commit {
+		t.Errorf("origin/release/1.2 = %s, want %s", head, results[1].Commit)
+	}
+	if msg := gitRun(t, origin, "log", "-1", "--format=%B", "release/1.2"); !strings.Contains(msg, "(cherry picked from commit "+commit+")") {
+		t.Errorf("backport message:\n%s", msg)
+	}
+
+	// release/1.1 gets a conflict task instead.
+	task, err := e.beads.Show(results[0].Task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(task.Title, "release/1.1") || !strings.Contains(task.Description, "git cherry-pick -x "+commit) {
+		t.Errorf("conflict task = %q\n%s", task.Title, task.Description)
+	}
+
+	// release/1.3 fails its checks and is left alone.
+	if head := gitRun(t, origin, "rev-parse", "release/1.3"); head != before13 {
+		t.Errorf("failed backport pushed to release/1.3")
+	}
+	if len(results[2].Stages) == 0 || results[2].Stages[0].Status != StageFailed {
+		t.Errorf("release/1.3 stages = %+v", results[2].Stages)
+	}
+}
+
+func TestProcessBackports_MergeCommitConflictTask(t *testing.T) {
+	e, _ := trainRepo(t)
+	useNativeBeads(t, e)
+	e.config.MergeStrategy = config.MergeStrategyMerge
+	releaseBranch(t, e, "release/1.1", "README", "release 1.1\n")
+
+	mr := mrBranch(t, e, "fix", "README", "fixed\n")
+	mr.Backports = []string{"release/1.1"}
+	commit := landMR(t, e, mr)
+
+	results := e.ProcessBackports(context.Background(), mr, commit)
+	if got := backportSummary(results); got != "release/1.1=conflict" {
+		t.Fatalf("backports = %s", got)
+	}
+	task, err := e.beads.Show(results[0].Task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(task.Description, "git cherry-pick -x -m 1 "+commit) {
+		t.Errorf("conflict task should cherry-pick the merge against its first parent:\n%s", task.Description)
+	}
+}
+
+func TestBackportTargets(t *testing.T) {
+	e, _ := trainRepo(t)
+	useNativeBeads(t, e)
+	issue, err := e.beads.Create(beads.CreateOptions{Title: "fix", Type: "bug", Priority: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.beads.Update(issue.ID, beads.UpdateOptions{AddLabels: []string{"backport:release/1.3", "backport:release/1.2", "backport:hotfix", "urgent"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := &MRInfo{ID: "mr-fix", Target: "main", SourceIssue: issue.ID, Backports: []string{"release/1.2", "main"}}
+	if got := strings.Join(e.backportTargets(mr), " "); got != "release/1.2 release/1.3" {
+		t.Errorf("targets = %s", got)
+	}
+
+	if got := splitBackports("release/1.2, release/1.3,,"); strings.Join(got, " ") != "release/1.2 release/1.3" {
+		t.Errorf("splitBackports = %q", got)
+	}
+
+	statuses := parseBackportStatus("release/1.2,release/1.3", "release/1.2=merged")
+	if len(statuses) != 2 || statuses["release/1.2"] != BackportMerged || statuses["release/1.3"] != BackportPending {
+		t.Errorf("parseBackportStatus = %v", statuses)
+	}
+}
+
+func TestProcessQueue_RecordsBackports(t *testing.T) {
+	e, origin := trainRepo(t)
+	useNativeBeads(t, e)
+	releaseBranch(t, e, "release/1.2", "", "")
+	mrBranch(t, e, "fix", "fix.txt", "fix\n")
+	mrBead, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Merge: fix",
+		Type:        "merge-request",
+		Priority:    1,
+		Description: beads.FormatMRFields(&beads.MRFields{Branch: "fix", Target: "main", Backports: "release/1.2"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if merged, err := e.ProcessQueue(context.Background()); err != nil || merged != 1 {
+		t.Fatalf("ProcessQueue = %d, %v", merged, err)
+	}
+	if msg := gitRun(t, origin, "log", "-1", "--format=%s", "release/1.2"); msg != "feat: fix" {
+		t.Errorf("origin/release/1.2 tip = %q", msg)
+	}
+	mrBead, err = e.beads.Show(mrBead.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := beads.ParseMRFields(mrBead)
+	if fields == nil || fields.BackportStatus != "release/1.2=merged" || !strings.Contains(mrBead.Description, backportsHeading+"\n- release/1.2: merged as ") {
+		t.Errorf("MR bead:\n%s", mrBead.Description)
+	}
+}
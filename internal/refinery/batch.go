@@ -0,0 +1,205 @@
+// This file contains batched merging, used when MergeQueueConfig.BatchSize
+// is greater than one.
+
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ProcessBatch merges mrs, which must share a target branch and be in
+// merge order (see NextTrain), as a single batch.
+//
+// The MRs are merged with the configured strategy one after another into
+// a chain of commits on the target, and only the tip of the chain is
+// tested. If it passes, the target is fast-forwarded to the tip and every
+// MR lands. If it fails, the chain is bisected for the first failing
+// prefix: the MR that ends it is the culprit and fails alone, the MRs
+// ahead of it are known good, and the MRs behind it are rebuilt without
+// it and tested again. So a batch of K MRs with one bad MR costs about
+// log2(K)+2 test runs instead of K.
+//
+// Returns one TrainCar per MR with its result. If the push fails or the
+// run is canceled, the MRs that would have landed are Invalidated so they
+// go back to the queue.
+func (e *Engineer) ProcessBatch(ctx context.Context, mrs []*MRInfo) []*TrainCar {
+	cars := make([]*TrainCar, len(mrs))
+	for i, mr := range mrs {
+		cars[i] = &TrainCar{MR: mr}
+	}
+	if len(cars) == 0 {
+		return cars
+	}
+	target := mrs[0].Target
+
+	defer e.cleanupTrain(cars)
+	defer e.startCarSpans(cars, "gt.batch_size")()
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Building batch of %d MR(s) for %s\n", len(cars), target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", target, err)
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		for _, car := range cars {
+			car.Result = ProcessResult{FailureType: FailureFetch, Error: fmt.Sprintf("failed to resolve origin/%s: %v", target, err)}
+		}
+		return cars
+	}
+
+	// pending is the chain still to be landed; its first verified cars are
+	// known to pass (the target itself is assumed to).
+	pending := e.buildChain(cars, base)
+	verified := 0
+	for verified < len(pending) {
+		tip := len(pending) - 1
+		result := e.testBatchPrefix(ctx, pending, tip, base)
+		if ctx.Err() != nil {
+			invalidate(pending, result.Error)
+			return cars
+		}
+		if result.Success {
+			verified = len(pending)
+			break
+		}
+
+		// Bisect for the first failing prefix. lo is the last car known
+		// to pass (-1 for the target), hi the first car known to fail.
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Batch failed at %s; bisecting %d MR(s)\n", pending[tip].MR.ID, tip-verified+1)
+		lo, hi := verified-1, tip
+		failed := result
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			r := e.testBatchPrefix(ctx, pending, mid, base)
+			if ctx.Err() != nil {
+				invalidate(pending, r.Error)
+				return cars
+			}
+			if r.Success {
+				lo = mid
+			} else {
+				hi, failed = mid, r
+			}
+		}
+
+		culprit := pending[hi]
+		culprit.Result = failed
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Batch culprit: %s (%s)\n", culprit.MR.ID, failed.Error)
+
+		// Cars ahead of the culprit passed as a prefix; rebuild the cars
+		// behind it without it.
+		rest := pending[hi+1:]
+		pending = pending[:hi]
+		verified = hi
+		tipCommit := base
+		if hi > 0 {
+			tipCommit = pending[hi-1].Commit
+		}
+		pending = append(pending, e.rebuildChain(rest, tipCommit)...)
+	}
+
+	if len(pending) == 0 {
+		return cars
+	}
+	tipCommit := pending[len(pending)-1].Commit
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch: pushing %s (%d MR(s)) to origin/%s...\n", shortSHA(tipCommit), len(pending), target)
+	if err := e.git.PushCommit("origin", tipCommit, target); err != nil {
+		invalidate(pending, fmt.Sprintf("failed to push batch to origin: %v", err))
+		return cars
+	}
+	for _, car := range pending {
+		car.Result.Success = true
+		car.Result.MergeCommit = car.Commit
+		car.Result.LandedFrom = e.landedFrom(car.MR, car.Base)
+		car.Result.ConflictResolution = car.resolution
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch: merged %d MR(s) at %s\n", len(pending), shortSHA(tipCommit))
+	return cars
+}
+
+// buildChain merges each car on top of the previous one, starting
+// at base. Cars that cannot be built (missing branch, conflict) get a
+// failure result and are left out of the returned chain.
+func (e *Engineer) buildChain(cars []*TrainCar, base string) []*TrainCar {
+	var chain []*TrainCar
+	var ahead *TrainCar
+	for _, car := range cars {
+		car.Base = base
+		car.ahead = ahead
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Batch: %s (%s) on %s\n", car.MR.ID, car.MR.Branch, shortSHA(base))
+		if err := e.buildCar(car); err != nil {
+			car.Result = *err
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Batch: %s: %s\n", car.MR.ID, car.Result.Error)
+			continue
+		}
+		chain = append(chain, car)
+		base = car.Commit
+		ahead = car
+	}
+	return chain
+}
+
+// rebuildChain discards the cars' worktrees and builds them again on base.
+func (e *Engineer) rebuildChain(cars []*TrainCar, base string) []*TrainCar {
+	for _, car := range cars {
+		if car.workDir != "" {
+			e.removeScratchWorktree(car.workDir)
+		}
+		car.workDir, car.Commit, car.resolution = "", "", ""
+		car.Result = ProcessResult{}
+	}
+	return e.buildChain(cars, base)
+}
+
+// testBatchPrefix runs the checks on chain[i], which holds chain[0..i]
+// merged onto base. A passing result is recorded on every car of the
+// prefix; only chain[i] keeps the parsed test results, so the run counts
+// once in the test history.
+func (e *Engineer) testBatchPrefix(ctx context.Context, chain []*TrainCar, i int, base string) ProcessResult {
+	car := chain[i]
+	if len(e.checkStages()) == 0 {
+		return ProcessResult{Success: true}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch: testing %d MR(s) through %s\n", i+1, car.MR.ID)
+	changed, err := e.git.ChangedFiles(base, car.Commit)
+	if err != nil {
+		changed = nil // run every stage
+	}
+	span := car.span.Start("refinery.tests")
+	span.SetAttr("gt.batch_prefix", i+1)
+	result := e.runChecks(ctx, car.workDir, changed, &car.Log)
+	if !result.Success {
+		span.SetError(errors.New(result.Error))
+	}
+	span.End()
+
+	if result.Success {
+		for j, c := range chain[:i+1] {
+			c.Result = result
+			if j < i {
+				c.Result.Stages = withoutTests(result.Stages)
+			}
+		}
+	}
+	return result
+}
+
+// withoutTests returns a copy of stages without their parsed test results.
+func withoutTests(stages []StageResult) []StageResult {
+	out := make([]StageResult, len(stages))
+	for i, s := range stages {
+		s.Tests, s.Flaky = nil, nil
+		out[i] = s
+	}
+	return out
+}
+
+// invalidate marks cars as Invalidated so they return to the queue.
+func invalidate(cars []*TrainCar, reason string) {
+	for _, car := range cars {
+		car.Invalidated = true
+		car.Result = ProcessResult{Error: reason}
+	}
+}
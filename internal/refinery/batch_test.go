@@ -0,0 +1,157 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countTestRuns makes every check run append a line to a counter file and
+// returns a func reporting the number of runs so far.
+func countTestRuns(t *testing.T, e *Engineer) func() int {
+	t.Helper()
+	counter := filepath.Join(t.TempDir(), "runs")
+	e.config.TestCommand = "echo run >> " + counter + " && test ! -f broken"
+	return func() int {
+		data, err := os.ReadFile(counter)
+		if err != nil {
+			return 0
+		}
+		return strings.Count(string(data), "run\n")
+	}
+}
+
+func TestProcessBatch_AllPassTestsOnce(t *testing.T) {
+	e, origin := trainRepo(t)
+	runs := countTestRuns(t, e)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+		mrBranch(t, e, "d", "d.txt", "d\n"),
+	}
+
+	cars := e.ProcessBatch(context.Background(), mrs)
+	for _, car := range cars {
+		if !car.Result.Success || car.Result.MergeCommit != car.Commit {
+			t.Fatalf("%s: result = %+v", car.MR.ID, car.Result)
+		}
+	}
+	if n := runs(); n != 1 {
+		t.Errorf("test runs = %d, want 1", n)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[3].Commit {
+		t.Errorf("origin/main = %s, want batch tip %s", head, cars[3].Commit)
+	}
+	if log := gitRun(t, origin, "log", "--format=%s", "main"); log != "feat: d\nfeat: c\nfeat: b\nfeat: a\nbase" {
+		t.Errorf("origin history:\n%s", log)
+	}
+	// Only the tip keeps the run's parsed tests, so history counts it once.
+	if got := len(cars[0].Result.Stages); got != 1 {
+		t.Errorf("cars[0] stages = %d, want 1", got)
+	}
+}
+
+func TestProcessBatch_BisectsToCulprit(t *testing.T) {
+	e, origin := trainRepo(t)
+	runs := countTestRuns(t, e)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+		mrBranch(t, e, "bad", "broken", "x\n"),
+		mrBranch(t, e, "d", "d.txt", "d\n"),
+		mrBranch(t, e, "e", "e.txt", "e\n"),
+	}
+
+	cars := e.ProcessBatch(context.Background(), mrs)
+	for i, car := range cars {
+		if i == 2 {
+			if car.Result.Success || car.Invalidated || car.Result.FailureType != FailureTestsFail {
+				t.Errorf("culprit: invalidated=%v result=%+v", car.Invalidated, car.Result)
+			}
+			continue
+		}
+		if !car.Result.Success || car.Invalidated {
+			t.Errorf("%s should land: invalidated=%v result=%+v", car.MR.ID, car.Invalidated, car.Result)
+		}
+	}
+	// tip of 5 fails, bisect tests 2 then 3, rebuilt tip of 4 passes
+	if n := runs(); n != 4 {
+		t.Errorf("test runs = %d, want 4", n)
+	}
+	if log := gitRun(t, origin, "log", "--format=%s", "main"); log != "feat: e\nfeat: d\nfeat: b\nfeat: a\nbase" {
+		t.Errorf("origin history:\n%s", log)
+	}
+	if cars[3].Base != cars[1].Commit {
+		t.Errorf("MR behind culprit should be rebuilt on %s, got %s", cars[1].Commit, cars[3].Base)
+	}
+}
+
+func TestProcessBatch_MultipleCulprits(t *testing.T) {
+	e, origin := trainRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "bad1", "broken", "1\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+		mrBranch(t, e, "bad2", "broken", "2\n"),
+		mrBranch(t, e, "d", "d.txt", "d\n"),
+	}
+
+	cars := e.ProcessBatch(context.Background(), mrs)
+	var landed, failed []string
+	for _, car := range cars {
+		if car.Result.Success {
+			landed = append(landed, car.MR.Branch)
+		} else {
+			failed = append(failed, car.MR.Branch)
+		}
+	}
+	if strings.Join(landed, ",") != "b,d" || strings.Join(failed, ",") != "bad1,bad2" {
+		t.Errorf("landed %v, failed %v", landed, failed)
+	}
+	if log := gitRun(t, origin, "log", "--format=%s", "main"); log != "feat: d\nfeat: b\nbase" {
+		t.Errorf("origin history:\n%s", log)
+	}
+}
+
+func TestProcessBatch_ConflictFailsOnlyThatMR(t *testing.T) {
+	e, origin := trainRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "README", "from a\n"),
+		mrBranch(t, e, "b", "README", "from b\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+	}
+
+	cars := e.ProcessBatch(context.Background(), mrs)
+	if !cars[0].Result.Success || !cars[2].Result.Success {
+		t.Errorf("non-conflicting MRs should land: %+v / %+v", cars[0].Result, cars[2].Result)
+	}
+	if !cars[1].Result.Conflict || cars[1].Invalidated {
+		t.Errorf("conflicting MR: %+v", cars[1].Result)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[2].Commit {
+		t.Errorf("origin/main = %s, want %s", head, cars[2].Commit)
+	}
+}
+
+func TestProcessBatch_CanceledRequeues(t *testing.T) {
+	e, origin := trainRepo(t)
+	before := gitRun(t, origin, "rev-parse", "main")
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cars := e.ProcessBatch(ctx, mrs)
+	for _, car := range cars {
+		if car.Result.Success || !car.Invalidated {
+			t.Errorf("%s: invalidated=%v result=%+v", car.MR.ID, car.Invalidated, car.Result)
+		}
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != before {
+		t.Errorf("origin/main moved to %s on a canceled batch", head)
+	}
+}
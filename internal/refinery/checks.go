@@ -0,0 +1,464 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/protocol"
+)
+
+// Check stage statuses.
+const (
+	StagePassed  = "passed"
+	StageFailed  = "failed"
+	StageSkipped = "skipped" // no changed file matched the stage's paths
+	StageBlocked = "blocked" // a stage it needs failed or was blocked
+)
+
+// Log tail limits for failed stages, kept small enough for mail and beads.
+const (
+	logTailLines = 20
+	logTailBytes = 2000
+)
+
+// StageResult is the outcome of one pre-merge check stage.
+type StageResult struct {
+	Name     string
+	Status   string
+	Optional bool
+	Attempts int
+	Duration time.Duration
+
+	// Reason explains a failed, skipped or blocked stage, and notes
+	// flaky or quarantined tests.
+	Reason string
+
+	// LogTail holds the last lines of output of a failed stage.
+	LogTail string
+
+	// Tests are the parsed test results of the last attempt, if the
+	// stage produced go test -json output or JUnit reports.
+	Tests []TestOutcome
+
+	// Flaky lists tests that failed in one attempt and passed in a later
+	// one, with the output of the failure.
+	Flaky []TestOutcome
+
+	// Quarantined lists quarantined tests whose failures were ignored.
+	Quarantined []string
+}
+
+// checkStages returns the check stages to run before merging, or nil if
+// tests are disabled. Without merge_queue.checks, the legacy test_command
+// runs as a single "test" stage with retry_flaky_tests attempts.
+func (e *Engineer) checkStages() []config.CheckStage {
+	if !e.config.RunTests {
+		return nil
+	}
+	if len(e.config.Checks) > 0 {
+		return e.config.Checks
+	}
+	if e.config.TestCommand == "" {
+		return nil
+	}
+	retries := e.config.RetryFlakyTests - 1
+	if retries < 0 {
+		retries = 0
+	}
+	return []config.CheckStage{{Name: "test", Command: e.config.TestCommand, Retries: retries}}
+}
+
+// runChecks runs the configured check stages in dir. Stages run in
+// parallel except where one needs another. A stage with paths is skipped
+// when none of the changed files match; changed == nil means the change
+// set is unknown and every stage runs. The merge fails if any required
+// stage fails or is blocked.
+func (e *Engineer) runChecks(ctx context.Context, dir string, changed []string, out io.Writer) ProcessResult {
+	return e.runCheckStages(ctx, e.checkStages(), dir, changed, out)
+}
+
+// runCheckStages runs stages in dir as described for runChecks.
+func (e *Engineer) runCheckStages(ctx context.Context, stages []config.CheckStage, dir string, changed []string, out io.Writer) ProcessResult {
+	if len(stages) == 0 {
+		return ProcessResult{Success: true}
+	}
+	if err := config.ValidateCheckStages(stages); err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+
+	out = &lockedWriter{w: out}
+	results := make([]StageResult, len(stages))
+	index := make(map[string]int, len(stages))
+	done := make(map[string]chan struct{}, len(stages))
+	for i, stage := range stages {
+		index[stage.Name] = i
+		done[stage.Name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i, stage := range stages {
+		wg.Add(1)
+		go func(i int, stage config.CheckStage) {
+			defer wg.Done()
+			defer close(done[stage.Name])
+
+			for _, need := range stage.Needs {
+				<-done[need]
+				if s := results[index[need]].Status; s != StagePassed && s != StageSkipped {
+					results[i] = StageResult{
+						Name:     stage.Name,
+						Status:   StageBlocked,
+						Optional: stage.Optional,
+						Reason:   fmt.Sprintf("%s %s", need, s),
+					}
+					_, _ = fmt.Fprintf(out, "[Engineer] Check %s blocked: %s %s\n", stage.Name, need, s)
+					return
+				}
+			}
+			if changed != nil && len(stage.Paths) > 0 && !matchesPaths(stage.Paths, changed) {
+				results[i] = StageResult{
+					Name:     stage.Name,
+					Status:   StageSkipped,
+					Optional: stage.Optional,
+					Reason:   "no matching changes",
+				}
+				_, _ = fmt.Fprintf(out, "[Engineer] Check %s skipped: no matching changes\n", stage.Name)
+				return
+			}
+			results[i] = runStage(ctx, dir, stage, e.config.Quarantine, out)
+		}(i, stage)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ProcessResult{
+			Success: false,
+			Error:   "test run canceled",
+			Stages:  results,
+		}
+	}
+
+	failureType := FailureTestsFail
+	var failed []string
+	for _, r := range results {
+		if r.Optional || (r.Status != StageFailed && r.Status != StageBlocked) {
+			continue
+		}
+		if r.Status == StageFailed && isBuildCheck(r.Name) {
+			failureType = FailureBuildFail
+		}
+		failed = append(failed, fmt.Sprintf("%s (%s)", r.Name, r.Reason))
+	}
+	if len(failed) == 0 {
+		return ProcessResult{Success: true, Stages: results}
+	}
+	return ProcessResult{
+		Success:     false,
+		TestsFailed: failureType == FailureTestsFail,
+		FailureType: failureType,
+		Error:       "checks failed: " + strings.Join(failed, ", "),
+		Stages:      results,
+	}
+}
+
+// runStage runs one check stage, retrying failed attempts up to
+// stage.Retries times. Each attempt gets the stage timeout. When test
+// results can be parsed, tests that fail and then pass on retry are
+// reported as flaky, and an attempt whose only failures are quarantined
+// tests counts as passed. A package that fails to build, panics or times
+// out is never masked by the quarantine.
+func runStage(ctx context.Context, dir string, stage config.CheckStage, quarantine []string, out io.Writer) StageResult {
+	result := StageResult{Name: stage.Name, Optional: stage.Optional}
+	timeout, _ := time.ParseDuration(stage.Timeout) // validated by ValidateCheckStages
+	start := time.Now()
+
+	_, _ = fmt.Fprintf(out, "[Engineer] Running check %s: %s\n", stage.Name, stage.Command)
+	var text string
+	var err error
+	failedBefore := make(map[string]TestOutcome)
+	attempts := stage.Retries + 1
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			_, _ = fmt.Fprintf(out, "[Engineer] Retrying check %s (attempt %d/%d)...\n", stage.Name, attempt, attempts)
+		}
+		result.Attempts = attempt
+		if stage.JUnit != "" {
+			removeJUnit(dir, stage.JUnit)
+		}
+		var output []byte
+		var brokenPkgs []string
+		output, err = runStageCommand(ctx, dir, stage.Command, timeout)
+		result.Tests, brokenPkgs, text = stageTests(dir, stage, output)
+
+		for _, t := range result.Tests {
+			if prev, ok := failedBefore[t.ID()]; ok && !t.Failed {
+				result.Flaky = append(result.Flaky, prev)
+				delete(failedBefore, t.ID())
+			}
+		}
+		failed := failedTests(result.Tests)
+		for _, t := range failed {
+			failedBefore[t.ID()] = t
+		}
+		if err != nil && len(failed) > 0 && len(brokenPkgs) == 0 && allQuarantined(failed, quarantine) {
+			for _, t := range failed {
+				result.Quarantined = append(result.Quarantined, t.ID())
+			}
+			err = nil
+		}
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+
+	if err == nil {
+		result.Status = StagePassed
+		var notes []string
+		if len(result.Quarantined) > 0 {
+			notes = append(notes, "quarantined failures: "+strings.Join(result.Quarantined, ", "))
+		}
+		if len(result.Flaky) > 0 {
+			notes = append(notes, "flaky: "+strings.Join(testIDs(result.Flaky), ", "))
+		}
+		result.Reason = strings.Join(notes, "; ")
+		_, _ = fmt.Fprintf(out, "[Engineer] Check %s passed (%s)\n", stage.Name, result.Duration.Round(time.Millisecond))
+		return result
+	}
+
+	result.Status = StageFailed
+	result.Reason = err.Error()
+	if result.Attempts > 1 {
+		result.Reason += fmt.Sprintf(" after %d attempts", result.Attempts)
+	}
+	if len(result.Flaky) > 0 {
+		result.Reason += "; flaky: " + strings.Join(testIDs(result.Flaky), ", ")
+	}
+	result.LogTail = logTail([]byte(text))
+	_, _ = fmt.Fprintf(out, "[Engineer] Check %s failed: %s\n", stage.Name, result.Reason)
+	return result
+}
+
+// runStageCommand runs command through sh in dir and returns its combined
+// output. A timeout of zero means no limit beyond ctx.
+func runStageCommand(ctx context.Context, dir, command string, timeout time.Duration) ([]byte, error) {
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Note: check commands come from rig's config.json (trusted infrastructure config),
+	// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
+	cmd := exec.CommandContext(runCtx, "sh", "-c", command) //nolint:gosec // G204: command is from trusted rig config
+	cmd.Dir = dir
+	cmd.WaitDelay = time.Second // don't wait on children still holding the output pipe
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	err := cmd.Run()
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	return buf.Bytes(), err
+}
+
+// stageTests parses the test results of one attempt, from go test -json
+// output or the stage's JUnit reports. It also returns the go packages
+// that failed for reasons other than failed tests, and the attempt's
+// human-readable output: decoded text for go test -json, else as is.
+func stageTests(dir string, stage config.CheckStage, output []byte) ([]TestOutcome, []string, string) {
+	tests, brokenPkgs, text, ok := parseGoTestJSON(output)
+	if !ok {
+		text = string(output)
+	}
+	if stage.JUnit != "" {
+		tests = append(tests, readJUnit(dir, stage.JUnit)...)
+	}
+	return tests, brokenPkgs, text
+}
+
+// logTail returns the last logTailLines lines of output, capped at
+// logTailBytes without splitting a UTF-8 character.
+func logTail(output []byte) string {
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) > logTailLines {
+		lines = lines[len(lines)-logTailLines:]
+	}
+	tail := strings.Join(lines, "\n")
+	if len(tail) > logTailBytes {
+		cut := len(tail) - logTailBytes
+		for cut < len(tail) && !utf8.RuneStart(tail[cut]) {
+			cut++
+		}
+		tail = tail[cut:]
+	}
+	return tail
+}
+
+// matchesPaths reports whether any changed file matches one of the
+// patterns. Patterns use path.Match syntax; a pattern without a slash
+// also matches base names, and "dir/**" matches everything under dir.
+func matchesPaths(patterns, changed []string) bool {
+	for _, file := range changed {
+		for _, pattern := range patterns {
+			if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+				if strings.HasPrefix(file, prefix+"/") {
+					return true
+				}
+				continue
+			}
+			if ok, _ := path.Match(pattern, file); ok {
+				return true
+			}
+			if !strings.Contains(pattern, "/") {
+				if ok, _ := path.Match(pattern, path.Base(file)); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isBuildCheck reports whether a check name denotes a build rather than
+// a test, which decides between build_fail and tests_fail.
+func isBuildCheck(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "build") || strings.Contains(name, "compile")
+}
+
+// checkResults converts stage results for the MERGE_FAILED report.
+func checkResults(stages []StageResult) []protocol.CheckResult {
+	var results []protocol.CheckResult
+	for _, s := range stages {
+		status := s.Status
+		if s.Optional && (s.Status == StageFailed || s.Status == StageBlocked) {
+			status += " (optional)"
+		}
+		results = append(results, protocol.CheckResult{
+			Name:    s.Name,
+			Status:  status,
+			Detail:  s.Reason,
+			LogTail: s.LogTail,
+		})
+	}
+	return results
+}
+
+// checksSummary formats stage results as the MR bead's checks field.
+func checksSummary(stages []StageResult) string {
+	parts := make([]string, 0, len(stages))
+	for _, s := range stages {
+		parts = append(parts, s.Name+"="+s.Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+// checksHeading starts the MR bead description section holding the log
+// tails of failed stages.
+const checksHeading = "## Checks"
+
+// recordChecks stores the stage results on the MR bead: a checks field
+// with every stage's status, and a "## Checks" section with the log tail
+// of each failed stage. The section from a previous attempt is replaced.
+func (e *Engineer) recordChecks(mrID string, stages []StageResult) {
+	if mrID == "" || len(stages) == 0 {
+		return
+	}
+	mrBead, err := e.beads.Show(mrID)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to fetch MR bead %s: %v\n", mrID, err)
+		return
+	}
+	mrFields := beads.ParseMRFields(mrBead)
+	if mrFields == nil {
+		mrFields = &beads.MRFields{}
+	}
+	mrFields.Checks = checksSummary(stages)
+	newDesc := beads.SetMRFields(mrBead, mrFields)
+	newDesc = replaceChecksSection(newDesc, formatChecksSection(stages))
+	if err := e.beads.Update(mrID, beads.UpdateOptions{Description: &newDesc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record checks on %s: %v\n", mrID, err)
+	}
+}
+
+// formatChecksSection formats the log tails of failed stages, or returns
+// "" if none failed.
+func formatChecksSection(stages []StageResult) string {
+	failed := formatFailedStages(stages)
+	if failed == "" {
+		return ""
+	}
+	return checksHeading + "\n" + failed
+}
+
+// formatFailedStages formats each failed stage with its log tail, each
+// preceded by a blank line.
+func formatFailedStages(stages []StageResult) string {
+	var sb strings.Builder
+	for _, s := range stages {
+		if s.Status != StageFailed {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%s failed (%s):\n", s.Name, s.Reason))
+		for _, line := range strings.Split(s.LogTail, "\n") {
+			sb.WriteString("| " + line + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// replaceChecksSection replaces (or removes, if section is empty) the
+// "## Checks" section of desc, appending it if there is none.
+func replaceChecksSection(desc, section string) string {
+	return replaceSection(desc, checksHeading, section)
+}
+
+// replaceSection replaces (or removes, if section is empty) the section of
+// desc starting at heading, appending it if there is none.
+func replaceSection(desc, heading, section string) string {
+	before, rest, found := strings.Cut(desc, heading+"\n")
+	if !found && strings.HasSuffix(desc, heading) {
+		before, found = strings.TrimSuffix(desc, heading), true
+	}
+	after := ""
+	if found {
+		if i := strings.Index(rest, "\n## "); i >= 0 {
+			after = rest[i+1:]
+		}
+		desc = strings.TrimRight(before, "\n")
+		if after != "" {
+			desc += "\n\n" + after
+		}
+	}
+	if section == "" {
+		return desc
+	}
+	return strings.TrimRight(desc, "\n") + "\n\n" + section
+}
+
+// lockedWriter serializes writes from concurrently running stages.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,252 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// checksEngineer returns an engineer whose checks run in a temp dir.
+func checksEngineer(t *testing.T, stages ...config.CheckStage) (*Engineer, string) {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	e.config.RunTests = true
+	e.config.Checks = stages
+	return e, t.TempDir()
+}
+
+func stageStatuses(result ProcessResult) map[string]string {
+	got := make(map[string]string)
+	for _, s := range result.Stages {
+		got[s.Name] = s.Status
+	}
+	return got
+}
+
+func TestRunChecks_ParallelAndNeeds(t *testing.T) {
+	// a and b wait for each other's marker files, so they only pass when
+	// run concurrently; c needs both.
+	e, dir := checksEngineer(t,
+		config.CheckStage{Name: "a", Command: "touch a; for i in $(seq 50); do test -f b && exit 0; sleep 0.1; done; exit 1"},
+		config.CheckStage{Name: "b", Command: "touch b; for i in $(seq 50); do test -f a && exit 0; sleep 0.1; done; exit 1"},
+		config.CheckStage{Name: "c", Command: "test -f a && test -f b", Needs: []string{"a", "b"}},
+	)
+
+	result := e.runChecks(context.Background(), dir, nil, io.Discard)
+	if !result.Success {
+		t.Fatalf("runChecks failed: %s", result.Error)
+	}
+	want := map[string]string{"a": StagePassed, "b": StagePassed, "c": StagePassed}
+	if got := stageStatuses(result); !equalStatuses(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
+
+func TestRunChecks_FailureBlocksDependents(t *testing.T) {
+	e, dir := checksEngineer(t,
+		config.CheckStage{Name: "build", Command: "echo compiling; echo 'main.go:3: syntax error' >&2; exit 2"},
+		config.CheckStage{Name: "unit", Command: "true", Needs: []string{"build"}},
+		config.CheckStage{Name: "lint", Command: "exit 1", Optional: true},
+	)
+
+	result := e.runChecks(context.Background(), dir, nil, io.Discard)
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.FailureType != FailureBuildFail || result.TestsFailed {
+		t.Errorf("FailureType = %q, TestsFailed = %v, want build_fail", result.FailureType, result.TestsFailed)
+	}
+	want := map[string]string{"build": StageFailed, "unit": StageBlocked, "lint": StageFailed}
+	if got := stageStatuses(result); !equalStatuses(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if !strings.Contains(result.Error, "build (exit status 2)") || !strings.Contains(result.Error, "unit (build failed)") {
+		t.Errorf("Error = %q", result.Error)
+	}
+	if strings.Contains(result.Error, "lint") {
+		t.Errorf("optional stage should not be reported as a merge failure: %q", result.Error)
+	}
+	if tail := result.Stages[0].LogTail; tail != "compiling\nmain.go:3: syntax error" {
+		t.Errorf("LogTail = %q", tail)
+	}
+}
+
+func TestRunChecks_OptionalFailureDoesNotBlockMerge(t *testing.T) {
+	e, dir := checksEngineer(t,
+		config.CheckStage{Name: "unit", Command: "true"},
+		config.CheckStage{Name: "lint", Command: "exit 1", Optional: true},
+	)
+
+	result := e.runChecks(context.Background(), dir, nil, io.Discard)
+	if !result.Success {
+		t.Fatalf("optional failure blocked merge: %s", result.Error)
+	}
+	if got := stageStatuses(result)["lint"]; got != StageFailed {
+		t.Errorf("lint = %q, want failed", got)
+	}
+}
+
+func TestRunChecks_PathFilter(t *testing.T) {
+	e, dir := checksEngineer(t,
+		config.CheckStage{Name: "unit", Command: "true", Paths: []string{"*.go"}},
+		config.CheckStage{Name: "docs", Command: "exit 1", Paths: []string{"docs/**"}},
+		config.CheckStage{Name: "e2e", Command: "true", Needs: []string{"docs"}},
+	)
+
+	result := e.runChecks(context.Background(), dir, []string{"internal/refinery/checks.go"}, io.Discard)
+	if !result.Success {
+		t.Fatalf("runChecks failed: %s", result.Error)
+	}
+	want := map[string]string{"unit": StagePassed, "docs": StageSkipped, "e2e": StagePassed}
+	if got := stageStatuses(result); !equalStatuses(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+
+	// An unknown change set runs every stage.
+	result = e.runChecks(context.Background(), dir, nil, io.Discard)
+	if got := stageStatuses(result)["docs"]; got != StageFailed {
+		t.Errorf("docs with unknown changes = %q, want failed", got)
+	}
+}
+
+func TestRunChecks_TimeoutAndRetries(t *testing.T) {
+	e, dir := checksEngineer(t,
+		config.CheckStage{Name: "slow", Command: "sleep 5", Timeout: "200ms"},
+		config.CheckStage{Name: "flaky", Command: "test -f tried && exit 0; touch tried; exit 1", Retries: 1},
+	)
+
+	start := time.Now()
+	result := e.runChecks(context.Background(), dir, nil, io.Discard)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("timeout not enforced: took %s", elapsed)
+	}
+	if result.Success || result.FailureType != FailureTestsFail {
+		t.Fatalf("result = %+v, want tests_fail", result)
+	}
+	slow, flaky := result.Stages[0], result.Stages[1]
+	if slow.Status != StageFailed || slow.Reason != "timed out after 200ms" {
+		t.Errorf("slow = %+v", slow)
+	}
+	if flaky.Status != StagePassed || flaky.Attempts != 2 {
+		t.Errorf("flaky = %+v, want passed on attempt 2", flaky)
+	}
+}
+
+func TestRunChecks_LegacyTestCommand(t *testing.T) {
+	e, dir := checksEngineer(t)
+	e.config.TestCommand = "exit 1"
+	e.config.RetryFlakyTests = 2
+
+	result := e.runChecks(context.Background(), dir, nil, io.Discard)
+	if result.Success || !result.TestsFailed {
+		t.Fatalf("result = %+v, want tests failure", result)
+	}
+	if len(result.Stages) != 1 || result.Stages[0].Name != "test" || result.Stages[0].Attempts != 2 {
+		t.Errorf("stages = %+v, want one \"test\" stage with 2 attempts", result.Stages)
+	}
+
+	e.config.RunTests = false
+	if result := e.runChecks(context.Background(), dir, nil, io.Discard); !result.Success || result.Stages != nil {
+		t.Errorf("run_tests=false result = %+v, want success without stages", result)
+	}
+}
+
+func TestMatchesPaths(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		file     string
+		want     bool
+	}{
+		{[]string{"*.go"}, "internal/refinery/checks.go", true},
+		{[]string{"*.go"}, "README.md", false},
+		{[]string{"docs/*.md"}, "docs/reference.md", true},
+		{[]string{"docs/*.md"}, "docs/design/x.md", false},
+		{[]string{"internal/**"}, "internal/refinery/checks.go", true},
+		{[]string{"internal/**"}, "internals.go", false},
+		{[]string{"*.md", "go.mod"}, "go.mod", true},
+	}
+	for _, tt := range tests {
+		if got := matchesPaths(tt.patterns, []string{tt.file}); got != tt.want {
+			t.Errorf("matchesPaths(%v, %q) = %v, want %v", tt.patterns, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestLogTail_CutsOnRuneBoundary(t *testing.T) {
+	// One long line of 3-byte runes: the byte cap falls inside a rune.
+	tail := logTail([]byte(strings.Repeat("✗", logTailBytes)))
+	if !utf8.ValidString(tail) || len(tail) > logTailBytes || !strings.HasSuffix(tail, "✗") {
+		t.Errorf("logTail = %d bytes, valid UTF-8 %v", len(tail), utf8.ValidString(tail))
+	}
+}
+
+func TestReplaceChecksSection(t *testing.T) {
+	section := formatChecksSection([]StageResult{
+		{Name: "build", Status: StagePassed},
+		{Name: "unit", Status: StageFailed, Reason: "exit status 1", LogTail: "--- FAIL: TestX\nFAIL"},
+	})
+	want := "## Checks\n\nunit failed (exit status 1):\n| --- FAIL: TestX\n| FAIL"
+	if section != want {
+		t.Fatalf("section =\n%s\nwant\n%s", section, want)
+	}
+
+	desc := "branch: polecat/nux\nchecks: unit=failed\n\n" + section + "\n\n## Notes\nkeep me"
+	got := replaceChecksSection(desc, "")
+	if got != "branch: polecat/nux\nchecks: unit=failed\n\n## Notes\nkeep me" {
+		t.Errorf("removed section:\n%s", got)
+	}
+	got = replaceChecksSection(got, section)
+	if !strings.HasSuffix(got, "## Notes\nkeep me\n\n"+section) || strings.Count(got, "## Checks") != 1 {
+		t.Errorf("appended section:\n%s", got)
+	}
+}
+
+func TestEngineer_LoadConfig_Checks(t *testing.T) {
+	e, _ := checksEngineer(t)
+	write := func(mq map[string]interface{}) {
+		data, _ := json.Marshal(map[string]interface{}{"merge_queue": mq})
+		if err := os.WriteFile(filepath.Join(e.rig.Path, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(map[string]interface{}{"checks": []map[string]interface{}{
+		{"name": "build", "command": "go build ./...", "timeout": "5m"},
+		{"name": "unit", "command": "go test ./...", "needs": []string{"build"}, "retries": 1},
+	}})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(e.config.Checks) != 2 || e.config.Checks[1].Needs[0] != "build" || e.config.Checks[1].Retries != 1 {
+		t.Errorf("Checks = %+v", e.config.Checks)
+	}
+
+	write(map[string]interface{}{"checks": []map[string]interface{}{
+		{"name": "unit", "command": "go test ./...", "needs": []string{"build"}},
+	}})
+	if err := e.LoadConfig(); err == nil {
+		t.Error("expected error for unknown needs")
+	}
+}
+
+func equalStatuses(got, want map[string]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if got[k] != v {
+			return false
+		}
+	}
+	return true
+}
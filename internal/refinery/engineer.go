@@ -2,24 +2,26 @@
 package refinery
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/convoy"
 	"github.com/steveyegge/gastown/internal/crew"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/protocol"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tracing"
 )
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -53,6 +55,44 @@ type MergeQueueConfig struct {
 
 	// MaxConcurrent is the maximum number of MRs to process concurrently.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// BatchSize, when greater than one, merges up to this many MRs as a
+	// single tested batch (see ProcessBatch) instead of a train.
+	BatchSize int `json:"batch_size"`
+
+	// MergeMode is "direct" (merge locally and push) or "pr" (merge
+	// through a forge pull request, see doPRMerge).
+	MergeMode string `json:"merge_mode"`
+
+	// MergeStrategy is "squash", "rebase" or "merge" (see mergeOnto).
+	// MRs may override it.
+	MergeStrategy string `json:"merge_strategy"`
+
+	// CommitTemplate formats squash and merge commit messages (see
+	// commitMessage). Empty keeps the branch's own message. MRs may
+	// override it.
+	CommitTemplate string `json:"commit_template"`
+
+	// Forge configures the forge API used in merge_mode "pr".
+	Forge config.ForgeConfig `json:"forge"`
+
+	// Checks are the named pre-merge check stages (see runChecks).
+	// If empty, TestCommand runs as a single "test" stage.
+	Checks []config.CheckStage `json:"checks"`
+
+	// Quarantine lists tests whose failures don't gate merges.
+	Quarantine []string `json:"quarantine"`
+
+	// PostMergeVerify verifies the target branch after MRs land (see
+	// postMergeVerify).
+	PostMergeVerify bool `json:"post_merge_verify"`
+
+	// VerifyCommand replaces the checks for post-merge verification.
+	VerifyCommand string `json:"verify_command"`
+
+	// BackportBranches are the glob patterns of branches MRs may be
+	// backported to (see ProcessBackports).
+	BackportBranches []string `json:"backport_branches"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
@@ -68,6 +108,9 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		RetryFlakyTests:      1,
 		PollInterval:         30 * time.Second,
 		MaxConcurrent:        1,
+		MergeMode:            config.MergeModeDirect,
+		MergeStrategy:        config.MergeStrategySquash,
+		BackportBranches:     []string{"release/*"},
 	}
 }
 
@@ -88,6 +131,11 @@ type MRInfo struct {
 	ConvoyCreatedAt *time.Time // Convoy creation time
 	CreatedAt       time.Time  // MR creation time
 	BlockedBy       string     // Task ID blocking this MR
+	TraceParent     string     // Source issue's lifecycle trace (empty if untraced)
+	TraceStartedAt  time.Time  // Start of that trace, if this MR ends it
+	Backports       []string   // Branches to backport to after merging
+	MergeStrategy   string     // Overrides the rig's merge strategy (empty: rig default)
+	CommitTemplate  string     // Overrides the rig's commit template (empty: rig default)
 }
 
 // Engineer is the merge queue processor that polls for ready merge-requests
@@ -98,8 +146,16 @@ type Engineer struct {
 	git     *git.Git
 	config  *MergeQueueConfig
 	workDir string
-	output  io.Writer    // Output destination for user-facing messages
-	router  *mail.Router // Mail router for sending protocol messages
+	output  io.Writer       // Output destination for user-facing messages
+	router  *mail.Router    // Mail router for sending protocol messages
+	tracer  *tracing.Tracer // Lifecycle trace exporter (nil when tracing is off)
+
+	// forge merges pull requests in merge_mode "pr" (created on first use)
+	forge     Forge
+	forgePoll time.Duration // how often to poll PR checks
+
+	// escalate raises an escalation (gtEscalate; replaced in tests)
+	escalate func(severity, description, reason, related string) error
 
 	// stopCh is used for graceful shutdown
 	stopCh chan struct{}
@@ -119,7 +175,7 @@ func NewEngineer(r *rig.Rig) *Engineer {
 		gitDir = filepath.Join(r.Path, "mayor", "rig")
 	}
 
-	return &Engineer{
+	e := &Engineer{
 		rig:     r,
 		beads:   beads.New(r.Path),
 		git:     git.NewGit(gitDir),
@@ -127,8 +183,13 @@ func NewEngineer(r *rig.Rig) *Engineer {
 		workDir: gitDir,
 		output:  os.Stdout,
 		router:  mail.NewRouter(r.Path),
+		tracer:  tracing.New(filepath.Dir(r.Path)),
 		stopCh:  make(chan struct{}),
+
+		forgePoll: 15 * time.Second,
 	}
+	e.escalate = e.gtEscalate
+	return e
 }
 
 // SetOutput sets the output writer for user-facing messages.
@@ -165,16 +226,26 @@ func (e *Engineer) LoadConfig() error {
 	// Parse merge_queue section into our config struct
 	// We need special handling for poll_interval (string -> Duration)
 	var mqRaw struct {
-		Enabled              *bool   `json:"enabled"`
-		TargetBranch         *string `json:"target_branch"`
-		IntegrationBranches  *bool   `json:"integration_branches"`
-		OnConflict           *string `json:"on_conflict"`
-		RunTests             *bool   `json:"run_tests"`
-		TestCommand          *string `json:"test_command"`
-		DeleteMergedBranches *bool   `json:"delete_merged_branches"`
-		RetryFlakyTests      *int    `json:"retry_flaky_tests"`
-		PollInterval         *string `json:"poll_interval"`
-		MaxConcurrent        *int    `json:"max_concurrent"`
+		Enabled              *bool               `json:"enabled"`
+		TargetBranch         *string             `json:"target_branch"`
+		IntegrationBranches  *bool               `json:"integration_branches"`
+		OnConflict           *string             `json:"on_conflict"`
+		RunTests             *bool               `json:"run_tests"`
+		TestCommand          *string             `json:"test_command"`
+		DeleteMergedBranches *bool               `json:"delete_merged_branches"`
+		RetryFlakyTests      *int                `json:"retry_flaky_tests"`
+		PollInterval         *string             `json:"poll_interval"`
+		MaxConcurrent        *int                `json:"max_concurrent"`
+		BatchSize            *int                `json:"batch_size"`
+		MergeMode            *string             `json:"merge_mode"`
+		MergeStrategy        *string             `json:"merge_strategy"`
+		CommitTemplate       *string             `json:"commit_template"`
+		Forge                *config.ForgeConfig `json:"forge"`
+		Checks               []config.CheckStage `json:"checks"`
+		Quarantine           []string            `json:"quarantine"`
+		PostMergeVerify      *bool               `json:"post_merge_verify"`
+		VerifyCommand        *string             `json:"verify_command"`
+		BackportBranches     []string            `json:"backport_branches"`
 	}
 
 	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
@@ -209,6 +280,9 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.MaxConcurrent != nil {
 		e.config.MaxConcurrent = *mqRaw.MaxConcurrent
 	}
+	if mqRaw.BatchSize != nil {
+		e.config.BatchSize = *mqRaw.BatchSize
+	}
 	if mqRaw.PollInterval != nil {
 		dur, err := time.ParseDuration(*mqRaw.PollInterval)
 		if err != nil {
@@ -216,6 +290,45 @@ func (e *Engineer) LoadConfig() error {
 		}
 		e.config.PollInterval = dur
 	}
+	if mqRaw.MergeMode != nil {
+		switch *mqRaw.MergeMode {
+		case config.MergeModeDirect, config.MergeModePR:
+			e.config.MergeMode = *mqRaw.MergeMode
+		default:
+			return fmt.Errorf("invalid merge_mode %q: want %q or %q", *mqRaw.MergeMode, config.MergeModeDirect, config.MergeModePR)
+		}
+	}
+	if mqRaw.MergeStrategy != nil && *mqRaw.MergeStrategy != "" {
+		if !config.ValidMergeStrategy(*mqRaw.MergeStrategy) {
+			return fmt.Errorf("invalid merge_strategy %q: want %q, %q or %q", *mqRaw.MergeStrategy,
+				config.MergeStrategySquash, config.MergeStrategyRebase, config.MergeStrategyMerge)
+		}
+		e.config.MergeStrategy = *mqRaw.MergeStrategy
+	}
+	if mqRaw.CommitTemplate != nil {
+		e.config.CommitTemplate = *mqRaw.CommitTemplate
+	}
+	if mqRaw.Forge != nil {
+		e.config.Forge = *mqRaw.Forge
+	}
+	if mqRaw.Checks != nil {
+		if err := config.ValidateCheckStages(mqRaw.Checks); err != nil {
+			return err
+		}
+		e.config.Checks = mqRaw.Checks
+	}
+	if mqRaw.Quarantine != nil {
+		e.config.Quarantine = mqRaw.Quarantine
+	}
+	if mqRaw.PostMergeVerify != nil {
+		e.config.PostMergeVerify = *mqRaw.PostMergeVerify
+	}
+	if mqRaw.VerifyCommand != nil {
+		e.config.VerifyCommand = *mqRaw.VerifyCommand
+	}
+	if mqRaw.BackportBranches != nil {
+		e.config.BackportBranches = mqRaw.BackportBranches
+	}
 
 	return nil
 }
@@ -229,9 +342,28 @@ func (e *Engineer) Config() *MergeQueueConfig {
 type ProcessResult struct {
 	Success     bool
 	MergeCommit string
+
+	// LandedFrom is set when the MR landed as its own rebased commits
+	// (merge strategy "rebase"): they are LandedFrom..MergeCommit.
+	LandedFrom string
+
 	Error       string
 	Conflict    bool
 	TestsFailed bool
+
+	// ConflictResolution is set when the MR conflicted with its target:
+	// ResolutionAutoRebase if the refinery rebased it, ResolutionAssignBack
+	// if it must be resolved by hand.
+	ConflictResolution string
+
+	// FailureType categorizes a failed merge (FailureNone on success).
+	FailureType FailureType
+
+	// PRURL is the forge pull request used in merge_mode "pr".
+	PRURL string
+
+	// Stages holds the per-stage results of the pre-merge checks.
+	Stages []StageResult
 }
 
 // ProcessMR processes a single merge request from a beads issue.
@@ -251,25 +383,101 @@ func (e *Engineer) ProcessMR(ctx context.Context, mr *beads.Issue) ProcessResult
 	_, _ = fmt.Fprintf(e.output, "  Target: %s\n", mrFields.Target)
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mrFields.Worker)
 
-	return e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue)
+	info := e.newMRInfo(mr, mrFields)
+	span := e.startMRSpan(mrFields.TraceParent, mr.ID, "refinery.merge", time.Now())
+	result := e.doMerge(tracing.ContextWithSpan(ctx, span), info)
+	endMRSpan(span, result)
+	return result
+}
+
+// newMRInfo builds the MRInfo of a merge request bead from the bead and
+// its parsed MR fields. An invalid merge_strategy is dropped with a
+// warning, leaving the rig's strategy in effect.
+func (e *Engineer) newMRInfo(issue *beads.Issue, fields *beads.MRFields) *MRInfo {
+	mr := &MRInfo{
+		ID:             issue.ID,
+		Branch:         fields.Branch,
+		Target:         fields.Target,
+		SourceIssue:    fields.SourceIssue,
+		Worker:         fields.Worker,
+		Rig:            fields.Rig,
+		Title:          issue.Title,
+		Priority:       issue.Priority,
+		AgentBead:      fields.AgentBead,
+		RetryCount:     fields.RetryCount,
+		ConvoyID:       fields.ConvoyID,
+		TraceParent:    fields.TraceParent,
+		Backports:      splitBackports(fields.Backports),
+		CommitTemplate: strings.ReplaceAll(fields.CommitTemplate, `\n`, "\n"),
+	}
+	if fields.ConvoyCreatedAt != "" {
+		if t, err := time.Parse(time.RFC3339, fields.ConvoyCreatedAt); err == nil {
+			mr.ConvoyCreatedAt = &t
+		}
+	}
+	if issue.CreatedAt != "" {
+		mr.CreatedAt, _ = time.Parse(time.RFC3339, issue.CreatedAt)
+	}
+	if fields.TraceStartedAt != "" {
+		mr.TraceStartedAt, _ = time.Parse(time.RFC3339Nano, fields.TraceStartedAt)
+	}
+	if config.ValidMergeStrategy(fields.MergeStrategy) {
+		mr.MergeStrategy = fields.MergeStrategy
+	} else {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %s has invalid merge_strategy %q, using the rig's\n", issue.ID, fields.MergeStrategy)
+	}
+	return mr
+}
+
+// startMRSpan starts a span in a merge request's lifecycle trace. MRs
+// without a trace (submitted before tracing was enabled) get no spans.
+func (e *Engineer) startMRSpan(traceParent, mrID, name string, start time.Time) *tracing.Span {
+	if traceParent == "" {
+		return nil
+	}
+	span := e.tracer.StartAt(traceParent, name, start)
+	span.SetAttr("gt.mr", mrID).SetAttr("gt.rig", e.rig.Name)
+	return span
+}
+
+// endMRSpan records the outcome of a merge attempt and ends its span.
+func endMRSpan(span *tracing.Span, result ProcessResult) {
+	if result.MergeCommit != "" {
+		span.SetAttr("gt.merge_commit", result.MergeCommit)
+	}
+	if result.ConflictResolution != "" {
+		span.SetAttr("gt.conflict_resolution", result.ConflictResolution)
+	}
+	if !result.Success {
+		span.SetAttr("gt.failure_type", string(result.FailureType))
+		span.SetError(errors.New(result.Error))
+	}
+	span.End()
 }
 
 // doMerge performs the actual git merge operation.
 // This is the core merge logic shared by ProcessMR and ProcessMRFromQueue.
-func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string) ProcessResult {
+func (e *Engineer) doMerge(ctx context.Context, mr *MRInfo) ProcessResult {
+	if e.prMode() {
+		return e.doPRMerge(ctx, mr)
+	}
+	branch, target := mr.Branch, mr.Target
+
 	// Step 1: Verify source branch exists locally (shared .repo.git with polecats)
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking local branch %s...\n", branch)
 	exists, err := e.git.BranchExists(branch)
 	if err != nil {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to check branch %s: %v", branch, err),
+			Success:     false,
+			FailureType: FailureFetch,
+			Error:       fmt.Sprintf("failed to check branch %s: %v", branch, err),
 		}
 	}
 	if !exists {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("branch %s not found locally", branch),
+			Success:     false,
+			FailureType: FailureFetch,
+			Error:       fmt.Sprintf("branch %s not found locally", branch),
 		}
 	}
 
@@ -277,8 +485,9 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking out target branch %s...\n", target)
 	if err := e.git.Checkout(target); err != nil {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to checkout target %s: %v", target, err),
+			Success:     false,
+			FailureType: FailureCheckout,
+			Error:       fmt.Sprintf("failed to checkout target %s: %v", target, err),
 		}
 	}
 
@@ -293,135 +502,150 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	conflicts, err := e.git.CheckConflicts(branch, target)
 	if err != nil {
 		return ProcessResult{
-			Success:  false,
-			Conflict: true,
-			Error:    fmt.Sprintf("conflict check failed: %v", err),
+			Success:     false,
+			Conflict:    true,
+			FailureType: FailureConflict,
+			Error:       fmt.Sprintf("conflict check failed: %v", err),
 		}
 	}
+	// mergeRef is what gets merged: the branch itself, or its rebased tip
+	// when auto_rebase resolved a conflict.
+	mergeRef := branch
+	resolution := ""
 	if len(conflicts) > 0 {
-		return ProcessResult{
-			Success:  false,
-			Conflict: true,
-			Error:    fmt.Sprintf("merge conflicts in: %v", conflicts),
+		if !e.autoRebaseEnabled() {
+			return ProcessResult{
+				Success:            false,
+				Conflict:           true,
+				ConflictResolution: ResolutionAssignBack,
+				FailureType:        FailureConflict,
+				Error:              fmt.Sprintf("merge conflicts in: %v", conflicts),
+			}
 		}
-	}
 
-	// Step 4: Run tests if configured
-	if e.config.RunTests && e.config.TestCommand != "" {
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
-		result := e.runTests(ctx)
-		if !result.Success {
+		// Step 3b: auto_rebase - replay the branch onto the target and
+		// merge the result. Fall back to assign_back only on real conflicts.
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Conflicts in %v, attempting auto-rebase onto %s...\n", conflicts, target)
+		rebaseSpan := tracing.SpanFromContext(ctx).Start("refinery.rebase")
+		rb, rebaseConflicts, err := e.rebaseBranch(branch, target)
+		if err == nil && len(rebaseConflicts) > 0 {
+			rebaseSpan.SetError(fmt.Errorf("conflicts in %v", rebaseConflicts))
+		}
+		rebaseSpan.EndErr(err)
+		if err != nil {
+			return ProcessResult{
+				Success:            false,
+				Conflict:           true,
+				ConflictResolution: ResolutionAssignBack,
+				FailureType:        FailureConflict,
+				Error:              fmt.Sprintf("auto-rebase failed: %v", err),
+			}
+		}
+		if len(rebaseConflicts) > 0 {
 			return ProcessResult{
-				Success:     false,
-				TestsFailed: true,
-				Error:       result.Error,
+				Success:            false,
+				Conflict:           true,
+				ConflictResolution: ResolutionAssignBack,
+				FailureType:        FailureConflict,
+				Error:              fmt.Sprintf("auto-rebase conflicts in: %v", rebaseConflicts),
 			}
 		}
-		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
+		defer e.cleanupRebase(rb)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Rebased %s onto %s: %s\n", branch, target, shortSHA(rb.Commit))
+		mergeRef = rb.Commit
+		resolution = ResolutionAutoRebase
 	}
 
-	// Step 5: Perform the actual merge using squash merge
-	// Get the original commit message from the polecat branch to preserve the
-	// conventional commit format (feat:/fix:) instead of creating redundant merge commits
-	originalMsg, err := e.git.GetBranchCommitMessage(branch)
+	// Step 4: Perform the actual merge with the MR's merge strategy. The
+	// default squash keeps the polecat's conventional commit message
+	// (feat:/fix:) instead of creating redundant merge commits.
+	base, err := e.git.Rev("HEAD")
 	if err != nil {
-		// Fallback to a descriptive message if we can't get the original
-		originalMsg = fmt.Sprintf("Squash merge %s into %s", branch, target)
-		if sourceIssue != "" {
-			originalMsg = fmt.Sprintf("Squash merge %s into %s (%s)", branch, target, sourceIssue)
-		}
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not get original commit message: %v\n", err)
-	}
-	_, _ = fmt.Fprintf(e.output, "[Engineer] Squash merging with message: %s\n", strings.TrimSpace(originalMsg))
-	if err := e.git.MergeSquash(branch, originalMsg); err != nil {
-		// ZFC: Use git's porcelain output to detect conflicts instead of parsing stderr.
-		// GetConflictingFiles() uses `git diff --diff-filter=U` which is proper.
-		conflicts, conflictErr := e.git.GetConflictingFiles()
-		if conflictErr == nil && len(conflicts) > 0 {
-			_ = e.git.AbortMerge()
-			return ProcessResult{
-				Success:  false,
-				Conflict: true,
-				Error:    "merge conflict during actual merge",
-			}
+		return ProcessResult{
+			Success:     false,
+			FailureType: FailureCheckout,
+			Error:       fmt.Sprintf("failed to resolve %s: %v", target, err),
 		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging %s into %s (%s)...\n", branch, target, e.mergeStrategy(mr))
+	conflicts, err = e.mergeOnto(e.git, mr, mergeRef)
+	if err != nil {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("merge failed: %v", err),
+			Success:     false,
+			FailureType: FailureBuildFail,
+			Error:       fmt.Sprintf("merge failed: %v", err),
+		}
+	}
+	if len(conflicts) > 0 {
+		return ProcessResult{
+			Success:     false,
+			Conflict:    true,
+			FailureType: FailureConflict,
+			Error:       "merge conflict during actual merge",
 		}
 	}
 
+	// Step 5: Run checks if configured, against the merged tree. On
+	// failure the target is reset so the untested merge is never pushed.
+	var stages []StageResult
+	if len(e.checkStages()) > 0 {
+		changed, err := e.git.ChangedFiles(base, "HEAD")
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: listing changed files: %v (running every check)\n", err)
+		}
+		testSpan := tracing.SpanFromContext(ctx).Start("refinery.tests")
+		result := e.runChecks(ctx, e.workDir, changed, e.output)
+		if !result.Success {
+			testSpan.SetError(errors.New(result.Error))
+		}
+		testSpan.End()
+		if !result.Success {
+			if err := e.git.ResetHard(base); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: resetting %s after failed checks: %v\n", target, err)
+			}
+			result.ConflictResolution = resolution
+			return result
+		}
+		stages = result.Stages
+		_, _ = fmt.Fprintln(e.output, "[Engineer] Checks passed")
+	}
+
 	// Step 6: Get the merge commit SHA
 	mergeCommit, err := e.git.Rev("HEAD")
 	if err != nil {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to get merge commit SHA: %v", err),
+			Success:     false,
+			FailureType: FailureBuildFail,
+			Error:       fmt.Sprintf("failed to get merge commit SHA: %v", err),
 		}
 	}
 
 	// Step 7: Push to origin
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing to origin/%s...\n", target)
-	if err := e.git.Push("origin", target, false); err != nil {
+	pushSpan := tracing.SpanFromContext(ctx).Start("refinery.push")
+	err = e.git.Push("origin", target, false)
+	pushSpan.EndErr(err)
+	if err != nil {
 		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to push to origin: %v", err),
+			Success:     false,
+			FailureType: FailurePushFail,
+			Error:       fmt.Sprintf("failed to push to origin: %v", err),
 		}
 	}
 
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Successfully merged: %s\n", mergeCommit[:8])
 	return ProcessResult{
-		Success:     true,
-		MergeCommit: mergeCommit,
+		Success:            true,
+		MergeCommit:        mergeCommit,
+		LandedFrom:         e.landedFrom(mr, base),
+		ConflictResolution: resolution,
+		Stages:             stages,
 	}
 }
 
-// runTests runs the configured test command and returns the result.
+// runTests runs the configured checks in the refinery clone.
 func (e *Engineer) runTests(ctx context.Context) ProcessResult {
-	if e.config.TestCommand == "" {
-		return ProcessResult{Success: true}
-	}
-
-	// Run the test command with retries for flaky tests
-	maxRetries := e.config.RetryFlakyTests
-	if maxRetries < 1 {
-		maxRetries = 1
-	}
-
-	var lastErr error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if attempt > 1 {
-			_, _ = fmt.Fprintf(e.output, "[Engineer] Retrying tests (attempt %d/%d)...\n", attempt, maxRetries)
-		}
-
-		// Note: TestCommand comes from rig's config.json (trusted infrastructure config),
-		// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
-		cmd := exec.CommandContext(ctx, "sh", "-c", e.config.TestCommand) //nolint:gosec // G204: TestCommand is from trusted rig config
-		cmd.Dir = e.workDir
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-
-		err := cmd.Run()
-		if err == nil {
-			return ProcessResult{Success: true}
-		}
-		lastErr = err
-
-		// Check if context was canceled
-		if ctx.Err() != nil {
-			return ProcessResult{
-				Success: false,
-				Error:   "test run canceled",
-			}
-		}
-	}
-
-	return ProcessResult{
-		Success:     false,
-		TestsFailed: true,
-		Error:       fmt.Sprintf("tests failed after %d attempts: %v", maxRetries, lastErr),
-	}
+	return e.runChecks(ctx, e.workDir, nil, e.output)
 }
 
 // handleSuccess handles a successful merge completion.
@@ -441,6 +665,12 @@ func (e *Engineer) handleSuccess(mr *beads.Issue, result ProcessResult) {
 	// 1. Update MR with merge_commit SHA
 	mrFields.MergeCommit = result.MergeCommit
 	mrFields.CloseReason = "merged"
+	if result.ConflictResolution != "" {
+		mrFields.ConflictResolution = result.ConflictResolution
+	}
+	if result.PRURL != "" {
+		mrFields.PRURL = result.PRURL
+	}
 	newDesc := beads.SetMRFields(mr, mrFields)
 	if err := e.beads.Update(mr.ID, beads.UpdateOptions{Description: &newDesc}); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update MR %s with merge commit: %v\n", mr.ID, err)
@@ -538,6 +768,7 @@ func (e *Engineer) handleFailure(mr *beads.Issue, result ProcessResult) {
 	if err := e.beads.Update(mr.ID, beads.UpdateOptions{Status: &open}); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reopen MR %s: %v\n", mr.ID, err)
 	}
+	e.recordConflictResolution(mr.ID, result.ConflictResolution)
 
 	// Log the failure
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Failed: %s - %s\n", mr.ID, result.Error)
@@ -552,8 +783,17 @@ func (e *Engineer) ProcessMRInfo(ctx context.Context, mr *MRInfo) ProcessResult
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mr.Worker)
 	_, _ = fmt.Fprintf(e.output, "  Source: %s\n", mr.SourceIssue)
 
+	// Time spent queued, then the merge itself, in the source issue's trace
+	if !mr.CreatedAt.IsZero() {
+		e.startMRSpan(mr.TraceParent, mr.ID, "mq.wait", mr.CreatedAt).End()
+	}
+	span := e.startMRSpan(mr.TraceParent, mr.ID, "refinery.merge", time.Now())
+	span.SetAttr("gt.branch", mr.Branch).SetAttr("gt.retry_count", mr.RetryCount)
+
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	result := e.doMerge(tracing.ContextWithSpan(ctx, span), mr)
+	endMRSpan(span, result)
+	return result
 }
 
 // HandleMRInfoSuccess handles a successful merge from MRInfo.
@@ -573,6 +813,8 @@ func (e *Engineer) HandleMRInfoSuccess(mr *MRInfo, result ProcessResult) {
 	}
 
 	// Update and close the MR bead
+	e.recordChecks(mr.ID, result.Stages)
+	e.recordTestHistory(mr, result.Stages)
 	if mr.ID != "" {
 		// Fetch the MR bead to update its fields
 		mrBead, err := e.beads.Show(mr.ID)
@@ -586,6 +828,12 @@ func (e *Engineer) HandleMRInfoSuccess(mr *MRInfo, result ProcessResult) {
 			}
 			mrFields.MergeCommit = result.MergeCommit
 			mrFields.CloseReason = "merged"
+			if result.ConflictResolution != "" {
+				mrFields.ConflictResolution = result.ConflictResolution
+			}
+			if result.PRURL != "" {
+				mrFields.PRURL = result.PRURL
+			}
 			newDesc := beads.SetMRFields(mrBead, mrFields)
 			if err := e.beads.Update(mr.ID, beads.UpdateOptions{Description: &newDesc}); err != nil {
 				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update MR %s with merge commit: %v\n", mr.ID, err)
@@ -632,7 +880,15 @@ func (e *Engineer) HandleMRInfoSuccess(mr *MRInfo, result ProcessResult) {
 		}
 	}
 
-	// 3. Log success
+	// 3. The work has landed: end the source issue's lifecycle trace
+	if !mr.TraceStartedAt.IsZero() {
+		root := e.tracer.Resume(mr.TraceParent, "bead "+mr.SourceIssue, mr.TraceStartedAt)
+		root.SetAttr("gt.bead", mr.SourceIssue).SetAttr("gt.rig", e.rig.Name).SetAttr("gt.merge_commit", result.MergeCommit)
+		root.End()
+	}
+
+	// 4. Log success
+	_ = events.LogFeed(events.TypeMerged, holder, e.mergeEventPayload(mr, result))
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Merged: %s (commit: %s)\n", mr.ID, result.MergeCommit)
 }
 
@@ -649,12 +905,23 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 		failureType = "tests"
 	}
 	msg := protocol.NewMergeFailedMessage(e.rig.Name, mr.Worker, mr.Branch, mr.SourceIssue, mr.Target, failureType, result.Error)
+	msg.Body += protocol.FormatChecks(checkResults(result.Stages))
+	if mr.TraceParent != "" {
+		msg.Body += "Traceparent: " + mr.TraceParent + "\n"
+	}
 	if err := e.router.Send(msg); err != nil {
 		fmt.Fprintf(e.output, "[Engineer] Warning: failed to send MERGE_FAILED to witness: %v\n", err)
 	} else {
 		fmt.Fprintf(e.output, "[Engineer] Notified witness of merge failure for %s\n", mr.Worker)
 	}
 
+	// Record which conflict path was taken (auto_rebase fallback, or a
+	// rebased MR that then failed tests)
+	e.recordConflictResolution(mr.ID, result.ConflictResolution)
+	e.recordPRURL(mr.ID, result.PRURL)
+	e.recordChecks(mr.ID, result.Stages)
+	e.recordTestHistory(mr, result.Stages)
+
 	// If this was a conflict, create a conflict-resolution task for dispatch
 	// and block the MR until the task is resolved (non-blocking delegation)
 	if result.Conflict {
@@ -673,6 +940,7 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 	}
 
 	// Log the failure - MR stays in queue but may be blocked
+	_ = events.LogFeed(events.TypeMergeFailed, e.rig.Name+"/refinery", e.mergeEventPayload(mr, result))
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Failed: %s - %s\n", mr.ID, result.Error)
 	if mr.BlockedBy != "" {
 		_, _ = fmt.Fprintln(e.output, "[Engineer] MR blocked pending conflict resolution - queue continues to next MR")
@@ -681,6 +949,24 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 	}
 }
 
+// mergeEventPayload builds the payload for merged and merge_failed events.
+// The rig, failure_type and duration_seconds (time since the MR was
+// submitted) fields feed the /metrics counters and time-to-merge histogram.
+func (e *Engineer) mergeEventPayload(mr *MRInfo, result ProcessResult) map[string]interface{} {
+	p := events.MergePayload(mr.ID, mr.Worker, mr.Branch, result.Error)
+	p["rig"] = e.rig.Name
+	p["target"] = mr.Target
+	if result.Success {
+		p["commit"] = result.MergeCommit
+		if !mr.CreatedAt.IsZero() {
+			p["duration_seconds"] = time.Since(mr.CreatedAt).Seconds()
+		}
+	} else if result.FailureType != FailureNone {
+		p["failure_type"] = string(result.FailureType)
+	}
+	return p
+}
+
 // createConflictResolutionTaskForMR creates a dispatchable task for resolving merge conflicts.
 // This task will be picked up by bd ready and can be slung to a fresh polecat (spawned on demand).
 // Returns the created task's ID for blocking the MR until resolution.
@@ -775,6 +1061,10 @@ The Refinery will automatically retry the merge after you force-push.`,
 		mr.Branch,
 		mr.Target,
 	)
+	// Whoever is slung the task joins the source issue's trace
+	if mr.TraceParent != "" {
+		description += "\n\ntraceparent: " + mr.TraceParent
+	}
 
 	// Create the conflict resolution task
 	taskTitle := fmt.Sprintf("Resolve merge conflicts: %s", originalTitle)
@@ -841,38 +1131,7 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 			continue
 		}
 
-		// Parse convoy created_at if present
-		var convoyCreatedAt *time.Time
-		if fields.ConvoyCreatedAt != "" {
-			if t, err := time.Parse(time.RFC3339, fields.ConvoyCreatedAt); err == nil {
-				convoyCreatedAt = &t
-			}
-		}
-
-		// Parse issue created_at
-		var createdAt time.Time
-		if issue.CreatedAt != "" {
-			if t, err := time.Parse(time.RFC3339, issue.CreatedAt); err == nil {
-				createdAt = t
-			}
-		}
-
-		mr := &MRInfo{
-			ID:              issue.ID,
-			Branch:          fields.Branch,
-			Target:          fields.Target,
-			SourceIssue:     fields.SourceIssue,
-			Worker:          fields.Worker,
-			Rig:             fields.Rig,
-			Title:           issue.Title,
-			Priority:        issue.Priority,
-			AgentBead:       fields.AgentBead,
-			RetryCount:      fields.RetryCount,
-			ConvoyID:        fields.ConvoyID,
-			ConvoyCreatedAt: convoyCreatedAt,
-			CreatedAt:       createdAt,
-		}
-		mrs = append(mrs, mr)
+		mrs = append(mrs, e.newMRInfo(issue, fields))
 	}
 
 	return mrs, nil
@@ -919,48 +1178,15 @@ func (e *Engineer) ListBlockedMRs() ([]*MRInfo, error) {
 			continue
 		}
 
-		// Parse convoy created_at if present
-		var convoyCreatedAt *time.Time
-		if fields.ConvoyCreatedAt != "" {
-			if t, err := time.Parse(time.RFC3339, fields.ConvoyCreatedAt); err == nil {
-				convoyCreatedAt = &t
-			}
-		}
-
-		// Parse issue created_at
-		var createdAt time.Time
-		if issue.CreatedAt != "" {
-			if t, err := time.Parse(time.RFC3339, issue.CreatedAt); err == nil {
-				createdAt = t
-			}
-		}
-
 		// Use the first open blocker as BlockedBy
-		blockedBy := ""
+		mr := e.newMRInfo(issue, fields)
 		for _, blockerID := range issue.BlockedBy {
 			isOpen, err := e.IsBeadOpen(blockerID)
 			if err == nil && isOpen {
-				blockedBy = blockerID
+				mr.BlockedBy = blockerID
 				break
 			}
 		}
-
-		mr := &MRInfo{
-			ID:              issue.ID,
-			Branch:          fields.Branch,
-			Target:          fields.Target,
-			SourceIssue:     fields.SourceIssue,
-			Worker:          fields.Worker,
-			Rig:             fields.Rig,
-			Title:           issue.Title,
-			Priority:        issue.Priority,
-			AgentBead:       fields.AgentBead,
-			RetryCount:      fields.RetryCount,
-			ConvoyID:        fields.ConvoyID,
-			ConvoyCreatedAt: convoyCreatedAt,
-			CreatedAt:       createdAt,
-			BlockedBy:       blockedBy,
-		}
 		mrs = append(mrs, mr)
 	}
 
@@ -1,12 +1,17 @@
 package refinery
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/rig"
 )
 
@@ -208,6 +213,56 @@ func TestNewEngineer(t *testing.T) {
 	}
 }
 
+func TestNewMRInfo(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	var out bytes.Buffer
+	e.output = &out
+	issue := &beads.Issue{ID: "gt-mr1", Title: "Merge: x", Priority: 1, CreatedAt: "2026-01-02T03:04:05Z"}
+	fields := &beads.MRFields{
+		Branch:         "x",
+		Target:         "main",
+		Backports:      "release/1.2, release/1.3",
+		MergeStrategy:  config.MergeStrategyRebase,
+		CommitTemplate: `{title}\n\n{message}`,
+	}
+
+	mr := e.newMRInfo(issue, fields)
+	if mr.ID != "gt-mr1" || mr.Branch != "x" || mr.Priority != 1 || mr.CreatedAt.IsZero() {
+		t.Errorf("mr = %+v", mr)
+	}
+	if strings.Join(mr.Backports, " ") != "release/1.2 release/1.3" || mr.MergeStrategy != config.MergeStrategyRebase || mr.CommitTemplate != "{title}\n\n{message}" {
+		t.Errorf("mr = %+v", mr)
+	}
+
+	// An invalid strategy falls back to the rig's, with a warning.
+	fields.MergeStrategy = "octopus"
+	if mr := e.newMRInfo(issue, fields); mr.MergeStrategy != "" || !strings.Contains(out.String(), `invalid merge_strategy "octopus"`) {
+		t.Errorf("MergeStrategy = %q, output = %q", mr.MergeStrategy, out.String())
+	}
+}
+
+func TestDoMerge_ChecksRunOnMergedTree(t *testing.T) {
+	e, origin := trainRepo(t)
+	before := gitRun(t, origin, "rev-parse", "main")
+	bad := mrBranch(t, e, "bad", "broken", "x\n")
+
+	result := e.doMerge(context.Background(), bad)
+	if result.Success || result.FailureType != FailureTestsFail {
+		t.Fatalf("MR breaking the checks merged: %+v", result)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != before {
+		t.Errorf("origin/main moved to %s after failed checks", head)
+	}
+	if head := gitRun(t, e.workDir, "rev-parse", "main"); head != before {
+		t.Errorf("local main left at untested merge %s", head)
+	}
+
+	good := mrBranch(t, e, "good", "a.txt", "a\n")
+	if result := e.doMerge(context.Background(), good); !result.Success {
+		t.Fatalf("good MR: %+v", result)
+	}
+}
+
 func TestEngineer_DeleteMergedBranchesConfig(t *testing.T) {
 	// Test that DeleteMergedBranches is true by default
 	cfg := DefaultMergeQueueConfig()
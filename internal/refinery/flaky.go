@@ -0,0 +1,266 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// TestHistory is the per-rig record of test outcomes across MRs, used to
+// measure how often each test flakes.
+type TestHistory struct {
+	Tests map[string]*TestStats `json:"tests"`
+}
+
+// TestStats counts the outcomes of one test across check runs.
+type TestStats struct {
+	Suite string `json:"suite,omitempty"`
+	Name  string `json:"name"`
+
+	// Runs is the number of check runs that reported the test.
+	Runs int `json:"runs"`
+
+	// Failures counts runs whose final attempt failed the test.
+	Failures int `json:"failures"`
+
+	// Flakes counts runs where the test failed and then passed on retry.
+	Flakes int `json:"flakes"`
+
+	// LastFlake is when the test last flaked.
+	LastFlake time.Time `json:"last_flake,omitempty"`
+
+	// Bead is the issue filed when the test first flaked.
+	Bead string `json:"bead,omitempty"`
+}
+
+// FlakeRate returns the fraction of runs in which the test flaked.
+func (s *TestStats) FlakeRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Flakes) / float64(s.Runs)
+}
+
+// TestHistoryPath returns the path of a rig's test history file.
+func TestHistoryPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "test-history.json")
+}
+
+// LoadTestHistory reads a rig's test history. A missing file yields an
+// empty history.
+func LoadTestHistory(rigPath string) (*TestHistory, error) {
+	h := &TestHistory{Tests: make(map[string]*TestStats)}
+	data, err := os.ReadFile(TestHistoryPath(rigPath)) //nolint:gosec // G304: path is constructed from trusted rigPath
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.Tests == nil {
+		h.Tests = make(map[string]*TestStats)
+	}
+	return h, nil
+}
+
+// SaveTestHistory writes a rig's test history.
+func SaveTestHistory(rigPath string, h *TestHistory) error {
+	path := TestHistoryPath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, h)
+}
+
+// Record adds one check run's stage results to the history and returns
+// the flaky tests that have no bead filed yet, once each even if they
+// flaked in several stages.
+func (h *TestHistory) Record(stages []StageResult, now time.Time) []TestOutcome {
+	var newlyFlaky []TestOutcome
+	seen := make(map[string]bool)
+	for _, stage := range stages {
+		for _, t := range stage.Tests {
+			s := h.stats(t)
+			s.Runs++
+			if t.Failed {
+				s.Failures++
+			}
+		}
+		for _, t := range stage.Flaky {
+			s := h.stats(t)
+			s.Flakes++
+			s.LastFlake = now
+			if s.Bead == "" && !seen[t.ID()] {
+				seen[t.ID()] = true
+				newlyFlaky = append(newlyFlaky, t)
+			}
+		}
+	}
+	return newlyFlaky
+}
+
+func (h *TestHistory) stats(t TestOutcome) *TestStats {
+	s := h.Tests[t.ID()]
+	if s == nil {
+		s = &TestStats{Suite: t.Suite, Name: t.Name}
+		h.Tests[t.ID()] = s
+	}
+	return s
+}
+
+// FlakyTest is one row of the flaky test report.
+type FlakyTest struct {
+	Name        string  `json:"name"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	Flakes      int     `json:"flakes"`
+	FlakeRate   float64 `json:"flake_rate"`
+	Bead        string  `json:"bead,omitempty"`
+	Quarantined bool    `json:"quarantined"`
+}
+
+// Flaky returns the tests that have flaked at least once, or are
+// quarantined, ordered by flake rate (highest first). Quarantine entries
+// that match no recorded test are listed with no runs.
+func (h *TestHistory) Flaky(quarantine []string) []FlakyTest {
+	var report []FlakyTest
+	matched := make(map[string]bool)
+	for id, s := range h.Tests {
+		t := TestOutcome{Suite: s.Suite, Name: s.Name}
+		q := false
+		for _, entry := range quarantine {
+			if isQuarantined(t, []string{entry}) {
+				q = true
+				matched[entry] = true
+			}
+		}
+		if s.Flakes == 0 && !q {
+			continue
+		}
+		report = append(report, FlakyTest{
+			Name:        id,
+			Runs:        s.Runs,
+			Failures:    s.Failures,
+			Flakes:      s.Flakes,
+			FlakeRate:   s.FlakeRate(),
+			Bead:        s.Bead,
+			Quarantined: q,
+		})
+	}
+	for _, entry := range quarantine {
+		if !matched[entry] {
+			report = append(report, FlakyTest{Name: entry, Quarantined: true})
+		}
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].FlakeRate != report[j].FlakeRate {
+			return report[i].FlakeRate > report[j].FlakeRate
+		}
+		return report[i].Name < report[j].Name
+	})
+	return report
+}
+
+// isQuarantined reports whether t matches a quarantine entry. An entry
+// matches the test name or its package-qualified ID, and covers subtests.
+func isQuarantined(t TestOutcome, quarantine []string) bool {
+	top, _, _ := strings.Cut(t.Name, "/")
+	for _, q := range quarantine {
+		switch q {
+		case t.Name, top, t.ID():
+			return true
+		}
+		if t.Suite != "" && q == t.Suite+"."+top {
+			return true
+		}
+	}
+	return false
+}
+
+// allQuarantined reports whether every test in tests is quarantined.
+func allQuarantined(tests []TestOutcome, quarantine []string) bool {
+	if len(quarantine) == 0 {
+		return false
+	}
+	for _, t := range tests {
+		if !isQuarantined(t, quarantine) {
+			return false
+		}
+	}
+	return true
+}
+
+func testIDs(tests []TestOutcome) []string {
+	ids := make([]string, 0, len(tests))
+	for _, t := range tests {
+		ids = append(ids, t.ID())
+	}
+	return ids
+}
+
+// recordTestHistory adds an MR's check results to the rig's test history
+// and files a bead for each test seen flaking for the first time.
+func (e *Engineer) recordTestHistory(mr *MRInfo, stages []StageResult) {
+	hasTests := false
+	for _, s := range stages {
+		if len(s.Tests) > 0 || len(s.Flaky) > 0 {
+			hasTests = true
+			break
+		}
+	}
+	if !hasTests {
+		return
+	}
+
+	h, err := LoadTestHistory(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to load test history: %v\n", err)
+		return
+	}
+	for _, t := range h.Record(stages, time.Now()) {
+		beadID, err := e.fileFlakyTestBead(mr, t)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to file flaky test %s: %v\n", t.ID(), err)
+			continue
+		}
+		h.Tests[t.ID()].Bead = beadID
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Filed %s for flaky test %s\n", beadID, t.ID())
+	}
+	if err := SaveTestHistory(e.rig.Path, h); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to save test history: %v\n", err)
+	}
+}
+
+// fileFlakyTestBead creates a bug for a newly flaky test.
+func (e *Engineer) fileFlakyTestBead(mr *MRInfo, t TestOutcome) (string, error) {
+	description := fmt.Sprintf(`Test %s failed and then passed on retry while the refinery checked %s (branch %s).
+
+To stop it gating merges until it is fixed, add it to merge_queue.quarantine
+in the rig's config.json. See flake rates with: gt mq flaky %s`,
+		t.ID(), mr.ID, mr.Branch, e.rig.Name)
+	if out := strings.TrimSpace(t.Output); out != "" {
+		description += "\n\n## Failure\n| " + strings.ReplaceAll(logTail([]byte(out)), "\n", "\n| ")
+	}
+
+	issue, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Flaky test: " + t.ID(),
+		Type:        "bug",
+		Priority:    2,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return "", err
+	}
+	return issue.ID, nil
+}
@@ -0,0 +1,315 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+const goTestJSON = `{"Action":"run","Package":"example.com/pkg","Test":"TestA"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestA","Output":"    a_test.go:9: boom\n"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestA","Elapsed":0.01}
+{"Action":"pass","Package":"example.com/pkg","Test":"TestB","Elapsed":0}
+{"Action":"output","Package":"example.com/pkg","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/pkg","Elapsed":0.02}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	tests, broken, text, ok := parseGoTestJSON([]byte("go: downloading x\n" + goTestJSON))
+	if !ok {
+		t.Fatal("expected go test -json output to be detected")
+	}
+	if len(tests) != 2 {
+		t.Fatalf("tests = %+v, want 2", tests)
+	}
+	if tests[0].ID() != "example.com/pkg.TestA" || !tests[0].Failed || !strings.Contains(tests[0].Output, "boom") {
+		t.Errorf("tests[0] = %+v", tests[0])
+	}
+	if tests[1].Failed || tests[1].Output != "" {
+		t.Errorf("tests[1] = %+v", tests[1])
+	}
+	if text != "=== RUN   TestA\n    a_test.go:9: boom\nFAIL\n" {
+		t.Errorf("text = %q", text)
+	}
+	if len(broken) != 0 {
+		t.Errorf("broken = %v, want none", broken)
+	}
+
+	if _, _, _, ok := parseGoTestJSON([]byte("ok  \texample.com/pkg\t0.01s\n")); ok {
+		t.Error("plain go test output should not be detected as JSON")
+	}
+}
+
+// brokenPkgsJSON has packages that fail without a failed test to explain
+// it: one that doesn't build, one whose test panics before TestLater runs,
+// and one that times out.
+const brokenPkgsJSON = `{"ImportPath":"example.com/build [example.com/build.test]","Action":"build-output","Output":"build/x_test.go:3: undefined: y\n"}
+{"ImportPath":"example.com/build [example.com/build.test]","Action":"build-fail"}
+{"Action":"fail","Package":"example.com/build","FailedBuild":"example.com/build [example.com/build.test]"}
+{"Action":"run","Package":"example.com/panic","Test":"TestPanic"}
+{"Action":"output","Package":"example.com/panic","Test":"TestPanic","Output":"panic: x\n"}
+{"Action":"fail","Package":"example.com/panic","Test":"TestPanic"}
+{"Action":"fail","Package":"example.com/panic"}
+{"Action":"run","Package":"example.com/slow","Test":"TestFlaky"}
+{"Action":"fail","Package":"example.com/slow","Test":"TestFlaky"}
+{"Action":"run","Package":"example.com/slow","Test":"TestSlow"}
+{"Action":"output","Package":"example.com/slow","Test":"TestSlow","Output":"panic: test timed out after 1s\n"}
+{"Action":"fail","Package":"example.com/slow"}
+`
+
+func TestParseGoTestJSON_BrokenPackages(t *testing.T) {
+	_, broken, text, _ := parseGoTestJSON([]byte(brokenPkgsJSON))
+	if got := strings.Join(broken, " "); got != "example.com/build example.com/panic example.com/slow" {
+		t.Errorf("broken = %v", broken)
+	}
+	if !strings.Contains(text, "undefined: y") {
+		t.Errorf("text = %q, want the build output", text)
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	report := `<?xml version="1.0"?>
+<testsuites>
+  <testsuite name="widgets">
+    <testcase classname="widgets.Spinner" name="spins"/>
+    <testcase classname="widgets.Spinner" name="stops"><failure message="expected stop">trace</failure></testcase>
+    <testcase name="orphan"><error message="crashed"/></testcase>
+    <testcase classname="widgets.Spinner" name="later"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+	tests, err := parseJUnit([]byte(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tc := range tests {
+		got = append(got, tc.ID()+"="+map[bool]string{true: "fail", false: "pass"}[tc.Failed])
+	}
+	want := "widgets.Spinner.spins=pass widgets.Spinner.stops=fail widgets.orphan=fail"
+	if strings.Join(got, " ") != want {
+		t.Errorf("tests = %v, want %s", got, want)
+	}
+	if tests[1].Output != "expected stop\ntrace" {
+		t.Errorf("failure output = %q", tests[1].Output)
+	}
+
+	// A bare <testsuite> root works too.
+	tests, err = parseJUnit([]byte(`<testsuite name="s"><testcase name="t"/></testsuite>`))
+	if err != nil || len(tests) != 1 || tests[0].ID() != "s.t" {
+		t.Errorf("bare testsuite = %+v, %v", tests, err)
+	}
+}
+
+// flakyScript emits go test -json output where TestFlaky fails on the
+// first attempt only.
+const flakyScript = `emit() { printf '{"Action":"%s","Package":"example.com/pkg","Test":"%s"}\n' "$1" "$2"; }
+emit pass TestSteady
+if [ -f attempted ]; then emit pass TestFlaky; exit 0; fi
+touch attempted
+emit fail TestFlaky
+exit 1`
+
+func TestRunStage_DetectsFlakyTests(t *testing.T) {
+	dir := t.TempDir()
+	stage := config.CheckStage{Name: "unit", Command: flakyScript, Retries: 1}
+
+	result := runStage(context.Background(), dir, stage, nil, io.Discard)
+	if result.Status != StagePassed || result.Attempts != 2 {
+		t.Fatalf("result = %+v, want passed on attempt 2", result)
+	}
+	if len(result.Flaky) != 1 || result.Flaky[0].ID() != "example.com/pkg.TestFlaky" {
+		t.Errorf("Flaky = %+v", result.Flaky)
+	}
+	if result.Reason != "flaky: example.com/pkg.TestFlaky" {
+		t.Errorf("Reason = %q", result.Reason)
+	}
+}
+
+func TestRunStage_Quarantine(t *testing.T) {
+	dir := t.TempDir()
+	stage := config.CheckStage{Name: "unit", Command: flakyScript}
+
+	result := runStage(context.Background(), dir, stage, []string{"TestFlaky"}, io.Discard)
+	if result.Status != StagePassed || result.Attempts != 1 {
+		t.Fatalf("result = %+v, want passed on attempt 1", result)
+	}
+	if len(result.Quarantined) != 1 || !strings.Contains(result.Reason, "quarantined failures: example.com/pkg.TestFlaky") {
+		t.Errorf("result = %+v", result)
+	}
+
+	// A failure outside the quarantine still gates.
+	_ = os.Remove(filepath.Join(dir, "attempted"))
+	result = runStage(context.Background(), dir, stage, []string{"TestSteady"}, io.Discard)
+	if result.Status != StageFailed {
+		t.Errorf("Status = %q, want failed", result.Status)
+	}
+}
+
+func TestRunStage_QuarantineDoesNotMaskBrokenPackage(t *testing.T) {
+	dir := t.TempDir()
+	script := `emit() { printf '{"Action":"%s","Package":"%s","Test":"%s"}\n' "$1" "$2" "$3"; }
+emit fail example.com/pkg TestFlaky
+emit fail example.com/pkg
+emit fail example.com/build
+exit 1`
+	stage := config.CheckStage{Name: "unit", Command: script}
+
+	result := runStage(context.Background(), dir, stage, []string{"TestFlaky"}, io.Discard)
+	if result.Status != StageFailed {
+		t.Errorf("result = %+v, want failed for the package that didn't build", result)
+	}
+}
+
+func TestRunStage_JUnitReports(t *testing.T) {
+	dir := t.TempDir()
+	stage := config.CheckStage{
+		Name:    "e2e",
+		Command: `mkdir -p reports; echo '<testsuite name="e2e"><testcase name="login"><failure message="timeout"/></testcase></testsuite>' > reports/e2e.xml; exit 1`,
+		JUnit:   "reports/*.xml",
+	}
+	result := runStage(context.Background(), dir, stage, []string{"e2e.login"}, io.Discard)
+	if result.Status != StagePassed || len(result.Quarantined) != 1 {
+		t.Errorf("result = %+v, want quarantined pass", result)
+	}
+}
+
+func TestIsQuarantined(t *testing.T) {
+	sub := TestOutcome{Suite: "example.com/pkg", Name: "TestA/case_1"}
+	for _, q := range []string{"TestA", "TestA/case_1", "example.com/pkg.TestA", "example.com/pkg.TestA/case_1"} {
+		if !isQuarantined(sub, []string{q}) {
+			t.Errorf("%q should quarantine %s", q, sub.ID())
+		}
+	}
+	for _, q := range []string{"TestAB", "other.TestA", "TestA/case_2"} {
+		if isQuarantined(sub, []string{q}) {
+			t.Errorf("%q should not quarantine %s", q, sub.ID())
+		}
+	}
+}
+
+func TestTestHistory_RecordAndReport(t *testing.T) {
+	rigPath := t.TempDir()
+	h, err := LoadTestHistory(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flaky := TestOutcome{Suite: "pkg", Name: "TestFlaky", Failed: true}
+	run := func(flaked bool) []TestOutcome {
+		stage := StageResult{Name: "unit", Tests: []TestOutcome{
+			{Suite: "pkg", Name: "TestSteady"},
+			{Suite: "pkg", Name: "TestFlaky"},
+		}}
+		if flaked {
+			stage.Flaky = []TestOutcome{flaky}
+		}
+		return h.Record([]StageResult{stage}, time.Now())
+	}
+
+	if newly := run(true); len(newly) != 1 || newly[0].ID() != "pkg.TestFlaky" {
+		t.Fatalf("newly flaky = %+v", newly)
+	}
+	h.Tests["pkg.TestFlaky"].Bead = "gt-flake"
+	run(false)
+	run(false)
+	if newly := run(true); len(newly) != 0 {
+		t.Errorf("a test with a bead should not be reported as newly flaky: %+v", newly)
+	}
+
+	if err := SaveTestHistory(rigPath, h); err != nil {
+		t.Fatal(err)
+	}
+	h, err = LoadTestHistory(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := h.Flaky([]string{"TestSteady", "TestGone"})
+	if len(report) != 3 {
+		t.Fatalf("report = %+v, want 3 rows", report)
+	}
+	if r := report[0]; r.Name != "pkg.TestFlaky" || r.Runs != 4 || r.Flakes != 2 || r.FlakeRate != 0.5 || r.Bead != "gt-flake" || r.Quarantined {
+		t.Errorf("report[0] = %+v", r)
+	}
+	if r := report[1]; r.Name != "TestGone" || !r.Quarantined || r.Runs != 0 {
+		t.Errorf("report[1] = %+v", r)
+	}
+	if r := report[2]; r.Name != "pkg.TestSteady" || !r.Quarantined || r.Runs != 4 {
+		t.Errorf("report[2] = %+v", r)
+	}
+}
+
+func TestTestHistory_RecordFlakeInTwoStages(t *testing.T) {
+	h := &TestHistory{Tests: make(map[string]*TestStats)}
+	flaky := TestOutcome{Suite: "pkg", Name: "TestFlaky", Failed: true}
+	stages := []StageResult{
+		{Name: "unit", Tests: []TestOutcome{{Suite: "pkg", Name: "TestFlaky"}}, Flaky: []TestOutcome{flaky}},
+		{Name: "race", Tests: []TestOutcome{{Suite: "pkg", Name: "TestFlaky"}}, Flaky: []TestOutcome{flaky}},
+	}
+
+	if newly := h.Record(stages, time.Now()); len(newly) != 1 || newly[0].ID() != "pkg.TestFlaky" {
+		t.Errorf("newly flaky = %+v, want pkg.TestFlaky once", newly)
+	}
+}
+
+func TestRecordTestHistory_FilesBeadOnce(t *testing.T) {
+	townRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"mayor/town.json":    "{}",
+		".beads/config.yaml": "issue-prefix: gt\n",
+	} {
+		full := filepath.Join(townRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GT_BEADS_BACKEND", "native")
+	t.Cleanup(beads.WriteJSONLForTesting())
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: townRoot})
+	e.SetOutput(io.Discard)
+	mr := &MRInfo{ID: "gt-mr1", Branch: "polecat/nux"}
+	stages := []StageResult{{
+		Name:  "unit",
+		Tests: []TestOutcome{{Suite: "pkg", Name: "TestFlaky"}},
+		Flaky: []TestOutcome{{Suite: "pkg", Name: "TestFlaky", Failed: true, Output: "race detected"}},
+	}}
+
+	e.recordTestHistory(mr, stages)
+	e.recordTestHistory(mr, stages)
+
+	h, err := LoadTestHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := h.Tests["pkg.TestFlaky"]
+	if s == nil || s.Flakes != 2 || s.Bead == "" {
+		t.Fatalf("stats = %+v, want 2 flakes with a bead", s)
+	}
+	bead, err := e.beads.Show(s.Bead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bead.Title != "Flaky test: pkg.TestFlaky" || !strings.Contains(bead.Description, "| race detected") {
+		t.Errorf("bead = %q\n%s", bead.Title, bead.Description)
+	}
+	issues, err := e.beads.List(beads.ListOptions{Status: "all", Priority: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 {
+		t.Errorf("filed %d beads, want 1", len(issues))
+	}
+}
@@ -0,0 +1,461 @@
+// Package refinery provides the merge queue processing agent.
+// This file contains the forge adapters used by merge_mode "pr", where MRs
+// land through pull requests instead of direct pushes to the target.
+
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Forge opens, inspects and merges pull requests on a code forge.
+type Forge interface {
+	// EnsurePR opens a pull request from head into base, or updates the
+	// title and body of the one already open.
+	EnsurePR(ctx context.Context, head, base, title, body string) (*PullRequest, error)
+
+	// PR fetches the current state of a pull request.
+	PR(ctx context.Context, number int) (*PullRequest, error)
+
+	// Checks lists the check results reported for a commit.
+	Checks(ctx context.Context, sha string) ([]Check, error)
+
+	// Merge merges a pull request with strategy ("squash", "rebase" or
+	// "merge") and returns the resulting commit SHA. Returns an error
+	// wrapping ErrNotMergeable if the forge refuses.
+	Merge(ctx context.Context, pr *PullRequest, strategy, title, message string) (string, error)
+}
+
+// PullRequest is a forge pull request.
+type PullRequest struct {
+	Number  int
+	URL     string
+	HeadSHA string
+
+	// Mergeable is nil while the forge is still computing it.
+	Mergeable *bool
+
+	Merged      bool
+	MergeCommit string
+}
+
+// CheckState is the state of a CI check.
+type CheckState string
+
+const (
+	CheckPending CheckState = "pending"
+	CheckSuccess CheckState = "success"
+	CheckFailure CheckState = "failure"
+)
+
+// Check is a CI check (GitHub check run or commit status) on a commit.
+type Check struct {
+	Name  string
+	State CheckState
+	URL   string
+}
+
+// ErrNotMergeable is returned by Forge.Merge when the forge refuses to
+// merge: conflicts, or branch protection rules that are not yet met.
+var ErrNotMergeable = errors.New("pull request is not mergeable")
+
+// ForgeError is a non-2xx response from a forge API.
+type ForgeError struct {
+	Method  string
+	Path    string
+	Status  int
+	Message string
+}
+
+func (e *ForgeError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.Status, e.Message)
+}
+
+// NewForge creates the forge adapter for cfg. Unset fields are inferred
+// from originURL, the rig's origin remote.
+func NewForge(cfg config.ForgeConfig, originURL string) (Forge, error) {
+	host, repo := parseRemoteURL(originURL)
+	if cfg.Repo != "" {
+		repo = cfg.Repo
+	}
+	if strings.Count(repo, "/") != 1 {
+		return nil, fmt.Errorf("cannot determine forge repo from origin %q: set merge_queue.forge.repo", originURL)
+	}
+
+	kind := cfg.Type
+	if kind == "" {
+		if host != "github.com" {
+			return nil, fmt.Errorf("cannot infer forge type for host %q: set merge_queue.forge.type", host)
+		}
+		kind = config.ForgeGitHub
+	}
+
+	apiURL := cfg.APIURL
+	tokenEnv := cfg.TokenEnv
+	var f Forge
+	switch kind {
+	case config.ForgeGitHub:
+		if apiURL == "" {
+			apiURL = "https://api.github.com"
+		}
+		if tokenEnv == "" {
+			tokenEnv = "GITHUB_TOKEN"
+		}
+		f = &githubForge{client: newForgeClient(apiURL, "Bearer "+os.Getenv(tokenEnv)), repo: repo}
+	case config.ForgeGitea:
+		if apiURL == "" {
+			if host == "" {
+				return nil, fmt.Errorf("cannot determine Gitea host from origin %q: set merge_queue.forge.api_url", originURL)
+			}
+			apiURL = "https://" + host + "/api/v1"
+		}
+		if tokenEnv == "" {
+			tokenEnv = "GITEA_TOKEN"
+		}
+		f = &giteaForge{client: newForgeClient(apiURL, "token "+os.Getenv(tokenEnv)), repo: repo}
+	default:
+		return nil, fmt.Errorf("unknown forge type %q", kind)
+	}
+	if os.Getenv(tokenEnv) == "" {
+		return nil, fmt.Errorf("forge token not set: export %s", tokenEnv)
+	}
+	return f, nil
+}
+
+// parseRemoteURL extracts the host and "owner/name" repo from a git remote
+// URL (https://, ssh:// or scp-style git@host:owner/name).
+func parseRemoteURL(remote string) (host, repo string) {
+	remote = strings.TrimSpace(remote)
+	var path string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); at >= 0 {
+		rest := remote[at+1:]
+		colon := strings.Index(rest, ":")
+		if colon < 0 {
+			return "", ""
+		}
+		host, path = rest[:colon], rest[colon+1:]
+	} else {
+		return "", ""
+	}
+	return host, strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+// forgeClient is a minimal JSON REST client.
+type forgeClient struct {
+	baseURL string
+	auth    string
+	http    *http.Client
+}
+
+func newForgeClient(baseURL, auth string) *forgeClient {
+	return &forgeClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		auth:    auth,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request with an optional JSON body and decodes a JSON
+// response into out (if non-nil).
+func (c *forgeClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.auth)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		if msg.Message == "" {
+			msg.Message = http.StatusText(resp.StatusCode)
+		}
+		return &ForgeError{Method: method, Path: path, Status: resp.StatusCode, Message: msg.Message}
+	}
+	if out != nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// apiPull is a pull request as returned by both the GitHub and Gitea APIs.
+type apiPull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Mergeable      *bool  `json:"mergeable"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+}
+
+func (p *apiPull) pullRequest() *PullRequest {
+	return &PullRequest{
+		Number:      p.Number,
+		URL:         p.HTMLURL,
+		HeadSHA:     p.Head.SHA,
+		Mergeable:   p.Mergeable,
+		Merged:      p.Merged,
+		MergeCommit: p.MergeCommitSHA,
+	}
+}
+
+// statusState maps a commit status state onto a CheckState.
+func statusState(state string) CheckState {
+	switch state {
+	case "success", "warning":
+		return CheckSuccess
+	case "failure", "error":
+		return CheckFailure
+	default:
+		return CheckPending
+	}
+}
+
+// githubForge talks to the GitHub REST API.
+type githubForge struct {
+	client *forgeClient
+	repo   string
+}
+
+func (g *githubForge) EnsurePR(ctx context.Context, head, base, title, body string) (*PullRequest, error) {
+	owner, _, _ := strings.Cut(g.repo, "/")
+	var open []apiPull
+	query := url.Values{"state": {"open"}, "head": {owner + ":" + head}, "base": {base}}
+	if err := g.client.do(ctx, http.MethodGet, "/repos/"+g.repo+"/pulls?"+query.Encode(), nil, &open); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{"title": title, "body": body}
+	var pull apiPull
+	if len(open) > 0 {
+		path := fmt.Sprintf("/repos/%s/pulls/%d", g.repo, open[0].Number)
+		if err := g.client.do(ctx, http.MethodPatch, path, fields, &pull); err != nil {
+			return nil, err
+		}
+		return pull.pullRequest(), nil
+	}
+	fields["head"], fields["base"] = head, base
+	if err := g.client.do(ctx, http.MethodPost, "/repos/"+g.repo+"/pulls", fields, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+func (g *githubForge) PR(ctx context.Context, number int) (*PullRequest, error) {
+	var pull apiPull
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+// githubPageSize is the largest page the GitHub API serves.
+const githubPageSize = 100
+
+// Checks combines check runs (GitHub Actions and apps) with legacy commit
+// statuses, since required checks can be either. Both are paged.
+func (g *githubForge) Checks(ctx context.Context, sha string) ([]Check, error) {
+	var checks []Check
+	for page, seen := 1, 0; ; page++ {
+		var runs struct {
+			TotalCount int `json:"total_count"`
+			CheckRuns  []struct {
+				Name       string `json:"name"`
+				Status     string `json:"status"`
+				Conclusion string `json:"conclusion"`
+				HTMLURL    string `json:"html_url"`
+			} `json:"check_runs"`
+		}
+		path := fmt.Sprintf("/repos/%s/commits/%s/check-runs?per_page=%d&page=%d", g.repo, sha, githubPageSize, page)
+		if err := g.client.do(ctx, http.MethodGet, path, nil, &runs); err != nil {
+			return nil, err
+		}
+		for _, run := range runs.CheckRuns {
+			state := CheckPending
+			if run.Status == "completed" {
+				switch run.Conclusion {
+				case "success", "neutral", "skipped":
+					state = CheckSuccess
+				default:
+					state = CheckFailure
+				}
+			}
+			checks = append(checks, Check{Name: run.Name, State: state, URL: run.HTMLURL})
+		}
+		seen += len(runs.CheckRuns)
+		if len(runs.CheckRuns) < githubPageSize || seen >= runs.TotalCount {
+			break
+		}
+	}
+
+	for page, seen := 1, 0; ; page++ {
+		var combined struct {
+			TotalCount int `json:"total_count"`
+			Statuses   []struct {
+				Context   string `json:"context"`
+				State     string `json:"state"`
+				TargetURL string `json:"target_url"`
+			} `json:"statuses"`
+		}
+		path := fmt.Sprintf("/repos/%s/commits/%s/status?per_page=%d&page=%d", g.repo, sha, githubPageSize, page)
+		if err := g.client.do(ctx, http.MethodGet, path, nil, &combined); err != nil {
+			return nil, err
+		}
+		for _, st := range combined.Statuses {
+			checks = append(checks, Check{Name: st.Context, State: statusState(st.State), URL: st.TargetURL})
+		}
+		seen += len(combined.Statuses)
+		if len(combined.Statuses) < githubPageSize || seen >= combined.TotalCount {
+			break
+		}
+	}
+	return checks, nil
+}
+
+func (g *githubForge) Merge(ctx context.Context, pr *PullRequest, strategy, title, message string) (string, error) {
+	req := map[string]string{
+		"merge_method":   strategy,
+		"commit_title":   title,
+		"commit_message": message,
+		"sha":            pr.HeadSHA,
+	}
+	var resp struct {
+		SHA    string `json:"sha"`
+		Merged bool   `json:"merged"`
+	}
+	err := g.client.do(ctx, http.MethodPut, fmt.Sprintf("/repos/%s/pulls/%d/merge", g.repo, pr.Number), req, &resp)
+	var fe *ForgeError
+	if errors.As(err, &fe) && fe.Status == http.StatusMethodNotAllowed {
+		return "", fmt.Errorf("%w: %s", ErrNotMergeable, fe.Message)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !resp.Merged {
+		return "", fmt.Errorf("%w: merge not performed", ErrNotMergeable)
+	}
+	return resp.SHA, nil
+}
+
+// giteaForge talks to the Gitea (and Forgejo) REST API.
+type giteaForge struct {
+	client *forgeClient
+	repo   string
+}
+
+func (g *giteaForge) EnsurePR(ctx context.Context, head, base, title, body string) (*PullRequest, error) {
+	// Gitea's list endpoint cannot filter by head, so match client-side.
+	var open []apiPull
+	if err := g.client.do(ctx, http.MethodGet, "/repos/"+g.repo+"/pulls?state=open&limit=50", nil, &open); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{"title": title, "body": body}
+	var pull apiPull
+	for _, p := range open {
+		if p.Head.Ref == head && p.Base.Ref == base {
+			path := fmt.Sprintf("/repos/%s/pulls/%d", g.repo, p.Number)
+			if err := g.client.do(ctx, http.MethodPatch, path, fields, &pull); err != nil {
+				return nil, err
+			}
+			return pull.pullRequest(), nil
+		}
+	}
+	fields["head"], fields["base"] = head, base
+	if err := g.client.do(ctx, http.MethodPost, "/repos/"+g.repo+"/pulls", fields, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+func (g *giteaForge) PR(ctx context.Context, number int) (*PullRequest, error) {
+	var pull apiPull
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return pull.pullRequest(), nil
+}
+
+func (g *giteaForge) Checks(ctx context.Context, sha string) ([]Check, error) {
+	var combined struct {
+		Statuses []struct {
+			Context   string `json:"context"`
+			Status    string `json:"status"`
+			TargetURL string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	if err := g.client.do(ctx, http.MethodGet, "/repos/"+g.repo+"/commits/"+sha+"/status", nil, &combined); err != nil {
+		return nil, err
+	}
+	var checks []Check
+	for _, st := range combined.Statuses {
+		checks = append(checks, Check{Name: st.Context, State: statusState(st.Status), URL: st.TargetURL})
+	}
+	return checks, nil
+}
+
+func (g *giteaForge) Merge(ctx context.Context, pr *PullRequest, strategy, title, message string) (string, error) {
+	req := map[string]string{
+		"Do":                strategy,
+		"MergeTitleField":   title,
+		"MergeMessageField": message,
+		"head_commit_id":    pr.HeadSHA,
+	}
+	err := g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/merge", g.repo, pr.Number), req, nil)
+	var fe *ForgeError
+	if errors.As(err, &fe) && (fe.Status == http.StatusMethodNotAllowed || fe.Status == http.StatusConflict) {
+		return "", fmt.Errorf("%w: %s", ErrNotMergeable, fe.Message)
+	}
+	if err != nil {
+		return "", err
+	}
+	// The merge response has no body; read the commit off the PR.
+	merged, err := g.PR(ctx, pr.Number)
+	if err != nil {
+		return "", fmt.Errorf("reading merge commit: %w", err)
+	}
+	return merged.MergeCommit, nil
+}
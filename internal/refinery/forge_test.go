@@ -0,0 +1,513 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// fakeForge is an httptest stand-in for the GitHub and Gitea pull request
+// APIs, serving one repository.
+type fakeForge struct {
+	t     *testing.T
+	gitea bool
+
+	mu        sync.Mutex
+	pulls     []*apiPull
+	bodies    map[int]string
+	checks    map[string][]fakeCheck // head SHA -> checks, newest first
+	checkPoll int                    // Checks calls so far
+	pendingN  int                    // report every check pending for the first N polls
+	mergeable *bool
+	mergeCode int // status to refuse merges with (0 = merge)
+	merged    map[string]string
+}
+
+type fakeCheck struct {
+	name, state string
+}
+
+func newFakeForge(t *testing.T, gitea bool) (*fakeForge, *httptest.Server) {
+	f := &fakeForge{
+		t:      t,
+		gitea:  gitea,
+		bodies: make(map[int]string),
+		checks: make(map[string][]fakeCheck),
+		merged: make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/widgets/pulls", f.listPulls)
+	mux.HandleFunc("POST /repos/acme/widgets/pulls", f.createPull)
+	mux.HandleFunc("PATCH /repos/acme/widgets/pulls/{n}", f.updatePull)
+	mux.HandleFunc("GET /repos/acme/widgets/pulls/{n}", f.getPull)
+	mux.HandleFunc("GET /repos/acme/widgets/commits/{sha}/check-runs", f.checkRuns)
+	mux.HandleFunc("GET /repos/acme/widgets/commits/{sha}/status", f.status)
+	mux.HandleFunc("PUT /repos/acme/widgets/pulls/{n}/merge", f.merge)
+	mux.HandleFunc("POST /repos/acme/widgets/pulls/{n}/merge", f.merge)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "Bearer secret"
+		if gitea {
+			want = "token secret"
+		}
+		if r.Header.Get("Authorization") != want {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeForge) forge(srv *httptest.Server) Forge {
+	if f.gitea {
+		return &giteaForge{client: newForgeClient(srv.URL, "token secret"), repo: "acme/widgets"}
+	}
+	return &githubForge{client: newForgeClient(srv.URL, "Bearer secret"), repo: "acme/widgets"}
+}
+
+func (f *fakeForge) pull(r *http.Request) *apiPull {
+	n, _ := strconv.Atoi(r.PathValue("n"))
+	for _, p := range f.pulls {
+		if p.Number == n {
+			p.Mergeable = f.mergeable
+			return p
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeForge) listPulls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	open := []*apiPull{}
+	for _, p := range f.pulls {
+		if p.Merged {
+			continue
+		}
+		// Only GitHub filters server-side.
+		if !f.gitea && (q.Get("head") != "acme:"+p.Head.Ref || q.Get("base") != p.Base.Ref) {
+			continue
+		}
+		open = append(open, p)
+	}
+	writeJSON(w, open)
+}
+
+func (f *fakeForge) createPull(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	p := &apiPull{Number: len(f.pulls) + 1}
+	p.HTMLURL = fmt.Sprintf("https://forge.example/acme/widgets/pull/%d", p.Number)
+	p.Head.Ref, p.Head.SHA, p.Base.Ref = req["head"], "sha-"+strings.ReplaceAll(req["head"], "/", "-"), req["base"]
+	f.pulls = append(f.pulls, p)
+	f.bodies[p.Number] = req["title"] + "\n" + req["body"]
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, p)
+}
+
+func (f *fakeForge) updatePull(w http.ResponseWriter, r *http.Request) {
+	p := f.pull(r)
+	var req map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	f.bodies[p.Number] = req["title"] + "\n" + req["body"]
+	writeJSON(w, p)
+}
+
+func (f *fakeForge) getPull(w http.ResponseWriter, r *http.Request) {
+	if p := f.pull(r); p != nil {
+		writeJSON(w, p)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (f *fakeForge) currentChecks(sha string) []fakeCheck {
+	f.checkPoll++
+	if f.checkPoll <= f.pendingN {
+		var pending []fakeCheck
+		for _, c := range f.checks[sha] {
+			pending = append(pending, fakeCheck{c.name, "pending"})
+		}
+		return pending
+	}
+	return f.checks[sha]
+}
+
+// checkRuns serves GitHub check runs; the fake reports every check as a
+// check run and no commit statuses.
+func (f *fakeForge) checkRuns(w http.ResponseWriter, r *http.Request) {
+	type run struct {
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion,omitempty"`
+	}
+	runs := []run{}
+	for _, c := range f.currentChecks(r.PathValue("sha")) {
+		if c.state == "pending" {
+			runs = append(runs, run{Name: c.name, Status: "in_progress"})
+		} else {
+			runs = append(runs, run{Name: c.name, Status: "completed", Conclusion: c.state})
+		}
+	}
+	// Serve the requested page, as GitHub does (default 30 per page).
+	perPage, page := 30, 1
+	if n, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil {
+		perPage = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+		page = n
+	}
+	total := len(runs)
+	start := min((page-1)*perPage, total)
+	if page > 1 {
+		f.checkPoll-- // one poll spans every page
+	}
+	writeJSON(w, map[string]interface{}{"total_count": total, "check_runs": runs[start:min(start+perPage, total)]})
+}
+
+func (f *fakeForge) status(w http.ResponseWriter, r *http.Request) {
+	statuses := []map[string]string{}
+	if f.gitea {
+		for _, c := range f.currentChecks(r.PathValue("sha")) {
+			statuses = append(statuses, map[string]string{"context": c.name, "status": c.state})
+		}
+	}
+	writeJSON(w, map[string]interface{}{"statuses": statuses})
+}
+
+func (f *fakeForge) merge(w http.ResponseWriter, r *http.Request) {
+	p := f.pull(r)
+	var req map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if f.mergeCode != 0 {
+		w.WriteHeader(f.mergeCode)
+		writeJSON(w, map[string]string{"message": "Pull Request is not mergeable"})
+		return
+	}
+	title := req["commit_title"]
+	if f.gitea {
+		if req["Do"] != "squash" {
+			f.t.Errorf("gitea merge Do = %q, want squash", req["Do"])
+		}
+		title = req["MergeTitleField"]
+	} else if req["merge_method"] != "squash" {
+		f.t.Errorf("github merge_method = %q, want squash", req["merge_method"])
+	}
+	p.Merged = true
+	p.MergeCommitSHA = "merged-" + p.Head.Ref
+	f.merged[p.Head.Ref] = title
+	if f.gitea {
+		return // Gitea answers with an empty body
+	}
+	writeJSON(w, map[string]interface{}{"sha": p.MergeCommitSHA, "merged": true})
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestNewForge(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "gh")
+	t.Setenv("GITEA_TOKEN", "")
+	t.Setenv("MY_TOKEN", "mine")
+
+	tests := []struct {
+		name    string
+		cfg     config.ForgeConfig
+		origin  string
+		want    string // "<type> <api> <repo>"
+		wantErr string
+	}{
+		{"github https", config.ForgeConfig{}, "https://github.com/acme/widgets.git", "github https://api.github.com acme/widgets", ""},
+		{"github scp", config.ForgeConfig{}, "git@github.com:acme/widgets.git", "github https://api.github.com acme/widgets", ""},
+		{"gitea", config.ForgeConfig{Type: "gitea", TokenEnv: "MY_TOKEN"}, "ssh://git@git.example.com/acme/widgets", "gitea https://git.example.com/api/v1 acme/widgets", ""},
+		{"explicit", config.ForgeConfig{Type: "github", APIURL: "https://ghe.example/api/v3", Repo: "o/r"}, "", "github https://ghe.example/api/v3 o/r", ""},
+		{"unknown host", config.ForgeConfig{}, "https://git.example.com/acme/widgets", "", "set merge_queue.forge.type"},
+		{"no repo", config.ForgeConfig{}, "/srv/git/widgets.git", "", "set merge_queue.forge.repo"},
+		{"no token", config.ForgeConfig{Type: "gitea"}, "https://git.example.com/acme/widgets", "", "export GITEA_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewForge(tt.cfg, tt.origin)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			switch f := f.(type) {
+			case *githubForge:
+				got = "github " + f.client.baseURL + " " + f.repo
+			case *giteaForge:
+				got = "gitea " + f.client.baseURL + " " + f.repo
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForge_EnsurePRChecksAndMerge(t *testing.T) {
+	for _, gitea := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gitea=%v", gitea), func(t *testing.T) {
+			fake, srv := newFakeForge(t, gitea)
+			forge := fake.forge(srv)
+			ctx := context.Background()
+
+			pr, err := forge.EnsurePR(ctx, "polecat/nux", "main", "feat: widgets", "body")
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := forge.EnsurePR(ctx, "polecat/nux", "main", "feat: widgets v2", "body")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Number != pr.Number || len(fake.pulls) != 1 || !strings.HasPrefix(fake.bodies[pr.Number], "feat: widgets v2") {
+				t.Errorf("second EnsurePR did not update PR #%d: pulls=%d body=%q", pr.Number, len(fake.pulls), fake.bodies[pr.Number])
+			}
+
+			fake.checks[pr.HeadSHA] = []fakeCheck{{"test", "success"}, {"lint", "failure"}}
+			checks, err := forge.Checks(ctx, pr.HeadSHA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []Check{{Name: "test", State: CheckSuccess}, {Name: "lint", State: CheckFailure}}
+			if fmt.Sprint(checks) != fmt.Sprint(want) {
+				t.Errorf("Checks = %v, want %v", checks, want)
+			}
+
+			sha, err := forge.Merge(ctx, pr, config.MergeStrategySquash, "feat: widgets", "body")
+			if err != nil || sha != "merged-polecat/nux" {
+				t.Errorf("Merge = %q, %v", sha, err)
+			}
+		})
+	}
+}
+
+func TestForge_GitHubChecksPaged(t *testing.T) {
+	fake, srv := newFakeForge(t, false)
+	var checks []fakeCheck
+	for i := 0; i < 150; i++ {
+		checks = append(checks, fakeCheck{fmt.Sprintf("shard-%d", i), "success"})
+	}
+	fake.checks["abc"] = append(checks, fakeCheck{"e2e", "failure"})
+
+	got, err := fake.forge(srv).Checks(context.Background(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 151 || got[150].Name != "e2e" || got[150].State != CheckFailure {
+		t.Errorf("got %d checks, last %+v; want all 151 ending in the failing e2e", len(got), got[len(got)-1])
+	}
+}
+
+func TestForge_BadCredentials(t *testing.T) {
+	_, srv := newFakeForge(t, false)
+	forge := &githubForge{client: newForgeClient(srv.URL, "Bearer wrong"), repo: "acme/widgets"}
+	_, err := forge.PR(context.Background(), 1)
+	var fe *ForgeError
+	if !errors.As(err, &fe) || fe.Status != http.StatusUnauthorized || fe.Message != "Bad credentials" {
+		t.Errorf("err = %#v", err)
+	}
+}
+
+func TestEvaluateChecks(t *testing.T) {
+	checks := []Check{
+		{Name: "test", State: CheckSuccess},
+		{Name: "lint", State: CheckPending},
+		{Name: "test", State: CheckFailure}, // older run, superseded
+		{Name: "e2e", State: CheckFailure},
+	}
+	failed, pending := evaluateChecks(checks, nil)
+	if len(failed) != 1 || failed[0].Name != "e2e" || fmt.Sprint(pending) != "[lint]" {
+		t.Errorf("all checks: failed=%v pending=%v", failed, pending)
+	}
+	failed, pending = evaluateChecks(checks, []string{"test", "deploy"})
+	if len(failed) != 0 || fmt.Sprint(pending) != "[deploy]" {
+		t.Errorf("required checks: failed=%v pending=%v", failed, pending)
+	}
+}
+
+// prRepo is a trainRepo in merge_mode "pr" talking to a fake forge.
+func prRepo(t *testing.T, gitea bool) (*Engineer, *fakeForge, string) {
+	t.Helper()
+	e, origin := trainRepo(t)
+	fake, srv := newFakeForge(t, gitea)
+	e.config.MergeMode = config.MergeModePR
+	e.SetForge(fake.forge(srv))
+	e.forgePoll = time.Millisecond
+	return e, fake, origin
+}
+
+func TestDoPRMerge(t *testing.T) {
+	for _, gitea := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gitea=%v", gitea), func(t *testing.T) {
+			e, fake, origin := prRepo(t, gitea)
+			mr := mrBranch(t, e, "polecat/nux", "a.txt", "a\n")
+			fake.checks["sha-polecat-nux"] = []fakeCheck{{"test", "success"}}
+			fake.pendingN = 2
+			mainBefore := gitRun(t, origin, "rev-parse", "main")
+
+			mr.SourceIssue = "gt-abc"
+			result := e.doMerge(context.Background(), mr)
+			if !result.Success {
+				t.Fatalf("doMerge failed: %+v", result)
+			}
+			if result.MergeCommit != "merged-polecat/nux" || result.PRURL != "https://forge.example/acme/widgets/pull/1" {
+				t.Errorf("result = %+v", result)
+			}
+			if fake.checkPoll < 3 {
+				t.Errorf("merged after %d check polls, want to wait out pending checks", fake.checkPoll)
+			}
+			if fake.merged["polecat/nux"] != "feat: polecat/nux" || !strings.Contains(fake.bodies[1], "Source issue: gt-abc") {
+				t.Errorf("merge title %q, PR body %q", fake.merged["polecat/nux"], fake.bodies[1])
+			}
+			// The branch is published for the PR; the target is left to the forge.
+			gitRun(t, origin, "rev-parse", "--verify", "polecat/nux")
+			if head := gitRun(t, origin, "rev-parse", "main"); head != mainBefore {
+				t.Errorf("refinery pushed to main in PR mode")
+			}
+		})
+	}
+}
+
+func TestDoPRMerge_RewrittenBranch(t *testing.T) {
+	e, fake, origin := prRepo(t, false)
+	mr := mrBranch(t, e, "polecat/nux", "a.txt", "a\n")
+	fake.checks["sha-polecat-nux"] = []fakeCheck{{"test", "failure"}}
+	if result := e.doMerge(context.Background(), mr); result.Success {
+		t.Fatal("merged despite a failing check")
+	}
+
+	// The branch is rewritten before the retry; the push replaces it.
+	gitRun(t, e.workDir, "checkout", "-q", "polecat/nux")
+	writeFile(t, e.workDir, "a.txt", "fixed\n")
+	gitRun(t, e.workDir, "commit", "-q", "-a", "--amend", "--no-edit")
+	gitRun(t, e.workDir, "checkout", "-q", "main")
+	fake.checks["sha-polecat-nux"] = []fakeCheck{{"test", "success"}}
+	if result := e.doMerge(context.Background(), mr); !result.Success {
+		t.Fatalf("retry failed: %+v", result)
+	}
+	if got, want := gitRun(t, origin, "rev-parse", "polecat/nux"), gitRun(t, e.workDir, "rev-parse", "polecat/nux"); got != want {
+		t.Errorf("origin polecat/nux = %s, want the rewritten %s", got, want)
+	}
+}
+
+func TestDoPRMerge_AutoRebase(t *testing.T) {
+	e, fake, origin := prRepo(t, false)
+	e.config.OnConflict = config.OnConflictAutoRebase
+	mr := mrBranch(t, e, "polecat/nux", "a.txt", "a\n")
+	writeFile(t, e.workDir, "b.txt", "b\n")
+	gitRun(t, e.workDir, "add", ".")
+	gitRun(t, e.workDir, "commit", "-q", "-m", "moved")
+	gitRun(t, e.workDir, "push", "-q", "origin", "main")
+	fake.checks["sha-polecat-nux"] = []fakeCheck{{"test", "success"}}
+
+	result := e.doMerge(context.Background(), mr)
+	if !result.Success || result.ConflictResolution != ResolutionAutoRebase {
+		t.Fatalf("result = %+v", result)
+	}
+	// The PR branch was replayed onto the moved target.
+	gitRun(t, origin, "merge-base", "--is-ancestor", "main", "polecat/nux")
+}
+
+func TestDoPRMerge_Failures(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(*Engineer, *fakeForge)
+		want      FailureType
+		wantError string
+	}{
+		{"failing test check", func(e *Engineer, f *fakeForge) {
+			f.checks["sha-polecat-nux"] = []fakeCheck{{"build", "success"}, {"unit-tests", "failure"}}
+		}, FailureTestsFail, "checks failed on https://forge.example/acme/widgets/pull/1: unit-tests"},
+		{"failing build check", func(e *Engineer, f *fakeForge) {
+			f.checks["sha-polecat-nux"] = []fakeCheck{{"Build (linux)", "failure"}}
+		}, FailureBuildFail, "Build (linux)"},
+		{"conflicting PR", func(e *Engineer, f *fakeForge) {
+			f.mergeable = boolPtr(false)
+		}, FailureConflict, "conflicts with its base branch"},
+		{"required check never reported", func(e *Engineer, f *fakeForge) {
+			f.checks["sha-polecat-nux"] = []fakeCheck{{"test", "success"}}
+			e.config.Forge.RequiredChecks = []string{"test", "deploy-preview"}
+			e.config.Forge.CheckTimeout = "20ms"
+		}, FailureTestsFail, "waiting for checks on https://forge.example/acme/widgets/pull/1: deploy-preview"},
+		{"merge blocked by protection", func(e *Engineer, f *fakeForge) {
+			f.checks["sha-polecat-nux"] = []fakeCheck{{"test", "success"}}
+			f.mergeable = boolPtr(true)
+			f.mergeCode = http.StatusMethodNotAllowed
+		}, FailurePushFail, "not mergeable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, fake, _ := prRepo(t, false)
+			mr := mrBranch(t, e, "polecat/nux", "a.txt", "a\n")
+			tt.setup(e, fake)
+
+			result := e.doMerge(context.Background(), mr)
+			if result.Success || result.FailureType != tt.want || !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("result = %+v, want %s mentioning %q", result, tt.want, tt.wantError)
+			}
+			if result.Conflict != (tt.want == FailureConflict) || result.TestsFailed != (tt.want == FailureTestsFail) {
+				t.Errorf("Conflict=%v TestsFailed=%v for %s", result.Conflict, result.TestsFailed, tt.want)
+			}
+			if result.PRURL == "" {
+				t.Error("PRURL not set on failure")
+			}
+		})
+	}
+}
+
+func TestEngineer_LoadConfig_MergeMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(mq map[string]interface{}) {
+		data, _ := json.Marshal(map[string]interface{}{"merge_queue": mq})
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(map[string]interface{}{
+		"merge_mode": "pr",
+		"forge":      map[string]interface{}{"type": "gitea", "required_checks": []string{"ci"}, "check_timeout": "1h"},
+	})
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if e.config.MergeMode != config.MergeModeDirect {
+		t.Errorf("default MergeMode = %q", e.config.MergeMode)
+	}
+	if err := e.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if !e.prMode() || e.config.Forge.Type != "gitea" || e.config.Forge.RequiredChecks[0] != "ci" || e.config.Forge.CheckTimeout != "1h" {
+		t.Errorf("config = %+v", e.config)
+	}
+
+	write(map[string]interface{}{"merge_mode": "yolo"})
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for invalid merge_mode")
+	}
+}
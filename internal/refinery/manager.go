@@ -12,6 +12,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/mail"
@@ -59,14 +60,14 @@ func (m *Manager) SessionName() string {
 // IsRunning checks if the refinery session is active.
 // ZFC: tmux session existence is the source of truth.
 func (m *Manager) IsRunning() (bool, error) {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	return t.HasSession(m.SessionName())
 }
 
 // Status returns information about the refinery session.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Status() (*tmux.SessionInfo, error) {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	running, err := t.HasSession(sessionID)
@@ -86,7 +87,7 @@ func (m *Manager) Status() (*tmux.SessionInfo, error) {
 // The agentOverride parameter allows specifying an agent alias to use instead of the town default.
 // ZFC-compliant: no state file, tmux session is source of truth.
 func (m *Manager) Start(foreground bool, agentOverride string) error {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	if foreground {
@@ -115,7 +116,7 @@ func (m *Manager) Start(foreground bool, agentOverride string) error {
 
 	// Working directory is the refinery worktree (shares .git with mayor/polecats)
 	refineryRigDir := filepath.Join(m.rig.Path, "refinery", "rig")
-	if _, err := os.Stat(refineryRigDir); os.IsNotExist(err) {
+	if exists, _ := m.rig.Connection().Exists(refineryRigDir); !exists {
 		// Fall back to mayor/rig (legacy architecture) - ensures we use project git, not town git.
 		// Using rig.Path directly would find town's .git with rig-named remotes instead of "origin".
 		refineryRigDir = filepath.Join(m.rig.Path, "mayor", "rig")
@@ -126,8 +127,11 @@ func (m *Manager) Start(foreground bool, agentOverride string) error {
 	refineryParentDir := filepath.Join(m.rig.Path, "refinery")
 	townRoot := filepath.Dir(m.rig.Path)
 	runtimeConfig := config.ResolveRoleAgentConfig("refinery", townRoot, m.rig.Path)
-	if err := runtime.EnsureSettingsForRole(refineryParentDir, "refinery", runtimeConfig); err != nil {
-		return fmt.Errorf("ensuring runtime settings: %w", err)
+	// Remote rigs are provisioned by the town on their own machine.
+	if !m.rig.IsRemote() {
+		if err := runtime.EnsureSettingsForRole(refineryParentDir, "refinery", runtimeConfig); err != nil {
+			return fmt.Errorf("ensuring runtime settings: %w", err)
+		}
 	}
 
 	initialPrompt := session.BuildStartupPrompt(session.BeaconConfig{
@@ -196,7 +200,7 @@ func (m *Manager) Start(foreground bool, agentOverride string) error {
 // Stop stops the refinery.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Stop() error {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	// Check if tmux session exists
@@ -325,6 +329,7 @@ func (m *Manager) issueToMR(issue *beads.Issue) *MergeRequest {
 		Worker:       fields.Worker,
 		IssueID:      fields.SourceIssue,
 		TargetBranch: target,
+		Backports:    parseBackportStatus(fields.Backports, fields.BackportStatus),
 		Status:       MROpen,
 		CreatedAt:    parseTime(issue.CreatedAt),
 	}
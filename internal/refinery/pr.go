@@ -0,0 +1,364 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/tracing"
+)
+
+// defaultCheckTimeout bounds the wait for PR checks when
+// merge_queue.forge.check_timeout is not set.
+const defaultCheckTimeout = 30 * time.Minute
+
+// prMode reports whether MRs land through forge pull requests.
+func (e *Engineer) prMode() bool {
+	return e.config.MergeMode == config.MergeModePR
+}
+
+// SetForge sets the forge adapter used in merge_mode "pr".
+// This is useful for testing against a stand-in forge.
+func (e *Engineer) SetForge(f Forge) {
+	e.forge = f
+}
+
+// getForge returns the forge adapter, creating it from the merge queue
+// config and the origin remote on first use.
+func (e *Engineer) getForge() (Forge, error) {
+	if e.forge != nil {
+		return e.forge, nil
+	}
+	origin, err := e.git.RemoteURL("origin")
+	if err != nil && e.config.Forge.Repo == "" {
+		return nil, fmt.Errorf("reading origin URL: %w", err)
+	}
+	f, err := NewForge(e.config.Forge, origin)
+	if err != nil {
+		return nil, err
+	}
+	e.forge = f
+	return f, nil
+}
+
+// doPRMerge lands a branch through a forge pull request (merge_mode "pr"):
+// push the branch (rebased onto the target first under auto_rebase, if it
+// is behind), open or update its PR, wait for the PR's checks, then
+// merge it through the API with the MR's merge strategy. Nothing is pushed
+// to the target branch directly, so this works with protected branches.
+func (e *Engineer) doPRMerge(ctx context.Context, mr *MRInfo) ProcessResult {
+	branch, target, sourceIssue := mr.Branch, mr.Target, mr.SourceIssue
+	forge, err := e.getForge()
+	if err != nil {
+		return ProcessResult{
+			Success:     false,
+			FailureType: FailurePushFail,
+			Error:       fmt.Sprintf("forge not available: %v", err),
+		}
+	}
+
+	// Step 1: Verify source branch exists locally (shared .repo.git with polecats)
+	exists, err := e.git.BranchExists(branch)
+	if err != nil {
+		return ProcessResult{
+			Success:     false,
+			FailureType: FailureFetch,
+			Error:       fmt.Sprintf("failed to check branch %s: %v", branch, err),
+		}
+	}
+	if !exists {
+		return ProcessResult{
+			Success:     false,
+			FailureType: FailureFetch,
+			Error:       fmt.Sprintf("branch %s not found locally", branch),
+		}
+	}
+
+	// Step 2: With auto_rebase, replay a branch that is behind its target
+	// onto it, so the PR is checked and merged against the current target.
+	head, resolution := branch, ""
+	if e.autoRebaseEnabled() {
+		rb, result := e.rebasePRBranch(ctx, branch, target)
+		if !result.Success {
+			return result
+		}
+		if rb != nil {
+			defer e.cleanupRebase(rb)
+			head, resolution = rb.Commit, ResolutionAutoRebase
+		}
+	}
+
+	// Step 3: Publish the branch so the forge has the PR head. The branch
+	// may have been rewritten since it was last pushed (by auto_rebase, or
+	// by the polecat after a conflict), so replace it, unless someone else
+	// pushed to it since we last fetched.
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing %s to origin...\n", branch)
+	pushSpan := tracing.SpanFromContext(ctx).Start("refinery.push")
+	err = e.git.PushForceWithLease("origin", head, branch)
+	pushSpan.EndErr(err)
+	if err != nil {
+		return ProcessResult{
+			Success:            false,
+			FailureType:        FailurePushFail,
+			Error:              fmt.Sprintf("failed to push %s to origin: %v", branch, err),
+			ConflictResolution: resolution,
+		}
+	}
+
+	// Step 4: Open the PR, or update the one left open by an earlier attempt
+	title, body := splitCommitMessage(e.commitMessage(mr, "origin/"+target))
+	if sourceIssue != "" {
+		body = strings.TrimSpace(body + "\n\nSource issue: " + sourceIssue)
+	}
+	pr, err := forge.EnsurePR(ctx, branch, target, title, body)
+	if err != nil {
+		return ProcessResult{
+			Success:     false,
+			FailureType: FailurePushFail,
+			Error:       fmt.Sprintf("failed to open pull request: %v", err),
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Pull request: %s\n", pr.URL)
+
+	// Step 5: Wait for the PR's checks
+	checkSpan := tracing.SpanFromContext(ctx).Start("refinery.checks")
+	pr, result := e.awaitChecks(ctx, forge, pr)
+	if !result.Success {
+		checkSpan.SetError(errors.New(result.Error))
+	}
+	checkSpan.End()
+	result.PRURL = pr.URL
+	result.ConflictResolution = resolution
+	if !result.Success {
+		return result
+	}
+	_, _ = fmt.Fprintln(e.output, "[Engineer] Checks passed")
+
+	// Step 6: Merge through the API
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging pull request #%d...\n", pr.Number)
+	mergeCommit, err := forge.Merge(ctx, pr, e.mergeStrategy(mr), title, body)
+	if err != nil {
+		result := ProcessResult{
+			Success:            false,
+			FailureType:        FailurePushFail,
+			Error:              fmt.Sprintf("failed to merge %s: %v", pr.URL, err),
+			PRURL:              pr.URL,
+			ConflictResolution: resolution,
+		}
+		// Refused merges are conflicts only if the forge says so; otherwise
+		// branch protection is not satisfied yet and the MR is retried.
+		if errors.Is(err, ErrNotMergeable) {
+			if current, perr := forge.PR(ctx, pr.Number); perr == nil && current.Mergeable != nil && !*current.Mergeable {
+				result.Conflict = true
+				result.FailureType = FailureConflict
+			}
+		}
+		return result
+	}
+
+	// Keep the local target current for conflict checks and crew syncs
+	if err := e.git.Fetch("origin"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch from origin: %v (continuing)\n", err)
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Successfully merged %s: %s\n", pr.URL, shortSHA(mergeCommit))
+	return ProcessResult{
+		Success:            true,
+		MergeCommit:        mergeCommit,
+		PRURL:              pr.URL,
+		ConflictResolution: resolution,
+	}
+}
+
+// rebasePRBranch is auto_rebase in PR mode: if origin/target has moved past
+// branch's base, it replays branch onto it. It returns nil if the branch is
+// current. A rebase that conflicts hands the MR back, as in direct mode.
+func (e *Engineer) rebasePRBranch(ctx context.Context, branch, target string) (*rebasedBranch, ProcessResult) {
+	if err := e.git.Fetch("origin"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch from origin: %v (continuing)\n", err)
+	}
+	onto := "origin/" + target
+	if current, err := e.git.IsAncestor(onto, branch); err == nil && current {
+		return nil, ProcessResult{Success: true}
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s is behind %s, attempting auto-rebase...\n", branch, target)
+	rebaseSpan := tracing.SpanFromContext(ctx).Start("refinery.rebase")
+	rb, conflicts, err := e.rebaseBranch(branch, onto)
+	if err == nil && len(conflicts) > 0 {
+		rebaseSpan.SetError(fmt.Errorf("conflicts in %v", conflicts))
+	}
+	rebaseSpan.EndErr(err)
+	if err != nil {
+		return nil, ProcessResult{
+			Success:            false,
+			Conflict:           true,
+			ConflictResolution: ResolutionAssignBack,
+			FailureType:        FailureConflict,
+			Error:              fmt.Sprintf("auto-rebase failed: %v", err),
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, ProcessResult{
+			Success:            false,
+			Conflict:           true,
+			ConflictResolution: ResolutionAssignBack,
+			FailureType:        FailureConflict,
+			Error:              fmt.Sprintf("auto-rebase conflicts in: %v", conflicts),
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Rebased %s onto %s: %s\n", branch, target, shortSHA(rb.Commit))
+	return rb, ProcessResult{Success: true}
+}
+
+// awaitChecks polls a PR until its checks pass, a check fails, the PR
+// turns out to conflict with its base, or merge_queue.forge.check_timeout
+// passes. It returns the latest state of the PR and a successful result
+// if the PR may be merged.
+func (e *Engineer) awaitChecks(ctx context.Context, forge Forge, pr *PullRequest) (*PullRequest, ProcessResult) {
+	timeout := defaultCheckTimeout
+	if e.config.Forge.CheckTimeout != "" {
+		if d, err := time.ParseDuration(e.config.Forge.CheckTimeout); err == nil {
+			timeout = d
+		}
+	}
+	deadline := time.Now().Add(timeout)
+
+	for polls := 0; ; polls++ {
+		current, err := forge.PR(ctx, pr.Number)
+		if err != nil {
+			return pr, ProcessResult{
+				Success:     false,
+				FailureType: FailurePushFail,
+				Error:       fmt.Sprintf("failed to read pull request %s: %v", pr.URL, err),
+			}
+		}
+		pr = current
+		if pr.Mergeable != nil && !*pr.Mergeable {
+			return pr, ProcessResult{
+				Success:     false,
+				Conflict:    true,
+				FailureType: FailureConflict,
+				Error:       fmt.Sprintf("pull request %s conflicts with its base branch", pr.URL),
+			}
+		}
+
+		checks, err := forge.Checks(ctx, pr.HeadSHA)
+		if err != nil {
+			return pr, ProcessResult{
+				Success:     false,
+				FailureType: FailurePushFail,
+				Error:       fmt.Sprintf("failed to read checks for %s: %v", pr.URL, err),
+			}
+		}
+		failed, pending := evaluateChecks(checks, e.config.Forge.RequiredChecks)
+		if len(failed) > 0 {
+			return pr, checkFailure(pr, failed)
+		}
+		// A PR with no checks at all is given one poll for CI to pick it up.
+		if len(pending) == 0 && (len(checks) > 0 || polls > 0) {
+			return pr, ProcessResult{Success: true}
+		}
+
+		if time.Now().After(deadline) {
+			return pr, ProcessResult{
+				Success:     false,
+				TestsFailed: true,
+				FailureType: FailureTestsFail,
+				Error:       fmt.Sprintf("timed out after %s waiting for checks on %s: %s", timeout, pr.URL, strings.Join(pending, ", ")),
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return pr, ProcessResult{
+				Success: false,
+				Error:   "check wait canceled",
+			}
+		case <-time.After(e.forgePoll):
+		}
+	}
+}
+
+// evaluateChecks returns the failed checks and the names of the checks
+// still pending. With required names, only those checks count and a
+// required check that has not been reported yet is pending. Forges list
+// the newest result first, so the first check with a name wins.
+func evaluateChecks(checks []Check, required []string) (failed []Check, pending []string) {
+	latest := make(map[string]Check)
+	var names []string
+	for _, c := range checks {
+		if _, seen := latest[c.Name]; seen {
+			continue
+		}
+		latest[c.Name] = c
+		names = append(names, c.Name)
+	}
+	if len(required) > 0 {
+		names = required
+	}
+	for _, name := range names {
+		c, ok := latest[name]
+		switch {
+		case !ok || c.State == CheckPending:
+			pending = append(pending, name)
+		case c.State == CheckFailure:
+			failed = append(failed, c)
+		}
+	}
+	return failed, pending
+}
+
+// checkFailure maps failed checks onto a failure type: build_fail if any
+// failed check is a build or compile step, tests_fail otherwise.
+func checkFailure(pr *PullRequest, failed []Check) ProcessResult {
+	failureType := FailureTestsFail
+	var parts []string
+	for _, c := range failed {
+		if isBuildCheck(c.Name) {
+			failureType = FailureBuildFail
+		}
+		if c.URL != "" {
+			parts = append(parts, fmt.Sprintf("%s (%s)", c.Name, c.URL))
+		} else {
+			parts = append(parts, c.Name)
+		}
+	}
+	return ProcessResult{
+		Success:     false,
+		TestsFailed: failureType == FailureTestsFail,
+		FailureType: failureType,
+		Error:       fmt.Sprintf("checks failed on %s: %s", pr.URL, strings.Join(parts, ", ")),
+	}
+}
+
+// splitCommitMessage splits a commit message into its subject and body.
+func splitCommitMessage(msg string) (title, body string) {
+	msg = strings.TrimSpace(msg)
+	title, body, _ = strings.Cut(msg, "\n")
+	return strings.TrimSpace(title), strings.TrimSpace(body)
+}
+
+// recordPRURL stores the MR's pull request URL on the MR bead.
+func (e *Engineer) recordPRURL(mrID, prURL string) {
+	if mrID == "" || prURL == "" {
+		return
+	}
+	mrBead, err := e.beads.Show(mrID)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to fetch MR bead %s: %v\n", mrID, err)
+		return
+	}
+	mrFields := beads.ParseMRFields(mrBead)
+	if mrFields == nil {
+		mrFields = &beads.MRFields{}
+	}
+	mrFields.PRURL = prURL
+	newDesc := beads.SetMRFields(mrBead, mrFields)
+	if err := e.beads.Update(mrID, beads.UpdateOptions{Description: &newDesc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record pull request on %s: %v\n", mrID, err)
+	}
+}
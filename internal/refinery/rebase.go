@@ -0,0 +1,101 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Conflict resolution paths recorded on the MR bead (conflict_resolution).
+const (
+	// ResolutionAutoRebase means the refinery rebased the branch itself.
+	ResolutionAutoRebase = config.OnConflictAutoRebase
+
+	// ResolutionAssignBack means the conflict was handed back for manual
+	// resolution (a conflict-resolution task was created).
+	ResolutionAssignBack = config.OnConflictAssignBack
+)
+
+// rebasedBranch is a polecat branch replayed onto a new base in a scratch
+// worktree. The polecat's own branch ref is left untouched; the rebased
+// commit is merged by SHA.
+type rebasedBranch struct {
+	Branch string
+	Onto   string
+	Commit string // rebased tip
+	Dir    string // worktree with Commit checked out
+}
+
+// autoRebaseEnabled reports whether on_conflict is auto_rebase.
+func (e *Engineer) autoRebaseEnabled() bool {
+	return e.config.OnConflict == config.OnConflictAutoRebase
+}
+
+// rebaseBranch replays branch onto onto in a detached scratch worktree.
+//
+// On success the caller owns the returned worktree and must call cleanup.
+// If the rebase stops on conflicts, the conflicting files are returned and
+// no worktree is left behind. Other failures return an error.
+func (e *Engineer) rebaseBranch(branch, onto string) (rb *rebasedBranch, conflicts []string, err error) {
+	dir := filepath.Join(filepath.Dir(e.workDir), ".rebase", strings.ReplaceAll(branch, "/", "-"))
+	e.removeScratchWorktree(dir) // leftovers from an interrupted run
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, nil, fmt.Errorf("creating rebase directory: %w", err)
+	}
+	if err := e.git.WorktreeAddDetached(dir, branch); err != nil {
+		return nil, nil, fmt.Errorf("creating rebase worktree: %w", err)
+	}
+
+	wt := git.NewGit(dir)
+	if err := wt.Rebase(onto); err != nil {
+		conflicts, conflictErr := wt.GetConflictingFiles()
+		_ = wt.AbortRebase()
+		e.removeScratchWorktree(dir)
+		if conflictErr == nil && len(conflicts) > 0 {
+			return nil, conflicts, nil
+		}
+		return nil, nil, fmt.Errorf("rebase onto %s: %w", onto, err)
+	}
+
+	commit, err := wt.Rev("HEAD")
+	if err != nil {
+		e.removeScratchWorktree(dir)
+		return nil, nil, fmt.Errorf("reading rebased HEAD: %w", err)
+	}
+	return &rebasedBranch{Branch: branch, Onto: onto, Commit: commit, Dir: dir}, nil, nil
+}
+
+// cleanupRebase removes the rebase worktree.
+func (e *Engineer) cleanupRebase(rb *rebasedBranch) {
+	if rb == nil {
+		return
+	}
+	e.removeScratchWorktree(rb.Dir)
+	_ = e.git.WorktreePrune()
+}
+
+// recordConflictResolution stores which conflict path was taken on the MR bead.
+func (e *Engineer) recordConflictResolution(mrID, resolution string) {
+	if mrID == "" || resolution == "" {
+		return
+	}
+	mrBead, err := e.beads.Show(mrID)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to fetch MR bead %s: %v\n", mrID, err)
+		return
+	}
+	mrFields := beads.ParseMRFields(mrBead)
+	if mrFields == nil {
+		mrFields = &beads.MRFields{}
+	}
+	mrFields.ConflictResolution = resolution
+	newDesc := beads.SetMRFields(mrBead, mrFields)
+	if err := e.beads.Update(mrID, beads.UpdateOptions{Description: &newDesc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record conflict resolution on %s: %v\n", mrID, err)
+	}
+}
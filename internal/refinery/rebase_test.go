@@ -0,0 +1,114 @@
+package refinery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// cherryPickedBranch sets up a branch whose first commit was cherry-picked
+// to main and then edited further. A plain merge conflicts on README, but
+// a rebase drops the already-applied commit and replays the rest cleanly.
+func cherryPickedBranch(t *testing.T, e *Engineer) {
+	t.Helper()
+	dir := e.workDir
+	gitRun(t, dir, "checkout", "-q", "-b", "fix", "main")
+	writeFile(t, dir, "README", "v1\n")
+	gitRun(t, dir, "commit", "-q", "-am", "fix: readme")
+	writeFile(t, dir, "new.txt", "new\n")
+	gitRun(t, dir, "add", "new.txt")
+	gitRun(t, dir, "commit", "-q", "-m", "feat: new file")
+
+	gitRun(t, dir, "checkout", "-q", "main")
+	writeFile(t, dir, "other.txt", "other\n")
+	gitRun(t, dir, "add", "other.txt")
+	gitRun(t, dir, "commit", "-q", "-m", "chore: other")
+	gitRun(t, dir, "cherry-pick", "fix~1")
+	writeFile(t, dir, "README", "v2\n")
+	gitRun(t, dir, "commit", "-q", "-am", "docs: readme v2")
+	gitRun(t, dir, "push", "-q", "origin", "main")
+}
+
+func TestDoMerge_AutoRebase(t *testing.T) {
+	e, origin := trainRepo(t)
+	e.config.OnConflict = config.OnConflictAutoRebase
+	// Tests must run against the rebased tree, which has new.txt.
+	e.config.TestCommand = "test -f new.txt && grep -q v2 README"
+	cherryPickedBranch(t, e)
+
+	result := e.doMerge(context.Background(), &MRInfo{ID: "mr-fix", Branch: "fix", Target: "main"})
+	if !result.Success {
+		t.Fatalf("doMerge failed: %+v", result)
+	}
+	if result.ConflictResolution != ResolutionAutoRebase {
+		t.Errorf("ConflictResolution = %q, want %q", result.ConflictResolution, ResolutionAutoRebase)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != result.MergeCommit {
+		t.Errorf("origin/main = %s, want %s", head, result.MergeCommit)
+	}
+	if got := gitRun(t, origin, "show", "main:README"); got != "v2" {
+		t.Errorf("README = %q, want v2", got)
+	}
+	gitRun(t, origin, "cat-file", "-e", "main:new.txt")
+}
+
+func TestDoMerge_AssignBackDoesNotRebase(t *testing.T) {
+	e, _ := trainRepo(t)
+	cherryPickedBranch(t, e)
+
+	result := e.doMerge(context.Background(), &MRInfo{ID: "mr-fix", Branch: "fix", Target: "main"})
+	if result.Success || !result.Conflict {
+		t.Fatalf("expected conflict, got %+v", result)
+	}
+	if result.ConflictResolution != ResolutionAssignBack {
+		t.Errorf("ConflictResolution = %q, want %q", result.ConflictResolution, ResolutionAssignBack)
+	}
+	if result.FailureType != FailureConflict {
+		t.Errorf("FailureType = %q, want %q", result.FailureType, FailureConflict)
+	}
+}
+
+func TestDoMerge_AutoRebaseFallsBackOnRealConflict(t *testing.T) {
+	e, _ := trainRepo(t)
+	e.config.OnConflict = config.OnConflictAutoRebase
+	mrBranch(t, e, "b", "README", "from b\n")
+	writeFile(t, e.workDir, "README", "from main\n")
+	gitRun(t, e.workDir, "commit", "-q", "-am", "main edit")
+	gitRun(t, e.workDir, "push", "-q", "origin", "main")
+
+	result := e.doMerge(context.Background(), &MRInfo{ID: "mr-b", Branch: "b", Target: "main"})
+	if result.Success || !result.Conflict {
+		t.Fatalf("expected conflict, got %+v", result)
+	}
+	if result.ConflictResolution != ResolutionAssignBack {
+		t.Errorf("ConflictResolution = %q, want %q", result.ConflictResolution, ResolutionAssignBack)
+	}
+	// The polecat branch is left as it was.
+	if got := gitRun(t, e.workDir, "show", "b:README"); got != "from b" {
+		t.Errorf("branch b README = %q", got)
+	}
+}
+
+func TestProcessTrain_AutoRebaseConflictingCar(t *testing.T) {
+	e, origin := trainRepo(t)
+	e.config.OnConflict = config.OnConflictAutoRebase
+	cherryPickedBranch(t, e)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		{ID: "mr-fix", Branch: "fix", Target: "main"},
+	}
+
+	cars := e.ProcessTrain(context.Background(), mrs)
+	for _, car := range cars {
+		if !car.Result.Success {
+			t.Fatalf("%s: %+v", car.MR.ID, car.Result)
+		}
+	}
+	if cars[1].Result.ConflictResolution != ResolutionAutoRebase {
+		t.Errorf("car fix resolution = %q", cars[1].Result.ConflictResolution)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[1].Commit {
+		t.Errorf("origin/main = %s, want %s", head, cars[1].Commit)
+	}
+}
@@ -0,0 +1,144 @@
+// This file contains merge strategies: how an MR's commits land on its
+// target, and the message of the commit the refinery writes for them.
+
+package refinery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// mergeStrategy returns the merge strategy for mr: its own merge_strategy
+// if set, else the rig's.
+func (e *Engineer) mergeStrategy(mr *MRInfo) string {
+	if mr.MergeStrategy != "" {
+		return mr.MergeStrategy
+	}
+	if e.config.MergeStrategy == "" {
+		return config.MergeStrategySquash
+	}
+	return e.config.MergeStrategy
+}
+
+// mergeOnto lands ref, mr's branch or its tip rebased by auto_rebase, on
+// the commit checked out in wt, using mr's merge strategy:
+//   - squash: a single commit with the branch's changes
+//   - rebase: the branch's commits replayed onto HEAD, then fast-forwarded
+//   - merge: a merge commit (--no-ff)
+//
+// If ref doesn't apply cleanly, wt is left at HEAD and the conflicting
+// files are returned.
+func (e *Engineer) mergeOnto(wt *git.Git, mr *MRInfo, ref string) ([]string, error) {
+	head, err := wt.Rev("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	switch e.mergeStrategy(mr) {
+	case config.MergeStrategyRebase:
+		rb, conflicts, err := e.rebaseBranch(ref, head)
+		if err != nil || len(conflicts) > 0 {
+			return conflicts, err
+		}
+		defer e.cleanupRebase(rb)
+		return nil, wt.MergeFFOnly(rb.Commit)
+	case config.MergeStrategyMerge:
+		err = wt.MergeNoFF(ref, e.commitMessage(mr, head))
+	default:
+		err = wt.MergeSquash(ref, e.commitMessage(mr, head))
+	}
+	if err != nil {
+		// ZFC: Use git's porcelain output to detect conflicts instead of parsing stderr.
+		conflicts, conflictErr := wt.GetConflictingFiles()
+		if conflictErr == nil && len(conflicts) > 0 {
+			_ = wt.ResetHard(head)
+			return conflicts, nil
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+// commitMessage returns the message of the squash or merge commit landing
+// mr on base, formatted with its commit template. Without a template, a
+// squash keeps the branch's own message (feat:/fix:) and a merge commit
+// names the branch.
+//
+// Template variables: {message} (the branch's message), {title} (the
+// source issue's title), {issue}, {mr}, {branch}, {target}, {polecat},
+// {rig} and {coauthors} (a Co-authored-by trailer per commit author).
+func (e *Engineer) commitMessage(mr *MRInfo, base string) string {
+	tmpl := mr.CommitTemplate
+	if tmpl == "" {
+		tmpl = e.config.CommitTemplate
+	}
+	message := strings.TrimSpace(e.squashMessage(mr.Branch, mr.Target, mr.SourceIssue))
+	if tmpl == "" {
+		if e.mergeStrategy(mr) != config.MergeStrategyMerge {
+			return message
+		}
+		if mr.SourceIssue != "" {
+			return fmt.Sprintf("Merge %s into %s (%s)", mr.Branch, mr.Target, mr.SourceIssue)
+		}
+		return fmt.Sprintf("Merge %s into %s", mr.Branch, mr.Target)
+	}
+
+	title, _, _ := strings.Cut(message, "\n")
+	if strings.Contains(tmpl, "{title}") && mr.SourceIssue != "" {
+		if issue, err := e.beads.Show(mr.SourceIssue); err == nil && issue.Title != "" {
+			title = issue.Title
+		}
+	}
+	var coauthors []string
+	if strings.Contains(tmpl, "{coauthors}") {
+		authors, err := e.git.BranchAuthors(base, mr.Branch)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: listing authors of %s: %v\n", mr.Branch, err)
+		}
+		for _, a := range authors {
+			coauthors = append(coauthors, "Co-authored-by: "+a)
+		}
+	}
+
+	msg := strings.NewReplacer(
+		"{message}", message,
+		"{title}", title,
+		"{issue}", mr.SourceIssue,
+		"{mr}", mr.ID,
+		"{branch}", mr.Branch,
+		"{target}", mr.Target,
+		"{polecat}", mr.Worker,
+		"{rig}", e.rig.Name,
+		"{coauthors}", strings.Join(coauthors, "\n"),
+	).Replace(tmpl)
+
+	// Empty variables leave blank lines behind; keep at most one in a row.
+	for strings.Contains(msg, "\n\n\n") {
+		msg = strings.ReplaceAll(msg, "\n\n\n", "\n\n")
+	}
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return message
+	}
+	return msg
+}
+
+// landed returns the commits the MR landed as, to cherry-pick or revert
+// them: its merge commit, or the range of its commits after a rebase.
+func (r ProcessResult) landed() string {
+	if r.LandedFrom != "" {
+		return r.LandedFrom + ".." + r.MergeCommit
+	}
+	return r.MergeCommit
+}
+
+// landedFrom returns the ProcessResult.LandedFrom of mr landed on base:
+// base under the rebase strategy, where the MR lands as several commits.
+func (e *Engineer) landedFrom(mr *MRInfo, base string) string {
+	if e.mergeStrategy(mr) == config.MergeStrategyRebase {
+		return base
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// pairBranch creates a branch off main with two commits by different
+// authors.
+func pairBranch(t *testing.T, e *Engineer, branch string) *MRInfo {
+	t.Helper()
+	gitRun(t, e.workDir, "checkout", "-q", "-b", branch, "main")
+	writeFile(t, e.workDir, branch+"-1.txt", "one\n")
+	gitRun(t, e.workDir, "add", ".")
+	gitRun(t, e.workDir, "commit", "-q", "-m", "feat: "+branch+" one", "--author", "Ann <ann@example.com>")
+	writeFile(t, e.workDir, branch+"-2.txt", "two\n")
+	gitRun(t, e.workDir, "add", ".")
+	gitRun(t, e.workDir, "commit", "-q", "-m", "feat: "+branch+" two", "--author", "Bob <bob@example.com>")
+	gitRun(t, e.workDir, "checkout", "-q", "main")
+	return &MRInfo{ID: "mr-" + branch, Branch: branch, Target: "main", Worker: "nux"}
+}
+
+func TestDoMerge_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		wantLog  string // first-parent subjects on origin/main
+		parents  int    // parents of the tip
+	}{
+		{config.MergeStrategySquash, "feat: x two", 1},
+		{config.MergeStrategyRebase, "feat: x two\nfeat: x one", 1},
+		{config.MergeStrategyMerge, "Merge x into main", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			e, origin := trainRepo(t)
+			e.config.MergeStrategy = tt.strategy
+			mr := pairBranch(t, e, "x")
+			// Land on a target that moved, so rebase must replay the commits.
+			gitRun(t, e.workDir, "commit", "-q", "--allow-empty", "-m", "moved")
+			gitRun(t, e.workDir, "push", "-q", "origin", "main")
+			before := gitRun(t, origin, "rev-parse", "main")
+
+			result := e.doMerge(context.Background(), mr)
+			if !result.Success {
+				t.Fatalf("doMerge failed: %+v", result)
+			}
+			if head := gitRun(t, origin, "rev-parse", "main"); head != result.MergeCommit {
+				t.Errorf("origin/main = %s, want %s", head, result.MergeCommit)
+			}
+			log := gitRun(t, origin, "log", "--first-parent", "--format=%s", "main")
+			if got := strings.TrimSuffix(log, "\nmoved\nbase"); got != tt.wantLog {
+				t.Errorf("origin history:\n%s", log)
+			}
+			if got := len(strings.Fields(gitRun(t, origin, "log", "-1", "--format=%P", "main"))); got != tt.parents {
+				t.Errorf("tip parents = %d, want %d", got, tt.parents)
+			}
+			wantFrom := ""
+			if tt.strategy == config.MergeStrategyRebase {
+				wantFrom = before
+				if author := gitRun(t, origin, "log", "-1", "--format=%an", "main~1"); author != "Ann" {
+					t.Errorf("rebased commit author = %q, want Ann", author)
+				}
+			}
+			if result.LandedFrom != wantFrom {
+				t.Errorf("LandedFrom = %q, want %q", result.LandedFrom, wantFrom)
+			}
+		})
+	}
+}
+
+func TestCommitMessage_Template(t *testing.T) {
+	e, _ := trainRepo(t)
+	useNativeBeads(t, e)
+	issue, err := e.beads.Create(beads.CreateOptions{Title: "Add widgets", Type: "task", Priority: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := pairBranch(t, e, "x")
+	mr.SourceIssue = issue.ID
+	e.config.CommitTemplate = "{title} ({issue})\n\n{message}\n\nPolecat: {rig}/{polecat}\n{coauthors}"
+
+	want := "Add widgets (" + issue.ID + ")\n\nfeat: x two\n\nPolecat: test-rig/nux\n" +
+		"Co-authored-by: Ann <ann@example.com>\nCo-authored-by: Bob <bob@example.com>"
+	if got := e.commitMessage(mr, "main"); got != want {
+		t.Errorf("commitMessage =\n%s\nwant\n%s", got, want)
+	}
+
+	// Empty variables don't leave runs of blank lines; the MR's own
+	// template overrides the rig's.
+	mr.SourceIssue, mr.Worker = "", ""
+	mr.CommitTemplate = "{message}\n\n{issue}\n\n{coauthors}"
+	if got := e.commitMessage(mr, "x"); got != "feat: x two" {
+		t.Errorf("commitMessage = %q", got)
+	}
+}
+
+func TestProcessQueue_PerMRStrategyOverride(t *testing.T) {
+	e, origin := trainRepo(t)
+	useNativeBeads(t, e)
+	pairBranch(t, e, "x")
+	if _, err := e.beads.Create(beads.CreateOptions{
+		Title:    "Merge: x",
+		Type:     "merge-request",
+		Priority: 1,
+		Description: beads.FormatMRFields(&beads.MRFields{
+			Branch:         "x",
+			Target:         "main",
+			MergeStrategy:  config.MergeStrategyMerge,
+			CommitTemplate: `Merge {branch}\n\n{coauthors}`,
+		}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if merged, err := e.ProcessQueue(context.Background()); err != nil || merged != 1 {
+		t.Fatalf("ProcessQueue = %d, %v", merged, err)
+	}
+	msg := gitRun(t, origin, "log", "-1", "--format=%B", "main")
+	if msg != "Merge x\n\nCo-authored-by: Ann <ann@example.com>\nCo-authored-by: Bob <bob@example.com>" {
+		t.Errorf("merge commit message:\n%s", msg)
+	}
+	if log := gitRun(t, origin, "log", "--format=%s", "main^2"); log != "feat: x two\nfeat: x one\nbase" {
+		t.Errorf("merged branch history:\n%s", log)
+	}
+}
+
+func TestProcessBackports_RebasedAndMergedMRs(t *testing.T) {
+	for _, strategy := range []string{config.MergeStrategyRebase, config.MergeStrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			e, origin := trainRepo(t)
+			useNativeBeads(t, e)
+			e.config.MergeStrategy = strategy
+			releaseBranch(t, e, "release/1.2", "", "")
+			mr := pairBranch(t, e, "x")
+			mr.Backports = []string{"release/1.2"}
+			cars := e.ProcessTrain(context.Background(), []*MRInfo{mr})
+			if !cars[0].Result.Success {
+				t.Fatalf("did not land: %+v", cars[0].Result)
+			}
+
+			results := e.ProcessBackports(context.Background(), mr, cars[0].Result.landed())
+			if got := backportSummary(results); got != "release/1.2=merged" {
+				t.Fatalf("backports = %s (%s)", got, results[0].Error)
+			}
+			// Both commits' changes reach the release branch.
+			for _, f := range []string{"x-1.txt", "x-2.txt"} {
+				gitRun(t, origin, "cat-file", "-e", "release/1.2:"+f)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package refinery
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TestOutcome is the result of one test in a check stage attempt.
+type TestOutcome struct {
+	// Suite is the Go package or JUnit classname (may be empty).
+	Suite string `json:"suite,omitempty"`
+
+	// Name is the test name, including any subtest path.
+	Name string `json:"name"`
+
+	Failed bool `json:"failed"`
+
+	// Output is the test's own output, kept for failed tests.
+	Output string `json:"output,omitempty"`
+}
+
+// ID returns the package-qualified test name.
+func (t TestOutcome) ID() string {
+	if t.Suite == "" {
+		return t.Name
+	}
+	return t.Suite + "." + t.Name
+}
+
+// goTestEvent is one line of "go test -json" output.
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Output  string
+}
+
+// parseGoTestJSON extracts test outcomes from "go test -json" output. It
+// also returns the packages that failed for reasons other than failed
+// tests (a build failure, a panic, a timeout, a failing TestMain) and the
+// decoded text output, for log tails. ok is false if the output contains
+// no test events.
+func parseGoTestJSON(output []byte) (tests []TestOutcome, brokenPkgs []string, text string, ok bool) {
+	var sb strings.Builder
+	testOutput := make(map[string]*strings.Builder)
+	running := make(map[string]map[string]bool) // package -> tests without a result
+	failedIn := make(map[string]bool)           // packages with a failed test
+	panicked := make(map[string]bool)           // packages with a test that panicked
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var ev goTestEvent
+		if err := json.Unmarshal(line, &ev); err != nil || ev.Action == "" {
+			continue
+		}
+		ok = true
+		key := ev.Package + "." + ev.Test
+		switch ev.Action {
+		case "build-output":
+			sb.WriteString(ev.Output)
+		case "output":
+			sb.WriteString(ev.Output)
+			if ev.Test != "" {
+				if testOutput[key] == nil {
+					testOutput[key] = &strings.Builder{}
+				}
+				testOutput[key].WriteString(ev.Output)
+				if strings.HasPrefix(ev.Output, "panic: ") {
+					panicked[ev.Package] = true
+				}
+			}
+		case "run":
+			if running[ev.Package] == nil {
+				running[ev.Package] = make(map[string]bool)
+			}
+			running[ev.Package][ev.Test] = true
+		case "skip":
+			delete(running[ev.Package], ev.Test)
+		case "pass", "fail":
+			if ev.Test == "" {
+				// A failed package is explained by its failed tests only if
+				// every test it started finished and none panicked.
+				if ev.Action == "fail" && (!failedIn[ev.Package] || panicked[ev.Package] || len(running[ev.Package]) > 0) {
+					brokenPkgs = append(brokenPkgs, ev.Package)
+				}
+				continue
+			}
+			delete(running[ev.Package], ev.Test)
+			t := TestOutcome{Suite: ev.Package, Name: ev.Test, Failed: ev.Action == "fail"}
+			if t.Failed {
+				failedIn[ev.Package] = true
+				if testOutput[key] != nil {
+					t.Output = testOutput[key].String()
+				}
+			}
+			tests = append(tests, t)
+		}
+	}
+	return tests, brokenPkgs, sb.String(), ok
+}
+
+// junitSuite matches both <testsuites> and <testsuite> elements.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnit extracts test outcomes from a JUnit XML report. Skipped
+// tests are left out.
+func parseJUnit(data []byte) ([]TestOutcome, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var tests []TestOutcome
+	var walk func(s junitSuite)
+	walk = func(s junitSuite) {
+		for _, c := range s.Cases {
+			if c.Skipped != nil {
+				continue
+			}
+			t := TestOutcome{Suite: c.ClassName, Name: c.Name}
+			if t.Suite == "" {
+				t.Suite = s.Name
+			}
+			for _, f := range []*junitFailure{c.Failure, c.Error} {
+				if f == nil {
+					continue
+				}
+				t.Failed = true
+				t.Output = strings.TrimSpace(strings.Join([]string{f.Message, f.Text, c.SystemOut}, "\n"))
+			}
+			tests = append(tests, t)
+		}
+		for _, child := range s.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	return tests, nil
+}
+
+// readJUnit parses every report matching pattern under dir. Unreadable or
+// malformed reports are skipped.
+func readJUnit(dir, pattern string) []TestOutcome {
+	paths, _ := filepath.Glob(filepath.Join(dir, pattern))
+	var tests []TestOutcome
+	for _, p := range paths {
+		data, err := os.ReadFile(p) //nolint:gosec // G304: pattern is from trusted rig config
+		if err != nil {
+			continue
+		}
+		parsed, err := parseJUnit(data)
+		if err != nil {
+			continue
+		}
+		tests = append(tests, parsed...)
+	}
+	return tests
+}
+
+// removeJUnit deletes reports left by an earlier attempt so they are not
+// mistaken for the next attempt's results.
+func removeJUnit(dir, pattern string) {
+	paths, _ := filepath.Glob(filepath.Join(dir, pattern))
+	for _, p := range paths {
+		_ = os.Remove(p)
+	}
+}
+
+// failedTests returns the failed tests in tests.
+func failedTests(tests []TestOutcome) []TestOutcome {
+	var failed []TestOutcome
+	for _, t := range tests {
+		if t.Failed {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,488 @@
+// Package refinery provides the merge queue processing agent.
+// This file contains the speculative merge train used when
+// MergeQueueConfig.MaxConcurrent is greater than one.
+
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/tracing"
+)
+
+// TrainCar is one MR in a speculative merge train.
+//
+// Cars are stacked: each car's worktree starts from the predicted merge
+// commit of the car ahead of it, so car N is tested against the target as
+// it will look once cars 1..N-1 have landed.
+type TrainCar struct {
+	MR *MRInfo
+
+	// Base is the commit this car was stacked on.
+	Base string
+
+	// Commit is the predicted merge commit (MR merged onto Base).
+	// Empty if the car could not be built (missing branch, conflict).
+	Commit string
+
+	// Result is the outcome for this MR.
+	Result ProcessResult
+
+	// Invalidated is set when a car ahead of this one failed after this
+	// car was stacked on it. Invalidated MRs did nothing wrong and go back
+	// to the queue to be rebuilt on the new target.
+	Invalidated bool
+
+	// Log holds the car's test output messages.
+	Log bytes.Buffer
+
+	workDir    string
+	ahead      *TrainCar // nearest built car this one is stacked on
+	resolution string    // conflict path taken while building (auto_rebase)
+	span       *tracing.Span
+}
+
+// SortMRsByScore orders MRs by ScoreMR priority (highest first).
+// Ties fall back to creation time, then ID, so the order is stable.
+func SortMRsByScore(mrs []*MRInfo, now time.Time) {
+	sort.SliceStable(mrs, func(i, j int) bool {
+		si, sj := mrs[i].ScoreAt(now), mrs[j].ScoreAt(now)
+		if si != sj {
+			return si > sj
+		}
+		if !mrs[i].CreatedAt.Equal(mrs[j].CreatedAt) {
+			return mrs[i].CreatedAt.Before(mrs[j].CreatedAt)
+		}
+		return mrs[i].ID < mrs[j].ID
+	})
+}
+
+// NextTrain selects the MRs for the next train: the highest-scoring MR's
+// target decides the train, and up to max MRs for that target are taken
+// in score order. MRs for other targets wait for a later train.
+func NextTrain(mrs []*MRInfo, max int, now time.Time) []*MRInfo {
+	if len(mrs) == 0 {
+		return nil
+	}
+	if max < 1 {
+		max = 1
+	}
+	sorted := make([]*MRInfo, len(mrs))
+	copy(sorted, mrs)
+	SortMRsByScore(sorted, now)
+
+	target := sorted[0].Target
+	var train []*MRInfo
+	for _, mr := range sorted {
+		if mr.Target != target {
+			continue
+		}
+		train = append(train, mr)
+		if len(train) == max {
+			break
+		}
+	}
+	return train
+}
+
+// trainDir is where train worktrees are created, next to the refinery clone.
+func (e *Engineer) trainDir() string {
+	return filepath.Join(filepath.Dir(e.workDir), ".train")
+}
+
+// ProcessTrain runs a speculative merge train over mrs, which must share a
+// target branch and be in merge order (see NextTrain).
+//
+// Each MR is merged with the configured strategy in its own worktree on
+// top of the predicted result of the MRs ahead of it. Tests for all cars
+// run concurrently. Cars then land in order by fast-forwarding the target
+// to each car's commit.
+// When a car fails its tests, the cars stacked behind it are cancelled and
+// marked Invalidated; cars ahead of it still land. Conflicts and missing
+// branches fail only that car, since nothing is stacked on it.
+func (e *Engineer) ProcessTrain(ctx context.Context, mrs []*MRInfo) []*TrainCar {
+	cars := make([]*TrainCar, len(mrs))
+	for i, mr := range mrs {
+		cars[i] = &TrainCar{MR: mr}
+	}
+	if len(cars) == 0 {
+		return cars
+	}
+	target := mrs[0].Target
+
+	defer e.cleanupTrain(cars)
+	defer e.startCarSpans(cars, "gt.train_size")()
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Building merge train of %d MR(s) for %s\n", len(cars), target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", target, err)
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		for _, car := range cars {
+			car.Result = ProcessResult{FailureType: FailureFetch, Error: fmt.Sprintf("failed to resolve origin/%s: %v", target, err)}
+		}
+		return cars
+	}
+
+	// Phase 1: build the stack sequentially (merges are cheap).
+	var ahead *TrainCar
+	for i, car := range cars {
+		car.Base = base
+		car.ahead = ahead
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: %s (%s) on %s\n", i+1, car.MR.ID, car.MR.Branch, shortSHA(base))
+		if err := e.buildCar(car); err != nil {
+			car.Result = *err
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: %s\n", i+1, car.Result.Error)
+			continue
+		}
+		base = car.Commit
+		ahead = car
+	}
+
+	// Phase 2: test all built cars concurrently. A failure cancels every
+	// car stacked behind it, since their predicted base is now wrong.
+	e.testTrain(ctx, cars)
+
+	// Phase 3: land passing cars in order.
+	for i, car := range cars {
+		if car.Commit == "" {
+			continue // never built; result already set
+		}
+		if car.Invalidated {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: %s invalidated (%s)\n", i+1, car.MR.ID, car.Result.Error)
+			continue
+		}
+		if !car.Result.Success {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: %s failed: %s\n", i+1, car.MR.ID, car.Result.Error)
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: pushing %s to origin/%s...\n", i+1, shortSHA(car.Commit), target)
+		if err := e.git.PushCommit("origin", car.Commit, target); err != nil {
+			car.Result = ProcessResult{FailureType: FailurePushFail, Error: fmt.Sprintf("failed to push to origin: %v", err)}
+			invalidateBehind(cars, car)
+			continue
+		}
+		car.Result.MergeCommit = car.Commit
+		car.Result.LandedFrom = e.landedFrom(car.MR, car.Base)
+		car.Result.ConflictResolution = car.resolution
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Car %d: merged %s\n", i+1, shortSHA(car.Commit))
+	}
+
+	return cars
+}
+
+// startCarSpans starts each car's queue wait and merge spans in its source
+// issue's trace, tagging the merge span with the number of cars under
+// sizeAttr. The returned func ends the merge spans with the cars' results.
+func (e *Engineer) startCarSpans(cars []*TrainCar, sizeAttr string) func() {
+	for _, car := range cars {
+		mr := car.MR
+		if !mr.CreatedAt.IsZero() {
+			e.startMRSpan(mr.TraceParent, mr.ID, "mq.wait", mr.CreatedAt).End()
+		}
+		car.span = e.startMRSpan(mr.TraceParent, mr.ID, "refinery.merge", time.Now())
+		car.span.SetAttr("gt.branch", mr.Branch).SetAttr(sizeAttr, len(cars))
+	}
+	return func() {
+		for _, car := range cars {
+			car.span.SetAttr("gt.invalidated", car.Invalidated)
+			endMRSpan(car.span, car.Result)
+		}
+	}
+}
+
+// buildCar creates the car's worktree at car.Base and merges its branch.
+// Returns a failure result if the car cannot join the train.
+func (e *Engineer) buildCar(car *TrainCar) *ProcessResult {
+	mr := car.MR
+	exists, err := e.git.BranchExists(mr.Branch)
+	if err != nil {
+		return &ProcessResult{FailureType: FailureFetch, Error: fmt.Sprintf("failed to check branch %s: %v", mr.Branch, err)}
+	}
+	if !exists {
+		return &ProcessResult{FailureType: FailureFetch, Error: fmt.Sprintf("branch %s not found locally", mr.Branch)}
+	}
+
+	dir := filepath.Join(e.trainDir(), mr.ID)
+	e.removeScratchWorktree(dir) // leftovers from an interrupted train
+	if err := os.MkdirAll(e.trainDir(), 0755); err != nil {
+		return &ProcessResult{FailureType: FailureCheckout, Error: fmt.Sprintf("creating train directory: %v", err)}
+	}
+	if err := e.git.WorktreeAddDetached(dir, car.Base); err != nil {
+		return &ProcessResult{FailureType: FailureCheckout, Error: fmt.Sprintf("creating worktree: %v", err)}
+	}
+	car.workDir = dir
+
+	wt := git.NewGit(dir)
+	conflicts, err := e.mergeOnto(wt, mr, mr.Branch)
+	if err != nil {
+		e.removeScratchWorktree(dir)
+		car.workDir = ""
+		return &ProcessResult{FailureType: FailureBuildFail, Error: fmt.Sprintf("merge failed: %v", err)}
+	}
+	if len(conflicts) > 0 {
+		// A rebase conflict under the rebase strategy won't go away by
+		// rebasing again.
+		if !e.autoRebaseEnabled() || e.mergeStrategy(mr) == config.MergeStrategyRebase {
+			e.removeScratchWorktree(dir)
+			car.workDir = ""
+			return &ProcessResult{Conflict: true, ConflictResolution: ResolutionAssignBack, FailureType: FailureConflict, Error: fmt.Sprintf("merge conflicts in: %v", conflicts)}
+		}
+		if result := e.rebaseCar(car, wt); result != nil {
+			e.removeScratchWorktree(dir)
+			car.workDir = ""
+			return result
+		}
+	}
+
+	commit, err := wt.Rev("HEAD")
+	if err != nil {
+		return &ProcessResult{FailureType: FailureBuildFail, Error: fmt.Sprintf("failed to get merge commit SHA: %v", err)}
+	}
+	car.Commit = commit
+	return nil
+}
+
+// rebaseCar handles a conflicting car under auto_rebase: the branch is
+// rebased onto car.Base and the rebased tip is merged in the car's
+// worktree instead. Returns a failure result if the rebase conflicts too.
+func (e *Engineer) rebaseCar(car *TrainCar, wt *git.Git) *ProcessResult {
+	if err := wt.ResetHard(car.Base); err != nil {
+		return &ProcessResult{FailureType: FailureCheckout, Error: fmt.Sprintf("resetting worktree: %v", err)}
+	}
+	rb, conflicts, err := e.rebaseBranch(car.MR.Branch, car.Base)
+	if err != nil {
+		return &ProcessResult{Conflict: true, ConflictResolution: ResolutionAssignBack, FailureType: FailureConflict, Error: fmt.Sprintf("auto-rebase failed: %v", err)}
+	}
+	if len(conflicts) > 0 {
+		return &ProcessResult{Conflict: true, ConflictResolution: ResolutionAssignBack, FailureType: FailureConflict, Error: fmt.Sprintf("auto-rebase conflicts in: %v", conflicts)}
+	}
+	defer e.cleanupRebase(rb)
+
+	if conflicts, err := e.mergeOnto(wt, car.MR, rb.Commit); err != nil || len(conflicts) > 0 {
+		if err == nil {
+			err = fmt.Errorf("conflicts in %v", conflicts)
+		}
+		return &ProcessResult{FailureType: FailureBuildFail, Error: fmt.Sprintf("merge of rebased branch failed: %v", err)}
+	}
+	car.resolution = ResolutionAutoRebase
+	return nil
+}
+
+// testTrain runs tests for every built car concurrently. When a car fails,
+// all cars stacked behind it are cancelled and marked Invalidated.
+func (e *Engineer) testTrain(ctx context.Context, cars []*TrainCar) {
+	runTests := len(e.checkStages()) > 0
+
+	ctxs := make([]context.Context, len(cars))
+	cancels := make([]context.CancelFunc, len(cars))
+	for i := range cars {
+		ctxs[i], cancels[i] = context.WithCancel(ctx)
+		defer cancels[i]()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, car := range cars {
+		if car.Commit == "" {
+			continue
+		}
+		if !runTests {
+			car.Result = ProcessResult{Success: true}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, car *TrainCar) {
+			defer wg.Done()
+			testSpan := car.span.Start("refinery.tests")
+			changed, err := e.git.ChangedFiles(car.Base, car.Commit)
+			if err != nil {
+				changed = nil // run every stage
+			}
+			result := e.runChecks(ctxs[i], car.workDir, changed, &car.Log)
+			if !result.Success {
+				testSpan.SetError(errors.New(result.Error))
+			}
+			testSpan.End()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if car.Invalidated {
+				return // a car ahead already failed; keep that reason
+			}
+			car.Result = result
+			if !result.Success {
+				for j := i + 1; j < len(cars); j++ {
+					cancels[j]()
+				}
+				invalidateBehind(cars, car)
+			}
+		}(i, car)
+	}
+	wg.Wait()
+}
+
+// invalidateBehind marks every built car stacked (directly or transitively)
+// on failed as invalidated.
+func invalidateBehind(cars []*TrainCar, failed *TrainCar) {
+	for _, car := range cars {
+		if car == failed || car.Commit == "" || car.Invalidated {
+			continue
+		}
+		for a := car.ahead; a != nil; a = a.ahead {
+			if a == failed {
+				car.Invalidated = true
+				car.Result = ProcessResult{Error: fmt.Sprintf("stacked behind %s, which failed", failed.MR.ID)}
+				break
+			}
+		}
+	}
+}
+
+// cleanupTrain removes all train worktrees.
+func (e *Engineer) cleanupTrain(cars []*TrainCar) {
+	for _, car := range cars {
+		if car.workDir != "" {
+			e.removeScratchWorktree(car.workDir)
+		}
+	}
+	_ = e.git.WorktreePrune()
+}
+
+// removeScratchWorktree force-removes a train or rebase worktree, tolerating
+// partial state.
+func (e *Engineer) removeScratchWorktree(dir string) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return
+	}
+	if err := e.git.WorktreeRemove(dir, true); err != nil {
+		_ = os.RemoveAll(dir)
+		_ = e.git.WorktreePrune()
+	}
+}
+
+// ProcessQueue processes the next batch of ready MRs.
+//
+// With max_concurrent <= 1 the highest-scoring MR is merged on its own,
+// as a one-car train. Otherwise up to max_concurrent MRs for the same
+// target are claimed and run as a speculative train (see ProcessTrain).
+// With batch_size > 1, up to batch_size MRs are claimed and merged as one
+// tested batch instead (see ProcessBatch). In merge_mode "pr" the forge
+// merges, so MRs always go one at a time. Merged and failed MRs are handled as usual;
+// invalidated MRs are released back to the queue. Merged MRs are then
+// backported (see ProcessBackports). With post_merge_verify, the target
+// is verified after MRs land, backports wait until it verifies green, and
+// only the revert MR is processed while the queue is paused for a broken
+// target.
+// Returns the number of MRs merged.
+func (e *Engineer) ProcessQueue(ctx context.Context) (int, error) {
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return 0, err
+	}
+	paused := false
+	if e.config.PostMergeVerify {
+		if ready, paused = e.holdForPause(ctx, ready); paused && len(ready) == 0 {
+			return 0, nil
+		}
+	}
+	max := e.config.MaxConcurrent
+	batch := e.config.BatchSize > 1
+	if batch {
+		max = e.config.BatchSize
+	}
+	if e.prMode() {
+		max, batch = 1, false
+	}
+	mrs := NextTrain(ready, max, time.Now())
+	if len(mrs) == 0 {
+		_, _ = fmt.Fprintln(e.output, "[Engineer] Queue empty")
+		return 0, nil
+	}
+
+	worker := e.rig.Name + "/refinery"
+	var claimed []*MRInfo
+	for _, mr := range mrs {
+		if err := e.ClaimMR(mr.ID, worker); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to claim %s: %v\n", mr.ID, err)
+			continue
+		}
+		claimed = append(claimed, mr)
+	}
+	if len(claimed) == 0 {
+		return 0, fmt.Errorf("could not claim any of %d ready MR(s)", len(mrs))
+	}
+
+	// A single MR runs as a one-car train, so it is tested merged onto
+	// the target exactly like a longer train. That includes the revert
+	// MR while the queue is paused for a broken target.
+	var cars []*TrainCar
+	switch {
+	case e.prMode():
+		mr := claimed[0]
+		cars = []*TrainCar{{MR: mr, Result: e.ProcessMRInfo(ctx, mr)}}
+	case batch && len(claimed) > 1 && !paused:
+		cars = e.ProcessBatch(ctx, claimed)
+	default:
+		cars = e.ProcessTrain(ctx, claimed)
+	}
+
+	merged := 0
+	for _, car := range cars {
+		switch {
+		case car.Result.Success:
+			e.HandleMRInfoSuccess(car.MR, car.Result)
+			if !e.verifiesTargets() {
+				e.recordBackports(car.MR.ID, e.ProcessBackports(ctx, car.MR, car.Result.landed()))
+			}
+			merged++
+		case car.Invalidated:
+			if err := e.ReleaseMR(car.MR.ID); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release %s: %v\n", car.MR.ID, err)
+			} else {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Requeued %s: %s\n", car.MR.ID, car.Result.Error)
+			}
+		default:
+			e.HandleMRInfoFailure(car.MR, car.Result)
+			if err := e.ReleaseMR(car.MR.ID); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release %s: %v\n", car.MR.ID, err)
+			}
+		}
+	}
+	if merged > 0 && e.config.PostMergeVerify {
+		e.postMergeVerify(ctx, cars)
+	}
+	return merged, nil
+}
+
+// squashMessage returns the commit message for squash-merging branch,
+// preserving the branch's own message (feat:/fix:) when available.
+func (e *Engineer) squashMessage(branch, target, sourceIssue string) string {
+	msg, err := e.git.GetBranchCommitMessage(branch)
+	if err == nil && strings.TrimSpace(msg) != "" {
+		return msg
+	}
+	if sourceIssue != "" {
+		return fmt.Sprintf("Squash merge %s into %s (%s)", branch, target, sourceIssue)
+	}
+	return fmt.Sprintf("Squash merge %s into %s", branch, target)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
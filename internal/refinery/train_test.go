@@ -0,0 +1,204 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// trainRepo creates a rig with a bare origin and a refinery clone on main.
+func trainRepo(t *testing.T) (*Engineer, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "Test")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "test@example.com")
+	}
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	rigPath := filepath.Join(root, "rig")
+	clone := filepath.Join(rigPath, "refinery", "rig")
+
+	gitRun(t, root, "init", "--bare", "-b", "main", origin)
+	gitRun(t, root, "clone", origin, clone)
+	gitRun(t, clone, "checkout", "-b", "main")
+	writeFile(t, clone, "README", "base\n")
+	gitRun(t, clone, "add", ".")
+	gitRun(t, clone, "commit", "-m", "base")
+	gitRun(t, clone, "push", "origin", "main")
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(io.Discard)
+	e.config.RunTests = true
+	e.config.TestCommand = "test ! -f broken"
+	return e, origin
+}
+
+// mrBranch creates a branch off main that writes name=content.
+func mrBranch(t *testing.T, e *Engineer, branch, name, content string) *MRInfo {
+	t.Helper()
+	gitRun(t, e.workDir, "checkout", "-q", "-b", branch, "main")
+	writeFile(t, e.workDir, name, content)
+	gitRun(t, e.workDir, "add", ".")
+	gitRun(t, e.workDir, "commit", "-q", "-m", "feat: "+branch)
+	gitRun(t, e.workDir, "checkout", "-q", "main")
+	return &MRInfo{ID: "mr-" + branch, Branch: branch, Target: "main"}
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessTrain_AllPass(t *testing.T) {
+	e, origin := trainRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+	}
+
+	cars := e.ProcessTrain(context.Background(), mrs)
+	for _, car := range cars {
+		if !car.Result.Success || car.Result.MergeCommit == "" {
+			t.Fatalf("%s: result = %+v", car.MR.ID, car.Result)
+		}
+	}
+	// Each car is stacked on the one ahead of it.
+	if cars[1].Base != cars[0].Commit || cars[2].Base != cars[1].Commit {
+		t.Errorf("cars not stacked: %s <- %s, %s <- %s", cars[0].Commit, cars[1].Base, cars[1].Commit, cars[2].Base)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[2].Commit {
+		t.Errorf("origin/main = %s, want last car %s", head, cars[2].Commit)
+	}
+	if log := gitRun(t, origin, "log", "--format=%s", "main"); log != "feat: c\nfeat: b\nfeat: a\nbase" {
+		t.Errorf("origin history:\n%s", log)
+	}
+	if _, err := os.Stat(e.trainDir()); err == nil {
+		if entries, _ := os.ReadDir(e.trainDir()); len(entries) != 0 {
+			t.Errorf("train worktrees not cleaned up: %v", entries)
+		}
+	}
+}
+
+func TestProcessTrain_FailureInvalidatesOnlyCarsBehind(t *testing.T) {
+	e, origin := trainRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "bad", "broken", "x\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+	}
+
+	cars := e.ProcessTrain(context.Background(), mrs)
+	if !cars[0].Result.Success {
+		t.Errorf("car ahead of failure should land: %+v", cars[0].Result)
+	}
+	if cars[1].Result.Success || !cars[1].Result.TestsFailed || cars[1].Result.FailureType != FailureTestsFail || cars[1].Invalidated {
+		t.Errorf("failing car: invalidated=%v result=%+v", cars[1].Invalidated, cars[1].Result)
+	}
+	if cars[2].Result.Success || !cars[2].Invalidated {
+		t.Errorf("car behind failure should be invalidated: %+v", cars[2].Result)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[0].Commit {
+		t.Errorf("origin/main = %s, want first car %s", head, cars[0].Commit)
+	}
+}
+
+func TestProcessTrain_ConflictSkipsCar(t *testing.T) {
+	e, origin := trainRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "README", "from a\n"),
+		mrBranch(t, e, "b", "README", "from b\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+	}
+
+	cars := e.ProcessTrain(context.Background(), mrs)
+	if !cars[1].Result.Conflict || cars[1].Commit != "" {
+		t.Errorf("car b should conflict: %+v", cars[1].Result)
+	}
+	// c is stacked on a, not on the conflicting b, so it still lands.
+	if cars[2].Base != cars[0].Commit || !cars[2].Result.Success {
+		t.Errorf("car c: base=%s result=%+v", cars[2].Base, cars[2].Result)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != cars[2].Commit {
+		t.Errorf("origin/main = %s, want %s", head, cars[2].Commit)
+	}
+}
+
+func TestProcessQueue_SingleMRTestedMerged(t *testing.T) {
+	e, origin := trainRepo(t)
+	useNativeBeads(t, e)
+	before := gitRun(t, origin, "rev-parse", "main")
+	mrBranch(t, e, "bad", "broken", "x\n")
+	if _, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Merge: bad",
+		Type:        "merge-request",
+		Priority:    1,
+		Description: beads.FormatMRFields(&beads.MRFields{Branch: "bad", Target: "main"}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only MR in the round must be tested with its changes merged in.
+	if merged, err := e.ProcessQueue(context.Background()); err != nil || merged != 0 {
+		t.Fatalf("ProcessQueue = %d, %v", merged, err)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); head != before {
+		t.Errorf("origin/main moved to %s; MR breaking the checks landed", head)
+	}
+}
+
+func TestNextTrain(t *testing.T) {
+	now := time.Now()
+	mrs := []*MRInfo{
+		{ID: "low", Target: "main", Priority: 3, CreatedAt: now},
+		{ID: "other", Target: "release", Priority: 1, CreatedAt: now},
+		{ID: "high", Target: "main", Priority: 0, CreatedAt: now},
+		{ID: "mid", Target: "main", Priority: 2, CreatedAt: now},
+	}
+
+	got := NextTrain(mrs, 2, now)
+	if len(got) != 2 || got[0].ID != "high" || got[1].ID != "mid" {
+		t.Errorf("NextTrain = %v", trainIDs(got))
+	}
+	if got := NextTrain(mrs, 0, now); len(got) != 1 || got[0].ID != "high" {
+		t.Errorf("NextTrain(max=0) = %v", trainIDs(got))
+	}
+	if NextTrain(nil, 3, now) != nil {
+		t.Error("NextTrain(nil) should be nil")
+	}
+}
+
+func trainIDs(mrs []*MRInfo) []string {
+	var ids []string
+	for _, mr := range mrs {
+		ids = append(ids, mr.ID)
+	}
+	return ids
+}
@@ -30,6 +30,10 @@ type MergeRequest struct {
 	// TargetBranch is where this should merge (usually integration or main).
 	TargetBranch string `json:"target_branch"`
 
+	// Backports maps each branch the MR is backported to after merging
+	// to its backport status (BackportPending until processed).
+	Backports map[string]string `json:"backports,omitempty"`
+
 	// CreatedAt is when the MR was queued.
 	CreatedAt time.Time `json:"created_at"`
 
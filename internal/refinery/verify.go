@@ -0,0 +1,500 @@
+// This file contains post-merge verification, used when
+// MergeQueueConfig.PostMergeVerify is set.
+
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// VerifyState is a rig's post-merge verification state.
+type VerifyState struct {
+	// Green maps each target branch to the last commit verified green.
+	Green map[string]string `json:"green,omitempty"`
+
+	// Merges are the merges landed since their target was last verified
+	// green, oldest first. They are the candidates when bisecting.
+	Merges []LandedMerge `json:"merges,omitempty"`
+
+	// Reverted lists the merge commits the refinery has queued reverts
+	// for, so a target still broken after a revert isn't reverted twice.
+	Reverted []string `json:"reverted,omitempty"`
+
+	// Pause is set while a target branch is broken.
+	Pause *QueuePause `json:"pause,omitempty"`
+}
+
+// LandedMerge is one merge the refinery pushed to a target branch.
+type LandedMerge struct {
+	Target      string `json:"target"`
+	Commit      string `json:"commit"`
+	MR          string `json:"mr"`
+	SourceIssue string `json:"source_issue,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+
+	// From is set when the MR landed as several rebased commits, which
+	// are From..Commit.
+	From string `json:"from,omitempty"`
+
+	// Backports are the MR's backport targets, landed once Target
+	// verifies green with this merge.
+	Backports []string `json:"backports,omitempty"`
+}
+
+// landed returns the commits m landed as, like ProcessResult.landed.
+func (m LandedMerge) landed() string {
+	if m.From != "" {
+		return m.From + ".." + m.Commit
+	}
+	return m.Commit
+}
+
+// QueuePause records why the merge queue is paused. While it is, only the
+// revert MR is processed.
+type QueuePause struct {
+	Target string    `json:"target"`
+	Commit string    `json:"commit"` // target commit that failed verification
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason"`
+
+	// Culprit is the MR whose merge broke the target, if bisecting found one.
+	Culprit string `json:"culprit,omitempty"`
+
+	// RevertMR is the MR queued to revert the culprit's merge.
+	RevertMR string `json:"revert_mr,omitempty"`
+}
+
+// VerifyStatePath returns the path of a rig's post-merge verification state.
+func VerifyStatePath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "verify-state.json")
+}
+
+// LoadVerifyState reads a rig's verification state. A missing file yields
+// an empty state.
+func LoadVerifyState(rigPath string) (*VerifyState, error) {
+	s := &VerifyState{Green: make(map[string]string)}
+	data, err := os.ReadFile(VerifyStatePath(rigPath)) //nolint:gosec // G304: path is constructed from trusted rigPath
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Green == nil {
+		s.Green = make(map[string]string)
+	}
+	return s, nil
+}
+
+// SaveVerifyState writes a rig's verification state.
+func SaveVerifyState(rigPath string, s *VerifyState) error {
+	path := VerifyStatePath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, s)
+}
+
+// verifyStages returns the stages run to verify a target branch.
+func (e *Engineer) verifyStages() []config.CheckStage {
+	if e.config.VerifyCommand != "" {
+		return []config.CheckStage{{Name: "verify", Command: e.config.VerifyCommand}}
+	}
+	return e.checkStages()
+}
+
+// verifiesTargets reports whether merged MRs are verified on their target
+// branch, which holds their backports until it passes.
+func (e *Engineer) verifiesTargets() bool {
+	return e.config.PostMergeVerify && len(e.verifyStages()) > 0
+}
+
+// postMergeVerify records the merges that just landed and verifies their
+// target branch. Called by ProcessQueue after a round merges something.
+// The merges' backports run once the target verifies green; until then
+// they are recorded as pending on the MR beads.
+func (e *Engineer) postMergeVerify(ctx context.Context, cars []*TrainCar) {
+	if len(e.verifyStages()) == 0 {
+		return
+	}
+	state, err := LoadVerifyState(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to load verify state: %v\n", err)
+		return
+	}
+	target := ""
+	for _, car := range cars {
+		if !car.Result.Success {
+			continue
+		}
+		target = car.MR.Target
+		state.Merges = append(state.Merges, LandedMerge{
+			Target:      car.MR.Target,
+			Commit:      car.Result.MergeCommit,
+			MR:          car.MR.ID,
+			SourceIssue: car.MR.SourceIssue,
+			Branch:      car.MR.Branch,
+			From:        car.Result.LandedFrom,
+			Backports:   e.backportTargets(car.MR),
+		})
+	}
+	if target == "" {
+		return
+	}
+	verified := e.verifyTarget(ctx, state, target)
+	if err := SaveVerifyState(e.rig.Path, state); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to save verify state: %v\n", err)
+	}
+	for _, m := range state.Merges {
+		if m.Target != target || len(m.Backports) == 0 || !slices.ContainsFunc(cars, func(c *TrainCar) bool { return c.MR.ID == m.MR }) {
+			continue
+		}
+		var pending []BackportResult
+		for _, t := range m.Backports {
+			pending = append(pending, BackportResult{Target: t, Status: BackportPending, Error: "waiting for origin/" + target + " to verify green"})
+		}
+		e.recordBackports(m.MR, pending)
+	}
+	e.backportVerified(ctx, verified)
+}
+
+// backportVerified lands the backports of merges whose target verified
+// green.
+func (e *Engineer) backportVerified(ctx context.Context, merges []LandedMerge) {
+	for _, m := range merges {
+		mr := &MRInfo{ID: m.MR, Branch: m.Branch, Target: m.Target, SourceIssue: m.SourceIssue}
+		if issue, err := e.beads.Show(m.MR); err == nil {
+			if fields := beads.ParseMRFields(issue); fields != nil {
+				mr = e.newMRInfo(issue, fields)
+			}
+		}
+		mr.Backports = m.Backports
+		e.recordBackports(m.MR, e.ProcessBackports(ctx, mr, m.landed()))
+	}
+}
+
+// holdForPause returns the MRs that may be processed while the queue is
+// paused for a broken target: only the revert MR. If the target moved
+// since it failed verification, it is verified again first, which lifts
+// the pause when it passes. Reports whether the queue is paused.
+func (e *Engineer) holdForPause(ctx context.Context, ready []*MRInfo) ([]*MRInfo, bool) {
+	state, err := LoadVerifyState(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to load verify state: %v\n", err)
+		return ready, false
+	}
+	if state.Pause == nil {
+		return ready, false
+	}
+
+	target := state.Pause.Target
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", target, err)
+	}
+	if tip, err := e.git.Rev("origin/" + target); err == nil && tip != state.Pause.Commit {
+		verified := e.verifyTarget(ctx, state, target)
+		if err := SaveVerifyState(e.rig.Path, state); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to save verify state: %v\n", err)
+		}
+		e.backportVerified(ctx, verified)
+		if state.Pause == nil {
+			return ready, false
+		}
+	}
+
+	var allowed []*MRInfo
+	for _, mr := range ready {
+		if mr.ID == state.Pause.RevertMR {
+			allowed = append(allowed, mr)
+		}
+	}
+	if len(allowed) == 0 {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Queue paused: %s\n", state.Pause.Reason)
+	}
+	return allowed, true
+}
+
+// verifyTarget runs verification on origin/target and updates state. On
+// success the target's recorded merges are cleared, any pause for it is
+// lifted and the merges whose backports may now land are returned. On
+// failure the recorded merges are bisected for the culprit, its merge is
+// reverted through the queue, its source issue is reopened, the queue is
+// paused and the failure is escalated.
+func (e *Engineer) verifyTarget(ctx context.Context, state *VerifyState, target string) []LandedMerge {
+	stages := e.verifyStages()
+	if len(stages) == 0 {
+		return nil
+	}
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", target, err)
+	}
+	tip, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: cannot verify origin/%s: %v\n", target, err)
+		return nil
+	}
+
+	dir := filepath.Join(e.trainDir(), "verify")
+	e.removeScratchWorktree(dir) // leftovers from an interrupted run
+	if err := os.MkdirAll(e.trainDir(), 0755); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: cannot verify origin/%s: %v\n", target, err)
+		return nil
+	}
+	if err := e.git.WorktreeAddDetached(dir, tip); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: cannot verify origin/%s: creating worktree: %v\n", target, err)
+		return nil
+	}
+	defer func() {
+		e.removeScratchWorktree(dir)
+		_ = e.git.WorktreePrune()
+	}()
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Verifying origin/%s at %s...\n", target, shortSHA(tip))
+	result := e.runCheckStages(ctx, stages, dir, nil, e.output)
+	if ctx.Err() != nil {
+		return nil // inconclusive; verify again next time
+	}
+	if result.Success {
+		return e.markGreen(state, target, tip)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ origin/%s failed verification at %s: %s\n", target, shortSHA(tip), result.Error)
+
+	var merges []LandedMerge
+	for _, m := range state.Merges {
+		if m.Target == target {
+			merges = append(merges, m)
+		}
+	}
+	culprit, failed := e.bisectMerges(ctx, stages, dir, merges, tip, result)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	pause := &QueuePause{
+		Target: target,
+		Commit: tip,
+		Since:  time.Now(),
+		Reason: fmt.Sprintf("origin/%s failed post-merge verification at %s", target, shortSHA(tip)),
+	}
+	if state.Pause != nil && state.Pause.Target == target {
+		pause.Since = state.Pause.Since
+	}
+	details := []string{result.Error}
+	related := ""
+	switch {
+	case culprit == nil:
+		details = append(details, "No refinery merge since the last green commit is to blame; fix the target by hand.")
+	case slices.Contains(state.Reverted, culprit.Commit):
+		pause.Culprit = culprit.MR
+		related = culprit.MR
+		details = append(details, fmt.Sprintf("Still failing after reverting %s (%s); fix the target by hand.", culprit.MR, shortSHA(culprit.Commit)))
+	default:
+		pause.Culprit = culprit.MR
+		related = culprit.MR
+		if culprit.SourceIssue != "" {
+			related = culprit.SourceIssue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Culprit: %s (%s)\n", culprit.MR, shortSHA(culprit.Commit))
+		details = append(details, fmt.Sprintf("Bisected to %s (merge %s).", culprit.MR, shortSHA(culprit.Commit)))
+		revertMR, err := e.queueRevert(dir, tip, *culprit)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to queue revert of %s: %v\n", culprit.MR, err)
+			details = append(details, fmt.Sprintf("Could not queue a revert: %v", err))
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Queued %s to revert %s\n", revertMR, culprit.MR)
+			pause.RevertMR = revertMR
+			state.Reverted = append(state.Reverted, culprit.Commit)
+			details = append(details, fmt.Sprintf("Queued %s to revert it.", revertMR))
+		}
+		e.reopenCulpritSource(*culprit, target, pause.RevertMR, failed)
+	}
+	if pause.Culprit != "" {
+		pause.Reason += " (culprit " + pause.Culprit + ")"
+	}
+	state.Pause = pause
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merge queue paused until origin/%s is green\n", target)
+
+	description := fmt.Sprintf("%s: origin/%s broken after merge, merge queue paused", e.rig.Name, target)
+	if err := e.escalate(config.SeverityHigh, description, strings.Join(details, "\n"), related); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: escalation failed: %v\n", err)
+	}
+	return nil
+}
+
+// markGreen records tip as the last green commit of target, forgets the
+// merges it covers and lifts a pause for it. Returns the merges it covers
+// that have backports and weren't reverted.
+func (e *Engineer) markGreen(state *VerifyState, target, tip string) []LandedMerge {
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ origin/%s verified at %s\n", target, shortSHA(tip))
+	state.Green[target] = tip
+	var merges, verified []LandedMerge
+	var reverted []string
+	for _, m := range state.Merges {
+		if m.Target == target && len(m.Backports) > 0 && !slices.Contains(state.Reverted, m.Commit) {
+			verified = append(verified, m)
+		}
+		if m.Target != target {
+			merges = append(merges, m)
+			if slices.Contains(state.Reverted, m.Commit) {
+				reverted = append(reverted, m.Commit)
+			}
+		}
+	}
+	state.Merges, state.Reverted = merges, reverted
+	if state.Pause != nil && state.Pause.Target == target {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] origin/%s is green again; resuming the merge queue\n", target)
+		state.Pause = nil
+	}
+	return verified
+}
+
+// bisectMerges finds the first of merges whose commit fails verification,
+// given that tip fails with tipResult and the target passed before
+// merges[0]. dir is a worktree it may reset. Returns nil if every merge
+// passes, i.e. something else broke the target, along with the failing
+// result closest to the culprit.
+func (e *Engineer) bisectMerges(ctx context.Context, stages []config.CheckStage, dir string, merges []LandedMerge, tip string, tipResult ProcessResult) (*LandedMerge, ProcessResult) {
+	// lo is the last merge known to pass (-1 for the last green commit),
+	// hi the first known to fail (len(merges) for the tip).
+	lo, hi := -1, len(merges)
+	if hi > 0 && merges[hi-1].Commit == tip {
+		hi--
+	}
+	failed := tipResult
+	if hi-lo > 1 {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Bisecting %d merge(s) on origin/%s\n", hi-lo-1, merges[0].Target)
+	}
+	wt := git.NewGit(dir)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if err := wt.ResetHard(merges[mid].Commit); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: bisect cannot check out %s: %v\n", shortSHA(merges[mid].Commit), err)
+			return nil, failed
+		}
+		r := e.runCheckStages(ctx, stages, dir, nil, io.Discard)
+		if ctx.Err() != nil {
+			return nil, failed
+		}
+		if r.Success {
+			lo = mid
+		} else {
+			hi, failed = mid, r
+		}
+	}
+	if hi == len(merges) {
+		return nil, failed
+	}
+	return &merges[hi], failed
+}
+
+// queueRevert commits a revert of m on tip in the worktree dir, points a
+// revert/<sha> branch at it and submits that branch as a P0 merge request.
+// Returns the MR's ID.
+func (e *Engineer) queueRevert(dir, tip string, m LandedMerge) (string, error) {
+	wt := git.NewGit(dir)
+	if err := wt.ResetHard(tip); err != nil {
+		return "", err
+	}
+	if err := wt.Revert(m.landed()); err != nil {
+		_ = wt.AbortRevert()
+		return "", fmt.Errorf("reverting %s: %w", shortSHA(m.Commit), err)
+	}
+	commit, err := wt.Rev("HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch := "revert/" + shortSHA(m.Commit)
+	if err := e.git.ResetBranch(branch, commit); err != nil {
+		return "", fmt.Errorf("creating branch %s: %w", branch, err)
+	}
+
+	name := m.MR
+	if m.SourceIssue != "" {
+		name = m.SourceIssue
+	}
+	issue, err := e.beads.Create(beads.CreateOptions{
+		Title:    "Revert: " + name,
+		Type:     "merge-request",
+		Priority: 0,
+		Description: beads.FormatMRFields(&beads.MRFields{
+			Branch: branch,
+			Target: m.Target,
+			Rig:    e.rig.Name,
+		}),
+		Ephemeral: true,
+		Actor:     e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating merge request bead: %w", err)
+	}
+	return issue.ID, nil
+}
+
+// postMergeHeading starts the source issue section describing why its
+// merge was reverted.
+const postMergeHeading = "## Post-merge failure"
+
+// reopenCulpritSource reopens the source issue of a merge that broke
+// target and records the failure on it.
+func (e *Engineer) reopenCulpritSource(m LandedMerge, target, revertMR string, failed ProcessResult) {
+	if m.SourceIssue == "" {
+		return
+	}
+	issue, err := e.beads.Show(m.SourceIssue)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to fetch source issue %s: %v\n", m.SourceIssue, err)
+		return
+	}
+	if issue.Status == "closed" {
+		if err := e.beads.Reopen(m.SourceIssue, "broke "+target+" after merge"); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reopen source issue %s: %v\n", m.SourceIssue, err)
+			return
+		}
+	}
+
+	section := fmt.Sprintf("%s\nMerged in %s as %s, this broke origin/%s: %s", postMergeHeading, m.MR, shortSHA(m.Commit), target, failed.Error)
+	if revertMR != "" {
+		section += fmt.Sprintf("\nThe refinery queued %s to revert it.", revertMR)
+	}
+	if log := formatFailedStages(failed.Stages); log != "" {
+		section += "\n" + log
+	}
+	desc := replaceSection(issue.Description, postMergeHeading, section)
+	if err := e.beads.Update(m.SourceIssue, beads.UpdateOptions{Description: &desc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record failure on %s: %v\n", m.SourceIssue, err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Reopened source issue: %s\n", m.SourceIssue)
+}
+
+// gtEscalate raises an escalation through gt escalate, which routes it
+// according to the town's escalation config.
+func (e *Engineer) gtEscalate(severity, description, reason, related string) error {
+	args := []string{"escalate", description, "--severity", severity, "--reason", reason, "--source", "refinery:" + e.rig.Name}
+	if related != "" {
+		args = append(args, "--related", related)
+	}
+	cmd := exec.Command("gt", args...) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = e.rig.Path
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// useNativeBeads gives e's rig a native beads store that writes
+// issues.jsonl itself, so tests run without bd.
+func useNativeBeads(t *testing.T, e *Engineer) {
+	t.Helper()
+	for path, content := range map[string]string{
+		"mayor/town.json":    "{}",
+		".beads/config.yaml": "issue-prefix: gt\n",
+	} {
+		full := filepath.Join(e.rig.Path, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GT_BEADS_BACKEND", "native")
+	t.Cleanup(beads.WriteJSONLForTesting())
+	e.beads = beads.New(e.rig.Path)
+}
+
+// verifyRepo is trainRepo with a native beads store in the rig and
+// escalations captured instead of sent.
+func verifyRepo(t *testing.T) (*Engineer, string, *[]string) {
+	t.Helper()
+	e, origin := trainRepo(t)
+	useNativeBeads(t, e)
+	e.config.PostMergeVerify = true
+
+	var escalations []string
+	e.escalate = func(severity, description, reason, related string) error {
+		escalations = append(escalations, severity+"|"+description+"|"+related)
+		return nil
+	}
+	return e, origin, &escalations
+}
+
+// landUntested lands mrs as a train with checks off, the way MRs tested
+// independently can land together and break the target.
+func landUntested(t *testing.T, e *Engineer, mrs []*MRInfo) []*TrainCar {
+	t.Helper()
+	e.config.RunTests = false
+	cars := e.ProcessTrain(context.Background(), mrs)
+	e.config.RunTests = true
+	for _, car := range cars {
+		if !car.Result.Success {
+			t.Fatalf("%s did not land: %+v", car.MR.ID, car.Result)
+		}
+	}
+	return cars
+}
+
+// closedIssue creates a source issue and closes it, as a merge would.
+func closedIssue(t *testing.T, e *Engineer, title string) string {
+	t.Helper()
+	issue, err := e.beads.Create(beads.CreateOptions{Title: title, Type: "task", Priority: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.beads.CloseWithReason("Merged", issue.ID); err != nil {
+		t.Fatal(err)
+	}
+	return issue.ID
+}
+
+func TestPostMergeVerify_Green(t *testing.T) {
+	e, origin, escalations := verifyRepo(t)
+	cars := landUntested(t, e, []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "b", "b.txt", "b\n"),
+	})
+
+	e.postMergeVerify(context.Background(), cars)
+
+	state, err := LoadVerifyState(e.rig.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Pause != nil || len(state.Merges) != 0 || len(*escalations) != 0 {
+		t.Errorf("state = %+v, escalations = %v", state, *escalations)
+	}
+	if head := gitRun(t, origin, "rev-parse", "main"); state.Green["main"] != head {
+		t.Errorf("green = %s, want %s", state.Green["main"], head)
+	}
+}
+
+func TestPostMergeVerify_BisectsRevertsAndPauses(t *testing.T) {
+	e, origin, escalations := verifyRepo(t)
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "bad", "broken", "x\n"),
+		mrBranch(t, e, "c", "c.txt", "c\n"),
+	}
+	for _, mr := range mrs {
+		mr.SourceIssue = closedIssue(t, e, "work on "+mr.Branch)
+	}
+	cars := landUntested(t, e, mrs)
+
+	e.postMergeVerify(context.Background(), cars)
+
+	state, err := LoadVerifyState(e.rig.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := state.Pause
+	if p == nil || p.Target != "main" || p.Culprit != "mr-bad" || p.RevertMR == "" {
+		t.Fatalf("pause = %+v", p)
+	}
+	if p.Commit != gitRun(t, origin, "rev-parse", "main") {
+		t.Errorf("pause commit = %s, want origin/main", p.Commit)
+	}
+	if len(state.Reverted) != 1 || state.Reverted[0] != cars[1].Commit {
+		t.Errorf("reverted = %v, want [%s]", state.Reverted, cars[1].Commit)
+	}
+
+	// The revert is queued as a P0 MR on a revert branch.
+	revert, err := e.beads.Show(p.RevertMR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := beads.ParseMRFields(revert)
+	if revert.Priority != 0 || fields == nil || fields.Branch != "revert/"+shortSHA(cars[1].Commit) || fields.Target != "main" {
+		t.Errorf("revert MR = %+v, fields = %+v", revert, fields)
+	}
+	if msg := gitRun(t, e.workDir, "log", "-1", "--format=%s", fields.Branch); msg != `Revert "feat: bad"` {
+		t.Errorf("revert commit = %q", msg)
+	}
+
+	// Only the culprit's source issue is reopened, with the failure.
+	bad, err := e.beads.Show(mrs[1].SourceIssue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bad.Status != "open" || !strings.Contains(bad.Description, postMergeHeading) || !strings.Contains(bad.Description, p.RevertMR) {
+		t.Errorf("culprit source = %s\n%s", bad.Status, bad.Description)
+	}
+	if good, _ := e.beads.Show(mrs[0].SourceIssue); good.Status != "closed" {
+		t.Errorf("innocent source issue reopened: %s", good.Status)
+	}
+
+	if len(*escalations) != 1 || !strings.HasPrefix((*escalations)[0], "high|") || !strings.HasSuffix((*escalations)[0], "|"+mrs[1].SourceIssue) {
+		t.Errorf("escalations = %v", *escalations)
+	}
+}
+
+func TestProcessQueue_PausedUntilRevertLands(t *testing.T) {
+	e, origin, _ := verifyRepo(t)
+	cars := landUntested(t, e, []*MRInfo{mrBranch(t, e, "bad", "broken", "x\n")})
+	e.postMergeVerify(context.Background(), cars)
+	state, err := LoadVerifyState(e.rig.Path)
+	if err != nil || state.Pause == nil {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+
+	// A regular MR queued behind the breakage, ahead of the revert.
+	mrBranch(t, e, "d", "d.txt", "d\n")
+	waiting, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Merge: d",
+		Type:        "merge-request",
+		Priority:    0,
+		Description: beads.FormatMRFields(&beads.MRFields{Branch: "d", Target: "main"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := e.ProcessQueue(context.Background())
+	if err != nil || merged != 1 {
+		t.Fatalf("ProcessQueue = %d, %v", merged, err)
+	}
+	if msg := gitRun(t, origin, "log", "-1", "--format=%s", "main"); msg != `Revert "feat: bad"` {
+		t.Errorf("origin/main tip = %q, want the revert", msg)
+	}
+	state, err = LoadVerifyState(e.rig.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Pause != nil || len(state.Merges) != 0 {
+		t.Errorf("queue should resume once main is green: %+v", state)
+	}
+	if mr, _ := e.beads.Show(waiting.ID); mr.Status != "open" {
+		t.Errorf("waiting MR processed while paused: %s", mr.Status)
+	}
+
+	// Resumed: the waiting MR goes through.
+	if merged, err := e.ProcessQueue(context.Background()); err != nil || merged != 1 {
+		t.Errorf("ProcessQueue after resume = %d, %v", merged, err)
+	}
+}
+
+func TestHoldForPause_NoRevertHoldsEverything(t *testing.T) {
+	e, origin, _ := verifyRepo(t)
+	head := gitRun(t, origin, "rev-parse", "main")
+	if err := SaveVerifyState(e.rig.Path, &VerifyState{Pause: &QueuePause{Target: "main", Commit: head, Reason: "broken"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ready, paused := e.holdForPause(context.Background(), []*MRInfo{{ID: "mr-x", Target: "main"}})
+	if !paused || len(ready) != 0 {
+		t.Errorf("holdForPause = %v, %v; want everything held", ready, paused)
+	}
+
+	// Someone fixes main by hand: the new tip is verified and the pause lifts.
+	gitRun(t, e.workDir, "commit", "-q", "--allow-empty", "-m", "fix")
+	gitRun(t, e.workDir, "push", "-q", "origin", "main")
+	ready, paused = e.holdForPause(context.Background(), []*MRInfo{{ID: "mr-x", Target: "main"}})
+	if paused || len(ready) != 1 {
+		t.Errorf("holdForPause after fix = %v, %v; want resumed", ready, paused)
+	}
+}
+
+func TestPostMergeVerify_BackportsWaitForGreenTarget(t *testing.T) {
+	e, origin, _ := verifyRepo(t)
+	releaseBranch(t, e, "release/1.2", "", "")
+	mrs := []*MRInfo{
+		mrBranch(t, e, "a", "a.txt", "a\n"),
+		mrBranch(t, e, "bad", "broken", "x\n"),
+	}
+	for _, mr := range mrs {
+		mr.Backports = []string{"release/1.2"}
+		issue, err := e.beads.Create(beads.CreateOptions{
+			Title:       "Merge: " + mr.Branch,
+			Type:        "merge-request",
+			Priority:    2,
+			Description: beads.FormatMRFields(&beads.MRFields{Branch: mr.Branch, Target: "main", Backports: "release/1.2"}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr.ID = issue.ID
+	}
+	cars := landUntested(t, e, mrs)
+
+	// main is broken: nothing reaches the release branch yet.
+	e.postMergeVerify(context.Background(), cars)
+	if log := gitRun(t, origin, "log", "--format=%s", "release/1.2"); log != "base" {
+		t.Fatalf("release/1.2 changed before main verified:\n%s", log)
+	}
+	for _, mr := range mrs {
+		issue, _ := e.beads.Show(mr.ID)
+		if fields := beads.ParseMRFields(issue); fields == nil || fields.BackportStatus != "release/1.2=pending" {
+			t.Errorf("%s backport_status = %+v", mr.Branch, fields)
+		}
+	}
+
+	// Once the revert lands and main is green, only the innocent MR is
+	// backported.
+	if merged, err := e.ProcessQueue(context.Background()); err != nil || merged != 1 {
+		t.Fatalf("ProcessQueue = %d, %v", merged, err)
+	}
+	gitRun(t, origin, "cat-file", "-e", "release/1.2:a.txt")
+	if err := exec.Command("git", "-C", origin, "cat-file", "-e", "release/1.2:broken").Run(); err == nil {
+		t.Error("reverted MR was backported")
+	}
+	if issue, _ := e.beads.Show(mrs[0].ID); beads.ParseMRFields(issue).BackportStatus != "release/1.2=merged" {
+		t.Errorf("backport_status = %q", beads.ParseMRFields(issue).BackportStatus)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/runtime"
@@ -143,6 +145,10 @@ func (m *Manager) RigExists(name string) bool {
 
 // loadRig loads rig details from the filesystem.
 func (m *Manager) loadRig(name string, entry config.RigEntry) (*Rig, error) {
+	if entry.IsRemote() {
+		return m.loadRemoteRig(name, entry)
+	}
+
 	rigPath := filepath.Join(m.townRoot, name)
 
 	// Verify directory exists
@@ -208,6 +214,110 @@ func (m *Manager) loadRig(name string, entry config.RigEntry) (*Rig, error) {
 	return rig, nil
 }
 
+// loadRemoteRig loads rig details from another machine over its connection.
+// The returned Rig's Path is the rig's path on that machine.
+func (m *Manager) loadRemoteRig(name string, entry config.RigEntry) (*Rig, error) {
+	conn, rigPath, err := remoteRigLocation(m.townRoot, name, entry.Machine)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := conn.Stat(rigPath)
+	if err != nil {
+		return nil, fmt.Errorf("rig directory on %s: %w", entry.Machine, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory on %s: %s", entry.Machine, rigPath)
+	}
+
+	rig := &Rig{
+		Name:      name,
+		Path:      rigPath,
+		GitURL:    entry.GitURL,
+		LocalRepo: entry.LocalRepo,
+		Config:    entry.BeadsConfig,
+		Machine:   entry.Machine,
+		conn:      conn,
+	}
+
+	rig.Polecats = remoteSubdirs(conn, path.Join(rigPath, "polecats"))
+	rig.Crew = remoteSubdirs(conn, path.Join(rigPath, "crew"))
+
+	if info, err := conn.Stat(path.Join(rigPath, "witness")); err == nil && info.IsDir() {
+		rig.HasWitness = true
+	}
+	if ok, _ := conn.Exists(path.Join(rigPath, "refinery", "rig")); ok {
+		rig.HasRefinery = true
+	}
+	if ok, _ := conn.Exists(path.Join(rigPath, "mayor", "rig")); ok {
+		rig.HasMayor = true
+	}
+
+	return rig, nil
+}
+
+// remoteSubdirs lists the non-hidden subdirectories of dir over conn.
+func remoteSubdirs(conn connection.Connection, dir string) []string {
+	matches, err := conn.Glob(path.Join(dir, "*"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, match := range matches {
+		if info, err := conn.Stat(match); err == nil && info.IsDir() {
+			names = append(names, path.Base(match))
+		}
+	}
+	return names
+}
+
+// remoteRigLocation resolves the connection and remote path for a rig hosted
+// on the named machine in the town's machine registry.
+func remoteRigLocation(townRoot, rigName, machine string) (connection.Connection, string, error) {
+	registry, err := connection.NewMachineRegistry(constants.MayorMachinesPath(townRoot))
+	if err != nil {
+		return nil, "", err
+	}
+	rigPath, err := registry.RigPath(machine, townRoot, rigName)
+	if err != nil {
+		return nil, "", fmt.Errorf("rig %s: %w", rigName, err)
+	}
+	conn, err := registry.Connection(machine)
+	if err != nil {
+		return nil, "", fmt.Errorf("rig %s: %w", rigName, err)
+	}
+	return conn, rigPath, nil
+}
+
+// Locate returns a Rig with its name, path and machine connection resolved
+// from mayor/rigs.json, without scanning the rig's directories. It is cheap
+// enough for per-heartbeat use. Rigs that are unregistered or local resolve
+// to <townRoot>/<rigName> on this machine.
+func Locate(townRoot, rigName string) (*Rig, error) {
+	r := &Rig{
+		Name: rigName,
+		Path: filepath.Join(townRoot, rigName),
+	}
+
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil {
+		return r, nil
+	}
+	entry, ok := rigsConfig.Rigs[rigName]
+	if !ok || !entry.IsRemote() {
+		return r, nil
+	}
+
+	conn, rigPath, err := remoteRigLocation(townRoot, rigName, entry.Machine)
+	if err != nil {
+		return nil, err
+	}
+	r.Path = rigPath
+	r.Machine = entry.Machine
+	r.conn = conn
+	return r, nil
+}
+
 // AddRigOptions configures rig creation.
 type AddRigOptions struct {
 	Name          string // Rig name (directory name)
@@ -810,3 +810,117 @@ func TestDetectBeadsPrefixFromConfig_TrailingDash(t *testing.T) {
 		})
 	}
 }
+
+// installFakeSSH puts an ssh stand-in on PATH that runs the "remote"
+// command with the local shell, so a temp dir can play the remote town.
+func installFakeSSH(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh stand-in requires /bin/sh")
+	}
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --) shift; break ;;
+    -O) exit 0 ;;
+    -o|-i|-p) shift 2 ;;
+    *) shift ;;
+  esac
+done
+shift
+exec /bin/sh -c "$*"
+`
+	if err := os.WriteFile(filepath.Join(binDir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatalf("write fake ssh: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", t.TempDir())
+}
+
+func writeMachines(t *testing.T, root, remoteTown string) {
+	t.Helper()
+	data := `{"version":1,"machines":{"vm":{"type":"ssh","host":"me@vm","town_path":"` + remoteTown + `"}}}`
+	if err := os.MkdirAll(filepath.Join(root, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "mayor", "machines.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetRig_Remote(t *testing.T) {
+	installFakeSSH(t)
+	root, rigsConfig := setupTestTown(t)
+	remoteTown := t.TempDir()
+	createTestRig(t, remoteTown, "farrig")
+	writeMachines(t, root, remoteTown)
+	rigsConfig.Rigs["farrig"] = config.RigEntry{
+		GitURL:  "git@github.com:test/farrig.git",
+		Machine: "vm",
+	}
+
+	manager := NewManager(root, rigsConfig, git.NewGit(root))
+	rig, err := manager.GetRig("farrig")
+	if err != nil {
+		t.Fatalf("GetRig: %v", err)
+	}
+
+	if !rig.IsRemote() || rig.Machine != "vm" {
+		t.Errorf("Machine = %q, IsRemote = %v; want vm, true", rig.Machine, rig.IsRemote())
+	}
+	if rig.Path != filepath.Join(remoteTown, "farrig") {
+		t.Errorf("Path = %q, want remote path under %q", rig.Path, remoteTown)
+	}
+	if rig.Connection().IsLocal() {
+		t.Error("Connection().IsLocal() = true, want remote connection")
+	}
+	slices.Sort(rig.Polecats)
+	if !slices.Equal(rig.Polecats, []string{"Cheedo", "Toast"}) {
+		t.Errorf("Polecats = %v, want [Cheedo Toast]", rig.Polecats)
+	}
+	if !rig.HasWitness || !rig.HasRefinery || !rig.HasMayor {
+		t.Errorf("agent dirs not detected over connection: %+v", rig)
+	}
+}
+
+func TestGetRig_RemoteUnknownMachine(t *testing.T) {
+	root, rigsConfig := setupTestTown(t)
+	rigsConfig.Rigs["farrig"] = config.RigEntry{Machine: "nowhere"}
+
+	manager := NewManager(root, rigsConfig, git.NewGit(root))
+	if _, err := manager.GetRig("farrig"); err == nil {
+		t.Error("GetRig with unregistered machine succeeded, want error")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	root := t.TempDir()
+	remoteTown := "/srv/town"
+	writeMachines(t, root, remoteTown)
+	rigs := `{"version":1,"rigs":{"near":{"git_url":"x"},"far":{"git_url":"y","machine":"vm"}}}`
+	if err := os.WriteFile(filepath.Join(root, "mayor", "rigs.json"), []byte(rigs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	near, err := Locate(root, "near")
+	if err != nil {
+		t.Fatalf("Locate(near): %v", err)
+	}
+	if near.IsRemote() || near.Path != filepath.Join(root, "near") || !near.Connection().IsLocal() {
+		t.Errorf("Locate(near) = %+v, want local rig under town root", near)
+	}
+
+	far, err := Locate(root, "far")
+	if err != nil {
+		t.Fatalf("Locate(far): %v", err)
+	}
+	if !far.IsRemote() || far.Path != "/srv/town/far" || far.Connection().Name() != "vm" {
+		t.Errorf("Locate(far) = %+v, want remote rig on vm", far)
+	}
+
+	unknown, err := Locate(root, "unregistered")
+	if err != nil || unknown.IsRemote() {
+		t.Errorf("Locate(unregistered) = %+v, %v; want local fallback", unknown, err)
+	}
+}
@@ -3,6 +3,7 @@ package rig
 
 import (
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 )
 
 // Rig represents a managed repository in the workspace.
@@ -37,6 +38,32 @@ type Rig struct {
 
 	// HasMayor indicates if the rig has a mayor clone.
 	HasMayor bool `json:"has_mayor"`
+
+	// Machine is the machine hosting the rig (empty for local rigs).
+	// For remote rigs, Path is the rig's path on that machine.
+	Machine string `json:"machine,omitempty"`
+
+	// conn reaches the rig's machine. Nil means the local machine.
+	conn connection.Connection
+}
+
+// IsRemote returns true if the rig lives on another machine.
+func (r *Rig) IsRemote() bool {
+	return r.Machine != "" && r.Machine != "local"
+}
+
+// Connection returns the connection used for the rig's files, commands and
+// tmux sessions. Rigs without an explicit connection use the local machine.
+func (r *Rig) Connection() connection.Connection {
+	if r.conn == nil {
+		return connection.NewLocalConnection()
+	}
+	return r.conn
+}
+
+// SetConnection sets the connection used to reach the rig's machine.
+func (r *Rig) SetConnection(c connection.Connection) {
+	r.conn = c
 }
 
 // AgentDirs are the standard agent directories in a rig.
@@ -0,0 +1,320 @@
+package scripted
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExitError is returned by Run when the script ends with an exit step.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("script exited with status %d", e.Code)
+}
+
+// Runner executes a script in the agent's pane.
+type Runner struct {
+	Script *Script
+	// Prompt is the initial prompt the session was started with (the
+	// startup beacon). Commands see it as $GT_PROMPT.
+	Prompt string
+	// GT is the gt binary used for mail. Default: "gt".
+	GT string
+	// Dir is the agent's working directory. Default: the current directory.
+	Dir string
+	// Env is the environment for commands. Default: os.Environ().
+	Env []string
+	// Stdin receives nudges typed into the pane.
+	Stdin io.Reader
+	// Stdout is the pane.
+	Stdout io.Writer
+
+	env      []string
+	input    chan string
+	atPrompt bool
+}
+
+// Run executes every step, then idles at the ready prompt (answering
+// input) until stdin closes or ctx is done, unless the script sets
+// exit_when_done.
+func (r *Runner) Run(ctx context.Context) error {
+	if r.GT == "" {
+		r.GT = "gt"
+	}
+	r.env = append([]string(nil), r.Env...)
+	if r.Env == nil {
+		r.env = os.Environ()
+	}
+	r.setEnv("GT_PROMPT", r.Prompt)
+	r.input = make(chan string)
+	go r.readInput()
+
+	// Signal readiness the way an interactive agent does.
+	r.showPrompt()
+
+	for i, step := range r.Script.Steps {
+		if err := r.step(ctx, step); err != nil {
+			var exit *ExitError
+			if errors.As(err, &exit) {
+				return err
+			}
+			r.printf("step %d (line %d) failed: %v\n", i+1, step.Line, err)
+			return fmt.Errorf("step %d (line %d): %w", i+1, step.Line, err)
+		}
+	}
+
+	if r.Script.ExitWhenDone {
+		return nil
+	}
+	for {
+		r.showPrompt()
+		line, err := r.readLine(ctx, 0)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		r.printf("(scripted) received: %s\n", line)
+	}
+}
+
+func (r *Runner) step(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionRun:
+		return r.run(ctx, step)
+	case ActionSay:
+		r.printf("%s\n", r.expand(step.Value))
+	case ActionPrompt:
+		return r.prompt(ctx, step)
+	case ActionWaitMail:
+		return r.waitMail(ctx, step)
+	case ActionSleep:
+		select {
+		case <-time.After(step.Duration):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case ActionExit:
+		return &ExitError{Code: step.Code}
+	}
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, step Step) error {
+	r.printf("$ %s\n", step.Value)
+
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", step.Value) //nolint:gosec // G204: scripts are trusted test fixtures
+	cmd.Dir = r.Dir
+	if step.Dir != "" {
+		cmd.Dir = filepath.Join(r.Dir, r.expand(step.Dir))
+	}
+	cmd.Env = r.env
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stdout
+
+	err := cmd.Run()
+	if err != nil && step.AllowFailure {
+		r.printf("(allowed failure: %v)\n", err)
+		return nil
+	}
+	return err
+}
+
+// prompt waits for a line of input containing the step's value.
+func (r *Runner) prompt(ctx context.Context, step Step) error {
+	want := r.expand(step.Value)
+	var deadline time.Time
+	if step.Timeout > 0 {
+		deadline = time.Now().Add(step.Timeout)
+	}
+	for {
+		r.showPrompt()
+		remaining := time.Until(deadline)
+		if !deadline.IsZero() && remaining <= 0 {
+			// readLine would treat a spent timeout as no timeout.
+			return fmt.Errorf("no input containing %q within %s", want, step.Timeout)
+		}
+		line, err := r.readLine(ctx, remaining)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("no input containing %q within %s", want, step.Timeout)
+			}
+			return err
+		}
+		if strings.Contains(line, want) {
+			r.setEnv("GT_INPUT", line)
+			return nil
+		}
+		r.printf("(scripted) waiting for %q, ignoring: %s\n", want, line)
+	}
+}
+
+// mailMessage is the subset of gt mail inbox --json that scripts match on.
+type mailMessage struct {
+	ID      string `json:"id"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// waitMail polls the inbox for a matching unread message and marks it read.
+func (r *Runner) waitMail(ctx context.Context, step Step) error {
+	subject, from := r.expand(step.Value), r.expand(step.From)
+	r.printf("(scripted) waiting for mail %q from %q\n", subject, from)
+
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+	for {
+		msg, err := r.findMail(ctx, subject, from)
+		if err != nil && ctx.Err() == nil {
+			r.printf("(scripted) checking mail: %v\n", err)
+		}
+		if msg != nil {
+			if err := r.gt(ctx, "mail", "mark-read", msg.ID); err != nil {
+				return fmt.Errorf("marking %s read: %w", msg.ID, err)
+			}
+			r.setEnv("GT_MAIL_ID", msg.ID)
+			r.setEnv("GT_MAIL_FROM", msg.From)
+			r.setEnv("GT_MAIL_SUBJECT", msg.Subject)
+			r.setEnv("GT_MAIL_BODY", msg.Body)
+			r.printf("(scripted) got mail %s: %s\n", msg.ID, msg.Subject)
+			return nil
+		}
+		select {
+		case <-time.After(r.Script.PollInterval):
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("no mail matching %q within %s", subject, step.Timeout)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *Runner) findMail(ctx context.Context, subject, from string) (*mailMessage, error) {
+	cmd := exec.CommandContext(ctx, r.GT, "mail", "inbox", "--unread", "--json") //nolint:gosec // G204: GT is the gt binary
+	cmd.Dir = r.Dir
+	cmd.Env = r.env
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var msgs []mailMessage
+	if err := json.Unmarshal(out, &msgs); err != nil {
+		return nil, fmt.Errorf("parsing inbox: %w", err)
+	}
+	for i := range msgs {
+		if strings.Contains(msgs[i].Subject, subject) && strings.Contains(msgs[i].From, from) {
+			return &msgs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Runner) gt(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, r.GT, args...) //nolint:gosec // G204: GT is the gt binary
+	cmd.Dir = r.Dir
+	cmd.Env = r.env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// readInput forwards stdin lines to r.input and closes it at EOF.
+func (r *Runner) readInput() {
+	defer close(r.input)
+	if r.Stdin == nil {
+		return
+	}
+	scanner := bufio.NewScanner(r.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			r.input <- line
+		}
+	}
+}
+
+// readLine returns the next input line. A non-positive timeout waits forever.
+func (r *Runner) readLine(ctx context.Context, timeout time.Duration) (string, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case line, ok := <-r.input:
+		if !ok {
+			return "", io.EOF
+		}
+		r.atPrompt = false
+		return line, nil
+	case <-expired:
+		return "", context.DeadlineExceeded
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// showPrompt prints the ready prompt on its own line, leaving the cursor
+// after it as an interactive agent would.
+func (r *Runner) showPrompt() {
+	if r.atPrompt {
+		return
+	}
+	_, _ = fmt.Fprint(r.Stdout, r.Script.ReadyPrompt)
+	r.atPrompt = true
+}
+
+func (r *Runner) printf(format string, args ...interface{}) {
+	if r.atPrompt {
+		_, _ = fmt.Fprintln(r.Stdout)
+		r.atPrompt = false
+	}
+	_, _ = fmt.Fprintf(r.Stdout, format, args...)
+}
+
+func (r *Runner) setEnv(key, value string) {
+	prefix := key + "="
+	for i, kv := range r.env {
+		if strings.HasPrefix(kv, prefix) {
+			r.env[i] = prefix + value
+			return
+		}
+	}
+	r.env = append(r.env, prefix+value)
+}
+
+// expand substitutes $VAR and ${VAR} from the runner's environment.
+func (r *Runner) expand(s string) string {
+	return os.Expand(s, func(key string) string {
+		prefix := key + "="
+		for i := len(r.env) - 1; i >= 0; i-- {
+			if strings.HasPrefix(r.env[i], prefix) {
+				return r.env[i][len(prefix):]
+			}
+		}
+		return ""
+	})
+}
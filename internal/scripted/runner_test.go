@@ -0,0 +1,196 @@
+package scripted
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes from commands.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("scripted runtime uses sh")
+	}
+}
+
+func TestRunnerRunsSteps(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	s, err := Parse([]byte(`exit_when_done: true
+steps:
+  - run: echo "$GT_PROMPT" > prompt.txt
+  - say: hello from ${GT_ROLE}
+  - prompt: continue
+  - run: echo "$GT_INPUT" > input.txt
+  - run: exit 7
+    allow_failure: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &syncBuffer{}
+	r := &Runner{
+		Script: s,
+		Prompt: "startup beacon",
+		Dir:    dir,
+		Env:    append(os.Environ(), "GT_ROLE=gastown/polecats/Toast"),
+		Stdin:  strings.NewReader("ignored\nplease continue now\n"),
+		Stdout: out,
+	}
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v\n%s", err, out)
+	}
+
+	for file, want := range map[string]string{"prompt.txt": "startup beacon", "input.txt": "please continue now"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || strings.TrimSpace(string(data)) != want {
+			t.Errorf("%s = %q, %v; want %q", file, data, err, want)
+		}
+	}
+	text := out.String()
+	if !strings.HasPrefix(text, DefaultReadyPrompt) {
+		t.Errorf("output does not start with the ready prompt: %q", text)
+	}
+	for _, want := range []string{"hello from gastown/polecats/Toast", `ignoring: ignored`, "allowed failure"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRunnerFailureAndExit(t *testing.T) {
+	skipOnWindows(t)
+	s, _ := Parse([]byte("steps:\n  - run: exit 1\n  - say: unreachable\n"))
+	out := &syncBuffer{}
+	err := (&Runner{Script: s, Dir: t.TempDir(), Stdout: out}).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "step 1 (line 2)") {
+		t.Errorf("Run error = %v", err)
+	}
+	if strings.Contains(out.String(), "unreachable") {
+		t.Error("ran a step after a failure")
+	}
+
+	s, _ = Parse([]byte("steps:\n  - exit: 4\n"))
+	err = (&Runner{Script: s, Dir: t.TempDir(), Stdout: io.Discard}).Run(context.Background())
+	var exit *ExitError
+	if !errors.As(err, &exit) || exit.Code != 4 {
+		t.Errorf("Run error = %v, want exit 4", err)
+	}
+}
+
+func TestRunnerPromptTimeout(t *testing.T) {
+	s, _ := Parse([]byte("steps:\n  - prompt: go\n    timeout: 50ms\n"))
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	err := (&Runner{Script: s, Stdin: pr, Stdout: io.Discard}).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no input") {
+		t.Errorf("Run error = %v", err)
+	}
+}
+
+// noiseReader yields non-matching input lines forever.
+type noiseReader struct{}
+
+func (noiseReader) Read(p []byte) (int, error) {
+	return copy(p, "noise\n"), nil
+}
+
+func TestRunnerPromptTimeoutWhileIgnoringInput(t *testing.T) {
+	s, _ := Parse([]byte("steps:\n  - prompt: go\n    timeout: 50ms\n"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(5*time.Second, cancel)
+	err := (&Runner{Script: s, Stdin: noiseReader{}, Stdout: io.Discard}).Run(ctx)
+	if err == nil || !strings.Contains(err.Error(), "no input") {
+		t.Errorf("Run error = %v", err)
+	}
+}
+
+func TestRunnerIdlesUntilEOF(t *testing.T) {
+	s, _ := Parse([]byte("steps:\n  - say: working\n"))
+	out := &syncBuffer{}
+	err := (&Runner{Script: s, Stdin: strings.NewReader("gt prime\n"), Stdout: out}).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "received: gt prime") || !strings.HasSuffix(text, DefaultReadyPrompt) {
+		t.Errorf("output = %q", text)
+	}
+}
+
+func TestRunnerWaitMail(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	// Fake gt: the inbox is empty until the second poll, and mark-read is logged.
+	gt := filepath.Join(dir, "gt")
+	script := `#!/bin/sh
+case "$1 $2" in
+"mail inbox")
+  n=$(cat "` + dir + `/polls" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "` + dir + `/polls"
+  if [ $n -lt 2 ]; then echo '[]'; exit 0; fi
+  echo '[{"id":"hq-1","from":"gastown/witness","subject":"HELLO"},{"id":"hq-2","from":"gastown/refinery","subject":"MERGED gt-abc","body":"ok"}]'
+  ;;
+"mail mark-read") echo "$3" >> "` + dir + `/read" ;;
+*) exit 2 ;;
+esac
+`
+	if err := os.WriteFile(gt, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Parse([]byte(`poll_interval: 10ms
+exit_when_done: true
+steps:
+  - wait_mail: MERGED
+    from: refinery
+    timeout: 5s
+  - run: echo "$GT_MAIL_ID $GT_MAIL_SUBJECT" > got.txt
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &syncBuffer{}
+	r := &Runner{Script: s, GT: gt, Dir: dir, Stdout: out}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.Run(ctx); err != nil {
+		t.Fatalf("Run: %v\n%s", err, out)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "got.txt"))
+	if strings.TrimSpace(string(got)) != "hq-2 MERGED gt-abc" {
+		t.Errorf("got.txt = %q", got)
+	}
+	read, _ := os.ReadFile(filepath.Join(dir, "read"))
+	if strings.TrimSpace(string(read)) != "hq-2" {
+		t.Errorf("marked read = %q", read)
+	}
+}
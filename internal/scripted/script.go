@@ -0,0 +1,414 @@
+// Package scripted implements the "scripted" agent runtime: a deterministic
+// stand-in for an LLM CLI that runs in an agent's tmux pane and follows a
+// script of shell commands, mail waits and prompts. It lets whole town
+// workflows (sling, polecat work, gt done, refinery merge) run in
+// integration tests and demos without model access.
+package scripted
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReadyPrompt is printed when the script is waiting for input. It
+// matches the scripted provider's default ReadyPromptPrefix in config.
+const DefaultReadyPrompt = "> "
+
+// DefaultPollInterval is how often wait_mail checks the inbox.
+const DefaultPollInterval = 2 * time.Second
+
+// Action is what a step does.
+type Action string
+
+// Step actions.
+const (
+	// ActionRun runs a shell command in the agent's working directory.
+	ActionRun Action = "run"
+	// ActionSay prints a line to the pane.
+	ActionSay Action = "say"
+	// ActionPrompt prints the ready prompt and waits for a line of input
+	// (e.g., a nudge) containing the step's value.
+	ActionPrompt Action = "prompt"
+	// ActionWaitMail waits for an unread message whose subject contains
+	// the step's value, then marks it read.
+	ActionWaitMail Action = "wait_mail"
+	// ActionSleep pauses for a duration.
+	ActionSleep Action = "sleep"
+	// ActionExit exits the runtime with the given status code.
+	ActionExit Action = "exit"
+)
+
+// Script is a parsed scripted-agent script.
+//
+// Scripts are written in a small YAML subset:
+//
+//	ready_prompt: "> "
+//	exit_when_done: false
+//	steps:
+//	  - run: gt hook
+//	  - wait_mail: MERGED
+//	    from: refinery
+//	    timeout: 10m
+//	  - run: |
+//	      git commit -am "work"
+//	      gt done
+//	    allow_failure: true
+//
+// Only top-level scalars, the steps list, quoted or plain scalars and "|"
+// block scalars are supported.
+type Script struct {
+	// ReadyPrompt is printed when waiting for input. Default: DefaultReadyPrompt.
+	ReadyPrompt string
+	// ExitWhenDone exits after the last step instead of idling at the prompt.
+	ExitWhenDone bool
+	// PollInterval is how often wait_mail polls. Default: DefaultPollInterval.
+	PollInterval time.Duration
+	// Steps run in order.
+	Steps []Step
+}
+
+// Step is one scripted action.
+type Step struct {
+	Action Action
+	// Value is the action's argument: the command for run, the text for
+	// say, the expected input for prompt, the subject match for wait_mail.
+	Value string
+	// Duration is the sleep length for sleep steps.
+	Duration time.Duration
+	// Code is the exit status for exit steps.
+	Code int
+	// Timeout bounds run, prompt and wait_mail steps. Zero waits forever.
+	Timeout time.Duration
+	// From restricts wait_mail to senders containing this string.
+	From string
+	// Dir is the working directory for run steps, relative to the agent's.
+	Dir string
+	// AllowFailure continues past a failing run step.
+	AllowFailure bool
+	// Line is the script line the step starts on, for error messages.
+	Line int
+}
+
+// Load reads and parses a script file.
+func Load(path string) (*Script, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from the operator
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse parses a script.
+func Parse(data []byte) (*Script, error) {
+	p := &parser{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+	s := &Script{ReadyPrompt: DefaultReadyPrompt, PollInterval: DefaultPollInterval}
+
+	for p.next() {
+		if p.indent != 0 {
+			return nil, p.errorf("unexpected indentation")
+		}
+		key, value, err := p.keyValue(p.text)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "ready_prompt":
+			if s.ReadyPrompt, err = scalar(value); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		case "exit_when_done":
+			if s.ExitWhenDone, err = p.boolean(value); err != nil {
+				return nil, err
+			}
+		case "poll_interval":
+			if s.PollInterval, err = p.duration(value); err != nil {
+				return nil, err
+			}
+		case "steps":
+			if value != "" {
+				return nil, p.errorf("steps must be a list")
+			}
+			if s.Steps, err = p.steps(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf("unknown key %q", key)
+		}
+	}
+	if s.ReadyPrompt == "" {
+		return nil, fmt.Errorf("ready_prompt must not be empty")
+	}
+	return s, nil
+}
+
+// parser walks script lines, skipping blanks and comments.
+type parser struct {
+	lines  []string
+	pos    int // index of the next unread line
+	line   int // 1-based number of the current line
+	indent int
+	text   string // current line without indentation
+}
+
+// next advances to the next significant line.
+func (p *parser) next() bool {
+	for p.pos < len(p.lines) {
+		raw := strings.TrimRight(p.lines[p.pos], " \t")
+		p.pos++
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		p.line = p.pos
+		p.indent = len(raw) - len(trimmed)
+		p.text = trimmed
+		return true
+	}
+	return false
+}
+
+// back un-reads the current line.
+func (p *parser) back() {
+	p.pos = p.line - 1
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) keyValue(text string) (string, string, error) {
+	key, value, ok := strings.Cut(text, ":")
+	if !ok || key == "" || strings.ContainsAny(key, " \t\"'") {
+		return "", "", p.errorf("expected \"key: value\", got %q", text)
+	}
+	return key, strings.TrimSpace(value), nil
+}
+
+// steps parses the list under "steps:".
+func (p *parser) steps() ([]Step, error) {
+	var steps []Step
+	for p.next() {
+		if p.indent == 0 && !strings.HasPrefix(p.text, "-") {
+			p.back()
+			break
+		}
+		if !strings.HasPrefix(p.text, "- ") && p.text != "-" {
+			return nil, p.errorf("expected a \"- \" list item")
+		}
+		step, err := p.step()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// step parses one list item whose first line is current.
+func (p *parser) step() (Step, error) {
+	step := Step{Line: p.line}
+	itemIndent := p.indent
+	keyIndent := p.indent + 2
+	fields := make(map[string]string)
+
+	text := strings.TrimSpace(strings.TrimPrefix(p.text, "-"))
+	for {
+		if text != "" {
+			key, value, err := p.keyValue(text)
+			if err != nil {
+				return step, err
+			}
+			if _, dup := fields[key]; dup {
+				return step, p.errorf("duplicate key %q", key)
+			}
+			if value == "|" || value == "|-" {
+				value = p.block(keyIndent)
+			} else if value, err = scalar(value); err != nil {
+				return step, p.errorf("%v", err)
+			}
+			fields[key] = value
+		}
+		if !p.next() {
+			break
+		}
+		if p.indent <= itemIndent {
+			p.back()
+			break
+		}
+		if p.indent != keyIndent {
+			return step, p.errorf("inconsistent indentation")
+		}
+		text = p.text
+	}
+
+	return step, p.fill(&step, fields)
+}
+
+// block reads a "|" block scalar indented deeper than keyIndent.
+func (p *parser) block(keyIndent int) string {
+	var out []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		raw := strings.TrimRight(p.lines[p.pos], " \t")
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+		if trimmed != "" && indent <= keyIndent {
+			break
+		}
+		p.pos++
+		if trimmed == "" {
+			out = append(out, "")
+			continue
+		}
+		if blockIndent < 0 {
+			blockIndent = indent
+		}
+		if indent < blockIndent {
+			out = append(out, trimmed) // tolerate under-indented lines
+			continue
+		}
+		out = append(out, raw[blockIndent:])
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// fill validates a step's fields and stores them.
+func (p *parser) fill(step *Step, fields map[string]string) error {
+	for _, a := range []Action{ActionRun, ActionSay, ActionPrompt, ActionWaitMail, ActionSleep, ActionExit} {
+		value, ok := fields[string(a)]
+		if !ok {
+			continue
+		}
+		if step.Action != "" {
+			return fmt.Errorf("line %d: step has both %s and %s", step.Line, step.Action, a)
+		}
+		step.Action = a
+		step.Value = value
+		delete(fields, string(a))
+	}
+
+	var err error
+	switch step.Action {
+	case "":
+		return fmt.Errorf("line %d: step has no action (run, say, prompt, wait_mail, sleep, exit)", step.Line)
+	case ActionRun:
+		if step.Value == "" {
+			return fmt.Errorf("line %d: run needs a command", step.Line)
+		}
+	case ActionSleep:
+		if step.Duration, err = time.ParseDuration(step.Value); err != nil {
+			return fmt.Errorf("line %d: sleep: %v", step.Line, err)
+		}
+	case ActionExit:
+		if step.Value != "" {
+			if step.Code, err = strconv.Atoi(step.Value); err != nil {
+				return fmt.Errorf("line %d: exit code must be a number", step.Line)
+			}
+		}
+	}
+
+	allowed := map[Action][]string{
+		ActionRun:      {"timeout", "dir", "allow_failure"},
+		ActionPrompt:   {"timeout"},
+		ActionWaitMail: {"timeout", "from"},
+	}
+	for key, value := range fields {
+		ok := false
+		for _, k := range allowed[step.Action] {
+			ok = ok || k == key
+		}
+		if !ok {
+			return fmt.Errorf("line %d: %s step does not take %q", step.Line, step.Action, key)
+		}
+		switch key {
+		case "timeout":
+			if step.Timeout, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("line %d: timeout: %v", step.Line, err)
+			}
+		case "dir":
+			step.Dir = value
+		case "from":
+			step.From = value
+		case "allow_failure":
+			if step.AllowFailure, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("line %d: allow_failure must be true or false", step.Line)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) boolean(value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, p.errorf("expected true or false, got %q", value)
+	}
+	return b, nil
+}
+
+func (p *parser) duration(value string) (time.Duration, error) {
+	s, err := scalar(value)
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	return d, nil
+}
+
+// scalar decodes a plain, single-quoted or double-quoted scalar, dropping
+// a trailing comment.
+func scalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", fmt.Errorf("unterminated string %s", value)
+		}
+		if err := trailing(value[len(quoted):]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(quoted)
+	case strings.HasPrefix(value, "'"):
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			if value[i] != '\'' {
+				b.WriteByte(value[i])
+				continue
+			}
+			if i+1 < len(value) && value[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			if err := trailing(value[i+1:]); err != nil {
+				return "", err
+			}
+			return b.String(), nil
+		}
+		return "", fmt.Errorf("unterminated string %s", value)
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+// trailing checks that only whitespace or a comment follows a quoted scalar.
+func trailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected text after string: %s", rest)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package scripted
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	src := `# polecat script
+ready_prompt: "$ "
+exit_when_done: true
+poll_interval: 100ms
+steps:
+  - run: gt hook
+  - say: 'it''s working' # comment
+  - run: |
+      echo one
+      echo two
+    allow_failure: true
+    timeout: 30s
+    dir: sub
+  - wait_mail: MERGED
+    from: refinery
+  - prompt: go
+  - sleep: 1s
+  - exit: 3
+`
+	s, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ReadyPrompt != "$ " || !s.ExitWhenDone || s.PollInterval != 100*time.Millisecond {
+		t.Errorf("script = %+v", s)
+	}
+	if len(s.Steps) != 7 {
+		t.Fatalf("got %d steps, want 7", len(s.Steps))
+	}
+
+	want := []Step{
+		{Action: ActionRun, Value: "gt hook", Line: 6},
+		{Action: ActionSay, Value: "it's working", Line: 7},
+		{Action: ActionRun, Value: "echo one\necho two", AllowFailure: true, Timeout: 30 * time.Second, Dir: "sub", Line: 8},
+		{Action: ActionWaitMail, Value: "MERGED", From: "refinery", Line: 14},
+		{Action: ActionPrompt, Value: "go", Line: 16},
+		{Action: ActionSleep, Value: "1s", Duration: time.Second, Line: 17},
+		{Action: ActionExit, Value: "3", Code: 3, Line: 18},
+	}
+	for i := range want {
+		if s.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, s.Steps[i], want[i])
+		}
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	s, err := Parse([]byte("steps:\n- say: hi\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ReadyPrompt != DefaultReadyPrompt || s.ExitWhenDone || s.PollInterval != DefaultPollInterval {
+		t.Errorf("defaults = %+v", s)
+	}
+	if len(s.Steps) != 1 || s.Steps[0].Value != "hi" {
+		t.Errorf("steps = %+v", s.Steps)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unknown key", "model: opus\n", `line 1: unknown key "model"`},
+		{"two actions", "steps:\n  - run: a\n    say: b\n", "both run and say"},
+		{"no action", "steps:\n  - timeout: 1s\n", "no action"},
+		{"wrong modifier", "steps:\n  - say: hi\n    from: x\n", `say step does not take "from"`},
+		{"bad duration", "steps:\n  - sleep: soon\n", "line 2: sleep"},
+		{"bad indent", "steps:\n  - run: a\n     dir: b\n", "line 3: inconsistent indentation"},
+		{"unterminated", "ready_prompt: \"> \n", "unterminated string"},
+		{"not a list", "steps: run\n", "steps must be a list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
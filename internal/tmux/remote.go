@@ -0,0 +1,102 @@
+package tmux
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// CommandFunc builds the command used to run a program on the machine that
+// hosts the tmux server. Remote transports (e.g. SSH) supply one that wraps
+// the invocation so it executes on the far side.
+type CommandFunc func(name string, args ...string) *exec.Cmd
+
+// NewTmuxWithCommand creates a Tmux wrapper whose tmux and process-inspection
+// commands are built by fn instead of running locally. This lets the same
+// session management code drive a tmux server on another machine.
+func NewTmuxWithCommand(fn CommandFunc) *Tmux {
+	return &Tmux{cmdFn: fn}
+}
+
+// IsRemote returns true if this Tmux drives a tmux server through a
+// CommandFunc rather than on the local machine.
+func (t *Tmux) IsRemote() bool {
+	return t.cmdFn != nil
+}
+
+// command builds an *exec.Cmd for name on the machine this Tmux manages.
+func (t *Tmux) command(name string, args ...string) *exec.Cmd {
+	if t.cmdFn != nil {
+		return t.cmdFn(name, args...)
+	}
+	return exec.Command(name, args...)
+}
+
+// remoteKillScript terminates a pane's process tree on the machine hosting the
+// tmux server. It mirrors KillPaneProcessesExcluding: descendants are walked
+// deepest-first with pgrep, sent SIGTERM, given a grace period, then SIGKILL.
+// Process-group kills are deliberately skipped because procps-ng kill can
+// misparse negative PGIDs (see KillSessionWithProcesses).
+//
+// Arguments: $1 = tmux target, $2 = grace seconds, $3... = PIDs to exclude.
+const remoteKillScript = `target="$1"; grace="$2"; shift 2
+pid=$(tmux -u list-panes -t "$target" -F '#{pane_pid}' 2>/dev/null | head -n 1)
+[ -n "$pid" ] || exit 3
+excluded() { for x in $EXCLUDE; do [ "$x" = "$1" ] && return 0; done; return 1; }
+EXCLUDE="$*"
+desc() { for c in $(pgrep -P "$1" 2>/dev/null); do desc "$c"; echo "$c"; done; }
+kill_list=""
+for p in $(desc "$pid"); do excluded "$p" || kill_list="$kill_list $p"; done
+[ -n "$kill_list" ] && kill -TERM $kill_list 2>/dev/null
+sleep "$grace"
+[ -n "$kill_list" ] && kill -KILL $kill_list 2>/dev/null
+if ! excluded "$pid"; then
+  kill -TERM "$pid" 2>/dev/null
+  sleep "$grace"
+  kill -KILL "$pid" 2>/dev/null
+fi
+exit 0`
+
+// errRemotePaneMissing is returned when the remote kill script cannot find
+// the target pane (exit status 3).
+var errRemotePaneMissing = errors.New("pane not found")
+
+// runRemoteKill runs remoteKillScript against target on the remote machine.
+func (t *Tmux) runRemoteKill(target string, excludePIDs []string) error {
+	grace := strings.TrimSuffix(processKillGracePeriod.String(), "s")
+	args := append([]string{"-c", remoteKillScript, "sh", target, grace}, excludePIDs...)
+	cmd := t.command("sh", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+			return errRemotePaneMissing
+		}
+		return t.wrapError(err, stderr.String(), []string{"kill-pane-processes"})
+	}
+	return nil
+}
+
+// killRemoteSession is KillSessionWithProcessesExcluding for remote servers.
+func (t *Tmux) killRemoteSession(name string, excludePIDs []string) error {
+	if err := t.runRemoteKill(name, excludePIDs); err != nil && !errors.Is(err, errRemotePaneMissing) {
+		return err
+	}
+	// Killing the last session's processes can take the server down with it.
+	err := t.KillSession(name)
+	if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrNoServer) {
+		return nil
+	}
+	return err
+}
+
+// killRemotePane is KillPaneProcessesExcluding for remote servers.
+func (t *Tmux) killRemotePane(pane string, excludePIDs []string) error {
+	err := t.runRemoteKill(pane, excludePIDs)
+	if errors.Is(err, errRemotePaneMissing) {
+		return errors.New("pane PID is empty")
+	}
+	return err
+}
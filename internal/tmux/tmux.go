@@ -38,7 +38,11 @@ var (
 var claudeVersionRe = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
 
 // Tmux wraps tmux operations.
-type Tmux struct{}
+type Tmux struct {
+	// cmdFn builds the commands used to invoke tmux and its process helpers.
+	// Nil means run them on the local machine. See NewTmuxWithCommand.
+	cmdFn CommandFunc
+}
 
 // NewTmux creates a new Tmux wrapper.
 func NewTmux() *Tmux {
@@ -51,7 +55,7 @@ func NewTmux() *Tmux {
 func (t *Tmux) run(args ...string) (string, error) {
 	// Prepend -u flag for UTF-8 mode (PATCH-004)
 	allArgs := append([]string{"-u"}, args...)
-	cmd := exec.Command("tmux", allArgs...)
+	cmd := t.command("tmux", allArgs...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -178,6 +182,10 @@ const processKillGracePeriod = 2 * time.Second
 //
 // This ensures Claude processes and all their children are properly terminated.
 func (t *Tmux) KillSessionWithProcesses(name string) error {
+	if t.IsRemote() {
+		return t.killRemoteSession(name, nil)
+	}
+
 	// Get the pane PID
 	pid, err := t.GetPanePID(name)
 	if err != nil {
@@ -238,6 +246,10 @@ func (t *Tmux) KillSessionWithProcesses(name string) error {
 // the calling process (e.g., gt done) is running inside the session it's terminating.
 // Without exclusion, the caller would be killed before completing the cleanup.
 func (t *Tmux) KillSessionWithProcessesExcluding(name string, excludePIDs []string) error {
+	if t.IsRemote() {
+		return t.killRemoteSession(name, excludePIDs)
+	}
+
 	// Build exclusion set for O(1) lookup
 	exclude := make(map[string]bool)
 	for _, pid := range excludePIDs {
@@ -348,6 +360,10 @@ func getAllDescendants(pid string) []string {
 // This ensures Claude processes and all their children are properly terminated
 // before respawning the pane.
 func (t *Tmux) KillPaneProcesses(pane string) error {
+	if t.IsRemote() {
+		return t.killRemotePane(pane, nil)
+	}
+
 	// Get the pane PID
 	pid, err := t.GetPanePID(pane)
 	if err != nil {
@@ -403,6 +419,10 @@ func (t *Tmux) KillPaneProcesses(pane string) error {
 // survive. After this function returns, RespawnPane's -k flag will send SIGHUP to
 // clean up the remaining processes.
 func (t *Tmux) KillPaneProcessesExcluding(pane string, excludePIDs []string) error {
+	if t.IsRemote() {
+		return t.killRemotePane(pane, excludePIDs)
+	}
+
 	// Build exclusion set for O(1) lookup
 	exclude := make(map[string]bool)
 	for _, pid := range excludePIDs {
@@ -481,7 +501,7 @@ func (t *Tmux) SetExitEmpty(on bool) error {
 
 // IsAvailable checks if tmux is installed and can be invoked.
 func (t *Tmux) IsAvailable() bool {
-	cmd := exec.Command("tmux", "-V")
+	cmd := t.command("tmux", "-V")
 	return cmd.Run() == nil
 }
 
@@ -898,11 +918,16 @@ func (t *Tmux) GetPanePID(session string) (string, error) {
 // Uses ps to get the actual command name from the process's executable path.
 // This handles cases where argv[0] is modified (e.g., Claude showing version "2.1.30").
 func processMatchesNames(pid string, names []string) bool {
+	return (&Tmux{}).processMatchesNames(pid, names)
+}
+
+// processMatchesNames is processMatchesNames run on the machine this Tmux manages.
+func (t *Tmux) processMatchesNames(pid string, names []string) bool {
 	if len(names) == 0 {
 		return false
 	}
 	// Use ps to get the command name (COMM column gives the executable name)
-	cmd := exec.Command("ps", "-p", pid, "-o", "comm=")
+	cmd := t.command("ps", "-p", pid, "-o", "comm=")
 	out, err := cmd.Output()
 	if err != nil {
 		return false
@@ -937,12 +962,17 @@ func processMatchesNames(pid string, names []string) bool {
 // matching any of the given names. Recursively traverses the process tree up to maxDepth.
 // Used when the pane command is a shell (bash, zsh) that launched an agent.
 func hasDescendantWithNames(pid string, names []string, depth int) bool {
+	return (&Tmux{}).hasDescendantWithNames(pid, names, depth)
+}
+
+// hasDescendantWithNames is hasDescendantWithNames run on the machine this Tmux manages.
+func (t *Tmux) hasDescendantWithNames(pid string, names []string, depth int) bool {
 	const maxDepth = 10 // Prevent infinite loops in case of circular references
 	if len(names) == 0 || depth > maxDepth {
 		return false
 	}
 	// Use pgrep to find child processes
-	cmd := exec.Command("pgrep", "-P", pid, "-l")
+	cmd := t.command("pgrep", "-P", pid, "-l")
 	out, err := cmd.Output()
 	if err != nil {
 		return false
@@ -969,7 +999,7 @@ func hasDescendantWithNames(pid string, names []string, depth int) bool {
 				return true
 			}
 			// Recursive check of descendants
-			if hasDescendantWithNames(childPid, names, depth+1) {
+			if t.hasDescendantWithNames(childPid, names, depth+1) {
 				return true
 			}
 		}
@@ -1216,17 +1246,17 @@ func (t *Tmux) IsRuntimeRunning(session string, processNames []string) bool {
 	// If pane command is a shell, check descendants
 	for _, shell := range constants.SupportedShells {
 		if cmd == shell {
-			return hasDescendantWithNames(pid, processNames, 0)
+			return t.hasDescendantWithNames(pid, processNames, 0)
 		}
 	}
 	// If pane command is unrecognized (not in processNames, not a shell),
 	// check if the process ITSELF matches (handles version-as-argv[0] like "2.1.30")
 	// before checking descendants.
-	if processMatchesNames(pid, processNames) {
+	if t.processMatchesNames(pid, processNames) {
 		return true
 	}
 	// Finally check descendants as fallback
-	return hasDescendantWithNames(pid, processNames, 0)
+	return t.hasDescendantWithNames(pid, processNames, 0)
 }
 
 // IsAgentAlive checks if an agent is running in the session using agent-agnostic detection.
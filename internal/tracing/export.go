@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// exportTimeout bounds a collector request. Spans are exported
+// synchronously from gt commands, so an unresponsive collector must not
+// hold them up for long.
+const exportTimeout = 2 * time.Second
+
+// exporter sends ended spans to an OTLP/HTTP collector, or appends them to
+// a file when there is no collector endpoint or the collector fails.
+type exporter struct {
+	endpoint string // OTLP traces URL; "" writes straight to file
+	file     string
+	client   *http.Client
+}
+
+func newExporter(endpoint, file string) exporter {
+	return exporter{endpoint: endpoint, file: file, client: &http.Client{Timeout: exportTimeout}}
+}
+
+func (e exporter) export(s *Span, end time.Time) {
+	body, err := json.Marshal(newExportRequest(s, end))
+	if err != nil {
+		return
+	}
+	if e.endpoint != "" && e.post(body) == nil {
+		return
+	}
+	e.append(body)
+}
+
+func (e exporter) post(body []byte) error {
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// append writes one export request per line, the format read by the
+// OpenTelemetry Collector's file receiver (otlpjsonfile).
+func (e exporter) append(body []byte) {
+	if e.file == "" {
+		return
+	}
+	f, err := os.OpenFile(e.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: trace file is not sensitive
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(body, '\n'))
+}
+
+// OTLP JSON encoding of an ExportTraceServiceRequest holding one span.
+// IDs are hex and 64-bit integers are strings, as the OTLP/JSON mapping
+// requires.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanJSON `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanJSON struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+func newExportRequest(s *Span, end time.Time) exportRequest {
+	span := spanJSON{
+		TraceID:           hex.EncodeToString(s.ctx.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.ctx.SpanID[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+	}
+	if s.parent != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for _, a := range s.attrs {
+		span.Attributes = append(span.Attributes, newKeyValue(a.key, a.value))
+	}
+	if s.err != "" {
+		span.Status = &status{Code: statusCodeError, Message: s.err}
+	}
+
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{newKeyValue("service.name", ServiceName)}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "github.com/steveyegge/gastown/internal/tracing"},
+			Spans: []spanJSON{span},
+		}},
+	}}}
+}
+
+func newKeyValue(key string, value interface{}) keyValue {
+	kv := keyValue{Key: key}
+	switch v := value.(type) {
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case bool:
+		kv.Value.BoolValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
@@ -0,0 +1,302 @@
+// Package tracing records each bead's lifecycle, from gt sling to merge, as
+// one OpenTelemetry trace.
+//
+// The work is spread over short-lived gt commands, agent sessions and the
+// refinery, so there is no process to hold a trace open. Instead the W3C
+// traceparent of the bead's root span is stored on the bead (and copied to
+// its merge request), and each step starts its spans from it. Spans are
+// exported as they end, over OTLP/HTTP JSON to a local collector, and are
+// appended to a JSON lines file when the collector cannot be reached.
+//
+// A nil *Tracer and a nil *Span are valid and do nothing, so callers need
+// not check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Defaults for config.TracingConfig.
+const (
+	DefaultEndpoint = "http://localhost:4318"
+	DefaultFile     = ".traces.jsonl"
+
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// ServiceName is reported as the service.name resource attribute.
+const ServiceName = "gastown"
+
+// EnvTraceParent is the environment variable carrying a traceparent into
+// agent sessions, following the convention of OpenTelemetry CLI tools.
+const EnvTraceParent = "TRACEPARENT"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether both IDs are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats sc as a W3C traceparent header value (sampled).
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-01"
+}
+
+// ParseTraceParent parses a W3C traceparent header value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	traceID, err1 := hex.DecodeString(parts[1])
+	spanID, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(traceID) != 16 || len(spanID) != 8 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent %q: zero ID", s)
+	}
+	return sc, nil
+}
+
+// Tracer starts spans and exports them when they end.
+type Tracer struct {
+	exporter exporter
+}
+
+// New returns the tracer configured for a town, or nil if tracing is
+// disabled or the town settings cannot be read.
+func New(townRoot string) *Tracer {
+	if townRoot == "" {
+		return nil
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.Tracing == nil || !settings.Tracing.Enabled {
+		return nil
+	}
+	cfg := settings.Tracing
+
+	file := cfg.File
+	if file == "" {
+		file = DefaultFile
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(townRoot, file)
+	}
+	endpoint := ""
+	if cfg.Exporter != ExporterFile {
+		endpoint = otlpEndpoint(cfg.Endpoint)
+	}
+	return &Tracer{exporter: newExporter(endpoint, file)}
+}
+
+// ForDir returns the tracer for the town containing dir (see New).
+func ForDir(dir string) *Tracer {
+	townRoot, err := workspace.Find(dir)
+	if err != nil {
+		return nil
+	}
+	return New(townRoot)
+}
+
+// otlpEndpoint resolves the traces URL from the environment and config,
+// following the OTLP exporter conventions: the signal-specific variable is
+// used as is, the generic one gets /v1/traces appended.
+func otlpEndpoint(configured string) string {
+	if env := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); env != "" {
+		return env
+	}
+	base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if base == "" {
+		base = configured
+	}
+	if base == "" {
+		base = DefaultEndpoint
+	}
+	return strings.TrimSuffix(base, "/") + "/v1/traces"
+}
+
+// Start starts a span. It is a child of traceParent if that parses, and
+// otherwise the root of a new trace.
+func (t *Tracer) Start(traceParent, name string) *Span {
+	return t.StartAt(traceParent, name, time.Now())
+}
+
+// StartAt is Start with an explicit start time, for spans covering work
+// that began before the current process (such as time spent in a queue).
+func (t *Tracer) StartAt(traceParent, name string, start time.Time) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{tracer: t, name: name, start: start}
+	if parent, err := ParseTraceParent(traceParent); err == nil {
+		s.parent = parent.SpanID
+		s.ctx.TraceID = parent.TraceID
+	} else {
+		s.ctx.TraceID = newTraceID()
+	}
+	s.ctx.SpanID = newSpanID()
+	return s
+}
+
+// Resume recreates a span that was started, but not ended, by an earlier
+// process: its identity is traceParent itself rather than a child of it.
+// It is used to end a bead's root span once the bead's work is merged.
+func (t *Tracer) Resume(traceParent, name string, start time.Time) *Span {
+	if t == nil {
+		return nil
+	}
+	sc, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return nil
+	}
+	return &Span{tracer: t, name: name, start: start, ctx: sc}
+}
+
+// Span is one timed operation in a trace.
+type Span struct {
+	tracer *Tracer
+	name   string
+	ctx    SpanContext
+	parent [8]byte
+	start  time.Time
+	attrs  []attribute
+	err    string
+	ended  bool
+}
+
+type attribute struct {
+	key   string
+	value interface{} // string, int64 or bool
+}
+
+// TraceParent returns the traceparent to propagate to the span's children.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return s.ctx.TraceParent()
+}
+
+// Start starts a child span.
+func (s *Span) Start(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.Start(s.TraceParent(), name)
+}
+
+// SetAttr sets an attribute. Values other than strings, ints and bools are
+// formatted as strings.
+func (s *Span) SetAttr(key string, value interface{}) *Span {
+	if s == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case string, int64, bool:
+	case int:
+		value = int64(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return s
+		}
+	}
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+	return s
+}
+
+// SetError marks the span as failed. A nil err is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err.Error()
+}
+
+// End ends the span now and exports it.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt ends the span at the given time and exports it. Export failures
+// are dropped: tracing never fails the work being traced.
+func (s *Span) EndAt(end time.Time) {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	if end.Before(s.start) {
+		end = s.start
+	}
+	s.tracer.exporter.export(s, end)
+}
+
+// EndErr records err, if any, and ends the span. It suits defer:
+//
+//	defer func() { span.EndErr(err) }()
+func (s *Span) EndErr(err error) {
+	s.SetError(err)
+	s.End()
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context carrying span, so that functions further
+// down the call chain can start children of it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sc.TraceParent(); got != tp {
+		t.Errorf("round trip = %q, want %q", got, tp)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceParent(bad); err == nil {
+			t.Errorf("ParseTraceParent(%q) succeeded", bad)
+		}
+	}
+}
+
+// collector records OTLP export requests.
+type collector struct {
+	status   int
+	requests []exportRequest
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var req exportRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, req)
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+}
+
+func (c *collector) spans() []spanJSON {
+	var spans []spanJSON
+	for _, req := range c.requests {
+		spans = append(spans, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+	}
+	return spans
+}
+
+func attr(s spanJSON, key string) string {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			switch {
+			case kv.Value.StringValue != nil:
+				return *kv.Value.StringValue
+			case kv.Value.IntValue != nil:
+				return *kv.Value.IntValue
+			case kv.Value.BoolValue != nil && *kv.Value.BoolValue:
+				return "true"
+			}
+		}
+	}
+	return ""
+}
+
+func TestSpansExportAsOneTrace(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	tr := &Tracer{exporter: newExporter(srv.URL+"/v1/traces", "")}
+
+	start := time.Now().Add(-time.Hour)
+	root := tr.StartAt("", "bead gt-abc", start)
+	child := tr.Start(root.TraceParent(), "gt sling")
+	child.SetAttr("gt.bead", "gt-abc").SetAttr("gt.retries", 2).SetAttr("gt.no_merge", true)
+	child.End()
+	grandchild := child.Start("refinery.tests")
+	grandchild.EndErr(errors.New("tests failed"))
+	tr.Resume(root.TraceParent(), "bead gt-abc", start).End()
+
+	spans := c.spans()
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(spans))
+	}
+	sling, tests, bead := spans[0], spans[1], spans[2]
+	for _, s := range spans {
+		if s.TraceID != bead.TraceID {
+			t.Errorf("span %q in trace %s, want %s", s.Name, s.TraceID, bead.TraceID)
+		}
+	}
+	if bead.ParentSpanID != "" || sling.ParentSpanID != bead.SpanID || tests.ParentSpanID != sling.SpanID {
+		t.Errorf("bad parentage: bead %+v, sling %+v, tests %+v", bead, sling, tests)
+	}
+	if bead.StartTimeUnixNano != strconv.FormatInt(start.UnixNano(), 10) {
+		t.Errorf("resumed root start = %s, want %d", bead.StartTimeUnixNano, start.UnixNano())
+	}
+	if attr(sling, "gt.bead") != "gt-abc" || attr(sling, "gt.retries") != "2" || attr(sling, "gt.no_merge") != "true" {
+		t.Errorf("sling attributes = %+v", sling.Attributes)
+	}
+	if tests.Status == nil || tests.Status.Code != statusCodeError || tests.Status.Message != "tests failed" {
+		t.Errorf("tests status = %+v", tests.Status)
+	}
+	if res := c.requests[0].ResourceSpans[0].Resource.Attributes; len(res) != 1 || *res[0].Value.StringValue != ServiceName {
+		t.Errorf("resource = %+v", res)
+	}
+}
+
+func TestExportFallsBackToFile(t *testing.T) {
+	c := &collector{status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), DefaultFile)
+
+	tr := &Tracer{exporter: newExporter(srv.URL+"/v1/traces", file)}
+	tr.Start("", "one").End()
+	srv.Close()
+	tr.Start("", "two").End()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file has %d lines, want 2:\n%s", len(lines), data)
+	}
+	var req exportRequest
+	if err := json.Unmarshal([]byte(lines[1]), &req); err != nil {
+		t.Fatal(err)
+	}
+	if name := req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; name != "two" {
+		t.Errorf("second span = %q, want two", name)
+	}
+}
+
+func TestNewFromTownSettings(t *testing.T) {
+	townRoot := t.TempDir()
+	if New(townRoot) != nil {
+		t.Error("tracing enabled without settings")
+	}
+
+	settings := config.NewTownSettings()
+	settings.Tracing = &config.TracingConfig{Enabled: true, Exporter: ExporterFile}
+	if err := config.SaveTownSettings(config.TownSettingsPath(townRoot), settings); err != nil {
+		t.Fatal(err)
+	}
+	tr := New(townRoot)
+	if tr == nil {
+		t.Fatal("tracing disabled with enabled settings")
+	}
+	if tr.exporter.endpoint != "" || tr.exporter.file != filepath.Join(townRoot, DefaultFile) {
+		t.Errorf("exporter = %+v", tr.exporter)
+	}
+}
+
+func TestOTLPEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	if got := otlpEndpoint(""); got != DefaultEndpoint+"/v1/traces" {
+		t.Errorf("default = %q", got)
+	}
+	if got := otlpEndpoint("http://collector:4318/"); got != "http://collector:4318/v1/traces" {
+		t.Errorf("configured = %q", got)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://env:4318")
+	if got := otlpEndpoint("http://collector:4318"); got != "http://env:4318/v1/traces" {
+		t.Errorf("generic env = %q", got)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://env:4318/custom")
+	if got := otlpEndpoint(""); got != "http://env:4318/custom" {
+		t.Errorf("traces env = %q", got)
+	}
+}
+
+func TestNilTracerIsNoop(t *testing.T) {
+	var tr *Tracer
+	span := tr.Start("", "x")
+	span.SetAttr("k", "v").SetAttr("n", 1)
+	span.Start("child").End()
+	span.EndErr(errors.New("boom"))
+	if span.TraceParent() != "" || tr.Resume("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "x", time.Now()) != nil {
+		t.Error("nil tracer produced a span")
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 const (
@@ -49,6 +53,10 @@ type APIHandler struct {
 	gtPath string
 	// workDir is the working directory for command execution.
 	workDir string
+	// eventsPath is the town's .events.jsonl; empty outside a workspace.
+	eventsPath string
+	// v1 serves the typed /api/v1 endpoints; nil outside a workspace.
+	v1 http.Handler
 	// Options cache
 	optionsCache     *OptionsResponse
 	optionsCacheTime time.Time
@@ -62,10 +70,15 @@ func NewAPIHandler() *APIHandler {
 	// Use PATH lookup for gt binary. Do NOT use os.Executable() here - during
 	// tests it returns the test binary, causing fork bombs when executed.
 	workDir, _ := os.Getwd()
-	return &APIHandler{
+	h := &APIHandler{
 		gtPath:  "gt",
 		workDir: workDir,
 	}
+	if townRoot, err := workspace.Find(workDir); err == nil && townRoot != "" {
+		h.eventsPath = filepath.Join(townRoot, events.EventsFile)
+		h.v1 = NewV1Handler(NewLiveV1Backend(townRoot))
+	}
+	return h
 }
 
 // ServeHTTP routes API requests to the appropriate handler.
@@ -73,7 +86,7 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers for dashboard
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusOK)
@@ -82,6 +95,12 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	path := strings.TrimPrefix(r.URL.Path, "/api")
 	switch {
+	case strings.HasPrefix(path, "/v1/"):
+		if h.v1 == nil {
+			writeV1Error(w, http.StatusServiceUnavailable, "not in a Gas Town workspace")
+			return
+		}
+		h.v1.ServeHTTP(w, r)
 	case path == "/run" && r.Method == http.MethodPost:
 		h.handleRun(w, r)
 	case path == "/commands" && r.Method == http.MethodGet:
@@ -104,6 +123,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleCrew(w, r)
 	case path == "/ready" && r.Method == http.MethodGet:
 		h.handleReady(w, r)
+	case path == "/events/stream" && r.Method == http.MethodGet:
+		h.handleEventStream(w, r)
 	default:
 		http.Error(w, "Not found", http.StatusNotFound)
 	}
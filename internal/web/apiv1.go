@@ -0,0 +1,322 @@
+package web
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIV1 is the OpenAPI 3 description of /api/v1.
+//
+//go:embed openapi/v1.json
+var openAPIV1 []byte
+
+// ErrV1NotFound is returned by a V1Backend when the requested rig, issue or
+// mailbox does not exist. The handler maps it to 404.
+var ErrV1NotFound = errors.New("not found")
+
+// v1IssueIDPattern matches bead IDs such as gt-abc12 or hq-cv-x9.1. IDs
+// are passed to bd, so a leading dash (a flag) is rejected.
+var v1IssueIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// V1Backend provides the data behind /api/v1. The live implementation reads
+// the internal packages directly; tests substitute a fake.
+type V1Backend interface {
+	Rigs() ([]V1Rig, error)
+	Polecats(rig string) ([]V1Polecat, error)
+	MergeQueue(rig string) ([]V1MergeRequest, error)
+	Convoys(status string) ([]V1Convoy, error)
+	Issues(filter V1IssueFilter) ([]V1Issue, error)
+	Issue(id string) (*V1Issue, error)
+	Inbox(address string, unreadOnly bool) ([]V1MailMessage, error)
+}
+
+// V1Rig is a rig in the town.
+type V1Rig struct {
+	Name        string   `json:"name"`
+	GitURL      string   `json:"git_url"`
+	Polecats    []string `json:"polecats"`
+	Crew        []string `json:"crew"`
+	HasWitness  bool     `json:"has_witness"`
+	HasRefinery bool     `json:"has_refinery"`
+	Remote      bool     `json:"remote"`
+}
+
+// V1Polecat is a polecat worker in a rig.
+type V1Polecat struct {
+	Name      string    `json:"name"`
+	Rig       string    `json:"rig"`
+	State     string    `json:"state"`
+	Branch    string    `json:"branch,omitempty"`
+	Issue     string    `json:"issue,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// V1MergeRequest is a merge request ready for the refinery.
+type V1MergeRequest struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Branch      string    `json:"branch"`
+	Target      string    `json:"target"`
+	SourceIssue string    `json:"source_issue,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+	Priority    int       `json:"priority"`
+	RetryCount  int       `json:"retry_count"`
+	ConvoyID    string    `json:"convoy_id,omitempty"`
+	Score       float64   `json:"score"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// V1Convoy is a convoy and the issues it tracks.
+type V1Convoy struct {
+	ID        string           `json:"id"`
+	Title     string           `json:"title"`
+	Status    string           `json:"status"`
+	Completed int              `json:"completed"`
+	Total     int              `json:"total"`
+	Tracked   []V1TrackedIssue `json:"tracked"`
+}
+
+// V1TrackedIssue is an issue tracked by a convoy.
+type V1TrackedIssue struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// V1Issue is a beads issue.
+type V1Issue struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status"`
+	Priority    int      `json:"priority"`
+	Type        string   `json:"type"`
+	Assignee    string   `json:"assignee,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Parent      string   `json:"parent,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+	BlockedBy   []string `json:"blocked_by,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	ClosedAt    string   `json:"closed_at,omitempty"`
+}
+
+// V1IssueFilter selects issues for GET /api/v1/issues.
+type V1IssueFilter struct {
+	Rig      string // rig whose beads to query; empty for town beads
+	Status   string // open, closed, all (default open)
+	Type     string
+	Label    string
+	Assignee string
+	Limit    int // 0 for no limit
+}
+
+// V1MailMessage is a message in an agent's inbox.
+type V1MailMessage struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	Timestamp time.Time `json:"timestamp"`
+	Read      bool      `json:"read"`
+	Priority  string    `json:"priority"`
+	Type      string    `json:"type"`
+	ThreadID  string    `json:"thread_id,omitempty"`
+}
+
+// V1Error is the body of every non-2xx /api/v1 response.
+type V1Error struct {
+	Error string `json:"error"`
+}
+
+// V1Handler serves the versioned JSON API under /api/v1.
+type V1Handler struct {
+	backend V1Backend
+	mux     *http.ServeMux
+}
+
+// NewV1Handler creates a /api/v1 handler backed by backend.
+func NewV1Handler(backend V1Backend) *V1Handler {
+	h := &V1Handler{backend: backend, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /api/v1/openapi.json", h.handleOpenAPI)
+	h.mux.HandleFunc("GET /api/v1/rigs", h.handleRigs)
+	h.mux.HandleFunc("GET /api/v1/rigs/{rig}/polecats", h.handlePolecats)
+	h.mux.HandleFunc("GET /api/v1/rigs/{rig}/merge-queue", h.handleMergeQueue)
+	h.mux.HandleFunc("GET /api/v1/convoys", h.handleConvoys)
+	h.mux.HandleFunc("GET /api/v1/issues", h.handleIssues)
+	h.mux.HandleFunc("GET /api/v1/issues/{id}", h.handleIssue)
+	h.mux.HandleFunc("GET /api/v1/mail/inbox", h.handleInbox)
+	h.mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, _ *http.Request) {
+		writeV1Error(w, http.StatusNotFound, "no such endpoint")
+	})
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *V1Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func writeV1JSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeV1Error(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(V1Error{Error: msg})
+}
+
+// writeV1BackendError maps a backend error to a status code.
+func writeV1BackendError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrV1NotFound) {
+		writeV1Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeV1Error(w, http.StatusInternalServerError, err.Error())
+}
+
+func (h *V1Handler) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIV1)
+}
+
+func (h *V1Handler) handleRigs(w http.ResponseWriter, _ *http.Request) {
+	rigs, err := h.backend.Rigs()
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, struct {
+		Rigs []V1Rig `json:"rigs"`
+	}{nonNil(rigs)})
+}
+
+func (h *V1Handler) handlePolecats(w http.ResponseWriter, r *http.Request) {
+	polecats, err := h.backend.Polecats(r.PathValue("rig"))
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, struct {
+		Polecats []V1Polecat `json:"polecats"`
+	}{nonNil(polecats)})
+}
+
+func (h *V1Handler) handleMergeQueue(w http.ResponseWriter, r *http.Request) {
+	mrs, err := h.backend.MergeQueue(r.PathValue("rig"))
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, struct {
+		MergeRequests []V1MergeRequest `json:"merge_requests"`
+	}{nonNil(mrs)})
+}
+
+func (h *V1Handler) handleConvoys(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "open"
+	case "open", "closed", "all":
+	default:
+		writeV1Error(w, http.StatusBadRequest, "status must be open, closed or all")
+		return
+	}
+	convoys, err := h.backend.Convoys(status)
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, struct {
+		Convoys []V1Convoy `json:"convoys"`
+	}{nonNil(convoys)})
+}
+
+func (h *V1Handler) handleIssues(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := V1IssueFilter{
+		Rig:      q.Get("rig"),
+		Status:   q.Get("status"),
+		Type:     q.Get("type"),
+		Label:    q.Get("label"),
+		Assignee: q.Get("assignee"),
+	}
+	switch filter.Status {
+	case "":
+		filter.Status = "open"
+	case "open", "closed", "in_progress", "all":
+	default:
+		writeV1Error(w, http.StatusBadRequest, "status must be open, in_progress, closed or all")
+		return
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeV1Error(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		filter.Limit = n
+	}
+
+	issues, err := h.backend.Issues(filter)
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	if filter.Limit > 0 && len(issues) > filter.Limit {
+		issues = issues[:filter.Limit]
+	}
+	writeV1JSON(w, struct {
+		Issues []V1Issue `json:"issues"`
+	}{nonNil(issues)})
+}
+
+func (h *V1Handler) handleIssue(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !v1IssueIDPattern.MatchString(id) {
+		writeV1Error(w, http.StatusBadRequest, "invalid issue id")
+		return
+	}
+	issue, err := h.backend.Issue(id)
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, issue)
+}
+
+func (h *V1Handler) handleInbox(w http.ResponseWriter, r *http.Request) {
+	address := strings.TrimSpace(r.URL.Query().Get("address"))
+	if address == "" {
+		writeV1Error(w, http.StatusBadRequest, "address is required")
+		return
+	}
+	unread := r.URL.Query().Get("unread") == "true"
+	msgs, err := h.backend.Inbox(address, unread)
+	if err != nil {
+		writeV1BackendError(w, err)
+		return
+	}
+	writeV1JSON(w, struct {
+		Messages []V1MailMessage `json:"messages"`
+	}{nonNil(msgs)})
+}
+
+// nonNil returns s, or an empty slice so lists encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
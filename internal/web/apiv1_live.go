@@ -0,0 +1,246 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/convoy"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// LiveV1Backend serves /api/v1 from the town on disk, using the internal
+// packages rather than parsing gt/bd output.
+type LiveV1Backend struct {
+	townRoot string
+}
+
+// NewLiveV1Backend creates a V1Backend for the town at townRoot.
+func NewLiveV1Backend(townRoot string) *LiveV1Backend {
+	return &LiveV1Backend{townRoot: townRoot}
+}
+
+func (b *LiveV1Backend) rigManager() (*rig.Manager, error) {
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(b.townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading rigs config: %w", err)
+	}
+	return rig.NewManager(b.townRoot, rigsConfig, git.NewGit(b.townRoot)), nil
+}
+
+func (b *LiveV1Backend) getRig(name string) (*rig.Rig, error) {
+	mgr, err := b.rigManager()
+	if err != nil {
+		return nil, err
+	}
+	r, err := mgr.GetRig(name)
+	if errors.Is(err, rig.ErrRigNotFound) {
+		return nil, fmt.Errorf("rig %q: %w", name, ErrV1NotFound)
+	}
+	return r, err
+}
+
+// Rigs implements V1Backend.
+func (b *LiveV1Backend) Rigs() ([]V1Rig, error) {
+	mgr, err := b.rigManager()
+	if err != nil {
+		return nil, err
+	}
+	rigs, err := mgr.DiscoverRigs()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1Rig, 0, len(rigs))
+	for _, r := range rigs {
+		out = append(out, V1Rig{
+			Name:        r.Name,
+			GitURL:      r.GitURL,
+			Polecats:    nonNil(r.Polecats),
+			Crew:        nonNil(r.Crew),
+			HasWitness:  r.HasWitness,
+			HasRefinery: r.HasRefinery,
+			Remote:      r.IsRemote(),
+		})
+	}
+	return out, nil
+}
+
+// Polecats implements V1Backend.
+func (b *LiveV1Backend) Polecats(rigName string) ([]V1Polecat, error) {
+	r, err := b.getRig(rigName)
+	if err != nil {
+		return nil, err
+	}
+	// Listing reads worktrees and beads only; no tmux needed.
+	polecats, err := polecat.NewManager(r, git.NewGit(r.Path), nil).List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1Polecat, 0, len(polecats))
+	for _, p := range polecats {
+		out = append(out, V1Polecat{
+			Name:      p.Name,
+			Rig:       p.Rig,
+			State:     string(p.State),
+			Branch:    p.Branch,
+			Issue:     p.Issue,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+// MergeQueue implements V1Backend.
+func (b *LiveV1Backend) MergeQueue(rigName string) ([]V1MergeRequest, error) {
+	r, err := b.getRig(rigName)
+	if err != nil {
+		return nil, err
+	}
+	mrs, err := refinery.NewEngineer(r).ListReadyMRs()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1MergeRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		out = append(out, V1MergeRequest{
+			ID:          mr.ID,
+			Title:       mr.Title,
+			Branch:      mr.Branch,
+			Target:      mr.Target,
+			SourceIssue: mr.SourceIssue,
+			Worker:      mr.Worker,
+			Priority:    mr.Priority,
+			RetryCount:  mr.RetryCount,
+			ConvoyID:    mr.ConvoyID,
+			Score:       mr.Score(),
+			CreatedAt:   mr.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// Convoys implements V1Backend. Convoys live in the town beads.
+func (b *LiveV1Backend) Convoys(status string) ([]V1Convoy, error) {
+	convoys, err := convoy.List(b.townRoot, status)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1Convoy, 0, len(convoys))
+	for _, c := range convoys {
+		v := V1Convoy{ID: c.ID, Title: c.Title, Status: c.Status, Completed: c.Completed(), Total: len(c.Tracked)}
+		v.Tracked = make([]V1TrackedIssue, 0, len(c.Tracked))
+		for _, t := range c.Tracked {
+			v.Tracked = append(v.Tracked, V1TrackedIssue{ID: t.ID, Title: t.Title, Status: t.Status})
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Issues implements V1Backend.
+func (b *LiveV1Backend) Issues(filter V1IssueFilter) ([]V1Issue, error) {
+	workDir := b.townRoot
+	if filter.Rig != "" {
+		r, err := b.getRig(filter.Rig)
+		if err != nil {
+			return nil, err
+		}
+		workDir = r.Path
+	}
+	opts := beads.ListOptions{
+		Status:   filter.Status,
+		Label:    filter.Label,
+		Assignee: filter.Assignee,
+		Priority: -1,
+	}
+	// bd list takes one label; with both set, type is checked here.
+	if opts.Label == "" {
+		opts.Type = filter.Type
+	}
+	issues, err := beads.New(workDir).List(opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1Issue, 0, len(issues))
+	for _, issue := range issues {
+		if filter.Label != "" && filter.Type != "" && issue.Type != filter.Type {
+			continue
+		}
+		out = append(out, v1Issue(issue))
+	}
+	return out, nil
+}
+
+// Issue implements V1Backend. bd routes the ID to its rig by prefix.
+func (b *LiveV1Backend) Issue(id string) (*V1Issue, error) {
+	issue, err := beads.New(b.townRoot).Show(id)
+	if errors.Is(err, beads.ErrNotFound) {
+		return nil, fmt.Errorf("issue %s: %w", id, ErrV1NotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := v1Issue(issue)
+	return &v, nil
+}
+
+func v1Issue(issue *beads.Issue) V1Issue {
+	return V1Issue{
+		ID:          issue.ID,
+		Title:       issue.Title,
+		Description: issue.Description,
+		Status:      issue.Status,
+		Priority:    issue.Priority,
+		Type:        issue.Type,
+		Assignee:    issue.Assignee,
+		Labels:      issue.Labels,
+		Parent:      issue.Parent,
+		DependsOn:   issue.DependsOn,
+		BlockedBy:   issue.BlockedBy,
+		CreatedAt:   issue.CreatedAt,
+		UpdatedAt:   issue.UpdatedAt,
+		ClosedAt:    issue.ClosedAt,
+	}
+}
+
+// Inbox implements V1Backend.
+func (b *LiveV1Backend) Inbox(address string, unreadOnly bool) ([]V1MailMessage, error) {
+	mailbox, err := mail.NewRouterWithTownRoot(b.townRoot, b.townRoot).GetMailbox(address)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*mail.Message
+	if unreadOnly {
+		msgs, err = mailbox.ListUnread()
+	} else {
+		msgs, err = mailbox.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]V1MailMessage, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, V1MailMessage{
+			ID:        m.ID,
+			From:      m.From,
+			To:        m.To,
+			Subject:   m.Subject,
+			Body:      m.Body,
+			Timestamp: m.Timestamp,
+			Read:      m.Read,
+			Priority:  string(m.Priority),
+			Type:      string(m.Type),
+			ThreadID:  m.ThreadID,
+		})
+	}
+	return out, nil
+}
+
+var _ V1Backend = (*LiveV1Backend)(nil)
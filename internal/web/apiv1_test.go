@@ -0,0 +1,247 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// MockV1Backend is a V1Backend with canned data.
+type MockV1Backend struct {
+	RigList     []V1Rig
+	PolecatsBy  map[string][]V1Polecat
+	MRsBy       map[string][]V1MergeRequest
+	ConvoyList  []V1Convoy
+	IssueList   []V1Issue
+	Messages    []V1MailMessage
+	Err         error
+	LastFilter  V1IssueFilter
+	LastStatus  string
+	LastAddress string
+	LastUnread  bool
+}
+
+func (m *MockV1Backend) Rigs() ([]V1Rig, error) { return m.RigList, m.Err }
+
+func (m *MockV1Backend) Polecats(rig string) ([]V1Polecat, error) {
+	p, ok := m.PolecatsBy[rig]
+	if !ok {
+		return nil, fmt.Errorf("rig %q: %w", rig, ErrV1NotFound)
+	}
+	return p, m.Err
+}
+
+func (m *MockV1Backend) MergeQueue(rig string) ([]V1MergeRequest, error) {
+	mrs, ok := m.MRsBy[rig]
+	if !ok {
+		return nil, fmt.Errorf("rig %q: %w", rig, ErrV1NotFound)
+	}
+	return mrs, m.Err
+}
+
+func (m *MockV1Backend) Convoys(status string) ([]V1Convoy, error) {
+	m.LastStatus = status
+	return m.ConvoyList, m.Err
+}
+
+func (m *MockV1Backend) Issues(filter V1IssueFilter) ([]V1Issue, error) {
+	m.LastFilter = filter
+	return m.IssueList, m.Err
+}
+
+func (m *MockV1Backend) Issue(id string) (*V1Issue, error) {
+	for i := range m.IssueList {
+		if m.IssueList[i].ID == id {
+			return &m.IssueList[i], nil
+		}
+	}
+	return nil, fmt.Errorf("issue %s: %w", id, ErrV1NotFound)
+}
+
+func (m *MockV1Backend) Inbox(address string, unreadOnly bool) ([]V1MailMessage, error) {
+	m.LastAddress, m.LastUnread = address, unreadOnly
+	return m.Messages, m.Err
+}
+
+func v1Get(t *testing.T, h http.Handler, target string, out interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s: Content-Type = %q", target, ct)
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s: decoding %q: %v", target, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+func TestV1_Lists(t *testing.T) {
+	backend := &MockV1Backend{
+		RigList:    []V1Rig{{Name: "gastown", Polecats: []string{"nux"}, Crew: []string{}, HasRefinery: true}},
+		PolecatsBy: map[string][]V1Polecat{"gastown": {{Name: "nux", Rig: "gastown", State: "working", Issue: "gt-1"}}},
+		MRsBy:      map[string][]V1MergeRequest{"gastown": {{ID: "gt-mr1", Branch: "polecat/nux", Target: "main", Score: 1100}}},
+		ConvoyList: []V1Convoy{{ID: "hq-cv-1", Title: "Ship it", Status: "open", Completed: 1, Total: 2}},
+	}
+	h := NewV1Handler(backend)
+
+	var rigs struct{ Rigs []V1Rig }
+	if code := v1Get(t, h, "/api/v1/rigs", &rigs); code != http.StatusOK || len(rigs.Rigs) != 1 || !rigs.Rigs[0].HasRefinery {
+		t.Errorf("rigs: %d %+v", code, rigs)
+	}
+
+	var polecats struct{ Polecats []V1Polecat }
+	if code := v1Get(t, h, "/api/v1/rigs/gastown/polecats", &polecats); code != http.StatusOK || polecats.Polecats[0].Issue != "gt-1" {
+		t.Errorf("polecats: %d %+v", code, polecats)
+	}
+
+	var mq struct {
+		MergeRequests []V1MergeRequest `json:"merge_requests"`
+	}
+	if code := v1Get(t, h, "/api/v1/rigs/gastown/merge-queue", &mq); code != http.StatusOK || mq.MergeRequests[0].Score != 1100 {
+		t.Errorf("merge queue: %d %+v", code, mq)
+	}
+
+	var convoys struct{ Convoys []V1Convoy }
+	if code := v1Get(t, h, "/api/v1/convoys?status=all", &convoys); code != http.StatusOK || convoys.Convoys[0].Total != 2 {
+		t.Errorf("convoys: %d %+v", code, convoys)
+	}
+	if backend.LastStatus != "all" {
+		t.Errorf("convoy status = %q, want all", backend.LastStatus)
+	}
+}
+
+func TestV1_EmptyListsAreArrays(t *testing.T) {
+	h := NewV1Handler(&MockV1Backend{})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/issues", nil))
+	if got := strings.TrimSpace(w.Body.String()); got != `{"issues":[]}` {
+		t.Errorf("body = %s", got)
+	}
+}
+
+func TestV1_Issues(t *testing.T) {
+	backend := &MockV1Backend{IssueList: []V1Issue{
+		{ID: "gt-1", Title: "one", Status: "open", Type: "task"},
+		{ID: "gt-2", Title: "two", Status: "open", Type: "bug"},
+	}}
+	h := NewV1Handler(backend)
+
+	var list struct{ Issues []V1Issue }
+	code := v1Get(t, h, "/api/v1/issues?rig=gastown&type=bug&label=gt:x&assignee=gastown/polecats/nux&limit=1", &list)
+	if code != http.StatusOK || len(list.Issues) != 1 {
+		t.Fatalf("issues: %d %+v", code, list)
+	}
+	want := V1IssueFilter{Rig: "gastown", Status: "open", Type: "bug", Label: "gt:x", Assignee: "gastown/polecats/nux", Limit: 1}
+	if backend.LastFilter != want {
+		t.Errorf("filter = %+v, want %+v", backend.LastFilter, want)
+	}
+
+	var issue V1Issue
+	if code := v1Get(t, h, "/api/v1/issues/gt-2", &issue); code != http.StatusOK || issue.Type != "bug" {
+		t.Errorf("issue: %d %+v", code, issue)
+	}
+}
+
+func TestV1_Inbox(t *testing.T) {
+	backend := &MockV1Backend{Messages: []V1MailMessage{{ID: "hq-m1", From: "mayor/", Subject: "hi", Priority: "normal"}}}
+	h := NewV1Handler(backend)
+
+	var inbox struct{ Messages []V1MailMessage }
+	if code := v1Get(t, h, "/api/v1/mail/inbox?address=gastown/witness&unread=true", &inbox); code != http.StatusOK || len(inbox.Messages) != 1 {
+		t.Errorf("inbox: %d %+v", code, inbox)
+	}
+	if backend.LastAddress != "gastown/witness" || !backend.LastUnread {
+		t.Errorf("inbox called with %q unread=%v", backend.LastAddress, backend.LastUnread)
+	}
+}
+
+func TestV1_Errors(t *testing.T) {
+	h := NewV1Handler(&MockV1Backend{})
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/v1/rigs/nope/polecats", http.StatusNotFound},
+		{"/api/v1/rigs/nope/merge-queue", http.StatusNotFound},
+		{"/api/v1/issues/gt-missing", http.StatusNotFound},
+		{"/api/v1/issues/--help", http.StatusBadRequest},
+		{"/api/v1/issues?status=bogus", http.StatusBadRequest},
+		{"/api/v1/issues?limit=-1", http.StatusBadRequest},
+		{"/api/v1/convoys?status=bogus", http.StatusBadRequest},
+		{"/api/v1/mail/inbox", http.StatusBadRequest},
+		{"/api/v1/nothing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var body V1Error
+		if code := v1Get(t, h, tt.target, &body); code != tt.want || body.Error == "" {
+			t.Errorf("%s: %d %+v, want %d with error", tt.target, code, body, tt.want)
+		}
+	}
+
+	failing := NewV1Handler(&MockV1Backend{Err: errors.New("bd exploded")})
+	var body V1Error
+	if code := v1Get(t, failing, "/api/v1/rigs", &body); code != http.StatusInternalServerError || body.Error != "bd exploded" {
+		t.Errorf("backend error: %d %+v", code, body)
+	}
+}
+
+func TestV1_OutsideWorkspace(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&APIHandler{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/rigs", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
+
+// TestV1_OpenAPIDocumentsEveryRoute keeps the published document in step
+// with the routes registered in NewV1Handler.
+func TestV1_OpenAPIDocumentsEveryRoute(t *testing.T) {
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if code := v1Get(t, NewV1Handler(&MockV1Backend{}), "/api/v1/openapi.json", &doc); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	src, err := openAPIRoutesFromSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var documented []string
+	for p := range doc.Paths {
+		documented = append(documented, p)
+	}
+	sort.Strings(documented)
+	if strings.Join(src, "\n") != strings.Join(documented, "\n") {
+		t.Errorf("documented paths:\n%v\nregistered routes:\n%v", documented, src)
+	}
+}
+
+// openAPIRoutesFromSource lists the GET patterns registered in apiv1.go.
+func openAPIRoutesFromSource() ([]string, error) {
+	data, err := os.ReadFile("apiv1.go")
+	if err != nil {
+		return nil, err
+	}
+	re := regexp.MustCompile(`HandleFunc\("GET (/api/v1/[^"]+)"`)
+	var routes []string
+	for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+		routes = append(routes, m[1])
+	}
+	sort.Strings(routes)
+	return routes, nil
+}
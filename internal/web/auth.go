@@ -0,0 +1,261 @@
+package web
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// AuthCookie carries the token for browser sessions, which cannot attach an
+// Authorization header to page loads, htmx polls or EventSource.
+const AuthCookie = "gt_dashboard_token"
+
+// tokenPrefix marks dashboard secrets so they are recognisable in configs
+// and secret scanners.
+const tokenPrefix = "gtd_"
+
+// maxAuditBody bounds how much of a mutating request's body is inspected
+// for the audit log.
+const maxAuditBody = 64 * 1024
+
+// GenerateDashboardToken creates a new token for name with the given role.
+// It returns the secret to hand to the user and the entry to store in
+// town settings.
+func GenerateDashboardToken(name, role string) (string, config.DashboardToken, error) {
+	if role != config.DashboardRoleReadOnly && role != config.DashboardRoleOperator {
+		return "", config.DashboardToken{}, fmt.Errorf("invalid role %q (want %s or %s)",
+			role, config.DashboardRoleReadOnly, config.DashboardRoleOperator)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", config.DashboardToken{}, fmt.Errorf("generating token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+	return secret, config.DashboardToken{
+		Name:      name,
+		Role:      role,
+		SHA256:    HashDashboardToken(secret),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// HashDashboardToken returns the hex SHA-256 stored for a token secret.
+func HashDashboardToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator enforces dashboard bearer tokens and records mutating
+// requests in the audit log.
+type Authenticator struct {
+	tokens []config.DashboardToken
+	// audit records a mutating request; defaults to events.LogAudit.
+	audit func(eventType, actor string, payload map[string]interface{}) error
+}
+
+// NewAuthenticator returns an Authenticator for cfg, or nil when cfg has no
+// tokens (the dashboard stays open, as before tokens existed).
+func NewAuthenticator(cfg *config.DashboardConfig) *Authenticator {
+	if cfg == nil || len(cfg.Tokens) == 0 {
+		return nil
+	}
+	return &Authenticator{tokens: cfg.Tokens, audit: events.LogAudit}
+}
+
+// lookup returns the token matching secret, or nil.
+func (a *Authenticator) lookup(secret string) *config.DashboardToken {
+	if secret == "" {
+		return nil
+	}
+	hash := []byte(HashDashboardToken(secret))
+	var match *config.DashboardToken
+	for i := range a.tokens {
+		// Compare every entry so timing doesn't reveal which one matched.
+		if subtle.ConstantTimeCompare(hash, []byte(a.tokens[i].SHA256)) == 1 {
+			match = &a.tokens[i]
+		}
+	}
+	return match
+}
+
+// pageLoad reports whether r is a browser page load: a GET outside /api/.
+func pageLoad(r *http.Request) bool {
+	return r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// corsPreflight reports whether r is a CORS preflight request.
+func corsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// requestSecret returns the token presented with r and where it came from.
+// ?token= counts only on page loads, which move it into a cookie; API and
+// mutating requests must use the Authorization header or the cookie, so
+// tokens stay out of URLs, browser history and access logs.
+func requestSecret(r *http.Request) (secret, source string) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if s, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(s), "header"
+		}
+	}
+	if s := r.URL.Query().Get("token"); s != "" && pageLoad(r) {
+		return s, "query"
+	}
+	if c, err := r.Cookie(AuthCookie); err == nil {
+		return c.Value, "cookie"
+	}
+	return "", ""
+}
+
+// readOnlyMethod reports whether method cannot change town state.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Wrap returns next guarded by token auth. A nil Authenticator returns next
+// unchanged.
+//
+// Read-only tokens may make GET, HEAD and OPTIONS requests; anything else
+// needs an operator token. CORS preflights are answered without a token and
+// never reach next. A valid ?token= on a page load is moved into a cookie so the
+// browser UI keeps working, and the token is stripped from the URL; it is
+// not accepted anywhere else.
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Static assets carry no town data.
+		if strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Browsers send CORS preflights without credentials, so answer them
+		// here; handlers never see an unauthenticated request.
+		if corsPreflight(r) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		secret, source := requestSecret(r)
+		tok := a.lookup(secret)
+		if tok == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gt dashboard"`)
+			if !readOnlyMethod(r.Method) {
+				a.record(r, nil, http.StatusUnauthorized, "")
+			}
+			authError(w, r, "Unauthorized: a dashboard token is required", http.StatusUnauthorized)
+			return
+		}
+
+		if source == "query" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     AuthCookie,
+				Value:    secret,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			u := *r.URL
+			q := u.Query()
+			q.Del("token")
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if readOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		command := auditCommand(r)
+		if tok.Role != config.DashboardRoleOperator {
+			a.record(r, tok, http.StatusForbidden, command)
+			authError(w, r, "Forbidden: "+tok.Role+" token cannot modify the town", http.StatusForbidden)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		a.record(r, tok, rec.status, command)
+	})
+}
+
+// authError rejects a request. API clients get the JSON error shape the
+// dashboard scripts already understand; pages get plain text.
+func authError(w http.ResponseWriter, r *http.Request, msg string, status int) {
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		http.Error(w, msg, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(CommandResponse{Success: false, Error: msg})
+}
+
+// record writes a mutating request to the audit log.
+func (a *Authenticator) record(r *http.Request, tok *config.DashboardToken, status int, command string) {
+	actor, role := "dashboard/anonymous", ""
+	if tok != nil {
+		actor, role = "dashboard/"+tok.Name, tok.Role
+	}
+	payload := map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"status": status,
+		"remote": r.RemoteAddr,
+	}
+	if role != "" {
+		payload["role"] = role
+	}
+	if command != "" {
+		payload["command"] = command
+	}
+	_ = a.audit(events.TypeDashboardRequest, actor, payload)
+}
+
+// auditCommand returns the "command" field of a JSON request body (set by
+// /api/run), leaving the body intact for the handler. Other fields, such as
+// mail bodies, are not logged.
+func auditCommand(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	var body struct {
+		Command string `json:"command"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+	return body.Command
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,245 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+type auditEntry struct {
+	Type    string
+	Actor   string
+	Payload map[string]interface{}
+}
+
+// newTestAuth returns an authenticator with a read-only and an operator
+// token, their secrets, and the audit entries it records.
+func newTestAuth(t *testing.T) (*Authenticator, string, string, *[]auditEntry) {
+	t.Helper()
+	viewer, viewerTok, err := GenerateDashboardToken("viewer", config.DashboardRoleReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, opTok, err := GenerateDashboardToken("alice", config.DashboardRoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(&config.DashboardConfig{Tokens: []config.DashboardToken{viewerTok, opTok}})
+	var log []auditEntry
+	a.audit = func(eventType, actor string, payload map[string]interface{}) error {
+		log = append(log, auditEntry{eventType, actor, payload})
+		return nil
+	}
+	return a, viewer, op, &log
+}
+
+// echoHandler reports the request body so tests can check it survives auditing.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.URL.Path == "/api/fail" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	_, _ = w.Write(body)
+})
+
+func authRequest(method, target, token, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestNewAuthenticator_NoTokens(t *testing.T) {
+	if a := NewAuthenticator(nil); a != nil {
+		t.Error("nil config should disable auth")
+	}
+	if a := NewAuthenticator(&config.DashboardConfig{}); a != nil {
+		t.Error("empty token list should disable auth")
+	}
+	var a *Authenticator
+	if h := a.Wrap(echoHandler); h == nil {
+		t.Error("nil authenticator should pass the handler through")
+	}
+}
+
+func TestGenerateDashboardToken(t *testing.T) {
+	secret, tok, err := GenerateDashboardToken("ci", config.DashboardRoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) || strings.Contains(tok.SHA256, secret) {
+		t.Errorf("secret %q, entry %+v", secret, tok)
+	}
+	if tok.SHA256 != HashDashboardToken(secret) || tok.Name != "ci" {
+		t.Errorf("entry = %+v", tok)
+	}
+	if _, _, err := GenerateDashboardToken("x", "admin"); err == nil {
+		t.Error("expected error for unknown role")
+	}
+}
+
+func TestAuthenticator_Roles(t *testing.T) {
+	a, viewer, op, log := newTestAuth(t)
+	h := a.Wrap(echoHandler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"no token", http.MethodGet, "/api/v1/rigs", "", http.StatusUnauthorized},
+		{"bad token", http.MethodGet, "/api/v1/rigs", "gtd_nope", http.StatusUnauthorized},
+		{"viewer get", http.MethodGet, "/api/v1/rigs", viewer, http.StatusOK},
+		{"viewer run", http.MethodPost, "/api/run", viewer, http.StatusForbidden},
+		{"viewer mail", http.MethodPost, "/api/mail/send", viewer, http.StatusForbidden},
+		{"operator run", http.MethodPost, "/api/run", op, http.StatusOK},
+		{"operator create", http.MethodPost, "/api/issues/create", op, http.StatusOK},
+		{"static open", http.MethodGet, "/static/dashboard.js", "", http.StatusOK},
+		{"options needs token", http.MethodOptions, "/api/run", "", http.StatusUnauthorized},
+		{"viewer options", http.MethodOptions, "/api/run", viewer, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, authRequest(tt.method, tt.path, tt.token, ""))
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	// Every mutating request is audited; reads are not.
+	if len(*log) != 4 {
+		t.Fatalf("audit entries = %d, want 4: %+v", len(*log), *log)
+	}
+	for _, e := range *log {
+		if e.Type != events.TypeDashboardRequest {
+			t.Errorf("event type = %q", e.Type)
+		}
+	}
+	if got := (*log)[0]; got.Actor != "dashboard/viewer" || got.Payload["status"] != http.StatusForbidden {
+		t.Errorf("viewer entry = %+v", got)
+	}
+	if got := (*log)[2]; got.Actor != "dashboard/alice" || got.Payload["role"] != config.DashboardRoleOperator {
+		t.Errorf("operator entry = %+v", got)
+	}
+}
+
+func TestAuthenticator_OptionsNeverReachesHandlerWithoutToken(t *testing.T) {
+	a, _, _, _ := newTestAuth(t)
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>town dashboard</html>"))
+	})
+	h := a.Wrap(page)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, authRequest(http.MethodOptions, "/", "", ""))
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "town dashboard") {
+		t.Errorf("OPTIONS / without token: status = %d, body %q", w.Code, w.Body.String())
+	}
+
+	r := authRequest(http.MethodOptions, "/api/run", "", "")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("preflight: status = %d, body %q; want empty 204", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+		t.Errorf("preflight Access-Control-Allow-Headers = %q", got)
+	}
+}
+
+func TestAuthenticator_AuditsCommandAndStatus(t *testing.T) {
+	a, _, op, log := newTestAuth(t)
+	h := a.Wrap(echoHandler)
+
+	body := `{"command":"mail send mayor/ -s hi"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, authRequest(http.MethodPost, "/api/run", op, body))
+	if w.Body.String() != body {
+		t.Errorf("handler saw body %q, want it intact", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, authRequest(http.MethodPost, "/api/fail", op, `{"subject":"x","body":"secret"}`))
+
+	if len(*log) != 2 {
+		t.Fatalf("audit entries = %d", len(*log))
+	}
+	if got := (*log)[0].Payload; got["command"] != "mail send mayor/ -s hi" || got["path"] != "/api/run" || got["status"] != http.StatusOK {
+		t.Errorf("run entry = %+v", got)
+	}
+	if got := (*log)[1].Payload; got["status"] != http.StatusBadRequest || got["command"] != nil {
+		t.Errorf("fail entry = %+v", got)
+	}
+}
+
+func TestAuthenticator_AnonymousWriteIsAudited(t *testing.T) {
+	a, _, _, log := newTestAuth(t)
+	w := httptest.NewRecorder()
+	a.Wrap(echoHandler).ServeHTTP(w, authRequest(http.MethodPost, "/api/run", "", `{"command":"status"}`))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("status = %d, headers %v", w.Code, w.Header())
+	}
+	var resp CommandResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Success || resp.Error == "" {
+		t.Errorf("body = %s, want JSON error", w.Body.String())
+	}
+	if len(*log) != 1 || (*log)[0].Actor != "dashboard/anonymous" {
+		t.Errorf("audit = %+v", *log)
+	}
+}
+
+func TestAuthenticator_QueryTokenSetsCookie(t *testing.T) {
+	a, viewer, _, _ := newTestAuth(t)
+	h := a.Wrap(echoHandler)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?token="+viewer+"&rig=gastown", nil))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want redirect", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/?rig=gastown" {
+		t.Errorf("Location = %q", loc)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != AuthCookie || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+
+	// The cookie authenticates later requests, including the event stream.
+	r := httptest.NewRequest(http.MethodGet, "/api/events/stream", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("cookie request status = %d", w.Code)
+	}
+}
+
+func TestAuthenticator_QueryTokenOnlyOnPageLoads(t *testing.T) {
+	a, _, op, log := newTestAuth(t)
+	h := a.Wrap(echoHandler)
+
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodGet, "/api/v1/rigs?token=" + op},
+		{http.MethodPost, "/api/run?token=" + op},
+		{http.MethodPost, "/?token=" + op},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"command":"status"}`)))
+		if w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 0 {
+			t.Errorf("%s %s: status = %d, cookies %v; want 401", tt.method, tt.target, w.Code, w.Result().Cookies())
+		}
+	}
+	if len(*log) != 2 || (*log)[0].Actor != "dashboard/anonymous" {
+		t.Errorf("audit = %+v, want the two writes as anonymous", *log)
+	}
+}
@@ -0,0 +1,299 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+var (
+	// eventStreamPollInterval is how often the events log is checked for new lines.
+	eventStreamPollInterval = 250 * time.Millisecond
+	// eventStreamHeartbeat is how often a comment is sent to keep idle
+	// connections (and proxies) from timing out.
+	eventStreamHeartbeat = 15 * time.Second
+)
+
+// eventStreamRetryMs is the reconnect delay suggested to EventSource clients.
+const eventStreamRetryMs = 3000
+
+// StreamEvent is one event pushed by /api/events/stream.
+//
+// ID is the byte offset just past the event's line in .events.jsonl. Clients
+// resume by sending it back as Last-Event-ID (EventSource does this
+// automatically) or as the last_event_id query parameter.
+type StreamEvent struct {
+	ID         string                 `json:"id"`
+	Time       time.Time              `json:"time"`
+	Type       string                 `json:"type"`
+	Actor      string                 `json:"actor"`
+	Rig        string                 `json:"rig,omitempty"`
+	Source     string                 `json:"source,omitempty"`
+	Visibility string                 `json:"visibility,omitempty"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+}
+
+// EventFilter selects which events a stream delivers. Empty sets match all.
+type EventFilter struct {
+	Rigs   map[string]bool
+	Actors []string // exact names, or prefixes ending in "*"
+	Types  map[string]bool
+}
+
+// parseEventFilter reads rig, actor and type filters from query parameters.
+// Each may be repeated or comma-separated: ?type=sling,done&rig=gastown
+func parseEventFilter(q url.Values) EventFilter {
+	set := func(key string) map[string]bool {
+		vals := splitQueryList(q[key])
+		if len(vals) == 0 {
+			return nil
+		}
+		m := make(map[string]bool, len(vals))
+		for _, v := range vals {
+			m[v] = true
+		}
+		return m
+	}
+	return EventFilter{
+		Rigs:   set("rig"),
+		Actors: splitQueryList(q["actor"]),
+		Types:  set("type"),
+	}
+}
+
+func splitQueryList(vals []string) []string {
+	var out []string
+	for _, v := range vals {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// Match reports whether ev passes the filter.
+func (f EventFilter) Match(ev *StreamEvent) bool {
+	if f.Types != nil && !f.Types[ev.Type] {
+		return false
+	}
+	if f.Rigs != nil && !f.Rigs[ev.Rig] {
+		return false
+	}
+	if len(f.Actors) > 0 {
+		for _, a := range f.Actors {
+			if prefix, ok := strings.CutSuffix(a, "*"); ok {
+				if strings.HasPrefix(ev.Actor, prefix) {
+					return true
+				}
+			} else if ev.Actor == a {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseStreamEvent converts one .events.jsonl line into a StreamEvent.
+// Returns nil for blank or malformed lines.
+func parseStreamEvent(line []byte, offset int64) *StreamEvent {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	var raw events.Event
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil
+	}
+	t, _ := time.Parse(time.RFC3339, raw.Timestamp)
+	return &StreamEvent{
+		ID:         strconv.FormatInt(offset, 10),
+		Time:       t,
+		Type:       raw.Type,
+		Actor:      raw.Actor,
+		Rig:        eventRig(raw.Actor, raw.Payload),
+		Source:     raw.Source,
+		Visibility: raw.Visibility,
+		Payload:    raw.Payload,
+	}
+}
+
+// eventRig returns the rig an event belongs to: the payload's rig field, or
+// the first segment of a rig-scoped actor such as "gastown/witness".
+func eventRig(actor string, payload map[string]interface{}) string {
+	if r, ok := payload["rig"].(string); ok && r != "" {
+		return r
+	}
+	first, _, scoped := strings.Cut(actor, "/")
+	if !scoped || first == "mayor" || first == "deacon" {
+		return ""
+	}
+	return first
+}
+
+// eventTailer reads complete lines appended to the events log since offset.
+type eventTailer struct {
+	path    string
+	offset  int64 // position after the last complete line consumed
+	partial []byte
+}
+
+// poll returns events for lines appended since the last call. A log that
+// shrank (rotated or truncated) is re-read from the start.
+func (t *eventTailer) poll() ([]*StreamEvent, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.offset, t.partial = 0, nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	readFrom := t.offset + int64(len(t.partial))
+	if info.Size() < readFrom {
+		t.offset, t.partial, readFrom = 0, nil, 0
+	}
+	if info.Size() == readFrom {
+		return nil, nil
+	}
+
+	if _, err := f.Seek(readFrom, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, info.Size()-readFrom))
+	if err != nil {
+		return nil, err
+	}
+	data = append(t.partial, data...)
+
+	var out []*StreamEvent
+	for {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			break
+		}
+		line := data[:nl]
+		data = data[nl+1:]
+		t.offset += int64(nl + 1)
+		if ev := parseStreamEvent(line, t.offset); ev != nil {
+			out = append(out, ev)
+		}
+	}
+	t.partial = append([]byte(nil), data...)
+	return out, nil
+}
+
+// resumeOffset returns the offset to start streaming from: Last-Event-ID,
+// then ?last_event_id, then the current end of the log (live events only).
+func resumeOffset(r *http.Request, path string) (int64, error) {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		id = r.URL.Query().Get("last_event_id")
+	}
+	if id != "" {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid last event id %q", id)
+		}
+		return n, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, nil
+	}
+	return info.Size(), nil
+}
+
+// handleEventStream streams .events.jsonl as server-sent events.
+//
+// Each event is sent as an unnamed (message) event:
+//
+//	id: <offset>
+//	data: <StreamEvent JSON>
+//
+// Query parameters rig, actor and type filter the stream.
+func (h *APIHandler) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if h.eventsPath == "" {
+		h.sendError(w, "Not in a Gas Town workspace", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.sendError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	offset, err := resumeOffset(r, h.eventsPath)
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := parseEventFilter(r.URL.Query())
+
+	// The dashboard server has a write timeout; streams outlive it.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetryMs)
+	flusher.Flush()
+
+	tailer := &eventTailer{path: h.eventsPath, offset: offset}
+	poll := time.NewTicker(eventStreamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		evs, err := tailer.poll()
+		if err != nil {
+			_, _ = fmt.Fprintf(w, ": error reading events: %s\n\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		}
+		sent := false
+		for _, ev := range evs {
+			if !filter.Match(ev) {
+				continue
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", ev.ID, data); err != nil {
+				return
+			}
+			sent = true
+		}
+		if sent || err != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-poll.C:
+		}
+	}
+}
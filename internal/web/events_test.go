@@ -0,0 +1,234 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func appendEvents(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, l := range lines {
+		if _, err := f.WriteString(l + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const (
+	evSling   = `{"ts":"2026-01-02T15:04:05Z","source":"gt","type":"sling","actor":"mayor","payload":{"bead":"gt-1","target":"gastown/polecats/nux","rig":"gastown"},"visibility":"feed"}`
+	evPatrol  = `{"ts":"2026-01-02T15:04:06Z","source":"gt","type":"patrol_started","actor":"beads/witness","payload":{"polecat_count":2},"visibility":"audit"}`
+	evDone    = `{"ts":"2026-01-02T15:04:07Z","source":"gt","type":"done","actor":"gastown/polecats/nux","payload":{"bead":"gt-1"},"visibility":"feed"}`
+	evBadLine = `not json`
+)
+
+// sseEvent is a parsed server-sent event.
+type sseEvent struct {
+	ID   string
+	Data StreamEvent
+}
+
+// openStream connects to the event stream and returns a channel of events.
+func openStream(t *testing.T, srv *httptest.Server, query string, header http.Header) <-chan sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events/stream"+query, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	out := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(out)
+		scanner := bufio.NewScanner(resp.Body)
+		var ev sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ev.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.Data)
+			case line == "" && ev.ID != "":
+				out <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return out
+}
+
+func nextEvent(t *testing.T, ch <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return ev
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return sseEvent{}
+}
+
+func newStreamServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	old := eventStreamPollInterval
+	eventStreamPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { eventStreamPollInterval = old })
+
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	srv := httptest.NewServer(&APIHandler{eventsPath: path})
+	t.Cleanup(srv.Close)
+	return srv, path
+}
+
+func TestEventStream_LiveAndResume(t *testing.T) {
+	srv, path := newStreamServer(t)
+	appendEvents(t, path, evSling) // before connect: not replayed by default
+
+	stream := openStream(t, srv, "", nil)
+	// Give the handler a moment to record the starting offset.
+	time.Sleep(50 * time.Millisecond)
+	appendEvents(t, path, evBadLine, evDone)
+
+	got := nextEvent(t, stream)
+	if got.Data.Type != "done" || got.Data.Actor != "gastown/polecats/nux" || got.Data.Rig != "gastown" {
+		t.Errorf("event = %+v", got.Data)
+	}
+	if got.ID != got.Data.ID {
+		t.Errorf("SSE id %q != payload id %q", got.ID, got.Data.ID)
+	}
+	info, _ := os.Stat(path)
+	if got.ID != itoa(info.Size()) {
+		t.Errorf("id = %s, want end offset %d", got.ID, info.Size())
+	}
+
+	// Resuming from the first event's id replays everything after it.
+	first := itoa(int64(len(evSling) + 1))
+	replay := openStream(t, srv, "", http.Header{"Last-Event-Id": {first}})
+	if ev := nextEvent(t, replay); ev.Data.Type != "done" {
+		t.Errorf("resumed event = %+v, want done", ev.Data)
+	}
+
+	// The query parameter works too, from the start of the log.
+	fromStart := openStream(t, srv, "?last_event_id=0", nil)
+	if ev := nextEvent(t, fromStart); ev.Data.Type != "sling" || ev.Data.Payload["bead"] != "gt-1" {
+		t.Errorf("first event = %+v, want sling", ev.Data)
+	}
+}
+
+func TestEventStream_Filters(t *testing.T) {
+	srv, path := newStreamServer(t)
+	appendEvents(t, path, evSling, evPatrol, evDone)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?type=done,patrol_started", []string{"patrol_started", "done"}},
+		{"?rig=beads", []string{"patrol_started"}},
+		{"?actor=gastown/*", []string{"done"}},
+		{"?actor=mayor&actor=beads/witness", []string{"sling", "patrol_started"}},
+		{"?rig=gastown&type=sling", []string{"sling"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(strings.TrimPrefix(tt.query, "?"))
+			q.Set("last_event_id", "0")
+			stream := openStream(t, srv, "?"+q.Encode(), nil)
+			for _, want := range tt.want {
+				if ev := nextEvent(t, stream); ev.Data.Type != want {
+					t.Errorf("got %q, want %q", ev.Data.Type, want)
+				}
+			}
+			select {
+			case ev := <-stream:
+				t.Errorf("unexpected extra event %q", ev.Data.Type)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestEventStream_Errors(t *testing.T) {
+	srv, _ := newStreamServer(t)
+	resp, err := http.Get(srv.URL + "/api/events/stream?last_event_id=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad id status = %d, want 400", resp.StatusCode)
+	}
+
+	w := httptest.NewRecorder()
+	(&APIHandler{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/events/stream", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no workspace status = %d, want 503", w.Code)
+	}
+}
+
+func TestEventTailer_Truncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	appendEvents(t, path, evSling, evDone)
+	tailer := &eventTailer{path: path}
+	if evs, _ := tailer.poll(); len(evs) != 2 {
+		t.Fatalf("got %d events, want 2", len(evs))
+	}
+
+	// A partial line is held until its newline arrives.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString(evPatrol[:20])
+	f.Close()
+	if evs, _ := tailer.poll(); len(evs) != 0 {
+		t.Fatalf("partial line produced %d events", len(evs))
+	}
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString(evPatrol[20:] + "\n")
+	f.Close()
+	if evs, _ := tailer.poll(); len(evs) != 1 || evs[0].Type != "patrol_started" {
+		t.Fatalf("completed line: %v", evs)
+	}
+
+	// After rotation the log is read from the start.
+	if err := os.WriteFile(path, []byte(evDone+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	evs, _ := tailer.poll()
+	if len(evs) != 1 || evs[0].Type != "done" || evs[0].ID != itoa(int64(len(evDone)+1)) {
+		t.Fatalf("after rotation: %+v", evs)
+	}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
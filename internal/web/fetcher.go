@@ -1661,6 +1661,7 @@ func eventIcon(eventType string) string {
 		"session_end":       "⏹️",
 		"session_death":     "☠️",
 		"mass_death":        "💥",
+		"session_restart":   "🔁",
 		"patrol_started":    "🔍",
 		"patrol_complete":   "✔️",
 		"escalation_sent":   "⚠️",
@@ -1724,6 +1725,9 @@ func eventSummary(eventType, actor string, payload map[string]interface{}) strin
 	case "mass_death":
 		count, _ := payload["count"].(float64)
 		return fmt.Sprintf("%.0f sessions died", count)
+	case "session_restart":
+		session, _ := payload["session"].(string)
+		return fmt.Sprintf("%s restarted", session)
 	default:
 		return eventType
 	}
@@ -336,5 +336,13 @@ func NewDashboardMux(fetcher ConvoyFetcher) (http.Handler, error) {
 	mux.Handle("/static/", http.StripPrefix("/static/", staticHandler))
 	mux.Handle("/", convoyHandler)
 
+	if mf, ok := fetcher.(MoleculeFetcher); ok {
+		moleculeHandler, err := NewMoleculeHandler(mf)
+		if err != nil {
+			return nil, err
+		}
+		mux.Handle("GET /molecule/{id}", moleculeHandler)
+	}
+
 	return mux, nil
 }
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/graph"
+)
+
+// MoleculeFetcher is implemented by fetchers that can load molecule graphs.
+// The molecule detail page is only served when the dashboard's fetcher
+// implements it.
+type MoleculeFetcher interface {
+	FetchMoleculeGraph(id string) (*graph.Graph, error)
+}
+
+// MoleculeData is passed to the molecule detail template.
+type MoleculeData struct {
+	ID     string
+	Graph  *graph.Graph
+	SVG    template.HTML // rendered by graph.SVG, which escapes all labels
+	Counts map[string]int
+	Error  string
+}
+
+// MoleculeHandler serves GET /molecule/{id}: a molecule's step graph,
+// coloured by status. ?format=dot|mermaid|json returns the graph export
+// that gt mol dag --format produces instead.
+type MoleculeHandler struct {
+	fetcher  MoleculeFetcher
+	template *template.Template
+}
+
+// NewMoleculeHandler creates a molecule detail handler.
+func NewMoleculeHandler(fetcher MoleculeFetcher) (*MoleculeHandler, error) {
+	tmpl, err := LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	return &MoleculeHandler{fetcher: fetcher, template: tmpl}, nil
+}
+
+// ServeHTTP renders the molecule page or a graph export.
+func (h *MoleculeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !v1IssueIDPattern.MatchString(id) {
+		http.Error(w, "invalid molecule id", http.StatusBadRequest)
+		return
+	}
+
+	g, err := h.fetcher.FetchMoleculeGraph(id)
+
+	if format := r.URL.Query().Get("format"); format != "" {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		out, err := g.Render(format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contentType := "text/plain; charset=utf-8"
+		if format == "json" {
+			contentType = "application/json"
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(out))
+		return
+	}
+
+	data := MoleculeData{ID: id}
+	if err != nil {
+		log.Printf("dashboard: FetchMoleculeGraph(%s) failed: %v", id, err)
+		data.Error = err.Error()
+	} else {
+		data.Graph = g
+		data.SVG = template.HTML(g.SVG()) //nolint:gosec // G203: SVG escapes every label
+		data.Counts = make(map[string]int)
+		for _, n := range g.Nodes {
+			data.Counts[n.Status]++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.template.ExecuteTemplate(w, "molecule.html", data); err != nil {
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+// FetchMoleculeGraph loads the graph of a molecule. For a hooked bead with
+// a molecule attached, the attached molecule's graph is returned.
+func (f *LiveConvoyFetcher) FetchMoleculeGraph(id string) (*graph.Graph, error) {
+	b := beads.New(beads.ResolveHookDir(f.townRoot, id, ""))
+	if issue, err := b.Show(id); err == nil {
+		if fields := beads.ParseAttachmentFields(issue); fields != nil && fields.AttachedMolecule != "" {
+			id = fields.AttachedMolecule
+			b = beads.New(beads.ResolveHookDir(f.townRoot, id, ""))
+		}
+	}
+	return graph.Molecule(b, id)
+}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/graph"
+)
+
+// mockMoleculeFetcher adds molecule graphs to MockConvoyFetcher.
+type mockMoleculeFetcher struct {
+	MockConvoyFetcher
+	Graphs map[string]*graph.Graph
+}
+
+func (m *mockMoleculeFetcher) FetchMoleculeGraph(id string) (*graph.Graph, error) {
+	if g, ok := m.Graphs[id]; ok {
+		return g, nil
+	}
+	return nil, errors.New("no steps found for " + id)
+}
+
+func newMoleculeMux(t *testing.T) http.Handler {
+	t.Helper()
+	fetcher := &mockMoleculeFetcher{Graphs: map[string]*graph.Graph{
+		"gt-mol-1": {
+			Name:  "gt-mol-1",
+			Title: "Ship <feature>",
+			Nodes: []graph.Node{
+				{ID: "gt-mol-1.1", Label: "gt-mol-1.1: Design", Kind: graph.KindStep, Status: graph.StatusDone},
+				{ID: "gt-mol-1.2", Label: "gt-mol-1.2: Implement", Kind: graph.KindStep, Status: graph.StatusReady},
+			},
+			Edges: []graph.Edge{{From: "gt-mol-1.1", To: "gt-mol-1.2"}},
+		},
+	}}
+	mux, err := NewDashboardMux(fetcher)
+	if err != nil {
+		t.Fatalf("NewDashboardMux() error = %v", err)
+	}
+	return mux
+}
+
+func TestMoleculeHandler_RendersGraph(t *testing.T) {
+	mux := newMoleculeMux(t)
+	req := httptest.NewRequest("GET", "/molecule/gt-mol-1", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<svg", "gt-mol-1.2: Implement", "Ship &lt;feature&gt;", "done 1", "ready 1", "?format=mermaid"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Response should contain %q", want)
+		}
+	}
+}
+
+func TestMoleculeHandler_Export(t *testing.T) {
+	mux := newMoleculeMux(t)
+	tests := []struct {
+		format      string
+		contentType string
+		want        string
+	}{
+		{"dot", "text/plain; charset=utf-8", `"gt-mol-1.1" -> "gt-mol-1.2";`},
+		{"mermaid", "text/plain; charset=utf-8", "n0 --> n1"},
+		{"json", "application/json", `"status": "done"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/molecule/gt-mol-1?format="+tt.format, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("Body should contain %q, got:\n%s", tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMoleculeHandler_Errors(t *testing.T) {
+	mux := newMoleculeMux(t)
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/molecule/gt-missing", http.StatusOK, "no steps found for gt-missing"},
+		{"/molecule/gt-missing?format=dot", http.StatusNotFound, "no steps found"},
+		{"/molecule/gt-mol-1?format=png", http.StatusBadRequest, "unknown format"},
+		{"/molecule/not%20an%20id", http.StatusBadRequest, "invalid molecule id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("Status = %d, want %d", w.Code, tt.code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("Body should contain %q, got:\n%s", tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDashboardMux_NoMoleculeRouteWithoutFetcher(t *testing.T) {
+	mux, err := NewDashboardMux(&MockConvoyFetcher{})
+	if err != nil {
+		t.Fatalf("NewDashboardMux() error = %v", err)
+	}
+	req := httptest.NewRequest("GET", "/molecule/gt-mol-1?format=dot", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if strings.Contains(w.Body.String(), "digraph") {
+		t.Error("molecule export served without a MoleculeFetcher")
+	}
+}
@@ -51,6 +51,25 @@
         if (window.refreshReadyPanel) window.refreshReadyPanel();
     });
 
+    // ============================================
+    // LIVE EVENTS (SSE)
+    // ============================================
+    // Refresh as soon as something happens instead of waiting for the next
+    // poll. The 10s poll stays as a fallback if the stream is unavailable.
+    if (window.EventSource) {
+        var liveRefreshTimer = null;
+        var eventStream = new EventSource('/api/events/stream');
+        eventStream.onmessage = function() {
+            // Coalesce bursts (e.g. a convoy dispatch) into one refresh
+            if (liveRefreshTimer) return;
+            liveRefreshTimer = setTimeout(function() {
+                liveRefreshTimer = null;
+                var main = document.getElementById('dashboard-main');
+                if (main && window.htmx) htmx.trigger(main, 'gt-event');
+            }, 500);
+        };
+    }
+
     // ============================================
     // COMMAND PALETTE
     // ============================================
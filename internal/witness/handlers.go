@@ -15,6 +15,7 @@ import (
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/tracing"
 	"github.com/steveyegge/gastown/internal/util"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -30,6 +31,22 @@ type HandlerResult struct {
 	Error        error
 }
 
+// traceHandler records a handler's work as a span in the issue's lifecycle
+// trace. The returned func ends the span with the handler's outcome; it is
+// meant to be deferred once the message has been parsed.
+func traceHandler(workDir, traceParent, name, polecatName string, result *HandlerResult) func() {
+	if traceParent == "" {
+		return func() {}
+	}
+	span := tracing.ForDir(workDir).Start(traceParent, name)
+	span.SetAttr("gt.polecat", polecatName)
+	return func() {
+		span.SetAttr("gt.action", result.Action)
+		span.SetError(result.Error)
+		span.End()
+	}
+}
+
 // HandlePolecatDone processes a POLECAT_DONE message from a polecat.
 // For ESCALATED/DEFERRED exits (no pending MR), auto-nukes if clean.
 // For PHASE_COMPLETE exits, recycles the polecat (session ends, worktree kept).
@@ -53,6 +70,7 @@ func HandlePolecatDone(workDir, rigName string, msg *mail.Message) *HandlerResul
 		result.Error = fmt.Errorf("parsing POLECAT_DONE: %w", err)
 		return result
 	}
+	defer traceHandler(workDir, payload.TraceParent, "witness.polecat_done", payload.PolecatName, result)()
 
 	if stale, reason := isStalePolecatDone(rigName, payload.PolecatName, msg); stale {
 		result.Handled = true
@@ -238,6 +256,7 @@ func HandleMerged(workDir, rigName string, msg *mail.Message) *HandlerResult {
 		result.Error = fmt.Errorf("parsing MERGED: %w", err)
 		return result
 	}
+	defer traceHandler(workDir, payload.TraceParent, "witness.merged", payload.PolecatName, result)()
 
 	// Find the cleanup wisp for this polecat
 	wispID, err := findCleanupWisp(workDir, payload.PolecatName)
@@ -353,9 +372,14 @@ func HandleMergeFailed(workDir, rigName string, msg *mail.Message, router *mail.
 		result.Error = fmt.Errorf("parsing MERGE_FAILED: %w", err)
 		return result
 	}
+	defer traceHandler(workDir, payload.TraceParent, "witness.merge_failed", payload.PolecatName, result)()
 
 	// Notify the polecat about the failure
 	polecatAddr := fmt.Sprintf("%s/polecats/%s", rigName, payload.PolecatName)
+	checks := ""
+	if payload.Checks != "" {
+		checks = "\nChecks:\n" + payload.Checks + "\n"
+	}
 	notification := &mail.Message{
 		From:     fmt.Sprintf("%s/witness", rigName),
 		To:       polecatAddr,
@@ -368,12 +392,13 @@ Branch: %s
 Issue: %s
 Failure: %s
 Error: %s
-
+%s
 Please fix the issue and resubmit with 'gt done'.`,
 			payload.Branch,
 			payload.IssueID,
 			payload.FailureType,
 			payload.Error,
+			checks,
 		),
 	}
 
@@ -3,13 +3,13 @@ package witness
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/runtime"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/rig"
@@ -40,7 +40,7 @@ func NewManager(r *rig.Rig) *Manager {
 // IsRunning checks if the witness session is active.
 // ZFC: tmux session existence is the source of truth.
 func (m *Manager) IsRunning() (bool, error) {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	return t.HasSession(m.SessionName())
 }
 
@@ -52,7 +52,7 @@ func (m *Manager) SessionName() string {
 // Status returns information about the witness session.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Status() (*tmux.SessionInfo, error) {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	running, err := t.HasSession(sessionID)
@@ -69,13 +69,14 @@ func (m *Manager) Status() (*tmux.SessionInfo, error) {
 // witnessDir returns the working directory for the witness.
 // Prefers witness/rig/, falls back to witness/, then rig root.
 func (m *Manager) witnessDir() string {
+	conn := m.rig.Connection()
 	witnessRigDir := filepath.Join(m.rig.Path, "witness", "rig")
-	if _, err := os.Stat(witnessRigDir); err == nil {
+	if _, err := conn.Stat(witnessRigDir); err == nil {
 		return witnessRigDir
 	}
 
 	witnessDir := filepath.Join(m.rig.Path, "witness")
-	if _, err := os.Stat(witnessDir); err == nil {
+	if _, err := conn.Stat(witnessDir); err == nil {
 		return witnessDir
 	}
 
@@ -89,7 +90,7 @@ func (m *Manager) witnessDir() string {
 // envOverrides are KEY=VALUE pairs that override all other env var sources.
 // ZFC-compliant: no state file, tmux session is source of truth.
 func (m *Manager) Start(foreground bool, agentOverride string, envOverrides []string) error {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	if foreground {
@@ -121,8 +122,11 @@ func (m *Manager) Start(foreground bool, agentOverride string, envOverrides []st
 	witnessParentDir := filepath.Join(m.rig.Path, "witness")
 	townRoot := m.townRoot()
 	runtimeConfig := config.ResolveRoleAgentConfig("witness", townRoot, m.rig.Path)
-	if err := runtime.EnsureSettingsForRole(witnessParentDir, "witness", runtimeConfig); err != nil {
-		return fmt.Errorf("ensuring runtime settings: %w", err)
+	// Remote rigs are provisioned by the town on their own machine.
+	if !m.rig.IsRemote() {
+		if err := runtime.EnsureSettingsForRole(witnessParentDir, "witness", runtimeConfig); err != nil {
+			return fmt.Errorf("ensuring runtime settings: %w", err)
+		}
 	}
 
 	roleConfig, err := m.roleConfig()
@@ -186,6 +190,11 @@ func (m *Manager) Start(foreground bool, agentOverride string, envOverrides []st
 }
 
 func (m *Manager) roleConfig() (*beads.RoleConfig, error) {
+	if m.rig.IsRemote() {
+		// Town-level beads aren't reachable at the remote town path from
+		// here; remote witnesses use the default startup command.
+		return nil, nil
+	}
 	// Role beads use hq- prefix and live in town-level beads, not rig beads
 	townRoot := m.townRoot()
 	bd := beads.NewWithBeadsDir(townRoot, beads.ResolveBeadsDir(townRoot))
@@ -197,6 +206,10 @@ func (m *Manager) roleConfig() (*beads.RoleConfig, error) {
 }
 
 func (m *Manager) townRoot() string {
+	if m.rig.IsRemote() {
+		// Remote rigs sit directly under the machine's town_path.
+		return filepath.Dir(m.rig.Path)
+	}
 	townRoot, err := workspace.Find(m.rig.Path)
 	if err != nil || townRoot == "" {
 		return m.rig.Path
@@ -237,7 +250,7 @@ func buildWitnessStartCommand(rigPath, rigName, townRoot, agentOverride string,
 // Stop stops the witness.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Stop() error {
-	t := tmux.NewTmux()
+	t := connection.TmuxFor(m.rig.Connection())
 	sessionID := m.SessionName()
 
 	// Check if tmux session exists
@@ -54,6 +54,7 @@ type PolecatDonePayload struct {
 	MRID        string
 	Branch      string
 	Gate        string // Gate ID when Exit is PHASE_COMPLETE
+	TraceParent string // Lifecycle trace of the issue (empty if untraced)
 }
 
 // HelpPayload contains parsed data from a HELP message.
@@ -72,6 +73,7 @@ type MergedPayload struct {
 	Branch      string
 	IssueID     string
 	MergedAt    time.Time
+	TraceParent string // Lifecycle trace of the issue (empty if untraced)
 }
 
 // MergeFailedPayload contains parsed data from a MERGE_FAILED message.
@@ -82,6 +84,8 @@ type MergeFailedPayload struct {
 	FailureType string // "build", "test", "lint", etc.
 	Error       string
 	FailedAt    time.Time
+	TraceParent string // Lifecycle trace of the issue (empty if untraced)
+	Checks      string // Per-stage check results, one "- name: status" per stage
 }
 
 // SwarmStartPayload contains parsed data from a SWARM_START message.
@@ -123,6 +127,7 @@ func ClassifyMessage(subject string) ProtocolType {
 //	MR: <mr-id>
 //	Gate: <gate-id>
 //	Branch: <branch>
+//	Traceparent: <w3c-traceparent>
 func ParsePolecatDone(subject, body string) (*PolecatDonePayload, error) {
 	matches := PatternPolecatDone.FindStringSubmatch(subject)
 	if len(matches) < 2 {
@@ -146,6 +151,8 @@ func ParsePolecatDone(subject, body string) (*PolecatDonePayload, error) {
 			payload.Gate = strings.TrimSpace(strings.TrimPrefix(line, "Gate:"))
 		} else if strings.HasPrefix(line, "Branch:") {
 			payload.Branch = strings.TrimSpace(strings.TrimPrefix(line, "Branch:"))
+		} else if strings.HasPrefix(line, "Traceparent:") {
+			payload.TraceParent = strings.TrimSpace(strings.TrimPrefix(line, "Traceparent:"))
 		}
 	}
 
@@ -195,6 +202,7 @@ func ParseHelp(subject, body string) (*HelpPayload, error) {
 //	Branch: <branch>
 //	Issue: <issue-id>
 //	Merged-At: <timestamp>
+//	Traceparent: <w3c-traceparent>
 func ParseMerged(subject, body string) (*MergedPayload, error) {
 	matches := PatternMerged.FindStringSubmatch(subject)
 	if len(matches) < 2 {
@@ -217,6 +225,8 @@ func ParseMerged(subject, body string) (*MergedPayload, error) {
 			if t, err := time.Parse(time.RFC3339, ts); err == nil {
 				payload.MergedAt = t
 			}
+		} else if strings.HasPrefix(line, "Traceparent:") {
+			payload.TraceParent = strings.TrimSpace(strings.TrimPrefix(line, "Traceparent:"))
 		}
 	}
 
@@ -231,6 +241,7 @@ func ParseMerged(subject, body string) (*MergedPayload, error) {
 //	Issue: <issue-id>
 //	FailureType: <type>
 //	Error: <error-message>
+//	Traceparent: <w3c-traceparent>
 func ParseMergeFailed(subject, body string) (*MergeFailedPayload, error) {
 	matches := PatternMergeFailed.FindStringSubmatch(subject)
 	if len(matches) < 2 {
@@ -242,10 +253,20 @@ func ParseMergeFailed(subject, body string) (*MergeFailedPayload, error) {
 		FailedAt:    time.Now(),
 	}
 
-	// Parse body for structured fields
-	for _, line := range strings.Split(body, "\n") {
-		line = strings.TrimSpace(line)
+	// Parse body for structured fields. "Checks:" starts an indented
+	// section that runs until the next unindented line.
+	var checks []string
+	inChecks := false
+	for _, raw := range strings.Split(body, "\n") {
+		if inChecks && strings.HasPrefix(raw, " ") {
+			checks = append(checks, strings.TrimPrefix(raw, "  "))
+			continue
+		}
+		inChecks = false
+		line := strings.TrimSpace(raw)
 		switch {
+		case line == "Checks:":
+			inChecks = true
 		case strings.HasPrefix(line, "Branch:"):
 			payload.Branch = strings.TrimSpace(strings.TrimPrefix(line, "Branch:"))
 		case strings.HasPrefix(line, "Issue:"):
@@ -254,8 +275,11 @@ func ParseMergeFailed(subject, body string) (*MergeFailedPayload, error) {
 			payload.FailureType = strings.TrimSpace(strings.TrimPrefix(line, "FailureType:"))
 		case strings.HasPrefix(line, "Error:"):
 			payload.Error = strings.TrimSpace(strings.TrimPrefix(line, "Error:"))
+		case strings.HasPrefix(line, "Traceparent:"):
+			payload.TraceParent = strings.TrimSpace(strings.TrimPrefix(line, "Traceparent:"))
 		}
 	}
+	payload.Checks = strings.Join(checks, "\n")
 
 	return payload, nil
 }
@@ -64,6 +64,22 @@ Branch: feature-branch`
 	}
 }
 
+func TestParsePolecatDone_TraceParent(t *testing.T) {
+	subject := "POLECAT_DONE nux"
+	body := `Exit: MERGED
+Issue: gt-abc123
+Traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`
+
+	payload, err := ParsePolecatDone(subject, body)
+	if err != nil {
+		t.Fatalf("ParsePolecatDone() error = %v", err)
+	}
+
+	if payload.TraceParent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("TraceParent = %q", payload.TraceParent)
+	}
+}
+
 func TestParsePolecatDone_MinimalBody(t *testing.T) {
 	subject := "POLECAT_DONE ace"
 	body := "Exit: DEFERRED"
@@ -211,6 +227,33 @@ func TestParseMergeFailed_MinimalBody(t *testing.T) {
 	}
 }
 
+func TestParseMergeFailed_Checks(t *testing.T) {
+	subject := "MERGE_FAILED nux"
+	body := `Branch: feature-nux
+Error: checks failed: unit (exit status 1)
+Checks:
+  - build: passed (2s)
+  - unit: failed (exit status 1)
+    | --- FAIL: TestWidget
+Traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01`
+
+	payload, err := ParseMergeFailed(subject, body)
+	if err != nil {
+		t.Fatalf("ParseMergeFailed() error = %v", err)
+	}
+
+	wantChecks := "- build: passed (2s)\n- unit: failed (exit status 1)\n  | --- FAIL: TestWidget"
+	if payload.Checks != wantChecks {
+		t.Errorf("Checks = %q, want %q", payload.Checks, wantChecks)
+	}
+	if payload.Error != "checks failed: unit (exit status 1)" {
+		t.Errorf("Error = %q", payload.Error)
+	}
+	if payload.TraceParent == "" {
+		t.Error("TraceParent should be parsed after the checks section")
+	}
+}
+
 func TestParseMergeFailed_InvalidSubject(t *testing.T) {
 	_, err := ParseMergeFailed("Not a merge failed", "body")
 	if err == nil {